@@ -0,0 +1,536 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/config"
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/grpcapi"
+	"github.com/AnnaCarter465/assessment-tax/handler"
+	"github.com/AnnaCarter465/assessment-tax/history"
+	"github.com/AnnaCarter465/assessment-tax/pkg/cache"
+	"github.com/AnnaCarter465/assessment-tax/pkg/httpclient"
+	"github.com/AnnaCarter465/assessment-tax/pkg/metrics"
+	"github.com/AnnaCarter465/assessment-tax/pkg/pubsub"
+	"github.com/AnnaCarter465/assessment-tax/pkg/queue"
+	"github.com/AnnaCarter465/assessment-tax/pkg/uploadscan"
+	"github.com/AnnaCarter465/assessment-tax/retention"
+	"github.com/AnnaCarter465/assessment-tax/schedule"
+	"github.com/AnnaCarter465/assessment-tax/webhook"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc"
+)
+
+// App is the service assembled from its dependencies, servers and background jobs are started by Run
+type App struct {
+	cfg         config.Config
+	loadConfig  func() (config.Config, error)
+	logger      *slog.Logger
+	store       database.Store
+	cache       cache.Cache
+	queue       queue.Queue
+	pubsub      pubsub.PubSub
+	metrics     metrics.Metrics
+	now         func() time.Time
+	auditLog    bool
+	gzip        bool
+	middlewares []echo.MiddlewareFunc
+	ipExtractor echo.IPExtractor
+
+	db          *database.Cached
+	notifier    *webhook.Dispatcher
+	maintenance *handler.Maintenance
+	drain       *handler.Drain
+	recorder    *history.Recorder
+	taxService  *grpcapi.Server
+	batchJobs   *handler.BatchJobHandler
+
+	live        *config.Live // limits, timeouts and feature flags which can be reloaded
+	reloadMu    sync.Mutex
+	reloadables []*reloadable
+	rateLimiter *rateLimiter
+
+	e  *echo.Echo
+	ae *echo.Echo // same as e without a separate admin listener
+
+	id string // tells broadcasts of this replica from the ones of others
+}
+
+// Option replaces a dependency or setting of App, dependencies which aren't replaced are created from config
+type Option func(a *App)
+
+// WithConfig sets settings of the service, config.Default is used without it
+func WithConfig(cfg config.Config) Option {
+	return func(a *App) {
+		a.cfg = cfg
+		a.auditLog = cfg.AuditLog
+	}
+}
+
+// WithConfigLoader sets how settings are read again by Reload, config.Load is used without it
+func WithConfigLoader(load func() (config.Config, error)) Option {
+	return func(a *App) {
+		a.loadConfig = load
+	}
+}
+
+// WithStore sets store instead of the database of config, e.g. database.NewMemory in tests
+func WithStore(store database.Store) Option {
+	return func(a *App) {
+		a.store = store
+	}
+}
+
+func WithCache(c cache.Cache) Option {
+	return func(a *App) {
+		a.cache = c
+	}
+}
+
+// WithQueue sets queue of batch jobs instead of the one of config
+func WithQueue(q queue.Queue) Option {
+	return func(a *App) {
+		a.queue = q
+	}
+}
+
+// WithPubSub sets how settings writes and config reloads are broadcast to other replicas instead of the one of config
+func WithPubSub(ps pubsub.PubSub) Option {
+	return func(a *App) {
+		a.pubsub = ps
+	}
+}
+
+func WithMetrics(mt metrics.Metrics) Option {
+	return func(a *App) {
+		a.metrics = mt
+	}
+}
+
+func WithLogger(logger *slog.Logger) Option {
+	return func(a *App) {
+		a.logger = logger
+	}
+}
+
+// WithClock sets clock of calculations and scheduled changes
+func WithClock(now func() time.Time) Option {
+	return func(a *App) {
+		a.now = now
+	}
+}
+
+// WithAuditLog turns audit log on or off whatever config says, it must come after WithConfig
+func WithAuditLog(enabled bool) Option {
+	return func(a *App) {
+		a.auditLog = enabled
+	}
+}
+
+// WithGzip turns compression of responses on or off, it's on by default
+func WithGzip(enabled bool) Option {
+	return func(a *App) {
+		a.gzip = enabled
+	}
+}
+
+// WithMiddleware adds middlewares after the built-in ones of both listeners
+func WithMiddleware(middlewares ...echo.MiddlewareFunc) Option {
+	return func(a *App) {
+		a.middlewares = append(a.middlewares, middlewares...)
+	}
+}
+
+// New assembles the service, nothing listens until Run
+func New(opts ...Option) (*App, error) {
+	a := &App{
+		cfg:        config.Default(),
+		loadConfig: config.Load,
+		logger:     slog.Default(),
+		now:        time.Now,
+		gzip:       true,
+		id:         uuid.NewString(),
+	}
+
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	a.live = config.NewLive(a.cfg, a.loadConfig)
+
+	var err error
+
+	if a.cache == nil {
+		if a.cache, err = cache.New(a.cfg.Cache.Backend, a.cfg.Cache.RedisURL); err != nil {
+			return nil, err
+		}
+	}
+
+	if a.queue == nil {
+		if a.queue, err = queue.New(a.cfg.Batch.Queue, a.cfg.Batch.QueueURL, batchQueueName); err != nil {
+			return nil, err
+		}
+	}
+
+	if a.metrics == nil {
+		if a.metrics, err = metrics.New(a.cfg.Metrics.Backend, a.cfg.Metrics.Prefix, a.cfg.Metrics.StatsDAddr); err != nil {
+			return nil, err
+		}
+	}
+
+	if a.store == nil {
+		if a.store, err = newDatabase(a.cfg, a.metrics); err != nil {
+			return nil, err
+		}
+	}
+
+	if a.pubsub == nil && a.cfg.Cache.PubSub == "redis" {
+		if a.pubsub, err = pubsub.NewRedis(a.cfg.Cache.RedisURL); err != nil {
+			return nil, err
+		}
+	}
+
+	a.db = database.NewCached(a.store, a.cache, a.cfg.Cache.SettingsTTL).SetBroadcast(func(ctx context.Context) {
+		a.broadcast(ctx, broadcastSettings)
+	})
+
+	client, err := httpclient.New(a.cfg.Outbound)
+	if err != nil {
+		return nil, err
+	}
+
+	a.notifier = webhook.NewDispatcher(a.db, client)
+
+	// read-only mode during database migrations, it can be turned off by admin
+	a.maintenance = handler.NewMaintenance(a.cfg.MaintenanceMode).SetOnChange(a.broadcastMaintenance)
+
+	// csv jobs in flight are finished before shutting down, new ones are rejected meanwhile
+	a.drain = handler.NewDrain()
+
+	// calculations are buffered and written in batches by the recorder running in background
+	if a.cfg.History.Enabled {
+		a.recorder = history.NewRecorder(a.db, a.cfg.History.BufferSize, a.cfg.History.BatchSize)
+	}
+
+	if a.ipExtractor, err = newIPExtractor(a.cfg.TrustedProxies); err != nil {
+		return nil, err
+	}
+
+	scanner, err := uploadscan.New(a.cfg.Upload.Scanner, a.cfg.Upload.ScannerAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.routes(scanner); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Handler serves public routes, and admin routes too without a separate admin listener
+func (a *App) Handler() http.Handler {
+	return a.e
+}
+
+// AdminHandler serves admin routes
+func (a *App) AdminHandler() http.Handler {
+	return a.ae
+}
+
+// Reload reads settings again and applies limits, timeouts and feature flags of the new ones to later requests,
+// e.g. on SIGHUP. Invalid settings are rejected as a whole and the running ones are kept. Other replicas reload
+// too when reloads are broadcast
+func (a *App) Reload() error {
+	if err := a.reload(); err != nil {
+		return err
+	}
+
+	a.broadcast(context.Background(), broadcastConfig)
+
+	return nil
+}
+
+func (a *App) reload() error {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	cfg, err := a.live.Reload()
+	if err != nil {
+		return err
+	}
+
+	for _, r := range a.reloadables {
+		r.store(r.build(cfg))
+	}
+
+	slog.Info("configuration reloaded")
+
+	return nil
+}
+
+// reloadable is middleware built from settings again on every reload
+type reloadable struct {
+	build   func(cfg config.Config) echo.MiddlewareFunc
+	current atomic.Pointer[echo.MiddlewareFunc]
+}
+
+func (r *reloadable) store(mw echo.MiddlewareFunc) {
+	r.current.Store(&mw)
+}
+
+// reloadable returns middleware delegating to the one built from the current settings
+func (a *App) reloadable(build func(cfg config.Config) echo.MiddlewareFunc) echo.MiddlewareFunc {
+	r := &reloadable{build: build}
+	r.store(build(a.live.Get()))
+
+	a.reloadables = append(a.reloadables, r)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return (*r.current.Load())(next)(c)
+		}
+	}
+}
+
+// Run serves until ctx is done, then stops background jobs and drains requests within ShutdownTimeout
+func (a *App) Run(ctx context.Context) error {
+	var grpcServer *grpc.Server
+
+	if a.cfg.GRPCPort != "" {
+		s, err := a.newGRPCServer()
+		if err != nil {
+			return err
+		}
+
+		grpcServer = s
+	}
+
+	serverErrs := make(chan error, 3)
+
+	go func() {
+		slog.Info("starting server", "port", a.cfg.Port, "tls", a.cfg.TLS.Enabled())
+
+		// echo's own servers are used, so they are stopped by e.Shutdown
+		s := a.e.Server
+		if a.cfg.TLS.Enabled() {
+			tlsConf, err := newTLSConfig(a.cfg.TLS)
+			if err != nil {
+				serverErrs <- err
+				return
+			}
+
+			s = a.e.TLSServer
+			s.TLSConfig = tlsConf
+		}
+
+		s.Addr = ":" + a.cfg.Port
+
+		if err := a.e.StartServer(s); err != nil && err != http.ErrServerClosed {
+			serverErrs <- err
+		}
+	}()
+
+	if a.ae != a.e {
+		go func() {
+			slog.Info("starting admin server", "port", a.cfg.Admin.Port)
+
+			tlsConf, err := newAdminTLSConfig(a.cfg.Admin)
+			if err != nil {
+				serverErrs <- err
+				return
+			}
+
+			// stopped by ae.Shutdown, like the public server
+			s := a.ae.TLSServer
+			s.Addr = ":" + a.cfg.Admin.Port
+			s.TLSConfig = tlsConf
+
+			if err := a.ae.StartServer(s); err != nil && err != http.ErrServerClosed {
+				serverErrs <- err
+			}
+		}()
+	}
+
+	if grpcServer != nil {
+		go func() {
+			slog.Info("starting grpc server", "port", a.cfg.GRPCPort)
+
+			if err := a.serveGRPC(grpcServer); err != nil {
+				serverErrs <- err
+			}
+		}()
+	}
+
+	// context of background jobs, they stop before shutting down servers
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+
+	go schedule.NewApplier(a.db, a.notifier).SetClock(a.now).SetPaused(a.maintenance.Enabled).
+		Run(backgroundCtx, a.cfg.Jobs.ScheduleInterval)
+
+	go retention.NewCleaner(a.db, a.cfg.Jobs.RetentionPeriod).SetMetrics(a.metrics).SetPaused(a.maintenance.Enabled).
+		Run(backgroundCtx, a.cfg.Jobs.RetentionInterval)
+
+	// settings written by other replicas are dropped from the cache right away instead of after its ttl
+	if pg, ok := a.store.(*database.DB); ok {
+		go pg.ListenSettingsChanged(backgroundCtx, func() { a.db.Invalidate(backgroundCtx) })
+	}
+
+	go a.subscribeBroadcasts(backgroundCtx)
+
+	// a local queue is only seen by this process, so its jobs are calculated here instead of by workers.
+	// Jobs it lost when the process stopped are queued again
+	var localWorkers sync.WaitGroup
+
+	if _, ok := a.queue.(*queue.Local); ok {
+		for range a.cfg.Batch.Workers {
+			localWorkers.Add(1)
+
+			go func() {
+				defer localWorkers.Done()
+				a.consumeBatchJobs(backgroundCtx)
+			}()
+		}
+
+		go func() {
+			if err := a.batchJobs.RequeueBatchJobs(backgroundCtx); err != nil && backgroundCtx.Err() == nil {
+				slog.Error("cannot requeue batch jobs", "error", err)
+			}
+		}()
+	}
+
+	// history is stopped after servers, so calculations of in-flight requests are still written
+	historyCtx, stopHistory := context.WithCancel(context.Background())
+	historyDone := make(chan struct{})
+
+	if a.recorder != nil {
+		go func() {
+			defer close(historyDone)
+			a.recorder.Run(historyCtx, a.cfg.History.FlushInterval)
+		}()
+	} else {
+		close(historyDone)
+	}
+
+	var serveErr error
+
+	select {
+	case <-ctx.Done():
+	case serveErr = <-serverErrs:
+		slog.Error("cannot start server", "error", serveErr)
+	}
+
+	slog.Info("shutting down the server", "timeout", a.cfg.ShutdownTimeout)
+
+	a.drain.Start()
+	stopBackground()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := a.drain.Wait(shutdownCtx); err != nil {
+		slog.Warn("csv jobs didn't finish before shutdown timeout", "error", err)
+	}
+
+	// jobs being calculated are finished, interrupted ones are queued again on the next start
+	if err := wait(shutdownCtx, &localWorkers); err != nil {
+		slog.Warn("batch jobs didn't finish before shutdown timeout", "error", err)
+	}
+
+	err := a.e.Shutdown(shutdownCtx)
+
+	if a.ae != a.e {
+		err = errors.Join(err, a.ae.Shutdown(shutdownCtx))
+	}
+
+	if grpcServer != nil {
+		stopGRPC(shutdownCtx, grpcServer)
+	}
+
+	stopHistory()
+	<-historyDone
+
+	return errors.Join(serveErr, err)
+}
+
+// wait returns when wg is done, or error of ctx when it's done first
+func wait(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// name of the redis list, kafka topic or rabbitmq queue of batch jobs
+const batchQueueName = "assessment-tax:batch-jobs"
+
+// RunWorker calculates batch jobs of the queue until ctx is done, jobs taken by then are finished first. It's run
+// in processes apart from the API, which share the queue and the database with it
+func (a *App) RunWorker(ctx context.Context) error {
+	if pg, ok := a.store.(*database.DB); ok {
+		go pg.ListenSettingsChanged(ctx, func() { a.db.Invalidate(ctx) })
+	}
+
+	go a.subscribeBroadcasts(ctx)
+
+	historyCtx, stopHistory := context.WithCancel(context.Background())
+	historyDone := make(chan struct{})
+
+	if a.recorder != nil {
+		go func() {
+			defer close(historyDone)
+			a.recorder.Run(historyCtx, a.cfg.History.FlushInterval)
+		}()
+	} else {
+		close(historyDone)
+	}
+
+	slog.Info("starting batch workers", "queue", a.cfg.Batch.Queue, "workers", a.cfg.Batch.Workers)
+
+	var wg sync.WaitGroup
+
+	errs := make([]error, a.cfg.Batch.Workers)
+
+	for i := range a.cfg.Batch.Workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			errs[i] = a.consumeBatchJobs(ctx)
+		}()
+	}
+
+	wg.Wait()
+
+	// calculations of the last jobs are written before exiting
+	stopHistory()
+	<-historyDone
+
+	return errors.Join(errs...)
+}
+
+func (a *App) consumeBatchJobs(ctx context.Context) error {
+	err := a.queue.Consume(ctx, a.batchJobs.ProcessBatchJob)
+	if err != nil {
+		slog.Error("cannot consume batch jobs", "error", err)
+	}
+
+	return err
+}
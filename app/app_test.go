@@ -0,0 +1,554 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/config"
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/handler"
+	"github.com/AnnaCarter465/assessment-tax/openapi"
+	"github.com/AnnaCarter465/assessment-tax/pkg/buildinfo"
+	"github.com/AnnaCarter465/assessment-tax/pkg/cache"
+	"github.com/AnnaCarter465/assessment-tax/pkg/pubsub"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	var called bool
+
+	a, err := New(
+		WithStore(database.NewMemory()),
+		WithClock(func() time.Time { return time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC) }),
+		WithGzip(false),
+		WithMiddleware(func(next echo.HandlerFunc) echo.HandlerFunc {
+			return func(c echo.Context) error {
+				called = true
+				return next(c)
+			}
+		}),
+	)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/tax/calculations", strings.NewReader(`{"totalIncome":500000,"wht":0,"allowances":[{"allowanceType":"donation","amount":0}]}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderAcceptEncoding, "gzip")
+	rec := httptest.NewRecorder()
+
+	a.Handler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get(echo.HeaderContentEncoding))
+	assert.True(t, called)
+
+	var got handler.TaxResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, 29_000.0, got.Tax)
+
+	rec = httptest.NewRecorder()
+	a.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/deductions", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestVersionedRoutes(t *testing.T) {
+	now := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	a, err := New(WithStore(database.NewMemory()), WithClock(func() time.Time { return now }))
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/tax/calculations", strings.NewReader(`{"totalIncome":500000,"wht":0,"allowances":[]}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(echo.HeaderXRequestID, "req-1")
+	rec := httptest.NewRecorder()
+
+	a.Handler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var got handler.EnvelopeResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Nil(t, got.Error)
+	assert.Equal(t, handler.EnvelopeMeta{RequestID: "req-1", Timestamp: now, Version: buildinfo.Version}, got.Meta)
+
+	var resp handler.TaxResponse
+	assert.NoError(t, json.Unmarshal(got.Data, &resp))
+	assert.Equal(t, 29_000.0, resp.Tax)
+
+	// errors of admin routes are wrapped too
+	rec = httptest.NewRecorder()
+	a.AdminHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/admin/deductions", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	got = handler.EnvelopeResponse{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, "null", string(got.Data))
+	assert.NotNil(t, got.Error)
+	assert.NotEmpty(t, got.Meta.RequestID)
+
+	// routes without /v1 are responded as they were
+	rec = httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/version", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), `"meta"`)
+}
+
+func TestRateLimit(t *testing.T) {
+	cfg := config.Default()
+	cfg.RateLimit.Rate, cfg.RateLimit.Burst = 1, 1
+
+	a, err := New(WithConfig(cfg), WithStore(database.NewMemory()))
+	assert.NoError(t, err)
+
+	var codes []int
+
+	for range 2 {
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", strings.NewReader(`{"totalIncome":500000,"wht":0,"allowances":[]}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		a.Handler().ServeHTTP(rec, req)
+
+		codes = append(codes, rec.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests}, codes)
+}
+
+func TestGateway(t *testing.T) {
+	cfg := config.Default()
+	cfg.RateLimit.Rate, cfg.RateLimit.Burst = 1, 2
+
+	a, err := New(WithConfig(cfg), WithStore(database.NewMemory()))
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/tax/gateway/calculations", strings.NewReader(`{"request":{"total_income":500000}}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	a.Handler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"tax_level"`)
+
+	var codes []int
+
+	for _, target := range []string{"/tax/deductions", "/tax/gateway/deductions"} {
+		rec := httptest.NewRecorder()
+		a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+		codes = append(codes, rec.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusTooManyRequests}, codes, "the gateway shares the limit of the HTTP API")
+
+	cfg = config.Default()
+	cfg.API.KeyRequired = true
+
+	a, err = New(WithConfig(cfg), WithStore(database.NewMemory()))
+	assert.NoError(t, err)
+
+	rec = httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tax/gateway/deductions", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "the gateway is authenticated like /tax")
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.TrustedProxies = "not a cidr"
+
+	_, err := New(WithConfig(cfg), WithStore(database.NewMemory()))
+
+	assert.ErrorContains(t, err, "TRUSTED_PROXIES")
+}
+
+func TestRun(t *testing.T) {
+	cfg := config.Default()
+	cfg.Port = "0"
+	cfg.GRPCPort = "0"
+
+	a, err := New(WithConfig(cfg), WithStore(database.NewMemory()))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.NoError(t, a.Run(ctx))
+}
+
+func TestRunRequeuesBatchJobs(t *testing.T) {
+	cfg := config.Default()
+	cfg.Port = "0"
+
+	store := database.NewMemory()
+
+	// queued in the local queue of a process which stopped
+	j, err := store.CreateBatchJob(context.Background(), database.BatchJob{
+		TaxYear:       2024,
+		EffectiveDate: time.Now(),
+		Payload:       []byte("totalIncome,wht,donation\n500000,0,0\n"),
+	})
+	assert.NoError(t, err)
+
+	a, err := New(WithConfig(cfg), WithStore(store))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error)
+
+	go func() { runErr <- a.Run(ctx) }()
+
+	assert.Eventually(t, func() bool {
+		j, err = store.FindBatchJob(context.Background(), j.ID)
+		return err == nil && j.Status == database.BatchJobDone
+	}, 5*time.Second, 10*time.Millisecond, "jobs lost by the local queue are calculated after a restart")
+
+	cancel()
+	assert.NoError(t, <-runErr)
+}
+
+func TestBatchJobsReadOnly(t *testing.T) {
+	cfg := config.Default()
+	cfg.MaintenanceMode = true
+
+	a, err := New(WithConfig(cfg), WithStore(database.NewMemory()))
+	assert.NoError(t, err)
+
+	create := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/jobs", strings.NewReader("totalIncome,wht,donation\n500000,0,0\n"))
+		req.Header.Set("Content-Type", "text/csv")
+		rec := httptest.NewRecorder()
+
+		a.Handler().ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusServiceUnavailable, create(), "jobs aren't written during maintenance")
+
+	a.maintenance.Set(false)
+	assert.Equal(t, http.StatusAccepted, create())
+
+	a.drain.Start()
+	assert.Equal(t, http.StatusServiceUnavailable, create(), "jobs aren't accepted while shutting down")
+}
+
+func TestReload(t *testing.T) {
+	next := config.Default()
+	next.Upload.MaxSize = "1K"
+
+	var loadErr error
+
+	a, err := New(WithStore(database.NewMemory()), WithConfigLoader(func() (config.Config, error) {
+		return next, loadErr
+	}))
+	assert.NoError(t, err)
+
+	upload := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations/upload-csv", strings.NewReader(strings.Repeat("a", 2048)))
+		rec := httptest.NewRecorder()
+
+		a.Handler().ServeHTTP(rec, req)
+
+		return rec.Code
+	}
+
+	assert.NotEqual(t, http.StatusRequestEntityTooLarge, upload())
+
+	assert.NoError(t, a.Reload())
+	assert.Equal(t, http.StatusRequestEntityTooLarge, upload())
+
+	next.Upload.MaxSize = "1M"
+	loadErr = errors.New("invalid setting")
+
+	assert.Error(t, a.Reload())
+	assert.Equal(t, http.StatusRequestEntityTooLarge, upload(), "invalid settings keep the previous ones")
+}
+
+func TestReloadRateLimit(t *testing.T) {
+	next := config.Default()
+
+	a, err := New(WithStore(database.NewMemory()), WithConfigLoader(func() (config.Config, error) {
+		return next, nil
+	}))
+	assert.NoError(t, err)
+
+	deductions := func() []int {
+		var codes []int
+
+		for range 3 {
+			rec := httptest.NewRecorder()
+			a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tax/deductions", nil))
+
+			codes = append(codes, rec.Code)
+		}
+
+		return codes
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusOK}, deductions())
+
+	next.RateLimit = config.RateLimit{Backend: "local", Rate: 1, Burst: 2}
+
+	assert.NoError(t, a.Reload())
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, deductions(), "turned on by a reload")
+
+	next.Upload.MaxSize = "1M"
+
+	assert.NoError(t, a.Reload())
+	assert.Equal(t, http.StatusTooManyRequests, deductions()[0], "buckets are kept while the limit doesn't change")
+
+	next.RateLimit = config.RateLimit{}
+
+	assert.NoError(t, a.Reload())
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusOK}, deductions(), "turned off by a reload")
+}
+
+func TestBodyLimitAfterDecompress(t *testing.T) {
+	cfg := config.Default()
+	cfg.Upload.MaxSize = "64K"
+
+	a, err := New(WithConfig(cfg), WithStore(database.NewMemory()))
+	assert.NoError(t, err)
+
+	type TC struct {
+		target      string
+		contentType string
+		body        string
+		wantCode    int
+	}
+
+	tcs := []TC{
+		{
+			target:      "/tax/calculations/upload-csv",
+			contentType: "text/csv",
+			body:        "totalIncome,wht,donation\n" + strings.Repeat("500000,0,0\n", 1<<20),
+			wantCode:    http.StatusRequestEntityTooLarge,
+		},
+		{
+			// json bodies are cut at the limit, so they fail to bind
+			target:      "/tax/calculations",
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"totalIncome":500000,"wht":0,"allowances":[],"padding":"` + strings.Repeat("0", 8<<20) + `"}`,
+			wantCode:    http.StatusBadRequest,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var body bytes.Buffer
+
+			zw := gzip.NewWriter(&body)
+			_, err := zw.Write([]byte(tc.body))
+			assert.NoError(t, err)
+			assert.NoError(t, zw.Close())
+			assert.Less(t, body.Len(), 64<<10, "compressed body is under the limit")
+
+			req := httptest.NewRequest(http.MethodPost, tc.target, &body)
+			req.Header.Set(echo.HeaderContentType, tc.contentType)
+			req.Header.Set(echo.HeaderContentEncoding, "gzip")
+			rec := httptest.NewRecorder()
+
+			a.Handler().ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, ps := database.NewMemory(), pubsub.NewLocal()
+
+	next := config.Default()
+	next.Upload.MaxSize = "1K"
+
+	load := func() (config.Config, error) { return next, nil }
+
+	cfg := config.Default()
+	cfg.Admin.Username, cfg.Admin.Password = "adminTax", "admin!"
+
+	// replicas share the database and the pubsub, but cache settings on their own
+	a, err := New(WithConfig(cfg), WithStore(store), WithPubSub(ps), WithCache(cache.NewLocal()), WithConfigLoader(load))
+	assert.NoError(t, err)
+
+	b, err := New(WithStore(store), WithPubSub(ps), WithCache(cache.NewLocal()), WithConfigLoader(load))
+	assert.NoError(t, err)
+
+	go b.subscribeBroadcasts(ctx)
+
+	personal := func() float64 {
+		allowances, err := b.db.FindAllDefaultAllowances(ctx)
+		assert.NoError(t, err)
+
+		return allowances[0].Amount
+	}
+
+	assert.Equal(t, 60_000.0, personal())
+
+	// written again until b has subscribed, broadcasts before aren't received
+	assert.Eventually(t, func() bool {
+		_, err := a.db.UpdateAmountDefaultAllowances(ctx, "personal", 70_000)
+		return err == nil && personal() == 70_000
+	}, time.Second, 10*time.Millisecond, "cached settings of other replicas are dropped")
+
+	assert.NoError(t, a.Reload())
+	assert.Equal(t, "1K", b.live.Get().Upload.MaxSize, "other replicas reload too")
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/maintenance", strings.NewReader(`{"enabled":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.SetBasicAuth("adminTax", "admin!")
+	rec := httptest.NewRecorder()
+
+	a.AdminHandler().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Eventually(t, b.maintenance.Enabled, time.Second, 10*time.Millisecond, "other replicas turn maintenance mode on too")
+}
+
+func TestRoutesAreDocumented(t *testing.T) {
+	cfg := config.Default()
+	cfg.Admin.JWTSecret = []byte(strings.Repeat("s", 32))
+	cfg.Accounts.JWTSecret = []byte(strings.Repeat("u", 32))
+	cfg.Admin.TOTPEncryptionKey = make([]byte, 32)
+
+	a, err := New(WithConfig(cfg), WithStore(database.NewMemory()))
+	assert.NoError(t, err)
+
+	doc, err := openapi.Document()
+	assert.NoError(t, err)
+
+	for _, r := range a.e.Routes() {
+		// groups register not found routes of their prefix
+		if r.Method == echo.RouteNotFound || strings.HasPrefix(r.Path, "/swagger") {
+			continue
+		}
+
+		item := doc.Paths.Value(openapi.Path(r.Path))
+		if !assert.NotNil(t, item, r.Path) {
+			continue
+		}
+
+		assert.NotNil(t, item.GetOperation(r.Method), r.Method+" "+r.Path)
+	}
+
+	rec := httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, openapi.SpecPath, nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"/tax/calculations"`)
+
+	rec = httptest.NewRecorder()
+	a.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/swagger", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), openapi.SpecPath)
+}
+
+func TestDeletedAdminUserToken(t *testing.T) {
+	cfg := config.Default()
+	cfg.Admin.Username, cfg.Admin.Password = "adminTax", "admin!"
+	cfg.Admin.Auth = "both"
+	cfg.Admin.JWTSecret = []byte(strings.Repeat("s", 32))
+
+	a, err := New(WithConfig(cfg), WithStore(database.NewMemory()))
+	assert.NoError(t, err)
+
+	id := createAdminUser(t, a, "somsri", handler.RoleEditor)
+	token := adminToken(t, a, "somsri")
+
+	rec := adminRequest(a, http.MethodGet, "/admin/deductions", "", token)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = adminRequest(a, http.MethodDelete, "/admin/users/"+strconv.Itoa(id), "", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = adminRequest(a, http.MethodGet, "/admin/deductions", "", token)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "sessions of deleted users are revoked")
+}
+
+func TestDemotedAdminUserToken(t *testing.T) {
+	cfg := config.Default()
+	cfg.Admin.Username, cfg.Admin.Password = "adminTax", "admin!"
+	cfg.Admin.Auth = "both"
+	cfg.Admin.JWTSecret = []byte(strings.Repeat("s", 32))
+
+	a, err := New(WithConfig(cfg), WithStore(database.NewMemory()))
+	assert.NoError(t, err)
+
+	id := createAdminUser(t, a, "somsri", handler.RoleSuperadmin)
+	token := adminToken(t, a, "somsri")
+
+	rec := adminRequest(a, http.MethodPut, "/admin/users/"+strconv.Itoa(id)+"/role", `{"role":"viewer"}`, "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = adminRequest(a, http.MethodDelete, "/admin/deductions/k-receipt", "", token)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "tokens issued with the old role are revoked")
+
+	rec = adminRequest(a, http.MethodDelete, "/admin/deductions/k-receipt", "", adminToken(t, a, "somsri"))
+	assert.Equal(t, http.StatusForbidden, rec.Code, "new tokens carry the new role")
+}
+
+const adminUserPassword = "correct horse battery"
+
+// adminRequest sends request to admin routes with token, or as the admin of config when token is empty
+func adminRequest(a *App, method string, target string, body string, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	} else {
+		req.SetBasicAuth("adminTax", "admin!")
+	}
+
+	rec := httptest.NewRecorder()
+	a.AdminHandler().ServeHTTP(rec, req)
+
+	return rec
+}
+
+func createAdminUser(t *testing.T, a *App, username string, role string) int {
+	t.Helper()
+
+	rec := adminRequest(a, http.MethodPost, "/admin/users",
+		`{"username":"`+username+`","password":"`+adminUserPassword+`","role":"`+role+`"}`, "")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	var u handler.AdminUserResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &u))
+
+	return u.ID
+}
+
+func adminToken(t *testing.T, a *App, username string) string {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/login",
+		strings.NewReader(`{"username":"`+username+`","password":"`+adminUserPassword+`"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	a.AdminHandler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var resp handler.LoginResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+
+	return resp.AccessToken
+}
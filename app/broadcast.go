@@ -0,0 +1,98 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+)
+
+// channel of broadcasts shared by replicas
+const broadcastChannel = "assessment-tax:settings"
+
+// kinds of broadcasts
+const (
+	broadcastSettings    = "settings"    // settings in the database were written, cached ones are stale
+	broadcastConfig      = "config"      // configuration was reloaded
+	broadcastMaintenance = "maintenance" // maintenance mode was turned on or off by admin
+)
+
+type broadcastMessage struct {
+	Origin  string `json:"origin"`
+	Kind    string `json:"kind"`
+	Enabled *bool  `json:"enabled,omitempty"` // maintenance mode of broadcastMaintenance
+}
+
+// broadcast tells other replicas about a change of kind, it does nothing without a pubsub
+func (a *App) broadcast(ctx context.Context, kind string) {
+	a.publish(ctx, broadcastMessage{Kind: kind})
+}
+
+// broadcastMaintenance tells other replicas to turn maintenance mode on or off too,
+// replicas started later take MAINTENANCE_MODE
+func (a *App) broadcastMaintenance(ctx context.Context, enabled bool) {
+	a.publish(ctx, broadcastMessage{Kind: broadcastMaintenance, Enabled: &enabled})
+}
+
+func (a *App) publish(ctx context.Context, m broadcastMessage) {
+	if a.pubsub == nil {
+		return
+	}
+
+	m.Origin = a.id
+
+	msg, err := json.Marshal(m)
+	if err == nil {
+		err = a.pubsub.Publish(ctx, broadcastChannel, msg)
+	}
+
+	// other replicas catch up once their cache expires or they are reloaded
+	if err != nil {
+		slog.WarnContext(ctx, "failed to broadcast change", "kind", m.Kind, "error", err)
+	}
+}
+
+// subscribeBroadcasts applies changes broadcast by other replicas until ctx is done, it subscribes again after
+// errors and drops cached settings then since broadcasts may be missed meanwhile
+func (a *App) subscribeBroadcasts(ctx context.Context) {
+	if a.pubsub == nil {
+		return
+	}
+
+	for {
+		err := a.pubsub.Subscribe(ctx, broadcastChannel, a.applyBroadcast)
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.ErrorContext(ctx, "stopped receiving broadcasts of other replicas, subscribing again", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+
+		a.db.Invalidate(ctx)
+	}
+}
+
+func (a *App) applyBroadcast(msg []byte) {
+	var m broadcastMessage
+	if err := json.Unmarshal(msg, &m); err != nil || m.Origin == a.id {
+		return
+	}
+
+	switch m.Kind {
+	case broadcastSettings:
+		a.db.Invalidate(context.Background())
+	case broadcastConfig:
+		if err := a.reload(); err != nil {
+			slog.Error("cannot reload configuration broadcast by another replica", "error", err)
+		}
+	case broadcastMaintenance:
+		if m.Enabled != nil {
+			a.maintenance.Set(*m.Enabled)
+		}
+	}
+}
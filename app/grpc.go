@@ -0,0 +1,79 @@
+package app
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/grpcapi"
+	taxv1 "github.com/AnnaCarter465/assessment-tax/proto/tax/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// newGRPCServer serves TaxService on GRPC_PORT with TLS of the public listener, calls are authenticated,
+// verified, limited and metered by api keys like the HTTP API when API_KEY_REQUIRED is set
+func (a *App) newGRPCServer() (*grpc.Server, error) {
+	interceptors := []grpc.UnaryServerInterceptor{grpcapi.Recover(), a.grpcTimeout}
+	if a.cfg.API.KeyRequired {
+		interceptors = append(interceptors, grpcapi.APIKeyAuth(a.db),
+			grpcapi.VerifySignature(func() time.Duration { return a.live.Get().API.SignatureMaxAge }))
+	}
+
+	// calls share buckets with the HTTP API, so the limit holds across both
+	interceptors = append(interceptors, grpcapi.RateLimit(a.rateLimiter.current))
+
+	if a.cfg.API.KeyRequired {
+		interceptors = append(interceptors, grpcapi.UsageMeter(a.db))
+	}
+
+	opts := []grpc.ServerOption{grpc.ChainUnaryInterceptor(interceptors...)}
+
+	if a.cfg.TLS.Enabled() {
+		tlsConf, err := newTLSConfig(a.cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConf)))
+	}
+
+	s := grpc.NewServer(opts...)
+	taxv1.RegisterTaxServiceServer(s, a.taxService)
+
+	return s, nil
+}
+
+// grpcTimeout limits calls by CALCULATION_TIMEOUT, like calculations of the HTTP API
+func (a *App) grpcTimeout(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+	ctx, cancel := context.WithTimeout(ctx, a.live.Get().API.CalculationTimeout)
+	defer cancel()
+
+	return next(ctx, req)
+}
+
+// stopGRPC waits for calls in flight until ctx is done, then cancels the rest
+func stopGRPC(ctx context.Context, s *grpc.Server) {
+	stopped := make(chan struct{})
+
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.Stop()
+	}
+}
+
+// serveGRPC serves until s is stopped
+func (a *App) serveGRPC(s *grpc.Server) error {
+	lis, err := net.Listen("tcp", ":"+a.cfg.GRPCPort)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(lis)
+}
@@ -0,0 +1,80 @@
+package app
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/AnnaCarter465/assessment-tax/config"
+	"github.com/AnnaCarter465/assessment-tax/handler"
+	"github.com/AnnaCarter465/assessment-tax/pkg/ratelimit"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// rateLimiter keeps the store of the current rate limit settings, a reload which doesn't change them keeps
+// the buckets and a reload which does starts new ones
+type rateLimiter struct {
+	redisURL string
+
+	mu    sync.Mutex
+	conf  config.RateLimit
+	store middleware.RateLimiterStore // nil while the limit is off
+	built bool
+}
+
+func newRateLimiter(redisURL string, conf config.RateLimit) (*rateLimiter, error) {
+	r := &rateLimiter{redisURL: redisURL}
+
+	if err := r.update(conf); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// update replaces the store when conf differs from the settings it was created by
+func (r *rateLimiter) update(conf config.RateLimit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.built && conf == r.conf {
+		return nil
+	}
+
+	var store middleware.RateLimiterStore
+
+	if conf.Rate > 0 {
+		s, err := ratelimit.New(conf.Backend, r.redisURL, conf.Rate, conf.Burst)
+		if err != nil {
+			return err
+		}
+
+		store = s
+	}
+
+	r.conf, r.store, r.built = conf, store, true
+
+	return nil
+}
+
+// current returns store of the current settings, nil when the limit is off
+func (r *rateLimiter) current() middleware.RateLimiterStore {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.store
+}
+
+// middleware is built by a.reloadable, invalid settings keep the previous limit
+func (r *rateLimiter) middleware(cfg config.Config) echo.MiddlewareFunc {
+	if err := r.update(cfg.RateLimit); err != nil {
+		slog.Error("invalid rate limit settings, the previous ones are kept", "error", err)
+	}
+
+	store := r.current()
+	if store == nil {
+		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	}
+
+	return handler.RateLimit(store)
+}
@@ -0,0 +1,378 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/AnnaCarter465/assessment-tax/config"
+	"github.com/AnnaCarter465/assessment-tax/grpcapi"
+	"github.com/AnnaCarter465/assessment-tax/handler"
+	"github.com/AnnaCarter465/assessment-tax/openapi"
+	"github.com/AnnaCarter465/assessment-tax/pkg/logging"
+	"github.com/AnnaCarter465/assessment-tax/pkg/metrics"
+	"github.com/AnnaCarter465/assessment-tax/pkg/tracing"
+	"github.com/AnnaCarter465/assessment-tax/pkg/uploadscan"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// routes registers handlers of public and admin listeners
+func (a *App) routes(scanner uploadscan.Scanner) error {
+	vl := validator.New()
+
+	// must be set before groups are created, groups copy not found handler when middlewares are added
+	echo.NotFoundHandler = handler.NotFound
+	echo.MethodNotAllowedHandler = handler.MethodNotAllowed
+
+	a.e = a.newEcho()
+
+	if p, ok := a.metrics.(*metrics.Prometheus); ok {
+		a.e.GET("/metrics", echo.WrapHandler(p.Handler()))
+	}
+
+	a.e.GET("/", handler.Healthcheck)
+	a.e.GET("/version", handler.Version)
+
+	doc, err := openapi.Document()
+	if err != nil {
+		return fmt.Errorf("failed to build api document: %w", err)
+	}
+
+	docs, err := openapi.NewHandler(doc)
+	if err != nil {
+		return err
+	}
+
+	a.e.GET("/swagger", docs.UI)
+	a.e.GET(openapi.SpecPath, docs.Spec)
+
+	// json bodies are checked against the document after authentication, so anonymous clients only see 401
+	validateRequest := openapi.ValidateRequest(doc)
+
+	// user ------------------------------------------------------------------------------
+	u := a.e.Group("/tax")
+
+	// api keys authenticate and limit /tax, /vat and the gateway alike
+	var keyAuth []echo.MiddlewareFunc
+
+	if a.cfg.API.KeyRequired {
+		keyAuth = append(keyAuth, handler.APIKeyAuth(a.db),
+			a.reloadable(func(cfg config.Config) echo.MiddlewareFunc {
+				return handler.VerifySignature(cfg.API.SignatureMaxAge)
+			}))
+	}
+
+	// requests over the limit aren't metered, a redis backend makes the limit hold across replicas.
+	// The limit can be turned on, off or changed by a reload
+	limiter, err := newRateLimiter(a.cfg.Cache.RedisURL, a.cfg.RateLimit)
+	if err != nil {
+		return err
+	}
+
+	a.rateLimiter = limiter
+	keyAuth = append(keyAuth, a.reloadable(limiter.middleware))
+
+	if a.cfg.API.KeyRequired {
+		keyAuth = append(keyAuth, handler.UsageMeter(a.db))
+	}
+
+	u.Use(keyAuth...)
+
+	// taxpayers are optional, calculations of anonymous requests have no owner
+	accountConf := newAccountConfig(a.cfg.Accounts)
+	if len(accountConf.Secret) > 0 {
+		u.Use(handler.AccountAuth(accountConf))
+	}
+
+	u.Use(validateRequest)
+
+	u.GET("/deductions", handler.NewConfigHandler(a.db).SetTenants(a.db).SetVersions(a.db).GetDeductions, handler.RequireScope(handler.ScopeConfigRead))
+	u.GET("/brackets", handler.NewConfigHandler(a.db).SetBrackets(a.db).SetTenants(a.db).SetVersions(a.db).GetBrackets, handler.RequireScope(handler.ScopeConfigRead))
+	calculations := handler.NewTaxHandler(vl, a.db).SetClock(a.now).SetCalendar(a.db).SetEffectiveAllowances(a.db).SetBrackets(a.db).
+		SetTenants(a.db).SetProfiles(a.db).SetAliases(a.db).SetMaintenance(a.maintenance).SetFallback(a.degradedMode).
+		SetDuplicateAllowances(a.duplicateAllowances).SetPrecision(a.outputPrecision)
+	csvCalculations := handler.NewTaxHandler(vl, a.db).SetClock(a.now).SetScanner(scanner).SetEffectiveAllowances(a.db).SetBrackets(a.db).
+		SetTenants(a.db).SetMaintenance(a.maintenance).SetFallback(a.degradedMode).SetPrecision(a.outputPrecision)
+
+	if a.recorder != nil {
+		calculations.SetHistory(a.recorder)
+		csvCalculations.SetHistory(a.recorder)
+	}
+
+	// calculations by gRPC share settings, history and degraded mode with the HTTP API
+	a.taxService = grpcapi.NewServer(calculations, handler.NewConfigHandler(a.db).SetTenants(a.db)).SetClock(a.now)
+
+	// the gateway serves TaxService as JSON for clients which can't call gRPC
+	gateway, err := grpcapi.NewGateway(context.Background(), a.taxService)
+	if err != nil {
+		return err
+	}
+
+	gw := a.e.Group("/tax/gateway", keyAuth...)
+	gw.POST("/calculations", gateway,
+		handler.RequireScope(handler.ScopeCalculate),
+		a.reloadable(func(cfg config.Config) echo.MiddlewareFunc {
+			return middleware.ContextTimeout(cfg.API.CalculationTimeout)
+		}))
+	gw.GET("/deductions", gateway, handler.RequireScope(handler.ScopeConfigRead))
+
+	u.POST("/calculations", calculations.CalculateTax,
+		handler.RequireScope(handler.ScopeCalculate),
+		a.reloadable(func(cfg config.Config) echo.MiddlewareFunc {
+			return middleware.ContextTimeout(cfg.API.CalculationTimeout)
+		}))
+	u.POST("/calculations/upload-csv", csvCalculations.CalculateTaxWithCSV,
+		handler.RequireScope(handler.ScopeUploadCSV),
+		a.drain.Track(),
+		a.reloadable(func(cfg config.Config) echo.MiddlewareFunc {
+			return middleware.ContextTimeout(cfg.Upload.Timeout)
+		}))
+	// heavy uploads are calculated by workers, which may run apart from the API
+	a.batchJobs = handler.NewBatchJobHandler(a.db, csvCalculations, a.queue)
+
+	u.POST("/calculations/jobs", a.batchJobs.CreateBatchJob, handler.RequireScope(handler.ScopeUploadCSV),
+		a.drain.Track(), a.maintenance.ReadOnly())
+	u.GET("/calculations/jobs/:id", a.batchJobs.GetBatchJob, handler.RequireScope(handler.ScopeUploadCSV))
+	// projections and withholdings are estimates, they share settings with calculations but aren't recorded to history
+	estimates := handler.NewTaxHandler(vl, a.db).SetClock(a.now).SetCalendar(a.db).SetEffectiveAllowances(a.db).SetBrackets(a.db).
+		SetTenants(a.db).SetProfiles(a.db).SetAliases(a.db).SetMaintenance(a.maintenance).SetFallback(a.degradedMode).
+		SetDuplicateAllowances(a.duplicateAllowances).SetPrecision(a.outputPrecision)
+
+	u.POST("/projections", handler.NewProjectionHandler(vl, estimates).ProjectTax,
+		handler.RequireScope(handler.ScopeCalculate),
+		a.reloadable(func(cfg config.Config) echo.MiddlewareFunc {
+			return middleware.ContextTimeout(cfg.API.CalculationTimeout)
+		}))
+	u.POST("/withholdings", handler.NewWithholdingHandler(vl, estimates).CalculateWithholding,
+		handler.RequireScope(handler.ScopeCalculate),
+		a.reloadable(func(cfg config.Config) echo.MiddlewareFunc {
+			return middleware.ContextTimeout(cfg.API.CalculationTimeout)
+		}))
+	u.POST("/payment-withholdings", handler.NewPaymentWhtHandler(vl).CalculatePaymentWht, handler.RequireScope(handler.ScopeCalculate))
+	u.POST("/penalties", handler.NewPenaltyHandler(vl, a.db).CalculatePenalty, handler.RequireScope(handler.ScopeCalculate))
+	u.POST("/corporate/calculations", handler.NewCorporateTaxHandler(vl).SetPrecision(a.outputPrecision).CalculateCorporateTax,
+		handler.RequireScope(handler.ScopeCalculate))
+	// any key can erase its own data, whichever scopes it has
+	u.DELETE("/calculations/history", handler.NewHistoryHandler(a.db).DeleteHistory)
+
+	// vat --------------------------------------------------------------------------------
+	v := a.e.Group("/vat", keyAuth...)
+	v.Use(validateRequest)
+
+	v.POST("/calculations", handler.NewVATHandler(vl, a.vatRate).CalculateVAT, handler.RequireScope(handler.ScopeCalculate))
+
+	// accounts ---------------------------------------------------------------------------
+	if len(accountConf.Secret) > 0 {
+		u.GET("/calculations/history", handler.NewHistoryHandler(a.db).GetHistory, handler.RequireAccount())
+
+		drafts := handler.NewCalculationDraftHandler(vl, a.db, calculations)
+
+		u.GET("/drafts", drafts.GetCalculationDrafts, handler.RequireAccount())
+		u.POST("/drafts", drafts.CreateCalculationDraft, handler.RequireAccount())
+		u.PUT("/drafts/:id", drafts.UpdateCalculationDraft, handler.RequireAccount())
+		u.POST("/drafts/:id/finalize", drafts.FinalizeCalculationDraft,
+			handler.RequireAccount(),
+			handler.RequireScope(handler.ScopeCalculate),
+			a.reloadable(func(cfg config.Config) echo.MiddlewareFunc {
+				return middleware.ContextTimeout(cfg.API.CalculationTimeout)
+			}))
+
+		accounts := handler.NewAccountHandler(vl, accountConf, a.db)
+
+		ua := a.e.Group("/users", validateRequest)
+		ua.POST("", accounts.Register)
+		ua.POST("/login", accounts.Login)
+
+		me := ua.Group("/me", handler.AccountAuth(accountConf), handler.RequireAccount())
+		me.GET("", accounts.GetAccount)
+		me.GET("/profile", handler.NewProfileHandler(vl, a.db).GetProfile)
+		me.PUT("/profile", handler.NewProfileHandler(vl, a.db).PutProfile)
+		me.DELETE("/profile", handler.NewProfileHandler(vl, a.db).DeleteProfile)
+	}
+
+	// admin -----------------------------------------------------------------------------
+	// admin routes are served by a separate listener when ADMIN_PORT is set, so it can require client certificates
+	ae := a.e
+	if a.cfg.Admin.Port != "" {
+		ae = a.newEcho()
+		ae.GET("/", handler.Healthcheck)
+	}
+
+	authConf := newAuthConfig(a.cfg.Admin)
+
+	totpBox, err := newTOTPBox(a.cfg.Admin.TOTPEncryptionKey)
+	if err != nil {
+		return err
+	}
+
+	// checked before credentials, so credentials can't be guessed from outside the allowed networks
+	var adminAllowlist []echo.MiddlewareFunc
+
+	if a.cfg.Admin.AllowedCIDRs != "" {
+		nets, err := handler.ParseCIDRs(a.cfg.Admin.AllowedCIDRs)
+		if err != nil {
+			return fmt.Errorf("invalid setting ADMIN_ALLOWED_CIDRS: %w", err)
+		}
+
+		adminAllowlist = append(adminAllowlist, handler.AllowCIDRs(nets))
+	}
+
+	if len(authConf.Secret) > 0 {
+		ae.POST("/admin/login", handler.NewAuthHandler(vl, authConf).SetUsers(a.db).SetTOTP(totpBox).SetSessions(a.db).Login,
+			append(adminAllowlist, validateRequest)...)
+	}
+
+	am := ae.Group("/admin", adminAllowlist...)
+	am.Use(handler.AdminAuth(authConf, a.db), a.maintenance.ReadOnly("/admin/maintenance", "/admin/sessions/:id", "/admin/config/reload"), handler.ExpectedSettingsVersion(), validateRequest)
+
+	viewer := handler.RequireRole(handler.RoleViewer)
+	editor := handler.RequireRole(handler.RoleEditor)
+	superadmin := handler.RequireRole(handler.RoleSuperadmin)
+
+	am.GET("/maintenance", a.maintenance.GetMaintenance, viewer)
+	am.PUT("/maintenance", a.maintenance.SetMaintenance, superadmin)
+
+	am.POST("/config/reload", handler.NewReloadHandler(a).Reload, superadmin)
+
+	am.GET("/deductions", handler.NewAdminHandler(vl, a.db).GetDeductions, viewer)
+	am.POST("/deductions/personal", handler.NewAdminHandler(vl, a.db).SetNotifier(a.notifier).SetBrackets(a.db).UpdatePesonal, editor)
+	am.POST("/deductions/k-receipt", handler.NewAdminHandler(vl, a.db).SetNotifier(a.notifier).SetBrackets(a.db).UpdateKReceipt, editor)
+	am.POST("/deductions/donation", handler.NewAdminHandler(vl, a.db).SetNotifier(a.notifier).SetBrackets(a.db).UpdateDonation, editor)
+	am.DELETE("/deductions/:allowanceType", handler.NewAdminHandler(vl, a.db).DisableAllowanceType, editor)
+	am.GET("/deductions/effective", handler.NewAdminHandler(vl, a.db).GetEffectiveAllowances, viewer)
+	am.POST("/deductions/effective", handler.NewAdminHandler(vl, a.db).PublishEffectiveAllowance, editor)
+
+	drafts := handler.NewDraftHandler(vl, a.db).SetNotifier(a.notifier).SetBrackets(a.db).
+		SetRequireSecondAdmin(a.draftRequireSecondAdmin)
+
+	am.GET("/drafts", drafts.GetDrafts, viewer)
+	am.POST("/drafts", drafts.CreateDraft, editor)
+	am.POST("/drafts/:id/preview", drafts.PreviewDraft, viewer)
+	am.POST("/drafts/:id/publish", drafts.PublishDraft, editor)
+	am.DELETE("/drafts/:id", drafts.DiscardDraft, editor)
+
+	am.GET("/settings/versions", handler.NewSettingsHandler(a.db).GetVersions, viewer)
+	am.GET("/settings/history", handler.NewSettingsHandler(a.db).GetHistory, viewer)
+	am.POST("/settings/rollback/:version", handler.NewSettingsHandler(a.db).SetNotifier(a.notifier).Rollback, editor)
+	am.POST("/settings/import", handler.NewSettingsHandler(a.db).SetNotifier(a.notifier).Import, editor)
+
+	am.GET("/scheduled-changes", handler.NewScheduleHandler(vl, a.db).GetScheduledChanges, viewer)
+	am.POST("/scheduled-changes", handler.NewScheduleHandler(vl, a.db).CreateScheduledChange, editor)
+	am.DELETE("/scheduled-changes/:id", handler.NewScheduleHandler(vl, a.db).CancelScheduledChange, editor)
+
+	am.GET("/calendar", handler.NewCalendarHandler(vl, a.db).GetCalendars, viewer)
+	am.PUT("/calendar/:taxYear", handler.NewCalendarHandler(vl, a.db).UpsertCalendar, editor)
+	am.DELETE("/calendar/:taxYear", handler.NewCalendarHandler(vl, a.db).DeleteCalendar, editor)
+
+	am.GET("/allowance-aliases", handler.NewAllowanceAliasHandler(vl, a.db).GetAllowanceAliases, viewer)
+	am.PUT("/allowance-aliases/:alias", handler.NewAllowanceAliasHandler(vl, a.db).PutAllowanceAlias, editor)
+	am.DELETE("/allowance-aliases/:alias", handler.NewAllowanceAliasHandler(vl, a.db).DeleteAllowanceAlias, editor)
+	am.GET("/webhooks", handler.NewWebhookHandler(vl, a.db).GetWebhooks, viewer)
+	am.POST("/webhooks", handler.NewWebhookHandler(vl, a.db).CreateWebhook, editor)
+	am.DELETE("/webhooks/:id", handler.NewWebhookHandler(vl, a.db).DeleteWebhook, editor)
+
+	am.GET("/api-keys", handler.NewAPIKeyHandler(vl, a.db).GetAPIKeys, viewer)
+	am.POST("/api-keys", handler.NewAPIKeyHandler(vl, a.db).CreateAPIKey, superadmin)
+	am.PUT("/api-keys/:id/scopes", handler.NewAPIKeyHandler(vl, a.db).UpdateAPIKeyScopes, superadmin)
+	am.PUT("/api-keys/:id/quota", handler.NewAPIKeyHandler(vl, a.db).UpdateAPIKeyQuota, superadmin)
+	am.PUT("/api-keys/:id/signing-secret", handler.NewAPIKeyHandler(vl, a.db).EnableSigning, superadmin)
+	am.DELETE("/api-keys/:id/signing-secret", handler.NewAPIKeyHandler(vl, a.db).DisableSigning, superadmin)
+	am.DELETE("/api-keys/:id", handler.NewAPIKeyHandler(vl, a.db).RevokeAPIKey, superadmin)
+	am.PUT("/api-keys/:id/tenant", handler.NewTenantHandler(vl, a.db).AssignAPIKey, superadmin)
+
+	am.GET("/tenants", handler.NewTenantHandler(vl, a.db).GetTenants, viewer)
+	am.POST("/tenants", handler.NewTenantHandler(vl, a.db).CreateTenant, superadmin)
+	am.DELETE("/tenants/:id", handler.NewTenantHandler(vl, a.db).DeleteTenant, superadmin)
+	am.GET("/tenants/:id/settings", handler.NewTenantHandler(vl, a.db).GetTenantSettings, viewer)
+	am.PUT("/tenants/:id/settings", handler.NewTenantHandler(vl, a.db).ReplaceTenantSettings, editor)
+
+	am.GET("/usage", handler.NewUsageHandler(a.db).GetUsage, viewer)
+
+	if totpBox != nil {
+		am.POST("/totp", handler.NewTOTPHandler(vl, a.db, totpBox).Enroll, viewer)
+		am.GET("/totp/qr", handler.NewTOTPHandler(vl, a.db, totpBox).QRCode, viewer)
+		am.POST("/totp/verify", handler.NewTOTPHandler(vl, a.db, totpBox).Verify, viewer)
+	}
+
+	am.GET("/sessions", handler.NewSessionHandler(a.db).GetSessions, superadmin)
+	am.DELETE("/sessions/:id", handler.NewSessionHandler(a.db).RevokeSession, viewer)
+
+	am.GET("/users", handler.NewAdminUserHandler(vl, a.db).GetAdminUsers, superadmin)
+	am.POST("/users", handler.NewAdminUserHandler(vl, a.db).CreateAdminUser, superadmin)
+	am.PUT("/users/:id/role", handler.NewAdminUserHandler(vl, a.db).UpdateAdminUserRole, superadmin)
+	am.DELETE("/users/:id", handler.NewAdminUserHandler(vl, a.db).DeleteAdminUser, superadmin)
+
+	a.ae = ae
+
+	return nil
+}
+
+// degradedMode reports DEGRADED_MODE of the current settings
+func (a *App) degradedMode() bool {
+	return a.live.Get().API.DegradedMode
+}
+
+// duplicateAllowances reports DUPLICATE_ALLOWANCES of the current settings
+func (a *App) duplicateAllowances() string {
+	return a.live.Get().API.DuplicateAllowances
+}
+
+// outputPrecision reports OUTPUT_PRECISION of the current settings
+func (a *App) outputPrecision() string {
+	return a.live.Get().API.OutputPrecision
+}
+
+// vatRate reports VAT_RATE of the current settings
+func (a *App) vatRate() float64 {
+	return a.live.Get().VAT.Rate
+}
+
+// draftRequireSecondAdmin reports DRAFT_REQUIRE_SECOND_ADMIN of the current settings
+func (a *App) draftRequireSecondAdmin() bool {
+	return a.live.Get().Admin.DraftRequireSecondAdmin
+}
+
+// newEcho creates server with error handlers and middlewares shared by public and admin listeners
+func (a *App) newEcho() *echo.Echo {
+	e := echo.New()
+	e.HTTPErrorHandler = handler.HTTPErrorHandler
+	e.IPExtractor = a.ipExtractor
+
+	// startup messages are logged by slog, so json output stays parseable
+	e.HideBanner = true
+	e.HidePort = true
+
+	e.Use(tracing.Middleware())
+	e.Use(logging.Middleware(a.logger))
+	// inside logging middleware, so the panic is logged with request id and the request is logged as 500
+	e.Use(handler.Recover())
+	e.Use(metrics.Middleware(a.metrics))
+	e.Use(middleware.Decompress())
+	// limit is checked after decompression, so a small gzip body can't expand without bound on any route,
+	// csv uploads are the largest bodies accepted
+	e.Use(a.reloadable(func(cfg config.Config) echo.MiddlewareFunc {
+		return middleware.BodyLimit(cfg.Upload.MaxSize)
+	}))
+
+	if a.gzip {
+		e.Use(middleware.GzipWithConfig(middleware.GzipConfig{
+			MinLength: 1024,
+		}))
+	}
+
+	// after gzip, so audited bodies are uncompressed
+	if a.auditLog {
+		e.Use(logging.Audit(a.logger, logging.AuditConfig{
+			Bodies: a.cfg.AuditLogBodies,
+		}))
+	}
+
+	// after gzip and audit, so they get the wrapped body of /v1 routes
+	e.Pre(handler.VersionedPath())
+	e.Use(handler.Envelope(a.now))
+
+	e.Use(a.middlewares...)
+
+	return e
+}
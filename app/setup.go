@@ -0,0 +1,178 @@
+package app
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/AnnaCarter465/assessment-tax/config"
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/handler"
+	"github.com/AnnaCarter465/assessment-tax/pkg/envelope"
+	"github.com/AnnaCarter465/assessment-tax/pkg/metrics"
+	"github.com/AnnaCarter465/assessment-tax/pkg/secretbox"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// newTLSConfig loads certificate files, or obtains certificates of the domains from Let's Encrypt
+// by TLS-ALPN challenge, so the listener must be reachable on port 443. HTTP/2 is negotiated by ALPN
+func newTLSConfig(conf config.TLS) (*tls.Config, error) {
+	if conf.AutocertDomains != "" {
+		var domains []string
+		for _, d := range strings.Split(conf.AutocertDomains, ",") {
+			domains = append(domains, strings.TrimSpace(d))
+		}
+
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(domains...),
+			Cache:      autocert.DirCache(conf.AutocertCacheDir),
+		}
+
+		tlsConf := m.TLSConfig()
+		tlsConf.MinVersion = tls.VersionTLS12
+
+		return tlsConf, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load tls certificate: %w", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		NextProtos:   []string{"h2", "http/1.1"},
+	}, nil
+}
+
+// newAdminTLSConfig loads server certificate of admin listener,
+// clients must present certificates signed by ClientCA when it's set
+func newAdminTLSConfig(conf config.Admin) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(conf.TLSCert, conf.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load admin tls certificate: %w", err)
+	}
+
+	tlsConf := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if conf.ClientCA != "" {
+		pem, err := os.ReadFile(conf.ClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read admin client ca: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in admin client ca %s", conf.ClientCA)
+		}
+
+		tlsConf.ClientCAs = pool
+		tlsConf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConf, nil
+}
+
+// newDatabase connects to postgres, or creates memory store for local development without postgres
+func newDatabase(cfg config.Config, mt metrics.Metrics) (database.Store, error) {
+	if cfg.Database.Driver == "memory" {
+		slog.Warn("using in-memory database, data is lost on restart")
+		return database.NewMemory(), nil
+	}
+
+	historyEnvelope, err := newHistoryEnvelope(cfg.History)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := database.NewDB(cfg.Database.URL, cfg.Database.Config)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to database: %w", err)
+	}
+
+	return db.SetMetrics(mt).SetEnvelope(historyEnvelope), nil
+}
+
+func newAuthConfig(conf config.Admin) handler.AuthConfig {
+	return handler.AuthConfig{
+		Username:   conf.Username,
+		Password:   conf.Password,
+		TokenTTL:   conf.TokenTTL,
+		AllowBasic: conf.Auth != "jwt",
+		Secret:     conf.JWTSecret,
+	}
+}
+
+func newAccountConfig(conf config.Accounts) handler.AccountConfig {
+	return handler.AccountConfig{
+		Secret:   conf.JWTSecret,
+		TokenTTL: conf.TokenTTL,
+	}
+}
+
+// newIPExtractor trusts X-Forwarded-For only from trustedProxies, e.g. the load balancer subnet
+func newIPExtractor(trustedProxies string) (echo.IPExtractor, error) {
+	if trustedProxies == "" {
+		return echo.ExtractIPDirect(), nil
+	}
+
+	nets, err := handler.ParseCIDRs(trustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid setting TRUSTED_PROXIES: %w", err)
+	}
+
+	options := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+
+	for _, n := range nets {
+		options = append(options, echo.TrustIPRange(n))
+	}
+
+	return echo.ExtractIPFromXFFHeader(options...), nil
+}
+
+// newTOTPBox returns box encrypting totp secrets, two-factor authentication is unavailable without the key
+func newTOTPBox(key []byte) (*secretbox.Box, error) {
+	if key == nil {
+		return nil, nil
+	}
+
+	box, err := secretbox.New(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid setting TOTP_ENCRYPTION_KEY: %w", err)
+	}
+
+	return box, nil
+}
+
+// newHistoryEnvelope returns envelope sealing amounts of calculation history, amounts are stored in plain without the key
+func newHistoryEnvelope(conf config.History) (*envelope.Envelope, error) {
+	if conf.EncryptionKey == nil {
+		return nil, nil
+	}
+
+	box, err := secretbox.New(conf.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid setting CALCULATION_HISTORY_ENCRYPTION_KEY: %w", err)
+	}
+
+	// id is kept with every sealed row, it must change when the master key is rotated
+	e, err := envelope.New(conf.EncryptionKeyID, box)
+	if err != nil {
+		return nil, fmt.Errorf("invalid setting CALCULATION_HISTORY_ENCRYPTION_KEY_ID: %w", err)
+	}
+
+	return e, nil
+}
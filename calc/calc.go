@@ -0,0 +1,303 @@
+package calc
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/handler"
+	"github.com/AnnaCarter465/assessment-tax/seed"
+	"github.com/AnnaCarter465/assessment-tax/tax"
+)
+
+// SettingsReader finds allowances and brackets a calculation is made with
+type SettingsReader interface {
+	FindAllDefaultAllowances(ctx context.Context) ([]database.DefaultAllowance, error)
+	FindAllAllowedAllowances(ctx context.Context) ([]database.AllowedAllowance, error)
+	FindTaxBrackets(ctx context.Context, taxYear int) ([]database.TaxBracket, error)
+}
+
+// Run calculates tax of one taxpayer and prints response like POST /tax/calculations, or calculates every row
+// of -file like POST /tax/calculations/upload-csv and writes results as csv. It returns process exit code.
+// Settings are read from database of -database-url, settings import file of -settings, or the reference
+// data of seed without either
+func Run(args []string) int {
+	fs := flag.NewFlagSet("calc", flag.ContinueOnError)
+
+	dbURL := fs.String("database-url", "", "database to read settings from")
+	settingsFile := fs.String("settings", "", "settings in the format of POST /admin/settings/import, json or csv by extension")
+	file := fs.String("file", "", "csv with header totalIncome,wht,donation, calculates every row instead of -income")
+	out := fs.String("out", "", "file results of -file are written to, stdout without it")
+	income := fs.Float64("income", 0, "total income")
+	wht := fs.Float64("wht", 0, "withholding tax")
+	taxYear := fs.Int("tax-year", 2024, "tax year of brackets")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout of reading settings")
+
+	allowances := tax.Allowances{}
+	fs.Func("allowance", "allowance as type=amount, e.g. donation=100000, can be repeated", func(v string) error {
+		allowanceType, amount, ok := strings.Cut(v, "=")
+		if !ok {
+			return fmt.Errorf("expected type=amount")
+		}
+
+		n, err := strconv.ParseFloat(amount, 64)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid amount %q", amount)
+		}
+
+		allowances[allowanceType] += n
+
+		return nil
+	})
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *dbURL != "" && *settingsFile != "" {
+		fmt.Println("-database-url and -settings can't be used together")
+		return 2
+	}
+
+	if *file == "" && (*income < 0 || *wht < 0 || *income < *wht) {
+		fmt.Println("income and wht must not be negative, and wht must not exceed income")
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	settings, err := openSettings(ctx, *dbURL, *settingsFile, *timeout)
+	if err != nil {
+		fmt.Printf("cannot read settings: %v\n", err)
+		return 1
+	}
+
+	conf, err := LoadTaxConfig(ctx, settings, *taxYear)
+	if err != nil {
+		fmt.Printf("cannot load settings: %v\n", err)
+		return 1
+	}
+
+	if *file != "" {
+		if err := calculateFile(conf, *file, *out); err != nil {
+			fmt.Printf("cannot calculate %s: %v\n", *file, err)
+			return 1
+		}
+
+		return 0
+	}
+
+	tx := tax.NewTax(conf).SetIncome(*income).SetWht(*wht)
+
+	for allowanceType, amount := range allowances {
+		tx.AddAllowance(allowanceType, amount)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(response(tx.CalculateTaxSummary())); err != nil {
+		fmt.Printf("cannot write result: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// openSettings returns database of dbURL, memory store with settings of settingsFile imported,
+// or memory store with the reference data
+func openSettings(ctx context.Context, dbURL string, settingsFile string, timeout time.Duration) (SettingsReader, error) {
+	if dbURL != "" {
+		db, err := database.NewDB(dbURL, database.Config{ConnectTimeout: timeout})
+		if err != nil {
+			return nil, err
+		}
+
+		return db, nil
+	}
+
+	mem := database.NewMemory()
+
+	if settingsFile == "" {
+		return mem, nil
+	}
+
+	body, err := os.ReadFile(settingsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	imp, err := handler.NewSettingsHandler(mem).ParseImport(ctx, body, strings.EqualFold(filepath.Ext(settingsFile), ".csv"))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", settingsFile, err)
+	}
+
+	if _, err := mem.ImportSettings(ctx, imp); err != nil {
+		return nil, err
+	}
+
+	return mem, nil
+}
+
+// calculateFile writes results of every row of file to out, or stdout when out is empty.
+// Nothing is written when a row is invalid
+func calculateFile(conf tax.TaxConfig, file string, out string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	rows, err := handler.ParseTaxCSV(f)
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		return CalculateCSV(conf, rows, os.Stdout)
+	}
+
+	w, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+
+	if err := CalculateCSV(conf, rows, w); err != nil {
+		w.Close()
+		return err
+	}
+
+	return w.Close()
+}
+
+// CalculateCSV writes rows with their tax and refund as csv, donation must be allowed when a row claims it
+func CalculateCSV(conf tax.TaxConfig, rows []handler.TaxCSVRow, w io.Writer) error {
+	if _, ok := conf.AllowedAllowances["donation"]; !ok {
+		for i, r := range rows {
+			if r.Donation != 0 {
+				return fmt.Errorf("row %d: donation is disabled", i+1)
+			}
+		}
+	}
+
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write([]string{"totalIncome", "wht", "donation", "tax", "taxRefund"}); err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+		summary := tax.NewTax(conf).
+			SetIncome(r.TotalIncome).
+			SetWht(r.Wht).
+			AddAllowance("donation", r.Donation).
+			CalculateTaxSummary()
+
+		err := cw.Write([]string{
+			formatAmount(r.TotalIncome),
+			formatAmount(r.Wht),
+			formatAmount(r.Donation),
+			formatAmount(summary.Tax),
+			formatAmount(summary.Refund),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// LoadTaxConfig reads current allowances, disabled types are left out. Brackets of tax year fall back
+// to the reference data when none are imported
+func LoadTaxConfig(ctx context.Context, settings SettingsReader, taxYear int) (tax.TaxConfig, error) {
+	conf := tax.TaxConfig{
+		DefaultAllowances: tax.Allowances{},
+		AllowedAllowances: tax.Allowances{},
+	}
+
+	defaultAllowances, err := settings.FindAllDefaultAllowances(ctx)
+	if err != nil {
+		return tax.TaxConfig{}, err
+	}
+
+	for _, a := range defaultAllowances {
+		if a.DisabledAt == nil {
+			conf.DefaultAllowances[a.AllowanceType] = a.Amount
+		}
+	}
+
+	allowedAllowances, err := settings.FindAllAllowedAllowances(ctx)
+	if err != nil {
+		return tax.TaxConfig{}, err
+	}
+
+	for _, a := range allowedAllowances {
+		if a.DisabledAt == nil {
+			conf.AllowedAllowances[a.AllowanceType] = a.MaxAmount
+		}
+	}
+
+	brackets, err := settings.FindTaxBrackets(ctx, taxYear)
+	if err != nil {
+		return tax.TaxConfig{}, err
+	}
+
+	if len(brackets) == 0 {
+		brackets = seed.Settings.Brackets[taxYear]
+	}
+
+	if len(brackets) == 0 {
+		return tax.TaxConfig{}, fmt.Errorf("no brackets of tax year %d", taxYear)
+	}
+
+	for _, b := range brackets {
+		rate := tax.Rate{
+			Percentage: b.Percentage,
+			Max:        -1,
+		}
+
+		if b.MaxAmount != nil {
+			rate.Max = *b.MaxAmount
+		}
+
+		conf.Rates = append(conf.Rates, rate)
+	}
+
+	conf.Rates = tax.LabelRates(conf.Rates)
+
+	return conf, nil
+}
+
+func response(summary tax.TaxSummary) handler.TaxResponse {
+	resp := handler.TaxResponse{
+		Tax:       summary.Tax,
+		TaxRefund: summary.Refund,
+		TaxLevel:  []handler.TaxLevel{},
+	}
+
+	for _, s := range summary.TaxStatements {
+		resp.TaxLevel = append(resp.TaxLevel, handler.TaxLevel{
+			Level: s.Rate.Label,
+			Tax:   s.Tax,
+		})
+	}
+
+	return resp
+}
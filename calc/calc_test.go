@@ -0,0 +1,70 @@
+package calc
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/handler"
+	"github.com/AnnaCarter465/assessment-tax/tax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadTaxConfig(t *testing.T) {
+	ctx := context.Background()
+	db := database.NewMemory()
+
+	assert.NoError(t, db.DisableAllowanceType(ctx, "k-receipt"))
+
+	conf, err := LoadTaxConfig(ctx, db, 2024)
+
+	assert.NoError(t, err)
+	assert.Equal(t, tax.Allowances{"personal": 60_000}, conf.DefaultAllowances)
+	assert.Equal(t, tax.Allowances{"donation": 100_000}, conf.AllowedAllowances)
+	assert.Len(t, conf.Rates, 5, "brackets fall back to reference data")
+
+	summary := tax.NewTax(conf).SetIncome(500_000).AddAllowance("donation", 200_000).CalculateTaxSummary()
+	assert.Equal(t, 19_000.0, summary.Tax)
+
+	_, err = LoadTaxConfig(ctx, db, 1999)
+	assert.Error(t, err)
+}
+
+func TestCalculateCSV(t *testing.T) {
+	rows, err := handler.ParseTaxCSV(strings.NewReader("totalIncome,wht,donation\n500000,0,0\n600000,40000,20000\n"))
+	assert.NoError(t, err)
+
+	conf, err := LoadTaxConfig(context.Background(), database.NewMemory(), 2024)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	assert.NoError(t, CalculateCSV(conf, rows, &buf))
+	assert.Equal(t, "totalIncome,wht,donation,tax,taxRefund\n500000,0,0,29000,0\n600000,40000,20000,10000,0\n", buf.String())
+
+	delete(conf.AllowedAllowances, "donation")
+	assert.ErrorContains(t, CalculateCSV(conf, rows, &bytes.Buffer{}), "row 2: donation is disabled")
+}
+
+func TestOpenSettingsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{
+		"defaultAllowances": [{"allowanceType": "personal", "amount": 50000}],
+		"allowedAllowances": [{"allowanceType": "donation", "maxAmount": 80000}]
+	}`), 0o600))
+
+	ctx := context.Background()
+
+	settings, err := openSettings(ctx, "", path, time.Second)
+	assert.NoError(t, err)
+
+	conf, err := LoadTaxConfig(ctx, settings, 2024)
+	assert.NoError(t, err)
+	assert.Equal(t, tax.Allowances{"personal": 50_000}, conf.DefaultAllowances)
+	assert.Equal(t, 80_000.0, conf.AllowedAllowances["donation"])
+}
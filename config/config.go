@@ -0,0 +1,529 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/httpclient"
+	"github.com/labstack/gommon/bytes"
+)
+
+// Config is every setting of the service, settings are named by their environment variables
+type Config struct {
+	Port            string
+	GRPCPort        string // empty disables the gRPC listener
+	LogFormat       string
+	LogLevel        string
+	ServiceName     string
+	TrustedProxies  string // comma separated CIDRs allowed to set X-Forwarded-For
+	MaintenanceMode bool
+	AuditLog        bool
+	AuditLogBodies  bool
+	ShutdownTimeout time.Duration // drain time of requests and csv jobs in flight
+
+	TLS       TLS
+	Database  Database
+	Cache     Cache
+	Metrics   Metrics
+	Upload    Upload
+	API       API
+	VAT       VAT
+	Batch     Batch
+	RateLimit RateLimit
+	History   History
+	Admin     Admin
+	Accounts  Accounts
+	Jobs      Jobs
+	Outbound  httpclient.Config
+}
+
+// TLS lets the public listener terminate HTTPS with HTTP/2, by certificate files or certificates
+// issued by Let's Encrypt for AutocertDomains. It's off when neither is set
+type TLS struct {
+	CertFile         string
+	KeyFile          string
+	AutocertDomains  string // comma separated
+	AutocertCacheDir string
+}
+
+// Enabled reports whether the public listener serves HTTPS
+func (t TLS) Enabled() bool {
+	return t.CertFile != "" || t.AutocertDomains != ""
+}
+
+type Database struct {
+	Driver string // postgres or memory
+	URL    string
+	database.Config
+}
+
+type Cache struct {
+	Backend     string
+	RedisURL    string
+	SettingsTTL time.Duration
+	PubSub      string // none or redis, how settings writes and config reloads are broadcast to other replicas
+}
+
+type Metrics struct {
+	Backend    string
+	Prefix     string
+	StatsDAddr string
+}
+
+type Upload struct {
+	Scanner     string
+	ScannerAddr string
+	Timeout     time.Duration
+	MaxSize     string
+}
+
+type API struct {
+	KeyRequired         bool
+	SignatureMaxAge     time.Duration
+	CalculationTimeout  time.Duration
+	DegradedMode        bool   // calculate with compiled-in settings when database can't be read
+	DuplicateAllowances string // sum, reject or last, how an allowance type claimed twice by a calculation is counted
+	OutputPrecision     string // raw, baht, decimal or satang, how monetary amounts of calculations are rounded
+}
+
+type VAT struct {
+	Rate float64 // fraction of net price, e.g. 0.07
+}
+
+// Batch is the queue of csv jobs, workers consume a shared queue in separate processes while a local queue is
+// consumed by the API process itself
+type Batch struct {
+	Queue    string // local, redis, kafka, sqs or rabbitmq
+	QueueURL string // redis or amqp url, kafka brokers separated by commas or the sqs queue url
+	Workers  int    // jobs calculated at once by a process
+}
+
+// RateLimit limits requests of an api key per second, by a token bucket per replica or one shared in redis at
+// REDIS_URL. It's off when Rate is 0
+type RateLimit struct {
+	Backend string // local or redis
+	Rate    int
+	Burst   int // requests allowed at once, Rate by default
+}
+
+type History struct {
+	Enabled         bool
+	BufferSize      int
+	BatchSize       int
+	FlushInterval   time.Duration
+	EncryptionKey   []byte // nil stores amounts in plain
+	EncryptionKeyID string
+}
+
+type Admin struct {
+	Port                    string // empty serves admin routes by the public listener
+	AllowedCIDRs            string
+	TLSCert                 string
+	TLSKey                  string
+	ClientCA                string
+	Auth                    string // basic, jwt or both
+	Username                string
+	Password                string
+	JWTSecret               []byte
+	TokenTTL                time.Duration
+	TOTPEncryptionKey       []byte // nil disables two-factor authentication
+	DraftRequireSecondAdmin bool
+}
+
+// Accounts are taxpayer accounts, their tokens are signed by a secret other than JWT_SECRET of admins,
+// so a taxpayer token can never be accepted by admin routes
+type Accounts struct {
+	JWTSecret []byte // empty disables registration and login of taxpayers
+	TokenTTL  time.Duration
+}
+
+type Jobs struct {
+	ScheduleInterval  time.Duration
+	RetentionPeriod   time.Duration
+	RetentionInterval time.Duration
+}
+
+// Load reads settings from the environment, then from .env file in CONFIG_ENV_FILE (default .env),
+// YAML file in CONFIG_FILE and the Vault secret in VAULT_SECRET_PATH, a setting is taken from the first of them having it.
+// A setting NAME can be read from the file in NAME_FILE instead, e.g. a Docker or Kubernetes secret.
+// The error lists every missing or invalid setting, not only the first one.
+func Load() (Config, error) {
+	l := &loader{sources: []source{os.LookupEnv}}
+
+	envFile, explicit := os.LookupEnv("CONFIG_ENV_FILE")
+	if !explicit {
+		envFile = ".env"
+	}
+
+	dotenv, err := readDotEnv(envFile)
+	if errors.Is(err, os.ErrNotExist) && !explicit {
+		dotenv, err = map[string]string{}, nil
+	}
+
+	if err != nil {
+		return Config{}, fmt.Errorf("cannot read env file: %w", err)
+	}
+
+	l.sources = append(l.sources, mapSource(dotenv))
+
+	if file := l.string("CONFIG_FILE", ""); file != "" {
+		values, err := readYAML(file)
+		if err != nil {
+			return Config{}, fmt.Errorf("cannot read config file: %w", err)
+		}
+
+		l.sources = append(l.sources, mapSource(values))
+	}
+
+	// secrets like DATABASE_URL and ADMIN_PASSWORD can be kept in Vault instead of the environment
+	if addr := l.string("VAULT_ADDR", ""); addr != "" {
+		token, path := l.required("VAULT_TOKEN"), l.required("VAULT_SECRET_PATH")
+
+		// vault is reached through the same proxy and trust settings as every other outbound integration
+		outbound := l.outbound()
+		if len(l.errs) > 0 {
+			return Config{}, errors.Join(l.errs...)
+		}
+
+		client, err := httpclient.New(outbound)
+		if err != nil {
+			return Config{}, fmt.Errorf("cannot create vault client: %w", err)
+		}
+
+		values, err := readVault(client, addr, token, path)
+		if err != nil {
+			return Config{}, fmt.Errorf("cannot read vault secret: %w", err)
+		}
+
+		l.sources = append(l.sources, mapSource(values))
+	}
+
+	cfg := l.load()
+
+	return cfg, errors.Join(l.errs...)
+}
+
+// Default returns settings having their default values, e.g. for embedding the service in tests.
+// Its database driver is memory since there's no database url
+func Default() Config {
+	cfg := (&loader{}).load()
+	cfg.Database.Driver = "memory"
+
+	return cfg
+}
+
+func (l *loader) load() Config {
+	cfg := Config{
+		Port:            l.string("PORT", "8080"),
+		GRPCPort:        l.string("GRPC_PORT", ""),
+		LogFormat:       l.string("LOG_FORMAT", ""),
+		LogLevel:        l.string("LOG_LEVEL", ""),
+		ServiceName:     l.string("OTEL_SERVICE_NAME", "assessment-tax"),
+		TrustedProxies:  l.string("TRUSTED_PROXIES", ""),
+		MaintenanceMode: l.bool("MAINTENANCE_MODE"),
+		AuditLog:        l.bool("AUDIT_LOG"),
+		AuditLogBodies:  l.bool("AUDIT_LOG_BODIES"),
+		ShutdownTimeout: l.duration("SHUTDOWN_TIMEOUT", 10*time.Second),
+		TLS: TLS{
+			CertFile:         l.string("TLS_CERT_FILE", ""),
+			KeyFile:          l.string("TLS_KEY_FILE", ""),
+			AutocertDomains:  l.string("TLS_AUTOCERT_DOMAINS", ""),
+			AutocertCacheDir: l.string("TLS_AUTOCERT_CACHE_DIR", "autocert"),
+		},
+		Database: Database{
+			Driver: l.oneOf("DATABASE_DRIVER", "postgres", "memory"),
+			Config: database.Config{
+				ConnectTimeout:     l.duration("DB_CONNECT_TIMEOUT", 30*time.Second),
+				BreakerThreshold:   l.int("DB_BREAKER_THRESHOLD", 5),
+				BreakerCooldown:    l.duration("DB_BREAKER_COOLDOWN", 10*time.Second),
+				SlowQueryThreshold: l.duration("DB_SLOW_QUERY_THRESHOLD", 500*time.Millisecond),
+				ReplicaURL:         l.string("DATABASE_REPLICA_URL", ""),
+			},
+		},
+		Cache: Cache{
+			Backend:     l.string("CACHE_BACKEND", ""),
+			RedisURL:    l.string("REDIS_URL", ""),
+			SettingsTTL: l.duration("SETTINGS_CACHE_TTL", 10*time.Second),
+			PubSub:      l.oneOf("SETTINGS_PUBSUB", "none", "redis"),
+		},
+		Metrics: Metrics{
+			Backend:    l.string("METRICS_BACKEND", ""),
+			Prefix:     l.string("METRICS_PREFIX", ""),
+			StatsDAddr: l.string("STATSD_ADDR", ""),
+		},
+		Upload: Upload{
+			Scanner:     l.string("UPLOAD_SCANNER", ""),
+			ScannerAddr: l.string("UPLOAD_SCANNER_ADDR", ""),
+			Timeout:     l.duration("CSV_UPLOAD_TIMEOUT", 30*time.Second),
+			MaxSize:     l.size("CSV_UPLOAD_MAX_SIZE", "10M"),
+		},
+		API: API{
+			KeyRequired:         l.bool("API_KEY_REQUIRED"),
+			SignatureMaxAge:     l.duration("SIGNATURE_MAX_AGE", 5*time.Minute),
+			CalculationTimeout:  l.duration("CALCULATION_TIMEOUT", 5*time.Second),
+			DegradedMode:        l.bool("DEGRADED_MODE"),
+			DuplicateAllowances: l.oneOf("DUPLICATE_ALLOWANCES", "sum", "reject", "last"),
+			OutputPrecision:     l.oneOf("OUTPUT_PRECISION", "raw", "baht", "decimal", "satang"),
+		},
+		VAT: VAT{
+			Rate: l.fraction("VAT_RATE", 0.07),
+		},
+		Batch: Batch{
+			Queue:    l.oneOf("BATCH_QUEUE", "local", "redis", "kafka", "sqs", "rabbitmq"),
+			QueueURL: l.string("BATCH_QUEUE_URL", ""),
+			Workers:  l.int("BATCH_WORKERS", 4),
+		},
+		RateLimit: RateLimit{
+			Backend: l.oneOf("RATE_LIMIT_BACKEND", "local", "redis"),
+			Rate:    l.int("RATE_LIMIT", 0),
+			Burst:   l.int("RATE_LIMIT_BURST", 0),
+		},
+		History: History{
+			Enabled:         l.bool("CALCULATION_HISTORY"),
+			BufferSize:      l.int("CALCULATION_HISTORY_BUFFER", 10_000),
+			BatchSize:       l.int("CALCULATION_HISTORY_BATCH_SIZE", 500),
+			FlushInterval:   l.duration("CALCULATION_HISTORY_FLUSH_INTERVAL", time.Second),
+			EncryptionKey:   l.key("CALCULATION_HISTORY_ENCRYPTION_KEY"),
+			EncryptionKeyID: l.string("CALCULATION_HISTORY_ENCRYPTION_KEY_ID", "local-1"),
+		},
+		Admin: Admin{
+			Port:                    l.string("ADMIN_PORT", ""),
+			AllowedCIDRs:            l.string("ADMIN_ALLOWED_CIDRS", ""),
+			ClientCA:                l.string("ADMIN_CLIENT_CA", ""),
+			Auth:                    l.oneOf("ADMIN_AUTH", "basic", "jwt", "both"),
+			Username:                l.string("ADMIN_USERNAME", ""),
+			Password:                l.string("ADMIN_PASSWORD", ""),
+			TokenTTL:                l.duration("ADMIN_TOKEN_TTL", 15*time.Minute),
+			TOTPEncryptionKey:       l.key("TOTP_ENCRYPTION_KEY"),
+			DraftRequireSecondAdmin: l.bool("DRAFT_REQUIRE_SECOND_ADMIN"),
+		},
+		Accounts: Accounts{
+			JWTSecret: []byte(l.string("USER_JWT_SECRET", "")),
+			TokenTTL:  l.duration("USER_TOKEN_TTL", time.Hour),
+		},
+		Jobs: Jobs{
+			ScheduleInterval:  l.duration("SCHEDULE_INTERVAL", time.Minute),
+			RetentionPeriod:   l.duration("RETENTION_PERIOD", 90*24*time.Hour),
+			RetentionInterval: l.duration("RETENTION_INTERVAL", time.Hour),
+		},
+		Outbound: l.outbound(),
+	}
+
+	if cfg.Database.Driver == "postgres" {
+		cfg.Database.URL = l.required("DATABASE_URL")
+	}
+
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		l.errs = append(l.errs, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together"))
+	}
+
+	if cfg.TLS.CertFile != "" && cfg.TLS.AutocertDomains != "" {
+		l.errs = append(l.errs, errors.New("TLS_CERT_FILE and TLS_AUTOCERT_DOMAINS can't be set together"))
+	}
+
+	if cfg.Admin.Port != "" {
+		cfg.Admin.TLSCert = l.required("ADMIN_TLS_CERT")
+		cfg.Admin.TLSKey = l.required("ADMIN_TLS_KEY")
+	}
+
+	if cfg.Admin.Auth != "basic" {
+		cfg.Admin.JWTSecret = []byte(l.required("JWT_SECRET"))
+
+		if len(cfg.Admin.JWTSecret) > 0 && len(cfg.Admin.JWTSecret) < 32 {
+			l.errs = append(l.errs, errors.New("JWT_SECRET must have at least 32 bytes"))
+		}
+	}
+
+	if len(cfg.Accounts.JWTSecret) > 0 && len(cfg.Accounts.JWTSecret) < 32 {
+		l.errs = append(l.errs, errors.New("USER_JWT_SECRET must have at least 32 bytes"))
+	}
+
+	if cfg.RateLimit.Burst == 0 {
+		cfg.RateLimit.Burst = cfg.RateLimit.Rate
+	}
+
+	return cfg
+}
+
+// source looks a setting up by name, it's os.LookupEnv or a parsed file
+type source func(name string) (string, bool)
+
+func mapSource(values map[string]string) source {
+	return func(name string) (string, bool) {
+		v, ok := values[name]
+		return v, ok
+	}
+}
+
+// loader reads settings from the first source having them and collects errors of every setting
+type loader struct {
+	sources []source
+	errs    []error
+}
+
+// outbound reads settings of the http client of outbound integrations
+func (l *loader) outbound() httpclient.Config {
+	return httpclient.Config{
+		ProxyURL: l.string("OUTBOUND_PROXY_URL", ""),
+		CAFile:   l.string("OUTBOUND_CA_FILE", ""),
+		Timeout:  l.duration("OUTBOUND_TIMEOUT", 0),
+		Retries:  l.int("OUTBOUND_RETRIES", 2),
+	}
+}
+
+// lookup returns value of setting from the first source having it, or content of the file in NAME_FILE
+func (l *loader) lookup(name string) string {
+	v, file := l.first(name), l.first(name+"_FILE")
+	if file == "" {
+		return v
+	}
+
+	if v != "" {
+		l.errs = append(l.errs, fmt.Errorf("%s and %s_FILE can't be set together", name, name))
+		return v
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("cannot read %s_FILE: %w", name, err))
+		return ""
+	}
+
+	return strings.TrimSpace(string(b))
+}
+
+func (l *loader) first(name string) string {
+	for _, s := range l.sources {
+		if v, ok := s(name); ok && strings.TrimSpace(v) != "" {
+			return strings.TrimSpace(v)
+		}
+	}
+
+	return ""
+}
+
+func (l *loader) string(name string, fallback string) string {
+	if v := l.lookup(name); v != "" {
+		return v
+	}
+
+	return fallback
+}
+
+func (l *loader) required(name string) string {
+	v := l.lookup(name)
+	if v == "" {
+		l.errs = append(l.errs, fmt.Errorf("missing required setting %s", name))
+	}
+
+	return v
+}
+
+// oneOf returns value of setting which must be one of allowed, the first one is the default
+func (l *loader) oneOf(name string, allowed ...string) string {
+	v := l.string(name, allowed[0])
+
+	for _, a := range allowed {
+		if v == a {
+			return v
+		}
+	}
+
+	l.errs = append(l.errs, fmt.Errorf("invalid setting %s %q, must be one of %s", name, v, strings.Join(allowed, ", ")))
+
+	return allowed[0]
+}
+
+func (l *loader) bool(name string) bool {
+	v := l.lookup(name)
+	if v == "" {
+		return false
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("invalid setting %s %q, must be true or false", name, v))
+	}
+
+	return b
+}
+
+func (l *loader) duration(name string, fallback time.Duration) time.Duration {
+	v := l.lookup(name)
+	if v == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		l.errs = append(l.errs, fmt.Errorf("invalid setting %s %q, must be a positive duration like 30s", name, v))
+		return fallback
+	}
+
+	return d
+}
+
+func (l *loader) int(name string, fallback int) int {
+	v := l.lookup(name)
+	if v == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n <= 0 {
+		l.errs = append(l.errs, fmt.Errorf("invalid setting %s %q, must be a positive number", name, v))
+		return fallback
+	}
+
+	return n
+}
+
+// fraction returns rate like 0.07, 0 is allowed so a rate can be zero-rated
+func (l *loader) fraction(name string, fallback float64) float64 {
+	v := l.lookup(name)
+	if v == "" {
+		return fallback
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil || f < 0 || f >= 1 {
+		l.errs = append(l.errs, fmt.Errorf("invalid setting %s %q, must be a fraction like 0.07", name, v))
+		return fallback
+	}
+
+	return f
+}
+
+// size returns size like 10M, which is checked up front since BodyLimit panics on an invalid one
+func (l *loader) size(name string, fallback string) string {
+	v := l.string(name, fallback)
+
+	if _, err := bytes.Parse(v); err != nil {
+		l.errs = append(l.errs, fmt.Errorf("invalid setting %s %q, must be a size like 10M", name, v))
+		return fallback
+	}
+
+	return v
+}
+
+// key returns base64 encoded AES-256 key, nil when it isn't set
+func (l *loader) key(name string) []byte {
+	v := l.lookup(name)
+	if v == "" {
+		return nil
+	}
+
+	key, err := base64.StdEncoding.DecodeString(v)
+	if err != nil || len(key) != 32 {
+		l.errs = append(l.errs, fmt.Errorf("invalid setting %s, must be 32 bytes in base64", name))
+		return nil
+	}
+
+	return key
+}
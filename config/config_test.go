@@ -0,0 +1,210 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	t.Setenv("DATABASE_DRIVER", "memory")
+
+	cfg, err := Load()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "8080", cfg.Port)
+	assert.Equal(t, "basic", cfg.Admin.Auth)
+	assert.Equal(t, "sum", cfg.API.DuplicateAllowances)
+	assert.Equal(t, "raw", cfg.API.OutputPrecision)
+	assert.Equal(t, 0.07, cfg.VAT.Rate)
+	assert.Equal(t, "local", cfg.Batch.Queue)
+	assert.Equal(t, 4, cfg.Batch.Workers)
+	assert.Equal(t, 0, cfg.RateLimit.Rate)
+	assert.Equal(t, 10*time.Second, cfg.Cache.SettingsTTL)
+	assert.Equal(t, 500, cfg.History.BatchSize)
+	assert.Nil(t, cfg.History.EncryptionKey)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yamlFile := writeFile(t, "config.yaml", `
+PORT: 9000
+DATABASE_URL: host=yaml
+SCHEDULE_INTERVAL: 5m
+`)
+	envFile := writeFile(t, ".env", `
+# comment
+export DATABASE_URL="host=dotenv"
+API_KEY_REQUIRED=true
+`)
+
+	t.Setenv("CONFIG_FILE", yamlFile)
+	t.Setenv("CONFIG_ENV_FILE", envFile)
+	t.Setenv("SCHEDULE_INTERVAL", "30s")
+
+	cfg, err := Load()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "9000", cfg.Port)
+	assert.Equal(t, "host=dotenv", cfg.Database.URL)
+	assert.True(t, cfg.API.KeyRequired)
+	assert.Equal(t, 30*time.Second, cfg.Jobs.ScheduleInterval)
+}
+
+func TestLoadRateLimit(t *testing.T) {
+	t.Setenv("DATABASE_DRIVER", "memory")
+	t.Setenv("RATE_LIMIT", "20")
+	t.Setenv("RATE_LIMIT_BACKEND", "redis")
+
+	cfg, err := Load()
+
+	assert.NoError(t, err)
+	assert.Equal(t, RateLimit{Backend: "redis", Rate: 20, Burst: 20}, cfg.RateLimit)
+}
+
+func TestLoadErrors(t *testing.T) {
+	t.Setenv("DATABASE_URL", "")
+	t.Setenv("ADMIN_AUTH", "jwt")
+	t.Setenv("JWT_SECRET", "short")
+	t.Setenv("ADMIN_PORT", "8443")
+	t.Setenv("CSV_UPLOAD_TIMEOUT", "soon")
+	t.Setenv("CSV_UPLOAD_MAX_SIZE", "ten megabytes")
+	t.Setenv("TOTP_ENCRYPTION_KEY", "not base64")
+	t.Setenv("USER_JWT_SECRET", "short")
+	t.Setenv("VAT_RATE", "7")
+
+	_, err := Load()
+
+	assert.Error(t, err)
+	for _, msg := range []string{
+		"missing required setting DATABASE_URL",
+		"JWT_SECRET must have at least 32 bytes",
+		"missing required setting ADMIN_TLS_CERT",
+		"missing required setting ADMIN_TLS_KEY",
+		`invalid setting CSV_UPLOAD_TIMEOUT "soon"`,
+		`invalid setting CSV_UPLOAD_MAX_SIZE "ten megabytes"`,
+		"invalid setting TOTP_ENCRYPTION_KEY",
+		"USER_JWT_SECRET must have at least 32 bytes",
+		`invalid setting VAT_RATE "7"`,
+	} {
+		assert.ErrorContains(t, err, msg)
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	t.Setenv("DATABASE_URL_FILE", writeFile(t, "database-url", "host=secret\n"))
+	t.Setenv("ADMIN_PASSWORD_FILE", writeFile(t, "admin-password", "admin!"))
+
+	cfg, err := Load()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "host=secret", cfg.Database.URL)
+	assert.Equal(t, "admin!", cfg.Admin.Password)
+
+	t.Setenv("ADMIN_PASSWORD", "admin!")
+	t.Setenv("JWT_SECRET_FILE", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("ADMIN_AUTH", "jwt")
+
+	_, err = Load()
+
+	assert.ErrorContains(t, err, "ADMIN_PASSWORD and ADMIN_PASSWORD_FILE can't be set together")
+	assert.ErrorContains(t, err, "cannot read JWT_SECRET_FILE")
+}
+
+func TestLoadVault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/assessment-tax" || r.Header.Get("X-Vault-Token") != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"data":{"data":{"DATABASE_URL":"host=vault","ADMIN_PASSWORD":"admin!"},"metadata":{"version":3}}}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("VAULT_ADDR", srv.URL)
+	t.Setenv("VAULT_TOKEN_FILE", writeFile(t, "vault-token", "s.token"))
+	t.Setenv("VAULT_SECRET_PATH", "secret/data/assessment-tax")
+	t.Setenv("ADMIN_USERNAME", "admin")
+
+	cfg, err := Load()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "host=vault", cfg.Database.URL)
+	assert.Equal(t, "admin", cfg.Admin.Username)
+	assert.Equal(t, "admin!", cfg.Admin.Password)
+
+	t.Setenv("VAULT_SECRET_PATH", "secret/data/other")
+
+	_, err = Load()
+
+	assert.ErrorContains(t, err, "cannot read vault secret")
+}
+
+func TestLoadVaultOutboundProxy(t *testing.T) {
+	var proxied string
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = r.URL.String()
+		_, _ = w.Write([]byte(`{"data":{"DATABASE_URL":"host=vault"}}`))
+	}))
+	defer proxy.Close()
+
+	t.Setenv("VAULT_ADDR", "http://vault.internal:8200")
+	t.Setenv("VAULT_TOKEN", "s.token")
+	t.Setenv("VAULT_SECRET_PATH", "secret/assessment-tax")
+	t.Setenv("OUTBOUND_PROXY_URL", proxy.URL)
+
+	cfg, err := Load()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "host=vault", cfg.Database.URL)
+	assert.Equal(t, "http://vault.internal:8200/v1/secret/assessment-tax", proxied, "vault is read through the outbound proxy")
+}
+
+func TestLoadMissingEnvFile(t *testing.T) {
+	t.Setenv("CONFIG_ENV_FILE", filepath.Join(t.TempDir(), "missing.env"))
+
+	_, err := Load()
+
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestLoadNestedYAML(t *testing.T) {
+	t.Setenv("DATABASE_DRIVER", "memory")
+	t.Setenv("CONFIG_FILE", writeFile(t, "config.yaml", "ADMIN:\n  PORT: 8443\n"))
+
+	_, err := Load()
+
+	assert.ErrorContains(t, err, "ADMIN must be a single value")
+}
+
+func TestLoadTLS(t *testing.T) {
+	t.Setenv("DATABASE_DRIVER", "memory")
+	t.Setenv("TLS_CERT_FILE", "server.pem")
+	t.Setenv("TLS_AUTOCERT_DOMAINS", "tax.example.com")
+
+	_, err := Load()
+
+	assert.ErrorContains(t, err, "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	assert.ErrorContains(t, err, "TLS_CERT_FILE and TLS_AUTOCERT_DOMAINS can't be set together")
+
+	t.Setenv("TLS_CERT_FILE", "")
+
+	cfg, err := Load()
+
+	assert.NoError(t, err)
+	assert.True(t, cfg.TLS.Enabled())
+	assert.Equal(t, "autocert", cfg.TLS.AutocertCacheDir)
+}
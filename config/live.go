@@ -0,0 +1,36 @@
+package config
+
+import "sync/atomic"
+
+// Live holds settings which can be reloaded while the service runs, readers get the latest snapshot
+// and a reload replaces it as a whole, so nobody sees half of old and half of new settings.
+// Settings read once at startup, e.g. ports and database, don't change by a reload
+type Live struct {
+	current atomic.Pointer[Config]
+	load    func() (Config, error)
+}
+
+// NewLive holds cfg until the first reload, which reads settings by load, e.g. Load
+func NewLive(cfg Config, load func() (Config, error)) *Live {
+	l := &Live{load: load}
+	l.current.Store(&cfg)
+
+	return l
+}
+
+// Get returns the current snapshot
+func (l *Live) Get() Config {
+	return *l.current.Load()
+}
+
+// Reload reads settings again and replaces the snapshot, the previous one is kept when they're invalid
+func (l *Live) Reload() (Config, error) {
+	cfg, err := l.load()
+	if err != nil {
+		return l.Get(), err
+	}
+
+	l.current.Store(&cfg)
+
+	return cfg, nil
+}
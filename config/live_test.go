@@ -0,0 +1,35 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiveReload(t *testing.T) {
+	cfg := Default()
+	next := cfg
+	next.API.CalculationTimeout = time.Minute
+
+	var loadErr error
+	live := NewLive(cfg, func() (Config, error) {
+		return next, loadErr
+	})
+
+	assert.Equal(t, cfg, live.Get())
+
+	got, err := live.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, got.API.CalculationTimeout)
+	assert.Equal(t, time.Minute, live.Get().API.CalculationTimeout)
+
+	next.API.CalculationTimeout = time.Hour
+	loadErr = errors.New("invalid setting")
+
+	got, err = live.Reload()
+	assert.Error(t, err)
+	assert.Equal(t, time.Minute, got.API.CalculationTimeout, "invalid settings keep the previous snapshot")
+	assert.Equal(t, time.Minute, live.Get().API.CalculationTimeout)
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// vaultResponse is response of reading a secret, data of kv version 2 is wrapped in another data with metadata
+type vaultResponse struct {
+	Data map[string]any `json:"data"`
+}
+
+// readVault reads settings kept in a secret of HashiCorp Vault, e.g. path secret/data/assessment-tax
+// of kv version 2 or secret/assessment-tax of version 1, keys of the secret are names of settings
+func readVault(client *http.Client, addr string, token string, path string) (map[string]string, error) {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault responded %s for %s", resp.Status, path)
+	}
+
+	var body vaultResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	data := body.Data
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, ok := data["metadata"]; ok {
+			data = nested
+		}
+	}
+
+	values := map[string]string{}
+
+	for name, v := range data {
+		switch v := v.(type) {
+		case string:
+			values[name] = v
+		case float64, bool:
+			values[name] = fmt.Sprint(v)
+		default:
+			return nil, fmt.Errorf("%s of %s must be a single value", name, path)
+		}
+	}
+
+	return values, nil
+}
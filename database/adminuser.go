@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const adminUserColumns = `id, username, password_hash, role, totp_secret, totp_enabled, uuid, created_at, updated_at`
+
+func scanAdminUser(row rowScanner) (AdminUser, error) {
+	var u AdminUser
+
+	err := row.Scan(&u.ID, &u.Username, &u.PasswordHash, &u.Role, &u.TOTPSecret, &u.TOTPEnabled, &u.UUID, &u.CreatedAt, &u.UpdatedAt)
+	if err != nil {
+		return AdminUser{}, err
+	}
+
+	return u, nil
+}
+
+func (db *DB) FindAllAdminUsers(ctx context.Context) ([]AdminUser, error) {
+	ctx, span := db.startSpan(ctx, "FindAllAdminUsers")
+	defer span.End()
+
+	return queryAll(ctx, db.getReadDB(), scanAdminUser, `SELECT `+adminUserColumns+` FROM admin_users ORDER BY id`)
+}
+
+func (db *DB) FindAdminUserByUsername(ctx context.Context, username string) (AdminUser, error) {
+	ctx, span := db.startSpan(ctx, "FindAdminUserByUsername")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`SELECT `+adminUserColumns+` FROM admin_users WHERE username = $1`, username)
+
+	u, err := scanAdminUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return AdminUser{}, ErrNotFound
+	}
+
+	return u, err
+}
+
+func (db *DB) CreateAdminUser(ctx context.Context, username string, passwordHash string, role string) (AdminUser, error) {
+	ctx, span := db.startSpan(ctx, "CreateAdminUser")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`
+		INSERT INTO admin_users (username, password_hash, role)
+		VALUES ($1, $2, $3)
+		RETURNING `+adminUserColumns, username, passwordHash, role)
+
+	u, err := scanAdminUser(row)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return AdminUser{}, ErrAlreadyExists
+	}
+
+	return u, err
+}
+
+// UpdateAdminUserRole changes role of user and revokes their sessions in the same transaction, since tokens
+// carry the role they were issued with
+func (db *DB) UpdateAdminUserRole(ctx context.Context, id int, role string) (AdminUser, error) {
+	ctx, span := db.startSpan(ctx, "UpdateAdminUserRole")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
+	if err != nil {
+		return AdminUser{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	u, err := scanAdminUser(tx.QueryRow(ctx,
+		`UPDATE admin_users SET role = $2, updated_at = now() WHERE id = $1 RETURNING `+adminUserColumns, id, role))
+	if errors.Is(err, sql.ErrNoRows) {
+		return AdminUser{}, ErrNotFound
+	}
+
+	if err != nil {
+		return AdminUser{}, err
+	}
+
+	if err := revokeAdminSessionsOf(ctx, tx, u.Username); err != nil {
+		return AdminUser{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return AdminUser{}, err
+	}
+
+	return u, nil
+}
+
+// UpdateAdminUserTOTP stores encrypted totp secret of user, enabled is false until the first code is verified
+func (db *DB) UpdateAdminUserTOTP(ctx context.Context, username string, secret []byte, enabled bool) error {
+	ctx, span := db.startSpan(ctx, "UpdateAdminUserTOTP")
+	defer span.End()
+
+	res, err := db.getPool().Exec(ctx,
+		`UPDATE admin_users SET totp_secret = $2, totp_enabled = $3, updated_at = now() WHERE username = $1`,
+		username, secret, enabled)
+	if err != nil {
+		return err
+	}
+
+	n := res.RowsAffected()
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteAdminUser deletes user and revokes their sessions in the same transaction, so tokens issued
+// to the user stop working with it
+func (db *DB) DeleteAdminUser(ctx context.Context, id int) error {
+	ctx, span := db.startSpan(ctx, "DeleteAdminUser")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var username string
+
+	err = tx.QueryRow(ctx, `DELETE FROM admin_users WHERE id = $1 RETURNING username`, id).Scan(&username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if err := revokeAdminSessionsOf(ctx, tx, username); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+type AdminUser struct {
+	ID           int    `db:"id"`
+	Username     string `db:"username"`
+	PasswordHash string `db:"password_hash"`
+	Role         string `db:"role"`
+	// TOTPSecret is encrypted with secretbox
+	TOTPSecret  []byte    `db:"totp_secret"`
+	TOTPEnabled bool      `db:"totp_enabled"`
+	UUID        string    `db:"uuid"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
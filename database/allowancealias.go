@@ -0,0 +1,105 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+const allowanceAliasColumns = `alias, allowance_type, created_at, updated_at`
+
+func scanAllowanceAlias(row rowScanner) (AllowanceAlias, error) {
+	var a AllowanceAlias
+
+	if err := row.Scan(&a.Alias, &a.AllowanceType, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		return AllowanceAlias{}, err
+	}
+
+	return a, nil
+}
+
+func (db *DB) FindAllowanceAliases(ctx context.Context) ([]AllowanceAlias, error) {
+	ctx, span := db.startSpan(ctx, "FindAllowanceAliases")
+	defer span.End()
+
+	return queryAll(ctx, db.getReadDB(), scanAllowanceAlias,
+		`
+		SELECT `+allowanceAliasColumns+` FROM allowance_aliases ORDER BY alias
+		`)
+}
+
+// UpsertAllowanceAlias creates alias or points it at another allowance type
+func (db *DB) UpsertAllowanceAlias(ctx context.Context, alias string, allowanceType string) (AllowanceAlias, error) {
+	ctx, span := db.startSpan(ctx, "UpsertAllowanceAlias")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
+	if err != nil {
+		return AllowanceAlias{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockSettings(ctx, tx); err != nil {
+		return AllowanceAlias{}, err
+	}
+
+	a, err := scanAllowanceAlias(tx.QueryRow(ctx,
+		`
+		INSERT INTO allowance_aliases (alias, allowance_type)
+		VALUES ($1, $2)
+		ON CONFLICT (alias) DO UPDATE
+		SET allowance_type = EXCLUDED.allowance_type, updated_at = now()
+		RETURNING `+allowanceAliasColumns, alias, allowanceType))
+	if err != nil {
+		return AllowanceAlias{}, err
+	}
+
+	if _, err := recordSettingVersion(ctx, tx); err != nil {
+		return AllowanceAlias{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return AllowanceAlias{}, err
+	}
+
+	return a, nil
+}
+
+func (db *DB) DeleteAllowanceAlias(ctx context.Context, alias string) error {
+	ctx, span := db.startSpan(ctx, "DeleteAllowanceAlias")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockSettings(ctx, tx); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(ctx, `DELETE FROM allowance_aliases WHERE alias = $1`, alias)
+	if err != nil {
+		return err
+	}
+
+	n := res.RowsAffected()
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := recordSettingVersion(ctx, tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// AllowanceAlias is another spelling of an allowance type, e.g. "kreceipt" of "k-receipt"
+type AllowanceAlias struct {
+	Alias         string    `db:"alias"`
+	AllowanceType string    `db:"allowance_type"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
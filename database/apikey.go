@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const apiKeyColumns = `id, name, key_prefix, scopes, monthly_quota, signing_secret, tenant_id, uuid, created_at, updated_at, revoked_at`
+
+func scanAPIKey(row rowScanner) (APIKey, error) {
+	var k APIKey
+
+	err := row.Scan(&k.ID, &k.Name, &k.Prefix, &k.Scopes, &k.MonthlyQuota, &k.SigningSecret, &k.TenantID, &k.UUID, &k.CreatedAt, &k.UpdatedAt, &k.RevokedAt)
+	if err != nil {
+		return APIKey{}, err
+	}
+
+	return k, nil
+}
+
+func (db *DB) CreateAPIKey(ctx context.Context, name string, prefix string, hash string, scopes []string) (APIKey, error) {
+	ctx, span := db.startSpan(ctx, "CreateAPIKey")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`
+		INSERT INTO api_keys (name, key_prefix, key_hash, scopes)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+apiKeyColumns, name, prefix, hash, scopes)
+
+	return scanAPIKey(row)
+}
+
+func (db *DB) FindAllAPIKeys(ctx context.Context) ([]APIKey, error) {
+	ctx, span := db.startSpan(ctx, "FindAllAPIKeys")
+	defer span.End()
+
+	return queryAll(ctx, db.getReadDB(), scanAPIKey, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id`)
+}
+
+// FindActiveAPIKeyByHash returns ErrNotFound when the key doesn't exist or is revoked
+func (db *DB) FindActiveAPIKeyByHash(ctx context.Context, hash string) (APIKey, error) {
+	ctx, span := db.startSpan(ctx, "FindActiveAPIKeyByHash")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1 AND revoked_at IS NULL`, hash)
+
+	k, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, ErrNotFound
+	}
+
+	return k, err
+}
+
+func (db *DB) UpdateAPIKeyScopes(ctx context.Context, id int, scopes []string) (APIKey, error) {
+	ctx, span := db.startSpan(ctx, "UpdateAPIKeyScopes")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`
+		UPDATE api_keys SET scopes = $2, updated_at = now()
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING `+apiKeyColumns, id, scopes)
+
+	k, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, ErrNotFound
+	}
+
+	return k, err
+}
+
+// UpdateAPIKeyQuota sets monthly request quota of key, nil quota means unlimited
+func (db *DB) UpdateAPIKeyQuota(ctx context.Context, id int, quota *int) (APIKey, error) {
+	ctx, span := db.startSpan(ctx, "UpdateAPIKeyQuota")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`
+		UPDATE api_keys SET monthly_quota = $2, updated_at = now()
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING `+apiKeyColumns, id, quota)
+
+	k, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, ErrNotFound
+	}
+
+	return k, err
+}
+
+// UpdateAPIKeySigningSecret sets secret used to verify signed requests of key, nil secret disables signing
+func (db *DB) UpdateAPIKeySigningSecret(ctx context.Context, id int, secret *string) (APIKey, error) {
+	ctx, span := db.startSpan(ctx, "UpdateAPIKeySigningSecret")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`
+		UPDATE api_keys SET signing_secret = $2, updated_at = now()
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING `+apiKeyColumns, id, secret)
+
+	k, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, ErrNotFound
+	}
+
+	return k, err
+}
+
+// UpdateAPIKeyTenant assigns key to tenant, nil tenantID makes the key use the global settings.
+// ErrNotFound is returned when either the key or the tenant doesn't exist
+func (db *DB) UpdateAPIKeyTenant(ctx context.Context, id int, tenantID *int) (APIKey, error) {
+	ctx, span := db.startSpan(ctx, "UpdateAPIKeyTenant")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`
+		UPDATE api_keys SET tenant_id = $2, updated_at = now()
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING `+apiKeyColumns, id, tenantID)
+
+	k, err := scanAPIKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return APIKey{}, ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return APIKey{}, ErrNotFound
+	}
+
+	return k, err
+}
+
+func (db *DB) RevokeAPIKey(ctx context.Context, id int) error {
+	ctx, span := db.startSpan(ctx, "RevokeAPIKey")
+	defer span.End()
+
+	res, err := db.getPool().Exec(ctx,
+		`UPDATE api_keys SET revoked_at = now(), updated_at = now() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	n := res.RowsAffected()
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+type APIKey struct {
+	ID           int      `db:"id"`
+	Name         string   `db:"name"`
+	Prefix       string   `db:"key_prefix"`
+	Scopes       []string `db:"scopes"`
+	MonthlyQuota *int     `db:"monthly_quota"`
+	// SigningSecret is set for partner keys which must sign their requests
+	SigningSecret *string `db:"signing_secret"`
+	// TenantID is set for keys of a tenant, their calculations use overrides of the tenant
+	TenantID  *int       `db:"tenant_id"`
+	UUID      string     `db:"uuid"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt time.Time  `db:"updated_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/envelope"
+	"github.com/google/uuid"
+)
+
+// statuses of batch jobs, a job is queued until a worker picks it up
+const (
+	BatchJobQueued  = "queued"
+	BatchJobRunning = "running"
+	BatchJobDone    = "done"
+	BatchJobFailed  = "failed"
+)
+
+const batchJobColumns = `id, status, tax_year, effective_date, api_key_id, tenant_id, user_id, payload, result, error_code,
+	created_at, updated_at, sealed_payload, payload_key, sealed_result, result_key, key_id`
+
+// scanBatchJob opens payload and result of a job sealed when encryption is configured
+func (db *DB) scanBatchJob(row rowScanner) (BatchJob, error) {
+	var (
+		j                                            BatchJob
+		sealedPayload, payloadKey, sealed, resultKey []byte
+		keyID                                        sql.NullString
+	)
+
+	err := row.Scan(&j.ID, &j.Status, &j.TaxYear, &j.EffectiveDate, &j.APIKeyID, &j.TenantID, &j.UserID, &j.Payload,
+		&j.Result, &j.ErrorCode, &j.CreatedAt, &j.UpdatedAt, &sealedPayload, &payloadKey, &sealed, &resultKey, &keyID)
+	if err != nil {
+		return BatchJob{}, err
+	}
+
+	if sealedPayload == nil && sealed == nil {
+		return j, nil
+	}
+
+	if db.envelope == nil {
+		return BatchJob{}, errors.New("batch job is sealed but no encryption key is configured")
+	}
+
+	if sealedPayload != nil {
+		if j.Payload, err = db.envelope.Open(envelope.Sealed{Ciphertext: sealedPayload, WrappedKey: payloadKey, KeyID: keyID.String}); err != nil {
+			return BatchJob{}, err
+		}
+	}
+
+	if sealed != nil {
+		if j.Result, err = db.envelope.Open(envelope.Sealed{Ciphertext: sealed, WrappedKey: resultKey, KeyID: keyID.String}); err != nil {
+			return BatchJob{}, err
+		}
+	}
+
+	return j, nil
+}
+
+// seal returns plain, or nil and plain sealed when encryption is configured, nil values stay nil
+func (db *DB) seal(plain []byte) ([]byte, envelope.Sealed, error) {
+	if db.envelope == nil || plain == nil {
+		return plain, envelope.Sealed{}, nil
+	}
+
+	sealed, err := db.envelope.Seal(plain)
+
+	return nil, sealed, err
+}
+
+// CreateBatchJob stores job as queued, its id is generated. The csv is sealed when encryption is configured,
+// it has incomes of taxpayers
+func (db *DB) CreateBatchJob(ctx context.Context, j BatchJob) (BatchJob, error) {
+	ctx, span := db.startSpan(ctx, "CreateBatchJob")
+	defer span.End()
+
+	payload, sealed, err := db.seal(j.Payload)
+	if err != nil {
+		return BatchJob{}, err
+	}
+
+	return db.scanBatchJob(db.getPool().QueryRow(ctx,
+		`
+		INSERT INTO batch_jobs (status, tax_year, effective_date, api_key_id, tenant_id, user_id, payload,
+			sealed_payload, payload_key, key_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, ''))
+		RETURNING `+batchJobColumns,
+		BatchJobQueued, j.TaxYear, j.EffectiveDate, j.APIKeyID, j.TenantID, j.UserID, payload,
+		sealed.Ciphertext, sealed.WrappedKey, sealed.KeyID))
+}
+
+// FindBatchJob returns ErrNotFound when there is no job id, ids which aren't uuids included
+func (db *DB) FindBatchJob(ctx context.Context, id string) (BatchJob, error) {
+	ctx, span := db.startSpan(ctx, "FindBatchJob")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return BatchJob{}, ErrNotFound
+	}
+
+	// workers read the job right after it's created, so it's read from the primary
+	j, err := db.scanBatchJob(db.getPool().QueryRow(ctx,
+		`SELECT `+batchJobColumns+` FROM batch_jobs WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return BatchJob{}, ErrNotFound
+	}
+
+	return j, err
+}
+
+// UpdateBatchJob sets status of job, with result of a done job or error code of a failed one.
+// The result is sealed when encryption is configured
+func (db *DB) UpdateBatchJob(ctx context.Context, id string, status string, result []byte, errorCode string) (BatchJob, error) {
+	ctx, span := db.startSpan(ctx, "UpdateBatchJob")
+	defer span.End()
+
+	if _, err := uuid.Parse(id); err != nil {
+		return BatchJob{}, ErrNotFound
+	}
+
+	result, sealed, err := db.seal(result)
+	if err != nil {
+		return BatchJob{}, err
+	}
+
+	j, err := db.scanBatchJob(db.getPool().QueryRow(ctx,
+		`
+		UPDATE batch_jobs SET status = $2, result = $3, error_code = $4, sealed_result = $5, result_key = $6,
+			key_id = COALESCE(key_id, NULLIF($7, '')), updated_at = now()
+		WHERE id = $1
+		RETURNING `+batchJobColumns, id, status, result, errorCode, sealed.Ciphertext, sealed.WrappedKey, sealed.KeyID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return BatchJob{}, ErrNotFound
+	}
+
+	return j, err
+}
+
+// RequeueBatchJobs sets jobs which are queued or running back to queued and returns their ids, it's called when
+// a process using a local queue starts since jobs of its queue were lost when it stopped
+func (db *DB) RequeueBatchJobs(ctx context.Context) ([]string, error) {
+	ctx, span := db.startSpan(ctx, "RequeueBatchJobs")
+	defer span.End()
+
+	return queryAll(ctx, db.getPool(), func(row rowScanner) (string, error) {
+		var id string
+		return id, row.Scan(&id)
+	},
+		`
+		UPDATE batch_jobs SET status = $1, updated_at = now()
+		WHERE status IN ($1, $2)
+		RETURNING id
+		`, BatchJobQueued, BatchJobRunning)
+}
+
+// BatchJob is a csv upload calculated by a worker, Payload is the csv and Result is the response of a done job
+type BatchJob struct {
+	ID            string    `db:"id"`
+	Status        string    `db:"status"`
+	TaxYear       int       `db:"tax_year"`
+	EffectiveDate time.Time `db:"effective_date"`
+	APIKeyID      *int      `db:"api_key_id"`
+	TenantID      *int      `db:"tenant_id"`
+	UserID        *int      `db:"user_id"`
+	Payload       []byte    `db:"payload"`
+	Result        []byte    `db:"result"`
+	ErrorCode     string    `db:"error_code"`
+	CreatedAt     time.Time `db:"created_at"`
+	UpdatedAt     time.Time `db:"updated_at"`
+}
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"bytes"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/envelope"
+	"github.com/AnnaCarter465/assessment-tax/pkg/secretbox"
+	"github.com/stretchr/testify/assert"
+)
+
+// rowStub scans values like a row of batchJobColumns
+type rowStub []any
+
+func (r rowStub) Scan(dest ...any) error {
+	for i, v := range r {
+		if v != nil {
+			reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(v))
+		}
+	}
+
+	return nil
+}
+
+func TestScanBatchJobOpensSealed(t *testing.T) {
+	box, err := secretbox.New(bytes.Repeat([]byte{1}, 32))
+	assert.NoError(t, err)
+
+	e, err := envelope.New("k1", box)
+	assert.NoError(t, err)
+
+	db := (&DB{}).SetEnvelope(e)
+
+	csv, result := []byte("totalIncome,wht,donation\n500000,0,0\n"), []byte(`[{"totalIncome":500000,"tax":29000}]`)
+
+	payload, sealedPayload, err := db.seal(csv)
+	assert.NoError(t, err)
+	assert.Nil(t, payload, "the csv isn't stored in plain")
+	assert.False(t, bytes.Contains(sealedPayload.Ciphertext, []byte("500000")))
+
+	_, sealedResult, err := db.seal(result)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	row := rowStub{"id", BatchJobDone, 2024, now, (*int)(nil), (*int)(nil), (*int)(nil), []byte(nil), []byte(nil), "",
+		now, now, sealedPayload.Ciphertext, sealedPayload.WrappedKey, sealedResult.Ciphertext, sealedResult.WrappedKey, sql.NullString{String: "k1", Valid: true}}
+
+	j, err := db.scanBatchJob(row)
+	assert.NoError(t, err)
+	assert.Equal(t, csv, j.Payload)
+	assert.Equal(t, result, j.Result)
+
+	_, err = (&DB{}).scanBatchJob(row)
+	assert.ErrorContains(t, err, "no encryption key")
+
+	payload, _, err = (&DB{}).seal(csv)
+	assert.NoError(t, err)
+	assert.Equal(t, csv, payload, "stored in plain without encryption")
+}
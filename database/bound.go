@@ -0,0 +1,32 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/AnnaCarter465/assessment-tax/database/repository"
+)
+
+// FindSettingBound returns ErrNotFound when no bound is configured for setting
+func (db *DB) FindSettingBound(ctx context.Context, setting string) (SettingBound, error) {
+	ctx, span := db.startSpan(ctx, "FindSettingBound")
+	defer span.End()
+
+	row, err := repository.New(db.getPool()).FindSettingBound(ctx, setting)
+	if errors.Is(err, sql.ErrNoRows) {
+		return SettingBound{}, ErrNotFound
+	}
+
+	if err != nil {
+		return SettingBound{}, err
+	}
+
+	return SettingBound{Setting: row.Setting, MinAmount: row.MinAmount, MaxAmount: row.MaxAmount}, nil
+}
+
+type SettingBound struct {
+	Setting   string  `db:"setting"`
+	MinAmount float64 `db:"min_amount"`
+	MaxAmount float64 `db:"max_amount"`
+}
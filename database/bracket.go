@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/AnnaCarter465/assessment-tax/database/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+func scanTaxBracket(row rowScanner) (TaxBracket, error) {
+	var (
+		b      TaxBracket
+		labels []byte
+	)
+
+	err := row.Scan(&b.TaxYear, &b.Level, &b.Percentage, &b.MaxAmount, &b.Label, &labels)
+	if err != nil {
+		return TaxBracket{}, err
+	}
+
+	if err := json.Unmarshal(labels, &b.Labels); err != nil {
+		return TaxBracket{}, err
+	}
+
+	return b, nil
+}
+
+func taxBracketFrom(r repository.TaxBracket) (TaxBracket, error) {
+	b := TaxBracket{
+		TaxYear:    int(r.TaxYear),
+		Level:      int(r.Level),
+		Percentage: r.Percentage,
+		MaxAmount:  r.MaxAmount,
+		Label:      r.Label,
+	}
+
+	if err := json.Unmarshal(r.Labels, &b.Labels); err != nil {
+		return TaxBracket{}, err
+	}
+
+	return b, nil
+}
+
+// FindTaxBrackets returns imported brackets of tax year ordered from the lowest, it's empty when none is imported
+func (db *DB) FindTaxBrackets(ctx context.Context, taxYear int) ([]TaxBracket, error) {
+	ctx, span := db.startSpan(ctx, "FindTaxBrackets")
+	defer span.End()
+
+	rows, err := repository.New(db.getPool()).FindTaxBrackets(ctx, int32(taxYear))
+
+	return fromRows(rows, err, taxBracketFrom)
+}
+
+// findAllTaxBrackets returns imported brackets of every tax year, it's read by q so a transaction sees its own writes
+func findAllTaxBrackets(ctx context.Context, q repository.DBTX) (map[int][]TaxBracket, error) {
+	rows, err := repository.New(q).FindAllTaxBrackets(ctx)
+
+	all, err := fromRows(rows, err, taxBracketFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	results := map[int][]TaxBracket{}
+
+	for _, b := range all {
+		results[b.TaxYear] = append(results[b.TaxYear], b)
+	}
+
+	return results, nil
+}
+
+// insertTaxBrackets inserts brackets of tax year numbered from the lowest, existing brackets must be deleted first
+func insertTaxBrackets(ctx context.Context, tx pgx.Tx, taxYear int, brackets []TaxBracket) error {
+	q := repository.New(tx)
+
+	for i, b := range brackets {
+		labels, err := json.Marshal(b.Labels)
+		if err != nil {
+			return err
+		}
+
+		err = q.InsertTaxBracket(ctx, repository.InsertTaxBracketParams{
+			TaxYear:    int32(taxYear),
+			Level:      int32(i + 1),
+			Percentage: b.Percentage,
+			MaxAmount:  b.MaxAmount,
+			Label:      b.Label,
+			Labels:     labels,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type TaxBracket struct {
+	TaxYear    int               `db:"tax_year"`
+	Level      int               `db:"level"`
+	Percentage float64           `db:"percentage"`
+	MaxAmount  *float64          `db:"max_amount"` // nil for the highest bracket
+	Label      string            `db:"label"`
+	Labels     map[string]string `db:"labels"`
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrCircuitOpen is returned without contacting the database after consecutive connection failures
+var ErrCircuitOpen = errors.New("database circuit breaker is open")
+
+// breaker opens after threshold consecutive failures and lets a trial through once cooldown has passed
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	now       func() time.Time
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	return &breaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.failures < b.threshold || b.now().Sub(b.openedAt) >= b.cooldown
+}
+
+func (b *breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+// breakerDial guards dial of new connections, the pool dials when it has no idle connection or drops a broken one,
+// so queries fail fast while the database is down instead of each waiting for its own dial timeout
+func breakerDial(dial pgconn.DialFunc, b *breaker) pgconn.DialFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if !b.allow() {
+			return nil, ErrCircuitOpen
+		}
+
+		conn, err := dial(ctx, network, addr)
+
+		// cancelled requests don't tell anything about the database
+		if ctx.Err() == nil {
+			b.record(err)
+		}
+
+		return conn, err
+	}
+}
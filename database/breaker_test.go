@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	b := newBreaker(2, 10*time.Second)
+	b.now = func() time.Time { return now }
+
+	b.record(errors.New("connection refused"))
+	assert.True(t, b.allow(), "stays closed under threshold")
+
+	b.record(errors.New("connection refused"))
+	assert.False(t, b.allow(), "opens at threshold")
+
+	now = now.Add(10 * time.Second)
+	assert.True(t, b.allow(), "lets trial through after cooldown")
+
+	b.record(errors.New("connection refused"))
+	assert.False(t, b.allow(), "reopens when trial fails")
+
+	now = now.Add(10 * time.Second)
+	b.record(nil)
+	assert.True(t, b.allow(), "closes when trial succeeds")
+
+	b.record(errors.New("connection refused"))
+	assert.True(t, b.allow(), "failures are counted again from zero")
+}
+
+func TestBreakerDial(t *testing.T) {
+	dials := 0
+	dial := breakerDial(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dials++
+		return nil, errors.New("connection refused")
+	}, newBreaker(2, time.Minute))
+
+	for range 3 {
+		_, err := dial(context.Background(), "tcp", "localhost:5432")
+		assert.Error(t, err)
+	}
+
+	_, err := dial(context.Background(), "tcp", "localhost:5432")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 2, dials, "fails fast without dialing once open")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	b := newBreaker(1, time.Minute)
+	_, err = breakerDial(func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, ctx.Err()
+	}, b)(ctx, "tcp", "localhost:5432")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.True(t, b.allow(), "cancelled dials aren't counted")
+}
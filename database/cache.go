@@ -0,0 +1,259 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/cache"
+)
+
+const (
+	defaultAllowancesKey = "settings:default_allowances"
+	allowedAllowancesKey = "settings:allowed_allowances"
+	effectiveKey         = "settings:effective_allowances"
+	allowanceAliasesKey  = "settings:allowance_aliases"
+	settingVersionKey    = "settings:version"
+)
+
+var settingsKeys = []string{defaultAllowancesKey, allowedAllowancesKey, effectiveKey, allowanceAliasesKey, settingVersionKey}
+
+// Cached caches allowances read on every calculation for ttl,
+// writes of settings through it invalidate the cache so the replica never serves its own stale values
+type Cached struct {
+	Store
+
+	cache cache.Cache
+	ttl   time.Duration
+
+	mu         sync.Mutex
+	generation int // incremented by Invalidate, so loads started before a write aren't cached after it
+
+	broadcast func(ctx context.Context)
+}
+
+func NewCached(store Store, c cache.Cache, ttl time.Duration) *Cached {
+	return &Cached{Store: store, cache: c, ttl: ttl}
+}
+
+// SetBroadcast sets function telling other replicas that settings were written through c, so they invalidate
+// their caches too
+func (c *Cached) SetBroadcast(broadcast func(ctx context.Context)) *Cached {
+	c.broadcast = broadcast
+	return c
+}
+
+// Invalidate drops every cached value
+func (c *Cached) Invalidate(ctx context.Context) {
+	c.mu.Lock()
+	c.generation++
+	c.mu.Unlock()
+
+	if err := c.cache.Delete(ctx, settingsKeys...); err != nil {
+		slog.ErrorContext(ctx, "failed to invalidate settings cache", "error", err)
+	}
+}
+
+// cached returns value of key, load is called when it isn't cached or is expired,
+// the store is used directly when the cache doesn't work
+func cached[T any](ctx context.Context, c *Cached, key string, load func() (T, error)) (T, error) {
+	c.mu.Lock()
+	generation := c.generation
+	c.mu.Unlock()
+
+	b, ok, err := c.cache.Get(ctx, key)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to read settings cache", "key", key, "error", err)
+	}
+
+	if ok {
+		var v T
+		if err := json.Unmarshal(b, &v); err == nil {
+			return v, nil
+		}
+	}
+
+	v, err := load()
+	if err != nil {
+		return v, err
+	}
+
+	c.mu.Lock()
+	stale := c.generation != generation
+	c.mu.Unlock()
+
+	if stale {
+		return v, nil
+	}
+
+	b, err = json.Marshal(v)
+	if err == nil {
+		err = c.cache.Set(ctx, key, b, c.ttl)
+	}
+
+	if err != nil {
+		slog.WarnContext(ctx, "failed to write settings cache", "key", key, "error", err)
+	}
+
+	return v, nil
+}
+
+func (c *Cached) FindAllDefaultAllowances(ctx context.Context) ([]DefaultAllowance, error) {
+	return cached(ctx, c, defaultAllowancesKey, func() ([]DefaultAllowance, error) {
+		return c.Store.FindAllDefaultAllowances(ctx)
+	})
+}
+
+func (c *Cached) FindAllAllowedAllowances(ctx context.Context) ([]AllowedAllowance, error) {
+	return cached(ctx, c, allowedAllowancesKey, func() ([]AllowedAllowance, error) {
+		return c.Store.FindAllAllowedAllowances(ctx)
+	})
+}
+
+func (c *Cached) FindAllowanceAliases(ctx context.Context) ([]AllowanceAlias, error) {
+	return cached(ctx, c, allowanceAliasesKey, func() ([]AllowanceAlias, error) {
+		return c.Store.FindAllowanceAliases(ctx)
+	})
+}
+
+// LatestSettingVersion is cached like settings, every write of settings records a new version
+func (c *Cached) LatestSettingVersion(ctx context.Context) (int, error) {
+	return cached(ctx, c, settingVersionKey, func() (int, error) {
+		return c.Store.LatestSettingVersion(ctx)
+	})
+}
+
+// FindEffectiveAllowances caches every effective allowance and picks values of at from them,
+// so a single key serves every date
+func (c *Cached) FindEffectiveAllowances(ctx context.Context, at time.Time) ([]EffectiveAllowance, error) {
+	all, err := cached(ctx, c, effectiveKey, func() ([]EffectiveAllowance, error) {
+		return c.Store.FindAllEffectiveAllowances(ctx)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return effectiveOn(all, at), nil
+}
+
+// effectiveOn returns the latest value of every allowance in all which is effective on at,
+// ordered like FindEffectiveAllowances of DB
+func effectiveOn(all []EffectiveAllowance, at time.Time) []EffectiveAllowance {
+	latest := map[[2]string]EffectiveAllowance{}
+
+	for _, a := range all {
+		if a.EffectiveFrom.After(at) {
+			continue
+		}
+
+		key := [2]string{a.AllowanceKind, a.AllowanceType}
+		if l, ok := latest[key]; !ok || a.EffectiveFrom.After(l.EffectiveFrom) {
+			latest[key] = a
+		}
+	}
+
+	var results []EffectiveAllowance
+
+	for _, a := range latest {
+		results = append(results, a)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].AllowanceKind != results[j].AllowanceKind {
+			return results[i].AllowanceKind < results[j].AllowanceKind
+		}
+
+		return results[i].AllowanceType < results[j].AllowanceType
+	})
+
+	return results
+}
+
+// written invalidates the cache of every replica after a write of settings
+func (c *Cached) written(ctx context.Context) {
+	c.Invalidate(ctx)
+
+	if c.broadcast != nil {
+		c.broadcast(ctx)
+	}
+}
+
+// invalidateAfter drops cached values once write returns, also when it fails since part of it may be committed
+func invalidateAfter[T any](ctx context.Context, c *Cached, write func() (T, error)) (T, error) {
+	defer c.written(context.WithoutCancel(ctx))
+
+	return write()
+}
+
+func (c *Cached) UpdateAmountDefaultAllowances(ctx context.Context, allowanceType string, amount float64) (DefaultAllowance, error) {
+	return invalidateAfter(ctx, c, func() (DefaultAllowance, error) {
+		return c.Store.UpdateAmountDefaultAllowances(ctx, allowanceType, amount)
+	})
+}
+
+func (c *Cached) UpdateAmountAllowedAllowances(ctx context.Context, allowanceType string, amount float64) (AllowedAllowance, error) {
+	return invalidateAfter(ctx, c, func() (AllowedAllowance, error) {
+		return c.Store.UpdateAmountAllowedAllowances(ctx, allowanceType, amount)
+	})
+}
+
+func (c *Cached) DisableAllowanceType(ctx context.Context, allowanceType string) error {
+	defer c.written(context.WithoutCancel(ctx))
+
+	return c.Store.DisableAllowanceType(ctx, allowanceType)
+}
+
+func (c *Cached) UpsertAllowanceAlias(ctx context.Context, alias string, allowanceType string) (AllowanceAlias, error) {
+	return invalidateAfter(ctx, c, func() (AllowanceAlias, error) {
+		return c.Store.UpsertAllowanceAlias(ctx, alias, allowanceType)
+	})
+}
+
+func (c *Cached) DeleteAllowanceAlias(ctx context.Context, alias string) error {
+	defer c.written(context.WithoutCancel(ctx))
+
+	return c.Store.DeleteAllowanceAlias(ctx, alias)
+}
+
+func (c *Cached) UpsertEffectiveAllowance(ctx context.Context, a EffectiveAllowance) (EffectiveAllowance, error) {
+	return invalidateAfter(ctx, c, func() (EffectiveAllowance, error) {
+		return c.Store.UpsertEffectiveAllowance(ctx, a)
+	})
+}
+
+// ApplyDueScheduledChanges runs every minute, it only invalidates and broadcasts when something may have changed
+func (c *Cached) ApplyDueScheduledChanges(ctx context.Context, now time.Time) ([]ScheduledChange, error) {
+	changes, err := c.Store.ApplyDueScheduledChanges(ctx, now)
+	if len(changes) > 0 || err != nil {
+		c.written(context.WithoutCancel(ctx))
+	}
+
+	return changes, err
+}
+
+func (c *Cached) PublishSettingDraft(ctx context.Context, id int, publishedBy string) (SettingDraft, error) {
+	return invalidateAfter(ctx, c, func() (SettingDraft, error) {
+		return c.Store.PublishSettingDraft(ctx, id, publishedBy)
+	})
+}
+
+func (c *Cached) RollbackSettings(ctx context.Context, version int) (SettingVersion, error) {
+	return invalidateAfter(ctx, c, func() (SettingVersion, error) {
+		return c.Store.RollbackSettings(ctx, version)
+	})
+}
+
+func (c *Cached) ImportSettings(ctx context.Context, imp SettingsImport) (SettingVersion, error) {
+	return invalidateAfter(ctx, c, func() (SettingVersion, error) {
+		return c.Store.ImportSettings(ctx, imp)
+	})
+}
+
+func (c *Cached) SeedSettings(ctx context.Context, s SettingsImport) (int, error) {
+	return invalidateAfter(ctx, c, func() (int, error) {
+		return c.Store.SeedSettings(ctx, s)
+	})
+}
@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCached(t *testing.T) {
+	ctx := context.Background()
+
+	store := NewMemory()
+	c := NewCached(store, cache.NewLocal(), time.Minute)
+
+	personal := func() float64 {
+		allowances, err := c.FindAllDefaultAllowances(ctx)
+		assert.NoError(t, err)
+
+		return allowances[0].Amount
+	}
+
+	assert.Equal(t, float64(60_000), personal())
+
+	// written behind the cache, e.g. by another replica
+	_, err := store.UpdateAmountDefaultAllowances(ctx, "personal", 70_000)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(60_000), personal(), "cached within ttl")
+
+	_, err = c.UpdateAmountDefaultAllowances(ctx, "personal", 80_000)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(80_000), personal(), "invalidated by write through cache")
+
+	effective, err := c.FindEffectiveAllowances(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, effective, 1)
+	assert.Equal(t, float64(80_000), effective[0].Amount)
+
+	effective, err = c.FindEffectiveAllowances(ctx, time.Now().AddDate(0, 0, -1))
+	assert.NoError(t, err)
+	assert.Empty(t, effective, "picked from the same cached value by date")
+
+	var broadcasts int
+
+	c.SetBroadcast(func(ctx context.Context) { broadcasts++ })
+
+	_, err = c.UpdateAmountDefaultAllowances(ctx, "personal", 90_000)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, broadcasts, "writes are broadcast to other replicas")
+}
+
+func TestCachedLatestSettingVersion(t *testing.T) {
+	ctx := context.Background()
+
+	store := NewMemory()
+	c := NewCached(store, cache.NewLocal(), time.Minute)
+
+	version := func() int {
+		v, err := c.LatestSettingVersion(ctx)
+		assert.NoError(t, err)
+
+		return v
+	}
+
+	assert.Equal(t, 1, version())
+
+	_, err := store.UpdateAmountDefaultAllowances(ctx, "personal", 70_000)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, version(), "cached within ttl")
+
+	_, err = c.UpdateAmountDefaultAllowances(ctx, "personal", 80_000)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, version(), "invalidated by write through cache")
+}
+
+func TestCachedApplyDueScheduledChanges(t *testing.T) {
+	ctx := context.Background()
+
+	store := NewMemory()
+	c := NewCached(store, cache.NewLocal(), time.Minute)
+
+	var broadcasts int
+
+	c.SetBroadcast(func(ctx context.Context) { broadcasts++ })
+
+	changes, err := c.ApplyDueScheduledChanges(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Empty(t, changes)
+	assert.Equal(t, 0, broadcasts, "nothing due isn't broadcast")
+
+	_, err = store.CreateScheduledChange(ctx, "default", "personal", 70_000, time.Now().Add(-time.Minute))
+	assert.NoError(t, err)
+
+	changes, err = c.ApplyDueScheduledChanges(ctx, time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, 1, broadcasts, "applied changes are broadcast")
+}
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const calculationDraftColumns = `id, user_id, name, total_income, wht, allowances, tax, tax_refund, uuid, created_at, updated_at, finalized_at`
+
+func scanCalculationDraft(row rowScanner) (CalculationDraft, error) {
+	var (
+		d          CalculationDraft
+		allowances []byte
+	)
+
+	err := row.Scan(&d.ID, &d.UserID, &d.Name, &d.TotalIncome, &d.Wht, &allowances, &d.Tax, &d.TaxRefund,
+		&d.UUID, &d.CreatedAt, &d.UpdatedAt, &d.FinalizedAt)
+	if err != nil {
+		return CalculationDraft{}, err
+	}
+
+	return d, json.Unmarshal(allowances, &d.Allowances)
+}
+
+// FindCalculationDrafts returns drafts of taxpayer, finalized ones included
+func (db *DB) FindCalculationDrafts(ctx context.Context, userID int) ([]CalculationDraft, error) {
+	ctx, span := db.startSpan(ctx, "FindCalculationDrafts")
+	defer span.End()
+
+	return queryAll(ctx, db.getPool(), scanCalculationDraft,
+		`SELECT `+calculationDraftColumns+` FROM calculation_drafts WHERE user_id = $1 ORDER BY id`, userID)
+}
+
+// FindCalculationDraft returns ErrNotFound when taxpayer has no draft id, finalized drafts are found too
+func (db *DB) FindCalculationDraft(ctx context.Context, userID int, id int) (CalculationDraft, error) {
+	ctx, span := db.startSpan(ctx, "FindCalculationDraft")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`SELECT `+calculationDraftColumns+` FROM calculation_drafts WHERE id = $1 AND user_id = $2`, id, userID)
+
+	d, err := scanCalculationDraft(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return CalculationDraft{}, ErrNotFound
+	}
+
+	return d, err
+}
+
+func (db *DB) CreateCalculationDraft(ctx context.Context, d CalculationDraft) (CalculationDraft, error) {
+	ctx, span := db.startSpan(ctx, "CreateCalculationDraft")
+	defer span.End()
+
+	allowances, err := json.Marshal(d.Allowances)
+	if err != nil {
+		return CalculationDraft{}, err
+	}
+
+	row := db.getPool().QueryRow(ctx,
+		`
+		INSERT INTO calculation_drafts (user_id, name, total_income, wht, allowances)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+calculationDraftColumns, d.UserID, d.Name, d.TotalIncome, d.Wht, allowances)
+
+	return scanCalculationDraft(row)
+}
+
+// UpdateCalculationDraft replaces inputs of draft, it returns ErrNotFound when the draft isn't pending
+func (db *DB) UpdateCalculationDraft(ctx context.Context, d CalculationDraft) (CalculationDraft, error) {
+	ctx, span := db.startSpan(ctx, "UpdateCalculationDraft")
+	defer span.End()
+
+	allowances, err := json.Marshal(d.Allowances)
+	if err != nil {
+		return CalculationDraft{}, err
+	}
+
+	row := db.getPool().QueryRow(ctx,
+		`
+		UPDATE calculation_drafts SET name = $3, total_income = $4, wht = $5, allowances = $6, updated_at = now()
+		WHERE id = $1 AND user_id = $2 AND finalized_at IS NULL
+		RETURNING `+calculationDraftColumns, d.ID, d.UserID, d.Name, d.TotalIncome, d.Wht, allowances)
+
+	updated, err := scanCalculationDraft(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return CalculationDraft{}, ErrNotFound
+	}
+
+	return updated, err
+}
+
+// FinalizeCalculationDraft records tax of draft calculated from its inputs as of updatedAt. It returns ErrNotFound
+// when the draft isn't pending and ErrConflict when its inputs were updated since
+func (db *DB) FinalizeCalculationDraft(ctx context.Context, userID int, id int, updatedAt time.Time, tax float64, taxRefund float64) (CalculationDraft, error) {
+	ctx, span := db.startSpan(ctx, "FinalizeCalculationDraft")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`
+		UPDATE calculation_drafts SET tax = $4, tax_refund = $5, finalized_at = now(), updated_at = now()
+		WHERE id = $1 AND user_id = $2 AND finalized_at IS NULL AND updated_at = $3
+		RETURNING `+calculationDraftColumns, id, userID, updatedAt, tax, taxRefund)
+
+	d, err := scanCalculationDraft(row)
+	if !errors.Is(err, sql.ErrNoRows) {
+		return d, err
+	}
+
+	// tell a finalized draft from one whose inputs changed
+	found, err := db.FindCalculationDraft(ctx, userID, id)
+	if err != nil {
+		return CalculationDraft{}, err
+	}
+
+	if found.FinalizedAt != nil {
+		return CalculationDraft{}, ErrNotFound
+	}
+
+	return CalculationDraft{}, ErrConflict
+}
+
+// CalculationDraft is a calculation a taxpayer builds up before it's complete, inputs which aren't known yet are nil.
+// Allowances are amounts by allowance type
+type CalculationDraft struct {
+	ID          int                `db:"id"`
+	UserID      int                `db:"user_id"`
+	Name        string             `db:"name"`
+	TotalIncome *float64           `db:"total_income"`
+	Wht         *float64           `db:"wht"`
+	Allowances  map[string]float64 `db:"allowances"`
+	Tax         *float64           `db:"tax"`        // nil until the draft is finalized
+	TaxRefund   *float64           `db:"tax_refund"` // nil until the draft is finalized
+	UUID        string             `db:"uuid"`
+	CreatedAt   time.Time          `db:"created_at"`
+	UpdatedAt   time.Time          `db:"updated_at"`
+	FinalizedAt *time.Time         `db:"finalized_at"`
+}
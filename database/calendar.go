@@ -0,0 +1,185 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const taxCalendarColumns = `tax_year, filing_deadline, uuid, created_at, updated_at`
+
+func scanTaxCalendar(row rowScanner) (TaxCalendar, error) {
+	var cal TaxCalendar
+
+	err := row.Scan(&cal.TaxYear, &cal.FilingDeadline, &cal.UUID, &cal.CreatedAt, &cal.UpdatedAt)
+	if err != nil {
+		return TaxCalendar{}, err
+	}
+
+	return cal, nil
+}
+
+func scanAllowanceWindow(row rowScanner) (AllowanceWindow, error) {
+	var w AllowanceWindow
+
+	err := row.Scan(&w.AllowanceType, &w.StartsOn, &w.EndsOn)
+	if err != nil {
+		return AllowanceWindow{}, err
+	}
+
+	return w, nil
+}
+
+func (db *DB) FindAllTaxCalendars(ctx context.Context) ([]TaxCalendar, error) {
+	ctx, span := db.startSpan(ctx, "FindAllTaxCalendars")
+	defer span.End()
+
+	results, err := queryAll(ctx, db.getReadDB(), scanTaxCalendar,
+		`
+		SELECT `+taxCalendarColumns+` FROM tax_calendars ORDER BY tax_year
+		`)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		windows, err := db.findAllowanceWindows(ctx, results[i].TaxYear)
+		if err != nil {
+			return nil, err
+		}
+
+		results[i].AllowanceWindows = windows
+	}
+
+	return results, nil
+}
+
+// FindTaxCalendar returns calendar of taxYear with its allowance windows
+func (db *DB) FindTaxCalendar(ctx context.Context, taxYear int) (TaxCalendar, error) {
+	ctx, span := db.startSpan(ctx, "FindTaxCalendar")
+	defer span.End()
+
+	cal, err := scanTaxCalendar(db.getPool().QueryRow(ctx,
+		`SELECT `+taxCalendarColumns+` FROM tax_calendars WHERE tax_year = $1`, taxYear))
+	if errors.Is(err, sql.ErrNoRows) {
+		return TaxCalendar{}, ErrNotFound
+	}
+
+	if err != nil {
+		return TaxCalendar{}, err
+	}
+
+	cal.AllowanceWindows, err = db.findAllowanceWindows(ctx, taxYear)
+	if err != nil {
+		return TaxCalendar{}, err
+	}
+
+	return cal, nil
+}
+
+// FindUpcomingTaxCalendar returns the calendar with the nearest filing deadline on or after from
+func (db *DB) FindUpcomingTaxCalendar(ctx context.Context, from time.Time) (TaxCalendar, error) {
+	ctx, span := db.startSpan(ctx, "FindUpcomingTaxCalendar")
+	defer span.End()
+
+	return scanTaxCalendar(db.getPool().QueryRow(ctx,
+		`
+		SELECT `+taxCalendarColumns+` FROM tax_calendars
+		WHERE filing_deadline >= $1
+		ORDER BY filing_deadline
+		LIMIT 1
+		`, from))
+}
+
+func (db *DB) UpsertTaxCalendar(ctx context.Context, cal TaxCalendar) (TaxCalendar, error) {
+	ctx, span := db.startSpan(ctx, "UpsertTaxCalendar")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
+	if err != nil {
+		return TaxCalendar{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	windows := cal.AllowanceWindows
+
+	cal, err = scanTaxCalendar(tx.QueryRow(ctx,
+		`
+		INSERT INTO tax_calendars (tax_year, filing_deadline)
+		VALUES ($1, $2)
+		ON CONFLICT (tax_year) DO UPDATE SET filing_deadline = EXCLUDED.filing_deadline, updated_at = now()
+		RETURNING `+taxCalendarColumns, cal.TaxYear, cal.FilingDeadline))
+	if err != nil {
+		return TaxCalendar{}, err
+	}
+
+	cal.AllowanceWindows = windows
+
+	_, err = tx.Exec(ctx, `DELETE FROM allowance_windows WHERE tax_year = $1`, cal.TaxYear)
+	if err != nil {
+		return TaxCalendar{}, err
+	}
+
+	for _, w := range cal.AllowanceWindows {
+		_, err = tx.Exec(ctx,
+			`
+			INSERT INTO allowance_windows (tax_year, allowance_type, starts_on, ends_on)
+			VALUES ($1, $2, $3, $4)
+			`, cal.TaxYear, w.AllowanceType, w.StartsOn, w.EndsOn)
+		if err != nil {
+			return TaxCalendar{}, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return TaxCalendar{}, err
+	}
+
+	return cal, nil
+}
+
+func (db *DB) DeleteTaxCalendar(ctx context.Context, taxYear int) error {
+	ctx, span := db.startSpan(ctx, "DeleteTaxCalendar")
+	defer span.End()
+
+	res, err := db.getPool().Exec(ctx, `DELETE FROM tax_calendars WHERE tax_year = $1`, taxYear)
+	if err != nil {
+		return err
+	}
+
+	n := res.RowsAffected()
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+func (db *DB) findAllowanceWindows(ctx context.Context, taxYear int) ([]AllowanceWindow, error) {
+	ctx, span := db.startSpan(ctx, "findAllowanceWindows")
+	defer span.End()
+
+	return queryAll(ctx, db.getPool(), scanAllowanceWindow,
+		`
+		SELECT allowance_type, starts_on, ends_on FROM allowance_windows
+		WHERE tax_year = $1
+		ORDER BY allowance_type
+		`, taxYear)
+}
+
+type TaxCalendar struct {
+	TaxYear          int       `db:"tax_year"`
+	FilingDeadline   time.Time `db:"filing_deadline"`
+	UUID             string    `db:"uuid"`
+	CreatedAt        time.Time `db:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at"`
+	AllowanceWindows []AllowanceWindow
+}
+
+type AllowanceWindow struct {
+	AllowanceType string    `db:"allowance_type"`
+	StartsOn      time.Time `db:"starts_on"`
+	EndsOn        time.Time `db:"ends_on"`
+}
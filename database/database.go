@@ -2,144 +2,364 @@ package database
 
 import (
 	"context"
-	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/AnnaCarter465/assessment-tax/database/repository"
+	"github.com/AnnaCarter465/assessment-tax/pkg/envelope"
+	"github.com/AnnaCarter465/assessment-tax/pkg/metrics"
+	"github.com/AnnaCarter465/assessment-tax/pkg/tracing"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var (
+	ErrNotFound      = errors.New("record not found")
+	ErrAlreadyExists = errors.New("record already exists")
+	// ErrConflict is returned when settings or a calculation draft were changed after the version expected by the caller
+	ErrConflict = errors.New("record was changed concurrently")
+	// ErrInUse is returned when a record can't be deleted since other records refer to it
+	ErrInUse = errors.New("record is still referenced")
+)
+
+// postgres error codes of constraint violations
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+const (
+	defaultConnectTimeout   = 30 * time.Second
+	defaultBreakerThreshold = 5
+	defaultBreakerCooldown  = 10 * time.Second
+	maxRetryInterval        = 5 * time.Second
+)
+
+type Config struct {
+	ConnectTimeout     time.Duration // how long to wait for the database at startup, it may start after the service
+	BreakerThreshold   int           // consecutive connection failures opening the circuit breaker
+	BreakerCooldown    time.Duration // how long the breaker fails fast before trying the database again
+	SlowQueryThreshold time.Duration // statements running longer are logged with parameters redacted, zero turns it off
+	ReplicaURL         string        // read replica serving FindAll* queries, empty means primary serves every query
+}
+
 type DB struct {
-	sqlDB *sql.DB
+	pool     *pgxpool.Pool
+	replica  *pgxpool.Pool // nil when no replica is configured
+	metrics  metrics.Metrics
+	envelope *envelope.Envelope // seals amounts of calculation history and csv of batch jobs, nil stores them in plain
 }
 
-func NewDB(dbURL string) (*DB, error) {
-	db, err := sql.Open("postgres", dbURL)
+// NewDB returns DB once the database answers a ping, retrying with exponential backoff until conf.ConnectTimeout
+func NewDB(dbURL string, conf Config) (*DB, error) {
+	if conf.ConnectTimeout <= 0 {
+		conf.ConnectTimeout = defaultConnectTimeout
+	}
+
+	if conf.BreakerThreshold <= 0 {
+		conf.BreakerThreshold = defaultBreakerThreshold
+	}
+
+	if conf.BreakerCooldown <= 0 {
+		conf.BreakerCooldown = defaultBreakerCooldown
+	}
+
+	pool, err := newPool(dbURL, conf)
 	if err != nil {
 		return nil, err
 	}
 
-	return &DB{sqlDB: db}, nil
+	db := &DB{pool: pool, metrics: metrics.Noop{}}
+
+	// replica isn't waited for, reads fall back to primary until it's up
+	if conf.ReplicaURL != "" {
+		db.replica, err = newPool(conf.ReplicaURL, conf)
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("replica: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), conf.ConnectTimeout)
+	defer cancel()
+
+	// waiting at startup bypasses the breaker, otherwise it would stay open after the database comes up
+	if err := ping(ctx, dbURL); err != nil {
+		db.close()
+		return nil, err
+	}
+
+	return db, nil
 }
 
-func (db *DB) getSQLDB() *sql.DB {
-	return db.sqlDB
+// newPool returns pool of dbURL, pool sizes are set by pool_max_conns and the like in dbURL.
+// Connections are dialed through a breaker of the pool.
+func newPool(dbURL string, conf Config) (*pgxpool.Pool, error) {
+	poolConf, err := pgxpool.ParseConfig(dbURL)
+	if err != nil {
+		return nil, err
+	}
+
+	poolConf.ConnConfig.DialFunc = breakerDial(poolConf.ConnConfig.DialFunc, newBreaker(conf.BreakerThreshold, conf.BreakerCooldown))
+
+	if conf.SlowQueryThreshold > 0 {
+		poolConf.ConnConfig.Tracer = &slowQueryTracer{threshold: conf.SlowQueryThreshold}
+	}
+
+	// connections are opened on first use, so this doesn't wait for the database
+	return pgxpool.NewWithConfig(context.Background(), poolConf)
 }
 
-func (db *DB) FindAllDefaultAllowances(ctx context.Context) ([]DefaultAllowance, error) {
-	var results []DefaultAllowance
+func (db *DB) close() {
+	db.pool.Close()
+
+	if db.replica != nil {
+		db.replica.Close()
+	}
+}
+
+// SetMetrics sets backend of per-query counters and latency histograms
+func (db *DB) SetMetrics(m metrics.Metrics) *DB {
+	db.metrics = m
+	return db
+}
+
+// SetEnvelope sets envelope sealing amounts of calculation history and csv and results of batch jobs,
+// so a database dump doesn't expose incomes
+func (db *DB) SetEnvelope(e *envelope.Envelope) *DB {
+	db.envelope = e
+	return db
+}
 
-	rows, err := db.getSQLDB().QueryContext(
-		ctx,
-		`
-			SELECT allowance_type, amount FROM default_allowances
-		`)
+// ping waits for database at dbURL to answer with exponential backoff, each attempt opens its own connection
+func ping(ctx context.Context, dbURL string) error {
+	connConf, err := pgx.ParseConfig(dbURL)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	for rows.Next() {
-		var (
-			allowanceType string
-			amount        float64
-		)
+	interval := 100 * time.Millisecond
 
-		err = rows.Scan(&allowanceType, &amount)
-		if err != nil {
-			return nil, err
+	for {
+		conn, err := pgx.ConnectConfig(ctx, connConf)
+		if err == nil {
+			return conn.Close(ctx)
 		}
 
-		results = append(results, DefaultAllowance{
-			AllowanceType: allowanceType,
-			Amount:        amount,
-		})
+		slog.WarnContext(ctx, "database is not ready, retrying", "error", err, "retryIn", interval)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database is not ready: %w", err)
+		case <-time.After(interval):
+		}
+
+		interval = min(interval*2, maxRetryInterval)
 	}
+}
+
+func (db *DB) getPool() *pgxpool.Pool {
+	return db.pool
+}
+
+// startSpan starts span of database call, name is the method name.
+// Ending the span records count and latency of the call labelled by name.
+func (db *DB) startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	ctx, span := tracing.Start(withQueryName(ctx, name), "db."+name, attribute.String("db.system", "postgresql"))
+
+	return ctx, &querySpan{Span: span, metrics: db.metrics, name: name, start: time.Now()}
+}
+
+// querySpan records metrics of database call when it ends, so slow requests
+// can be told apart as database-bound or compute-bound
+type querySpan struct {
+	trace.Span
+	metrics metrics.Metrics
+	name    string
+	start   time.Time
+}
+
+func (s *querySpan) End(options ...trace.SpanEndOption) {
+	labels := metrics.Labels{"query": s.name}
+
+	s.metrics.IncCounter("db_queries", 1, labels)
+	s.metrics.ObserveDuration("db_query_duration", time.Since(s.start), labels)
+
+	s.Span.End(options...)
+}
+
+func defaultAllowanceFrom(r repository.DefaultAllowance) (DefaultAllowance, error) {
+	return DefaultAllowance(r), nil
+}
+
+func allowedAllowanceFrom(r repository.AllowedAllowance) (AllowedAllowance, error) {
+	return AllowedAllowance(r), nil
+}
 
-	return results, nil
+func (db *DB) FindAllDefaultAllowances(ctx context.Context) ([]DefaultAllowance, error) {
+	ctx, span := db.startSpan(ctx, "FindAllDefaultAllowances")
+	defer span.End()
+
+	rows, err := repository.New(db.getReadDB()).FindAllDefaultAllowances(ctx)
+
+	return fromRows(rows, err, defaultAllowanceFrom)
 }
 
 func (db *DB) UpdateAmountDefaultAllowances(ctx context.Context, allowanceType string, amount float64) (DefaultAllowance, error) {
-	var (
-		at string
-		am float64
-	)
-
-	err := db.getSQLDB().QueryRowContext(ctx,
-		`
-			UPDATE default_allowances
-			SET amount = $2
-			WHERE allowance_type = $1
-			RETURNING allowance_type, amount
-	   	`, allowanceType, amount).Scan(&at, &am)
+	ctx, span := db.startSpan(ctx, "UpdateAmountDefaultAllowances")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
+	if err != nil {
+		return DefaultAllowance{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockSettings(ctx, tx); err != nil {
+		return DefaultAllowance{}, err
+	}
+
+	row, err := repository.New(tx).UpdateDefaultAllowanceAmount(ctx, repository.UpdateDefaultAllowanceAmountParams{
+		AllowanceType: allowanceType,
+		Amount:        amount,
+	})
+	if err != nil {
+		return DefaultAllowance{}, err
+	}
+
+	// the change is effective from today, so it overrides values published for earlier dates
+	_, err = upsertEffectiveAllowance(ctx, tx, EffectiveAllowance{
+		AllowanceKind: AllowanceKindDefault,
+		AllowanceType: row.AllowanceType,
+		Amount:        row.Amount,
+		EffectiveFrom: time.Now().UTC().Truncate(24 * time.Hour),
+	})
 	if err != nil {
 		return DefaultAllowance{}, err
 	}
 
-	return DefaultAllowance{
-		AllowanceType: at,
-		Amount:        am,
-	}, nil
+	if _, err := recordSettingVersion(ctx, tx); err != nil {
+		return DefaultAllowance{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return DefaultAllowance{}, err
+	}
+
+	return DefaultAllowance(row), nil
 }
 
 func (db *DB) FindAllAllowedAllowances(ctx context.Context) ([]AllowedAllowance, error) {
-	var results []AllowedAllowance
+	ctx, span := db.startSpan(ctx, "FindAllAllowedAllowances")
+	defer span.End()
 
-	rows, err := db.getSQLDB().QueryContext(
-		ctx,
-		`
-		SELECT allowance_type, max_amount FROM allowed_allowances
-		`)
+	rows, err := repository.New(db.getReadDB()).FindAllAllowedAllowances(ctx)
+
+	return fromRows(rows, err, allowedAllowanceFrom)
+}
+
+func (db *DB) UpdateAmountAllowedAllowances(ctx context.Context, allowanceType string, amount float64) (AllowedAllowance, error) {
+	ctx, span := db.startSpan(ctx, "UpdateAmountAllowedAllowances")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
 	if err != nil {
-		return nil, err
+		return AllowedAllowance{}, err
 	}
+	defer tx.Rollback(ctx)
 
-	for rows.Next() {
-		var (
-			allowanceType string
-			maxAmount     float64
-		)
+	if err := lockSettings(ctx, tx); err != nil {
+		return AllowedAllowance{}, err
+	}
 
-		err = rows.Scan(&allowanceType, &maxAmount)
-		if err != nil {
-			return nil, err
-		}
+	row, err := repository.New(tx).UpdateAllowedAllowanceMaxAmount(ctx, repository.UpdateAllowedAllowanceMaxAmountParams{
+		AllowanceType: allowanceType,
+		MaxAmount:     amount,
+	})
+	if err != nil {
+		return AllowedAllowance{}, err
+	}
 
-		results = append(results, AllowedAllowance{
-			AllowanceType: allowanceType,
-			MaxAmount:     maxAmount,
-		})
+	// the change is effective from today, so it overrides values published for earlier dates
+	_, err = upsertEffectiveAllowance(ctx, tx, EffectiveAllowance{
+		AllowanceKind: AllowanceKindAllowed,
+		AllowanceType: row.AllowanceType,
+		Amount:        row.MaxAmount,
+		EffectiveFrom: time.Now().UTC().Truncate(24 * time.Hour),
+	})
+	if err != nil {
+		return AllowedAllowance{}, err
 	}
 
-	return results, nil
+	if _, err := recordSettingVersion(ctx, tx); err != nil {
+		return AllowedAllowance{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return AllowedAllowance{}, err
+	}
+
+	return AllowedAllowance(row), nil
 }
 
-func (db *DB) UpdateAmountAllowedAllowances(ctx context.Context, allowanceType string, amount float64) (AllowedAllowance, error) {
-	var (
-		at string
-		am float64
-	)
-
-	err := db.getSQLDB().QueryRowContext(ctx,
-		`
-			UPDATE allowed_allowances
-			SET max_amount = $2
-			WHERE allowance_type = $1
-			RETURNING allowance_type, max_amount
-	   	`, allowanceType, amount).Scan(&at, &am)
+// DisableAllowanceType rejects allowanceType in new calculations, it's kept in both tables
+// so calculations made before stay resolvable. It returns ErrNotFound when no allowance has the type.
+func (db *DB) DisableAllowanceType(ctx context.Context, allowanceType string) error {
+	ctx, span := db.startSpan(ctx, "DisableAllowanceType")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
 	if err != nil {
-		return AllowedAllowance{}, err
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockSettings(ctx, tx); err != nil {
+		return err
+	}
+
+	q := repository.New(tx)
+
+	var n int64
+
+	for _, disable := range []func(context.Context, string) (int64, error){q.DisableDefaultAllowance, q.DisableAllowedAllowance} {
+		affected, err := disable(ctx, allowanceType)
+		if err != nil {
+			return err
+		}
+
+		n += affected
+	}
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := recordSettingVersion(ctx, tx); err != nil {
+		return err
 	}
 
-	return AllowedAllowance{
-		AllowanceType: at,
-		MaxAmount:     am,
-	}, nil
+	return tx.Commit(ctx)
 }
 
 type DefaultAllowance struct {
-	AllowanceType string  `db:"allowance_type"`
-	Amount        float64 `db:"amount"`
+	AllowanceType string     `db:"allowance_type"`
+	Amount        float64    `db:"amount"`
+	UUID          string     `db:"uuid"`
+	CreatedAt     time.Time  `db:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at"`
+	DisabledAt    *time.Time `db:"disabled_at"` // disabled types are rejected by new calculations
 }
 
 type AllowedAllowance struct {
-	AllowanceType string  `db:"allowance_type"`
-	MaxAmount     float64 `db:"max_amount"`
+	AllowanceType string     `db:"allowance_type"`
+	MaxAmount     float64    `db:"max_amount"`
+	UUID          string     `db:"uuid"`
+	CreatedAt     time.Time  `db:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at"`
+	DisabledAt    *time.Time `db:"disabled_at"` // disabled types are rejected by new calculations
 }
@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/database/repository"
+	"github.com/AnnaCarter465/assessment-tax/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartSpanRecordsQueryMetrics(t *testing.T) {
+	p := metrics.NewPrometheus("")
+	db := (&DB{}).SetMetrics(p)
+
+	for range 2 {
+		_, span := db.startSpan(context.Background(), "FindAllDefaultAllowances")
+		span.End()
+	}
+
+	rec := httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Contains(t, rec.Body.String(), `db_queries_total{query="FindAllDefaultAllowances"} 2`)
+	assert.Contains(t, rec.Body.String(), `db_query_duration_seconds_count{query="FindAllDefaultAllowances"} 2`)
+}
+
+func TestFromRows(t *testing.T) {
+	maxAmount := 150000.0
+
+	brackets, err := fromRows([]repository.TaxBracket{
+		{TaxYear: 2024, Level: 1, Percentage: 0, MaxAmount: &maxAmount, Label: "0-150,000", Labels: []byte(`{"th":"0-150,000"}`)},
+		{TaxYear: 2024, Level: 2, Percentage: 0.1, Label: "150,001 ขึ้นไป", Labels: []byte(`{}`)},
+	}, nil, taxBracketFrom)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []TaxBracket{
+		{TaxYear: 2024, Level: 1, Percentage: 0, MaxAmount: &maxAmount, Label: "0-150,000", Labels: map[string]string{"th": "0-150,000"}},
+		{TaxYear: 2024, Level: 2, Percentage: 0.1, Label: "150,001 ขึ้นไป", Labels: map[string]string{}},
+	}, brackets)
+
+	_, err = fromRows([]repository.TaxBracket{{Labels: []byte(`not json`)}}, nil, taxBracketFrom)
+	assert.Error(t, err)
+
+	queryErr := errors.New("connection reset")
+	_, err = fromRows[repository.TaxBracket](nil, queryErr, taxBracketFrom)
+	assert.ErrorIs(t, err, queryErr)
+}
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+const settingDraftColumns = `id, allowance_kind, allowance_type, amount, created_by, uuid, created_at, updated_at, published_by, published_at, discarded_at`
+
+func scanSettingDraft(row rowScanner) (SettingDraft, error) {
+	var d SettingDraft
+
+	err := row.Scan(&d.ID, &d.AllowanceKind, &d.AllowanceType, &d.Amount, &d.CreatedBy, &d.UUID, &d.CreatedAt, &d.UpdatedAt, &d.PublishedBy, &d.PublishedAt, &d.DiscardedAt)
+	if err != nil {
+		return SettingDraft{}, err
+	}
+
+	return d, nil
+}
+
+func (db *DB) CreateSettingDraft(ctx context.Context, kind string, allowanceType string, amount float64, createdBy string) (SettingDraft, error) {
+	ctx, span := db.startSpan(ctx, "CreateSettingDraft")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`
+		INSERT INTO setting_drafts (allowance_kind, allowance_type, amount, created_by)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+settingDraftColumns, kind, allowanceType, amount, createdBy)
+
+	return scanSettingDraft(row)
+}
+
+// FindSettingDraft returns ErrNotFound when the draft doesn't exist or isn't pending anymore
+func (db *DB) FindSettingDraft(ctx context.Context, id int) (SettingDraft, error) {
+	ctx, span := db.startSpan(ctx, "FindSettingDraft")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`
+		SELECT `+settingDraftColumns+` FROM setting_drafts
+		WHERE id = $1 AND published_at IS NULL AND discarded_at IS NULL
+		`, id)
+
+	d, err := scanSettingDraft(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return SettingDraft{}, ErrNotFound
+	}
+
+	return d, err
+}
+
+// FindPendingSettingDrafts returns drafts which are neither published nor discarded
+func (db *DB) FindPendingSettingDrafts(ctx context.Context) ([]SettingDraft, error) {
+	ctx, span := db.startSpan(ctx, "FindPendingSettingDrafts")
+	defer span.End()
+
+	return queryAll(ctx, db.getPool(), scanSettingDraft,
+		`
+		SELECT `+settingDraftColumns+` FROM setting_drafts
+		WHERE published_at IS NULL AND discarded_at IS NULL
+		ORDER BY id
+		`)
+}
+
+// PublishSettingDraft applies the draft to live settings, it returns ErrNotFound when the draft isn't pending
+func (db *DB) PublishSettingDraft(ctx context.Context, id int, publishedBy string) (SettingDraft, error) {
+	ctx, span := db.startSpan(ctx, "PublishSettingDraft")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
+	if err != nil {
+		return SettingDraft{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockSettings(ctx, tx); err != nil {
+		return SettingDraft{}, err
+	}
+
+	d, err := scanSettingDraft(tx.QueryRow(ctx,
+		`
+		UPDATE setting_drafts SET published_by = $2, published_at = now(), updated_at = now()
+		WHERE id = $1 AND published_at IS NULL AND discarded_at IS NULL
+		RETURNING `+settingDraftColumns, id, publishedBy))
+	if errors.Is(err, sql.ErrNoRows) {
+		return SettingDraft{}, ErrNotFound
+	}
+
+	if err != nil {
+		return SettingDraft{}, err
+	}
+
+	if err := applyAllowance(ctx, tx, d.AllowanceKind, d.AllowanceType, d.Amount, *d.PublishedAt); err != nil {
+		return SettingDraft{}, err
+	}
+
+	if _, err := recordSettingVersion(ctx, tx); err != nil {
+		return SettingDraft{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return SettingDraft{}, err
+	}
+
+	return d, nil
+}
+
+// DiscardSettingDraft returns ErrNotFound when the draft isn't pending
+func (db *DB) DiscardSettingDraft(ctx context.Context, id int) error {
+	ctx, span := db.startSpan(ctx, "DiscardSettingDraft")
+	defer span.End()
+
+	res, err := db.getPool().Exec(ctx,
+		`
+		UPDATE setting_drafts SET discarded_at = now(), updated_at = now()
+		WHERE id = $1 AND published_at IS NULL AND discarded_at IS NULL
+		`, id)
+	if err != nil {
+		return err
+	}
+
+	n := res.RowsAffected()
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+type SettingDraft struct {
+	ID            int        `db:"id"`
+	AllowanceKind string     `db:"allowance_kind"`
+	AllowanceType string     `db:"allowance_type"`
+	Amount        float64    `db:"amount"`
+	CreatedBy     string     `db:"created_by"`
+	UUID          string     `db:"uuid"`
+	CreatedAt     time.Time  `db:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at"`
+	PublishedBy   *string    `db:"published_by"`
+	PublishedAt   *time.Time `db:"published_at"`
+	DiscardedAt   *time.Time `db:"discarded_at"`
+}
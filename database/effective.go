@@ -0,0 +1,93 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database/repository"
+)
+
+// kinds of effective allowances, default allowances apply to everyone and allowed allowances are caps of claims
+const (
+	AllowanceKindDefault = "default"
+	AllowanceKindAllowed = "allowed"
+)
+
+func effectiveAllowanceFrom(r repository.EffectiveAllowance) (EffectiveAllowance, error) {
+	return EffectiveAllowance{
+		AllowanceKind: r.AllowanceKind,
+		AllowanceType: r.AllowanceType,
+		Amount:        r.Amount,
+		EffectiveFrom: r.EffectiveFrom,
+	}, nil
+}
+
+// FindEffectiveAllowances returns the latest value of every allowance which is effective on at
+func (db *DB) FindEffectiveAllowances(ctx context.Context, at time.Time) ([]EffectiveAllowance, error) {
+	ctx, span := db.startSpan(ctx, "FindEffectiveAllowances")
+	defer span.End()
+
+	rows, err := repository.New(db.getPool()).FindEffectiveAllowances(ctx, at)
+
+	return fromRows(rows, err, effectiveAllowanceFrom)
+}
+
+func (db *DB) FindAllEffectiveAllowances(ctx context.Context) ([]EffectiveAllowance, error) {
+	ctx, span := db.startSpan(ctx, "FindAllEffectiveAllowances")
+	defer span.End()
+
+	rows, err := repository.New(db.getReadDB()).FindAllEffectiveAllowances(ctx)
+
+	return fromRows(rows, err, effectiveAllowanceFrom)
+}
+
+func (db *DB) UpsertEffectiveAllowance(ctx context.Context, a EffectiveAllowance) (EffectiveAllowance, error) {
+	ctx, span := db.startSpan(ctx, "UpsertEffectiveAllowance")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
+	if err != nil {
+		return EffectiveAllowance{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockSettings(ctx, tx); err != nil {
+		return EffectiveAllowance{}, err
+	}
+
+	a, err = upsertEffectiveAllowance(ctx, tx, a)
+	if err != nil {
+		return EffectiveAllowance{}, err
+	}
+
+	if _, err := recordSettingVersion(ctx, tx); err != nil {
+		return EffectiveAllowance{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return EffectiveAllowance{}, err
+	}
+
+	return a, nil
+}
+
+func upsertEffectiveAllowance(ctx context.Context, q repository.DBTX, a EffectiveAllowance) (EffectiveAllowance, error) {
+	row, err := repository.New(q).UpsertEffectiveAllowance(ctx, repository.UpsertEffectiveAllowanceParams{
+		AllowanceKind: a.AllowanceKind,
+		AllowanceType: a.AllowanceType,
+		Amount:        a.Amount,
+		EffectiveFrom: a.EffectiveFrom,
+	})
+	if err != nil {
+		return EffectiveAllowance{}, err
+	}
+
+	return effectiveAllowanceFrom(row)
+}
+
+type EffectiveAllowance struct {
+	AllowanceKind string    `db:"allowance_kind"`
+	AllowanceType string    `db:"allowance_type"`
+	Amount        float64   `db:"amount"`
+	EffectiveFrom time.Time `db:"effective_from"`
+}
@@ -0,0 +1,229 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/envelope"
+)
+
+// InsertCalculations writes calculations in a single statement, callers batch them to keep inserts off the request path.
+// Amounts are sealed when SetEnvelope is set, then only time and subject of calculations are stored in plain.
+func (db *DB) InsertCalculations(ctx context.Context, calcs []Calculation) error {
+	ctx, span := db.startSpan(ctx, "InsertCalculations")
+	defer span.End()
+
+	if len(calcs) == 0 {
+		return nil
+	}
+
+	const columns = 11
+
+	values := make([]string, 0, len(calcs))
+	args := make([]any, 0, len(calcs)*columns)
+
+	for i, c := range calcs {
+		row, err := db.calculationRow(c)
+		if err != nil {
+			return err
+		}
+
+		placeholders := make([]string, columns)
+		for j := range placeholders {
+			placeholders[j] = fmt.Sprintf("$%d", i*columns+j+1)
+		}
+
+		values = append(values, "("+strings.Join(placeholders, ", ")+")")
+		args = append(args, row...)
+	}
+
+	_, err := db.getPool().Exec(ctx,
+		`
+		INSERT INTO calculation_history (total_income, wht, allowances, tax, tax_refund, calculated_at, api_key_id, user_id,
+			sealed_amounts, wrapped_key, key_id)
+		VALUES `+strings.Join(values, ", "), args...)
+
+	return err
+}
+
+// calculationAmounts are sealed together, they reveal income of the taxpayer
+type calculationAmounts struct {
+	TotalIncome float64            `json:"totalIncome"`
+	Wht         float64            `json:"wht"`
+	Allowances  map[string]float64 `json:"allowances"`
+	Tax         float64            `json:"tax"`
+	TaxRefund   float64            `json:"taxRefund"`
+}
+
+// calculationRow returns values of columns inserted by InsertCalculations
+func (db *DB) calculationRow(c Calculation) ([]any, error) {
+	if db.envelope == nil {
+		allowances, err := json.Marshal(c.Allowances)
+		if err != nil {
+			return nil, err
+		}
+
+		return []any{c.TotalIncome, c.Wht, allowances, c.Tax, c.TaxRefund, c.CalculatedAt, c.APIKeyID, c.UserID, nil, nil, nil}, nil
+	}
+
+	amounts, err := json.Marshal(calculationAmounts{
+		TotalIncome: c.TotalIncome,
+		Wht:         c.Wht,
+		Allowances:  c.Allowances,
+		Tax:         c.Tax,
+		TaxRefund:   c.TaxRefund,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := db.envelope.Seal(amounts)
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{nil, nil, nil, nil, nil, c.CalculatedAt, c.APIKeyID, c.UserID, sealed.Ciphertext, sealed.WrappedKey, sealed.KeyID}, nil
+}
+
+type Calculation struct {
+	ID           int                `db:"id"`
+	TotalIncome  float64            `db:"total_income"`
+	Wht          float64            `db:"wht"`
+	Allowances   map[string]float64 `db:"allowances"`
+	Tax          float64            `db:"tax"`
+	TaxRefund    float64            `db:"tax_refund"`
+	CalculatedAt time.Time          `db:"calculated_at"`
+	APIKeyID     *int               `db:"api_key_id"` // nil when api key auth is disabled
+	UserID       *int               `db:"user_id"`    // nil for anonymous calculations
+}
+
+// maxUserCalculations limits history returned to a taxpayer, older calculations are purged by retention anyway
+const maxUserCalculations = 100
+
+// FindUserCalculations returns the latest calculations of taxpayer, sealed amounts are opened
+func (db *DB) FindUserCalculations(ctx context.Context, userID int) ([]Calculation, error) {
+	ctx, span := db.startSpan(ctx, "FindUserCalculations")
+	defer span.End()
+
+	return queryAll(ctx, db.getReadDB(), db.scanCalculation,
+		`
+		SELECT id, total_income, wht, allowances, tax, tax_refund, calculated_at, api_key_id, user_id,
+			sealed_amounts, wrapped_key, key_id
+		FROM calculation_history
+		WHERE user_id = $1
+		ORDER BY calculated_at DESC, id DESC
+		LIMIT $2
+		`, userID, maxUserCalculations)
+}
+
+func (db *DB) scanCalculation(row rowScanner) (Calculation, error) {
+	var (
+		c                                Calculation
+		totalIncome, wht, tax, taxRefund sql.NullFloat64
+		allowances, sealed, wrapped      []byte
+		keyID                            sql.NullString
+	)
+
+	err := row.Scan(&c.ID, &totalIncome, &wht, &allowances, &tax, &taxRefund, &c.CalculatedAt, &c.APIKeyID, &c.UserID,
+		&sealed, &wrapped, &keyID)
+	if err != nil {
+		return Calculation{}, err
+	}
+
+	if sealed == nil {
+		c.TotalIncome, c.Wht, c.Tax, c.TaxRefund = totalIncome.Float64, wht.Float64, tax.Float64, taxRefund.Float64
+		return c, json.Unmarshal(allowances, &c.Allowances)
+	}
+
+	if db.envelope == nil {
+		return Calculation{}, errors.New("calculation is sealed but no encryption key is configured")
+	}
+
+	plain, err := db.envelope.Open(envelope.Sealed{Ciphertext: sealed, WrappedKey: wrapped, KeyID: keyID.String})
+	if err != nil {
+		return Calculation{}, err
+	}
+
+	var amounts calculationAmounts
+	if err := json.Unmarshal(plain, &amounts); err != nil {
+		return Calculation{}, err
+	}
+
+	c.TotalIncome, c.Wht, c.Allowances, c.Tax, c.TaxRefund = amounts.TotalIncome, amounts.Wht, amounts.Allowances, amounts.Tax, amounts.TaxRefund
+
+	return c, nil
+}
+
+// DeleteCalculations erases every calculation and batch job of api key and records a receipt of the erasure
+func (db *DB) DeleteCalculations(ctx context.Context, apiKeyID int) (DeletionReceipt, error) {
+	ctx, span := db.startSpan(ctx, "DeleteCalculations")
+	defer span.End()
+
+	return db.deleteCalculations(ctx, DeletionReceipt{APIKeyID: &apiKeyID})
+}
+
+// DeleteUserCalculations erases every calculation and batch job of taxpayer and records a receipt of the erasure
+func (db *DB) DeleteUserCalculations(ctx context.Context, userID int) (DeletionReceipt, error) {
+	ctx, span := db.startSpan(ctx, "DeleteUserCalculations")
+	defer span.End()
+
+	return db.deleteCalculations(ctx, DeletionReceipt{UserID: &userID})
+}
+
+// deleteCalculations erases calculations and batch jobs of the subject of r, it's either an api key or a taxpayer.
+// Batch jobs have the uploaded csv, so they're erased too and counted in the deleted rows
+func (db *DB) deleteCalculations(ctx context.Context, r DeletionReceipt) (DeletionReceipt, error) {
+	tx, err := db.getPool().Begin(ctx)
+	if err != nil {
+		return DeletionReceipt{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	// comparison with the NULL subject is never true
+	res, err := tx.Exec(ctx,
+		`DELETE FROM calculation_history WHERE api_key_id = $1 OR user_id = $2`, r.APIKeyID, r.UserID)
+	if err != nil {
+		return DeletionReceipt{}, err
+	}
+
+	r.DeletedRows = res.RowsAffected()
+
+	res, err = tx.Exec(ctx, `DELETE FROM batch_jobs WHERE api_key_id = $1 OR user_id = $2`, r.APIKeyID, r.UserID)
+	if err != nil {
+		return DeletionReceipt{}, err
+	}
+
+	jobs := res.RowsAffected()
+
+	r.DeletedRows += jobs
+
+	err = tx.QueryRow(ctx,
+		`
+		INSERT INTO calculation_deletions (api_key_id, user_id, deleted_rows)
+		VALUES ($1, $2, $3)
+		RETURNING id, deleted_at
+		`, r.APIKeyID, r.UserID, r.DeletedRows).Scan(&r.ID, &r.DeletedAt)
+	if err != nil {
+		return DeletionReceipt{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return DeletionReceipt{}, err
+	}
+
+	return r, nil
+}
+
+// DeletionReceipt proves calculations of an api key or a taxpayer were erased, it keeps no data of the erased calculations
+type DeletionReceipt struct {
+	ID          string    `db:"id"`
+	APIKeyID    *int      `db:"api_key_id"`
+	UserID      *int      `db:"user_id"`
+	DeletedRows int64     `db:"deleted_rows"`
+	DeletedAt   time.Time `db:"deleted_at"`
+}
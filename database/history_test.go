@@ -0,0 +1,48 @@
+package database
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/envelope"
+	"github.com/AnnaCarter465/assessment-tax/pkg/secretbox"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculationRowSealsAmounts(t *testing.T) {
+	box, err := secretbox.New(bytes.Repeat([]byte{1}, 32))
+	assert.NoError(t, err)
+
+	e, err := envelope.New("k1", box)
+	assert.NoError(t, err)
+
+	keyID, userID := 7, 3
+	calc := Calculation{
+		TotalIncome:  500_000,
+		Wht:          10_000,
+		Allowances:   map[string]float64{"donation": 100_000},
+		Tax:          19_000,
+		CalculatedAt: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		APIKeyID:     &keyID,
+		UserID:       &userID,
+	}
+
+	row, err := (&DB{}).SetEnvelope(e).calculationRow(calc)
+	assert.NoError(t, err)
+	assert.Equal(t, []any{nil, nil, nil, nil, nil, calc.CalculatedAt, &keyID, &userID}, row[:8])
+
+	opened, err := e.Open(envelope.Sealed{Ciphertext: row[8].([]byte), WrappedKey: row[9].([]byte), KeyID: row[10].(string)})
+	assert.NoError(t, err)
+
+	var amounts calculationAmounts
+
+	assert.NoError(t, json.Unmarshal(opened, &amounts))
+	assert.Equal(t, calculationAmounts{TotalIncome: 500_000, Wht: 10_000, Allowances: calc.Allowances, Tax: 19_000}, amounts)
+
+	row, err = (&DB{}).calculationRow(calc)
+	assert.NoError(t, err)
+	assert.Equal(t, 500_000.0, row[0])
+	assert.Nil(t, row[8])
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database/repository"
+)
+
+// ImportSettings applies all settings of imp in one transaction and records them as a new version.
+// Allowances which don't exist are created, disabled ones are enabled again,
+// brackets replace brackets of the same tax year.
+func (db *DB) ImportSettings(ctx context.Context, imp SettingsImport) (SettingVersion, error) {
+	ctx, span := db.startSpan(ctx, "ImportSettings")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
+	if err != nil {
+		return SettingVersion{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockSettings(ctx, tx); err != nil {
+		return SettingVersion{}, err
+	}
+
+	q := repository.New(tx)
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	for _, a := range imp.DefaultAllowances {
+		err := q.UpsertDefaultAllowance(ctx, repository.UpsertDefaultAllowanceParams{AllowanceType: a.AllowanceType, Amount: a.Amount})
+		if err != nil {
+			return SettingVersion{}, err
+		}
+
+		_, err = upsertEffectiveAllowance(ctx, tx, EffectiveAllowance{
+			AllowanceKind: AllowanceKindDefault,
+			AllowanceType: a.AllowanceType,
+			Amount:        a.Amount,
+			EffectiveFrom: today,
+		})
+		if err != nil {
+			return SettingVersion{}, err
+		}
+	}
+
+	for _, a := range imp.AllowedAllowances {
+		err := q.UpsertAllowedAllowance(ctx, repository.UpsertAllowedAllowanceParams{AllowanceType: a.AllowanceType, MaxAmount: a.MaxAmount})
+		if err != nil {
+			return SettingVersion{}, err
+		}
+
+		_, err = upsertEffectiveAllowance(ctx, tx, EffectiveAllowance{
+			AllowanceKind: AllowanceKindAllowed,
+			AllowanceType: a.AllowanceType,
+			Amount:        a.MaxAmount,
+			EffectiveFrom: today,
+		})
+		if err != nil {
+			return SettingVersion{}, err
+		}
+	}
+
+	for taxYear, brackets := range imp.Brackets {
+		if err := q.DeleteTaxBrackets(ctx, int32(taxYear)); err != nil {
+			return SettingVersion{}, err
+		}
+
+		if err := insertTaxBrackets(ctx, tx, taxYear, brackets); err != nil {
+			return SettingVersion{}, err
+		}
+	}
+
+	v, err := recordSettingVersion(ctx, tx)
+	if err != nil {
+		return SettingVersion{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return SettingVersion{}, err
+	}
+
+	return v, nil
+}
+
+type SettingsImport struct {
+	DefaultAllowances []DefaultAllowance
+	AllowedAllowances []AllowedAllowance
+	Brackets          map[int][]TaxBracket // brackets by tax year
+}
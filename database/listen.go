@@ -0,0 +1,61 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// channel notified on commit of every settings write
+const settingsChannel = "settings_changed"
+
+// notifySettingsChanged tells replicas listening on settingsChannel to drop cached settings,
+// in a transaction the notification is sent only when it commits
+func notifySettingsChanged(ctx context.Context, e execer) error {
+	_, err := e.Exec(ctx, `SELECT pg_notify($1, '')`, settingsChannel)
+	return err
+}
+
+// ListenSettingsChanged calls onChange whenever any replica changes settings until ctx is done,
+// it reconnects after errors and calls onChange once listening since notifications may be missed meanwhile
+func (db *DB) ListenSettingsChanged(ctx context.Context, onChange func()) {
+	for {
+		err := db.listenSettingsChanged(ctx, onChange)
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.ErrorContext(ctx, "stopped listening to settings changes, reconnecting", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (db *DB) listenSettingsChanged(ctx context.Context, onChange func()) error {
+	pooled, err := db.getPool().Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the connection keeps listening, so it's taken out of the pool and closed afterwards
+	conn := pooled.Hijack()
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+settingsChannel); err != nil {
+		return err
+	}
+
+	onChange()
+
+	for {
+		if _, err := conn.WaitForNotification(ctx); err != nil {
+			return err
+		}
+
+		onChange()
+	}
+}
@@ -0,0 +1,1876 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Memory keeps data in process for local development and tests without postgres,
+// it starts with the reference data of initialdata/init.sql and loses everything on restart
+type Memory struct {
+	mu  sync.Mutex
+	now func() time.Time
+
+	lastID map[string]int // last serial id by table
+
+	defaultAllowances map[string]float64
+	allowedAllowances map[string]float64
+	allowanceRecords  map[[2]string]memoryRecord // by kind and type of allowance
+	bounds            map[string]SettingBound
+	aliases           map[string]AllowanceAlias // by alias
+	effective         []EffectiveAllowance
+	brackets          map[int][]TaxBracket
+	calendars         map[int]TaxCalendar
+	scheduledChanges  []ScheduledChange
+	drafts            []SettingDraft
+	versions          []SettingVersion
+	settingHistory    []SettingHistory
+	webhooks          []Webhook
+	apiKeys           []memoryAPIKey
+	usage             map[usageKey]APIKeyUsage
+	calculations      []Calculation
+	adminUsers        []AdminUser
+	sessions          []AdminSession
+	tenants           []Tenant
+	users             []User
+	profiles          map[int]UserProfile // by user id
+	calculationDrafts []CalculationDraft
+	tenantSettings    map[int]SettingsImport // overrides by tenant id
+	batchJobs         []BatchJob
+}
+
+// memoryRecord is the surrogate key and timestamps of an allowance, other types keep them in their fields
+type memoryRecord struct {
+	uuid       string
+	createdAt  time.Time
+	updatedAt  time.Time
+	disabledAt *time.Time
+}
+
+type memoryAPIKey struct {
+	APIKey
+	hash string
+}
+
+type usageKey struct {
+	keyID  int
+	period time.Time
+}
+
+func NewMemory() *Memory {
+	m := &Memory{
+		now:    time.Now,
+		lastID: map[string]int{},
+		defaultAllowances: map[string]float64{
+			"personal": 60_000,
+		},
+		allowedAllowances: map[string]float64{
+			"donation":  100_000,
+			"k-receipt": 50_000,
+		},
+		bounds: map[string]SettingBound{
+			"personal":  {Setting: "personal", MinAmount: 10_000, MaxAmount: 100_000},
+			"k-receipt": {Setting: "k-receipt", MinAmount: 0, MaxAmount: 100_000},
+			"donation":  {Setting: "donation", MinAmount: 0, MaxAmount: 200_000},
+		},
+		allowanceRecords: map[[2]string]memoryRecord{},
+		aliases:          map[string]AllowanceAlias{},
+		brackets:         map[int][]TaxBracket{},
+		calendars:        map[int]TaxCalendar{},
+		usage:            map[usageKey]APIKeyUsage{},
+		tenantSettings:   map[int]SettingsImport{},
+		profiles:         map[int]UserProfile{},
+	}
+
+	for t := range m.defaultAllowances {
+		m.touchAllowance(AllowanceKindDefault, t)
+	}
+
+	for t := range m.allowedAllowances {
+		m.touchAllowance(AllowanceKindAllowed, t)
+	}
+
+	m.recordSettingVersion()
+
+	return m
+}
+
+func (m *Memory) nextID(table string) int {
+	m.lastID[table]++
+	return m.lastID[table]
+}
+
+// touchAllowance sets updated time of allowance, its key and created time are set when it's new
+func (m *Memory) touchAllowance(kind string, allowanceType string) memoryRecord {
+	key := [2]string{kind, allowanceType}
+	now := m.now()
+
+	r, ok := m.allowanceRecords[key]
+	if !ok {
+		r = memoryRecord{uuid: uuid.NewString(), createdAt: now}
+	}
+
+	r.updatedAt = now
+	m.allowanceRecords[key] = r
+
+	return r
+}
+
+func (m *Memory) defaultAllowance(allowanceType string) DefaultAllowance {
+	r := m.allowanceRecords[[2]string{AllowanceKindDefault, allowanceType}]
+
+	return DefaultAllowance{
+		AllowanceType: allowanceType,
+		Amount:        m.defaultAllowances[allowanceType],
+		UUID:          r.uuid,
+		CreatedAt:     r.createdAt,
+		UpdatedAt:     r.updatedAt,
+		DisabledAt:    clonePtr(r.disabledAt),
+	}
+}
+
+func (m *Memory) allowedAllowance(allowanceType string) AllowedAllowance {
+	r := m.allowanceRecords[[2]string{AllowanceKindAllowed, allowanceType}]
+
+	return AllowedAllowance{
+		AllowanceType: allowanceType,
+		MaxAmount:     m.allowedAllowances[allowanceType],
+		UUID:          r.uuid,
+		CreatedAt:     r.createdAt,
+		UpdatedAt:     r.updatedAt,
+		DisabledAt:    clonePtr(r.disabledAt),
+	}
+}
+
+// setAllowanceDisabled disables or enables allowance, it returns false when nothing changes
+func (m *Memory) setAllowanceDisabled(kind string, allowanceType string, disabled bool) bool {
+	key := [2]string{kind, allowanceType}
+
+	r, ok := m.allowanceRecords[key]
+	if !ok || (r.disabledAt != nil) == disabled {
+		return false
+	}
+
+	r = m.touchAllowance(kind, allowanceType)
+	r.disabledAt = nil
+
+	if disabled {
+		r.disabledAt = &r.updatedAt
+	}
+
+	m.allowanceRecords[key] = r
+
+	return true
+}
+
+func truncateDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+func (m *Memory) FindAllDefaultAllowances(ctx context.Context) ([]DefaultAllowance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var results []DefaultAllowance
+
+	for _, t := range sortedKeys(m.defaultAllowances) {
+		results = append(results, m.defaultAllowance(t))
+	}
+
+	return results, nil
+}
+
+func (m *Memory) UpdateAmountDefaultAllowances(ctx context.Context, allowanceType string, amount float64) (DefaultAllowance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkExpectedVersion(ctx); err != nil {
+		return DefaultAllowance{}, err
+	}
+
+	if _, ok := m.defaultAllowances[allowanceType]; !ok {
+		return DefaultAllowance{}, sql.ErrNoRows
+	}
+
+	m.applyAllowance(AllowanceKindDefault, allowanceType, amount, m.now())
+	m.recordSettingVersion()
+
+	return m.defaultAllowance(allowanceType), nil
+}
+
+func (m *Memory) FindAllAllowedAllowances(ctx context.Context) ([]AllowedAllowance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var results []AllowedAllowance
+
+	for _, t := range sortedKeys(m.allowedAllowances) {
+		results = append(results, m.allowedAllowance(t))
+	}
+
+	return results, nil
+}
+
+func (m *Memory) UpdateAmountAllowedAllowances(ctx context.Context, allowanceType string, amount float64) (AllowedAllowance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkExpectedVersion(ctx); err != nil {
+		return AllowedAllowance{}, err
+	}
+
+	if _, ok := m.allowedAllowances[allowanceType]; !ok {
+		return AllowedAllowance{}, sql.ErrNoRows
+	}
+
+	m.applyAllowance(AllowanceKindAllowed, allowanceType, amount, m.now())
+	m.recordSettingVersion()
+
+	return m.allowedAllowance(allowanceType), nil
+}
+
+func (m *Memory) DisableAllowanceType(ctx context.Context, allowanceType string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkExpectedVersion(ctx); err != nil {
+		return err
+	}
+
+	defaultDisabled := m.setAllowanceDisabled(AllowanceKindDefault, allowanceType, true)
+	allowedDisabled := m.setAllowanceDisabled(AllowanceKindAllowed, allowanceType, true)
+
+	if !defaultDisabled && !allowedDisabled {
+		return ErrNotFound
+	}
+
+	m.recordSettingVersion()
+
+	return nil
+}
+
+func (m *Memory) FindSettingBound(ctx context.Context, setting string) (SettingBound, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.bounds[setting]
+	if !ok {
+		return SettingBound{}, ErrNotFound
+	}
+
+	return b, nil
+}
+
+func (m *Memory) FindAllowanceAliases(ctx context.Context) ([]AllowanceAlias, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var results []AllowanceAlias
+
+	for _, alias := range sortedKeys(m.aliases) {
+		results = append(results, m.aliases[alias])
+	}
+
+	return results, nil
+}
+
+func (m *Memory) UpsertAllowanceAlias(ctx context.Context, alias string, allowanceType string) (AllowanceAlias, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkExpectedVersion(ctx); err != nil {
+		return AllowanceAlias{}, err
+	}
+
+	a := AllowanceAlias{Alias: alias, AllowanceType: allowanceType, CreatedAt: m.now(), UpdatedAt: m.now()}
+	if existing, ok := m.aliases[alias]; ok {
+		a.CreatedAt = existing.CreatedAt
+	}
+
+	m.aliases[alias] = a
+	m.recordSettingVersion()
+
+	return a, nil
+}
+
+func (m *Memory) DeleteAllowanceAlias(ctx context.Context, alias string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkExpectedVersion(ctx); err != nil {
+		return err
+	}
+
+	if _, ok := m.aliases[alias]; !ok {
+		return ErrNotFound
+	}
+
+	delete(m.aliases, alias)
+	m.recordSettingVersion()
+
+	return nil
+}
+
+func (m *Memory) FindEffectiveAllowances(ctx context.Context, at time.Time) ([]EffectiveAllowance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return effectiveOn(m.effective, at), nil
+}
+
+func (m *Memory) FindAllEffectiveAllowances(ctx context.Context) ([]EffectiveAllowance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	results := slices.Clone(m.effective)
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+
+		if !a.EffectiveFrom.Equal(b.EffectiveFrom) {
+			return a.EffectiveFrom.Before(b.EffectiveFrom)
+		}
+
+		if a.AllowanceKind != b.AllowanceKind {
+			return a.AllowanceKind < b.AllowanceKind
+		}
+
+		return a.AllowanceType < b.AllowanceType
+	})
+
+	return results, nil
+}
+
+func (m *Memory) UpsertEffectiveAllowance(ctx context.Context, a EffectiveAllowance) (EffectiveAllowance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkExpectedVersion(ctx); err != nil {
+		return EffectiveAllowance{}, err
+	}
+
+	a = m.upsertEffectiveAllowance(a)
+	m.recordSettingVersion()
+
+	return a, nil
+}
+
+func (m *Memory) upsertEffectiveAllowance(a EffectiveAllowance) EffectiveAllowance {
+	for i, e := range m.effective {
+		if e.AllowanceKind == a.AllowanceKind && e.AllowanceType == a.AllowanceType && e.EffectiveFrom.Equal(a.EffectiveFrom) {
+			m.effective[i].Amount = a.Amount
+			return m.effective[i]
+		}
+	}
+
+	m.effective = append(m.effective, a)
+
+	return a
+}
+
+// checkAllowance returns the same error as applyAllowance of DB when allowance doesn't exist
+func (m *Memory) checkAllowance(kind string, allowanceType string) error {
+	var ok bool
+
+	switch kind {
+	case AllowanceKindDefault:
+		_, ok = m.defaultAllowances[allowanceType]
+	case AllowanceKindAllowed:
+		_, ok = m.allowedAllowances[allowanceType]
+	default:
+		return fmt.Errorf("unknown allowance kind %q", kind)
+	}
+
+	if !ok {
+		return fmt.Errorf("allowance %q: %w", allowanceType, ErrNotFound)
+	}
+
+	return nil
+}
+
+// applyAllowance sets amount of allowance which must be checked with checkAllowance first
+func (m *Memory) applyAllowance(kind string, allowanceType string, amount float64, effectiveFrom time.Time) {
+	if kind == AllowanceKindDefault {
+		m.defaultAllowances[allowanceType] = amount
+	} else {
+		m.allowedAllowances[allowanceType] = amount
+	}
+
+	m.touchAllowance(kind, allowanceType)
+
+	m.upsertEffectiveAllowance(EffectiveAllowance{
+		AllowanceKind: kind,
+		AllowanceType: allowanceType,
+		Amount:        amount,
+		EffectiveFrom: truncateDay(effectiveFrom),
+	})
+}
+
+// checkExpectedVersion is lockSettings of DB, writes are already serialized by the mutex
+func (m *Memory) checkExpectedVersion(ctx context.Context) error {
+	expected, ok := ExpectedVersion(ctx)
+	if ok && expected != m.versions[len(m.versions)-1].Version {
+		return ErrConflict
+	}
+
+	return nil
+}
+
+func (m *Memory) recordSettingVersion() SettingVersion {
+	v := SettingVersion{
+		Version:            m.nextID("setting_versions"),
+		DefaultAllowances:  maps.Clone(m.defaultAllowances),
+		AllowedAllowances:  maps.Clone(m.allowedAllowances),
+		Brackets:           cloneBrackets(m.brackets),
+		DisabledAllowances: map[string][]string{AllowanceKindDefault: {}, AllowanceKindAllowed: {}},
+		CreatedAt:          m.now(),
+	}
+
+	for kind, amounts := range map[string]map[string]float64{AllowanceKindDefault: m.defaultAllowances, AllowanceKindAllowed: m.allowedAllowances} {
+		for _, allowanceType := range sortedKeys(amounts) {
+			if m.allowanceRecords[[2]string{kind, allowanceType}].disabledAt != nil {
+				v.DisabledAllowances[kind] = append(v.DisabledAllowances[kind], allowanceType)
+			}
+		}
+	}
+
+	m.versions = append(m.versions, v)
+	m.syncSettingHistory()
+
+	return v
+}
+
+// syncSettingHistory closes history of changed settings and opens history of their current values like DB
+func (m *Memory) syncSettingHistory() {
+	type value struct {
+		amount     *float64
+		percentage *float64
+	}
+
+	current := map[[2]string]value{}
+
+	for t, amount := range m.defaultAllowances {
+		current[[2]string{AllowanceKindDefault, t}] = value{amount: &amount}
+	}
+
+	for t, amount := range m.allowedAllowances {
+		current[[2]string{AllowanceKindAllowed, t}] = value{amount: &amount}
+	}
+
+	for _, brackets := range m.brackets {
+		for _, b := range brackets {
+			name := fmt.Sprintf("%d/%d", b.TaxYear, b.Level)
+			current[[2]string{SettingKindBracket, name}] = value{amount: b.MaxAmount, percentage: &b.Percentage}
+		}
+	}
+
+	now := m.now()
+	open := map[[2]string]bool{}
+
+	for i, h := range m.settingHistory {
+		if h.ValidTo != nil {
+			continue
+		}
+
+		key := [2]string{h.Kind, h.Name}
+
+		v, ok := current[key]
+		if ok && equalFloat(v.amount, h.Amount) && equalFloat(v.percentage, h.Percentage) {
+			open[key] = true
+			continue
+		}
+
+		m.settingHistory[i].ValidTo = &now
+	}
+
+	keys := make([][2]string, 0, len(current))
+	for key := range current {
+		keys = append(keys, key)
+	}
+
+	slices.SortFunc(keys, func(a, b [2]string) int {
+		return strings.Compare(a[0]+"/"+a[1], b[0]+"/"+b[1])
+	})
+
+	for _, key := range keys {
+		if open[key] {
+			continue
+		}
+
+		v := current[key]
+		m.settingHistory = append(m.settingHistory, SettingHistory{
+			ID:         m.nextID("setting_history"),
+			Kind:       key[0],
+			Name:       key[1],
+			Amount:     clonePtr(v.amount),
+			Percentage: clonePtr(v.percentage),
+			ValidFrom:  now,
+		})
+	}
+}
+
+func equalFloat(a *float64, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+
+	v := *p
+	return &v
+}
+
+func (m *Memory) FindSettingHistory(ctx context.Context, at time.Time) ([]SettingHistory, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var results []SettingHistory
+
+	for _, h := range m.settingHistory {
+		if h.ValidFrom.After(at) || (h.ValidTo != nil && !h.ValidTo.After(at)) {
+			continue
+		}
+
+		results = append(results, h)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Kind != results[j].Kind {
+			return results[i].Kind < results[j].Kind
+		}
+
+		return results[i].Name < results[j].Name
+	})
+
+	return results, nil
+}
+
+func (m *Memory) CreateScheduledChange(ctx context.Context, kind string, allowanceType string, amount float64, activateAt time.Time) (ScheduledChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := ScheduledChange{
+		ID:            m.nextID("scheduled_changes"),
+		AllowanceKind: kind,
+		AllowanceType: allowanceType,
+		Amount:        amount,
+		ActivateAt:    activateAt,
+		UUID:          uuid.NewString(),
+		CreatedAt:     m.now(),
+		UpdatedAt:     m.now(),
+	}
+
+	m.scheduledChanges = append(m.scheduledChanges, s)
+
+	return s, nil
+}
+
+func (m *Memory) FindPendingScheduledChanges(ctx context.Context) ([]ScheduledChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.pendingScheduledChanges(func(ScheduledChange) bool { return true }), nil
+}
+
+func (m *Memory) pendingScheduledChanges(match func(ScheduledChange) bool) []ScheduledChange {
+	var results []ScheduledChange
+
+	for _, s := range m.scheduledChanges {
+		if s.AppliedAt == nil && s.CancelledAt == nil && match(s) {
+			results = append(results, s)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].ActivateAt.Before(results[j].ActivateAt)
+	})
+
+	return results
+}
+
+func (m *Memory) CancelScheduledChange(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, s := range m.scheduledChanges {
+		if s.ID == id && s.AppliedAt == nil && s.CancelledAt == nil {
+			now := m.now()
+			m.scheduledChanges[i].CancelledAt = &now
+			m.scheduledChanges[i].UpdatedAt = now
+			return nil
+		}
+	}
+
+	return ErrNotFound
+}
+
+func (m *Memory) ApplyDueScheduledChanges(ctx context.Context, now time.Time) ([]ScheduledChange, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	changes := m.pendingScheduledChanges(func(s ScheduledChange) bool { return !s.ActivateAt.After(now) })
+
+	for _, s := range changes {
+		if err := m.checkAllowance(s.AllowanceKind, s.AllowanceType); err != nil {
+			return nil, fmt.Errorf("scheduled change %d: %w", s.ID, err)
+		}
+	}
+
+	appliedAt := m.now()
+
+	for i, s := range changes {
+		m.applyAllowance(s.AllowanceKind, s.AllowanceType, s.Amount, s.ActivateAt)
+
+		changes[i].AppliedAt = &appliedAt
+		changes[i].UpdatedAt = appliedAt
+		m.replaceScheduledChange(changes[i])
+	}
+
+	if len(changes) > 0 {
+		m.recordSettingVersion()
+	}
+
+	return changes, nil
+}
+
+func (m *Memory) replaceScheduledChange(s ScheduledChange) {
+	for i := range m.scheduledChanges {
+		if m.scheduledChanges[i].ID == s.ID {
+			m.scheduledChanges[i] = s
+		}
+	}
+}
+
+func (m *Memory) CreateSettingDraft(ctx context.Context, kind string, allowanceType string, amount float64, createdBy string) (SettingDraft, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d := SettingDraft{
+		ID:            m.nextID("setting_drafts"),
+		AllowanceKind: kind,
+		AllowanceType: allowanceType,
+		Amount:        amount,
+		CreatedBy:     createdBy,
+		UUID:          uuid.NewString(),
+		CreatedAt:     m.now(),
+		UpdatedAt:     m.now(),
+	}
+
+	m.drafts = append(m.drafts, d)
+
+	return d, nil
+}
+
+func (m *Memory) FindSettingDraft(ctx context.Context, id int) (SettingDraft, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.pendingDraftIndex(id)
+	if i < 0 {
+		return SettingDraft{}, ErrNotFound
+	}
+
+	return m.drafts[i], nil
+}
+
+func (m *Memory) pendingDraftIndex(id int) int {
+	return slices.IndexFunc(m.drafts, func(d SettingDraft) bool {
+		return d.ID == id && d.PublishedAt == nil && d.DiscardedAt == nil
+	})
+}
+
+func (m *Memory) FindPendingSettingDrafts(ctx context.Context) ([]SettingDraft, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var results []SettingDraft
+
+	for _, d := range m.drafts {
+		if d.PublishedAt == nil && d.DiscardedAt == nil {
+			results = append(results, d)
+		}
+	}
+
+	return results, nil
+}
+
+func (m *Memory) PublishSettingDraft(ctx context.Context, id int, publishedBy string) (SettingDraft, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkExpectedVersion(ctx); err != nil {
+		return SettingDraft{}, err
+	}
+
+	i := m.pendingDraftIndex(id)
+	if i < 0 {
+		return SettingDraft{}, ErrNotFound
+	}
+
+	d := m.drafts[i]
+	if err := m.checkAllowance(d.AllowanceKind, d.AllowanceType); err != nil {
+		return SettingDraft{}, err
+	}
+
+	now := m.now()
+	d.PublishedBy = &publishedBy
+	d.PublishedAt = &now
+	d.UpdatedAt = now
+	m.drafts[i] = d
+
+	m.applyAllowance(d.AllowanceKind, d.AllowanceType, d.Amount, now)
+	m.recordSettingVersion()
+
+	return d, nil
+}
+
+func (m *Memory) DiscardSettingDraft(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.pendingDraftIndex(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	now := m.now()
+	m.drafts[i].DiscardedAt = &now
+	m.drafts[i].UpdatedAt = now
+
+	return nil
+}
+
+func (m *Memory) FindAllSettingVersions(ctx context.Context) ([]SettingVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var results []SettingVersion
+
+	for i := len(m.versions) - 1; i >= 0; i-- {
+		results = append(results, cloneSettingVersion(m.versions[i]))
+	}
+
+	return results, nil
+}
+
+func (m *Memory) LatestSettingVersion(ctx context.Context) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.versions) == 0 {
+		return 0, nil
+	}
+
+	return m.versions[len(m.versions)-1].Version, nil
+}
+
+func cloneSettingVersion(v SettingVersion) SettingVersion {
+	v.DefaultAllowances = maps.Clone(v.DefaultAllowances)
+	v.AllowedAllowances = maps.Clone(v.AllowedAllowances)
+	v.Brackets = cloneBrackets(v.Brackets)
+
+	if v.DisabledAllowances != nil {
+		disabled := make(map[string][]string, len(v.DisabledAllowances))
+
+		for kind, types := range v.DisabledAllowances {
+			disabled[kind] = slices.Clone(types)
+		}
+
+		v.DisabledAllowances = disabled
+	}
+
+	return v
+}
+
+func cloneBrackets(brackets map[int][]TaxBracket) map[int][]TaxBracket {
+	if brackets == nil {
+		return nil
+	}
+
+	clone := make(map[int][]TaxBracket, len(brackets))
+
+	for taxYear, b := range brackets {
+		clone[taxYear] = slices.Clone(b)
+	}
+
+	return clone
+}
+
+func (m *Memory) RollbackSettings(ctx context.Context, version int) (SettingVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkExpectedVersion(ctx); err != nil {
+		return SettingVersion{}, err
+	}
+
+	i := slices.IndexFunc(m.versions, func(v SettingVersion) bool { return v.Version == version })
+	if i < 0 {
+		return SettingVersion{}, ErrNotFound
+	}
+
+	target := m.versions[i]
+
+	// like DB, types which aren't in the version are removed and missing types are added by applyAllowance
+	m.removeAllowanceTypes(AllowanceKindDefault, m.defaultAllowances, target.DefaultAllowances)
+	m.removeAllowanceTypes(AllowanceKindAllowed, m.allowedAllowances, target.AllowedAllowances)
+
+	now := m.now()
+
+	for allowanceType, amount := range target.DefaultAllowances {
+		m.applyAllowance(AllowanceKindDefault, allowanceType, amount, now)
+	}
+
+	for allowanceType, amount := range target.AllowedAllowances {
+		m.applyAllowance(AllowanceKindAllowed, allowanceType, amount, now)
+	}
+
+	if target.DisabledAllowances != nil {
+		for key := range m.allowanceRecords {
+			m.setAllowanceDisabled(key[0], key[1], slices.Contains(target.DisabledAllowances[key[0]], key[1]))
+		}
+	}
+
+	if target.Brackets != nil {
+		m.brackets = cloneBrackets(target.Brackets)
+	}
+
+	return cloneSettingVersion(m.recordSettingVersion()), nil
+}
+
+// removeAllowanceTypes deletes types of kind in current which aren't in kept with their records and effective values
+func (m *Memory) removeAllowanceTypes(kind string, current map[string]float64, kept map[string]float64) {
+	for allowanceType := range current {
+		if _, ok := kept[allowanceType]; ok {
+			continue
+		}
+
+		delete(current, allowanceType)
+		delete(m.allowanceRecords, [2]string{kind, allowanceType})
+
+		m.effective = slices.DeleteFunc(m.effective, func(e EffectiveAllowance) bool {
+			return e.AllowanceKind == kind && e.AllowanceType == allowanceType
+		})
+	}
+}
+
+func (m *Memory) ImportSettings(ctx context.Context, imp SettingsImport) (SettingVersion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkExpectedVersion(ctx); err != nil {
+		return SettingVersion{}, err
+	}
+
+	now := m.now()
+
+	for _, a := range imp.DefaultAllowances {
+		m.applyAllowance(AllowanceKindDefault, a.AllowanceType, a.Amount, now)
+		m.setAllowanceDisabled(AllowanceKindDefault, a.AllowanceType, false)
+	}
+
+	for _, a := range imp.AllowedAllowances {
+		m.applyAllowance(AllowanceKindAllowed, a.AllowanceType, a.MaxAmount, now)
+		m.setAllowanceDisabled(AllowanceKindAllowed, a.AllowanceType, false)
+	}
+
+	for taxYear, brackets := range imp.Brackets {
+		m.brackets[taxYear] = numberBrackets(taxYear, brackets)
+	}
+
+	return cloneSettingVersion(m.recordSettingVersion()), nil
+}
+
+// numberBrackets sets tax year and level of brackets like rows stored by DB
+func numberBrackets(taxYear int, brackets []TaxBracket) []TaxBracket {
+	results := slices.Clone(brackets)
+
+	for i := range results {
+		results[i].TaxYear = taxYear
+		results[i].Level = i + 1
+	}
+
+	return results
+}
+
+func (m *Memory) SeedSettings(ctx context.Context, s SettingsImport) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	inserted := 0
+
+	for _, a := range s.DefaultAllowances {
+		if _, ok := m.defaultAllowances[a.AllowanceType]; !ok {
+			m.defaultAllowances[a.AllowanceType] = a.Amount
+			m.touchAllowance(AllowanceKindDefault, a.AllowanceType)
+			inserted++
+		}
+	}
+
+	for _, a := range s.AllowedAllowances {
+		if _, ok := m.allowedAllowances[a.AllowanceType]; !ok {
+			m.allowedAllowances[a.AllowanceType] = a.MaxAmount
+			m.touchAllowance(AllowanceKindAllowed, a.AllowanceType)
+			inserted++
+		}
+	}
+
+	for taxYear, brackets := range s.Brackets {
+		existing := m.brackets[taxYear]
+
+		for _, b := range numberBrackets(taxYear, brackets) {
+			if b.Level > len(existing) {
+				existing = append(existing, b)
+				inserted++
+			}
+		}
+
+		m.brackets[taxYear] = existing
+	}
+
+	if inserted > 0 {
+		m.recordSettingVersion()
+	}
+
+	return inserted, nil
+}
+
+func (m *Memory) FindTaxBrackets(ctx context.Context, taxYear int) ([]TaxBracket, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.brackets[taxYear]), nil
+}
+
+func (m *Memory) FindAllTaxCalendars(ctx context.Context) ([]TaxCalendar, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var results []TaxCalendar
+
+	for _, taxYear := range sortedKeys(m.calendars) {
+		results = append(results, m.calendars[taxYear])
+	}
+
+	return results, nil
+}
+
+func (m *Memory) FindTaxCalendar(ctx context.Context, taxYear int) (TaxCalendar, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cal, ok := m.calendars[taxYear]
+	if !ok {
+		return TaxCalendar{}, ErrNotFound
+	}
+
+	cal.AllowanceWindows = slices.Clone(cal.AllowanceWindows)
+
+	return cal, nil
+}
+
+// FindUpcomingTaxCalendar returns sql.ErrNoRows when there is no upcoming calendar like DB
+func (m *Memory) FindUpcomingTaxCalendar(ctx context.Context, from time.Time) (TaxCalendar, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var (
+		upcoming TaxCalendar
+		found    bool
+	)
+
+	for _, cal := range m.calendars {
+		if cal.FilingDeadline.Before(from) {
+			continue
+		}
+
+		if !found || cal.FilingDeadline.Before(upcoming.FilingDeadline) {
+			upcoming, found = cal, true
+		}
+	}
+
+	if !found {
+		return TaxCalendar{}, sql.ErrNoRows
+	}
+
+	upcoming.AllowanceWindows = nil
+
+	return upcoming, nil
+}
+
+func (m *Memory) UpsertTaxCalendar(ctx context.Context, cal TaxCalendar) (TaxCalendar, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	stored := cal
+	stored.AllowanceWindows = slices.Clone(cal.AllowanceWindows)
+	stored.UUID, stored.CreatedAt, stored.UpdatedAt = uuid.NewString(), now, now
+
+	if existing, ok := m.calendars[cal.TaxYear]; ok {
+		stored.UUID, stored.CreatedAt = existing.UUID, existing.CreatedAt
+	}
+
+	sort.Slice(stored.AllowanceWindows, func(i, j int) bool {
+		return stored.AllowanceWindows[i].AllowanceType < stored.AllowanceWindows[j].AllowanceType
+	})
+
+	m.calendars[cal.TaxYear] = stored
+
+	cal.UUID, cal.CreatedAt, cal.UpdatedAt = stored.UUID, stored.CreatedAt, stored.UpdatedAt
+
+	return cal, nil
+}
+
+func (m *Memory) DeleteTaxCalendar(ctx context.Context, taxYear int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.calendars[taxYear]; !ok {
+		return ErrNotFound
+	}
+
+	delete(m.calendars, taxYear)
+
+	return nil
+}
+
+func (m *Memory) FindAllWebhooks(ctx context.Context) ([]Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.webhooks), nil
+}
+
+func (m *Memory) CreateWebhook(ctx context.Context, url string, secret string) (Webhook, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w := Webhook{ID: m.nextID("webhooks"), URL: url, Secret: secret, UUID: uuid.NewString(), CreatedAt: m.now(), UpdatedAt: m.now()}
+	m.webhooks = append(m.webhooks, w)
+
+	return w, nil
+}
+
+func (m *Memory) DeleteWebhook(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.webhooks, func(w Webhook) bool { return w.ID == id })
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	m.webhooks = slices.Delete(m.webhooks, i, i+1)
+
+	return nil
+}
+
+func (m *Memory) CreateAPIKey(ctx context.Context, name string, prefix string, hash string, scopes []string) (APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	k := memoryAPIKey{
+		APIKey: APIKey{
+			ID:        m.nextID("api_keys"),
+			Name:      name,
+			Prefix:    prefix,
+			Scopes:    slices.Clone(scopes),
+			UUID:      uuid.NewString(),
+			CreatedAt: m.now(),
+			UpdatedAt: m.now(),
+		},
+		hash: hash,
+	}
+
+	m.apiKeys = append(m.apiKeys, k)
+
+	return cloneAPIKey(k.APIKey), nil
+}
+
+func cloneAPIKey(k APIKey) APIKey {
+	k.Scopes = slices.Clone(k.Scopes)
+	return k
+}
+
+func (m *Memory) FindAllAPIKeys(ctx context.Context) ([]APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var results []APIKey
+
+	for _, k := range m.apiKeys {
+		results = append(results, cloneAPIKey(k.APIKey))
+	}
+
+	return results, nil
+}
+
+func (m *Memory) FindActiveAPIKeyByHash(ctx context.Context, hash string) (APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range m.apiKeys {
+		if k.hash == hash && k.RevokedAt == nil {
+			return cloneAPIKey(k.APIKey), nil
+		}
+	}
+
+	return APIKey{}, ErrNotFound
+}
+
+// updateActiveAPIKey applies update to key which isn't revoked
+func (m *Memory) updateActiveAPIKey(id int, update func(k *APIKey)) (APIKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range m.apiKeys {
+		if m.apiKeys[i].ID == id && m.apiKeys[i].RevokedAt == nil {
+			update(&m.apiKeys[i].APIKey)
+			m.apiKeys[i].UpdatedAt = m.now()
+			return cloneAPIKey(m.apiKeys[i].APIKey), nil
+		}
+	}
+
+	return APIKey{}, ErrNotFound
+}
+
+func (m *Memory) UpdateAPIKeyScopes(ctx context.Context, id int, scopes []string) (APIKey, error) {
+	return m.updateActiveAPIKey(id, func(k *APIKey) { k.Scopes = slices.Clone(scopes) })
+}
+
+func (m *Memory) UpdateAPIKeyQuota(ctx context.Context, id int, quota *int) (APIKey, error) {
+	return m.updateActiveAPIKey(id, func(k *APIKey) { k.MonthlyQuota = quota })
+}
+
+func (m *Memory) UpdateAPIKeySigningSecret(ctx context.Context, id int, secret *string) (APIKey, error) {
+	return m.updateActiveAPIKey(id, func(k *APIKey) { k.SigningSecret = secret })
+}
+
+func (m *Memory) UpdateAPIKeyTenant(ctx context.Context, id int, tenantID *int) (APIKey, error) {
+	m.mu.Lock()
+	exists := tenantID == nil || slices.ContainsFunc(m.tenants, func(t Tenant) bool { return t.ID == *tenantID })
+	m.mu.Unlock()
+
+	if !exists {
+		return APIKey{}, ErrNotFound
+	}
+
+	return m.updateActiveAPIKey(id, func(k *APIKey) { k.TenantID = tenantID })
+}
+
+func (m *Memory) RevokeAPIKey(ctx context.Context, id int) error {
+	_, err := m.updateActiveAPIKey(id, func(k *APIKey) {
+		now := m.now()
+		k.RevokedAt = &now
+	})
+
+	return err
+}
+
+func (m *Memory) RecordAPIKeyUsage(ctx context.Context, keyID int, period time.Time, requests int64, csvRows int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := usageKey{keyID: keyID, period: truncateDay(period)}
+
+	u := m.usage[key]
+	u.Requests += requests
+	u.CSVRows += csvRows
+	m.usage[key] = u
+
+	return nil
+}
+
+func (m *Memory) FindAPIKeyUsage(ctx context.Context, keyID int, period time.Time) (APIKeyUsage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u := m.usage[usageKey{keyID: keyID, period: truncateDay(period)}]
+
+	return APIKeyUsage{APIKeyID: keyID, Period: period, Requests: u.Requests, CSVRows: u.CSVRows}, nil
+}
+
+func (m *Memory) FindAllAPIKeyUsage(ctx context.Context, period time.Time) ([]APIKeyUsage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var results []APIKeyUsage
+
+	for _, k := range m.apiKeys {
+		u := m.usage[usageKey{keyID: k.ID, period: truncateDay(period)}]
+
+		results = append(results, APIKeyUsage{
+			APIKeyID:     k.ID,
+			Name:         k.Name,
+			MonthlyQuota: k.MonthlyQuota,
+			Period:       period,
+			Requests:     u.Requests,
+			CSVRows:      u.CSVRows,
+		})
+	}
+
+	return results, nil
+}
+
+func (m *Memory) InsertCalculations(ctx context.Context, calcs []Calculation) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range calcs {
+		c.ID = m.nextID("calculation_history")
+		c.Allowances = maps.Clone(c.Allowances)
+		c.APIKeyID = clonePtr(c.APIKeyID)
+		c.UserID = clonePtr(c.UserID)
+		m.calculations = append(m.calculations, c)
+	}
+
+	return nil
+}
+
+func (m *Memory) DeleteCalculations(ctx context.Context, apiKeyID int) (DeletionReceipt, error) {
+	return m.deleteCalculations(DeletionReceipt{APIKeyID: &apiKeyID}), nil
+}
+
+func (m *Memory) DeleteUserCalculations(ctx context.Context, userID int) (DeletionReceipt, error) {
+	return m.deleteCalculations(DeletionReceipt{UserID: &userID}), nil
+}
+
+// deleteCalculations erases calculations and batch jobs of the subject of r like DB
+func (m *Memory) deleteCalculations(r DeletionReceipt) DeletionReceipt {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	subject := func(apiKeyID *int, userID *int) bool {
+		return (apiKeyID != nil && r.APIKeyID != nil && *apiKeyID == *r.APIKeyID) ||
+			(userID != nil && r.UserID != nil && *userID == *r.UserID)
+	}
+
+	n := len(m.calculations) + len(m.batchJobs)
+	m.calculations = slices.DeleteFunc(m.calculations, func(c Calculation) bool { return subject(c.APIKeyID, c.UserID) })
+	m.batchJobs = slices.DeleteFunc(m.batchJobs, func(j BatchJob) bool { return subject(j.APIKeyID, j.UserID) })
+
+	r.ID = uuid.NewString()
+	r.DeletedRows = int64(n - len(m.calculations) - len(m.batchJobs))
+	r.DeletedAt = m.now()
+
+	return r
+}
+
+func (m *Memory) FindUserCalculations(ctx context.Context, userID int) ([]Calculation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var results []Calculation
+
+	// latest first like DB, calculations are appended in order of time
+	for i := len(m.calculations) - 1; i >= 0 && len(results) < maxUserCalculations; i-- {
+		c := m.calculations[i]
+		if c.UserID != nil && *c.UserID == userID {
+			c.Allowances = maps.Clone(c.Allowances)
+			results = append(results, c)
+		}
+	}
+
+	return results, nil
+}
+
+func (m *Memory) PurgeBefore(ctx context.Context, before time.Time) (map[string]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	deleted := map[string]int64{}
+
+	m.calculations = purge(m.calculations, deleted, "calculation_history", func(c Calculation) bool {
+		return c.CalculatedAt.Before(before)
+	})
+
+	m.scheduledChanges = purge(m.scheduledChanges, deleted, "scheduled_changes", func(s ScheduledChange) bool {
+		finishedAt := s.AppliedAt
+		if finishedAt == nil {
+			finishedAt = s.CancelledAt
+		}
+
+		return finishedAt != nil && finishedAt.Before(before)
+	})
+
+	m.sessions = purge(m.sessions, deleted, "admin_sessions", func(s AdminSession) bool {
+		endedAt := s.ExpiresAt
+		if s.RevokedAt != nil {
+			endedAt = *s.RevokedAt
+		}
+
+		return endedAt.Before(before)
+	})
+
+	m.batchJobs = purge(m.batchJobs, deleted, "batch_jobs", func(j BatchJob) bool {
+		return (j.Status == BatchJobDone || j.Status == BatchJobFailed) && j.UpdatedAt.Before(before)
+	})
+
+	return deleted, nil
+}
+
+// purge deletes values matched by expired and counts them as deleted rows of table
+func purge[T any](values []T, deleted map[string]int64, table string, expired func(T) bool) []T {
+	n := len(values)
+	values = slices.DeleteFunc(values, expired)
+	deleted[table] = int64(n - len(values))
+
+	return values
+}
+
+func (m *Memory) FindAllAdminUsers(ctx context.Context) ([]AdminUser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.adminUsers), nil
+}
+
+func (m *Memory) FindAdminUserByUsername(ctx context.Context, username string) (AdminUser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.adminUsers, func(u AdminUser) bool { return u.Username == username })
+	if i < 0 {
+		return AdminUser{}, ErrNotFound
+	}
+
+	return m.adminUsers[i], nil
+}
+
+func (m *Memory) CreateAdminUser(ctx context.Context, username string, passwordHash string, role string) (AdminUser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if slices.ContainsFunc(m.adminUsers, func(u AdminUser) bool { return u.Username == username }) {
+		return AdminUser{}, ErrAlreadyExists
+	}
+
+	u := AdminUser{
+		ID:           m.nextID("admin_users"),
+		Username:     username,
+		PasswordHash: passwordHash,
+		Role:         role,
+		UUID:         uuid.NewString(),
+		CreatedAt:    m.now(),
+		UpdatedAt:    m.now(),
+	}
+
+	m.adminUsers = append(m.adminUsers, u)
+
+	return u, nil
+}
+
+func (m *Memory) UpdateAdminUserRole(ctx context.Context, id int, role string) (AdminUser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.adminUsers, func(u AdminUser) bool { return u.ID == id })
+	if i < 0 {
+		return AdminUser{}, ErrNotFound
+	}
+
+	m.adminUsers[i].Role = role
+	m.adminUsers[i].UpdatedAt = m.now()
+	m.revokeAdminSessionsOf(m.adminUsers[i].Username)
+
+	return m.adminUsers[i], nil
+}
+
+func (m *Memory) UpdateAdminUserTOTP(ctx context.Context, username string, secret []byte, enabled bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.adminUsers, func(u AdminUser) bool { return u.Username == username })
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	m.adminUsers[i].TOTPSecret = slices.Clone(secret)
+	m.adminUsers[i].TOTPEnabled = enabled
+	m.adminUsers[i].UpdatedAt = m.now()
+
+	return nil
+}
+
+func (m *Memory) DeleteAdminUser(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.adminUsers, func(u AdminUser) bool { return u.ID == id })
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	m.revokeAdminSessionsOf(m.adminUsers[i].Username)
+	m.adminUsers = slices.Delete(m.adminUsers, i, i+1)
+
+	return nil
+}
+
+func (m *Memory) CreateAdminSession(ctx context.Context, id string, username string, role string, expiresAt time.Time) (AdminSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := AdminSession{ID: id, Username: username, Role: role, CreatedAt: m.now(), ExpiresAt: expiresAt}
+	m.sessions = append(m.sessions, s)
+
+	return s, nil
+}
+
+func (m *Memory) FindAdminSession(ctx context.Context, id string) (AdminSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.sessions, func(s AdminSession) bool { return s.ID == id })
+	if i < 0 {
+		return AdminSession{}, ErrNotFound
+	}
+
+	return m.sessions[i], nil
+}
+
+func (m *Memory) FindActiveAdminSessions(ctx context.Context) ([]AdminSession, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+
+	var results []AdminSession
+
+	for _, s := range m.sessions {
+		if s.RevokedAt == nil && s.ExpiresAt.After(now) {
+			results = append(results, s)
+		}
+	}
+
+	return results, nil
+}
+
+func (m *Memory) RevokeAdminSession(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.sessions, func(s AdminSession) bool { return s.ID == id && s.RevokedAt == nil })
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	now := m.now()
+	m.sessions[i].RevokedAt = &now
+
+	return nil
+}
+
+func (m *Memory) revokeAdminSessionsOf(username string) {
+	now := m.now()
+
+	for i, s := range m.sessions {
+		if s.Username == username && s.RevokedAt == nil {
+			m.sessions[i].RevokedAt = &now
+		}
+	}
+}
+
+func sortedKeys[K int | string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+func (m *Memory) FindAllTenants(ctx context.Context) ([]Tenant, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.tenants), nil
+}
+
+func (m *Memory) FindTenant(ctx context.Context, id int) (Tenant, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.tenants, func(t Tenant) bool { return t.ID == id })
+	if i < 0 {
+		return Tenant{}, ErrNotFound
+	}
+
+	return m.tenants[i], nil
+}
+
+func (m *Memory) CreateTenant(ctx context.Context, name string) (Tenant, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if slices.ContainsFunc(m.tenants, func(t Tenant) bool { return t.Name == name }) {
+		return Tenant{}, ErrAlreadyExists
+	}
+
+	t := Tenant{
+		ID:        m.nextID("tenants"),
+		Name:      name,
+		UUID:      uuid.NewString(),
+		CreatedAt: m.now(),
+		UpdatedAt: m.now(),
+	}
+
+	m.tenants = append(m.tenants, t)
+
+	return t, nil
+}
+
+func (m *Memory) DeleteTenant(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkExpectedVersion(ctx); err != nil {
+		return err
+	}
+
+	i := slices.IndexFunc(m.tenants, func(t Tenant) bool { return t.ID == id })
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	// revoked keys keep their tenant like rows of DB
+	if slices.ContainsFunc(m.apiKeys, func(k memoryAPIKey) bool { return k.TenantID != nil && *k.TenantID == id }) {
+		return ErrInUse
+	}
+
+	m.tenants = slices.Delete(m.tenants, i, i+1)
+	delete(m.tenantSettings, id)
+	m.recordSettingVersion()
+
+	return nil
+}
+
+func (m *Memory) FindTenantSettings(ctx context.Context, tenantID int) (SettingsImport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return cloneSettingsImport(m.tenantSettings[tenantID]), nil
+}
+
+func (m *Memory) ReplaceTenantSettings(ctx context.Context, tenantID int, s SettingsImport) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkExpectedVersion(ctx); err != nil {
+		return err
+	}
+
+	i := slices.IndexFunc(m.tenants, func(t Tenant) bool { return t.ID == tenantID })
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	replaced := SettingsImport{
+		DefaultAllowances: slices.Clone(s.DefaultAllowances),
+		AllowedAllowances: slices.Clone(s.AllowedAllowances),
+		Brackets:          map[int][]TaxBracket{},
+	}
+
+	for taxYear, brackets := range s.Brackets {
+		replaced.Brackets[taxYear] = numberBrackets(taxYear, brackets)
+	}
+
+	m.tenantSettings[tenantID] = replaced
+	m.tenants[i].UpdatedAt = m.now()
+	m.recordSettingVersion()
+
+	return nil
+}
+
+func cloneSettingsImport(s SettingsImport) SettingsImport {
+	clone := SettingsImport{
+		DefaultAllowances: slices.Clone(s.DefaultAllowances),
+		AllowedAllowances: slices.Clone(s.AllowedAllowances),
+		Brackets:          map[int][]TaxBracket{},
+	}
+
+	for taxYear, brackets := range s.Brackets {
+		clone.Brackets[taxYear] = slices.Clone(brackets)
+	}
+
+	return clone
+}
+
+func (m *Memory) CreateUser(ctx context.Context, email string, passwordHash string) (User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if slices.ContainsFunc(m.users, func(u User) bool { return u.Email == email }) {
+		return User{}, ErrAlreadyExists
+	}
+
+	u := User{
+		ID:           m.nextID("users"),
+		Email:        email,
+		PasswordHash: passwordHash,
+		UUID:         uuid.NewString(),
+		CreatedAt:    m.now(),
+		UpdatedAt:    m.now(),
+	}
+
+	m.users = append(m.users, u)
+
+	return u, nil
+}
+
+func (m *Memory) FindUserByEmail(ctx context.Context, email string) (User, error) {
+	return m.findUser(func(u User) bool { return u.Email == email })
+}
+
+func (m *Memory) FindUser(ctx context.Context, id int) (User, error) {
+	return m.findUser(func(u User) bool { return u.ID == id })
+}
+
+func (m *Memory) findUser(match func(User) bool) (User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.users, match)
+	if i < 0 {
+		return User{}, ErrNotFound
+	}
+
+	return m.users[i], nil
+}
+
+func (m *Memory) FindUserProfile(ctx context.Context, userID int) (UserProfile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.profiles[userID]
+	if !ok {
+		return UserProfile{}, ErrNotFound
+	}
+
+	p.Allowances = maps.Clone(p.Allowances)
+
+	return p, nil
+}
+
+func (m *Memory) UpsertUserProfile(ctx context.Context, p UserProfile) (UserProfile, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !slices.ContainsFunc(m.users, func(u User) bool { return u.ID == p.UserID }) {
+		return UserProfile{}, ErrNotFound
+	}
+
+	p.CreatedAt, p.UpdatedAt = m.now(), m.now()
+	if existing, ok := m.profiles[p.UserID]; ok {
+		p.CreatedAt = existing.CreatedAt
+	}
+
+	p.Allowances = maps.Clone(p.Allowances)
+	m.profiles[p.UserID] = p
+
+	p.Allowances = maps.Clone(p.Allowances)
+
+	return p, nil
+}
+
+func (m *Memory) DeleteUserProfile(ctx context.Context, userID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.profiles[userID]; !ok {
+		return ErrNotFound
+	}
+
+	delete(m.profiles, userID)
+
+	return nil
+}
+
+func cloneCalculationDraft(d CalculationDraft) CalculationDraft {
+	d.TotalIncome = clonePtr(d.TotalIncome)
+	d.Wht = clonePtr(d.Wht)
+	d.Allowances = maps.Clone(d.Allowances)
+	d.Tax = clonePtr(d.Tax)
+	d.TaxRefund = clonePtr(d.TaxRefund)
+	d.FinalizedAt = clonePtr(d.FinalizedAt)
+
+	return d
+}
+
+func (m *Memory) FindCalculationDrafts(ctx context.Context, userID int) ([]CalculationDraft, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	results := []CalculationDraft{}
+
+	for _, d := range m.calculationDrafts {
+		if d.UserID == userID {
+			results = append(results, cloneCalculationDraft(d))
+		}
+	}
+
+	return results, nil
+}
+
+func (m *Memory) FindCalculationDraft(ctx context.Context, userID int, id int) (CalculationDraft, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexCalculationDraft(userID, id)
+	if i < 0 {
+		return CalculationDraft{}, ErrNotFound
+	}
+
+	return cloneCalculationDraft(m.calculationDrafts[i]), nil
+}
+
+func (m *Memory) indexCalculationDraft(userID int, id int) int {
+	return slices.IndexFunc(m.calculationDrafts, func(d CalculationDraft) bool { return d.ID == id && d.UserID == userID })
+}
+
+func (m *Memory) CreateCalculationDraft(ctx context.Context, d CalculationDraft) (CalculationDraft, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d = cloneCalculationDraft(d)
+	d.ID = m.nextID("calculation_drafts")
+	d.UUID = uuid.NewString()
+	d.CreatedAt, d.UpdatedAt = m.now(), m.now()
+	d.Tax, d.TaxRefund, d.FinalizedAt = nil, nil, nil
+
+	m.calculationDrafts = append(m.calculationDrafts, d)
+
+	return cloneCalculationDraft(d), nil
+}
+
+func (m *Memory) UpdateCalculationDraft(ctx context.Context, d CalculationDraft) (CalculationDraft, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexCalculationDraft(d.UserID, d.ID)
+	if i < 0 || m.calculationDrafts[i].FinalizedAt != nil {
+		return CalculationDraft{}, ErrNotFound
+	}
+
+	stored := &m.calculationDrafts[i]
+	stored.Name = d.Name
+	stored.TotalIncome = clonePtr(d.TotalIncome)
+	stored.Wht = clonePtr(d.Wht)
+	stored.Allowances = maps.Clone(d.Allowances)
+	stored.UpdatedAt = m.now()
+
+	return cloneCalculationDraft(*stored), nil
+}
+
+func (m *Memory) FinalizeCalculationDraft(ctx context.Context, userID int, id int, updatedAt time.Time, tax float64, taxRefund float64) (CalculationDraft, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := m.indexCalculationDraft(userID, id)
+	if i < 0 || m.calculationDrafts[i].FinalizedAt != nil {
+		return CalculationDraft{}, ErrNotFound
+	}
+
+	stored := &m.calculationDrafts[i]
+	if !stored.UpdatedAt.Equal(updatedAt) {
+		return CalculationDraft{}, ErrConflict
+	}
+
+	now := m.now()
+	stored.Tax, stored.TaxRefund = &tax, &taxRefund
+	stored.FinalizedAt, stored.UpdatedAt = &now, now
+
+	return cloneCalculationDraft(*stored), nil
+}
+
+func cloneBatchJob(j BatchJob) BatchJob {
+	j.APIKeyID = clonePtr(j.APIKeyID)
+	j.TenantID = clonePtr(j.TenantID)
+	j.UserID = clonePtr(j.UserID)
+	j.Payload = slices.Clone(j.Payload)
+	j.Result = slices.Clone(j.Result)
+
+	return j
+}
+
+func (m *Memory) CreateBatchJob(ctx context.Context, j BatchJob) (BatchJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	j = cloneBatchJob(j)
+	j.ID = uuid.NewString()
+	j.Status = BatchJobQueued
+	j.Result, j.ErrorCode = nil, ""
+	j.CreatedAt, j.UpdatedAt = m.now(), m.now()
+
+	m.batchJobs = append(m.batchJobs, j)
+
+	return cloneBatchJob(j), nil
+}
+
+func (m *Memory) FindBatchJob(ctx context.Context, id string) (BatchJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.batchJobs, func(j BatchJob) bool { return j.ID == id })
+	if i < 0 {
+		return BatchJob{}, ErrNotFound
+	}
+
+	return cloneBatchJob(m.batchJobs[i]), nil
+}
+
+func (m *Memory) UpdateBatchJob(ctx context.Context, id string, status string, result []byte, errorCode string) (BatchJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	i := slices.IndexFunc(m.batchJobs, func(j BatchJob) bool { return j.ID == id })
+	if i < 0 {
+		return BatchJob{}, ErrNotFound
+	}
+
+	stored := &m.batchJobs[i]
+	stored.Status = status
+	stored.Result = slices.Clone(result)
+	stored.ErrorCode = errorCode
+	stored.UpdatedAt = m.now()
+
+	return cloneBatchJob(*stored), nil
+}
+
+func (m *Memory) RequeueBatchJobs(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var ids []string
+
+	for i := range m.batchJobs {
+		j := &m.batchJobs[i]
+		if j.Status == BatchJobQueued || j.Status == BatchJobRunning {
+			j.Status, j.UpdatedAt = BatchJobQueued, m.now()
+			ids = append(ids, j.ID)
+		}
+	}
+
+	return ids, nil
+}
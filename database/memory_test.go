@@ -0,0 +1,599 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemorySettings(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	_, err := m.UpdateAmountDefaultAllowances(ctx, "personal", 70_000)
+	assert.NoError(t, err)
+
+	now := time.Now()
+	effective, err := m.FindEffectiveAllowances(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, []EffectiveAllowance{
+		{AllowanceKind: AllowanceKindDefault, AllowanceType: "personal", Amount: 70_000, EffectiveFrom: truncateDay(now)},
+	}, effective)
+
+	versions, err := m.FindAllSettingVersions(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, versions, 2)
+
+	v, err := m.RollbackSettings(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, v.Version)
+	assert.Equal(t, map[string]float64{"personal": 60_000}, v.DefaultAllowances)
+
+	_, err = m.RollbackSettings(ctx, 10)
+	assert.True(t, errors.Is(err, ErrNotFound))
+}
+
+func TestMemoryRollbackSettingsRestoresBrackets(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	top := 150_000.0
+	imported, err := m.ImportSettings(ctx, SettingsImport{
+		Brackets: map[int][]TaxBracket{2024: {{Percentage: 0, MaxAmount: &top, Label: "0-150,000"}, {Percentage: 0.1, Label: "150,001 ขึ้นไป"}}},
+	})
+	assert.NoError(t, err)
+
+	_, err = m.ImportSettings(ctx, SettingsImport{
+		Brackets: map[int][]TaxBracket{2024: {{Percentage: 0.2, Label: "0 ขึ้นไป"}}},
+	})
+	assert.NoError(t, err)
+
+	v, err := m.RollbackSettings(ctx, imported.Version)
+	assert.NoError(t, err)
+	assert.Equal(t, imported.Brackets, v.Brackets)
+
+	brackets, err := m.FindTaxBrackets(ctx, 2024)
+	assert.NoError(t, err)
+	assert.Equal(t, imported.Brackets[2024], brackets)
+
+	_, err = m.RollbackSettings(ctx, 1)
+	assert.NoError(t, err)
+
+	brackets, err = m.FindTaxBrackets(ctx, 2024)
+	assert.NoError(t, err)
+	assert.Empty(t, brackets, "brackets imported after the version are removed")
+}
+
+func TestMemoryApplyDueScheduledChanges(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+	now := time.Now()
+
+	_, err := m.CreateScheduledChange(ctx, AllowanceKindAllowed, "donation", 150_000, now.Add(-time.Minute))
+	assert.NoError(t, err)
+	_, err = m.CreateScheduledChange(ctx, AllowanceKindAllowed, "k-receipt", 80_000, now.Add(time.Hour))
+	assert.NoError(t, err)
+
+	applied, err := m.ApplyDueScheduledChanges(ctx, now)
+	assert.NoError(t, err)
+	assert.Len(t, applied, 1)
+	assert.NotNil(t, applied[0].AppliedAt)
+
+	allowed, err := m.FindAllAllowedAllowances(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, allowed, 2)
+	assert.Equal(t, "donation", allowed[0].AllowanceType)
+	assert.Equal(t, 150_000.0, allowed[0].MaxAmount)
+	assert.Equal(t, "k-receipt", allowed[1].AllowanceType)
+	assert.Equal(t, 50_000.0, allowed[1].MaxAmount)
+
+	pending, err := m.FindPendingScheduledChanges(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, pending, 1)
+	assert.Equal(t, "k-receipt", pending[0].AllowanceType)
+}
+
+func TestMemoryExpectedVersion(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	_, err := m.UpdateAmountDefaultAllowances(WithExpectedVersion(ctx, 1), "personal", 70_000)
+	assert.NoError(t, err)
+
+	_, err = m.UpdateAmountDefaultAllowances(WithExpectedVersion(ctx, 1), "personal", 80_000)
+	assert.True(t, errors.Is(err, ErrConflict))
+
+	defaults, err := m.FindAllDefaultAllowances(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, defaults, 1)
+	assert.Equal(t, 70_000.0, defaults[0].Amount)
+}
+
+func TestMemorySettingHistory(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	before := time.Now()
+	m.now = func() time.Time { return before.Add(time.Hour) }
+
+	_, err := m.UpdateAmountAllowedAllowances(ctx, "donation", 150_000)
+	assert.NoError(t, err)
+
+	amount := func(h []SettingHistory, name string) float64 {
+		for _, s := range h {
+			if s.Name == name {
+				return *s.Amount
+			}
+		}
+
+		return 0
+	}
+
+	past, err := m.FindSettingHistory(ctx, before)
+	assert.NoError(t, err)
+	assert.Len(t, past, 3)
+	assert.Equal(t, 100_000.0, amount(past, "donation"))
+
+	current, err := m.FindSettingHistory(ctx, before.Add(2*time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, current, 3)
+	assert.Equal(t, 150_000.0, amount(current, "donation"))
+	assert.Equal(t, 50_000.0, amount(current, "k-receipt"))
+}
+
+func TestMemoryRecordTimestamps(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+
+	m.now = func() time.Time { return created }
+	before, err := m.FindAllDefaultAllowances(ctx)
+	assert.NoError(t, err)
+
+	m.now = func() time.Time { return updated }
+	a, err := m.UpdateAmountDefaultAllowances(ctx, "personal", 70_000)
+	assert.NoError(t, err)
+
+	assert.NotEmpty(t, a.UUID)
+	assert.Equal(t, before[0].UUID, a.UUID)
+	assert.Equal(t, before[0].CreatedAt, a.CreatedAt)
+	assert.Equal(t, updated, a.UpdatedAt)
+}
+
+func TestMemoryDisableAllowanceType(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	assert.ErrorIs(t, m.DisableAllowanceType(ctx, "unknown"), ErrNotFound)
+	assert.NoError(t, m.DisableAllowanceType(ctx, "k-receipt"))
+	assert.ErrorIs(t, m.DisableAllowanceType(ctx, "k-receipt"), ErrNotFound)
+
+	allowed, err := m.FindAllAllowedAllowances(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, allowed, 2)
+
+	for _, a := range allowed {
+		assert.Equal(t, a.AllowanceType == "k-receipt", a.DisabledAt != nil, a.AllowanceType)
+	}
+
+	_, err = m.ImportSettings(ctx, SettingsImport{
+		AllowedAllowances: []AllowedAllowance{{AllowanceType: "k-receipt", MaxAmount: 50_000}},
+	})
+	assert.NoError(t, err)
+
+	allowed, err = m.FindAllAllowedAllowances(ctx)
+	assert.NoError(t, err)
+
+	for _, a := range allowed {
+		assert.Nil(t, a.DisabledAt, a.AllowanceType)
+	}
+}
+
+func TestMemoryRollbackSettingsRestoresAllowanceTypes(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	before, err := m.LatestSettingVersion(ctx)
+	assert.NoError(t, err)
+
+	_, err = m.ImportSettings(ctx, SettingsImport{
+		AllowedAllowances: []AllowedAllowance{{AllowanceType: "rmf", MaxAmount: 500_000}},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, m.DisableAllowanceType(ctx, "donation"))
+
+	after, err := m.LatestSettingVersion(ctx)
+	assert.NoError(t, err)
+
+	_, err = m.RollbackSettings(ctx, before)
+	assert.NoError(t, err)
+
+	allowed, err := m.FindAllAllowedAllowances(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, allowed, 2, "types added after the version are removed")
+
+	for _, a := range allowed {
+		assert.Nil(t, a.DisabledAt, a.AllowanceType)
+	}
+
+	effective, err := m.FindAllEffectiveAllowances(ctx)
+	assert.NoError(t, err)
+	assert.False(t, slices.ContainsFunc(effective, func(e EffectiveAllowance) bool { return e.AllowanceType == "rmf" }),
+		"effective values of removed types aren't applied anymore")
+
+	_, err = m.RollbackSettings(ctx, after)
+	assert.NoError(t, err)
+
+	allowed, err = m.FindAllAllowedAllowances(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, allowed, 3)
+
+	for _, a := range allowed {
+		assert.Equal(t, a.AllowanceType == "donation", a.DisabledAt != nil, a.AllowanceType)
+	}
+}
+
+func TestMemorySettingsWritesRecordVersions(t *testing.T) {
+	m := NewMemory()
+
+	tenant, err := m.CreateTenant(context.Background(), "subsidiary")
+	assert.NoError(t, err)
+
+	writes := map[string]func(ctx context.Context) error{
+		"effective allowance": func(ctx context.Context) error {
+			_, err := m.UpsertEffectiveAllowance(ctx, EffectiveAllowance{AllowanceKind: AllowanceKindDefault, AllowanceType: "personal", Amount: 70_000})
+			return err
+		},
+		"alias": func(ctx context.Context) error {
+			_, err := m.UpsertAllowanceAlias(ctx, "kreceipt", "k-receipt")
+			return err
+		},
+		"alias deletion": func(ctx context.Context) error {
+			return m.DeleteAllowanceAlias(ctx, "kreceipt")
+		},
+		"tenant settings": func(ctx context.Context) error {
+			return m.ReplaceTenantSettings(ctx, tenant.ID, SettingsImport{})
+		},
+		"tenant deletion": func(ctx context.Context) error {
+			return m.DeleteTenant(ctx, tenant.ID)
+		},
+	}
+
+	for _, name := range []string{"effective allowance", "alias", "alias deletion", "tenant settings", "tenant deletion"} {
+		t.Run(name, func(t *testing.T) {
+			version, err := m.LatestSettingVersion(context.Background())
+			assert.NoError(t, err)
+
+			assert.ErrorIs(t, writes[name](WithExpectedVersion(context.Background(), version-1)), ErrConflict)
+			assert.NoError(t, writes[name](WithExpectedVersion(context.Background(), version)))
+
+			latest, err := m.LatestSettingVersion(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, version+1, latest)
+		})
+	}
+}
+
+func TestMemoryPurgeBefore(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	cutoff := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	err := m.InsertCalculations(ctx, []Calculation{
+		{TotalIncome: 500_000, CalculatedAt: cutoff.Add(-time.Hour)},
+		{TotalIncome: 600_000, CalculatedAt: cutoff.Add(time.Hour)},
+	})
+	assert.NoError(t, err)
+
+	pending, err := m.CreateScheduledChange(ctx, AllowanceKindDefault, "personal", 70_000, cutoff.Add(-time.Hour))
+	assert.NoError(t, err)
+
+	cancelled, err := m.CreateScheduledChange(ctx, AllowanceKindDefault, "personal", 80_000, cutoff.Add(-time.Hour))
+	assert.NoError(t, err)
+
+	m.now = func() time.Time { return cutoff.Add(-time.Minute) }
+	assert.NoError(t, m.CancelScheduledChange(ctx, cancelled.ID))
+
+	done, err := m.CreateBatchJob(ctx, BatchJob{TaxYear: 2024})
+	assert.NoError(t, err)
+
+	_, err = m.UpdateBatchJob(ctx, done.ID, BatchJobDone, []byte(`[]`), "")
+	assert.NoError(t, err)
+
+	queued, err := m.CreateBatchJob(ctx, BatchJob{TaxYear: 2024})
+	assert.NoError(t, err)
+
+	deleted, err := m.PurgeBefore(ctx, cutoff)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int64{"calculation_history": 1, "scheduled_changes": 1, "admin_sessions": 0, "batch_jobs": 1},
+		deleted)
+
+	_, err = m.FindBatchJob(ctx, queued.ID)
+	assert.NoError(t, err, "queued jobs aren't purged")
+
+	assert.Len(t, m.calculations, 1)
+	assert.Equal(t, 600_000.0, m.calculations[0].TotalIncome)
+
+	changes, err := m.FindPendingScheduledChanges(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, changes, 1)
+	assert.Equal(t, pending.ID, changes[0].ID)
+}
+
+func TestMemoryDeleteCalculations(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	subject, other := 1, 2
+
+	err := m.InsertCalculations(ctx, []Calculation{
+		{TotalIncome: 500_000, APIKeyID: &subject},
+		{TotalIncome: 600_000, APIKeyID: &other},
+		{TotalIncome: 700_000, APIKeyID: &subject},
+		{TotalIncome: 800_000},
+	})
+	assert.NoError(t, err)
+
+	_, err = m.CreateBatchJob(ctx, BatchJob{TaxYear: 2024, APIKeyID: &subject, Payload: []byte("totalIncome\n500000")})
+	assert.NoError(t, err)
+
+	kept, err := m.CreateBatchJob(ctx, BatchJob{TaxYear: 2024, APIKeyID: &other, Payload: []byte("totalIncome\n600000")})
+	assert.NoError(t, err)
+
+	receipt, err := m.DeleteCalculations(ctx, subject)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, receipt.ID)
+	assert.Equal(t, &subject, receipt.APIKeyID)
+	assert.Equal(t, int64(3), receipt.DeletedRows, "batch jobs have the uploaded incomes too")
+
+	assert.Len(t, m.calculations, 2)
+	assert.Len(t, m.batchJobs, 1)
+	assert.Equal(t, kept.ID, m.batchJobs[0].ID)
+
+	for _, c := range m.calculations {
+		assert.False(t, c.APIKeyID != nil && *c.APIKeyID == subject)
+	}
+}
+
+func TestMemoryUsers(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	u, err := m.CreateUser(ctx, "somchai@example.com", "hash")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, u.UUID)
+
+	_, err = m.CreateUser(ctx, "somchai@example.com", "hash")
+	assert.ErrorIs(t, err, ErrAlreadyExists)
+
+	found, err := m.FindUserByEmail(ctx, "somchai@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, u, found)
+
+	_, err = m.FindUser(ctx, u.ID+1)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	keyID := 1
+	calculatedAt := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	err = m.InsertCalculations(ctx, []Calculation{
+		{TotalIncome: 500_000, UserID: &u.ID, APIKeyID: &keyID, CalculatedAt: calculatedAt},
+		{TotalIncome: 600_000, UserID: &u.ID, CalculatedAt: calculatedAt.Add(time.Hour)},
+		{TotalIncome: 700_000, APIKeyID: &keyID},
+	})
+	assert.NoError(t, err)
+
+	calcs, err := m.FindUserCalculations(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Len(t, calcs, 2)
+	assert.Equal(t, 600_000.0, calcs[0].TotalIncome)
+
+	receipt, err := m.DeleteUserCalculations(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, &u.ID, receipt.UserID)
+	assert.Nil(t, receipt.APIKeyID)
+	assert.Equal(t, int64(2), receipt.DeletedRows)
+	assert.Len(t, m.calculations, 1)
+}
+
+func TestMemoryUserProfiles(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	_, err := m.UpsertUserProfile(ctx, UserProfile{UserID: 1, MaritalStatus: "single"})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	u, err := m.CreateUser(ctx, "somchai@example.com", "hash")
+	assert.NoError(t, err)
+
+	allowances := map[string]float64{"donation": 1_000}
+
+	created, err := m.UpsertUserProfile(ctx, UserProfile{UserID: u.ID, MaritalStatus: "single", Allowances: allowances})
+	assert.NoError(t, err)
+
+	// stored allowances aren't shared with the caller
+	allowances["donation"] = 2_000
+
+	found, err := m.FindUserProfile(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1_000.0, found.Allowances["donation"])
+
+	updated, err := m.UpsertUserProfile(ctx, UserProfile{UserID: u.ID, MaritalStatus: "married", Children: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, created.CreatedAt, updated.CreatedAt)
+
+	found, err = m.FindUserProfile(ctx, u.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "married", found.MaritalStatus)
+	assert.Empty(t, found.Allowances)
+
+	assert.NoError(t, m.DeleteUserProfile(ctx, u.ID))
+	assert.ErrorIs(t, m.DeleteUserProfile(ctx, u.ID), ErrNotFound)
+}
+
+func TestMemoryCalculationDrafts(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	income := 500_000.0
+
+	created, err := m.CreateCalculationDraft(ctx, CalculationDraft{UserID: 1, Name: "2024"})
+	assert.NoError(t, err)
+	assert.Nil(t, created.TotalIncome)
+
+	_, err = m.UpdateCalculationDraft(ctx, CalculationDraft{ID: created.ID, UserID: 2, TotalIncome: &income})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	updated, err := m.UpdateCalculationDraft(ctx, CalculationDraft{ID: created.ID, UserID: 1, TotalIncome: &income})
+	assert.NoError(t, err)
+	assert.Equal(t, &income, updated.TotalIncome)
+
+	_, err = m.FinalizeCalculationDraft(ctx, 1, created.ID, updated.UpdatedAt.Add(-time.Second), 29_000, 0)
+	assert.ErrorIs(t, err, ErrConflict)
+
+	finalized, err := m.FinalizeCalculationDraft(ctx, 1, created.ID, updated.UpdatedAt, 29_000, 0)
+	assert.NoError(t, err)
+	assert.NotNil(t, finalized.FinalizedAt)
+
+	_, err = m.UpdateCalculationDraft(ctx, CalculationDraft{ID: created.ID, UserID: 1})
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	drafts, err := m.FindCalculationDrafts(ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, drafts, 1)
+	assert.Equal(t, 29_000.0, *drafts[0].Tax)
+}
+
+func TestMemoryTenants(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	tenant, err := m.CreateTenant(ctx, "subsidiary")
+	assert.NoError(t, err)
+
+	_, err = m.CreateTenant(ctx, "subsidiary")
+	assert.True(t, errors.Is(err, ErrAlreadyExists))
+
+	max := 300_000.0
+	err = m.ReplaceTenantSettings(ctx, tenant.ID, SettingsImport{
+		AllowedAllowances: []AllowedAllowance{{AllowanceType: "donation", MaxAmount: 150_000}},
+		Brackets: map[int][]TaxBracket{
+			2024: {{Percentage: 0, MaxAmount: &max, Label: "0-300,000"}, {Percentage: 0.1, Label: "300,001 ขึ้นไป"}},
+		},
+	})
+	assert.NoError(t, err)
+
+	err = m.ReplaceTenantSettings(ctx, 10, SettingsImport{})
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	s, err := m.FindTenantSettings(ctx, tenant.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []AllowedAllowance{{AllowanceType: "donation", MaxAmount: 150_000}}, s.AllowedAllowances)
+	assert.Len(t, s.Brackets[2024], 2)
+	assert.Equal(t, 2, s.Brackets[2024][1].Level)
+
+	// global settings aren't changed by overrides of a tenant
+	brackets, err := m.FindTaxBrackets(ctx, 2024)
+	assert.NoError(t, err)
+	assert.Empty(t, brackets)
+
+	k, err := m.CreateAPIKey(ctx, "partner", "ktax_12345678", "hash", []string{"calculate"})
+	assert.NoError(t, err)
+
+	_, err = m.UpdateAPIKeyTenant(ctx, k.ID, &tenant.ID)
+	assert.NoError(t, err)
+
+	missing := 10
+	_, err = m.UpdateAPIKeyTenant(ctx, k.ID, &missing)
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	err = m.DeleteTenant(ctx, tenant.ID)
+	assert.True(t, errors.Is(err, ErrInUse))
+
+	_, err = m.UpdateAPIKeyTenant(ctx, k.ID, nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, m.DeleteTenant(ctx, tenant.ID))
+
+	s, err = m.FindTenantSettings(ctx, tenant.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, s.AllowedAllowances)
+}
+
+func TestMemoryAllowanceAliases(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	created, err := m.UpsertAllowanceAlias(ctx, "kreceipt", "donation")
+	assert.NoError(t, err)
+
+	updated, err := m.UpsertAllowanceAlias(ctx, "kreceipt", "k-receipt")
+	assert.NoError(t, err)
+	assert.Equal(t, created.CreatedAt, updated.CreatedAt)
+
+	_, err = m.UpsertAllowanceAlias(ctx, "k_receipt", "k-receipt")
+	assert.NoError(t, err)
+
+	aliases, err := m.FindAllowanceAliases(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, aliases, 2)
+	assert.Equal(t, "k_receipt", aliases[0].Alias)
+	assert.Equal(t, "k-receipt", aliases[1].AllowanceType)
+
+	assert.NoError(t, m.DeleteAllowanceAlias(ctx, "kreceipt"))
+	assert.ErrorIs(t, m.DeleteAllowanceAlias(ctx, "kreceipt"), ErrNotFound)
+}
+
+func TestMemoryBatchJobs(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemory()
+
+	keyID := 1
+
+	created, err := m.CreateBatchJob(ctx, BatchJob{TaxYear: 2024, APIKeyID: &keyID, Payload: []byte("totalIncome,wht,donation")})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, created.ID)
+	assert.Equal(t, BatchJobQueued, created.Status)
+
+	keyID = 2
+
+	found, err := m.FindBatchJob(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, *found.APIKeyID, "stored job isn't shared with the caller")
+	assert.Equal(t, []byte("totalIncome,wht,donation"), found.Payload)
+
+	failed, err := m.UpdateBatchJob(ctx, created.ID, BatchJobFailed, nil, "TAX_YEAR_UNSUPPORTED")
+	assert.NoError(t, err)
+	assert.Equal(t, BatchJobFailed, failed.Status)
+	assert.Equal(t, "TAX_YEAR_UNSUPPORTED", failed.ErrorCode)
+
+	_, err = m.FindBatchJob(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	_, err = m.UpdateBatchJob(ctx, "unknown", BatchJobDone, nil, "")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	queued, err := m.CreateBatchJob(ctx, BatchJob{TaxYear: 2024})
+	assert.NoError(t, err)
+
+	running, err := m.CreateBatchJob(ctx, BatchJob{TaxYear: 2024})
+	assert.NoError(t, err)
+
+	_, err = m.UpdateBatchJob(ctx, running.ID, BatchJobRunning, nil, "")
+	assert.NoError(t, err)
+
+	ids, err := m.RequeueBatchJobs(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{queued.ID, running.ID}, ids, "jobs which are done or failed aren't requeued")
+
+	found, err = m.FindBatchJob(ctx, running.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, BatchJobQueued, found.Status, "interrupted jobs are calculated again")
+}
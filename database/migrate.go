@@ -0,0 +1,31 @@
+package database
+
+import (
+	"context"
+
+	"github.com/AnnaCarter465/assessment-tax/initialdata"
+)
+
+// Migrate applies initialdata/init.sql in one transaction, replicas starting at the same time
+// wait for each other instead of altering the same tables
+func (db *DB) Migrate(ctx context.Context) error {
+	ctx, span := db.startSpan(ctx, "Migrate")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('migrate'))`); err != nil {
+		return err
+	}
+
+	// without arguments the script is sent by simple protocol, which allows many statements
+	if _, err := tx.Exec(ctx, initialdata.Schema); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const userProfileColumns = `user_id, marital_status, children, allowances, created_at, updated_at`
+
+func scanUserProfile(row rowScanner) (UserProfile, error) {
+	var (
+		p          UserProfile
+		allowances []byte
+	)
+
+	if err := row.Scan(&p.UserID, &p.MaritalStatus, &p.Children, &allowances, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return UserProfile{}, err
+	}
+
+	return p, json.Unmarshal(allowances, &p.Allowances)
+}
+
+func (db *DB) FindUserProfile(ctx context.Context, userID int) (UserProfile, error) {
+	ctx, span := db.startSpan(ctx, "FindUserProfile")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx, `SELECT `+userProfileColumns+` FROM user_profiles WHERE user_id = $1`, userID)
+
+	p, err := scanUserProfile(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return UserProfile{}, ErrNotFound
+	}
+
+	return p, err
+}
+
+// UpsertUserProfile creates or replaces profile of taxpayer, ErrNotFound is returned for an unknown taxpayer
+func (db *DB) UpsertUserProfile(ctx context.Context, p UserProfile) (UserProfile, error) {
+	ctx, span := db.startSpan(ctx, "UpsertUserProfile")
+	defer span.End()
+
+	allowances, err := json.Marshal(p.Allowances)
+	if err != nil {
+		return UserProfile{}, err
+	}
+
+	row := db.getPool().QueryRow(ctx,
+		`
+		INSERT INTO user_profiles (user_id, marital_status, children, allowances)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET marital_status = EXCLUDED.marital_status, children = EXCLUDED.children, allowances = EXCLUDED.allowances,
+			updated_at = now()
+		RETURNING `+userProfileColumns, p.UserID, p.MaritalStatus, p.Children, allowances)
+
+	upserted, err := scanUserProfile(row)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return UserProfile{}, ErrNotFound
+	}
+
+	return upserted, err
+}
+
+func (db *DB) DeleteUserProfile(ctx context.Context, userID int) error {
+	ctx, span := db.startSpan(ctx, "DeleteUserProfile")
+	defer span.End()
+
+	res, err := db.getPool().Exec(ctx, `DELETE FROM user_profiles WHERE user_id = $1`, userID)
+	if err != nil {
+		return err
+	}
+
+	n := res.RowsAffected()
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// UserProfile is what a taxpayer claims every year, allowances are amounts by allowance type
+type UserProfile struct {
+	UserID        int                `db:"user_id"`
+	MaritalStatus string             `db:"marital_status"`
+	Children      int                `db:"children"`
+	Allowances    map[string]float64 `db:"allowances"`
+	CreatedAt     time.Time          `db:"created_at"`
+	UpdatedAt     time.Time          `db:"updated_at"`
+}
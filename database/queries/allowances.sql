@@ -0,0 +1,39 @@
+-- name: FindAllDefaultAllowances :many
+SELECT * FROM default_allowances;
+
+-- name: UpdateDefaultAllowanceAmount :one
+UPDATE default_allowances
+SET amount = @amount, updated_at = now()
+WHERE allowance_type = @allowance_type
+RETURNING *;
+
+-- name: DisableDefaultAllowance :execrows
+UPDATE default_allowances
+SET disabled_at = now(), updated_at = now()
+WHERE allowance_type = @allowance_type AND disabled_at IS NULL;
+
+-- name: FindAllAllowedAllowances :many
+SELECT * FROM allowed_allowances;
+
+-- name: UpdateAllowedAllowanceMaxAmount :one
+UPDATE allowed_allowances
+SET max_amount = @max_amount, updated_at = now()
+WHERE allowance_type = @allowance_type
+RETURNING *;
+
+-- name: DisableAllowedAllowance :execrows
+UPDATE allowed_allowances
+SET disabled_at = now(), updated_at = now()
+WHERE allowance_type = @allowance_type AND disabled_at IS NULL;
+
+-- name: UpsertDefaultAllowance :exec
+-- UpsertDefaultAllowance enables allowance type again when it's disabled
+INSERT INTO default_allowances (allowance_type, amount)
+VALUES (@allowance_type, @amount)
+ON CONFLICT (allowance_type) DO UPDATE SET amount = EXCLUDED.amount, updated_at = now(), disabled_at = NULL;
+
+-- name: UpsertAllowedAllowance :exec
+-- UpsertAllowedAllowance enables allowance type again when it's disabled
+INSERT INTO allowed_allowances (allowance_type, max_amount)
+VALUES (@allowance_type, @max_amount)
+ON CONFLICT (allowance_type) DO UPDATE SET max_amount = EXCLUDED.max_amount, updated_at = now(), disabled_at = NULL;
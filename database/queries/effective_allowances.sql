@@ -0,0 +1,16 @@
+-- name: FindEffectiveAllowances :many
+-- FindEffectiveAllowances returns the latest value of every allowance which is effective on at
+SELECT DISTINCT ON (allowance_kind, allowance_type) *
+FROM effective_allowances
+WHERE effective_from <= @at
+ORDER BY allowance_kind, allowance_type, effective_from DESC;
+
+-- name: FindAllEffectiveAllowances :many
+SELECT * FROM effective_allowances
+ORDER BY effective_from, allowance_kind, allowance_type;
+
+-- name: UpsertEffectiveAllowance :one
+INSERT INTO effective_allowances (allowance_kind, allowance_type, amount, effective_from)
+VALUES (@allowance_kind, @allowance_type, @amount, @effective_from)
+ON CONFLICT (allowance_kind, allowance_type, effective_from) DO UPDATE SET amount = EXCLUDED.amount, updated_at = now()
+RETURNING *;
@@ -0,0 +1,2 @@
+-- name: FindSettingBound :one
+SELECT * FROM setting_bounds WHERE setting = @setting;
@@ -0,0 +1,18 @@
+-- name: FindTaxBrackets :many
+SELECT * FROM tax_brackets
+WHERE tax_year = @tax_year
+ORDER BY level;
+
+-- name: FindAllTaxBrackets :many
+SELECT * FROM tax_brackets
+ORDER BY tax_year, level;
+
+-- name: InsertTaxBracket :exec
+INSERT INTO tax_brackets (tax_year, level, percentage, max_amount, label, labels)
+VALUES (@tax_year, @level, @percentage, @max_amount, @label, @labels);
+
+-- name: DeleteTaxBrackets :exec
+DELETE FROM tax_brackets WHERE tax_year = @tax_year;
+
+-- name: DeleteAllTaxBrackets :exec
+DELETE FROM tax_brackets;
@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// rowsQuerier is implemented by both *pgxpool.Pool and pgx.Tx
+type rowsQuerier interface {
+	Query(ctx context.Context, query string, args ...any) (pgx.Rows, error)
+}
+
+// execer is implemented by both *pgxpool.Pool and pgx.Tx
+type execer interface {
+	Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error)
+}
+
+// queryAll runs query and scans every returned row with scan, so callers only describe one row
+func queryAll[T any](ctx context.Context, q rowsQuerier, scan func(rowScanner) (T, error), query string, args ...any) ([]T, error) {
+	rows, err := q.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []T
+
+	for rows.Next() {
+		v, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, v)
+	}
+
+	return results, rows.Err()
+}
+
+// fromRows converts rows returned by a generated query to models of the package, err of the query is returned as is
+func fromRows[R, T any](rows []R, err error, from func(R) (T, error)) ([]T, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	var results []T
+
+	for _, r := range rows {
+		v, err := from(r)
+		if err != nil {
+			return nil, err
+		}
+
+		results = append(results, v)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/AnnaCarter465/assessment-tax/database/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// getReadDB returns querier of FindAll* queries, they tolerate replication lag so the replica serves them.
+// Writes and reads inside transactions must use getPool.
+func (db *DB) getReadDB() repository.DBTX {
+	if db.replica == nil {
+		return db.pool
+	}
+
+	return &replicaQuerier{replica: db.replica, primary: db.pool}
+}
+
+// replicaQuerier queries replica and falls back to primary when replica fails,
+// replica has its own breaker so it fails fast while it's down. Statements other than Query run on primary.
+type replicaQuerier struct {
+	replica rowsQuerier
+	primary repository.DBTX
+}
+
+func (q *replicaQuerier) Exec(ctx context.Context, query string, args ...any) (pgconn.CommandTag, error) {
+	return q.primary.Exec(ctx, query, args...)
+}
+
+func (q *replicaQuerier) QueryRow(ctx context.Context, query string, args ...any) pgx.Row {
+	return q.primary.QueryRow(ctx, query, args...)
+}
+
+func (q *replicaQuerier) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	rows, err := q.replica.Query(ctx, query, args...)
+	if err == nil || ctx.Err() != nil {
+		return rows, err
+	}
+
+	slog.WarnContext(ctx, "read replica failed, querying primary", "query", queryName(ctx), "error", err)
+
+	return q.primary.Query(ctx, query, args...)
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/database/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeQuerier struct {
+	repository.DBTX
+	err   error
+	calls int
+}
+
+func (q *fakeQuerier) Query(ctx context.Context, query string, args ...any) (pgx.Rows, error) {
+	q.calls++
+	return nil, q.err
+}
+
+func TestReplicaQuerier(t *testing.T) {
+	type TC struct {
+		replicaErr   error
+		cancelled    bool
+		wantErr      error
+		wantFallback bool
+	}
+
+	tcs := []TC{
+		{replicaErr: nil, wantFallback: false},
+		{replicaErr: ErrCircuitOpen, wantFallback: true},
+		{replicaErr: context.Canceled, cancelled: true, wantErr: context.Canceled, wantFallback: false},
+	}
+
+	for _, tc := range tcs {
+		ctx, cancel := context.WithCancel(context.Background())
+		if tc.cancelled {
+			cancel()
+		}
+
+		replica := &fakeQuerier{err: tc.replicaErr}
+		primary := &fakeQuerier{}
+
+		_, err := (&replicaQuerier{replica: replica, primary: primary}).Query(ctx, "SELECT 1")
+		cancel()
+
+		assert.True(t, errors.Is(err, tc.wantErr))
+		assert.Equal(t, 1, replica.calls)
+		assert.Equal(t, tc.wantFallback, primary.calls == 1)
+	}
+}
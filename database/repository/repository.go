@@ -0,0 +1,5 @@
+// Package repository holds queries generated by sqlc from database/queries, code generated from them is committed.
+// Add a table to initialdata/init.sql and its queries to database/queries, then run go generate.
+package repository
+
+//go:generate sqlc generate -f ../../sqlc.yaml
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// tables purged by PurgeBefore with their statements, rows are deleted once they are finished before the cutoff
+var purgeQueries = []struct {
+	table string
+	query string
+}{
+	{table: "calculation_history", query: `DELETE FROM calculation_history WHERE calculated_at < $1`},
+	{table: "scheduled_changes", query: `DELETE FROM scheduled_changes WHERE COALESCE(applied_at, cancelled_at) < $1`},
+	{table: "admin_sessions", query: `DELETE FROM admin_sessions WHERE COALESCE(revoked_at, expires_at) < $1`},
+	{table: "batch_jobs", query: `DELETE FROM batch_jobs WHERE status IN ('done', 'failed') AND updated_at < $1`},
+}
+
+// PurgeBefore deletes calculation history, finished scheduled changes, ended admin sessions and finished batch jobs
+// older than before, it returns number of rows deleted by table. Tables are purged one by one, so a failure keeps
+// rows deleted before it.
+func (db *DB) PurgeBefore(ctx context.Context, before time.Time) (map[string]int64, error) {
+	ctx, span := db.startSpan(ctx, "PurgeBefore")
+	defer span.End()
+
+	deleted := map[string]int64{}
+
+	for _, p := range purgeQueries {
+		res, err := db.getPool().Exec(ctx, p.query, before)
+		if err != nil {
+			return deleted, err
+		}
+
+		n := res.RowsAffected()
+
+		deleted[p.table] = n
+	}
+
+	return deleted, nil
+}
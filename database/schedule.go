@@ -0,0 +1,175 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+const scheduledChangeColumns = `id, allowance_kind, allowance_type, amount, activate_at, uuid, created_at, updated_at, applied_at, cancelled_at`
+
+func scanScheduledChange(row rowScanner) (ScheduledChange, error) {
+	var s ScheduledChange
+
+	err := row.Scan(&s.ID, &s.AllowanceKind, &s.AllowanceType, &s.Amount, &s.ActivateAt, &s.UUID, &s.CreatedAt, &s.UpdatedAt, &s.AppliedAt, &s.CancelledAt)
+	if err != nil {
+		return ScheduledChange{}, err
+	}
+
+	return s, nil
+}
+
+func (db *DB) CreateScheduledChange(ctx context.Context, kind string, allowanceType string, amount float64, activateAt time.Time) (ScheduledChange, error) {
+	ctx, span := db.startSpan(ctx, "CreateScheduledChange")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`
+		INSERT INTO scheduled_changes (allowance_kind, allowance_type, amount, activate_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+scheduledChangeColumns, kind, allowanceType, amount, activateAt)
+
+	return scanScheduledChange(row)
+}
+
+// FindPendingScheduledChanges returns changes which are neither applied nor cancelled
+func (db *DB) FindPendingScheduledChanges(ctx context.Context) ([]ScheduledChange, error) {
+	ctx, span := db.startSpan(ctx, "FindPendingScheduledChanges")
+	defer span.End()
+
+	return queryAll(ctx, db.getPool(), scanScheduledChange,
+		`
+		SELECT `+scheduledChangeColumns+` FROM scheduled_changes
+		WHERE applied_at IS NULL AND cancelled_at IS NULL
+		ORDER BY activate_at, id
+		`)
+}
+
+// CancelScheduledChange returns ErrNotFound when the change doesn't exist or isn't pending anymore
+func (db *DB) CancelScheduledChange(ctx context.Context, id int) error {
+	ctx, span := db.startSpan(ctx, "CancelScheduledChange")
+	defer span.End()
+
+	res, err := db.getPool().Exec(ctx,
+		`
+		UPDATE scheduled_changes SET cancelled_at = now(), updated_at = now()
+		WHERE id = $1 AND applied_at IS NULL AND cancelled_at IS NULL
+		`, id)
+	if err != nil {
+		return err
+	}
+
+	n := res.RowsAffected()
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// ApplyDueScheduledChanges applies pending changes activated on or before now in one transaction. Only one replica
+// applies them at a time, it returns no changes when settings are locked by another replica or an admin write, and
+// due changes are applied by the next run instead
+func (db *DB) ApplyDueScheduledChanges(ctx context.Context, now time.Time) ([]ScheduledChange, error) {
+	ctx, span := db.startSpan(ctx, "ApplyDueScheduledChanges")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	locked, err := tryLockSettings(ctx, tx)
+	if err != nil || !locked {
+		return nil, err
+	}
+
+	changes, err := queryAll(ctx, tx, scanScheduledChange,
+		`
+		SELECT `+scheduledChangeColumns+` FROM scheduled_changes
+		WHERE activate_at <= $1 AND applied_at IS NULL AND cancelled_at IS NULL
+		ORDER BY activate_at, id
+		FOR UPDATE SKIP LOCKED
+		`, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, s := range changes {
+		if err := applyAllowance(ctx, tx, s.AllowanceKind, s.AllowanceType, s.Amount, s.ActivateAt); err != nil {
+			return nil, fmt.Errorf("scheduled change %d: %w", s.ID, err)
+		}
+
+		err = tx.QueryRow(ctx,
+			`UPDATE scheduled_changes SET applied_at = now(), updated_at = now() WHERE id = $1 RETURNING applied_at, updated_at`, s.ID).
+			Scan(&changes[i].AppliedAt, &changes[i].UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(changes) > 0 {
+		if _, err := recordSettingVersion(ctx, tx); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// applyAllowance sets amount of allowance in tx, the value is effective from effectiveFrom
+func applyAllowance(ctx context.Context, tx pgx.Tx, kind string, allowanceType string, amount float64, effectiveFrom time.Time) error {
+	q := repository.New(tx)
+
+	var err error
+
+	switch kind {
+	case AllowanceKindDefault:
+		_, err = q.UpdateDefaultAllowanceAmount(ctx, repository.UpdateDefaultAllowanceAmountParams{AllowanceType: allowanceType, Amount: amount})
+	case AllowanceKindAllowed:
+		_, err = q.UpdateAllowedAllowanceMaxAmount(ctx, repository.UpdateAllowedAllowanceMaxAmountParams{AllowanceType: allowanceType, MaxAmount: amount})
+	default:
+		return fmt.Errorf("unknown allowance kind %q", kind)
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("allowance %q: %w", allowanceType, ErrNotFound)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	_, err = upsertEffectiveAllowance(ctx, tx, EffectiveAllowance{
+		AllowanceKind: kind,
+		AllowanceType: allowanceType,
+		Amount:        amount,
+		EffectiveFrom: effectiveFrom.UTC().Truncate(24 * time.Hour),
+	})
+
+	return err
+}
+
+type ScheduledChange struct {
+	ID            int        `db:"id"`
+	AllowanceKind string     `db:"allowance_kind"`
+	AllowanceType string     `db:"allowance_type"`
+	Amount        float64    `db:"amount"`
+	ActivateAt    time.Time  `db:"activate_at"`
+	UUID          string     `db:"uuid"`
+	CreatedAt     time.Time  `db:"created_at"`
+	UpdatedAt     time.Time  `db:"updated_at"`
+	AppliedAt     *time.Time `db:"applied_at"`
+	CancelledAt   *time.Time `db:"cancelled_at"`
+}
@@ -0,0 +1,90 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// SeedSettings inserts settings of s which don't exist yet and returns number of inserted rows,
+// existing allowances and brackets are kept so it's safe to run more than once.
+func (db *DB) SeedSettings(ctx context.Context, s SettingsImport) (int, error) {
+	ctx, span := db.startSpan(ctx, "SeedSettings")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	// seeding on start of every replica mustn't interleave with imports or rollbacks
+	if err := lockSettings(ctx, tx); err != nil {
+		return 0, err
+	}
+
+	inserted := 0
+
+	for _, a := range s.DefaultAllowances {
+		res, err := tx.Exec(ctx,
+			`
+			INSERT INTO default_allowances (allowance_type, amount)
+			VALUES ($1, $2)
+			ON CONFLICT (allowance_type) DO NOTHING
+			`, a.AllowanceType, a.Amount)
+		if err != nil {
+			return 0, err
+		}
+
+		n := res.RowsAffected()
+		inserted += int(n)
+	}
+
+	for _, a := range s.AllowedAllowances {
+		res, err := tx.Exec(ctx,
+			`
+			INSERT INTO allowed_allowances (allowance_type, max_amount)
+			VALUES ($1, $2)
+			ON CONFLICT (allowance_type) DO NOTHING
+			`, a.AllowanceType, a.MaxAmount)
+		if err != nil {
+			return 0, err
+		}
+
+		n := res.RowsAffected()
+		inserted += int(n)
+	}
+
+	for taxYear, brackets := range s.Brackets {
+		for i, b := range brackets {
+			labels, err := json.Marshal(b.Labels)
+			if err != nil {
+				return 0, err
+			}
+
+			res, err := tx.Exec(ctx,
+				`
+				INSERT INTO tax_brackets (tax_year, level, percentage, max_amount, label, labels)
+				VALUES ($1, $2, $3, $4, $5, $6)
+				ON CONFLICT (tax_year, level) DO NOTHING
+				`, taxYear, i+1, b.Percentage, b.MaxAmount, b.Label, labels)
+			if err != nil {
+				return 0, err
+			}
+
+			n := res.RowsAffected()
+			inserted += int(n)
+		}
+	}
+
+	if inserted > 0 {
+		if _, err := recordSettingVersion(ctx, tx); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return inserted, nil
+}
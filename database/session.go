@@ -0,0 +1,100 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+const adminSessionColumns = `id, username, role, created_at, expires_at, revoked_at`
+
+func scanAdminSession(row rowScanner) (AdminSession, error) {
+	var s AdminSession
+
+	err := row.Scan(&s.ID, &s.Username, &s.Role, &s.CreatedAt, &s.ExpiresAt, &s.RevokedAt)
+	if err != nil {
+		return AdminSession{}, err
+	}
+
+	return s, nil
+}
+
+func (db *DB) CreateAdminSession(ctx context.Context, id string, username string, role string, expiresAt time.Time) (AdminSession, error) {
+	ctx, span := db.startSpan(ctx, "CreateAdminSession")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`
+		INSERT INTO admin_sessions (id, username, role, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+adminSessionColumns, id, username, role, expiresAt)
+
+	return scanAdminSession(row)
+}
+
+func (db *DB) FindAdminSession(ctx context.Context, id string) (AdminSession, error) {
+	ctx, span := db.startSpan(ctx, "FindAdminSession")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`SELECT `+adminSessionColumns+` FROM admin_sessions WHERE id = $1`, id)
+
+	s, err := scanAdminSession(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return AdminSession{}, ErrNotFound
+	}
+
+	return s, err
+}
+
+// FindActiveAdminSessions returns sessions which are neither expired nor revoked
+func (db *DB) FindActiveAdminSessions(ctx context.Context) ([]AdminSession, error) {
+	ctx, span := db.startSpan(ctx, "FindActiveAdminSessions")
+	defer span.End()
+
+	return queryAll(ctx, db.getPool(), scanAdminSession,
+		`
+		SELECT `+adminSessionColumns+` FROM admin_sessions
+		WHERE revoked_at IS NULL AND expires_at > now()
+		ORDER BY created_at
+		`)
+}
+
+func (db *DB) RevokeAdminSession(ctx context.Context, id string) error {
+	ctx, span := db.startSpan(ctx, "RevokeAdminSession")
+	defer span.End()
+
+	res, err := db.getPool().Exec(ctx,
+		`UPDATE admin_sessions SET revoked_at = now(), updated_at = now() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return err
+	}
+
+	n := res.RowsAffected()
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// revokeAdminSessionsOf revokes every session of username which isn't revoked yet
+func revokeAdminSessionsOf(ctx context.Context, tx pgx.Tx, username string) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE admin_sessions SET revoked_at = now(), updated_at = now() WHERE username = $1 AND revoked_at IS NULL`, username)
+
+	return err
+}
+
+type AdminSession struct {
+	ID        string     `db:"id"`
+	Username  string     `db:"username"`
+	Role      string     `db:"role"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+}
@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// SettingKindBracket is kind of setting history of tax brackets, allowances use kinds of effective allowances
+const SettingKindBracket = "bracket"
+
+const settingHistoryColumns = `id, kind, name, amount, percentage, valid_from, valid_to`
+
+func scanSettingHistory(row rowScanner) (SettingHistory, error) {
+	var h SettingHistory
+
+	err := row.Scan(&h.ID, &h.Kind, &h.Name, &h.Amount, &h.Percentage, &h.ValidFrom, &h.ValidTo)
+	if err != nil {
+		return SettingHistory{}, err
+	}
+
+	return h, nil
+}
+
+// syncSettingHistory closes history of settings changed in tx and opens history of their current values,
+// it must be called by every write of settings, recordSettingVersion calls it
+func syncSettingHistory(ctx context.Context, e execer) error {
+	// the insert doesn't see rows closed by the update, so a changed setting still has an open row with its old value
+	_, err := e.Exec(ctx,
+		`
+		WITH current (kind, name, amount, percentage) AS (
+			SELECT 'default', allowance_type, amount, NULL::float8 FROM default_allowances
+			UNION ALL
+			SELECT 'allowed', allowance_type, max_amount, NULL::float8 FROM allowed_allowances
+			UNION ALL
+			SELECT 'bracket', tax_year || '/' || level, max_amount, percentage FROM tax_brackets
+		), closed AS (
+			UPDATE setting_history h SET valid_to = now(), updated_at = now()
+			WHERE h.valid_to IS NULL AND NOT EXISTS (
+				SELECT FROM current c
+				WHERE c.kind = h.kind AND c.name = h.name
+					AND c.amount IS NOT DISTINCT FROM h.amount AND c.percentage IS NOT DISTINCT FROM h.percentage
+			)
+		)
+		INSERT INTO setting_history (kind, name, amount, percentage, valid_from)
+		SELECT c.kind, c.name, c.amount, c.percentage, now() FROM current c
+		WHERE NOT EXISTS (
+			SELECT FROM setting_history h
+			WHERE h.valid_to IS NULL AND h.kind = c.kind AND h.name = c.name
+				AND h.amount IS NOT DISTINCT FROM c.amount AND h.percentage IS NOT DISTINCT FROM c.percentage
+		)
+		`)
+
+	return err
+}
+
+// FindSettingHistory returns value of every setting which was valid at at, ordered by kind and name
+func (db *DB) FindSettingHistory(ctx context.Context, at time.Time) ([]SettingHistory, error) {
+	ctx, span := db.startSpan(ctx, "FindSettingHistory")
+	defer span.End()
+
+	return queryAll(ctx, db.getPool(), scanSettingHistory,
+		`
+		SELECT `+settingHistoryColumns+` FROM setting_history
+		WHERE valid_from <= $1 AND (valid_to IS NULL OR valid_to > $1)
+		ORDER BY kind, name
+		`, at)
+}
+
+// SettingHistory is a value of a setting in [ValidFrom, ValidTo), name of a bracket is "<tax year>/<level>"
+type SettingHistory struct {
+	ID         int        `db:"id"`
+	Kind       string     `db:"kind"`
+	Name       string     `db:"name"`
+	Amount     *float64   `db:"amount"`     // max amount of a bracket, nil for the highest bracket
+	Percentage *float64   `db:"percentage"` // nil for allowances
+	ValidFrom  time.Time  `db:"valid_from"`
+	ValidTo    *time.Time `db:"valid_to"` // nil while the value is current
+}
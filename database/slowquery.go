@@ -0,0 +1,75 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type queryNameKey struct{}
+
+// withQueryName keeps name of database call in ctx, statements run with ctx are logged under it
+func withQueryName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, queryNameKey{}, name)
+}
+
+func queryName(ctx context.Context) string {
+	name, _ := ctx.Value(queryNameKey{}).(string)
+	return name
+}
+
+type slowQueryStartKey struct{}
+
+type slowQueryStart struct {
+	at        time.Time
+	statement string
+	args      []any
+}
+
+// slowQueryTracer logs statements running longer than threshold, values of parameters are redacted
+// since they may be personal data, only their types are logged
+type slowQueryTracer struct {
+	threshold time.Duration
+}
+
+func (t *slowQueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, slowQueryStartKey{}, slowQueryStart{at: time.Now(), statement: data.SQL, args: data.Args})
+}
+
+func (t *slowQueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(slowQueryStartKey{}).(slowQueryStart)
+	if !ok {
+		return
+	}
+
+	d := time.Since(start.at)
+	if d < t.threshold {
+		return
+	}
+
+	slog.WarnContext(ctx, "slow database query",
+		"query", queryName(ctx),
+		"duration", d,
+		"statement", strings.Join(strings.Fields(start.statement), " "),
+		"args", redactArgs(start.args))
+}
+
+// redactArgs describes args by their types only
+func redactArgs(args []any) []string {
+	results := make([]string, 0, len(args))
+
+	for _, a := range args {
+		if a == nil {
+			results = append(results, "NULL")
+			continue
+		}
+
+		results = append(results, fmt.Sprintf("%T", a))
+	}
+
+	return results
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlowQueryTracer(t *testing.T) {
+	type TC struct {
+		delay   time.Duration
+		wantLog bool
+	}
+
+	tcs := []TC{
+		{delay: 0, wantLog: false},
+		{delay: 20 * time.Millisecond, wantLog: true},
+	}
+
+	for _, tc := range tcs {
+		var buf bytes.Buffer
+
+		defaultLogger := slog.Default()
+		slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+		tracer := &slowQueryTracer{threshold: 10 * time.Millisecond}
+
+		ctx := withQueryName(context.Background(), "DisableAllowanceType")
+		ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{
+			SQL:  "UPDATE allowed_allowances\n\tSET disabled_at = now() WHERE allowance_type = $1",
+			Args: []any{"k-receipt", nil},
+		})
+		time.Sleep(tc.delay)
+		tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+
+		slog.SetDefault(defaultLogger)
+
+		if !tc.wantLog {
+			assert.Empty(t, buf.String())
+			continue
+		}
+
+		assert.Contains(t, buf.String(), "query=DisableAllowanceType")
+		assert.Contains(t, buf.String(), `statement="UPDATE allowed_allowances SET disabled_at = now() WHERE allowance_type = $1"`)
+		assert.Contains(t, buf.String(), "args=\"[string NULL]\"")
+		assert.NotContains(t, buf.String(), "k-receipt")
+	}
+}
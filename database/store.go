@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// Store is every operation of the database layer, DB is backed by postgres and Memory keeps data in process
+type Store interface {
+	FindAllDefaultAllowances(ctx context.Context) ([]DefaultAllowance, error)
+	UpdateAmountDefaultAllowances(ctx context.Context, allowanceType string, amount float64) (DefaultAllowance, error)
+	FindAllAllowedAllowances(ctx context.Context) ([]AllowedAllowance, error)
+	UpdateAmountAllowedAllowances(ctx context.Context, allowanceType string, amount float64) (AllowedAllowance, error)
+	DisableAllowanceType(ctx context.Context, allowanceType string) error
+	FindSettingBound(ctx context.Context, setting string) (SettingBound, error)
+
+	FindAllowanceAliases(ctx context.Context) ([]AllowanceAlias, error)
+	UpsertAllowanceAlias(ctx context.Context, alias string, allowanceType string) (AllowanceAlias, error)
+	DeleteAllowanceAlias(ctx context.Context, alias string) error
+
+	FindEffectiveAllowances(ctx context.Context, at time.Time) ([]EffectiveAllowance, error)
+	FindAllEffectiveAllowances(ctx context.Context) ([]EffectiveAllowance, error)
+	UpsertEffectiveAllowance(ctx context.Context, a EffectiveAllowance) (EffectiveAllowance, error)
+
+	CreateScheduledChange(ctx context.Context, kind string, allowanceType string, amount float64, activateAt time.Time) (ScheduledChange, error)
+	FindPendingScheduledChanges(ctx context.Context) ([]ScheduledChange, error)
+	CancelScheduledChange(ctx context.Context, id int) error
+	ApplyDueScheduledChanges(ctx context.Context, now time.Time) ([]ScheduledChange, error)
+
+	CreateSettingDraft(ctx context.Context, kind string, allowanceType string, amount float64, createdBy string) (SettingDraft, error)
+	FindSettingDraft(ctx context.Context, id int) (SettingDraft, error)
+	FindPendingSettingDrafts(ctx context.Context) ([]SettingDraft, error)
+	PublishSettingDraft(ctx context.Context, id int, publishedBy string) (SettingDraft, error)
+	DiscardSettingDraft(ctx context.Context, id int) error
+
+	FindAllSettingVersions(ctx context.Context) ([]SettingVersion, error)
+	LatestSettingVersion(ctx context.Context) (int, error)
+	FindSettingHistory(ctx context.Context, at time.Time) ([]SettingHistory, error)
+	RollbackSettings(ctx context.Context, version int) (SettingVersion, error)
+	ImportSettings(ctx context.Context, imp SettingsImport) (SettingVersion, error)
+	SeedSettings(ctx context.Context, s SettingsImport) (int, error)
+	FindTaxBrackets(ctx context.Context, taxYear int) ([]TaxBracket, error)
+
+	FindAllTaxCalendars(ctx context.Context) ([]TaxCalendar, error)
+	FindTaxCalendar(ctx context.Context, taxYear int) (TaxCalendar, error)
+	FindUpcomingTaxCalendar(ctx context.Context, from time.Time) (TaxCalendar, error)
+	UpsertTaxCalendar(ctx context.Context, cal TaxCalendar) (TaxCalendar, error)
+	DeleteTaxCalendar(ctx context.Context, taxYear int) error
+
+	FindAllWebhooks(ctx context.Context) ([]Webhook, error)
+	CreateWebhook(ctx context.Context, url string, secret string) (Webhook, error)
+	DeleteWebhook(ctx context.Context, id int) error
+
+	CreateAPIKey(ctx context.Context, name string, prefix string, hash string, scopes []string) (APIKey, error)
+	FindAllAPIKeys(ctx context.Context) ([]APIKey, error)
+	FindActiveAPIKeyByHash(ctx context.Context, hash string) (APIKey, error)
+	UpdateAPIKeyScopes(ctx context.Context, id int, scopes []string) (APIKey, error)
+	UpdateAPIKeyQuota(ctx context.Context, id int, quota *int) (APIKey, error)
+	UpdateAPIKeySigningSecret(ctx context.Context, id int, secret *string) (APIKey, error)
+	UpdateAPIKeyTenant(ctx context.Context, id int, tenantID *int) (APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+	RecordAPIKeyUsage(ctx context.Context, keyID int, period time.Time, requests int64, csvRows int64) error
+	FindAPIKeyUsage(ctx context.Context, keyID int, period time.Time) (APIKeyUsage, error)
+	FindAllAPIKeyUsage(ctx context.Context, period time.Time) ([]APIKeyUsage, error)
+
+	InsertCalculations(ctx context.Context, calcs []Calculation) error
+	DeleteCalculations(ctx context.Context, apiKeyID int) (DeletionReceipt, error)
+	DeleteUserCalculations(ctx context.Context, userID int) (DeletionReceipt, error)
+	FindUserCalculations(ctx context.Context, userID int) ([]Calculation, error)
+	PurgeBefore(ctx context.Context, before time.Time) (map[string]int64, error)
+
+	CreateBatchJob(ctx context.Context, j BatchJob) (BatchJob, error)
+	FindBatchJob(ctx context.Context, id string) (BatchJob, error)
+	UpdateBatchJob(ctx context.Context, id string, status string, result []byte, errorCode string) (BatchJob, error)
+	RequeueBatchJobs(ctx context.Context) ([]string, error)
+
+	FindAllAdminUsers(ctx context.Context) ([]AdminUser, error)
+	FindAdminUserByUsername(ctx context.Context, username string) (AdminUser, error)
+	CreateAdminUser(ctx context.Context, username string, passwordHash string, role string) (AdminUser, error)
+	UpdateAdminUserRole(ctx context.Context, id int, role string) (AdminUser, error)
+	UpdateAdminUserTOTP(ctx context.Context, username string, secret []byte, enabled bool) error
+	DeleteAdminUser(ctx context.Context, id int) error
+
+	CreateAdminSession(ctx context.Context, id string, username string, role string, expiresAt time.Time) (AdminSession, error)
+	FindAdminSession(ctx context.Context, id string) (AdminSession, error)
+	FindActiveAdminSessions(ctx context.Context) ([]AdminSession, error)
+	RevokeAdminSession(ctx context.Context, id string) error
+
+	FindAllTenants(ctx context.Context) ([]Tenant, error)
+	FindTenant(ctx context.Context, id int) (Tenant, error)
+	CreateTenant(ctx context.Context, name string) (Tenant, error)
+	DeleteTenant(ctx context.Context, id int) error
+	FindTenantSettings(ctx context.Context, tenantID int) (SettingsImport, error)
+	ReplaceTenantSettings(ctx context.Context, tenantID int, s SettingsImport) error
+
+	CreateUser(ctx context.Context, email string, passwordHash string) (User, error)
+	FindUserByEmail(ctx context.Context, email string) (User, error)
+	FindUser(ctx context.Context, id int) (User, error)
+	FindUserProfile(ctx context.Context, userID int) (UserProfile, error)
+	UpsertUserProfile(ctx context.Context, p UserProfile) (UserProfile, error)
+	DeleteUserProfile(ctx context.Context, userID int) error
+
+	FindCalculationDrafts(ctx context.Context, userID int) ([]CalculationDraft, error)
+	FindCalculationDraft(ctx context.Context, userID int, id int) (CalculationDraft, error)
+	CreateCalculationDraft(ctx context.Context, d CalculationDraft) (CalculationDraft, error)
+	UpdateCalculationDraft(ctx context.Context, d CalculationDraft) (CalculationDraft, error)
+	FinalizeCalculationDraft(ctx context.Context, userID int, id int, updatedAt time.Time, tax float64, taxRefund float64) (CalculationDraft, error)
+}
+
+var (
+	_ Store = (*DB)(nil)
+	_ Store = (*Memory)(nil)
+)
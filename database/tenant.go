@@ -0,0 +1,247 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const tenantColumns = `id, name, uuid, created_at, updated_at`
+
+func scanTenant(row rowScanner) (Tenant, error) {
+	var t Tenant
+
+	err := row.Scan(&t.ID, &t.Name, &t.UUID, &t.CreatedAt, &t.UpdatedAt)
+
+	return t, err
+}
+
+func (db *DB) FindAllTenants(ctx context.Context) ([]Tenant, error) {
+	ctx, span := db.startSpan(ctx, "FindAllTenants")
+	defer span.End()
+
+	return queryAll(ctx, db.getReadDB(), scanTenant, `SELECT `+tenantColumns+` FROM tenants ORDER BY id`)
+}
+
+func (db *DB) FindTenant(ctx context.Context, id int) (Tenant, error) {
+	ctx, span := db.startSpan(ctx, "FindTenant")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx, `SELECT `+tenantColumns+` FROM tenants WHERE id = $1`, id)
+
+	t, err := scanTenant(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Tenant{}, ErrNotFound
+	}
+
+	return t, err
+}
+
+// CreateTenant returns ErrAlreadyExists when another tenant has name
+func (db *DB) CreateTenant(ctx context.Context, name string) (Tenant, error) {
+	ctx, span := db.startSpan(ctx, "CreateTenant")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`INSERT INTO tenants (name) VALUES ($1) RETURNING `+tenantColumns, name)
+
+	t, err := scanTenant(row)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return Tenant{}, ErrAlreadyExists
+	}
+
+	return t, err
+}
+
+// DeleteTenant deletes tenant with its overrides, ErrInUse is returned while an api key is assigned to it
+func (db *DB) DeleteTenant(ctx context.Context, id int) error {
+	ctx, span := db.startSpan(ctx, "DeleteTenant")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// overrides of the tenant are deleted with it
+	if err := lockSettings(ctx, tx); err != nil {
+		return err
+	}
+
+	res, err := tx.Exec(ctx, `DELETE FROM tenants WHERE id = $1`, id)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation {
+		return ErrInUse
+	}
+
+	if err != nil {
+		return err
+	}
+
+	n := res.RowsAffected()
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := recordSettingVersion(ctx, tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// FindTenantSettings returns overrides of tenant, settings which aren't overridden are left out,
+// brackets are ordered from the lowest
+func (db *DB) FindTenantSettings(ctx context.Context, tenantID int) (SettingsImport, error) {
+	ctx, span := db.startSpan(ctx, "FindTenantSettings")
+	defer span.End()
+
+	s := SettingsImport{Brackets: map[int][]TaxBracket{}}
+
+	type tenantAllowance struct {
+		kind          string
+		allowanceType string
+		amount        float64
+	}
+
+	allowances, err := queryAll(ctx, db.getPool(), func(row rowScanner) (tenantAllowance, error) {
+		var a tenantAllowance
+		err := row.Scan(&a.kind, &a.allowanceType, &a.amount)
+		return a, err
+	},
+		`
+		SELECT allowance_kind, allowance_type, amount FROM tenant_allowances
+		WHERE tenant_id = $1
+		ORDER BY allowance_type
+		`, tenantID)
+	if err != nil {
+		return SettingsImport{}, err
+	}
+
+	for _, a := range allowances {
+		switch a.kind {
+		case AllowanceKindDefault:
+			s.DefaultAllowances = append(s.DefaultAllowances, DefaultAllowance{AllowanceType: a.allowanceType, Amount: a.amount})
+		case AllowanceKindAllowed:
+			s.AllowedAllowances = append(s.AllowedAllowances, AllowedAllowance{AllowanceType: a.allowanceType, MaxAmount: a.amount})
+		}
+	}
+
+	brackets, err := queryAll(ctx, db.getPool(), scanTaxBracket,
+		`
+		SELECT tax_year, level, percentage, max_amount, label, labels FROM tenant_tax_brackets
+		WHERE tenant_id = $1
+		ORDER BY tax_year, level
+		`, tenantID)
+	if err != nil {
+		return SettingsImport{}, err
+	}
+
+	for _, b := range brackets {
+		s.Brackets[b.TaxYear] = append(s.Brackets[b.TaxYear], b)
+	}
+
+	return s, nil
+}
+
+// ReplaceTenantSettings replaces every override of tenant with s in one transaction, ErrNotFound is returned for an unknown tenant
+func (db *DB) ReplaceTenantSettings(ctx context.Context, tenantID int, s SettingsImport) error {
+	ctx, span := db.startSpan(ctx, "ReplaceTenantSettings")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// the lock keeps concurrent replaces from mixing their overrides
+	if err := lockSettings(ctx, tx); err != nil {
+		return err
+	}
+
+	var id int
+	err = tx.QueryRow(ctx, `SELECT id FROM tenants WHERE id = $1 FOR UPDATE`, tenantID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM tenant_allowances WHERE tenant_id = $1`, tenantID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM tenant_tax_brackets WHERE tenant_id = $1`, tenantID); err != nil {
+		return err
+	}
+
+	insertAllowance := func(kind string, allowanceType string, amount float64) error {
+		_, err := tx.Exec(ctx,
+			`
+			INSERT INTO tenant_allowances (tenant_id, allowance_kind, allowance_type, amount)
+			VALUES ($1, $2, $3, $4)
+			`, tenantID, kind, allowanceType, amount)
+		return err
+	}
+
+	for _, a := range s.DefaultAllowances {
+		if err := insertAllowance(AllowanceKindDefault, a.AllowanceType, a.Amount); err != nil {
+			return err
+		}
+	}
+
+	for _, a := range s.AllowedAllowances {
+		if err := insertAllowance(AllowanceKindAllowed, a.AllowanceType, a.MaxAmount); err != nil {
+			return err
+		}
+	}
+
+	for taxYear, brackets := range s.Brackets {
+		for i, b := range brackets {
+			labels, err := json.Marshal(b.Labels)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.Exec(ctx,
+				`
+				INSERT INTO tenant_tax_brackets (tenant_id, tax_year, level, percentage, max_amount, label, labels)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+				`, tenantID, taxYear, i+1, b.Percentage, b.MaxAmount, b.Label, labels)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE tenants SET updated_at = now() WHERE id = $1`, tenantID); err != nil {
+		return err
+	}
+
+	if _, err := recordSettingVersion(ctx, tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Tenant is a subsidiary served by the deployment, api keys assigned to it calculate with its overrides
+type Tenant struct {
+	ID        int       `db:"id"`
+	Name      string    `db:"name"`
+	UUID      string    `db:"uuid"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
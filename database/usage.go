@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// RecordAPIKeyUsage adds usage of key in period, period is first day of the month
+func (db *DB) RecordAPIKeyUsage(ctx context.Context, keyID int, period time.Time, requests int64, csvRows int64) error {
+	ctx, span := db.startSpan(ctx, "RecordAPIKeyUsage")
+	defer span.End()
+
+	_, err := db.getPool().Exec(ctx,
+		`
+		INSERT INTO api_key_usage (api_key_id, period, requests, csv_rows)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (api_key_id, period) DO UPDATE
+		SET requests = api_key_usage.requests + EXCLUDED.requests,
+			csv_rows = api_key_usage.csv_rows + EXCLUDED.csv_rows,
+			updated_at = now()
+		`, keyID, period, requests, csvRows)
+
+	return err
+}
+
+// FindAPIKeyUsage returns zero usage when key has no usage in period
+func (db *DB) FindAPIKeyUsage(ctx context.Context, keyID int, period time.Time) (APIKeyUsage, error) {
+	ctx, span := db.startSpan(ctx, "FindAPIKeyUsage")
+	defer span.End()
+
+	u := APIKeyUsage{APIKeyID: keyID, Period: period}
+
+	err := db.getPool().QueryRow(ctx,
+		`SELECT requests, csv_rows FROM api_key_usage WHERE api_key_id = $1 AND period = $2`,
+		keyID, period).Scan(&u.Requests, &u.CSVRows)
+	if errors.Is(err, sql.ErrNoRows) {
+		return u, nil
+	}
+
+	if err != nil {
+		return APIKeyUsage{}, err
+	}
+
+	return u, nil
+}
+
+func (db *DB) FindAllAPIKeyUsage(ctx context.Context, period time.Time) ([]APIKeyUsage, error) {
+	ctx, span := db.startSpan(ctx, "FindAllAPIKeyUsage")
+	defer span.End()
+
+	return queryAll(ctx, db.getReadDB(), scanAPIKeyUsage,
+		`
+		SELECT k.id, k.name, k.monthly_quota, $1::date, COALESCE(u.requests, 0), COALESCE(u.csv_rows, 0)
+		FROM api_keys k
+		LEFT JOIN api_key_usage u ON u.api_key_id = k.id AND u.period = $1
+		ORDER BY k.id
+		`, period)
+}
+
+func scanAPIKeyUsage(row rowScanner) (APIKeyUsage, error) {
+	var u APIKeyUsage
+
+	err := row.Scan(&u.APIKeyID, &u.Name, &u.MonthlyQuota, &u.Period, &u.Requests, &u.CSVRows)
+	if err != nil {
+		return APIKeyUsage{}, err
+	}
+
+	return u, nil
+}
+
+type APIKeyUsage struct {
+	APIKeyID     int       `db:"api_key_id"`
+	Name         string    `db:"name"`
+	MonthlyQuota *int      `db:"monthly_quota"`
+	Period       time.Time `db:"period"`
+	Requests     int64     `db:"requests"`
+	CSVRows      int64     `db:"csv_rows"`
+}
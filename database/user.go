@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const userColumns = `id, email, password_hash, uuid, created_at, updated_at`
+
+func scanUser(row rowScanner) (User, error) {
+	var u User
+
+	err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.UUID, &u.CreatedAt, &u.UpdatedAt)
+
+	return u, err
+}
+
+// CreateUser returns ErrAlreadyExists when email is registered already
+func (db *DB) CreateUser(ctx context.Context, email string, passwordHash string) (User, error) {
+	ctx, span := db.startSpan(ctx, "CreateUser")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx,
+		`
+		INSERT INTO users (email, password_hash)
+		VALUES ($1, $2)
+		RETURNING `+userColumns, email, passwordHash)
+
+	u, err := scanUser(row)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+		return User{}, ErrAlreadyExists
+	}
+
+	return u, err
+}
+
+func (db *DB) FindUserByEmail(ctx context.Context, email string) (User, error) {
+	ctx, span := db.startSpan(ctx, "FindUserByEmail")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE email = $1`, email)
+
+	u, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrNotFound
+	}
+
+	return u, err
+}
+
+func (db *DB) FindUser(ctx context.Context, id int) (User, error) {
+	ctx, span := db.startSpan(ctx, "FindUser")
+	defer span.End()
+
+	row := db.getPool().QueryRow(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id)
+
+	u, err := scanUser(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrNotFound
+	}
+
+	return u, err
+}
+
+// User is a taxpayer account, handlers store email in lower case so it's unique regardless of case
+type User struct {
+	ID           int       `db:"id"`
+	Email        string    `db:"email"`
+	PasswordHash string    `db:"password_hash"`
+	UUID         string    `db:"uuid"`
+	CreatedAt    time.Time `db:"created_at"`
+	UpdatedAt    time.Time `db:"updated_at"`
+}
@@ -0,0 +1,316 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+const settingVersionColumns = `version, default_allowances, allowed_allowances, brackets, disabled_allowances, created_at`
+
+func scanSettingVersion(row rowScanner) (SettingVersion, error) {
+	var (
+		v                 SettingVersion
+		defaultAllowances []byte
+		allowedAllowances []byte
+		brackets          []byte
+		disabled          []byte
+	)
+
+	err := row.Scan(&v.Version, &defaultAllowances, &allowedAllowances, &brackets, &disabled, &v.CreatedAt)
+	if err != nil {
+		return SettingVersion{}, err
+	}
+
+	if brackets != nil {
+		if err := json.Unmarshal(brackets, &v.Brackets); err != nil {
+			return SettingVersion{}, err
+		}
+	}
+
+	if disabled != nil {
+		if err := json.Unmarshal(disabled, &v.DisabledAllowances); err != nil {
+			return SettingVersion{}, err
+		}
+	}
+
+	if err := json.Unmarshal(defaultAllowances, &v.DefaultAllowances); err != nil {
+		return SettingVersion{}, err
+	}
+
+	if err := json.Unmarshal(allowedAllowances, &v.AllowedAllowances); err != nil {
+		return SettingVersion{}, err
+	}
+
+	return v, nil
+}
+
+type expectedVersionKey struct{}
+
+// WithExpectedVersion makes settings writes with ctx fail with ErrConflict
+// unless version is still the latest settings version when they start
+func WithExpectedVersion(ctx context.Context, version int) context.Context {
+	return context.WithValue(ctx, expectedVersionKey{}, version)
+}
+
+// ExpectedVersion returns version set by WithExpectedVersion
+func ExpectedVersion(ctx context.Context) (int, bool) {
+	v, ok := ctx.Value(expectedVersionKey{}).(int)
+	return v, ok
+}
+
+// lockSettings serializes settings writes until tx ends and checks version expected by ctx,
+// it must be called by every write of settings before changing anything
+func lockSettings(ctx context.Context, tx pgx.Tx) error {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('settings'))`); err != nil {
+		return err
+	}
+
+	expected, ok := ExpectedVersion(ctx)
+	if !ok {
+		return nil
+	}
+
+	var latest int
+
+	err := tx.QueryRow(ctx, `SELECT COALESCE(max(version), 0) FROM setting_versions`).Scan(&latest)
+	if err != nil {
+		return err
+	}
+
+	if latest != expected {
+		return ErrConflict
+	}
+
+	return nil
+}
+
+// tryLockSettings takes the lock of lockSettings when it's free and reports whether it was taken, so a job running
+// on every replica is done by one of them while the others skip it
+func tryLockSettings(ctx context.Context, tx pgx.Tx) (bool, error) {
+	var locked bool
+
+	err := tx.QueryRow(ctx, `SELECT pg_try_advisory_xact_lock(hashtext('settings'))`).Scan(&locked)
+
+	return locked, err
+}
+
+// recordSettingVersion snapshots current settings in tx, it must be called by every write of settings
+func recordSettingVersion(ctx context.Context, tx pgx.Tx) (SettingVersion, error) {
+	if err := notifySettingsChanged(ctx, tx); err != nil {
+		return SettingVersion{}, err
+	}
+
+	if err := syncSettingHistory(ctx, tx); err != nil {
+		return SettingVersion{}, err
+	}
+
+	brackets, err := findAllTaxBrackets(ctx, tx)
+	if err != nil {
+		return SettingVersion{}, err
+	}
+
+	snapshot, err := json.Marshal(brackets)
+	if err != nil {
+		return SettingVersion{}, err
+	}
+
+	row := tx.QueryRow(ctx,
+		`
+		INSERT INTO setting_versions (default_allowances, allowed_allowances, brackets, disabled_allowances)
+		SELECT
+			(SELECT COALESCE(jsonb_object_agg(allowance_type, amount), '{}') FROM default_allowances),
+			(SELECT COALESCE(jsonb_object_agg(allowance_type, max_amount), '{}') FROM allowed_allowances),
+			$1,
+			jsonb_build_object(
+				'default', (SELECT COALESCE(jsonb_agg(allowance_type ORDER BY allowance_type), '[]') FROM default_allowances WHERE disabled_at IS NOT NULL),
+				'allowed', (SELECT COALESCE(jsonb_agg(allowance_type ORDER BY allowance_type), '[]') FROM allowed_allowances WHERE disabled_at IS NOT NULL)
+			)
+		RETURNING `+settingVersionColumns, snapshot)
+
+	return scanSettingVersion(row)
+}
+
+func (db *DB) FindAllSettingVersions(ctx context.Context) ([]SettingVersion, error) {
+	ctx, span := db.startSpan(ctx, "FindAllSettingVersions")
+	defer span.End()
+
+	return queryAll(ctx, db.getReadDB(), scanSettingVersion,
+		`SELECT `+settingVersionColumns+` FROM setting_versions ORDER BY version DESC`)
+}
+
+// LatestSettingVersion returns the version recorded by the latest write of settings, 0 when none is recorded
+func (db *DB) LatestSettingVersion(ctx context.Context) (int, error) {
+	ctx, span := db.startSpan(ctx, "LatestSettingVersion")
+	defer span.End()
+
+	// read like FindAll* queries, so the version is never ahead of the settings it tags
+	versions, err := queryAll(ctx, db.getReadDB(), func(row rowScanner) (int, error) {
+		var v int
+		err := row.Scan(&v)
+		return v, err
+	}, `SELECT COALESCE(max(version), 0) FROM setting_versions`)
+	if err != nil {
+		return 0, err
+	}
+
+	return versions[0], nil
+}
+
+// RollbackSettings restores settings of version atomically and records them as a new version,
+// allowance types added after the version are removed and types removed since are added back.
+// Brackets and disabled types are restored too unless the version predates their snapshots.
+// It returns ErrNotFound when version doesn't exist
+func (db *DB) RollbackSettings(ctx context.Context, version int) (SettingVersion, error) {
+	ctx, span := db.startSpan(ctx, "RollbackSettings")
+	defer span.End()
+
+	tx, err := db.getPool().Begin(ctx)
+	if err != nil {
+		return SettingVersion{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockSettings(ctx, tx); err != nil {
+		return SettingVersion{}, err
+	}
+
+	target, err := scanSettingVersion(tx.QueryRow(ctx,
+		`SELECT `+settingVersionColumns+` FROM setting_versions WHERE version = $1`, version))
+	if errors.Is(err, sql.ErrNoRows) {
+		return SettingVersion{}, ErrNotFound
+	}
+
+	if err != nil {
+		return SettingVersion{}, err
+	}
+
+	if err := restoreAllowanceTypes(ctx, tx, AllowanceKindDefault, target.DefaultAllowances); err != nil {
+		return SettingVersion{}, err
+	}
+
+	if err := restoreAllowanceTypes(ctx, tx, AllowanceKindAllowed, target.AllowedAllowances); err != nil {
+		return SettingVersion{}, err
+	}
+
+	now := time.Now()
+
+	for allowanceType, amount := range target.DefaultAllowances {
+		if err := applyAllowance(ctx, tx, AllowanceKindDefault, allowanceType, amount, now); err != nil {
+			return SettingVersion{}, err
+		}
+	}
+
+	for allowanceType, amount := range target.AllowedAllowances {
+		if err := applyAllowance(ctx, tx, AllowanceKindAllowed, allowanceType, amount, now); err != nil {
+			return SettingVersion{}, err
+		}
+	}
+
+	if target.DisabledAllowances != nil {
+		for _, kind := range []string{AllowanceKindDefault, AllowanceKindAllowed} {
+			if err := restoreDisabledAllowances(ctx, tx, kind, target.DisabledAllowances[kind]); err != nil {
+				return SettingVersion{}, err
+			}
+		}
+	}
+
+	if target.Brackets != nil {
+		if err := repository.New(tx).DeleteAllTaxBrackets(ctx); err != nil {
+			return SettingVersion{}, err
+		}
+
+		for taxYear, brackets := range target.Brackets {
+			if err := insertTaxBrackets(ctx, tx, taxYear, brackets); err != nil {
+				return SettingVersion{}, err
+			}
+		}
+	}
+
+	v, err := recordSettingVersion(ctx, tx)
+	if err != nil {
+		return SettingVersion{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return SettingVersion{}, err
+	}
+
+	return v, nil
+}
+
+// allowanceTables are tables and amount columns of allowance types by their kind
+var allowanceTables = map[string][2]string{
+	AllowanceKindDefault: {"default_allowances", "amount"},
+	AllowanceKindAllowed: {"allowed_allowances", "max_amount"},
+}
+
+// restoreAllowanceTypes makes allowance types of kind the types of amounts, types which aren't in amounts are
+// deleted with their effective values and missing types are inserted, their amounts are applied by the caller
+func restoreAllowanceTypes(ctx context.Context, tx pgx.Tx, kind string, amounts map[string]float64) error {
+	table, column := allowanceTables[kind][0], allowanceTables[kind][1]
+
+	kept, err := json.Marshal(sortedKeys(amounts))
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM `+table+` WHERE NOT ($1::jsonb ? allowance_type)`, kept); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`DELETE FROM effective_allowances WHERE allowance_kind = $1 AND NOT ($2::jsonb ? allowance_type)`, kind, kept)
+	if err != nil {
+		return err
+	}
+
+	for allowanceType, amount := range amounts {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO `+table+` (allowance_type, `+column+`) VALUES ($1, $2) ON CONFLICT (allowance_type) DO NOTHING`,
+			allowanceType, amount)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreDisabledAllowances disables allowance types of kind in disabled and enables the others,
+// types already in their state keep their disabled_at
+func restoreDisabledAllowances(ctx context.Context, tx pgx.Tx, kind string, disabled []string) error {
+	if disabled == nil {
+		disabled = []string{}
+	}
+
+	types, err := json.Marshal(disabled)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx,
+		`
+		UPDATE `+allowanceTables[kind][0]+`
+		SET disabled_at = CASE WHEN $1::jsonb ? allowance_type THEN now() END, updated_at = now()
+		WHERE (disabled_at IS NOT NULL) <> ($1::jsonb ? allowance_type)
+		`, types)
+
+	return err
+}
+
+// SettingVersion is a snapshot of settings, Brackets and DisabledAllowances are nil for versions
+// recorded before they were snapshotted
+type SettingVersion struct {
+	Version            int                  `db:"version"`
+	DefaultAllowances  map[string]float64   `db:"default_allowances"`
+	AllowedAllowances  map[string]float64   `db:"allowed_allowances"`
+	Brackets           map[int][]TaxBracket `db:"brackets"`            // brackets by tax year
+	DisabledAllowances map[string][]string  `db:"disabled_allowances"` // disabled types by allowance kind
+	CreatedAt          time.Time            `db:"created_at"`
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+const webhookColumns = `id, url, secret, uuid, created_at, updated_at`
+
+func scanWebhook(row rowScanner) (Webhook, error) {
+	var w Webhook
+
+	err := row.Scan(&w.ID, &w.URL, &w.Secret, &w.UUID, &w.CreatedAt, &w.UpdatedAt)
+	if err != nil {
+		return Webhook{}, err
+	}
+
+	return w, nil
+}
+
+func (db *DB) FindAllWebhooks(ctx context.Context) ([]Webhook, error) {
+	ctx, span := db.startSpan(ctx, "FindAllWebhooks")
+	defer span.End()
+
+	return queryAll(ctx, db.getReadDB(), scanWebhook,
+		`
+		SELECT `+webhookColumns+` FROM webhooks ORDER BY id
+		`)
+}
+
+func (db *DB) CreateWebhook(ctx context.Context, url string, secret string) (Webhook, error) {
+	ctx, span := db.startSpan(ctx, "CreateWebhook")
+	defer span.End()
+
+	return scanWebhook(db.getPool().QueryRow(ctx,
+		`
+		INSERT INTO webhooks (url, secret)
+		VALUES ($1, $2)
+		RETURNING `+webhookColumns, url, secret))
+}
+
+func (db *DB) DeleteWebhook(ctx context.Context, id int) error {
+	ctx, span := db.startSpan(ctx, "DeleteWebhook")
+	defer span.End()
+
+	res, err := db.getPool().Exec(ctx, `DELETE FROM webhooks WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+
+	n := res.RowsAffected()
+
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+type Webhook struct {
+	ID        int       `db:"id"`
+	URL       string    `db:"url"`
+	Secret    string    `db:"secret"`
+	UUID      string    `db:"uuid"`
+	CreatedAt time.Time `db:"created_at"`
+	UpdatedAt time.Time `db:"updated_at"`
+}
@@ -0,0 +1,141 @@
+package exchangerate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultBOTURL = "https://gateway.api.bot.or.th/Stat-ExchangeRate/v2/DAILY_AVG_EXG_RATE/"
+
+	dateLayout = "2006-01-02"
+
+	// rates aren't published on weekends and holidays, the latest rate within this many days before is used
+	lookbackDays = 7
+)
+
+// BOT returns daily average buying rates of transfers published by the Bank of Thailand. Rates are cached daily,
+// a rate of a past date doesn't change, but one of today may not be published yet so it's requested again tomorrow
+type BOT struct {
+	client  *http.Client
+	baseURL string
+	token   string
+	now     func() time.Time
+
+	mu    sync.Mutex
+	rates map[string]cachedRate // by currency and date
+}
+
+type cachedRate struct {
+	rate      float64
+	fetchedOn string
+}
+
+func NewBOT(client *http.Client, baseURL string, token string) *BOT {
+	return &BOT{client: client, baseURL: baseURL, token: token, now: time.Now, rates: make(map[string]cachedRate)}
+}
+
+// SetClock sets clock deciding when cached rates are requested again
+func (b *BOT) SetClock(now func() time.Time) *BOT {
+	b.now = now
+	return b
+}
+
+type botResponse struct {
+	Result struct {
+		Data struct {
+			DataDetail []struct {
+				Period         string `json:"period"`
+				BuyingTransfer string `json:"buying_transfer"`
+			} `json:"data_detail"`
+		} `json:"data"`
+	} `json:"result"`
+}
+
+func (b *BOT) Rate(ctx context.Context, currency string, on time.Time) (float64, error) {
+	currency = normalizeCurrency(currency)
+	if currency == baht {
+		return 1, nil
+	}
+
+	date, today := on.Format(dateLayout), b.now().Format(dateLayout)
+	key := currency + ":" + date
+
+	b.mu.Lock()
+	cached, ok := b.rates[key]
+	b.mu.Unlock()
+
+	if ok && (cached.fetchedOn == today || date < cached.fetchedOn) {
+		return cached.rate, nil
+	}
+
+	rate, err := b.fetch(ctx, currency, on)
+	if err != nil {
+		return 0, err
+	}
+
+	b.mu.Lock()
+	b.rates[key] = cachedRate{rate: rate, fetchedOn: today}
+	b.mu.Unlock()
+
+	return rate, nil
+}
+
+// fetch requests rates of the days up to on, and returns the latest one
+func (b *BOT) fetch(ctx context.Context, currency string, on time.Time) (float64, error) {
+	q := url.Values{}
+	q.Set("start_period", on.AddDate(0, 0, -lookbackDays).Format(dateLayout))
+	q.Set("end_period", on.Format(dateLayout))
+	q.Set("currency", currency)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, b.baseURL+"?"+q.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Authorization", b.token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("cannot request exchange rate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("cannot request exchange rate: status %d", resp.StatusCode)
+	}
+
+	var body botResponse
+
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("cannot decode exchange rate: %w", err)
+	}
+
+	var latest string
+	var rate float64
+
+	for _, d := range body.Result.Data.DataDetail {
+		r, err := strconv.ParseFloat(d.BuyingTransfer, 64)
+		if err != nil || r <= 0 {
+			continue
+		}
+
+		// periods are dates, so they compare in order as strings
+		if d.Period > latest {
+			latest, rate = d.Period, r
+		}
+	}
+
+	if latest == "" {
+		return 0, ErrRateNotFound
+	}
+
+	return rate, nil
+}
@@ -0,0 +1,40 @@
+// Package exchangerate converts foreign currencies to baht, by a static table or by rates of the Bank of Thailand
+package exchangerate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// ErrRateNotFound is returned when a provider has no rate of the currency on or before the date
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// RateProvider returns baht per unit of currency on date, currency is an ISO 4217 code like USD
+type RateProvider interface {
+	Rate(ctx context.Context, currency string, on time.Time) (float64, error)
+}
+
+const baht = "THB"
+
+// Static has fixed rates by currency code whatever the date, e.g. for tests or when the BOT API can't be used
+type Static map[string]float64
+
+func (s Static) Rate(_ context.Context, currency string, _ time.Time) (float64, error) {
+	currency = normalizeCurrency(currency)
+	if currency == baht {
+		return 1, nil
+	}
+
+	rate, ok := s[currency]
+	if !ok {
+		return 0, ErrRateNotFound
+	}
+
+	return rate, nil
+}
+
+func normalizeCurrency(currency string) string {
+	return strings.ToUpper(strings.TrimSpace(currency))
+}
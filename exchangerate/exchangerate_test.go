@@ -0,0 +1,96 @@
+package exchangerate
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatic(t *testing.T) {
+	rates := Static{"USD": 36.5}
+	on := time.Date(2024, time.June, 28, 0, 0, 0, 0, time.UTC)
+
+	rate, err := rates.Rate(context.Background(), " usd", on)
+	assert.NoError(t, err)
+	assert.Equal(t, 36.5, rate)
+
+	rate, err = rates.Rate(context.Background(), "THB", on)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, rate)
+
+	_, err = rates.Rate(context.Background(), "EUR", on)
+	assert.ErrorIs(t, err, ErrRateNotFound)
+}
+
+func TestBOT(t *testing.T) {
+	var requests int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		assert.Equal(t, "token", r.Header.Get("Authorization"))
+		assert.Equal(t, "USD", r.URL.Query().Get("currency"))
+		assert.Equal(t, "2024-06-30", r.URL.Query().Get("end_period"))
+
+		// 2024-06-30 is a Sunday, so the rate of Friday is the latest
+		_, _ = w.Write([]byte(`{"result":{"data":{"data_detail":[
+			{"period":"2024-06-28","buying_transfer":"36.5"},
+			{"period":"2024-06-27","buying_transfer":"36.4"},
+			{"period":"2024-06-26","buying_transfer":""}
+		]}}}`))
+	}))
+	defer srv.Close()
+
+	now := time.Date(2024, time.June, 30, 12, 0, 0, 0, time.UTC)
+	bot := NewBOT(srv.Client(), srv.URL, "token").SetClock(func() time.Time { return now })
+
+	on := time.Date(2024, time.June, 30, 0, 0, 0, 0, time.UTC)
+
+	rate, err := bot.Rate(context.Background(), "usd", on)
+	assert.NoError(t, err)
+	assert.Equal(t, 36.5, rate)
+
+	// cached for the rest of the day
+	_, err = bot.Rate(context.Background(), "USD", on)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, requests)
+
+	// rate of today may be published later, so it's requested again the next day
+	now = now.AddDate(0, 0, 1)
+
+	_, err = bot.Rate(context.Background(), "USD", on)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests)
+
+	// then it's final
+	now = now.AddDate(0, 0, 1)
+
+	_, err = bot.Rate(context.Background(), "USD", on)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, requests)
+}
+
+func TestBOTErrors(t *testing.T) {
+	status := http.StatusOK
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"result":{"data":{"data_detail":[]}}}`))
+	}))
+	defer srv.Close()
+
+	bot := NewBOT(srv.Client(), srv.URL, "token")
+	on := time.Date(2024, time.June, 28, 0, 0, 0, 0, time.UTC)
+
+	_, err := bot.Rate(context.Background(), "USD", on)
+	assert.ErrorIs(t, err, ErrRateNotFound)
+
+	status = http.StatusUnauthorized
+
+	_, err = bot.Rate(context.Background(), "USD", on)
+	assert.ErrorContains(t, err, "status 401")
+}
@@ -0,0 +1,68 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/AnnaCarter465/assessment-tax/handler"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	taxv1 "github.com/AnnaCarter465/assessment-tax/proto/tax/v1"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// NewGateway serves TaxService as JSON by the paths of proto/tax/v1/tax_gateway.yaml. Calls of the gateway
+// don't pass through interceptors, so its routes must be authenticated and limited by middlewares of the HTTP API
+func NewGateway(ctx context.Context, s *Server) (echo.HandlerFunc, error) {
+	mux := runtime.NewServeMux(
+		// fields are named like messages of the gRPC API, unset fields are responded as zero values
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions:   protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+			UnmarshalOptions: protojson.UnmarshalOptions{DiscardUnknown: true},
+		}),
+		runtime.WithErrorHandler(gatewayError),
+	)
+
+	if err := taxv1.RegisterTaxServiceHandlerServer(ctx, mux, s); err != nil {
+		return nil, err
+	}
+
+	return func(c echo.Context) error {
+		req := c.Request()
+
+		if k, ok := handler.CurrentAPIKey(c); ok {
+			req = req.WithContext(WithAPIKey(req.Context(), k))
+		}
+
+		mux.ServeHTTP(c.Response(), req)
+
+		return nil
+	}, nil
+}
+
+// gatewayError responds errors of calls like errors of the HTTP API, with reason of ErrorInfo as error code
+func gatewayError(_ context.Context, _ *runtime.ServeMux, _ runtime.Marshaler, w http.ResponseWriter, _ *http.Request, err error) {
+	st := status.Convert(err)
+
+	// errors of the gateway itself, e.g. invalid json, have no ErrorInfo
+	code := errcode.Internal
+	if st.Code() == codes.InvalidArgument {
+		code = errcode.InvalidRequest
+	}
+
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok && info.Domain == errorDomain {
+			code = errcode.Code(info.Reason)
+		}
+	}
+
+	w.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+	w.WriteHeader(runtime.HTTPStatusFromCode(st.Code()))
+
+	_ = json.NewEncoder(w).Encode(handler.ResponseMsg{Message: handler.ErrorMessage(code), ErrorCode: code})
+}
@@ -0,0 +1,73 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/handler"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGateway(t *testing.T) {
+	db := database.NewMemory()
+	s := NewServer(handler.NewTaxHandler(validator.New(), db), handler.NewConfigHandler(db))
+
+	gateway, err := NewGateway(context.Background(), s)
+	assert.NoError(t, err)
+
+	e := echo.New()
+	e.POST("/tax/gateway/calculations", gateway)
+	e.GET("/tax/gateway/deductions", gateway)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/tax/gateway/calculations",
+		strings.NewReader(`{"request":{"total_income":500000,"allowances":[{"allowance_type":"donation","amount":0}]}}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var calculated struct {
+		Response struct {
+			Tax      float64 `json:"tax"`
+			TaxLevel []any   `json:"tax_level"`
+		} `json:"response"`
+		Degraded bool `json:"degraded"`
+	}
+
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &calculated))
+	assert.Equal(t, 29_000.0, calculated.Response.Tax)
+	assert.NotEmpty(t, calculated.Response.TaxLevel)
+	assert.Contains(t, rec.Body.String(), `"degraded":false`, "unset fields are responded")
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/tax/gateway/calculations", strings.NewReader(`{"request":{"total_income":100,"wht":200}}`))
+	e.ServeHTTP(rec, req)
+
+	var failed handler.ResponseMsg
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &failed))
+	assert.Equal(t, errcode.WhtExceedsIncome, failed.ErrorCode, "errors are responded like errors of the HTTP API")
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/tax/gateway/calculations", strings.NewReader(`not json`)))
+
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &failed))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, errcode.InvalidRequest, failed.ErrorCode)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tax/gateway/deductions", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"default_allowances"`)
+}
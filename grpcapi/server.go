@@ -0,0 +1,364 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/handler"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	taxv1 "github.com/AnnaCarter465/assessment-tax/proto/tax/v1"
+	"github.com/labstack/echo/v4/middleware"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// tax year used when request doesn't specify one, same as the HTTP API
+const defaultTaxYear = 2024
+
+// metadata of api keys and signatures, like headers of the HTTP API
+const (
+	apiKeyMetadata             = "x-api-key"
+	signatureMetadata          = "x-signature"
+	signatureTimestampMetadata = "x-signature-timestamp"
+)
+
+// errorDomain is domain of ErrorInfo details, their reason is the error code of the HTTP API
+const errorDomain = "assessment-tax"
+
+// Server serves TaxService by the same calculation and settings as the HTTP API
+type Server struct {
+	taxv1.UnimplementedTaxServiceServer
+
+	calculations *handler.TaxHandler
+	deductions   *handler.ConfigHandler
+	now          func() time.Time
+}
+
+func NewServer(calculations *handler.TaxHandler, deductions *handler.ConfigHandler) *Server {
+	return &Server{calculations: calculations, deductions: deductions, now: time.Now}
+}
+
+// SetClock sets clock of default date of allowances, it should be the clock of calculations
+func (s *Server) SetClock(now func() time.Time) *Server {
+	s.now = now
+	return s
+}
+
+func (s *Server) CalculateTax(ctx context.Context, req *taxv1.CalculateTaxRequest) (*taxv1.CalculateTaxResponse, error) {
+	taxYear := defaultTaxYear
+	if req.GetTaxYear() != 0 {
+		taxYear = handler.NormalizeTaxYear(int(req.GetTaxYear()))
+	}
+
+	effectiveDate, err := s.effectiveDate(req)
+	if err != nil {
+		return nil, newStatus(codes.InvalidArgument, errcode.InvalidDate)
+	}
+
+	calc := handler.Calculation{
+		TaxRequest:    handler.TaxRequestFromProto(req.GetRequest()),
+		TaxYear:       taxYear,
+		EffectiveDate: effectiveDate,
+		Language:      handler.PreferredLanguage(req.GetLanguage()),
+		APIKeyID:      currentAPIKeyID(ctx),
+		TenantID:      currentTenantID(ctx),
+	}
+
+	resp, degraded, err := s.calculations.Calculate(ctx, calc)
+	if err != nil {
+		var calcErr *handler.CalculationError
+		if errors.As(err, &calcErr) {
+			return nil, newStatus(calculationCode(calcErr.Status), calcErr.Code)
+		}
+
+		return nil, queryError(ctx)
+	}
+
+	return &taxv1.CalculateTaxResponse{Response: resp.Proto(), Degraded: degraded}, nil
+}
+
+// effectiveDate returns date of allowances like query params of the HTTP API, it is date,
+// the last day of tax_year or today. tax_year is in either Gregorian or Buddhist era
+func (s *Server) effectiveDate(req *taxv1.CalculateTaxRequest) (time.Time, error) {
+	if req.GetDate() != "" {
+		return time.Parse(time.DateOnly, req.GetDate())
+	}
+
+	if req.GetTaxYear() != 0 {
+		return time.Date(handler.NormalizeTaxYear(int(req.GetTaxYear())), time.December, 31, 0, 0, 0, 0, time.UTC), nil
+	}
+
+	return s.now().UTC().Truncate(24 * time.Hour), nil
+}
+
+func (s *Server) GetDeductions(ctx context.Context, _ *taxv1.GetDeductionsRequest) (*taxv1.GetDeductionsResponse, error) {
+	deductions, err := s.deductions.Deductions(ctx, currentTenantID(ctx))
+	if err != nil {
+		return nil, queryError(ctx)
+	}
+
+	resp := &taxv1.GetDeductionsResponse{}
+
+	for _, a := range deductions.DefaultAllowances {
+		resp.DefaultAllowances = append(resp.DefaultAllowances, &taxv1.DefaultAllowance{
+			AllowanceType: a.AllowanceType,
+			Amount:        a.Amount,
+		})
+	}
+
+	for _, a := range deductions.AllowedAllowances {
+		resp.AllowedAllowances = append(resp.AllowedAllowances, &taxv1.AllowedAllowance{
+			AllowanceType: a.AllowanceType,
+			MaxAmount:     a.MaxAmount,
+		})
+	}
+
+	return resp, nil
+}
+
+// calculationCode maps HTTP status of a rejected calculation to gRPC code
+func calculationCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
+// queryError is like respondQueryError of the HTTP API
+func queryError(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return newStatus(codes.Unavailable, errcode.RequestTimeout)
+	}
+
+	return newStatus(codes.Internal, errcode.Internal)
+}
+
+// newStatus returns error with English message of code, and the code itself as reason of ErrorInfo
+func newStatus(c codes.Code, code errcode.Code) error {
+	st := status.New(c, handler.ErrorMessage(code))
+
+	withInfo, err := st.WithDetails(&errdetails.ErrorInfo{Reason: string(code), Domain: errorDomain})
+	if err != nil {
+		return st.Err()
+	}
+
+	return withInfo.Err()
+}
+
+type apiKeyContextKey struct{}
+
+// methodScopes are scopes required by methods, like scopes of routes of the HTTP API
+var methodScopes = map[string]string{
+	taxv1.TaxService_CalculateTax_FullMethodName:  handler.ScopeCalculate,
+	taxv1.TaxService_GetDeductions_FullMethodName: handler.ScopeConfigRead,
+}
+
+// APIKeyAuth authenticates calls by x-api-key metadata and checks scope of the method
+func APIKeyAuth(db handler.APIKeyAuthIDB) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		keys := md.Get(apiKeyMetadata)
+		if len(keys) == 0 || keys[0] == "" {
+			return nil, newStatus(codes.Unauthenticated, errcode.APIKeyInvalid)
+		}
+
+		apiKey, err := handler.FindAPIKey(ctx, db, keys[0])
+		if errors.Is(err, database.ErrNotFound) {
+			return nil, newStatus(codes.Unauthenticated, errcode.APIKeyInvalid)
+		}
+
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to find api key", "error", err)
+			return nil, queryError(ctx)
+		}
+
+		if scope, ok := methodScopes[info.FullMethod]; ok && !slices.Contains(apiKey.Scopes, scope) {
+			return nil, newStatus(codes.PermissionDenied, errcode.APIKeyScopeDenied)
+		}
+
+		return next(WithAPIKey(ctx, apiKey), req)
+	}
+}
+
+// VerifySignature rejects tampered calls or calls older than maxAge from keys with signing secret,
+// like VerifySignature of the HTTP API. The signed method is POST, path is the full method, e.g. /tax.v1.TaxService/CalculateTax,
+// and body is the request message serialized in field order. It must be used after APIKeyAuth
+func VerifySignature(maxAge func() time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		k, ok := ctx.Value(apiKeyContextKey{}).(database.APIKey)
+		if !ok || k.SigningSecret == nil {
+			return next(ctx, req)
+		}
+
+		md, _ := metadata.FromIncomingContext(ctx)
+		timestamp := firstMetadata(md, signatureTimestampMetadata)
+
+		signature, code, ok := handler.ParseSignature(firstMetadata(md, signatureMetadata), timestamp, maxAge())
+		if !ok {
+			return nil, newStatus(codes.Unauthenticated, code)
+		}
+
+		msg, ok := req.(proto.Message)
+		if !ok {
+			return nil, newStatus(codes.Internal, errcode.Internal)
+		}
+
+		body, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+		if err != nil {
+			return nil, newStatus(codes.InvalidArgument, errcode.InvalidRequest)
+		}
+
+		want := handler.SignRequest(*k.SigningSecret, http.MethodPost, info.FullMethod, timestamp, body)
+		if !hmac.Equal([]byte(signature), []byte(want)) {
+			return nil, newStatus(codes.Unauthenticated, errcode.SignatureInvalid)
+		}
+
+		return next(ctx, req)
+	}
+}
+
+// RateLimit rejects calls over the limit of store, by api key or by address of the peer like RateLimit of the HTTP API.
+// store returns the store of the current settings, nil when the limit is off
+func RateLimit(store func() middleware.RateLimiterStore) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		s := store()
+		if s == nil {
+			return next(ctx, req)
+		}
+
+		allowed, err := s.Allow(rateLimitIdentifier(ctx))
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to check rate limit", "error", err)
+		}
+
+		if !allowed {
+			return nil, newStatus(codes.ResourceExhausted, errcode.TooManyRequests)
+		}
+
+		return next(ctx, req)
+	}
+}
+
+func rateLimitIdentifier(ctx context.Context) string {
+	if k, ok := ctx.Value(apiKeyContextKey{}).(database.APIKey); ok {
+		return "key:" + strconv.Itoa(k.ID)
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "ip:"
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return "ip:" + p.Addr.String()
+	}
+
+	return "ip:" + host
+}
+
+// UsageMeter counts calls of authenticated api key and rejects keys over monthly quota, calls and requests
+// of the HTTP API share the quota. It must be used after APIKeyAuth
+func UsageMeter(db handler.UsageMeterIDB) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		k, ok := ctx.Value(apiKeyContextKey{}).(database.APIKey)
+		if !ok {
+			return next(ctx, req)
+		}
+
+		period := handler.UsagePeriod(time.Now())
+
+		if k.MonthlyQuota != nil {
+			usage, err := db.FindAPIKeyUsage(ctx, k.ID, period)
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to find api key usage", "error", err)
+				return nil, queryError(ctx)
+			}
+
+			if usage.Requests >= int64(*k.MonthlyQuota) {
+				return nil, newStatus(codes.ResourceExhausted, errcode.QuotaExceeded)
+			}
+		}
+
+		resp, err := next(ctx, req)
+
+		// usage is recorded even when the call was timed out
+		if err := db.RecordAPIKeyUsage(context.WithoutCancel(ctx), k.ID, period, 1, 0); err != nil {
+			slog.ErrorContext(ctx, "failed to record api key usage", "error", err)
+		}
+
+		return resp, err
+	}
+}
+
+// WithAPIKey returns ctx of a call authenticated by k, e.g. by APIKeyAuth of the HTTP API for calls through the gateway
+func WithAPIKey(ctx context.Context, k database.APIKey) context.Context {
+	return context.WithValue(ctx, apiKeyContextKey{}, k)
+}
+
+// firstMetadata returns the first value of key, "" when it's missing
+func firstMetadata(md metadata.MD, key string) string {
+	values := md.Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// currentAPIKeyID returns id of authenticated key, nil when api key auth is disabled
+func currentAPIKeyID(ctx context.Context) *int {
+	k, ok := ctx.Value(apiKeyContextKey{}).(database.APIKey)
+	if !ok {
+		return nil
+	}
+
+	return &k.ID
+}
+
+// currentTenantID returns tenant of authenticated key, nil when the key has none or api key auth is disabled
+func currentTenantID(ctx context.Context) *int {
+	k, ok := ctx.Value(apiKeyContextKey{}).(database.APIKey)
+	if !ok {
+		return nil
+	}
+
+	return k.TenantID
+}
+
+// Recover responds Internal instead of crashing the process when a method panics
+func Recover() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.ErrorContext(ctx, "recovered from panic",
+					"method", info.FullMethod,
+					"panic", fmt.Sprint(r),
+					"stack", string(debug.Stack()))
+				err = newStatus(codes.Internal, errcode.Internal)
+			}
+		}()
+
+		return next(ctx, req)
+	}
+}
@@ -0,0 +1,295 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/handler"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/AnnaCarter465/assessment-tax/pkg/ratelimit"
+	taxv1 "github.com/AnnaCarter465/assessment-tax/proto/tax/v1"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+)
+
+// apiKeyDBStub finds keys by sha256 hash like the database does
+type apiKeyDBStub map[string]database.APIKey
+
+func (s apiKeyDBStub) FindActiveAPIKeyByHash(_ context.Context, hash string) (database.APIKey, error) {
+	for key, apiKey := range s {
+		sum := sha256.Sum256([]byte(key))
+		if hex.EncodeToString(sum[:]) == hash {
+			return apiKey, nil
+		}
+	}
+
+	return database.APIKey{}, database.ErrNotFound
+}
+
+func newTestClient(t *testing.T, opts ...grpc.ServerOption) taxv1.TaxServiceClient {
+	db := database.NewMemory()
+	calculations := handler.NewTaxHandler(validator.New(), db)
+
+	s := grpc.NewServer(opts...)
+	taxv1.RegisterTaxServiceServer(s, NewServer(calculations, handler.NewConfigHandler(db)))
+
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = s.Serve(lis) }()
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return taxv1.NewTaxServiceClient(conn)
+}
+
+func errorReason(t *testing.T, err error) string {
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			return info.Reason
+		}
+	}
+
+	t.Errorf("no ErrorInfo in %v", err)
+
+	return ""
+}
+
+func TestCalculateTax(t *testing.T) {
+	type TC struct {
+		req        *taxv1.CalculateTaxRequest
+		wantTax    float64
+		wantCode   codes.Code
+		wantReason errcode.Code
+	}
+
+	tcs := []TC{
+		{
+			req: &taxv1.CalculateTaxRequest{Request: &taxv1.TaxRequest{
+				TotalIncome: 500_000,
+				Allowances:  []*taxv1.Allowance{{AllowanceType: "donation", Amount: 0}},
+			}},
+			wantTax:  29_000,
+			wantCode: codes.OK,
+		},
+		{
+			req: &taxv1.CalculateTaxRequest{Request: &taxv1.TaxRequest{
+				TotalIncome: 500_000,
+				Wht:         25_000,
+				Allowances:  []*taxv1.Allowance{{AllowanceType: "donation", Amount: 200_000}},
+			}},
+			wantTax:  -6_000,
+			wantCode: codes.OK,
+		},
+		{
+			req:        &taxv1.CalculateTaxRequest{Request: &taxv1.TaxRequest{TotalIncome: 100, Wht: 200}},
+			wantCode:   codes.InvalidArgument,
+			wantReason: errcode.WhtExceedsIncome,
+		},
+		{
+			req:        &taxv1.CalculateTaxRequest{Request: &taxv1.TaxRequest{TotalIncome: 500_000}, TaxYear: 1999},
+			wantCode:   codes.InvalidArgument,
+			wantReason: errcode.TaxYearUnsupported,
+		},
+		{
+			req:        &taxv1.CalculateTaxRequest{Request: &taxv1.TaxRequest{TotalIncome: 500_000}, Date: "01-01-2024"},
+			wantCode:   codes.InvalidArgument,
+			wantReason: errcode.InvalidDate,
+		},
+	}
+
+	client := newTestClient(t)
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			resp, err := client.CalculateTax(context.Background(), tc.req)
+
+			assert.Equal(t, tc.wantCode, status.Code(err))
+
+			if tc.wantCode != codes.OK {
+				assert.Equal(t, string(tc.wantReason), errorReason(t, err))
+				return
+			}
+
+			got := resp.GetResponse().GetTax() - resp.GetResponse().GetTaxRefund()
+			assert.Equal(t, tc.wantTax, got)
+			assert.NotEmpty(t, resp.GetResponse().GetTaxLevel())
+			assert.False(t, resp.GetDegraded())
+		})
+	}
+}
+
+func TestGetDeductions(t *testing.T) {
+	resp, err := newTestClient(t).GetDeductions(context.Background(), &taxv1.GetDeductionsRequest{})
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.GetDefaultAllowances())
+	assert.NotEmpty(t, resp.GetAllowedAllowances())
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	db := apiKeyDBStub{
+		"ktx_calc": {ID: 1, Scopes: []string{handler.ScopeCalculate}},
+	}
+
+	client := newTestClient(t, grpc.ChainUnaryInterceptor(APIKeyAuth(db)))
+	req := &taxv1.CalculateTaxRequest{Request: &taxv1.TaxRequest{TotalIncome: 500_000}}
+
+	_, err := client.CalculateTax(context.Background(), req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), apiKeyMetadata, "ktx_unknown")
+	_, err = client.CalculateTax(ctx, req)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), apiKeyMetadata, "ktx_calc")
+	_, err = client.CalculateTax(ctx, req)
+	assert.NoError(t, err)
+
+	_, err = client.GetDeductions(ctx, &taxv1.GetDeductionsRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Equal(t, string(errcode.APIKeyScopeDenied), errorReason(t, err))
+}
+
+func TestVerifySignature(t *testing.T) {
+	secret := "s3cret"
+	db := apiKeyDBStub{
+		"ktx_signed":   {ID: 1, Scopes: []string{handler.ScopeCalculate}, SigningSecret: &secret},
+		"ktx_unsigned": {ID: 2, Scopes: []string{handler.ScopeCalculate}},
+	}
+
+	client := newTestClient(t, grpc.ChainUnaryInterceptor(APIKeyAuth(db), VerifySignature(func() time.Duration { return time.Minute })))
+	req := &taxv1.CalculateTaxRequest{Request: &taxv1.TaxRequest{TotalIncome: 500_000}}
+
+	body, err := proto.Marshal(req)
+	assert.NoError(t, err)
+
+	sign := func(timestamp time.Time) string {
+		ts := strconv.FormatInt(timestamp.Unix(), 10)
+		return "sha256=" + handler.SignRequest(secret, "POST", taxv1.TaxService_CalculateTax_FullMethodName, ts, body)
+	}
+
+	call := func(key string, signature string, timestamp time.Time) error {
+		ctx := metadata.AppendToOutgoingContext(context.Background(), apiKeyMetadata, key,
+			signatureMetadata, signature, signatureTimestampMetadata, strconv.FormatInt(timestamp.Unix(), 10))
+
+		_, err := client.CalculateTax(ctx, req)
+
+		return err
+	}
+
+	now := time.Now()
+
+	assert.NoError(t, call("ktx_signed", sign(now), now))
+	assert.NoError(t, call("ktx_unsigned", "", now), "keys without signing secret aren't verified")
+
+	err = call("ktx_signed", sign(now.Add(time.Second)), now)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, string(errcode.SignatureInvalid), errorReason(t, err))
+
+	err = call("ktx_signed", sign(now.Add(-time.Hour)), now.Add(-time.Hour))
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	assert.Equal(t, string(errcode.SignatureExpired), errorReason(t, err))
+
+	err = call("ktx_signed", "", now)
+	assert.Equal(t, string(errcode.SignatureInvalid), errorReason(t, err))
+}
+
+func TestRateLimit(t *testing.T) {
+	store, err := ratelimit.New("", "", 1, 1)
+	assert.NoError(t, err)
+
+	db := apiKeyDBStub{
+		"ktx_1": {ID: 1, Scopes: []string{handler.ScopeCalculate}},
+		"ktx_2": {ID: 2, Scopes: []string{handler.ScopeCalculate}},
+	}
+
+	client := newTestClient(t, grpc.ChainUnaryInterceptor(APIKeyAuth(db),
+		RateLimit(func() middleware.RateLimiterStore { return store })))
+	req := &taxv1.CalculateTaxRequest{Request: &taxv1.TaxRequest{TotalIncome: 500_000}}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), apiKeyMetadata, "ktx_1")
+
+	_, err = client.CalculateTax(ctx, req)
+	assert.NoError(t, err)
+
+	_, err = client.CalculateTax(ctx, req)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, string(errcode.TooManyRequests), errorReason(t, err))
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), apiKeyMetadata, "ktx_2")
+
+	_, err = client.CalculateTax(ctx, req)
+	assert.NoError(t, err, "every key has its own bucket")
+
+	unlimited := newTestClient(t, grpc.ChainUnaryInterceptor(RateLimit(func() middleware.RateLimiterStore { return nil })))
+
+	for range 3 {
+		_, err = unlimited.CalculateTax(context.Background(), req)
+		assert.NoError(t, err, "calls aren't limited while the limit is off")
+	}
+}
+
+// usageDBStub counts requests of every key in memory
+type usageDBStub struct {
+	mu       sync.Mutex
+	requests map[int]int64
+}
+
+func (s *usageDBStub) FindAPIKeyUsage(_ context.Context, keyID int, _ time.Time) (database.APIKeyUsage, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return database.APIKeyUsage{APIKeyID: keyID, Requests: s.requests[keyID]}, nil
+}
+
+func (s *usageDBStub) RecordAPIKeyUsage(_ context.Context, keyID int, _ time.Time, requests int64, _ int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests[keyID] += requests
+
+	return nil
+}
+
+func TestUsageMeter(t *testing.T) {
+	quota := 2
+	db := apiKeyDBStub{
+		"ktx_quota": {ID: 1, Scopes: []string{handler.ScopeCalculate}, MonthlyQuota: &quota},
+	}
+	usage := &usageDBStub{requests: map[int]int64{}}
+
+	client := newTestClient(t, grpc.ChainUnaryInterceptor(APIKeyAuth(db), UsageMeter(usage)))
+	ctx := metadata.AppendToOutgoingContext(context.Background(), apiKeyMetadata, "ktx_quota")
+
+	_, err := client.CalculateTax(ctx, &taxv1.CalculateTaxRequest{Request: &taxv1.TaxRequest{TotalIncome: 500_000}})
+	assert.NoError(t, err)
+
+	_, err = client.CalculateTax(ctx, &taxv1.CalculateTaxRequest{Request: &taxv1.TaxRequest{TotalIncome: 100, Wht: 200}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "rejected calls are metered too")
+
+	_, err = client.CalculateTax(ctx, &taxv1.CalculateTaxRequest{Request: &taxv1.TaxRequest{TotalIncome: 500_000}})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, string(errcode.QuotaExceeded), errorReason(t, err))
+
+	assert.Equal(t, int64(2), usage.requests[1], "calls over the quota aren't metered")
+}
@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// accountAudience tells taxpayer tokens apart from admin tokens
+	accountAudience         = "taxpayer"
+	accountClaimsContextKey = "accountClaims"
+)
+
+type AccountRequest struct {
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,min=12,max=72"`
+}
+
+// AccountLoginRequest doesn't check rules of registration, so a failed login doesn't tell which rule an account breaks
+type AccountLoginRequest struct {
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type AccountResponse struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	UUID      string    `json:"uuid"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type AccountConfig struct {
+	// Secret signs tokens with HS256, it must differ from the secret of admin tokens
+	Secret   []byte
+	TokenTTL time.Duration
+}
+
+type AccountIDB interface {
+	CreateUser(ctx context.Context, email string, passwordHash string) (database.User, error)
+	FindUserByEmail(ctx context.Context, email string) (database.User, error)
+	FindUser(ctx context.Context, id int) (database.User, error)
+}
+
+// AccountHandler registers and logs in taxpayers, their accounts are rows of users table
+type AccountHandler struct {
+	vl   *validator.Validate
+	conf AccountConfig
+	db   AccountIDB
+}
+
+func NewAccountHandler(vl *validator.Validate, conf AccountConfig, db AccountIDB) *AccountHandler {
+	return &AccountHandler{vl, conf, db}
+}
+
+func toAccountResponse(u database.User) AccountResponse {
+	return AccountResponse{
+		ID:        u.ID,
+		Email:     u.Email,
+		UUID:      u.UUID,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
+
+// normalizeEmail makes emails differing in case or surrounding spaces the same account
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func (h *AccountHandler) Register(c echo.Context) error {
+	var req AccountRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	req.Email = normalizeEmail(req.Email)
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to hash password", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	created, err := h.db.CreateUser(c.Request().Context(), req.Email, string(hash))
+	if errors.Is(err, database.ErrAlreadyExists) {
+		return respondError(c, http.StatusConflict, errcode.AccountExists)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to create user", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.JSON(http.StatusCreated, toAccountResponse(created))
+}
+
+func (h *AccountHandler) Login(c echo.Context) error {
+	var req AccountLoginRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	u, err := h.db.FindUserByEmail(c.Request().Context(), normalizeEmail(req.Email))
+	if errors.Is(err, database.ErrNotFound) {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(req.Password))
+		return respondError(c, http.StatusUnauthorized, errcode.InvalidCredentials)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find user", "error", err)
+		return respondQueryError(c)
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(req.Password)) != nil {
+		return respondError(c, http.StatusUnauthorized, errcode.InvalidCredentials)
+	}
+
+	now := time.Now()
+
+	claims := jwt.RegisteredClaims{
+		Issuer:    tokenIssuer,
+		Subject:   strconv.Itoa(u.ID),
+		Audience:  jwt.ClaimStrings{accountAudience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(h.conf.TokenTTL)),
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(h.conf.Secret)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to sign token", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.JSON(http.StatusOK, LoginResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(h.conf.TokenTTL.Seconds()),
+	})
+}
+
+// GetAccount returns account of the signed in taxpayer
+func (h *AccountHandler) GetAccount(c echo.Context) error {
+	id := currentUserID(c)
+	if id == nil {
+		return respondError(c, http.StatusUnauthorized, errcode.Unauthorized)
+	}
+
+	u, err := h.db.FindUser(c.Request().Context(), *id)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusUnauthorized, errcode.Unauthorized)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find user", "error", err)
+		return respondQueryError(c)
+	}
+
+	return c.JSON(http.StatusOK, toAccountResponse(u))
+}
+
+func (conf AccountConfig) parseToken(token string) (int, error) {
+	var claims jwt.RegisteredClaims
+
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return conf.Secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithAudience(accountAudience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(claims.Subject)
+}
+
+// AccountAuth signs taxpayers in by bearer tokens of Login, requests without a token stay anonymous.
+// Their id is available by currentUserID
+func AccountAuth(conf AccountConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if !ok {
+				return next(c)
+			}
+
+			id, err := conf.parseToken(token)
+			if err != nil {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+				return respondError(c, http.StatusUnauthorized, errcode.Unauthorized)
+			}
+
+			c.Set(accountClaimsContextKey, id)
+
+			return next(c)
+		}
+	}
+}
+
+// RequireAccount rejects anonymous requests, it must be used after AccountAuth
+func RequireAccount() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if currentUserID(c) == nil {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return respondError(c, http.StatusUnauthorized, errcode.Unauthorized)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// currentUserID returns id of the signed in taxpayer, nil for anonymous requests
+func currentUserID(c echo.Context) *int {
+	id, ok := c.Get(accountClaimsContextKey).(int)
+	if !ok {
+		return nil
+	}
+
+	return &id
+}
@@ -0,0 +1,212 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testAccountConfig = AccountConfig{
+	Secret:   []byte("fedcba9876543210fedcba9876543210"),
+	TokenTTL: time.Hour,
+}
+
+type AccountDBMock struct {
+	mock.Mock
+}
+
+func (o *AccountDBMock) CreateUser(ctx context.Context, email string, passwordHash string) (database.User, error) {
+	args := o.Called(ctx, email, passwordHash)
+	return args.Get(0).(database.User), args.Error(1)
+}
+
+func (o *AccountDBMock) FindUserByEmail(ctx context.Context, email string) (database.User, error) {
+	args := o.Called(ctx, email)
+	return args.Get(0).(database.User), args.Error(1)
+}
+
+func (o *AccountDBMock) FindUser(ctx context.Context, id int) (database.User, error) {
+	args := o.Called(ctx, id)
+	return args.Get(0).(database.User), args.Error(1)
+}
+
+func TestAccountRegister(t *testing.T) {
+	type TC struct {
+		reqbody   string
+		createErr error
+		wantCode  int
+		wantError errcode.Code
+	}
+
+	tcs := []TC{
+		{
+			reqbody:  `{"email":" Somchai@Example.com ","password":"correct horse battery"}`,
+			wantCode: http.StatusCreated,
+		},
+		{
+			reqbody:   `{"email":"somchai@example.com","password":"correct horse battery"}`,
+			createErr: database.ErrAlreadyExists,
+			wantCode:  http.StatusConflict,
+			wantError: errcode.AccountExists,
+		},
+		{
+			reqbody:   `{"email":"somchai@example.com","password":"short"}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: errcode.InvalidRequest,
+		},
+		{
+			reqbody:   `{"email":"somchai","password":"correct horse battery"}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: errcode.InvalidRequest,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(AccountDBMock)
+			mockObj.On("CreateUser", mock.Anything, "somchai@example.com", mock.Anything).
+				Return(database.User{ID: 1, Email: "somchai@example.com"}, tc.createErr)
+
+			req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			c := echo.New().NewContext(req, rec)
+
+			assert.NoError(t, NewAccountHandler(validator.New(), testAccountConfig, mockObj).Register(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusCreated {
+				var got ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, tc.wantError, got.ErrorCode)
+
+				return
+			}
+
+			var got AccountResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, "somchai@example.com", got.Email)
+			assert.NotContains(t, rec.Body.String(), "password")
+		})
+	}
+}
+
+func TestAccountLogin(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse battery"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	type TC struct {
+		reqbody  string
+		wantCode int
+	}
+
+	tcs := []TC{
+		{reqbody: `{"email":"Somchai@example.com","password":"correct horse battery"}`, wantCode: http.StatusOK},
+		{reqbody: `{"email":"somchai@example.com","password":"wrong password"}`, wantCode: http.StatusUnauthorized},
+		{reqbody: `{"email":"somsri@example.com","password":"correct horse battery"}`, wantCode: http.StatusUnauthorized},
+		{reqbody: `{"email":"somchai@example.com"}`, wantCode: http.StatusBadRequest},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(AccountDBMock)
+			mockObj.On("FindUserByEmail", mock.Anything, "somchai@example.com").
+				Return(database.User{ID: 5, Email: "somchai@example.com", PasswordHash: string(hash)}, nil)
+			mockObj.On("FindUserByEmail", mock.Anything, "somsri@example.com").Return(database.User{}, database.ErrNotFound)
+
+			req := httptest.NewRequest(http.MethodPost, "/users/login", strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, NewAccountHandler(validator.New(), testAccountConfig, mockObj).Login(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			var got LoginResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+
+			id, err := testAccountConfig.parseToken(got.AccessToken)
+			assert.NoError(t, err)
+			assert.Equal(t, 5, id)
+		})
+	}
+}
+
+func TestAccountAuth(t *testing.T) {
+	type TC struct {
+		authorization string
+		wantCode      int
+		wantUserID    *int
+	}
+
+	userID := 5
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct horse battery"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	mockObj := new(AccountDBMock)
+	mockObj.On("FindUserByEmail", mock.Anything, "somchai@example.com").
+		Return(database.User{ID: userID, PasswordHash: string(hash)}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/users/login",
+		strings.NewReader(`{"email":"somchai@example.com","password":"correct horse battery"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	assert.NoError(t, NewAccountHandler(validator.New(), testAccountConfig, mockObj).Login(echo.New().NewContext(req, rec)))
+
+	var login LoginResponse
+
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &login))
+
+	tcs := []TC{
+		{authorization: "Bearer " + login.AccessToken, wantCode: http.StatusOK, wantUserID: &userID},
+		{wantCode: http.StatusOK},
+		{authorization: "Bearer invalid", wantCode: http.StatusUnauthorized},
+		// admin tokens have no taxpayer audience
+		{authorization: "Bearer " + signTestToken(t, testAccountConfig.Secret, RoleSuperadmin, time.Now().Add(time.Hour)),
+			wantCode: http.StatusUnauthorized},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tax/deductions", nil)
+			req.Header.Set(echo.HeaderAuthorization, tc.authorization)
+			rec := httptest.NewRecorder()
+
+			c := echo.New().NewContext(req, rec)
+
+			var got *int
+
+			err := AccountAuth(testAccountConfig)(func(c echo.Context) error {
+				got = currentUserID(c)
+				return c.NoContent(http.StatusOK)
+			})(c)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Equal(t, tc.wantUserID, got)
+		})
+	}
+}
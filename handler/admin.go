@@ -2,96 +2,459 @@ package handler
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	taxv1 "github.com/AnnaCarter465/assessment-tax/proto/tax/v1"
+	"github.com/AnnaCarter465/assessment-tax/tax"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	"google.golang.org/protobuf/proto"
 )
 
 type AdminTaxRequest struct {
 	Amount float64 `json:"amount" validate:"required,number,gt=0"`
+	// SampleIncomes are projected by dry runs instead of defaultSampleIncomes
+	SampleIncomes []float64 `json:"sampleIncomes,omitempty" validate:"max=100,dive,gte=0"`
+}
+
+// incomes projected by dry runs when request doesn't provide sample incomes
+var defaultSampleIncomes = []float64{300_000, 500_000, 1_000_000, 2_000_000, 5_000_000}
+
+type DryRunResponse struct {
+	Setting     string             `json:"setting"`
+	Current     float64            `json:"current"`
+	Proposed    float64            `json:"proposed"`
+	Projections []ProjectionResult `json:"projections"`
+}
+
+type ProjectionResult struct {
+	TotalIncome  float64 `json:"totalIncome"`
+	CurrentTax   float64 `json:"currentTax"`
+	ProjectedTax float64 `json:"projectedTax"`
+}
+
+type AdminEffectiveAllowanceRequest struct {
+	AllowanceType string  `json:"allowanceType" validate:"required"`
+	Amount        float64 `json:"amount" validate:"required,number,gt=0"`
+	EffectiveFrom string  `json:"effectiveFrom" validate:"required"`
+}
+
+type EffectiveAllowanceResponse struct {
+	AllowanceType string  `json:"allowanceType"`
+	Amount        float64 `json:"amount"`
+	EffectiveFrom string  `json:"effectiveFrom"`
+}
+
+// settingKinds maps settings changeable by admin to kind of their allowance
+var settingKinds = map[string]string{
+	"personal":  database.AllowanceKindDefault,
+	"k-receipt": database.AllowanceKindAllowed,
+	"donation":  database.AllowanceKindAllowed,
+}
+
+type AdminDeductionsResponse struct {
+	PersonalDeduction float64                    `json:"personalDeduction"`
+	KReceipt          float64                    `json:"kReceipt"`
+	DefaultAllowances []DefaultAllowanceResponse `json:"defaultAllowances"`
+	AllowedAllowances []AllowedAllowanceResponse `json:"allowedAllowances"`
 }
 
 type AdminIDB interface {
+	FindAllDefaultAllowances(ctx context.Context) ([]database.DefaultAllowance, error)
+	FindAllAllowedAllowances(ctx context.Context) ([]database.AllowedAllowance, error)
 	UpdateAmountDefaultAllowances(ctx context.Context, allowanceType string, amount float64) (database.DefaultAllowance, error)
 	UpdateAmountAllowedAllowances(ctx context.Context, allowanceType string, amount float64) (database.AllowedAllowance, error)
+	DisableAllowanceType(ctx context.Context, allowanceType string) error
+	FindSettingBound(ctx context.Context, setting string) (database.SettingBound, error)
+	FindAllEffectiveAllowances(ctx context.Context) ([]database.EffectiveAllowance, error)
+	UpsertEffectiveAllowance(ctx context.Context, a database.EffectiveAllowance) (database.EffectiveAllowance, error)
+}
+
+type SettingsNotifier interface {
+	SettingsChanged(setting string, value float64)
+	BracketsChanged(taxYear int, brackets []database.TaxBracket)
 }
 
 type AdminHandler struct {
-	vl *validator.Validate
-	db AdminIDB
+	vl       *validator.Validate
+	db       AdminIDB
+	notifier SettingsNotifier
+	brackets BracketReader
 }
 
 func NewAdminHandler(vl *validator.Validate, db AdminIDB) *AdminHandler {
-	return &AdminHandler{vl, db}
+	return &AdminHandler{vl: vl, db: db}
+}
+
+// SetNotifier sets notifier called after a setting is changed
+func (a *AdminHandler) SetNotifier(notifier SettingsNotifier) *AdminHandler {
+	a.notifier = notifier
+	return a
+}
+
+// SetBrackets sets reader of imported brackets used by dry runs
+func (a *AdminHandler) SetBrackets(brackets BracketReader) *AdminHandler {
+	a.brackets = brackets
+	return a
+}
+
+func (a *AdminHandler) notify(setting string, value float64) {
+	if a.notifier != nil {
+		a.notifier.SettingsChanged(setting, value)
+	}
+}
+
+type BoundReader interface {
+	FindSettingBound(ctx context.Context, setting string) (database.SettingBound, error)
+}
+
+// withinBound checks amount against stored bound of setting, it responds error when amount is out of bound
+func withinBound(c echo.Context, db BoundReader, setting string, amount float64) (bool, error) {
+	bound, err := db.FindSettingBound(c.Request().Context(), setting)
+	if errors.Is(err, database.ErrNotFound) {
+		slog.ErrorContext(c.Request().Context(), "no validation bound configured", "setting", setting)
+		return false, respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find setting bound", "setting", setting, "error", err)
+		return false, respondQueryError(c)
+	}
+
+	if amount < bound.MinAmount || amount > bound.MaxAmount {
+		return false, respondError(c, http.StatusBadRequest, errcode.AmountOutOfRange)
+	}
+
+	return true, nil
+}
+
+// GetDeductions returns current settings, including settings which can't be changed by admin endpoints yet
+func (a *AdminHandler) GetDeductions(c echo.Context) error {
+	defaultAllowances, err := a.db.FindAllDefaultAllowances(c.Request().Context())
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find default allowances", "error", err)
+		return respondQueryError(c)
+	}
+
+	allowedAllowances, err := a.db.FindAllAllowedAllowances(c.Request().Context())
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find allowed allowances", "error", err)
+		return respondQueryError(c)
+	}
+
+	resp := AdminDeductionsResponse{
+		DefaultAllowances: []DefaultAllowanceResponse{},
+		AllowedAllowances: []AllowedAllowanceResponse{},
+	}
+
+	for _, d := range defaultAllowances {
+		if d.AllowanceType == "personal" {
+			resp.PersonalDeduction = d.Amount
+		}
+
+		resp.DefaultAllowances = append(resp.DefaultAllowances, DefaultAllowanceResponse{
+			AllowanceType: d.AllowanceType,
+			Amount:        d.Amount,
+			UUID:          d.UUID,
+			CreatedAt:     &d.CreatedAt,
+			UpdatedAt:     &d.UpdatedAt,
+			DisabledAt:    d.DisabledAt,
+		})
+	}
+
+	for _, d := range allowedAllowances {
+		if d.AllowanceType == "k-receipt" {
+			resp.KReceipt = d.MaxAmount
+		}
+
+		resp.AllowedAllowances = append(resp.AllowedAllowances, AllowedAllowanceResponse{
+			AllowanceType: d.AllowanceType,
+			MaxAmount:     d.MaxAmount,
+			UUID:          d.UUID,
+			CreatedAt:     &d.CreatedAt,
+			UpdatedAt:     &d.UpdatedAt,
+			DisabledAt:    d.DisabledAt,
+		})
+	}
+
+	return respondNegotiated(c, resp, func() proto.Message { return resp.Proto() })
 }
 
 func (a *AdminHandler) UpdatePesonal(c echo.Context) error {
 	var req AdminTaxRequest
 
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ResponseMsg{
-			Message: "Bad request",
-		})
+	if err := bindAdminTaxRequest(c, &req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
 	}
 
 	if err := a.vl.Struct(req); err != nil {
-		return c.JSON(http.StatusBadRequest, ResponseMsg{
-			Message: "Bad request",
-		})
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
 	}
 
-	if req.Amount < 10_000 || req.Amount > 100_000 {
-		return c.JSON(http.StatusBadRequest, ResponseMsg{
-			Message: "Invalid amount",
-		})
+	if ok, err := withinBound(c, a.db, "personal", req.Amount); !ok {
+		return err
+	}
+
+	if c.QueryParam("dryRun") == "true" {
+		return a.dryRun(c, "personal", req)
 	}
 
 	defaultAllowance, err := a.db.UpdateAmountDefaultAllowances(c.Request().Context(), "personal", req.Amount)
+	if errors.Is(err, database.ErrConflict) {
+		return respondError(c, http.StatusConflict, errcode.SettingsVersionConflict)
+	}
+
 	if err != nil {
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, ResponseMsg{
-			Message: "Failed to update personal amount",
-		})
+		slog.ErrorContext(c.Request().Context(), "failed to update personal allowance", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.PersonalUpdateFailed)
 	}
 
-	return c.JSON(http.StatusOK, map[string]float64{
+	a.notify("personal", defaultAllowance.Amount)
+
+	return respondNegotiated(c, map[string]float64{
 		"personalDeduction": defaultAllowance.Amount,
-	})
+	}, func() proto.Message { return &taxv1.AdminSettingResponse{PersonalDeduction: &defaultAllowance.Amount} })
 }
 
 func (a *AdminHandler) UpdateKReceipt(c echo.Context) error {
 	var req AdminTaxRequest
 
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ResponseMsg{
-			Message: "Bad request",
-		})
+	if err := bindAdminTaxRequest(c, &req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
 	}
 
 	if err := a.vl.Struct(req); err != nil {
-		return c.JSON(http.StatusBadRequest, ResponseMsg{
-			Message: "Bad request",
-		})
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
 	}
 
-	if req.Amount > 100_000 {
-		return c.JSON(http.StatusBadRequest, ResponseMsg{
-			Message: "Invalid amount",
-		})
+	if ok, err := withinBound(c, a.db, "k-receipt", req.Amount); !ok {
+		return err
+	}
+
+	if c.QueryParam("dryRun") == "true" {
+		return a.dryRun(c, "k-receipt", req)
 	}
 
 	allowance, err := a.db.UpdateAmountAllowedAllowances(c.Request().Context(), "k-receipt", req.Amount)
+	if errors.Is(err, database.ErrConflict) {
+		return respondError(c, http.StatusConflict, errcode.SettingsVersionConflict)
+	}
+
 	if err != nil {
-		log.Println(err)
-		return c.JSON(http.StatusInternalServerError, ResponseMsg{
-			Message: "Failed to update k-receipt amount",
-		})
+		slog.ErrorContext(c.Request().Context(), "failed to update k-receipt allowance", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.KReceiptUpdateFailed)
 	}
 
-	return c.JSON(http.StatusOK, map[string]float64{
+	a.notify("k-receipt", allowance.MaxAmount)
+
+	return respondNegotiated(c, map[string]float64{
 		"kReceipt": allowance.MaxAmount,
+	}, func() proto.Message { return &taxv1.AdminSettingResponse{KReceipt: &allowance.MaxAmount} })
+}
+
+func (a *AdminHandler) UpdateDonation(c echo.Context) error {
+	var req AdminTaxRequest
+
+	if err := bindAdminTaxRequest(c, &req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := a.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if ok, err := withinBound(c, a.db, "donation", req.Amount); !ok {
+		return err
+	}
+
+	if c.QueryParam("dryRun") == "true" {
+		return a.dryRun(c, "donation", req)
+	}
+
+	allowance, err := a.db.UpdateAmountAllowedAllowances(c.Request().Context(), "donation", req.Amount)
+	if errors.Is(err, database.ErrConflict) {
+		return respondError(c, http.StatusConflict, errcode.SettingsVersionConflict)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to update donation allowance", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.DonationUpdateFailed)
+	}
+
+	a.notify("donation", allowance.MaxAmount)
+
+	return respondNegotiated(c, map[string]float64{
+		"donation": allowance.MaxAmount,
+	}, func() proto.Message { return &taxv1.AdminSettingResponse{Donation: &allowance.MaxAmount} })
+}
+
+// DisableAllowanceType disables allowance type instead of deleting it, so calculations made before still resolve it
+func (a *AdminHandler) DisableAllowanceType(c echo.Context) error {
+	allowanceType := c.Param("allowanceType")
+
+	err := a.db.DisableAllowanceType(c.Request().Context(), allowanceType)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.AllowanceNotFound)
+	}
+
+	if errors.Is(err, database.ErrConflict) {
+		return respondError(c, http.StatusConflict, errcode.SettingsVersionConflict)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to disable allowance type", "allowanceType", allowanceType, "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.AllowanceDisableFailed)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func toEffectiveAllowanceResponse(a database.EffectiveAllowance) EffectiveAllowanceResponse {
+	return EffectiveAllowanceResponse{
+		AllowanceType: a.AllowanceType,
+		Amount:        a.Amount,
+		EffectiveFrom: a.EffectiveFrom.Format(time.DateOnly),
+	}
+}
+
+// GetEffectiveAllowances returns all published effective-dated settings
+func (a *AdminHandler) GetEffectiveAllowances(c echo.Context) error {
+	allowances, err := a.db.FindAllEffectiveAllowances(c.Request().Context())
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find effective allowances", "error", err)
+		return respondQueryError(c)
+	}
+
+	resp := []EffectiveAllowanceResponse{}
+
+	for _, al := range allowances {
+		resp = append(resp, toEffectiveAllowanceResponse(al))
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// PublishEffectiveAllowance publishes value of a setting which is used by calculations on or after effectiveFrom,
+// e.g. next year's personal deduction can be published without changing current results
+func (a *AdminHandler) PublishEffectiveAllowance(c echo.Context) error {
+	var req AdminEffectiveAllowanceRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := a.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	kind, ok := settingKinds[req.AllowanceType]
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	effectiveFrom, err := time.Parse(time.DateOnly, req.EffectiveFrom)
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidDate)
+	}
+
+	if ok, err := withinBound(c, a.db, req.AllowanceType, req.Amount); !ok {
+		return err
+	}
+
+	allowance, err := a.db.UpsertEffectiveAllowance(c.Request().Context(), database.EffectiveAllowance{
+		AllowanceKind: kind,
+		AllowanceType: req.AllowanceType,
+		Amount:        req.Amount,
+		EffectiveFrom: effectiveFrom,
 	})
+	if errors.Is(err, database.ErrConflict) {
+		return respondError(c, http.StatusConflict, errcode.SettingsVersionConflict)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to publish effective allowance", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.EffectiveAllowanceUpdateFailed)
+	}
+
+	return c.JSON(http.StatusOK, toEffectiveAllowanceResponse(allowance))
+}
+
+// dryRun responds tax of sample incomes under current and proposed value of setting without changing it,
+// sample taxpayers claim allowed allowances in full
+func (a *AdminHandler) dryRun(c echo.Context, setting string, req AdminTaxRequest) error {
+	rates, ok, err := findRates(c, a.brackets)
+	if err != nil {
+		return respondQueryError(c)
+	}
+
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.TaxYearUnsupported)
+	}
+
+	th := NewTaxHandler(a.vl, a.db)
+
+	defaultAllowancesMap, _, err := th.getDefaultAllowancesMap(c.Request().Context())
+	if err != nil {
+		return respondQueryError(c)
+	}
+
+	allowedAllowancesMap, _, err := th.getAllowedAllowancesMap(c.Request().Context())
+	if err != nil {
+		return respondQueryError(c)
+	}
+
+	settings := allowedAllowancesMap
+	if settingKinds[setting] == database.AllowanceKindDefault {
+		settings = defaultAllowancesMap
+	}
+
+	current := settings[setting]
+	claim := max(current, req.Amount)
+
+	calculate := func(income float64) float64 {
+		tx := tax.NewTax(tax.TaxConfig{
+			Rates:             rates,
+			DefaultAllowances: defaultAllowancesMap,
+			AllowedAllowances: allowedAllowancesMap,
+		}).SetIncome(income)
+
+		if settingKinds[setting] == database.AllowanceKindAllowed {
+			tx.AddAllowance(setting, claim)
+		}
+
+		return tx.CalculateTaxSummary().Tax
+	}
+
+	samples := req.SampleIncomes
+	if len(samples) == 0 {
+		samples = defaultSampleIncomes
+	}
+
+	resp := DryRunResponse{
+		Setting:     setting,
+		Current:     current,
+		Proposed:    req.Amount,
+		Projections: []ProjectionResult{},
+	}
+
+	for _, income := range samples {
+		settings[setting] = current
+		currentTax := calculate(income)
+
+		settings[setting] = req.Amount
+		projectedTax := calculate(income)
+
+		resp.Projections = append(resp.Projections, ProjectionResult{
+			TotalIncome:  income,
+			CurrentTax:   currentTax,
+			ProjectedTax: projectedTax,
+		})
+	}
+
+	return c.JSON(http.StatusOK, resp)
 }
@@ -11,8 +11,10 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
@@ -23,6 +25,16 @@ type AdminDBMock struct {
 	mock.Mock
 }
 
+func (o *AdminDBMock) FindAllDefaultAllowances(ctx context.Context) ([]database.DefaultAllowance, error) {
+	args := o.Called(ctx)
+	return args.Get(0).([]database.DefaultAllowance), args.Error(1)
+}
+
+func (o *AdminDBMock) FindAllAllowedAllowances(ctx context.Context) ([]database.AllowedAllowance, error) {
+	args := o.Called(ctx)
+	return args.Get(0).([]database.AllowedAllowance), args.Error(1)
+}
+
 func (o *AdminDBMock) UpdateAmountDefaultAllowances(ctx context.Context, allowanceType string, amount float64) (database.DefaultAllowance, error) {
 	args := o.Called(ctx, allowanceType, amount)
 	return args.Get(0).(database.DefaultAllowance), args.Error(1)
@@ -33,6 +45,48 @@ func (o *AdminDBMock) UpdateAmountAllowedAllowances(ctx context.Context, allowan
 	return args.Get(0).(database.AllowedAllowance), args.Error(1)
 }
 
+func (o *AdminDBMock) DisableAllowanceType(ctx context.Context, allowanceType string) error {
+	args := o.Called(ctx, allowanceType)
+	return args.Error(0)
+}
+
+func (o *AdminDBMock) FindSettingBound(ctx context.Context, setting string) (database.SettingBound, error) {
+	args := o.Called(ctx, setting)
+	return args.Get(0).(database.SettingBound), args.Error(1)
+}
+
+func (o *AdminDBMock) FindAllEffectiveAllowances(ctx context.Context) ([]database.EffectiveAllowance, error) {
+	args := o.Called(ctx)
+	return args.Get(0).([]database.EffectiveAllowance), args.Error(1)
+}
+
+func (o *AdminDBMock) UpsertEffectiveAllowance(ctx context.Context, a database.EffectiveAllowance) (database.EffectiveAllowance, error) {
+	args := o.Called(ctx, a)
+	return args.Get(0).(database.EffectiveAllowance), args.Error(1)
+}
+
+// mockSettingBounds mocks bounds seeded by init.sql
+func mockSettingBounds(dbmock *AdminDBMock) {
+	dbmock.On("FindSettingBound", mock.Anything, "personal").
+		Return(database.SettingBound{Setting: "personal", MinAmount: 10_000, MaxAmount: 100_000}, nil)
+	dbmock.On("FindSettingBound", mock.Anything, "k-receipt").
+		Return(database.SettingBound{Setting: "k-receipt", MaxAmount: 100_000}, nil)
+	dbmock.On("FindSettingBound", mock.Anything, "donation").
+		Return(database.SettingBound{Setting: "donation", MaxAmount: 200_000}, nil)
+}
+
+type NotifierMock struct {
+	mock.Mock
+}
+
+func (o *NotifierMock) SettingsChanged(setting string, value float64) {
+	o.Called(setting, value)
+}
+
+func (o *NotifierMock) BracketsChanged(taxYear int, brackets []database.TaxBracket) {
+	o.Called(taxYear, brackets)
+}
+
 type MockSetting struct {
 	Args    []interface{}
 	Returns []interface{}
@@ -74,7 +128,8 @@ func TestAdminUpdatePesonal(t *testing.T) {
 			mockUpdateAmountDefaultAllowances: nil,
 			want:                              nil,
 			errresp: &ResponseMsg{
-				Message: "Bad request",
+				Message:   "Bad request",
+				ErrorCode: errcode.InvalidRequest,
 			},
 		},
 		{
@@ -82,7 +137,8 @@ func TestAdminUpdatePesonal(t *testing.T) {
 			mockUpdateAmountDefaultAllowances: nil,
 			want:                              nil,
 			errresp: &ResponseMsg{
-				Message: "Bad request",
+				Message:   "Bad request",
+				ErrorCode: errcode.InvalidRequest,
 			},
 		},
 		{
@@ -92,7 +148,8 @@ func TestAdminUpdatePesonal(t *testing.T) {
 			mockUpdateAmountDefaultAllowances: nil,
 			want:                              nil,
 			errresp: &ResponseMsg{
-				Message: "Invalid amount",
+				Message:   "Invalid amount",
+				ErrorCode: errcode.AmountOutOfRange,
 			},
 		},
 		{
@@ -102,7 +159,8 @@ func TestAdminUpdatePesonal(t *testing.T) {
 			mockUpdateAmountDefaultAllowances: nil,
 			want:                              nil,
 			errresp: &ResponseMsg{
-				Message: "Invalid amount",
+				Message:   "Invalid amount",
+				ErrorCode: errcode.AmountOutOfRange,
 			},
 		},
 		{
@@ -122,7 +180,8 @@ func TestAdminUpdatePesonal(t *testing.T) {
 			},
 			want: nil,
 			errresp: &ResponseMsg{
-				Message: "Failed to update personal amount",
+				Message:   "Failed to update personal amount",
+				ErrorCode: errcode.PersonalUpdateFailed,
 			},
 		},
 	}
@@ -130,6 +189,7 @@ func TestAdminUpdatePesonal(t *testing.T) {
 	for i, tc := range tcs {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
 			dbmock := new(AdminDBMock)
+			mockSettingBounds(dbmock)
 
 			if tc.mockUpdateAmountDefaultAllowances != nil {
 				dbmock.On(
@@ -221,7 +281,8 @@ func TestAdminUpdateKReciept(t *testing.T) {
 			mockUpdateAmountAllowedAllowances: nil,
 			want:                              nil,
 			errresp: &ResponseMsg{
-				Message: "Bad request",
+				Message:   "Bad request",
+				ErrorCode: errcode.InvalidRequest,
 			},
 		},
 		{
@@ -229,7 +290,8 @@ func TestAdminUpdateKReciept(t *testing.T) {
 			mockUpdateAmountAllowedAllowances: nil,
 			want:                              nil,
 			errresp: &ResponseMsg{
-				Message: "Bad request",
+				Message:   "Bad request",
+				ErrorCode: errcode.InvalidRequest,
 			},
 		},
 		{
@@ -239,7 +301,8 @@ func TestAdminUpdateKReciept(t *testing.T) {
 			mockUpdateAmountAllowedAllowances: nil,
 			want:                              nil,
 			errresp: &ResponseMsg{
-				Message: "Invalid amount",
+				Message:   "Invalid amount",
+				ErrorCode: errcode.AmountOutOfRange,
 			},
 		},
 		{
@@ -259,7 +322,8 @@ func TestAdminUpdateKReciept(t *testing.T) {
 			},
 			want: nil,
 			errresp: &ResponseMsg{
-				Message: "Failed to update k-receipt amount",
+				Message:   "Failed to update k-receipt amount",
+				ErrorCode: errcode.KReceiptUpdateFailed,
 			},
 		},
 	}
@@ -267,6 +331,7 @@ func TestAdminUpdateKReciept(t *testing.T) {
 	for i, tc := range tcs {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
 			dbmock := new(AdminDBMock)
+			mockSettingBounds(dbmock)
 
 			if tc.mockUpdateAmountAllowedAllowances != nil {
 				dbmock.On(
@@ -321,3 +386,546 @@ func TestAdminUpdateKReciept(t *testing.T) {
 		})
 	}
 }
+
+func TestAdminUpdateDonation(t *testing.T) {
+	type TC struct {
+		reqbody                           map[string]interface{}
+		want                              map[string]float64
+		mockUpdateAmountAllowedAllowances *MockSetting
+		errresp                           *ResponseMsg
+	}
+
+	tcs := []TC{
+		{
+			reqbody: map[string]interface{}{
+				"amount": 70_000,
+			},
+			mockUpdateAmountAllowedAllowances: &MockSetting{
+				Args: []interface{}{
+					mock.Anything,
+					"donation",
+					float64(70_000),
+				},
+				Returns: []interface{}{
+					database.AllowedAllowance{AllowanceType: "donation", MaxAmount: 70_000},
+					nil,
+				},
+			},
+			want: map[string]float64{
+				"donation": 70_000,
+			},
+			errresp: nil,
+		},
+		{
+			reqbody: map[string]interface{}{
+				"amount": "wrong_amount",
+			},
+			mockUpdateAmountAllowedAllowances: nil,
+			want:                              nil,
+			errresp: &ResponseMsg{
+				Message:   "Bad request",
+				ErrorCode: errcode.InvalidRequest,
+			},
+		},
+		{
+			reqbody:                           nil,
+			mockUpdateAmountAllowedAllowances: nil,
+			want:                              nil,
+			errresp: &ResponseMsg{
+				Message:   "Bad request",
+				ErrorCode: errcode.InvalidRequest,
+			},
+		},
+		{
+			reqbody: map[string]interface{}{
+				"amount": 200_001,
+			},
+			mockUpdateAmountAllowedAllowances: nil,
+			want:                              nil,
+			errresp: &ResponseMsg{
+				Message:   "Invalid amount",
+				ErrorCode: errcode.AmountOutOfRange,
+			},
+		},
+		{
+			reqbody: map[string]interface{}{
+				"amount": 70_000,
+			},
+			mockUpdateAmountAllowedAllowances: &MockSetting{
+				Args: []interface{}{
+					mock.Anything,
+					"donation",
+					float64(70_000),
+				},
+				Returns: []interface{}{
+					database.AllowedAllowance{},
+					errors.New("an error"),
+				},
+			},
+			want: nil,
+			errresp: &ResponseMsg{
+				Message:   "Failed to update donation amount",
+				ErrorCode: errcode.DonationUpdateFailed,
+			},
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(AdminDBMock)
+			mockSettingBounds(dbmock)
+
+			if tc.mockUpdateAmountAllowedAllowances != nil {
+				dbmock.On(
+					"UpdateAmountAllowedAllowances",
+					tc.mockUpdateAmountAllowedAllowances.Args...,
+				).Return(tc.mockUpdateAmountAllowedAllowances.Returns...)
+			}
+
+			h := NewAdminHandler(validator.New(), dbmock)
+
+			val, _ := json.Marshal(tc.reqbody)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/deductions/donation", strings.NewReader(string(val)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			goterr := h.UpdateDonation(e.NewContext(req, rec))
+
+			assert.NoError(t, goterr)
+
+			if tc.errresp != nil {
+				var errresp ResponseMsg
+
+				err := json.Unmarshal([]byte(rec.Body.String()), &errresp)
+				assert.NoError(t, err)
+
+				assert.NotEqual(t, http.StatusOK, rec.Code)
+
+				equal := reflect.DeepEqual(*tc.errresp, errresp)
+
+				if !equal {
+					assert.Fail(t, fmt.Sprintf("expected %v, \nbut got %v", *tc.errresp, errresp))
+				}
+
+				return
+			}
+
+			var got map[string]float64
+
+			err := json.Unmarshal([]byte(rec.Body.String()), &got)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			equal := reflect.DeepEqual(tc.want, got)
+
+			if !equal {
+				assert.Fail(t, fmt.Sprintf("expected %v, \nbut got %v", tc.want, got))
+			}
+		})
+	}
+}
+
+func TestAdminUpdateNotifiesSettingsChanged(t *testing.T) {
+	dbmock := new(AdminDBMock)
+	mockSettingBounds(dbmock)
+	dbmock.On("UpdateAmountDefaultAllowances", mock.Anything, "personal", float64(70_000)).
+		Return(database.DefaultAllowance{AllowanceType: "personal", Amount: 70_000}, nil)
+	dbmock.On("UpdateAmountAllowedAllowances", mock.Anything, "k-receipt", float64(70_000)).
+		Return(database.AllowedAllowance{AllowanceType: "k-receipt", MaxAmount: 70_000}, nil)
+
+	notifier := new(NotifierMock)
+	notifier.On("SettingsChanged", "personal", float64(70_000)).Return()
+	notifier.On("SettingsChanged", "k-receipt", float64(70_000)).Return()
+
+	h := NewAdminHandler(validator.New(), dbmock).SetNotifier(notifier)
+
+	e := echo.New()
+
+	for _, update := range []echo.HandlerFunc{h.UpdatePesonal, h.UpdateKReceipt} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"amount":70000}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		assert.NoError(t, update(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	notifier.AssertExpectations(t)
+}
+
+func TestAdminUpdateUsesStoredBound(t *testing.T) {
+	type TC struct {
+		bound    *MockSetting
+		wantCode int
+		wantErr  errcode.Code
+	}
+
+	tcs := []TC{
+		{
+			bound: &MockSetting{
+				Args:    []interface{}{mock.Anything, "personal"},
+				Returns: []interface{}{database.SettingBound{Setting: "personal", MinAmount: 80_000, MaxAmount: 120_000}, nil},
+			},
+			wantCode: http.StatusBadRequest,
+			wantErr:  errcode.AmountOutOfRange,
+		},
+		{
+			bound: &MockSetting{
+				Args:    []interface{}{mock.Anything, "personal"},
+				Returns: []interface{}{database.SettingBound{}, database.ErrNotFound},
+			},
+			wantCode: http.StatusInternalServerError,
+			wantErr:  errcode.Internal,
+		},
+		{
+			bound: &MockSetting{
+				Args:    []interface{}{mock.Anything, "personal"},
+				Returns: []interface{}{database.SettingBound{}, errors.New("an error")},
+			},
+			wantCode: http.StatusInternalServerError,
+			wantErr:  errcode.Internal,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(AdminDBMock)
+			dbmock.On("FindSettingBound", tc.bound.Args...).Return(tc.bound.Returns...)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/deductions/personal", strings.NewReader(`{"amount":70000}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, NewAdminHandler(validator.New(), dbmock).UpdatePesonal(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			var got ResponseMsg
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tc.wantErr, got.ErrorCode)
+			dbmock.AssertNotCalled(t, "UpdateAmountDefaultAllowances", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAdminGetDeductions(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	updatedAt := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	type TC struct {
+		mockDefault *MockSetting
+		mockAllowed *MockSetting
+		wantCode    int
+		want        AdminDeductionsResponse
+	}
+
+	tcs := []TC{
+		{
+			mockDefault: &MockSetting{
+				Args: []interface{}{mock.Anything},
+				Returns: []interface{}{[]database.DefaultAllowance{
+					{AllowanceType: "personal", Amount: 60_000, UUID: "a1", CreatedAt: createdAt, UpdatedAt: updatedAt},
+				}, nil},
+			},
+			mockAllowed: &MockSetting{
+				Args: []interface{}{mock.Anything},
+				Returns: []interface{}{[]database.AllowedAllowance{
+					{AllowanceType: "donation", MaxAmount: 100_000, UUID: "b1", CreatedAt: createdAt, UpdatedAt: updatedAt},
+					{AllowanceType: "k-receipt", MaxAmount: 50_000, UUID: "b2", CreatedAt: createdAt, UpdatedAt: updatedAt},
+				}, nil},
+			},
+			wantCode: http.StatusOK,
+			want: AdminDeductionsResponse{
+				PersonalDeduction: 60_000,
+				KReceipt:          50_000,
+				DefaultAllowances: []DefaultAllowanceResponse{
+					{AllowanceType: "personal", Amount: 60_000, UUID: "a1", CreatedAt: &createdAt, UpdatedAt: &updatedAt},
+				},
+				AllowedAllowances: []AllowedAllowanceResponse{
+					{AllowanceType: "donation", MaxAmount: 100_000, UUID: "b1", CreatedAt: &createdAt, UpdatedAt: &updatedAt},
+					{AllowanceType: "k-receipt", MaxAmount: 50_000, UUID: "b2", CreatedAt: &createdAt, UpdatedAt: &updatedAt},
+				},
+			},
+		},
+		{
+			mockDefault: &MockSetting{
+				Args:    []interface{}{mock.Anything},
+				Returns: []interface{}{[]database.DefaultAllowance(nil), errors.New("an error")},
+			},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(AdminDBMock)
+
+			if tc.mockDefault != nil {
+				dbmock.On("FindAllDefaultAllowances", tc.mockDefault.Args...).Return(tc.mockDefault.Returns...)
+			}
+
+			if tc.mockAllowed != nil {
+				dbmock.On("FindAllAllowedAllowances", tc.mockAllowed.Args...).Return(tc.mockAllowed.Returns...)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/deductions", nil)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, NewAdminHandler(validator.New(), dbmock).GetDeductions(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			var got AdminDeductionsResponse
+
+			err := json.Unmarshal([]byte(rec.Body.String()), &got)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestAdminDisableAllowanceType(t *testing.T) {
+	type TC struct {
+		mockDisable error
+		wantCode    int
+		wantErr     errcode.Code
+	}
+
+	tcs := []TC{
+		{
+			mockDisable: nil,
+			wantCode:    http.StatusNoContent,
+		},
+		{
+			mockDisable: database.ErrNotFound,
+			wantCode:    http.StatusNotFound,
+			wantErr:     errcode.AllowanceNotFound,
+		},
+		{
+			mockDisable: database.ErrConflict,
+			wantCode:    http.StatusConflict,
+			wantErr:     errcode.SettingsVersionConflict,
+		},
+		{
+			mockDisable: errors.New("an error"),
+			wantCode:    http.StatusInternalServerError,
+			wantErr:     errcode.AllowanceDisableFailed,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(AdminDBMock)
+			dbmock.On("DisableAllowanceType", mock.Anything, "k-receipt").Return(tc.mockDisable)
+
+			req := httptest.NewRequest(http.MethodDelete, "/admin/deductions/k-receipt", nil)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("allowanceType")
+			c.SetParamValues("k-receipt")
+
+			assert.NoError(t, NewAdminHandler(validator.New(), dbmock).DisableAllowanceType(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+			dbmock.AssertExpectations(t)
+
+			if tc.wantErr == "" {
+				return
+			}
+
+			var got ResponseMsg
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tc.wantErr, got.ErrorCode)
+		})
+	}
+}
+
+func TestAdminPublishEffectiveAllowance(t *testing.T) {
+	type TC struct {
+		reqbody    string
+		mockUpsert *MockSetting
+		wantCode   int
+		want       *EffectiveAllowanceResponse
+		wantErr    errcode.Code
+	}
+
+	effectiveFrom := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	tcs := []TC{
+		{
+			reqbody: `{"allowanceType":"personal","amount":70000,"effectiveFrom":"2025-01-01"}`,
+			mockUpsert: &MockSetting{
+				Args: []interface{}{mock.Anything, database.EffectiveAllowance{
+					AllowanceKind: database.AllowanceKindDefault,
+					AllowanceType: "personal",
+					Amount:        70_000,
+					EffectiveFrom: effectiveFrom,
+				}},
+				Returns: []interface{}{database.EffectiveAllowance{
+					AllowanceKind: database.AllowanceKindDefault,
+					AllowanceType: "personal",
+					Amount:        70_000,
+					EffectiveFrom: effectiveFrom,
+				}, nil},
+			},
+			wantCode: http.StatusOK,
+			want:     &EffectiveAllowanceResponse{AllowanceType: "personal", Amount: 70_000, EffectiveFrom: "2025-01-01"},
+		},
+		{
+			reqbody: `{"allowanceType":"k-receipt","amount":80000,"effectiveFrom":"2025-01-01"}`,
+			mockUpsert: &MockSetting{
+				Args: []interface{}{mock.Anything, database.EffectiveAllowance{
+					AllowanceKind: database.AllowanceKindAllowed,
+					AllowanceType: "k-receipt",
+					Amount:        80_000,
+					EffectiveFrom: effectiveFrom,
+				}},
+				Returns: []interface{}{database.EffectiveAllowance{
+					AllowanceKind: database.AllowanceKindAllowed,
+					AllowanceType: "k-receipt",
+					Amount:        80_000,
+					EffectiveFrom: effectiveFrom,
+				}, nil},
+			},
+			wantCode: http.StatusOK,
+			want:     &EffectiveAllowanceResponse{AllowanceType: "k-receipt", Amount: 80_000, EffectiveFrom: "2025-01-01"},
+		},
+		{
+			reqbody:  `{"allowanceType":"unknown","amount":70000,"effectiveFrom":"2025-01-01"}`,
+			wantCode: http.StatusBadRequest,
+			wantErr:  errcode.InvalidRequest,
+		},
+		{
+			reqbody:  `{"allowanceType":"personal","amount":70000,"effectiveFrom":"01/01/2025"}`,
+			wantCode: http.StatusBadRequest,
+			wantErr:  errcode.InvalidDate,
+		},
+		{
+			reqbody:  `{"allowanceType":"personal","amount":9999,"effectiveFrom":"2025-01-01"}`,
+			wantCode: http.StatusBadRequest,
+			wantErr:  errcode.AmountOutOfRange,
+		},
+		{
+			reqbody: `{"allowanceType":"personal","amount":70000,"effectiveFrom":"2025-01-01"}`,
+			mockUpsert: &MockSetting{
+				Args:    []interface{}{mock.Anything, mock.Anything},
+				Returns: []interface{}{database.EffectiveAllowance{}, errors.New("an error")},
+			},
+			wantCode: http.StatusInternalServerError,
+			wantErr:  errcode.EffectiveAllowanceUpdateFailed,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(AdminDBMock)
+			mockSettingBounds(dbmock)
+
+			if tc.mockUpsert != nil {
+				dbmock.On("UpsertEffectiveAllowance", tc.mockUpsert.Args...).Return(tc.mockUpsert.Returns...)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/deductions/effective", strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, NewAdminHandler(validator.New(), dbmock).PublishEffectiveAllowance(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.want == nil {
+				var got ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, tc.wantErr, got.ErrorCode)
+
+				return
+			}
+
+			var got EffectiveAllowanceResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, *tc.want, got)
+		})
+	}
+}
+
+func TestAdminUpdateDryRun(t *testing.T) {
+	type TC struct {
+		update  func(h *AdminHandler) echo.HandlerFunc
+		reqbody string
+		want    DryRunResponse
+	}
+
+	tcs := []TC{
+		{
+			update:  func(h *AdminHandler) echo.HandlerFunc { return h.UpdatePesonal },
+			reqbody: `{"amount":70000,"sampleIncomes":[500000]}`,
+			want: DryRunResponse{
+				Setting:  "personal",
+				Current:  60_000,
+				Proposed: 70_000,
+				Projections: []ProjectionResult{
+					{TotalIncome: 500_000, CurrentTax: 29_000, ProjectedTax: 28_000},
+				},
+			},
+		},
+		{
+			update:  func(h *AdminHandler) echo.HandlerFunc { return h.UpdateKReceipt },
+			reqbody: `{"amount":100000,"sampleIncomes":[500000]}`,
+			want: DryRunResponse{
+				Setting:  "k-receipt",
+				Current:  50_000,
+				Proposed: 100_000,
+				Projections: []ProjectionResult{
+					{TotalIncome: 500_000, CurrentTax: 24_000, ProjectedTax: 19_000},
+				},
+			},
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(AdminDBMock)
+			mockSettingBounds(dbmock)
+			dbmock.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			dbmock.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+				{AllowanceType: "k-receipt", MaxAmount: 50_000},
+			}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/deductions?dryRun=true", strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			h := NewAdminHandler(validator.New(), dbmock)
+
+			assert.NoError(t, tc.update(h)(e.NewContext(req, rec)))
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var got DryRunResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tc.want, got)
+			dbmock.AssertNotCalled(t, "UpdateAmountDefaultAllowances", mock.Anything, mock.Anything, mock.Anything)
+			dbmock.AssertNotCalled(t, "UpdateAmountAllowedAllowances", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
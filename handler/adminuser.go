@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type AdminUserRequest struct {
+	Username string `json:"username" validate:"required,max=100"`
+	Password string `json:"password" validate:"required,min=12,max=72"`
+	Role     string `json:"role" validate:"required,oneof=viewer editor superadmin"`
+}
+
+type AdminUserRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=viewer editor superadmin"`
+}
+
+type AdminUserResponse struct {
+	ID        int       `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	UUID      string    `json:"uuid"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type AdminUserIDB interface {
+	FindAllAdminUsers(ctx context.Context) ([]database.AdminUser, error)
+	CreateAdminUser(ctx context.Context, username string, passwordHash string, role string) (database.AdminUser, error)
+	UpdateAdminUserRole(ctx context.Context, id int, role string) (database.AdminUser, error)
+	DeleteAdminUser(ctx context.Context, id int) error
+}
+
+type AdminUserHandler struct {
+	vl *validator.Validate
+	db AdminUserIDB
+}
+
+func NewAdminUserHandler(vl *validator.Validate, db AdminUserIDB) *AdminUserHandler {
+	return &AdminUserHandler{vl, db}
+}
+
+func toAdminUserResponse(u database.AdminUser) AdminUserResponse {
+	return AdminUserResponse{
+		ID:        u.ID,
+		Username:  u.Username,
+		Role:      u.Role,
+		UUID:      u.UUID,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
+
+func (h *AdminUserHandler) GetAdminUsers(c echo.Context) error {
+	users, err := h.db.FindAllAdminUsers(c.Request().Context())
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find admin users", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	results := []AdminUserResponse{}
+
+	for _, u := range users {
+		results = append(results, toAdminUserResponse(u))
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+func (h *AdminUserHandler) CreateAdminUser(c echo.Context) error {
+	var req AdminUserRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to hash password", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	created, err := h.db.CreateAdminUser(c.Request().Context(), req.Username, string(hash), req.Role)
+	if errors.Is(err, database.ErrAlreadyExists) {
+		return respondError(c, http.StatusConflict, errcode.AdminUserExists)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to create admin user", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.JSON(http.StatusCreated, toAdminUserResponse(created))
+}
+
+func (h *AdminUserHandler) UpdateAdminUserRole(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.AdminUserInvalidID)
+	}
+
+	var req AdminUserRoleRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	updated, err := h.db.UpdateAdminUserRole(c.Request().Context(), id, req.Role)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.AdminUserNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to update admin user role", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.JSON(http.StatusOK, toAdminUserResponse(updated))
+}
+
+func (h *AdminUserHandler) DeleteAdminUser(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.AdminUserInvalidID)
+	}
+
+	err = h.db.DeleteAdminUser(c.Request().Context(), id)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.AdminUserNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to delete admin user", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
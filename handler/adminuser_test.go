@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type AdminUserDBMock struct {
+	mock.Mock
+}
+
+func (o *AdminUserDBMock) FindAllAdminUsers(ctx context.Context) ([]database.AdminUser, error) {
+	args := o.Called(ctx)
+	return args.Get(0).([]database.AdminUser), args.Error(1)
+}
+
+func (o *AdminUserDBMock) FindAdminUserByUsername(ctx context.Context, username string) (database.AdminUser, error) {
+	args := o.Called(ctx, username)
+	return args.Get(0).(database.AdminUser), args.Error(1)
+}
+
+func (o *AdminUserDBMock) CreateAdminUser(ctx context.Context, username string, passwordHash string, role string) (database.AdminUser, error) {
+	args := o.Called(ctx, username, passwordHash, role)
+	return args.Get(0).(database.AdminUser), args.Error(1)
+}
+
+func (o *AdminUserDBMock) UpdateAdminUserRole(ctx context.Context, id int, role string) (database.AdminUser, error) {
+	args := o.Called(ctx, id, role)
+	return args.Get(0).(database.AdminUser), args.Error(1)
+}
+
+func (o *AdminUserDBMock) UpdateAdminUserTOTP(ctx context.Context, username string, secret []byte, enabled bool) error {
+	args := o.Called(ctx, username, secret, enabled)
+	return args.Error(0)
+}
+
+func (o *AdminUserDBMock) DeleteAdminUser(ctx context.Context, id int) error {
+	args := o.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestAdminCreateAdminUser(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type TC struct {
+		reqbody    map[string]interface{}
+		mockCreate *MockSetting
+		wantCode   int
+	}
+
+	tcs := []TC{
+		{
+			reqbody: map[string]interface{}{"username": "somchai", "password": "correct horse battery", "role": "editor"},
+			mockCreate: &MockSetting{
+				Args:    []interface{}{mock.Anything, "somchai", mock.Anything, "editor"},
+				Returns: []interface{}{database.AdminUser{ID: 1, Username: "somchai", Role: "editor", CreatedAt: createdAt}, nil},
+			},
+			wantCode: http.StatusCreated,
+		},
+		{
+			reqbody:  map[string]interface{}{"username": "somchai", "password": "correct horse battery", "role": "root"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			reqbody:  map[string]interface{}{"username": "somchai", "password": "short", "role": "editor"},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			reqbody: map[string]interface{}{"username": "somchai", "password": "correct horse battery", "role": "editor"},
+			mockCreate: &MockSetting{
+				Args:    []interface{}{mock.Anything, "somchai", mock.Anything, "editor"},
+				Returns: []interface{}{database.AdminUser{}, database.ErrAlreadyExists},
+			},
+			wantCode: http.StatusConflict,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(AdminUserDBMock)
+
+			if tc.mockCreate != nil {
+				dbmock.On("CreateAdminUser", tc.mockCreate.Args...).Return(tc.mockCreate.Returns...)
+			}
+
+			h := NewAdminUserHandler(validator.New(), dbmock)
+
+			val, _ := json.Marshal(tc.reqbody)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/users", strings.NewReader(string(val)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, h.CreateAdminUser(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.NotContains(t, rec.Body.String(), "password")
+
+			if tc.wantCode == http.StatusCreated {
+				// password is stored as bcrypt hash
+				hash := dbmock.Calls[0].Arguments.String(2)
+				assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("correct horse battery")))
+			}
+		})
+	}
+}
+
+func TestAdminLoginWithAdminUser(t *testing.T) {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("correct horse battery"), bcrypt.MinCost)
+
+	dbmock := new(AdminUserDBMock)
+	dbmock.On("FindAdminUserByUsername", mock.Anything, "somchai").
+		Return(database.AdminUser{ID: 1, Username: "somchai", PasswordHash: string(hash), Role: RoleViewer}, nil)
+	dbmock.On("FindAdminUserByUsername", mock.Anything, "nobody").
+		Return(database.AdminUser{}, database.ErrNotFound)
+
+	type TC struct {
+		username string
+		password string
+		wantCode int
+	}
+
+	tcs := []TC{
+		{username: "somchai", password: "correct horse battery", wantCode: http.StatusOK},
+		{username: "somchai", password: "wrong password", wantCode: http.StatusUnauthorized},
+		{username: "nobody", password: "correct horse battery", wantCode: http.StatusUnauthorized},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			h := NewAuthHandler(validator.New(), testAuthConfig).SetUsers(dbmock)
+
+			val, _ := json.Marshal(map[string]interface{}{"username": tc.username, "password": tc.password})
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/login", strings.NewReader(string(val)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, h.Login(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			var got LoginResponse
+
+			err := json.Unmarshal([]byte(rec.Body.String()), &got)
+			assert.NoError(t, err)
+
+			claims, err := testAuthConfig.parseToken(got.AccessToken)
+			assert.NoError(t, err)
+			assert.Equal(t, RoleViewer, claims.Role)
+		})
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	type TC struct {
+		role     string
+		require  string
+		wantCode int
+	}
+
+	tcs := []TC{
+		{role: RoleViewer, require: RoleViewer, wantCode: http.StatusOK},
+		{role: RoleViewer, require: RoleEditor, wantCode: http.StatusForbidden},
+		{role: RoleEditor, require: RoleViewer, wantCode: http.StatusOK},
+		{role: RoleEditor, require: RoleSuperadmin, wantCode: http.StatusForbidden},
+		{role: RoleSuperadmin, require: RoleSuperadmin, wantCode: http.StatusOK},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			e := echo.New()
+			e.POST("/admin/deductions/personal", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, AdminAuth(testAuthConfig, nil), RequireRole(tc.require))
+
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, AdminClaims{
+				Role: tc.role,
+				RegisteredClaims: jwt.RegisteredClaims{
+					Issuer:    tokenIssuer,
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+				},
+			}).SignedString(testAuthConfig.Secret)
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/deductions/personal", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
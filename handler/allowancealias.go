@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type AllowanceAliasRequest struct {
+	AllowanceType string `json:"allowanceType" validate:"required,max=100"`
+}
+
+type AllowanceAliasResponse struct {
+	Alias         string    `json:"alias"`
+	AllowanceType string    `json:"allowanceType"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+// AliasReader finds aliases resolved by calculations
+type AliasReader interface {
+	FindAllowanceAliases(ctx context.Context) ([]database.AllowanceAlias, error)
+}
+
+type AllowanceAliasIDB interface {
+	FindAllowanceAliases(ctx context.Context) ([]database.AllowanceAlias, error)
+	UpsertAllowanceAlias(ctx context.Context, alias string, allowanceType string) (database.AllowanceAlias, error)
+	DeleteAllowanceAlias(ctx context.Context, alias string) error
+}
+
+type AllowanceAliasHandler struct {
+	vl *validator.Validate
+	db AllowanceAliasIDB
+}
+
+func NewAllowanceAliasHandler(vl *validator.Validate, db AllowanceAliasIDB) *AllowanceAliasHandler {
+	return &AllowanceAliasHandler{vl, db}
+}
+
+func toAllowanceAliasResponse(a database.AllowanceAlias) AllowanceAliasResponse {
+	return AllowanceAliasResponse{
+		Alias:         a.Alias,
+		AllowanceType: a.AllowanceType,
+		CreatedAt:     a.CreatedAt,
+		UpdatedAt:     a.UpdatedAt,
+	}
+}
+
+// normalizeAllowanceType makes near-miss spellings of an allowance type match, e.g. " Donation" is "donation"
+func normalizeAllowanceType(allowanceType string) string {
+	return strings.ToLower(strings.TrimSpace(allowanceType))
+}
+
+func (h *AllowanceAliasHandler) GetAllowanceAliases(c echo.Context) error {
+	aliases, err := h.db.FindAllowanceAliases(c.Request().Context())
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find allowance aliases", "error", err)
+		return respondQueryError(c)
+	}
+
+	results := []AllowanceAliasResponse{}
+
+	for _, a := range aliases {
+		results = append(results, toAllowanceAliasResponse(a))
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// PutAllowanceAlias creates alias of path param or points it at another allowance type, both are normalized.
+// An alias of itself would never resolve to anything else, so it's rejected
+func (h *AllowanceAliasHandler) PutAllowanceAlias(c echo.Context) error {
+	alias := normalizeAllowanceType(c.Param("alias"))
+	if alias == "" || len(alias) > 100 {
+		return respondError(c, http.StatusBadRequest, errcode.AllowanceAliasInvalid)
+	}
+
+	var req AllowanceAliasRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	allowanceType := normalizeAllowanceType(req.AllowanceType)
+	if allowanceType == "" || allowanceType == alias {
+		return respondError(c, http.StatusBadRequest, errcode.AllowanceAliasInvalid)
+	}
+
+	a, err := h.db.UpsertAllowanceAlias(c.Request().Context(), alias, allowanceType)
+	if errors.Is(err, database.ErrConflict) {
+		return respondError(c, http.StatusConflict, errcode.SettingsVersionConflict)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to save allowance alias", "alias", alias, "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	slog.InfoContext(c.Request().Context(), "saved allowance alias", "alias", alias, "allowance_type", allowanceType)
+
+	return c.JSON(http.StatusOK, toAllowanceAliasResponse(a))
+}
+
+func (h *AllowanceAliasHandler) DeleteAllowanceAlias(c echo.Context) error {
+	alias := normalizeAllowanceType(c.Param("alias"))
+
+	err := h.db.DeleteAllowanceAlias(c.Request().Context(), alias)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.AllowanceAliasNotFound)
+	}
+
+	if errors.Is(err, database.ErrConflict) {
+		return respondError(c, http.StatusConflict, errcode.SettingsVersionConflict)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to delete allowance alias", "alias", alias, "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// findAllowanceAliases returns allowance type of every alias, nothing is aliased without a reader
+func findAllowanceAliases(ctx context.Context, aliases AliasReader) (map[string]string, error) {
+	if aliases == nil {
+		return nil, nil
+	}
+
+	all, err := aliases.FindAllowanceAliases(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find allowance aliases", "error", err)
+		return nil, err
+	}
+
+	types := make(map[string]string, len(all))
+	for _, a := range all {
+		types[a.Alias] = a.AllowanceType
+	}
+
+	return types, nil
+}
+
+// resolveAllowances returns allowances with normalized types, and aliases replaced by their allowance types.
+// allowances is left as is since it may be the request of the caller
+func resolveAllowances(allowances []Allowance, aliases map[string]string) []Allowance {
+	if allowances == nil {
+		return nil
+	}
+
+	resolved := make([]Allowance, 0, len(allowances))
+
+	for _, a := range allowances {
+		a.AllowanceType = normalizeAllowanceType(a.AllowanceType)
+		if allowanceType, ok := aliases[a.AllowanceType]; ok {
+			a.AllowanceType = allowanceType
+		}
+
+		resolved = append(resolved, a)
+	}
+
+	return resolved
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type AllowanceAliasDBMock struct {
+	mock.Mock
+}
+
+func (o *AllowanceAliasDBMock) FindAllowanceAliases(ctx context.Context) ([]database.AllowanceAlias, error) {
+	args := o.Called(ctx)
+	return args.Get(0).([]database.AllowanceAlias), args.Error(1)
+}
+
+func (o *AllowanceAliasDBMock) UpsertAllowanceAlias(ctx context.Context, alias string, allowanceType string) (database.AllowanceAlias, error) {
+	args := o.Called(ctx, alias, allowanceType)
+	return args.Get(0).(database.AllowanceAlias), args.Error(1)
+}
+
+func (o *AllowanceAliasDBMock) DeleteAllowanceAlias(ctx context.Context, alias string) error {
+	args := o.Called(ctx, alias)
+	return args.Error(0)
+}
+
+func TestPutAllowanceAlias(t *testing.T) {
+	type TC struct {
+		alias     string
+		reqbody   string
+		wantCode  int
+		wantError errcode.Code
+	}
+
+	tcs := []TC{
+		{alias: "KReceipt", reqbody: `{"allowanceType":" K-Receipt "}`, wantCode: http.StatusOK},
+		{alias: "k-receipt", reqbody: `{"allowanceType":"k-receipt"}`, wantCode: http.StatusBadRequest, wantError: errcode.AllowanceAliasInvalid},
+		{alias: " ", reqbody: `{"allowanceType":"k-receipt"}`, wantCode: http.StatusBadRequest, wantError: errcode.AllowanceAliasInvalid},
+		{alias: "kreceipt", reqbody: `{}`, wantCode: http.StatusBadRequest, wantError: errcode.InvalidRequest},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(AllowanceAliasDBMock)
+			mockObj.On("UpsertAllowanceAlias", mock.Anything, "kreceipt", "k-receipt").
+				Return(database.AllowanceAlias{Alias: "kreceipt", AllowanceType: "k-receipt"}, nil)
+
+			req := httptest.NewRequest(http.MethodPut, "/admin/allowance-aliases/alias", strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			c := echo.New().NewContext(req, rec)
+			c.SetParamNames("alias")
+			c.SetParamValues(tc.alias)
+
+			assert.NoError(t, NewAllowanceAliasHandler(validator.New(), mockObj).PutAllowanceAlias(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				var got ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, tc.wantError, got.ErrorCode)
+
+				return
+			}
+
+			var got AllowanceAliasResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, "kreceipt", got.Alias)
+			assert.Equal(t, "k-receipt", got.AllowanceType)
+		})
+	}
+}
+
+func TestCalculateAllowanceAliases(t *testing.T) {
+	type TC struct {
+		aliasErr error
+		fallback bool
+		wantTax  float64
+		wantErr  bool
+	}
+
+	tcs := []TC{
+		// 500,000 - 60,000 personal - 100,000 donation - 50,000 k-receipt
+		{wantTax: 14_000},
+		{aliasErr: errors.New("connection refused"), wantErr: true},
+		// the alias isn't resolved in degraded mode, so only donation is claimed
+		{aliasErr: errors.New("connection refused"), fallback: true, wantTax: 19_000},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			taxes := new(UserDBMock)
+			taxes.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			taxes.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+				{AllowanceType: "donation", MaxAmount: 100_000},
+				{AllowanceType: "k-receipt", MaxAmount: 50_000},
+			}, nil)
+
+			aliases := new(AllowanceAliasDBMock)
+			aliases.On("FindAllowanceAliases", mock.Anything).Return([]database.AllowanceAlias{
+				{Alias: "kreceipt", AllowanceType: "k-receipt"},
+			}, tc.aliasErr)
+
+			claimed := []Allowance{
+				{AllowanceType: " Donation ", Amount: 100_000},
+				{AllowanceType: "KReceipt", Amount: 50_000},
+			}
+
+			h := NewTaxHandler(validator.New(), taxes).SetAliases(aliases).SetFallback(func() bool { return tc.fallback })
+
+			resp, degraded, err := h.Calculate(context.Background(), Calculation{
+				TaxRequest: TaxRequest{TotalIncome: 500_000, Allowances: claimed},
+				TaxYear:    defaultTaxYear,
+			})
+
+			// allowances of the caller are left as they are
+			assert.Equal(t, " Donation ", claimed[0].AllowanceType)
+
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.fallback, degraded)
+			assert.Equal(t, tc.wantTax, resp.Tax)
+		})
+	}
+}
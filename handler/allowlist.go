@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+)
+
+// ParseCIDRs parses comma separated CIDRs, a single IP is treated as /32 or /128
+func ParseCIDRs(s string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid ip %q", v)
+			}
+
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+
+			v = fmt.Sprintf("%s/%d", v, bits)
+		}
+
+		_, n, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, err
+		}
+
+		nets = append(nets, n)
+	}
+
+	return nets, nil
+}
+
+// AllowCIDRs rejects clients whose ip is not in nets, client ip comes from echo's IPExtractor,
+// so X-Forwarded-For is only used when it's set by a trusted proxy
+func AllowCIDRs(nets []*net.IPNet) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ip := net.ParseIP(c.RealIP())
+
+			for _, n := range nets {
+				if ip != nil && n.Contains(ip) {
+					return next(c)
+				}
+			}
+
+			slog.WarnContext(c.Request().Context(), "rejected request from ip not in allowlist", "ip", c.RealIP())
+
+			return respondError(c, http.StatusForbidden, errcode.IPNotAllowed)
+		}
+	}
+}
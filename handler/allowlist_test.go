@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowCIDRs(t *testing.T) {
+	nets, err := ParseCIDRs("10.0.0.0/8, 203.0.113.7")
+	assert.NoError(t, err)
+
+	lb, err := ParseCIDRs("192.168.0.0/16")
+	assert.NoError(t, err)
+
+	type TC struct {
+		remoteAddr   string
+		forwardedFor string
+		trustProxies bool
+		wantCode     int
+	}
+
+	tcs := []TC{
+		{remoteAddr: "10.1.2.3:5000", wantCode: http.StatusOK},
+		{remoteAddr: "203.0.113.7:5000", wantCode: http.StatusOK},
+		{remoteAddr: "203.0.113.8:5000", wantCode: http.StatusForbidden},
+		// forwarded for is ignored when request doesn't come from a trusted proxy
+		{remoteAddr: "198.51.100.1:5000", forwardedFor: "10.1.2.3", wantCode: http.StatusForbidden},
+		{remoteAddr: "192.168.1.1:5000", forwardedFor: "10.1.2.3", trustProxies: true, wantCode: http.StatusOK},
+		{remoteAddr: "192.168.1.1:5000", forwardedFor: "198.51.100.1", trustProxies: true, wantCode: http.StatusForbidden},
+		// client can't spoof by prepending an address
+		{remoteAddr: "192.168.1.1:5000", forwardedFor: "10.1.2.3, 198.51.100.1", trustProxies: true, wantCode: http.StatusForbidden},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			e := echo.New()
+			e.IPExtractor = echo.ExtractIPDirect()
+
+			if tc.trustProxies {
+				e.IPExtractor = echo.ExtractIPFromXFFHeader(
+					echo.TrustLoopback(false),
+					echo.TrustLinkLocal(false),
+					echo.TrustPrivateNet(false),
+					echo.TrustIPRange(lb[0]),
+				)
+			}
+
+			e.POST("/admin/deductions/personal", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, AllowCIDRs(nets))
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/deductions/personal", nil)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tc.forwardedFor)
+			}
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/buildinfo"
+	"github.com/labstack/echo/v4"
+)
+
+// v1Prefix serves every route under /v1 as well, responses of /v1 routes are wrapped in EnvelopeResponse
+const v1Prefix = "/v1"
+
+const envelopeContextKey = "envelope"
+
+// EnvelopeResponse is the body of every JSON response of /v1 routes, Data is the body of the route without /v1
+// and is null when the request failed, Error is its ResponseMsg then
+type EnvelopeResponse struct {
+	Data  json.RawMessage `json:"data"`
+	Error *ResponseMsg    `json:"error"`
+	Meta  EnvelopeMeta    `json:"meta"`
+}
+
+type EnvelopeMeta struct {
+	RequestID string    `json:"requestId"`
+	Timestamp time.Time `json:"timestamp"`
+	Version   string    `json:"version"`
+}
+
+// VersionedPath must be used by echo's Pre, it strips /v1 before routing so /v1 routes are served by the same
+// handlers, and marks the request to be wrapped by Envelope
+func VersionedPath() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+
+			path, ok := strings.CutPrefix(req.URL.Path, v1Prefix)
+			if !ok || (path != "" && path[0] != '/') {
+				return next(c)
+			}
+
+			if path == "" {
+				path = "/"
+			}
+
+			req.URL.Path = path
+			req.URL.RawPath = strings.TrimPrefix(req.URL.RawPath, v1Prefix)
+
+			c.Set(envelopeContextKey, true)
+
+			return next(c)
+		}
+	}
+}
+
+// bufferedWriter keeps the response, so Envelope can wrap it once the handler is done
+type bufferedWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *bufferedWriter) WriteHeader(status int) {
+	w.status = status
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+// Envelope wraps JSON responses of requests marked by VersionedPath, responses of other content types and empty ones
+// are sent as they are. Errors are responded inside it, so it must be used after middlewares which replace the
+// response writer, e.g. gzip, to let them write the wrapped body. The request id is the one set by logging middleware
+func Envelope(now func() time.Time) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if wrap, _ := c.Get(envelopeContextKey).(bool); !wrap {
+				return next(c)
+			}
+
+			res := c.Response()
+			w := &bufferedWriter{ResponseWriter: res.Writer}
+
+			res.Writer = w
+			// a panic is responded by Recover with the writer of the request
+			defer func() { res.Writer = w.ResponseWriter }()
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			res.Writer = w.ResponseWriter
+
+			if w.status == 0 {
+				return err
+			}
+
+			body := w.body.Bytes()
+
+			if len(body) > 0 && strings.HasPrefix(res.Header().Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+				env := EnvelopeResponse{
+					Meta: EnvelopeMeta{
+						RequestID: res.Header().Get(echo.HeaderXRequestID),
+						Timestamp: now().UTC(),
+						Version:   buildinfo.Version,
+					},
+				}
+
+				var msg ResponseMsg
+
+				if w.status >= http.StatusBadRequest && json.Unmarshal(body, &msg) == nil {
+					env.Error = &msg
+				} else {
+					env.Data = body
+				}
+
+				if wrapped, marshalErr := json.Marshal(env); marshalErr == nil {
+					body = wrapped
+				}
+
+				res.Header().Del(echo.HeaderContentLength)
+			}
+
+			w.ResponseWriter.WriteHeader(w.status)
+
+			n, writeErr := w.ResponseWriter.Write(body)
+			res.Size = int64(n)
+
+			if err == nil {
+				err = writeErr
+			}
+
+			return err
+		}
+	}
+}
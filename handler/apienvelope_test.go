@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/buildinfo"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvelope(t *testing.T) {
+	now := time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)
+
+	e := echo.New()
+	e.Pre(VersionedPath())
+	e.Use(Envelope(func() time.Time { return now }))
+
+	e.GET("/items", func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderXRequestID, "req-1")
+		return c.JSON(http.StatusOK, map[string]int{"id": 1})
+	})
+	e.GET("/items/missing", func(c echo.Context) error {
+		return respondError(c, http.StatusNotFound, errcode.NotFound)
+	})
+	e.GET("/report", func(c echo.Context) error {
+		return c.String(http.StatusOK, "a,b")
+	})
+	e.DELETE("/items", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	type TC struct {
+		method   string
+		path     string
+		wantCode int
+		want     *EnvelopeResponse
+		wantBody string
+	}
+
+	tcs := []TC{
+		{
+			method:   http.MethodGet,
+			path:     "/v1/items",
+			wantCode: http.StatusOK,
+			want: &EnvelopeResponse{
+				Data: json.RawMessage(`{"id":1}`),
+				Meta: EnvelopeMeta{RequestID: "req-1", Timestamp: now, Version: buildinfo.Version},
+			},
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/v1/items/missing",
+			wantCode: http.StatusNotFound,
+			want: &EnvelopeResponse{
+				Data:  json.RawMessage(`null`),
+				Error: &ResponseMsg{Message: "Not found", ErrorCode: errcode.NotFound},
+				Meta:  EnvelopeMeta{Timestamp: now, Version: buildinfo.Version},
+			},
+		},
+		// errors of echo, e.g. unknown routes, are wrapped too
+		{
+			method:   http.MethodGet,
+			path:     "/v1/unknown",
+			wantCode: http.StatusNotFound,
+			want: &EnvelopeResponse{
+				Data:  json.RawMessage(`null`),
+				Error: &ResponseMsg{Message: "Not Found"},
+				Meta:  EnvelopeMeta{Timestamp: now, Version: buildinfo.Version},
+			},
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/v1/report",
+			wantCode: http.StatusOK,
+			wantBody: "a,b",
+		},
+		{
+			method:   http.MethodDelete,
+			path:     "/v1/items",
+			wantCode: http.StatusNoContent,
+			wantBody: "",
+		},
+		// routes without /v1 aren't wrapped
+		{
+			method:   http.MethodGet,
+			path:     "/items",
+			wantCode: http.StatusOK,
+			wantBody: "{\"id\":1}\n",
+		},
+		{
+			method:   http.MethodGet,
+			path:     "/v1items",
+			wantCode: http.StatusNotFound,
+			wantBody: "{\"message\":\"Not Found\"}\n",
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.want == nil {
+				assert.Equal(t, tc.wantBody, rec.Body.String())
+				return
+			}
+
+			var got EnvelopeResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, *tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	ScopeCalculate  = "calculate"
+	ScopeUploadCSV  = "upload-csv"
+	ScopeConfigRead = "config:read"
+)
+
+const (
+	apiKeyHeader     = "X-Api-Key"
+	apiKeyContextKey = "apiKey"
+	apiKeyPrefix     = "ktx_"
+)
+
+type APIKeyRequest struct {
+	Name   string   `json:"name" validate:"required,max=100"`
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=calculate upload-csv config:read"`
+}
+
+type APIKeyScopesRequest struct {
+	Scopes []string `json:"scopes" validate:"required,min=1,dive,oneof=calculate upload-csv config:read"`
+}
+
+type APIKeyQuotaRequest struct {
+	MonthlyQuota *int `json:"monthlyQuota" validate:"omitempty,gte=1"`
+}
+
+type APIKeyResponse struct {
+	ID           int      `json:"id"`
+	Name         string   `json:"name"`
+	Prefix       string   `json:"prefix"`
+	Scopes       []string `json:"scopes"`
+	MonthlyQuota *int     `json:"monthlyQuota"`
+	Signed       bool     `json:"signed"`
+	TenantID     *int     `json:"tenantId"`
+	Key          string   `json:"key,omitempty"`
+	// SigningSecret is shown only once when it's generated
+	SigningSecret string     `json:"signingSecret,omitempty"`
+	UUID          string     `json:"uuid"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	RevokedAt     *time.Time `json:"revokedAt"`
+}
+
+type APIKeyIDB interface {
+	CreateAPIKey(ctx context.Context, name string, prefix string, hash string, scopes []string) (database.APIKey, error)
+	FindAllAPIKeys(ctx context.Context) ([]database.APIKey, error)
+	UpdateAPIKeyScopes(ctx context.Context, id int, scopes []string) (database.APIKey, error)
+	UpdateAPIKeyQuota(ctx context.Context, id int, quota *int) (database.APIKey, error)
+	UpdateAPIKeySigningSecret(ctx context.Context, id int, secret *string) (database.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+}
+
+type APIKeyAuthIDB interface {
+	FindActiveAPIKeyByHash(ctx context.Context, hash string) (database.APIKey, error)
+}
+
+type APIKeyHandler struct {
+	vl *validator.Validate
+	db APIKeyIDB
+}
+
+func NewAPIKeyHandler(vl *validator.Validate, db APIKeyIDB) *APIKeyHandler {
+	return &APIKeyHandler{vl, db}
+}
+
+// hashAPIKey returns hash stored in database, keys are random so a fast hash is enough
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateAPIKey() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return apiKeyPrefix + hex.EncodeToString(b), nil
+}
+
+func toAPIKeyResponse(k database.APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:           k.ID,
+		Name:         k.Name,
+		Prefix:       k.Prefix,
+		Scopes:       k.Scopes,
+		MonthlyQuota: k.MonthlyQuota,
+		Signed:       k.SigningSecret != nil,
+		TenantID:     k.TenantID,
+		UUID:         k.UUID,
+		CreatedAt:    k.CreatedAt,
+		UpdatedAt:    k.UpdatedAt,
+		RevokedAt:    k.RevokedAt,
+	}
+}
+
+func (h *APIKeyHandler) GetAPIKeys(c echo.Context) error {
+	keys, err := h.db.FindAllAPIKeys(c.Request().Context())
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find api keys", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	results := []APIKeyResponse{}
+
+	for _, k := range keys {
+		results = append(results, toAPIKeyResponse(k))
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+func (h *APIKeyHandler) CreateAPIKey(c echo.Context) error {
+	var req APIKeyRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	key, err := generateAPIKey()
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to generate api key", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	created, err := h.db.CreateAPIKey(c.Request().Context(), req.Name, key[:len(apiKeyPrefix)+8], hashAPIKey(key), req.Scopes)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to create api key", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.APIKeyCreateFailed)
+	}
+
+	// plain key is shown only once, only its hash is stored
+	resp := toAPIKeyResponse(created)
+	resp.Key = key
+
+	return c.JSON(http.StatusCreated, resp)
+}
+
+func (h *APIKeyHandler) UpdateAPIKeyScopes(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.APIKeyInvalidID)
+	}
+
+	var req APIKeyScopesRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	updated, err := h.db.UpdateAPIKeyScopes(c.Request().Context(), id, req.Scopes)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.APIKeyNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to update api key scopes", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.JSON(http.StatusOK, toAPIKeyResponse(updated))
+}
+
+// UpdateAPIKeyQuota sets monthly request quota, null quota removes the limit
+func (h *APIKeyHandler) UpdateAPIKeyQuota(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.APIKeyInvalidID)
+	}
+
+	var req APIKeyQuotaRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	updated, err := h.db.UpdateAPIKeyQuota(c.Request().Context(), id, req.MonthlyQuota)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.APIKeyNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to update api key quota", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.JSON(http.StatusOK, toAPIKeyResponse(updated))
+}
+
+// EnableSigning generates new signing secret, requests of the key must be signed afterwards
+func (h *APIKeyHandler) EnableSigning(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.APIKeyInvalidID)
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to generate signing secret", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	secret := hex.EncodeToString(b)
+
+	updated, err := h.db.UpdateAPIKeySigningSecret(c.Request().Context(), id, &secret)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.APIKeyNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to enable api key signing", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	resp := toAPIKeyResponse(updated)
+	resp.SigningSecret = secret
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func (h *APIKeyHandler) DisableSigning(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.APIKeyInvalidID)
+	}
+
+	updated, err := h.db.UpdateAPIKeySigningSecret(c.Request().Context(), id, nil)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.APIKeyNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to disable api key signing", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.JSON(http.StatusOK, toAPIKeyResponse(updated))
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.APIKeyInvalidID)
+	}
+
+	err = h.db.RevokeAPIKey(c.Request().Context(), id)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.APIKeyNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to revoke api key", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// FindAPIKey returns active key, the error is database.ErrNotFound for an unknown or revoked key
+func FindAPIKey(ctx context.Context, db APIKeyAuthIDB, key string) (database.APIKey, error) {
+	return db.FindActiveAPIKeyByHash(ctx, hashAPIKey(key))
+}
+
+// APIKeyAuth authenticates requests by X-Api-Key header, the key is available by CurrentAPIKey
+func APIKeyAuth(db APIKeyAuthIDB) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			key := c.Request().Header.Get(apiKeyHeader)
+			if key == "" {
+				return respondError(c, http.StatusUnauthorized, errcode.APIKeyInvalid)
+			}
+
+			apiKey, err := FindAPIKey(c.Request().Context(), db, key)
+			if errors.Is(err, database.ErrNotFound) {
+				return respondError(c, http.StatusUnauthorized, errcode.APIKeyInvalid)
+			}
+
+			if err != nil {
+				slog.ErrorContext(c.Request().Context(), "failed to find api key", "error", err)
+				return respondQueryError(c)
+			}
+
+			c.Set(apiKeyContextKey, apiKey)
+
+			return next(c)
+		}
+	}
+}
+
+func CurrentAPIKey(c echo.Context) (database.APIKey, bool) {
+	k, ok := c.Get(apiKeyContextKey).(database.APIKey)
+	return k, ok
+}
+
+// currentAPIKeyID returns id of authenticated key as subject of stored data, nil when api key auth is disabled
+func currentAPIKeyID(c echo.Context) *int {
+	k, ok := CurrentAPIKey(c)
+	if !ok {
+		return nil
+	}
+
+	return &k.ID
+}
+
+// currentTenantID returns tenant of authenticated key, nil when the key has none or api key auth is disabled
+func currentTenantID(c echo.Context) *int {
+	k, ok := CurrentAPIKey(c)
+	if !ok {
+		return nil
+	}
+
+	return k.TenantID
+}
+
+// RequireScope rejects keys without scope, it does nothing when api key auth is disabled
+func RequireScope(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if k, ok := CurrentAPIKey(c); ok && !slices.Contains(k.Scopes, scope) {
+				return respondError(c, http.StatusForbidden, errcode.APIKeyScopeDenied)
+			}
+
+			return next(c)
+		}
+	}
+}
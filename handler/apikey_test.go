@@ -0,0 +1,294 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type APIKeyDBMock struct {
+	mock.Mock
+}
+
+func (o *APIKeyDBMock) CreateAPIKey(ctx context.Context, name string, prefix string, hash string, scopes []string) (database.APIKey, error) {
+	args := o.Called(ctx, name, prefix, hash, scopes)
+	return args.Get(0).(database.APIKey), args.Error(1)
+}
+
+func (o *APIKeyDBMock) FindAllAPIKeys(ctx context.Context) ([]database.APIKey, error) {
+	args := o.Called(ctx)
+	return args.Get(0).([]database.APIKey), args.Error(1)
+}
+
+func (o *APIKeyDBMock) FindActiveAPIKeyByHash(ctx context.Context, hash string) (database.APIKey, error) {
+	args := o.Called(ctx, hash)
+	return args.Get(0).(database.APIKey), args.Error(1)
+}
+
+func (o *APIKeyDBMock) UpdateAPIKeyScopes(ctx context.Context, id int, scopes []string) (database.APIKey, error) {
+	args := o.Called(ctx, id, scopes)
+	return args.Get(0).(database.APIKey), args.Error(1)
+}
+
+func (o *APIKeyDBMock) UpdateAPIKeyQuota(ctx context.Context, id int, quota *int) (database.APIKey, error) {
+	args := o.Called(ctx, id, quota)
+	return args.Get(0).(database.APIKey), args.Error(1)
+}
+
+func (o *APIKeyDBMock) UpdateAPIKeySigningSecret(ctx context.Context, id int, secret *string) (database.APIKey, error) {
+	args := o.Called(ctx, id, secret)
+	return args.Get(0).(database.APIKey), args.Error(1)
+}
+
+func (o *APIKeyDBMock) RevokeAPIKey(ctx context.Context, id int) error {
+	args := o.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestAdminCreateAPIKey(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type TC struct {
+		reqbody     map[string]interface{}
+		mockCreate  *MockSetting
+		wantCode    int
+		wantMessage string
+	}
+
+	tcs := []TC{
+		{
+			reqbody: map[string]interface{}{
+				"name":   "payroll",
+				"scopes": []string{"calculate"},
+			},
+			mockCreate: &MockSetting{
+				Args: []interface{}{mock.Anything, "payroll", mock.Anything, mock.Anything, []string{"calculate"}},
+				Returns: []interface{}{
+					database.APIKey{ID: 1, Name: "payroll", Prefix: "ktx_01234567", Scopes: []string{"calculate"}, CreatedAt: createdAt},
+					nil,
+				},
+			},
+			wantCode: http.StatusCreated,
+		},
+		{
+			reqbody: map[string]interface{}{
+				"name":   "payroll",
+				"scopes": []string{"admin"},
+			},
+			mockCreate:  nil,
+			wantCode:    http.StatusBadRequest,
+			wantMessage: "Bad request",
+		},
+		{
+			reqbody: map[string]interface{}{
+				"name": "payroll",
+			},
+			mockCreate:  nil,
+			wantCode:    http.StatusBadRequest,
+			wantMessage: "Bad request",
+		},
+		{
+			reqbody: map[string]interface{}{
+				"name":   "payroll",
+				"scopes": []string{"calculate"},
+			},
+			mockCreate: &MockSetting{
+				Args:    []interface{}{mock.Anything, "payroll", mock.Anything, mock.Anything, []string{"calculate"}},
+				Returns: []interface{}{database.APIKey{}, errors.New("an error")},
+			},
+			wantCode:    http.StatusInternalServerError,
+			wantMessage: "Failed to create api key",
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(APIKeyDBMock)
+
+			if tc.mockCreate != nil {
+				dbmock.On("CreateAPIKey", tc.mockCreate.Args...).Return(tc.mockCreate.Returns...)
+			}
+
+			h := NewAPIKeyHandler(validator.New(), dbmock)
+
+			val, _ := json.Marshal(tc.reqbody)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/api-keys", strings.NewReader(string(val)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, h.CreateAPIKey(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantMessage != "" {
+				var errresp ResponseMsg
+
+				err := json.Unmarshal([]byte(rec.Body.String()), &errresp)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wantMessage, errresp.Message)
+
+				return
+			}
+
+			var got APIKeyResponse
+
+			err := json.Unmarshal([]byte(rec.Body.String()), &got)
+			assert.NoError(t, err)
+			assert.True(t, strings.HasPrefix(got.Key, "ktx_"))
+
+			// only hash of the key is stored
+			hash := dbmock.Calls[0].Arguments.String(3)
+			assert.Equal(t, hashAPIKey(got.Key), hash)
+			assert.NotEqual(t, got.Key, hash)
+		})
+	}
+}
+
+func TestAdminRevokeAPIKey(t *testing.T) {
+	type TC struct {
+		id         string
+		mockRevoke *MockSetting
+		wantCode   int
+	}
+
+	tcs := []TC{
+		{
+			id: "1",
+			mockRevoke: &MockSetting{
+				Args:    []interface{}{mock.Anything, 1},
+				Returns: []interface{}{nil},
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			id:         "abc",
+			mockRevoke: nil,
+			wantCode:   http.StatusBadRequest,
+		},
+		{
+			id: "2",
+			mockRevoke: &MockSetting{
+				Args:    []interface{}{mock.Anything, 2},
+				Returns: []interface{}{database.ErrNotFound},
+			},
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(APIKeyDBMock)
+
+			if tc.mockRevoke != nil {
+				dbmock.On("RevokeAPIKey", tc.mockRevoke.Args...).Return(tc.mockRevoke.Returns...)
+			}
+
+			h := NewAPIKeyHandler(validator.New(), dbmock)
+
+			req := httptest.NewRequest(http.MethodDelete, "/admin/api-keys/"+tc.id, nil)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tc.id)
+
+			assert.NoError(t, h.RevokeAPIKey(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	type TC struct {
+		key      string
+		mockFind *MockSetting
+		scope    string
+		wantCode int
+	}
+
+	tcs := []TC{
+		{
+			key: "ktx_valid",
+			mockFind: &MockSetting{
+				Args:    []interface{}{mock.Anything, hashAPIKey("ktx_valid")},
+				Returns: []interface{}{database.APIKey{ID: 1, Scopes: []string{ScopeCalculate}}, nil},
+			},
+			scope:    ScopeCalculate,
+			wantCode: http.StatusOK,
+		},
+		{
+			key: "ktx_valid",
+			mockFind: &MockSetting{
+				Args:    []interface{}{mock.Anything, hashAPIKey("ktx_valid")},
+				Returns: []interface{}{database.APIKey{ID: 1, Scopes: []string{ScopeCalculate}}, nil},
+			},
+			scope:    ScopeUploadCSV,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			key:      "",
+			mockFind: nil,
+			scope:    ScopeCalculate,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			key: "ktx_revoked",
+			mockFind: &MockSetting{
+				Args:    []interface{}{mock.Anything, hashAPIKey("ktx_revoked")},
+				Returns: []interface{}{database.APIKey{}, database.ErrNotFound},
+			},
+			scope:    ScopeCalculate,
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			key: "ktx_valid",
+			mockFind: &MockSetting{
+				Args:    []interface{}{mock.Anything, hashAPIKey("ktx_valid")},
+				Returns: []interface{}{database.APIKey{}, errors.New("an error")},
+			},
+			scope:    ScopeCalculate,
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(APIKeyDBMock)
+
+			if tc.mockFind != nil {
+				dbmock.On("FindActiveAPIKeyByHash", tc.mockFind.Args...).Return(tc.mockFind.Returns...)
+			}
+
+			e := echo.New()
+			e.Use(APIKeyAuth(dbmock))
+			e.POST("/tax/calculations", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, RequireScope(tc.scope))
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations", nil)
+			if tc.key != "" {
+				req.Header.Set("X-Api-Key", tc.key)
+			}
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/AnnaCarter465/assessment-tax/pkg/secretbox"
+	"github.com/AnnaCarter465/assessment-tax/pkg/totp"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	RoleViewer     = "viewer"
+	RoleEditor     = "editor"
+	RoleSuperadmin = "superadmin"
+)
+
+// roleLevels orders roles, a role can do everything lower roles can
+var roleLevels = map[string]int{
+	RoleViewer:     1,
+	RoleEditor:     2,
+	RoleSuperadmin: 3,
+}
+
+const (
+	tokenIssuer           = "assessment-tax"
+	adminClaimsContextKey = "adminClaims"
+)
+
+type LoginRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+	// Code is totp code, required for users who enabled two-factor authentication
+	Code string `json:"code"`
+}
+
+type LoginResponse struct {
+	AccessToken string `json:"accessToken"`
+	TokenType   string `json:"tokenType"`
+	ExpiresIn   int    `json:"expiresIn"`
+}
+
+type AdminClaims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+type AuthConfig struct {
+	Username string
+	Password string
+	// Secret signs tokens with HS256, empty secret disables bearer tokens
+	Secret   []byte
+	TokenTTL time.Duration
+	// AllowBasic keeps basic auth working alongside bearer tokens
+	AllowBasic bool
+}
+
+type AdminUserReader interface {
+	FindAdminUserByUsername(ctx context.Context, username string) (database.AdminUser, error)
+}
+
+type SessionCreator interface {
+	CreateAdminSession(ctx context.Context, id string, username string, role string, expiresAt time.Time) (database.AdminSession, error)
+}
+
+type SessionReader interface {
+	FindAdminSession(ctx context.Context, id string) (database.AdminSession, error)
+}
+
+type AuthHandler struct {
+	vl       *validator.Validate
+	conf     AuthConfig
+	users    AdminUserReader
+	box      *secretbox.Box
+	sessions SessionCreator
+}
+
+func NewAuthHandler(vl *validator.Validate, conf AuthConfig) *AuthHandler {
+	return &AuthHandler{vl: vl, conf: conf}
+}
+
+// SetUsers sets admin users who can login besides the admin from env variables
+func (h *AuthHandler) SetUsers(users AdminUserReader) *AuthHandler {
+	h.users = users
+	return h
+}
+
+// SetTOTP sets box which decrypts totp secrets of users
+func (h *AuthHandler) SetTOTP(box *secretbox.Box) *AuthHandler {
+	h.box = box
+	return h
+}
+
+// SetSessions records issued tokens as sessions, so they can be revoked before they expire
+func (h *AuthHandler) SetSessions(sessions SessionCreator) *AuthHandler {
+	h.sessions = sessions
+	return h
+}
+
+// dummyPasswordHash is compared when user doesn't exist, so response time doesn't tell which usernames exist
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+// authenticate returns role and totp secret of user, admin from env variables is superadmin without totp.
+// Role is empty when credentials are invalid.
+func (h *AuthHandler) authenticate(ctx context.Context, username string, password string) (string, string, error) {
+	if h.conf.validCredentials(username, password) {
+		return RoleSuperadmin, "", nil
+	}
+
+	if h.users == nil {
+		return "", "", nil
+	}
+
+	u, err := h.users.FindAdminUserByUsername(ctx, username)
+	if errors.Is(err, database.ErrNotFound) {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return "", "", nil
+	}
+
+	if err != nil {
+		return "", "", err
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) != nil {
+		return "", "", nil
+	}
+
+	if !u.TOTPEnabled {
+		return u.Role, "", nil
+	}
+
+	if h.box == nil {
+		return "", "", errors.New("totp is enabled but no encryption key is configured")
+	}
+
+	secret, err := h.box.Open(u.TOTPSecret)
+	if err != nil {
+		return "", "", err
+	}
+
+	return u.Role, string(secret), nil
+}
+
+func (conf AuthConfig) validCredentials(username string, password string) bool {
+	// compare both to not leak which one is wrong by timing
+	u := subtle.ConstantTimeCompare([]byte(username), []byte(conf.Username))
+	p := subtle.ConstantTimeCompare([]byte(password), []byte(conf.Password))
+
+	return u&p == 1 && conf.Username != ""
+}
+
+func (h *AuthHandler) Login(c echo.Context) error {
+	var req LoginRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	role, totpSecret, err := h.authenticate(c.Request().Context(), req.Username, req.Password)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to authenticate admin user", "error", err)
+		return respondQueryError(c)
+	}
+
+	if role == "" {
+		return respondError(c, http.StatusUnauthorized, errcode.InvalidCredentials)
+	}
+
+	if totpSecret != "" {
+		if req.Code == "" {
+			return respondError(c, http.StatusUnauthorized, errcode.TOTPRequired)
+		}
+
+		if !totp.Validate(totpSecret, req.Code, time.Now()) {
+			return respondError(c, http.StatusUnauthorized, errcode.TOTPInvalid)
+		}
+	}
+
+	now := time.Now()
+
+	claims := AdminClaims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   req.Username,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(h.conf.TokenTTL)),
+		},
+	}
+
+	if h.sessions != nil {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			slog.ErrorContext(c.Request().Context(), "failed to generate session id", "error", err)
+			return respondError(c, http.StatusInternalServerError, errcode.Internal)
+		}
+
+		session, err := h.sessions.CreateAdminSession(c.Request().Context(), hex.EncodeToString(b), req.Username, role, claims.ExpiresAt.Time)
+		if err != nil {
+			slog.ErrorContext(c.Request().Context(), "failed to create admin session", "error", err)
+			return respondQueryError(c)
+		}
+
+		claims.ID = session.ID
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(h.conf.Secret)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to sign token", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.JSON(http.StatusOK, LoginResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(h.conf.TokenTTL.Seconds()),
+	})
+}
+
+func (conf AuthConfig) parseToken(token string) (*AdminClaims, error) {
+	var claims AdminClaims
+
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return conf.Secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+func CurrentAdmin(c echo.Context) (*AdminClaims, bool) {
+	claims, ok := c.Get(adminClaimsContextKey).(*AdminClaims)
+	return claims, ok
+}
+
+// RequireRole rejects admins with role lower than role, it must be used after AdminAuth
+func RequireRole(role string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			claims, ok := CurrentAdmin(c)
+			if !ok || roleLevels[claims.Role] < roleLevels[role] {
+				return respondError(c, http.StatusForbidden, errcode.Forbidden)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// AdminAuth accepts bearer tokens issued by Login, and basic auth when it's allowed.
+// When sessions is not nil, tokens must belong to a session which isn't revoked.
+func AdminAuth(conf AuthConfig, sessions SessionReader) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			auth := c.Request().Header.Get(echo.HeaderAuthorization)
+
+			if token, ok := strings.CutPrefix(auth, "Bearer "); ok && len(conf.Secret) > 0 {
+				claims, err := conf.parseToken(token)
+				if err != nil || roleLevels[claims.Role] == 0 {
+					c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+					return respondError(c, http.StatusUnauthorized, errcode.Unauthorized)
+				}
+
+				if sessions != nil {
+					session, err := sessions.FindAdminSession(c.Request().Context(), claims.ID)
+					if err != nil && !errors.Is(err, database.ErrNotFound) {
+						slog.ErrorContext(c.Request().Context(), "failed to find admin session", "error", err)
+						return respondQueryError(c)
+					}
+
+					if err != nil || session.RevokedAt != nil {
+						c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+						return respondError(c, http.StatusUnauthorized, errcode.Unauthorized)
+					}
+				}
+
+				c.Set(adminClaimsContextKey, claims)
+
+				return next(c)
+			}
+
+			if conf.AllowBasic {
+				if username, password, ok := c.Request().BasicAuth(); ok && conf.validCredentials(username, password) {
+					c.Set(adminClaimsContextKey, &AdminClaims{
+						Role:             RoleSuperadmin,
+						RegisteredClaims: jwt.RegisteredClaims{Subject: username},
+					})
+
+					return next(c)
+				}
+
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="Restricted"`)
+			} else {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+			}
+
+			return respondError(c, http.StatusUnauthorized, errcode.Unauthorized)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+var testAuthConfig = AuthConfig{
+	Username: "adminTax",
+	Password: "admin!",
+	Secret:   []byte("0123456789abcdef0123456789abcdef"),
+	TokenTTL: 15 * time.Minute,
+}
+
+func signTestToken(t *testing.T, secret []byte, role string, expiresAt time.Time) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, AdminClaims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   "adminTax",
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}).SignedString(secret)
+	assert.NoError(t, err)
+
+	return token
+}
+
+func TestAdminLogin(t *testing.T) {
+	type TC struct {
+		reqbody  map[string]interface{}
+		wantCode int
+	}
+
+	tcs := []TC{
+		{
+			reqbody:  map[string]interface{}{"username": "adminTax", "password": "admin!"},
+			wantCode: http.StatusOK,
+		},
+		{
+			reqbody:  map[string]interface{}{"username": "adminTax", "password": "wrong"},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			reqbody:  map[string]interface{}{"username": "adminTax"},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			h := NewAuthHandler(validator.New(), testAuthConfig)
+
+			val, _ := json.Marshal(tc.reqbody)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/login", strings.NewReader(string(val)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, h.Login(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			var got LoginResponse
+
+			err := json.Unmarshal([]byte(rec.Body.String()), &got)
+			assert.NoError(t, err)
+			assert.Equal(t, 900, got.ExpiresIn)
+
+			claims, err := testAuthConfig.parseToken(got.AccessToken)
+			assert.NoError(t, err)
+			assert.Equal(t, RoleSuperadmin, claims.Role)
+		})
+	}
+}
+
+func TestAdminAuth(t *testing.T) {
+	type TC struct {
+		allowBasic    bool
+		authorization string
+		wantCode      int
+	}
+
+	basic := "Basic YWRtaW5UYXg6YWRtaW4h" // adminTax:admin!
+
+	tcs := []TC{
+		{
+			authorization: "Bearer " + signTestToken(t, testAuthConfig.Secret, RoleSuperadmin, time.Now().Add(time.Minute)),
+			wantCode:      http.StatusOK,
+		},
+		{
+			authorization: "Bearer " + signTestToken(t, testAuthConfig.Secret, RoleSuperadmin, time.Now().Add(-time.Minute)),
+			wantCode:      http.StatusUnauthorized,
+		},
+		{
+			authorization: "Bearer " + signTestToken(t, []byte("another secret"), RoleSuperadmin, time.Now().Add(time.Minute)),
+			wantCode:      http.StatusUnauthorized,
+		},
+		{
+			authorization: "Bearer " + signTestToken(t, testAuthConfig.Secret, "user", time.Now().Add(time.Minute)),
+			wantCode:      http.StatusUnauthorized,
+		},
+		{
+			allowBasic:    false,
+			authorization: basic,
+			wantCode:      http.StatusUnauthorized,
+		},
+		{
+			allowBasic:    true,
+			authorization: basic,
+			wantCode:      http.StatusOK,
+		},
+		{
+			allowBasic: true,
+			wantCode:   http.StatusUnauthorized,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			conf := testAuthConfig
+			conf.AllowBasic = tc.allowBasic
+
+			e := echo.New()
+			e.GET("/admin/webhooks", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, AdminAuth(conf, nil))
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/webhooks", nil)
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
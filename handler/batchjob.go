@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+)
+
+// BatchJobResponse is the status of a csv upload calculated by a worker, taxes are returned once it's done
+type BatchJobResponse struct {
+	ID        string       `json:"id"`
+	Status    string       `json:"status"`
+	ErrorCode errcode.Code `json:"errorCode,omitempty"`
+	Taxes     []TaxCSV     `json:"taxes,omitempty"`
+	CreatedAt time.Time    `json:"createdAt"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
+
+type BatchJobIDB interface {
+	CreateBatchJob(ctx context.Context, j database.BatchJob) (database.BatchJob, error)
+	FindBatchJob(ctx context.Context, id string) (database.BatchJob, error)
+	UpdateBatchJob(ctx context.Context, id string, status string, result []byte, errorCode string) (database.BatchJob, error)
+	RequeueBatchJobs(ctx context.Context) ([]string, error)
+}
+
+// JobPublisher hands ids of queued jobs to workers
+type JobPublisher interface {
+	Publish(ctx context.Context, msg []byte) error
+}
+
+// BatchJobHandler queues csv uploads which are calculated by workers, the API and workers may be separate processes.
+// Uploads are checked when they're queued, so a job only fails for reasons a synchronous upload would fail after
+// parsing, like an unsupported tax year
+type BatchJobHandler struct {
+	db           BatchJobIDB
+	calculations *TaxHandler
+	publisher    JobPublisher
+}
+
+func NewBatchJobHandler(db BatchJobIDB, calculations *TaxHandler, publisher JobPublisher) *BatchJobHandler {
+	return &BatchJobHandler{db, calculations, publisher}
+}
+
+func toBatchJobResponse(j database.BatchJob) (BatchJobResponse, error) {
+	resp := BatchJobResponse{
+		ID:        j.ID,
+		Status:    j.Status,
+		ErrorCode: errcode.Code(j.ErrorCode),
+		CreatedAt: j.CreatedAt,
+		UpdatedAt: j.UpdatedAt,
+	}
+
+	if len(j.Result) > 0 {
+		if err := json.Unmarshal(j.Result, &resp.Taxes); err != nil {
+			return BatchJobResponse{}, err
+		}
+	}
+
+	return resp, nil
+}
+
+// CreateBatchJob queues csv of the request, it takes the query params and body of POST /tax/calculations/upload-csv
+func (h *BatchJobHandler) CreateBatchJob(c echo.Context) error {
+	upload, err := h.calculations.readCSVUpload(c)
+	if err != nil {
+		return respondCalculationError(c, err)
+	}
+
+	ctx := c.Request().Context()
+
+	j, err := h.db.CreateBatchJob(ctx, database.BatchJob{
+		TaxYear:       upload.taxYear,
+		EffectiveDate: upload.effectiveDate,
+		APIKeyID:      upload.apiKeyID,
+		TenantID:      upload.tenantID,
+		UserID:        upload.userID,
+		Payload:       upload.body,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create batch job", "error", err)
+		return respondQueryError(c)
+	}
+
+	if err := h.publisher.Publish(ctx, []byte(j.ID)); err != nil {
+		slog.ErrorContext(ctx, "failed to publish batch job", "id", j.ID, "error", err)
+
+		// nothing will pick the job up, so it isn't left queued
+		if _, err := h.db.UpdateBatchJob(ctx, j.ID, database.BatchJobFailed, nil, string(errcode.Internal)); err != nil {
+			slog.ErrorContext(ctx, "failed to update batch job", "id", j.ID, "error", err)
+		}
+
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	recordCSVRows(c, len(upload.datasets))
+
+	resp, err := toBatchJobResponse(j)
+	if err != nil {
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.JSON(http.StatusAccepted, resp)
+}
+
+// GetBatchJob returns job of the requesting api key or taxpayer, jobs of others aren't found
+func (h *BatchJobHandler) GetBatchJob(c echo.Context) error {
+	precision, ok := getPrecision(c, h.calculations.precision)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	j, err := h.db.FindBatchJob(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.BatchJobNotFound)
+	}
+
+	if err != nil {
+		return respondQueryError(c)
+	}
+
+	if !sameOwner(j.APIKeyID, currentAPIKeyID(c)) || !sameOwner(j.UserID, currentUserID(c)) {
+		return respondError(c, http.StatusNotFound, errcode.BatchJobNotFound)
+	}
+
+	resp, err := toBatchJobResponse(j)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to decode batch job result", "id", j.ID, "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	taxes := &TaxCSVResponse{Taxes: resp.Taxes}
+	taxes.round(precision)
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+func sameOwner(a *int, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+// ProcessBatchJob calculates the job whose id is msg, it's the handler of the queue consumed by workers.
+// A job fails with the code of its error. Messages are delivered again until it returns nil, so it returns an error
+// only when the result of the job isn't recorded. Missing, done and failed jobs are skipped, running jobs are
+// calculated again since their consumer stopped before finishing them
+func (h *BatchJobHandler) ProcessBatchJob(ctx context.Context, msg []byte) error {
+	id := string(msg)
+
+	j, err := h.db.FindBatchJob(ctx, id)
+	if errors.Is(err, database.ErrNotFound) {
+		slog.WarnContext(ctx, "batch job of message doesn't exist", "id", id)
+		return nil
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find batch job", "id", id, "error", err)
+		return err
+	}
+
+	if j.Status != database.BatchJobQueued && j.Status != database.BatchJobRunning {
+		return nil
+	}
+
+	if _, err := h.db.UpdateBatchJob(ctx, id, database.BatchJobRunning, nil, ""); err != nil {
+		slog.ErrorContext(ctx, "failed to update batch job", "id", id, "error", err)
+		return err
+	}
+
+	result, err := h.calculate(ctx, j)
+	if err != nil {
+		code := errcode.Internal
+
+		var calcErr *CalculationError
+		if errors.As(err, &calcErr) {
+			code = calcErr.Code
+		} else {
+			slog.ErrorContext(ctx, "failed to calculate batch job", "id", id, "error", err)
+		}
+
+		if _, err := h.db.UpdateBatchJob(ctx, id, database.BatchJobFailed, nil, string(code)); err != nil {
+			slog.ErrorContext(ctx, "failed to update batch job", "id", id, "error", err)
+			return err
+		}
+
+		return nil
+	}
+
+	if _, err := h.db.UpdateBatchJob(ctx, id, database.BatchJobDone, result, ""); err != nil {
+		slog.ErrorContext(ctx, "failed to update batch job", "id", id, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+// RequeueBatchJobs publishes jobs left queued or running again, a local queue loses them when the process stops.
+// Jobs which can't be published stay queued for the next start
+func (h *BatchJobHandler) RequeueBatchJobs(ctx context.Context) error {
+	ids, err := h.db.RequeueBatchJobs(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if err := h.publisher.Publish(ctx, []byte(id)); err != nil {
+			return err
+		}
+	}
+
+	if len(ids) > 0 {
+		slog.InfoContext(ctx, "requeued batch jobs", "jobs", len(ids))
+	}
+
+	return nil
+}
+
+// calculate returns taxes of job encoded as json, amounts are kept raw and rounded when the job is read
+func (h *BatchJobHandler) calculate(ctx context.Context, j database.BatchJob) ([]byte, error) {
+	datasets, err := parseCSVUpload(j.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	taxes, _, err := h.calculations.calculateCSV(ctx, csvUpload{
+		taxYear:       j.TaxYear,
+		effectiveDate: j.EffectiveDate,
+		tenantID:      j.TenantID,
+		apiKeyID:      j.APIKeyID,
+		userID:        j.UserID,
+		body:          j.Payload,
+		datasets:      datasets,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(taxes)
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type publisherStub struct {
+	msgs [][]byte
+	err  error
+}
+
+func (p *publisherStub) Publish(ctx context.Context, msg []byte) error {
+	p.msgs = append(p.msgs, msg)
+	return p.err
+}
+
+func newBatchJobTest(publisher JobPublisher) (*BatchJobHandler, *database.Memory) {
+	mockObj := new(UserDBMock)
+	mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+		{AllowanceType: "personal", Amount: 60_000},
+	}, nil)
+	mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+		{AllowanceType: "donation", MaxAmount: 100_000},
+	}, nil)
+
+	store := database.NewMemory()
+
+	return NewBatchJobHandler(store, NewTaxHandler(validator.New(), mockObj), publisher), store
+}
+
+func createBatchJob(h *BatchJobHandler, body string, userID *int) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/tax/calculations/jobs", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/csv")
+	rec := httptest.NewRecorder()
+
+	c := echo.New().NewContext(req, rec)
+	if userID != nil {
+		c.Set(accountClaimsContextKey, *userID)
+	}
+
+	h.CreateBatchJob(c)
+
+	return rec
+}
+
+func getBatchJob(h *BatchJobHandler, id string, userID *int) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/tax/calculations/jobs/"+id+"?precision=baht", nil)
+	rec := httptest.NewRecorder()
+
+	c := echo.New().NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(id)
+
+	if userID != nil {
+		c.Set(accountClaimsContextKey, *userID)
+	}
+
+	h.GetBatchJob(c)
+
+	return rec
+}
+
+func TestBatchJob(t *testing.T) {
+	publisher := &publisherStub{}
+	h, _ := newBatchJobTest(publisher)
+
+	owner, other := 1, 2
+
+	rec := createBatchJob(h, "totalIncome,wht,donation\n500000,0,0\n600000,40000,20000\n", &owner)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	var created BatchJobResponse
+
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, database.BatchJobQueued, created.Status)
+	assert.Equal(t, [][]byte{[]byte(created.ID)}, publisher.msgs)
+
+	assert.NoError(t, h.ProcessBatchJob(context.Background(), []byte(created.ID)))
+
+	rec = getBatchJob(h, created.ID, &owner)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var got BatchJobResponse
+
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, database.BatchJobDone, got.Status)
+	assert.Equal(t, []TaxCSV{{TotalIncome: 500_000, Tax: 29_000}, {TotalIncome: 600_000, Tax: 10_000}}, got.Taxes)
+
+	// a job delivered again isn't calculated again
+	assert.NoError(t, h.ProcessBatchJob(context.Background(), []byte(created.ID)))
+
+	rec = getBatchJob(h, created.ID, &other)
+	assert.Equal(t, http.StatusNotFound, rec.Code, "jobs of other taxpayers aren't found")
+
+	rec = getBatchJob(h, "unknown", &owner)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestBatchJobRejected(t *testing.T) {
+	h, _ := newBatchJobTest(&publisherStub{})
+
+	rec := createBatchJob(h, "totalIncome,wht,donation\nabc,0,0\n", nil)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var errresp ResponseMsg
+
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errresp))
+	assert.Equal(t, errcode.CSVInvalidIncome, errresp.ErrorCode, "csv is checked before it's queued")
+}
+
+func TestBatchJobPublishFailed(t *testing.T) {
+	publisher := &publisherStub{err: errors.New("queue is down")}
+	h, store := newBatchJobTest(publisher)
+
+	rec := createBatchJob(h, "totalIncome,wht,donation\n500000,0,0\n", nil)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	j, err := store.FindBatchJob(context.Background(), string(publisher.msgs[0]))
+	assert.NoError(t, err)
+	assert.Equal(t, database.BatchJobFailed, j.Status, "a job nothing will pick up isn't left queued")
+}
+
+func TestRequeueBatchJobs(t *testing.T) {
+	publisher := &publisherStub{}
+	h, store := newBatchJobTest(publisher)
+
+	queued, err := store.CreateBatchJob(context.Background(), database.BatchJob{TaxYear: 2024})
+	assert.NoError(t, err)
+
+	done, err := store.CreateBatchJob(context.Background(), database.BatchJob{TaxYear: 2024})
+	assert.NoError(t, err)
+
+	_, err = store.UpdateBatchJob(context.Background(), done.ID, database.BatchJobDone, []byte("[]"), "")
+	assert.NoError(t, err)
+
+	assert.NoError(t, h.RequeueBatchJobs(context.Background()))
+	assert.Equal(t, [][]byte{[]byte(queued.ID)}, publisher.msgs, "jobs lost by the local queue are published again")
+}
+
+func TestProcessBatchJobDeliveredAgain(t *testing.T) {
+	h, store := newBatchJobTest(&publisherStub{})
+
+	rec := createBatchJob(h, "totalIncome,wht,donation\n500000,0,0\n", nil)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	var created BatchJobResponse
+
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+
+	_, err := store.UpdateBatchJob(context.Background(), created.ID, database.BatchJobRunning, nil, "")
+	assert.NoError(t, err)
+
+	assert.NoError(t, h.ProcessBatchJob(context.Background(), []byte(created.ID)))
+
+	j, err := store.FindBatchJob(context.Background(), created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, database.BatchJobDone, j.Status, "a job its consumer stopped running is calculated again")
+
+	rejected, err := store.CreateBatchJob(context.Background(), database.BatchJob{TaxYear: 2024})
+	assert.NoError(t, err)
+
+	assert.NoError(t, h.ProcessBatchJob(context.Background(), []byte(rejected.ID)), "a failed job isn't delivered again")
+
+	j, err = store.FindBatchJob(context.Background(), rejected.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, database.BatchJobFailed, j.Status)
+	assert.Equal(t, string(errcode.CSVEmpty), j.ErrorCode)
+
+	assert.NoError(t, h.ProcessBatchJob(context.Background(), []byte("unknown")), "messages of purged jobs are dropped")
+}
@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// CalculationDraftRequest has inputs known so far, the draft can be finalized once totalIncome is known
+type CalculationDraftRequest struct {
+	Name        string      `json:"name" validate:"max=100"`
+	TotalIncome *float64    `json:"totalIncome" validate:"omitempty,gte=0"`
+	Wht         *float64    `json:"wht" validate:"omitempty,gte=0"`
+	Allowances  []Allowance `json:"allowances" validate:"dive"`
+}
+
+type CalculationDraftResponse struct {
+	ID          int         `json:"id"`
+	Name        string      `json:"name"`
+	TotalIncome *float64    `json:"totalIncome"`
+	Wht         *float64    `json:"wht"`
+	Allowances  []Allowance `json:"allowances"`
+	Tax         *float64    `json:"tax,omitempty"`
+	TaxRefund   *float64    `json:"taxRefund,omitempty"`
+	UUID        string      `json:"uuid"`
+	CreatedAt   time.Time   `json:"createdAt"`
+	UpdatedAt   time.Time   `json:"updatedAt"`
+	FinalizedAt *time.Time  `json:"finalizedAt,omitempty"`
+}
+
+type CalculationDraftIDB interface {
+	FindCalculationDrafts(ctx context.Context, userID int) ([]database.CalculationDraft, error)
+	FindCalculationDraft(ctx context.Context, userID int, id int) (database.CalculationDraft, error)
+	CreateCalculationDraft(ctx context.Context, d database.CalculationDraft) (database.CalculationDraft, error)
+	UpdateCalculationDraft(ctx context.Context, d database.CalculationDraft) (database.CalculationDraft, error)
+	FinalizeCalculationDraft(ctx context.Context, userID int, id int, updatedAt time.Time, tax float64, taxRefund float64) (database.CalculationDraft, error)
+}
+
+// CalculationDraftHandler keeps drafts of the signed in taxpayer, routes must be used after RequireAccount.
+// Drafts are finalized by calculations, so they're calculated like POST /tax/calculations
+type CalculationDraftHandler struct {
+	vl           *validator.Validate
+	db           CalculationDraftIDB
+	calculations *TaxHandler
+}
+
+func NewCalculationDraftHandler(vl *validator.Validate, db CalculationDraftIDB, calculations *TaxHandler) *CalculationDraftHandler {
+	return &CalculationDraftHandler{vl, db, calculations}
+}
+
+func toCalculationDraftResponse(d database.CalculationDraft) CalculationDraftResponse {
+	return CalculationDraftResponse{
+		ID:          d.ID,
+		Name:        d.Name,
+		TotalIncome: d.TotalIncome,
+		Wht:         d.Wht,
+		Allowances:  sortedAllowances(d.Allowances),
+		Tax:         d.Tax,
+		TaxRefund:   d.TaxRefund,
+		UUID:        d.UUID,
+		CreatedAt:   d.CreatedAt,
+		UpdatedAt:   d.UpdatedAt,
+		FinalizedAt: d.FinalizedAt,
+	}
+}
+
+// bindCalculationDraft returns draft of the request, an allowance type listed twice is claimed with the sum of its amounts
+func (h *CalculationDraftHandler) bindCalculationDraft(c echo.Context, userID int) (database.CalculationDraft, error) {
+	var req CalculationDraftRequest
+
+	if err := c.Bind(&req); err != nil {
+		return database.CalculationDraft{}, err
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return database.CalculationDraft{}, err
+	}
+
+	allowances := map[string]float64{}
+	for _, a := range req.Allowances {
+		allowances[a.AllowanceType] += a.Amount
+	}
+
+	return database.CalculationDraft{
+		UserID:      userID,
+		Name:        req.Name,
+		TotalIncome: req.TotalIncome,
+		Wht:         req.Wht,
+		Allowances:  allowances,
+	}, nil
+}
+
+func (h *CalculationDraftHandler) GetCalculationDrafts(c echo.Context) error {
+	userID := currentUserID(c)
+	if userID == nil {
+		return respondError(c, http.StatusUnauthorized, errcode.Unauthorized)
+	}
+
+	drafts, err := h.db.FindCalculationDrafts(c.Request().Context(), *userID)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find calculation drafts", "user_id", *userID, "error", err)
+		return respondQueryError(c)
+	}
+
+	results := []CalculationDraftResponse{}
+
+	for _, d := range drafts {
+		results = append(results, toCalculationDraftResponse(d))
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+func (h *CalculationDraftHandler) CreateCalculationDraft(c echo.Context) error {
+	userID := currentUserID(c)
+	if userID == nil {
+		return respondError(c, http.StatusUnauthorized, errcode.Unauthorized)
+	}
+
+	d, err := h.bindCalculationDraft(c, *userID)
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	created, err := h.db.CreateCalculationDraft(c.Request().Context(), d)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to create calculation draft", "user_id", *userID, "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.JSON(http.StatusCreated, toCalculationDraftResponse(created))
+}
+
+// UpdateCalculationDraft replaces inputs of a pending draft, finalized drafts can't be changed
+func (h *CalculationDraftHandler) UpdateCalculationDraft(c echo.Context) error {
+	userID := currentUserID(c)
+	if userID == nil {
+		return respondError(c, http.StatusUnauthorized, errcode.Unauthorized)
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.CalculationDraftInvalidID)
+	}
+
+	d, err := h.bindCalculationDraft(c, *userID)
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	d.ID = id
+
+	updated, err := h.db.UpdateCalculationDraft(c.Request().Context(), d)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.CalculationDraftNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to update calculation draft", "user_id", *userID, "id", id, "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.JSON(http.StatusOK, toCalculationDraftResponse(updated))
+}
+
+// FinalizeCalculationDraft calculates tax of a complete draft and keeps it with the draft. Query params taxYear and date
+// are the ones of POST /tax/calculations, and the calculation is recorded to history like other calculations
+func (h *CalculationDraftHandler) FinalizeCalculationDraft(c echo.Context) error {
+	userID := currentUserID(c)
+	if userID == nil {
+		return respondError(c, http.StatusUnauthorized, errcode.Unauthorized)
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.CalculationDraftInvalidID)
+	}
+
+	taxYear, ok := getTaxYear(c)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.TaxYearUnsupported)
+	}
+
+	effectiveDate, ok := getEffectiveDate(c, h.calculations.now())
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidDate)
+	}
+
+	d, err := h.db.FindCalculationDraft(c.Request().Context(), *userID, id)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.CalculationDraftNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find calculation draft", "user_id", *userID, "id", id, "error", err)
+		return respondQueryError(c)
+	}
+
+	if d.FinalizedAt != nil {
+		return respondError(c, http.StatusNotFound, errcode.CalculationDraftNotFound)
+	}
+
+	if d.TotalIncome == nil {
+		return respondError(c, http.StatusBadRequest, errcode.CalculationDraftIncomplete)
+	}
+
+	req := TaxRequest{TotalIncome: *d.TotalIncome, Allowances: sortedAllowances(d.Allowances)}
+	if d.Wht != nil {
+		req.Wht = *d.Wht
+	}
+
+	resp, degraded, err := h.calculations.Calculate(c.Request().Context(), Calculation{
+		TaxRequest:    req,
+		TaxYear:       taxYear,
+		EffectiveDate: effectiveDate,
+		Language:      preferredLanguage(c.Request().Header.Get("Accept-Language"), defaultLanguage),
+		APIKeyID:      currentAPIKeyID(c),
+		TenantID:      currentTenantID(c),
+		UserID:        userID,
+	})
+
+	if degraded {
+		c.Response().Header().Set(degradedHeader, "true")
+	}
+
+	if err != nil {
+		var calcErr *CalculationError
+		if errors.As(err, &calcErr) {
+			return respondError(c, calcErr.Status, calcErr.Code)
+		}
+
+		return respondQueryError(c)
+	}
+
+	finalized, err := h.db.FinalizeCalculationDraft(c.Request().Context(), *userID, id, d.UpdatedAt, resp.Tax, resp.TaxRefund)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.CalculationDraftNotFound)
+	}
+
+	if errors.Is(err, database.ErrConflict) {
+		return respondError(c, http.StatusConflict, errcode.CalculationDraftChanged)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to finalize calculation draft", "user_id", *userID, "id", id, "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.JSON(http.StatusOK, toCalculationDraftResponse(finalized))
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type CalculationDraftDBMock struct {
+	mock.Mock
+}
+
+func (o *CalculationDraftDBMock) FindCalculationDrafts(ctx context.Context, userID int) ([]database.CalculationDraft, error) {
+	args := o.Called(ctx, userID)
+	return args.Get(0).([]database.CalculationDraft), args.Error(1)
+}
+
+func (o *CalculationDraftDBMock) FindCalculationDraft(ctx context.Context, userID int, id int) (database.CalculationDraft, error) {
+	args := o.Called(ctx, userID, id)
+	return args.Get(0).(database.CalculationDraft), args.Error(1)
+}
+
+func (o *CalculationDraftDBMock) CreateCalculationDraft(ctx context.Context, d database.CalculationDraft) (database.CalculationDraft, error) {
+	args := o.Called(ctx, d)
+	return args.Get(0).(database.CalculationDraft), args.Error(1)
+}
+
+func (o *CalculationDraftDBMock) UpdateCalculationDraft(ctx context.Context, d database.CalculationDraft) (database.CalculationDraft, error) {
+	args := o.Called(ctx, d)
+	return args.Get(0).(database.CalculationDraft), args.Error(1)
+}
+
+func (o *CalculationDraftDBMock) FinalizeCalculationDraft(ctx context.Context, userID int, id int, updatedAt time.Time, tax float64, taxRefund float64) (database.CalculationDraft, error) {
+	args := o.Called(ctx, userID, id, updatedAt, tax, taxRefund)
+	return args.Get(0).(database.CalculationDraft), args.Error(1)
+}
+
+func TestCalculationDraftFinalize(t *testing.T) {
+	updatedAt := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	income := 500_000.0
+
+	type TC struct {
+		draft       database.CalculationDraft
+		finalizeErr error
+		wantCode    int
+		wantError   errcode.Code
+	}
+
+	tcs := []TC{
+		{
+			draft:    database.CalculationDraft{ID: 1, UserID: 3, TotalIncome: &income, Allowances: map[string]float64{"donation": 200_000}, UpdatedAt: updatedAt},
+			wantCode: http.StatusOK,
+		},
+		{
+			draft:     database.CalculationDraft{ID: 1, UserID: 3, UpdatedAt: updatedAt},
+			wantCode:  http.StatusBadRequest,
+			wantError: errcode.CalculationDraftIncomplete,
+		},
+		{
+			draft:     database.CalculationDraft{ID: 1, UserID: 3, TotalIncome: &income, UpdatedAt: updatedAt, FinalizedAt: &updatedAt},
+			wantCode:  http.StatusNotFound,
+			wantError: errcode.CalculationDraftNotFound,
+		},
+		{
+			draft:       database.CalculationDraft{ID: 1, UserID: 3, TotalIncome: &income, Allowances: map[string]float64{"donation": 200_000}, UpdatedAt: updatedAt},
+			finalizeErr: database.ErrConflict,
+			wantCode:    http.StatusConflict,
+			wantError:   errcode.CalculationDraftChanged,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			taxes := new(UserDBMock)
+			taxes.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			taxes.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+				{AllowanceType: "donation", MaxAmount: 100_000},
+			}, nil)
+
+			tax := 19_000.0
+			finalized := tc.draft
+			finalized.Tax = &tax
+
+			drafts := new(CalculationDraftDBMock)
+			drafts.On("FindCalculationDraft", mock.Anything, 3, 1).Return(tc.draft, nil)
+			drafts.On("FinalizeCalculationDraft", mock.Anything, 3, 1, updatedAt, tax, 0.0).Return(finalized, tc.finalizeErr)
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/drafts/1/finalize", nil)
+			rec := httptest.NewRecorder()
+
+			c := echo.New().NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+			c.Set(accountClaimsContextKey, 3)
+
+			h := NewCalculationDraftHandler(validator.New(), drafts, NewTaxHandler(validator.New(), taxes))
+
+			assert.NoError(t, h.FinalizeCalculationDraft(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				var got ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, tc.wantError, got.ErrorCode)
+
+				return
+			}
+
+			var got CalculationDraftResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, &tax, got.Tax)
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+const dateLayout = "2006-01-02"
+
+type CalendarRequest struct {
+	FilingDeadline   string                   `json:"filingDeadline" validate:"required,datetime=2006-01-02"`
+	AllowanceWindows []AllowanceWindowRequest `json:"allowanceWindows" validate:"dive"`
+}
+
+type AllowanceWindowRequest struct {
+	AllowanceType string `json:"allowanceType" validate:"required,lowercase"`
+	StartsOn      string `json:"startsOn" validate:"required,datetime=2006-01-02"`
+	EndsOn        string `json:"endsOn" validate:"required,datetime=2006-01-02"`
+}
+
+type CalendarResponse struct {
+	TaxYear          int                      `json:"taxYear"`
+	TaxYearBE        int                      `json:"taxYearBE"`
+	FilingDeadline   string                   `json:"filingDeadline"`
+	AllowanceWindows []AllowanceWindowRequest `json:"allowanceWindows"`
+	UUID             string                   `json:"uuid"`
+	CreatedAt        time.Time                `json:"createdAt"`
+	UpdatedAt        time.Time                `json:"updatedAt"`
+}
+
+type CalendarIDB interface {
+	FindAllTaxCalendars(ctx context.Context) ([]database.TaxCalendar, error)
+	UpsertTaxCalendar(ctx context.Context, cal database.TaxCalendar) (database.TaxCalendar, error)
+	DeleteTaxCalendar(ctx context.Context, taxYear int) error
+}
+
+type CalendarHandler struct {
+	vl *validator.Validate
+	db CalendarIDB
+}
+
+func NewCalendarHandler(vl *validator.Validate, db CalendarIDB) *CalendarHandler {
+	return &CalendarHandler{vl, db}
+}
+
+func toCalendarResponse(cal database.TaxCalendar) CalendarResponse {
+	windows := []AllowanceWindowRequest{}
+
+	for _, w := range cal.AllowanceWindows {
+		windows = append(windows, AllowanceWindowRequest{
+			AllowanceType: w.AllowanceType,
+			StartsOn:      w.StartsOn.Format(dateLayout),
+			EndsOn:        w.EndsOn.Format(dateLayout),
+		})
+	}
+
+	return CalendarResponse{
+		TaxYear:          cal.TaxYear,
+		TaxYearBE:        BuddhistEraYear(cal.TaxYear),
+		FilingDeadline:   cal.FilingDeadline.Format(dateLayout),
+		AllowanceWindows: windows,
+		UUID:             cal.UUID,
+		CreatedAt:        cal.CreatedAt,
+		UpdatedAt:        cal.UpdatedAt,
+	}
+}
+
+// parseTaxYear returns Gregorian tax year of path param, which is in either era
+func parseTaxYear(c echo.Context) (int, bool) {
+	taxYear, err := strconv.Atoi(c.Param("taxYear"))
+	if err != nil {
+		return 0, false
+	}
+
+	taxYear = NormalizeTaxYear(taxYear)
+	if taxYear < 2000 || taxYear > 2999 {
+		return 0, false
+	}
+
+	return taxYear, true
+}
+
+func (h *CalendarHandler) GetCalendars(c echo.Context) error {
+	calendars, err := h.db.FindAllTaxCalendars(c.Request().Context())
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find tax calendars", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	results := []CalendarResponse{}
+
+	for _, cal := range calendars {
+		results = append(results, toCalendarResponse(cal))
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+func (h *CalendarHandler) UpsertCalendar(c echo.Context) error {
+	taxYear, ok := parseTaxYear(c)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidTaxYear)
+	}
+
+	var req CalendarRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	// formats are checked by validator already
+	deadline, _ := time.Parse(dateLayout, req.FilingDeadline)
+
+	cal := database.TaxCalendar{
+		TaxYear:        taxYear,
+		FilingDeadline: deadline,
+	}
+
+	for _, w := range req.AllowanceWindows {
+		startsOn, _ := time.Parse(dateLayout, w.StartsOn)
+		endsOn, _ := time.Parse(dateLayout, w.EndsOn)
+
+		if endsOn.Before(startsOn) {
+			return respondError(c, http.StatusBadRequest, errcode.InvalidWindow)
+		}
+
+		cal.AllowanceWindows = append(cal.AllowanceWindows, database.AllowanceWindow{
+			AllowanceType: w.AllowanceType,
+			StartsOn:      startsOn,
+			EndsOn:        endsOn,
+		})
+	}
+
+	saved, err := h.db.UpsertTaxCalendar(c.Request().Context(), cal)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to upsert tax calendar", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.CalendarUpdateFailed)
+	}
+
+	return c.JSON(http.StatusOK, toCalendarResponse(saved))
+}
+
+func (h *CalendarHandler) DeleteCalendar(c echo.Context) error {
+	taxYear, ok := parseTaxYear(c)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidTaxYear)
+	}
+
+	err := h.db.DeleteTaxCalendar(c.Request().Context(), taxYear)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.CalendarNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to delete tax calendar", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.CalendarDeleteFailed)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
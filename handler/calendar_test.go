@@ -0,0 +1,293 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type CalendarDBMock struct {
+	mock.Mock
+}
+
+func (o *CalendarDBMock) FindAllTaxCalendars(ctx context.Context) ([]database.TaxCalendar, error) {
+	args := o.Called(ctx)
+	return args.Get(0).([]database.TaxCalendar), args.Error(1)
+}
+
+func (o *CalendarDBMock) FindTaxCalendar(ctx context.Context, taxYear int) (database.TaxCalendar, error) {
+	args := o.Called(ctx, taxYear)
+	return args.Get(0).(database.TaxCalendar), args.Error(1)
+}
+
+func (o *CalendarDBMock) FindUpcomingTaxCalendar(ctx context.Context, from time.Time) (database.TaxCalendar, error) {
+	args := o.Called(ctx, from)
+	return args.Get(0).(database.TaxCalendar), args.Error(1)
+}
+
+func (o *CalendarDBMock) UpsertTaxCalendar(ctx context.Context, cal database.TaxCalendar) (database.TaxCalendar, error) {
+	args := o.Called(ctx, cal)
+	return args.Get(0).(database.TaxCalendar), args.Error(1)
+}
+
+func (o *CalendarDBMock) DeleteTaxCalendar(ctx context.Context, taxYear int) error {
+	args := o.Called(ctx, taxYear)
+	return args.Error(0)
+}
+
+func TestAdminUpsertCalendar(t *testing.T) {
+	deadline := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+	startsOn := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	endsOn := time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)
+
+	calendar := database.TaxCalendar{
+		TaxYear:        2024,
+		FilingDeadline: deadline,
+		AllowanceWindows: []database.AllowanceWindow{
+			{AllowanceType: "k-receipt", StartsOn: startsOn, EndsOn: endsOn},
+		},
+	}
+
+	type TC struct {
+		taxYear               string
+		reqbody               map[string]interface{}
+		want                  *CalendarResponse
+		mockUpsertTaxCalendar *MockSetting
+		errresp               *ResponseMsg
+	}
+
+	tcs := []TC{
+		{
+			taxYear: "2024",
+			reqbody: map[string]interface{}{
+				"filingDeadline": "2025-03-31",
+				"allowanceWindows": []map[string]interface{}{
+					{"allowanceType": "k-receipt", "startsOn": "2024-01-01", "endsOn": "2024-02-15"},
+				},
+			},
+			want: &CalendarResponse{
+				TaxYear:        2024,
+				TaxYearBE:      2567,
+				FilingDeadline: "2025-03-31",
+				AllowanceWindows: []AllowanceWindowRequest{
+					{AllowanceType: "k-receipt", StartsOn: "2024-01-01", EndsOn: "2024-02-15"},
+				},
+			},
+			mockUpsertTaxCalendar: &MockSetting{
+				Args:    []interface{}{mock.Anything, calendar},
+				Returns: []interface{}{calendar, nil},
+			},
+			errresp: nil,
+		},
+		// Buddhist era year is the same tax year
+		{
+			taxYear: "2567",
+			reqbody: map[string]interface{}{
+				"filingDeadline": "2025-03-31",
+				"allowanceWindows": []map[string]interface{}{
+					{"allowanceType": "k-receipt", "startsOn": "2024-01-01", "endsOn": "2024-02-15"},
+				},
+			},
+			want: &CalendarResponse{
+				TaxYear:        2024,
+				TaxYearBE:      2567,
+				FilingDeadline: "2025-03-31",
+				AllowanceWindows: []AllowanceWindowRequest{
+					{AllowanceType: "k-receipt", StartsOn: "2024-01-01", EndsOn: "2024-02-15"},
+				},
+			},
+			mockUpsertTaxCalendar: &MockSetting{
+				Args:    []interface{}{mock.Anything, calendar},
+				Returns: []interface{}{calendar, nil},
+			},
+			errresp: nil,
+		},
+		{
+			taxYear: "abc",
+			reqbody: map[string]interface{}{
+				"filingDeadline": "2025-03-31",
+			},
+			want:                  nil,
+			mockUpsertTaxCalendar: nil,
+			errresp: &ResponseMsg{
+				Message:   "Invalid tax year",
+				ErrorCode: errcode.InvalidTaxYear,
+			},
+		},
+		{
+			taxYear: "2024",
+			reqbody: map[string]interface{}{
+				"filingDeadline": "31/03/2025",
+			},
+			want:                  nil,
+			mockUpsertTaxCalendar: nil,
+			errresp: &ResponseMsg{
+				Message:   "Bad request",
+				ErrorCode: errcode.InvalidRequest,
+			},
+		},
+		{
+			taxYear: "2024",
+			reqbody: map[string]interface{}{
+				"filingDeadline": "2025-03-31",
+				"allowanceWindows": []map[string]interface{}{
+					{"allowanceType": "k-receipt", "startsOn": "2024-02-15", "endsOn": "2024-01-01"},
+				},
+			},
+			want:                  nil,
+			mockUpsertTaxCalendar: nil,
+			errresp: &ResponseMsg{
+				Message:   "Invalid allowance window",
+				ErrorCode: errcode.InvalidWindow,
+			},
+		},
+		{
+			taxYear: "2024",
+			reqbody: map[string]interface{}{
+				"filingDeadline": "2025-03-31",
+				"allowanceWindows": []map[string]interface{}{
+					{"allowanceType": "k-receipt", "startsOn": "2024-01-01", "endsOn": "2024-02-15"},
+				},
+			},
+			want: nil,
+			mockUpsertTaxCalendar: &MockSetting{
+				Args:    []interface{}{mock.Anything, calendar},
+				Returns: []interface{}{database.TaxCalendar{}, errors.New("an error")},
+			},
+			errresp: &ResponseMsg{
+				Message:   "Failed to update tax calendar",
+				ErrorCode: errcode.CalendarUpdateFailed,
+			},
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(CalendarDBMock)
+
+			if tc.mockUpsertTaxCalendar != nil {
+				dbmock.On(
+					"UpsertTaxCalendar",
+					tc.mockUpsertTaxCalendar.Args...,
+				).Return(tc.mockUpsertTaxCalendar.Returns...)
+			}
+
+			h := NewCalendarHandler(validator.New(), dbmock)
+
+			val, _ := json.Marshal(tc.reqbody)
+
+			req := httptest.NewRequest(http.MethodPut, "/admin/calendar/"+tc.taxYear, strings.NewReader(string(val)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("taxYear")
+			c.SetParamValues(tc.taxYear)
+
+			goterr := h.UpsertCalendar(c)
+
+			assert.NoError(t, goterr)
+
+			if tc.errresp != nil {
+				var errresp ResponseMsg
+
+				err := json.Unmarshal([]byte(rec.Body.String()), &errresp)
+				assert.NoError(t, err)
+
+				assert.NotEqual(t, http.StatusOK, rec.Code)
+
+				equal := reflect.DeepEqual(*tc.errresp, errresp)
+
+				if !equal {
+					assert.Fail(t, fmt.Sprintf("expected %v, \nbut got %v", *tc.errresp, errresp))
+				}
+
+				return
+			}
+
+			var got CalendarResponse
+
+			err := json.Unmarshal([]byte(rec.Body.String()), &got)
+			assert.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			equal := reflect.DeepEqual(*tc.want, got)
+
+			if !equal {
+				assert.Fail(t, fmt.Sprintf("expected %#v, \nbut got %#v", *tc.want, got))
+			}
+		})
+	}
+}
+
+func TestAdminDeleteCalendar(t *testing.T) {
+	type TC struct {
+		taxYear    string
+		mockDelete *MockSetting
+		wantCode   int
+	}
+
+	tcs := []TC{
+		{
+			taxYear: "2024",
+			mockDelete: &MockSetting{
+				Args:    []interface{}{mock.Anything, 2024},
+				Returns: []interface{}{nil},
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			taxYear: "2024",
+			mockDelete: &MockSetting{
+				Args:    []interface{}{mock.Anything, 2024},
+				Returns: []interface{}{database.ErrNotFound},
+			},
+			wantCode: http.StatusNotFound,
+		},
+		{
+			taxYear:    "1",
+			mockDelete: nil,
+			wantCode:   http.StatusBadRequest,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(CalendarDBMock)
+
+			if tc.mockDelete != nil {
+				dbmock.On("DeleteTaxCalendar", tc.mockDelete.Args...).Return(tc.mockDelete.Returns...)
+			}
+
+			h := NewCalendarHandler(validator.New(), dbmock)
+
+			req := httptest.NewRequest(http.MethodDelete, "/admin/calendar/"+tc.taxYear, nil)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("taxYear")
+			c.SetParamValues(tc.taxYear)
+
+			assert.NoError(t, h.DeleteCalendar(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
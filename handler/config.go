@@ -0,0 +1,335 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/buildinfo"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+)
+
+// config rarely changes, clients revalidate with If-None-Match after max-age
+const configCacheControl = "public, max-age=60"
+
+type DeductionsResponse struct {
+	DefaultAllowances []DefaultAllowanceResponse `json:"defaultAllowances"`
+	AllowedAllowances []AllowedAllowanceResponse `json:"allowedAllowances"`
+}
+
+// DefaultAllowanceResponse is also a row of settings import, key and timestamps are responded to admin only
+type DefaultAllowanceResponse struct {
+	AllowanceType string     `json:"allowanceType"`
+	Amount        float64    `json:"amount"`
+	UUID          string     `json:"uuid,omitempty"`
+	CreatedAt     *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
+	DisabledAt    *time.Time `json:"disabledAt,omitempty"`
+}
+
+// AllowedAllowanceResponse is also a row of settings import, key and timestamps are responded to admin only
+type AllowedAllowanceResponse struct {
+	AllowanceType string     `json:"allowanceType"`
+	MaxAmount     float64    `json:"maxAmount"`
+	UUID          string     `json:"uuid,omitempty"`
+	CreatedAt     *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt     *time.Time `json:"updatedAt,omitempty"`
+	DisabledAt    *time.Time `json:"disabledAt,omitempty"`
+}
+
+type BracketsResponse struct {
+	TaxYear   int               `json:"taxYear"`
+	TaxYearBE int               `json:"taxYearBE"`
+	Brackets  []BracketResponse `json:"brackets"`
+}
+
+type BracketResponse struct {
+	Level string   `json:"level"`
+	Rate  float64  `json:"rate"`
+	Max   *float64 `json:"max"` // null for the highest bracket
+}
+
+type ConfigIDB interface {
+	FindAllDefaultAllowances(ctx context.Context) ([]database.DefaultAllowance, error)
+	FindAllAllowedAllowances(ctx context.Context) ([]database.AllowedAllowance, error)
+}
+
+// SettingsVersionReader reads the latest settings version, every write of settings records a new one
+type SettingsVersionReader interface {
+	LatestSettingVersion(ctx context.Context) (int, error)
+}
+
+type ConfigHandler struct {
+	db       ConfigIDB
+	brackets BracketReader
+	tenants  TenantReader
+	versions SettingsVersionReader
+}
+
+func NewConfigHandler(db ConfigIDB) *ConfigHandler {
+	return &ConfigHandler{db: db}
+}
+
+// SetBrackets sets reader of imported brackets, they take precedence over rates compiled into the service
+func (h *ConfigHandler) SetBrackets(brackets BracketReader) *ConfigHandler {
+	h.brackets = brackets
+	return h
+}
+
+// SetTenants sets reader of tenant overrides, keys of a tenant are responded its settings
+func (h *ConfigHandler) SetTenants(tenants TenantReader) *ConfigHandler {
+	h.tenants = tenants
+	return h
+}
+
+// SetVersions sets reader of settings versions. ETags are derived from the version, so a client which has the
+// latest config is answered 304 before anything is loaded
+func (h *ConfigHandler) SetVersions(versions SettingsVersionReader) *ConfigHandler {
+	h.versions = versions
+	return h
+}
+
+// versionETag returns ETag of config derived from the settings version, it's empty when config isn't versioned:
+// without a version reader or for keys of a tenant, whose overrides are written without recording a version
+func (h *ConfigHandler) versionETag(c echo.Context) (string, error) {
+	if h.versions == nil || currentTenantID(c) != nil {
+		return "", nil
+	}
+
+	version, err := h.versions.LatestSettingVersion(c.Request().Context())
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find settings version", "error", err)
+		return "", err
+	}
+
+	lang := preferredLanguage(c.Request().Header.Get("Accept-Language"), defaultLanguage)
+
+	// rates compiled into the service change with the build, labels with the language
+	return fmt.Sprintf(`"%d-%s-%s"`, version, lang, buildinfo.Version), nil
+}
+
+// checkVersion returns ETag of the settings version and responds 304 when client already has it, responded is
+// true then. The version is read before config is loaded, so a write in between can't tag new config with the
+// old version
+func (h *ConfigHandler) checkVersion(c echo.Context) (etag string, responded bool, err error) {
+	etag, err = h.versionETag(c)
+	if err != nil {
+		return "", true, respondQueryError(c)
+	}
+
+	if etag == "" || !notModified(c, etag) {
+		return etag, false, nil
+	}
+
+	setCacheHeaders(c, etag)
+
+	return etag, true, c.NoContent(http.StatusNotModified)
+}
+
+// respondConfig responds v with etag of checkVersion, or with ETag of its content when config isn't versioned
+func respondConfig(c echo.Context, etag string, v interface{}) error {
+	if etag == "" {
+		return respondCacheable(c, v)
+	}
+
+	setCacheHeaders(c, etag)
+
+	return c.JSON(http.StatusOK, v)
+}
+
+// respondCacheable responds v with ETag of its content, and 304 when client already has it
+func respondCacheable(c echo.Context, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	setCacheHeaders(c, etag)
+
+	if notModified(c, etag) {
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	return c.JSONBlob(http.StatusOK, body)
+}
+
+func setCacheHeaders(c echo.Context, etag string) {
+	c.Response().Header().Set(echo.HeaderCacheControl, configCacheControl)
+	c.Response().Header().Set("ETag", etag)
+	c.Response().Header().Add(echo.HeaderVary, "Accept-Language")
+
+	// keys of different tenants are responded different settings
+	if _, ok := CurrentAPIKey(c); ok {
+		c.Response().Header().Add(echo.HeaderVary, apiKeyHeader)
+	}
+}
+
+// notModified reports whether If-None-Match of the request matches etag
+func notModified(c echo.Context, etag string) bool {
+	for _, tag := range strings.Split(c.Request().Header.Get("If-None-Match"), ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+
+		if tag == etag || tag == "*" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetDeductions returns allowances which can be claimed, disabled types are left out
+func (h *ConfigHandler) GetDeductions(c echo.Context) error {
+	etag, responded, err := h.checkVersion(c)
+	if responded {
+		return err
+	}
+
+	resp, err := h.Deductions(c.Request().Context(), currentTenantID(c))
+	if err != nil {
+		return respondQueryError(c)
+	}
+
+	return respondConfig(c, etag, resp)
+}
+
+// Deductions returns allowances which can be claimed with overrides of tenantID, e.g. by GetDeductions or gRPC
+func (h *ConfigHandler) Deductions(ctx context.Context, tenantID *int) (DeductionsResponse, error) {
+	overrides, err := findTenantSettings(ctx, h.tenants, tenantID)
+	if err != nil {
+		return DeductionsResponse{}, err
+	}
+
+	defaultAllowances, err := h.db.FindAllDefaultAllowances(ctx)
+	if err != nil {
+		return DeductionsResponse{}, err
+	}
+
+	allowedAllowances, err := h.db.FindAllAllowedAllowances(ctx)
+	if err != nil {
+		return DeductionsResponse{}, err
+	}
+
+	resp := DeductionsResponse{
+		DefaultAllowances: []DefaultAllowanceResponse{},
+		AllowedAllowances: []AllowedAllowanceResponse{},
+	}
+
+	disabled := map[string]bool{}
+
+	for _, a := range defaultAllowances {
+		if a.DisabledAt != nil {
+			disabled[a.AllowanceType] = true
+			continue
+		}
+
+		resp.DefaultAllowances = append(resp.DefaultAllowances, DefaultAllowanceResponse{
+			AllowanceType: a.AllowanceType,
+			Amount:        a.Amount,
+		})
+	}
+
+	for _, a := range allowedAllowances {
+		if a.DisabledAt != nil {
+			disabled[a.AllowanceType] = true
+			continue
+		}
+
+		resp.AllowedAllowances = append(resp.AllowedAllowances, AllowedAllowanceResponse{
+			AllowanceType: a.AllowanceType,
+			MaxAmount:     a.MaxAmount,
+		})
+	}
+
+	// overrides are applied like in calculations, types disabled globally stay disabled
+	for _, o := range overrides.DefaultAllowances {
+		if disabled[o.AllowanceType] {
+			continue
+		}
+
+		i := slices.IndexFunc(resp.DefaultAllowances, func(a DefaultAllowanceResponse) bool { return a.AllowanceType == o.AllowanceType })
+		if i < 0 {
+			resp.DefaultAllowances = append(resp.DefaultAllowances, DefaultAllowanceResponse{AllowanceType: o.AllowanceType, Amount: o.Amount})
+			continue
+		}
+
+		resp.DefaultAllowances[i].Amount = o.Amount
+	}
+
+	for _, o := range overrides.AllowedAllowances {
+		if disabled[o.AllowanceType] {
+			continue
+		}
+
+		i := slices.IndexFunc(resp.AllowedAllowances, func(a AllowedAllowanceResponse) bool { return a.AllowanceType == o.AllowanceType })
+		if i < 0 {
+			resp.AllowedAllowances = append(resp.AllowedAllowances, AllowedAllowanceResponse{AllowanceType: o.AllowanceType, MaxAmount: o.MaxAmount})
+			continue
+		}
+
+		resp.AllowedAllowances[i].MaxAmount = o.MaxAmount
+	}
+
+	return resp, nil
+}
+
+func (h *ConfigHandler) GetBrackets(c echo.Context) error {
+	etag, responded, err := h.checkVersion(c)
+	if responded {
+		return err
+	}
+
+	rates, ok, err := findRates(c, h.brackets)
+	if err != nil {
+		return respondQueryError(c)
+	}
+
+	overrides, err := findTenantSettings(c.Request().Context(), h.tenants, currentTenantID(c))
+	if err != nil {
+		return respondQueryError(c)
+	}
+
+	taxYear, valid := getTaxYear(c)
+	if valid && len(overrides.Brackets[taxYear]) > 0 {
+		rates, ok = toRates(overrides.Brackets[taxYear]), true
+	}
+
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.TaxYearUnsupported)
+	}
+
+	lang := preferredLanguage(c.Request().Header.Get("Accept-Language"), defaultLanguage)
+
+	resp := BracketsResponse{
+		TaxYear:   taxYear,
+		TaxYearBE: BuddhistEraYear(taxYear),
+		Brackets:  []BracketResponse{},
+	}
+
+	for _, r := range rates {
+		bracket := BracketResponse{
+			Level: r.LabelFor(lang),
+			Rate:  r.Percentage,
+		}
+
+		if r.Max != -1 {
+			max := r.Max
+			bracket.Max = &max
+		}
+
+		resp.Brackets = append(resp.Brackets, bracket)
+	}
+
+	return respondConfig(c, etag, resp)
+}
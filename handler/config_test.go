@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/buildinfo"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetDeductionsETag(t *testing.T) {
+	mockObj := new(UserDBMock)
+	mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+		{AllowanceType: "personal", Amount: 60_000},
+	}, nil)
+	mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+		{AllowanceType: "donation", MaxAmount: 100_000},
+		{AllowanceType: "k-receipt", MaxAmount: 50_000},
+	}, nil)
+
+	h := NewConfigHandler(mockObj)
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/tax/deductions", nil)
+	rec := httptest.NewRecorder()
+
+	assert.NoError(t, h.GetDeductions(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, configCacheControl, rec.Header().Get(echo.HeaderCacheControl))
+
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	var got DeductionsResponse
+
+	err := json.Unmarshal([]byte(rec.Body.String()), &got)
+	assert.NoError(t, err)
+	assert.Equal(t, DeductionsResponse{
+		DefaultAllowances: []DefaultAllowanceResponse{{AllowanceType: "personal", Amount: 60_000}},
+		AllowedAllowances: []AllowedAllowanceResponse{
+			{AllowanceType: "donation", MaxAmount: 100_000},
+			{AllowanceType: "k-receipt", MaxAmount: 50_000},
+		},
+	}, got)
+
+	req = httptest.NewRequest(http.MethodGet, "/tax/deductions", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+
+	assert.NoError(t, h.GetDeductions(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+}
+
+type versionStub struct {
+	version int
+	err     error
+}
+
+func (s *versionStub) LatestSettingVersion(ctx context.Context) (int, error) {
+	return s.version, s.err
+}
+
+func TestGetDeductionsVersionETag(t *testing.T) {
+	mockObj := new(UserDBMock)
+	mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+		{AllowanceType: "personal", Amount: 60_000},
+	}, nil)
+	mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{}, nil)
+
+	versions := &versionStub{version: 3}
+	h := NewConfigHandler(mockObj).SetVersions(versions)
+	e := echo.New()
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/tax/deductions", nil)
+		req.Header.Set("If-None-Match", ifNoneMatch)
+		rec := httptest.NewRecorder()
+
+		assert.NoError(t, h.GetDeductions(e.NewContext(req, rec)))
+
+		return rec
+	}
+
+	rec := get("")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"3-th-`+buildinfo.Version+`"`, rec.Header().Get("ETag"))
+	mockObj.AssertNumberOfCalls(t, "FindAllDefaultAllowances", 1)
+
+	etag := rec.Header().Get("ETag")
+
+	rec = get(etag)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Equal(t, etag, rec.Header().Get("ETag"))
+	mockObj.AssertNumberOfCalls(t, "FindAllDefaultAllowances", 1)
+
+	versions.version = 4
+
+	rec = get(etag)
+	assert.Equal(t, http.StatusOK, rec.Code, "settings written since the client loaded them")
+	assert.Equal(t, `"4-th-`+buildinfo.Version+`"`, rec.Header().Get("ETag"))
+
+	versions.err = errors.New("an error")
+
+	rec = get(etag)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestGetDeductionsError(t *testing.T) {
+	mockObj := new(UserDBMock)
+	mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{}, errors.New("an error"))
+
+	h := NewConfigHandler(mockObj)
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/tax/deductions", nil)
+	rec := httptest.NewRecorder()
+
+	assert.NoError(t, h.GetDeductions(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+}
+
+func TestGetBrackets(t *testing.T) {
+	h := NewConfigHandler(new(UserDBMock))
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/tax/brackets", nil)
+	req.Header.Set("Accept-Language", "en")
+	rec := httptest.NewRecorder()
+
+	assert.NoError(t, h.GetBrackets(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var got BracketsResponse
+
+	err := json.Unmarshal([]byte(rec.Body.String()), &got)
+	assert.NoError(t, err)
+	assert.Len(t, got.Brackets, 5)
+	assert.Equal(t, "2,000,001 and above", got.Brackets[4].Level)
+	assert.Nil(t, got.Brackets[4].Max)
+	assert.Equal(t, float64(150_000), *got.Brackets[0].Max)
+}
+
+type BracketReaderMock struct {
+	mock.Mock
+}
+
+func (o *BracketReaderMock) FindTaxBrackets(ctx context.Context, taxYear int) ([]database.TaxBracket, error) {
+	args := o.Called(ctx, taxYear)
+	return args.Get(0).([]database.TaxBracket), args.Error(1)
+}
+
+func TestGetBracketsImported(t *testing.T) {
+	max := float64(200_000)
+
+	brackets := new(BracketReaderMock)
+	brackets.On("FindTaxBrackets", mock.Anything, 2025).Return([]database.TaxBracket{
+		{TaxYear: 2025, Level: 1, Percentage: 0, MaxAmount: &max, Label: "0-200,000"},
+		{TaxYear: 2025, Level: 2, Percentage: 0.1, Label: "200,001 ขึ้นไป", Labels: map[string]string{"en": "200,001 and above"}},
+	}, nil)
+	brackets.On("FindTaxBrackets", mock.Anything, 2024).Return([]database.TaxBracket{}, nil)
+
+	h := NewConfigHandler(new(UserDBMock)).SetBrackets(brackets)
+	e := echo.New()
+
+	// tax year is in Buddhist era
+	req := httptest.NewRequest(http.MethodGet, "/tax/brackets?taxYear=2568", nil)
+	req.Header.Set("Accept-Language", "en")
+	rec := httptest.NewRecorder()
+
+	assert.NoError(t, h.GetBrackets(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var got BracketsResponse
+
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, 2025, got.TaxYear)
+	assert.Equal(t, 2568, got.TaxYearBE)
+	assert.Equal(t, []BracketResponse{
+		{Level: "0-200,000", Rate: 0, Max: &max},
+		{Level: "200,001 and above", Rate: 0.1},
+	}, got.Brackets)
+
+	// tax years without imported brackets use compiled rates
+	req = httptest.NewRequest(http.MethodGet, "/tax/brackets?taxYear=2024", nil)
+	rec = httptest.NewRecorder()
+
+	assert.NoError(t, h.GetBrackets(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Len(t, got.Brackets, 5)
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/AnnaCarter465/assessment-tax/tax/corporate"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// CorporateTaxRequest is net profit of a company's accounting period. Scheme is decided by paid-up capital and revenue
+// when it isn't set
+type CorporateTaxRequest struct {
+	NetProfit     float64 `json:"netProfit" validate:"number,gte=0"`
+	PaidUpCapital float64 `json:"paidUpCapital" validate:"number,gte=0"`
+	Revenue       float64 `json:"revenue" validate:"number,gte=0"`
+	Wht           float64 `json:"wht" validate:"number,gte=0"`
+	Scheme        string  `json:"scheme" validate:"omitempty,oneof=sme standard"`
+}
+
+type CorporateTaxResponse struct {
+	Scheme    string     `json:"scheme"`
+	Tax       float64    `json:"tax"`
+	TaxRefund float64    `json:"taxRefund"`
+	TaxLevel  []TaxLevel `json:"taxLevel,omitempty"`
+}
+
+// CorporateTaxHandler calculates corporate income tax, rates are compiled in so it needs no database
+type CorporateTaxHandler struct {
+	vl        *validator.Validate
+	precision func() string
+}
+
+func NewCorporateTaxHandler(vl *validator.Validate) *CorporateTaxHandler {
+	return &CorporateTaxHandler{vl: vl}
+}
+
+// SetPrecision sets function reporting precision of amounts like TaxHandler.SetPrecision
+func (h *CorporateTaxHandler) SetPrecision(precision func() string) *CorporateTaxHandler {
+	h.precision = precision
+	return h
+}
+
+// CalculateCorporateTax walks brackets of the scheme like personal income tax, with query params detail and precision
+func (h *CorporateTaxHandler) CalculateCorporateTax(c echo.Context) error {
+	detail, ok := getDetail(c)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	precision, ok := getPrecision(c, h.precision)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	var req CorporateTaxRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	scheme := req.Scheme
+	if scheme == "" {
+		scheme = corporate.SchemeOf(req.PaidUpCapital, req.Revenue)
+	}
+
+	tx, ok := corporate.NewTax(scheme)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	summary := tx.SetIncome(req.NetProfit).SetWht(req.Wht).CalculateTaxSummary()
+	taxResp := newTaxResponse(c, summary)
+
+	resp := CorporateTaxResponse{
+		Scheme:    scheme,
+		Tax:       taxResp.Tax,
+		TaxRefund: taxResp.TaxRefund,
+	}
+
+	if detail == DetailFull {
+		resp.TaxLevel = taxResp.TaxLevel
+	}
+
+	resp.round(precision)
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateCorporateTax(t *testing.T) {
+	type TC struct {
+		query    string
+		reqbody  string
+		wantCode int
+		want     CorporateTaxResponse
+	}
+
+	tcs := []TC{
+		{
+			reqbody:  `{"netProfit":1000000,"paidUpCapital":1000000,"revenue":10000000}`,
+			wantCode: http.StatusOK,
+			want: CorporateTaxResponse{Scheme: "sme", Tax: 105_000, TaxLevel: []TaxLevel{
+				{Level: "0-300,000", Tax: 0},
+				{Level: "300,001-3,000,000", Tax: 105_000},
+				{Level: "3,000,001 ขึ้นไป", Tax: 0},
+			}},
+		},
+		// paid-up capital over the SME limit
+		{
+			query:    "?detail=summary",
+			reqbody:  `{"netProfit":1000000,"paidUpCapital":10000000,"revenue":10000000,"wht":250000}`,
+			wantCode: http.StatusOK,
+			want:     CorporateTaxResponse{Scheme: "standard", TaxRefund: 50_000},
+		},
+		{
+			query:    "?detail=summary",
+			reqbody:  `{"netProfit":1000000,"scheme":"standard"}`,
+			wantCode: http.StatusOK,
+			want:     CorporateTaxResponse{Scheme: "standard", Tax: 200_000},
+		},
+		{
+			reqbody:  `{"netProfit":1000000,"scheme":"cooperative"}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			reqbody:  `{"netProfit":-1}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tax/corporate/calculations"+tc.query, strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			assert.NoError(t, NewCorporateTaxHandler(validator.New()).CalculateCorporateTax(echo.New().NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			var got CorporateTaxResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/AnnaCarter465/assessment-tax/tax"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type DraftRequest struct {
+	AllowanceType string  `json:"allowanceType" validate:"required"`
+	Amount        float64 `json:"amount" validate:"required,number,gt=0"`
+}
+
+type DraftResponse struct {
+	ID            int       `json:"id"`
+	AllowanceType string    `json:"allowanceType"`
+	Amount        float64   `json:"amount"`
+	CreatedBy     string    `json:"createdBy"`
+	UUID          string    `json:"uuid"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+type DraftIDB interface {
+	IDB
+	FindSettingBound(ctx context.Context, setting string) (database.SettingBound, error)
+	CreateSettingDraft(ctx context.Context, kind string, allowanceType string, amount float64, createdBy string) (database.SettingDraft, error)
+	FindSettingDraft(ctx context.Context, id int) (database.SettingDraft, error)
+	FindPendingSettingDrafts(ctx context.Context) ([]database.SettingDraft, error)
+	PublishSettingDraft(ctx context.Context, id int, publishedBy string) (database.SettingDraft, error)
+	DiscardSettingDraft(ctx context.Context, id int) error
+}
+
+type DraftHandler struct {
+	vl                 *validator.Validate
+	db                 DraftIDB
+	notifier           SettingsNotifier
+	brackets           BracketReader
+	requireSecondAdmin func() bool
+}
+
+func NewDraftHandler(vl *validator.Validate, db DraftIDB) *DraftHandler {
+	return &DraftHandler{vl: vl, db: db}
+}
+
+// SetNotifier sets notifier called after a draft is published
+func (h *DraftHandler) SetNotifier(notifier SettingsNotifier) *DraftHandler {
+	h.notifier = notifier
+	return h
+}
+
+// SetBrackets sets reader of imported brackets used by previews
+func (h *DraftHandler) SetBrackets(brackets BracketReader) *DraftHandler {
+	h.brackets = brackets
+	return h
+}
+
+// SetRequireSecondAdmin sets function reporting whether drafts must be published by an admin other than their author
+func (h *DraftHandler) SetRequireSecondAdmin(required func() bool) *DraftHandler {
+	h.requireSecondAdmin = required
+	return h
+}
+
+func toDraftResponse(d database.SettingDraft) DraftResponse {
+	return DraftResponse{
+		ID:            d.ID,
+		AllowanceType: d.AllowanceType,
+		Amount:        d.Amount,
+		CreatedBy:     d.CreatedBy,
+		UUID:          d.UUID,
+		CreatedAt:     d.CreatedAt,
+		UpdatedAt:     d.UpdatedAt,
+	}
+}
+
+// findDraft responds error when id is invalid or draft isn't pending
+func (h *DraftHandler) findDraft(c echo.Context) (database.SettingDraft, bool, error) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return database.SettingDraft{}, false, respondError(c, http.StatusBadRequest, errcode.DraftInvalidID)
+	}
+
+	draft, err := h.db.FindSettingDraft(c.Request().Context(), id)
+	if errors.Is(err, database.ErrNotFound) {
+		return database.SettingDraft{}, false, respondError(c, http.StatusNotFound, errcode.DraftNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find setting draft", "error", err)
+		return database.SettingDraft{}, false, respondQueryError(c)
+	}
+
+	return draft, true, nil
+}
+
+func (h *DraftHandler) GetDrafts(c echo.Context) error {
+	drafts, err := h.db.FindPendingSettingDrafts(c.Request().Context())
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find setting drafts", "error", err)
+		return respondQueryError(c)
+	}
+
+	results := []DraftResponse{}
+
+	for _, d := range drafts {
+		results = append(results, toDraftResponse(d))
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+func (h *DraftHandler) CreateDraft(c echo.Context) error {
+	var req DraftRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	kind, ok := settingKinds[req.AllowanceType]
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	claims, ok := CurrentAdmin(c)
+	if !ok {
+		return respondError(c, http.StatusForbidden, errcode.Forbidden)
+	}
+
+	if ok, err := withinBound(c, h.db, req.AllowanceType, req.Amount); !ok {
+		return err
+	}
+
+	draft, err := h.db.CreateSettingDraft(c.Request().Context(), kind, req.AllowanceType, req.Amount, claims.Subject)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to create setting draft", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.DraftCreateFailed)
+	}
+
+	return c.JSON(http.StatusCreated, toDraftResponse(draft))
+}
+
+// PreviewDraft calculates tax of request body with live settings as if the draft was published
+func (h *DraftHandler) PreviewDraft(c echo.Context) error {
+	rates, ok, err := findRates(c, h.brackets)
+	if err != nil {
+		return respondQueryError(c)
+	}
+
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.TaxYearUnsupported)
+	}
+
+	draft, ok, err := h.findDraft(c)
+	if !ok {
+		return err
+	}
+
+	var req TaxRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if req.TotalIncome < req.Wht {
+		return respondError(c, http.StatusBadRequest, errcode.WhtExceedsIncome)
+	}
+
+	th := NewTaxHandler(h.vl, h.db)
+
+	defaultAllowancesMap, _, err := th.getDefaultAllowancesMap(c.Request().Context())
+	if err != nil {
+		return respondQueryError(c)
+	}
+
+	allowedAllowancesMap, _, err := th.getAllowedAllowancesMap(c.Request().Context())
+	if err != nil {
+		return respondQueryError(c)
+	}
+
+	switch draft.AllowanceKind {
+	case database.AllowanceKindDefault:
+		defaultAllowancesMap[draft.AllowanceType] = draft.Amount
+	case database.AllowanceKindAllowed:
+		allowedAllowancesMap[draft.AllowanceType] = draft.Amount
+	}
+
+	tx := tax.NewTax(tax.TaxConfig{
+		Rates:             rates,
+		DefaultAllowances: defaultAllowancesMap,
+		AllowedAllowances: allowedAllowancesMap,
+	}).SetIncome(req.TotalIncome).SetWht(req.Wht)
+
+	for _, a := range req.Allowances {
+		tx.AddAllowance(a.AllowanceType, a.Amount)
+	}
+
+	return c.JSON(http.StatusOK, newTaxResponse(c, tx.CalculateTaxSummary()))
+}
+
+func (h *DraftHandler) PublishDraft(c echo.Context) error {
+	draft, ok, err := h.findDraft(c)
+	if !ok {
+		return err
+	}
+
+	claims, ok := CurrentAdmin(c)
+	if !ok {
+		return respondError(c, http.StatusForbidden, errcode.Forbidden)
+	}
+
+	if h.requireSecondAdmin != nil && h.requireSecondAdmin() && claims.Subject == draft.CreatedBy {
+		return respondError(c, http.StatusForbidden, errcode.DraftSelfPublish)
+	}
+
+	published, err := h.db.PublishSettingDraft(c.Request().Context(), draft.ID, claims.Subject)
+	if errors.Is(err, database.ErrConflict) {
+		return respondError(c, http.StatusConflict, errcode.SettingsVersionConflict)
+	}
+
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.DraftNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to publish setting draft", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.DraftPublishFailed)
+	}
+
+	if h.notifier != nil {
+		h.notifier.SettingsChanged(published.AllowanceType, published.Amount)
+	}
+
+	return c.JSON(http.StatusOK, toDraftResponse(published))
+}
+
+func (h *DraftHandler) DiscardDraft(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.DraftInvalidID)
+	}
+
+	err = h.db.DiscardSettingDraft(c.Request().Context(), id)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.DraftNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to discard setting draft", "error", err)
+		return respondQueryError(c)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type DraftDBMock struct {
+	UserDBMock
+}
+
+func (o *DraftDBMock) FindSettingBound(ctx context.Context, setting string) (database.SettingBound, error) {
+	args := o.Called(ctx, setting)
+	return args.Get(0).(database.SettingBound), args.Error(1)
+}
+
+func (o *DraftDBMock) CreateSettingDraft(ctx context.Context, kind string, allowanceType string, amount float64, createdBy string) (database.SettingDraft, error) {
+	args := o.Called(ctx, kind, allowanceType, amount, createdBy)
+	return args.Get(0).(database.SettingDraft), args.Error(1)
+}
+
+func (o *DraftDBMock) FindSettingDraft(ctx context.Context, id int) (database.SettingDraft, error) {
+	args := o.Called(ctx, id)
+	return args.Get(0).(database.SettingDraft), args.Error(1)
+}
+
+func (o *DraftDBMock) FindPendingSettingDrafts(ctx context.Context) ([]database.SettingDraft, error) {
+	args := o.Called(ctx)
+	return args.Get(0).([]database.SettingDraft), args.Error(1)
+}
+
+func (o *DraftDBMock) PublishSettingDraft(ctx context.Context, id int, publishedBy string) (database.SettingDraft, error) {
+	args := o.Called(ctx, id, publishedBy)
+	return args.Get(0).(database.SettingDraft), args.Error(1)
+}
+
+func (o *DraftDBMock) DiscardSettingDraft(ctx context.Context, id int) error {
+	args := o.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestAdminCreateDraft(t *testing.T) {
+	type TC struct {
+		reqbody    string
+		mockCreate *MockSetting
+		wantCode   int
+		wantErr    errcode.Code
+	}
+
+	tcs := []TC{
+		{
+			reqbody: `{"allowanceType":"personal","amount":70000}`,
+			mockCreate: &MockSetting{
+				Args: []interface{}{mock.Anything, database.AllowanceKindDefault, "personal", float64(70_000), "somchai"},
+				Returns: []interface{}{
+					database.SettingDraft{ID: 1, AllowanceKind: database.AllowanceKindDefault, AllowanceType: "personal", Amount: 70_000, CreatedBy: "somchai"},
+					nil,
+				},
+			},
+			wantCode: http.StatusCreated,
+		},
+		{
+			reqbody:  `{"allowanceType":"unknown","amount":70000}`,
+			wantCode: http.StatusBadRequest,
+			wantErr:  errcode.InvalidRequest,
+		},
+		{
+			reqbody:  `{"allowanceType":"personal","amount":100001}`,
+			wantCode: http.StatusBadRequest,
+			wantErr:  errcode.AmountOutOfRange,
+		},
+		{
+			reqbody: `{"allowanceType":"personal","amount":70000}`,
+			mockCreate: &MockSetting{
+				Args:    []interface{}{mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything},
+				Returns: []interface{}{database.SettingDraft{}, errors.New("an error")},
+			},
+			wantCode: http.StatusInternalServerError,
+			wantErr:  errcode.DraftCreateFailed,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(DraftDBMock)
+			dbmock.On("FindSettingBound", mock.Anything, "personal").
+				Return(database.SettingBound{Setting: "personal", MinAmount: 10_000, MaxAmount: 100_000}, nil)
+
+			if tc.mockCreate != nil {
+				dbmock.On("CreateSettingDraft", tc.mockCreate.Args...).Return(tc.mockCreate.Returns...)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/drafts", strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, NewDraftHandler(validator.New(), dbmock).CreateDraft(newAdminContext(e, req, rec, "somchai")))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantErr != "" {
+				var got ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, tc.wantErr, got.ErrorCode)
+
+				return
+			}
+
+			var got DraftResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, DraftResponse{ID: 1, AllowanceType: "personal", Amount: 70_000, CreatedBy: "somchai"}, got)
+		})
+	}
+}
+
+func TestAdminPreviewDraft(t *testing.T) {
+	dbmock := new(DraftDBMock)
+	dbmock.On("FindSettingDraft", mock.Anything, 1).Return(database.SettingDraft{
+		ID: 1, AllowanceKind: database.AllowanceKindDefault, AllowanceType: "personal", Amount: 100_000, CreatedBy: "somchai",
+	}, nil)
+	dbmock.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+		{AllowanceType: "personal", Amount: 60_000},
+	}, nil)
+	dbmock.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/drafts/1/preview", strings.NewReader(`{"totalIncome":500000,"wht":0,"allowances":[]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	e := echo.New()
+	c := newAdminContext(e, req, rec, "somchai")
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+
+	assert.NoError(t, NewDraftHandler(validator.New(), dbmock).PreviewDraft(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var got TaxResponse
+
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, float64(25_000), got.Tax)
+	dbmock.AssertNotCalled(t, "PublishSettingDraft", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAdminPublishDraft(t *testing.T) {
+	type TC struct {
+		publisher          string
+		requireSecondAdmin bool
+		mockPublish        *MockSetting
+		wantCode           int
+		wantErr            errcode.Code
+	}
+
+	draft := database.SettingDraft{
+		ID: 1, AllowanceKind: database.AllowanceKindDefault, AllowanceType: "personal", Amount: 70_000, CreatedBy: "somchai",
+	}
+
+	tcs := []TC{
+		{
+			publisher: "somchai",
+			mockPublish: &MockSetting{
+				Args:    []interface{}{mock.Anything, 1, "somchai"},
+				Returns: []interface{}{draft, nil},
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			publisher:          "somchai",
+			requireSecondAdmin: true,
+			wantCode:           http.StatusForbidden,
+			wantErr:            errcode.DraftSelfPublish,
+		},
+		{
+			publisher:          "somying",
+			requireSecondAdmin: true,
+			mockPublish: &MockSetting{
+				Args:    []interface{}{mock.Anything, 1, "somying"},
+				Returns: []interface{}{draft, nil},
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			publisher: "somying",
+			mockPublish: &MockSetting{
+				Args:    []interface{}{mock.Anything, 1, "somying"},
+				Returns: []interface{}{database.SettingDraft{}, database.ErrNotFound},
+			},
+			wantCode: http.StatusNotFound,
+			wantErr:  errcode.DraftNotFound,
+		},
+		{
+			publisher: "somying",
+			mockPublish: &MockSetting{
+				Args:    []interface{}{mock.Anything, 1, "somying"},
+				Returns: []interface{}{database.SettingDraft{}, errors.New("an error")},
+			},
+			wantCode: http.StatusInternalServerError,
+			wantErr:  errcode.DraftPublishFailed,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(DraftDBMock)
+			dbmock.On("FindSettingDraft", mock.Anything, 1).Return(draft, nil)
+
+			if tc.mockPublish != nil {
+				dbmock.On("PublishSettingDraft", tc.mockPublish.Args...).Return(tc.mockPublish.Returns...)
+			}
+
+			notifier := new(NotifierMock)
+			notifier.On("SettingsChanged", "personal", float64(70_000)).Return()
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/drafts/1/publish", nil)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			c := newAdminContext(e, req, rec, tc.publisher)
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+
+			h := NewDraftHandler(validator.New(), dbmock).SetNotifier(notifier).SetRequireSecondAdmin(func() bool { return tc.requireSecondAdmin })
+
+			assert.NoError(t, h.PublishDraft(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantErr != "" {
+				var got ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, tc.wantErr, got.ErrorCode)
+				notifier.AssertNotCalled(t, "SettingsChanged", mock.Anything, mock.Anything)
+
+				return
+			}
+
+			notifier.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAdminDiscardDraft(t *testing.T) {
+	type TC struct {
+		id          string
+		mockDiscard *MockSetting
+		wantCode    int
+	}
+
+	tcs := []TC{
+		{id: "1", mockDiscard: &MockSetting{Args: []interface{}{mock.Anything, 1}, Returns: []interface{}{nil}}, wantCode: http.StatusNoContent},
+		{id: "abc", wantCode: http.StatusBadRequest},
+		{id: "2", mockDiscard: &MockSetting{Args: []interface{}{mock.Anything, 2}, Returns: []interface{}{database.ErrNotFound}}, wantCode: http.StatusNotFound},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(DraftDBMock)
+
+			if tc.mockDiscard != nil {
+				dbmock.On("DiscardSettingDraft", tc.mockDiscard.Args...).Return(tc.mockDiscard.Returns...)
+			}
+
+			req := httptest.NewRequest(http.MethodDelete, "/admin/drafts/"+tc.id, nil)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tc.id)
+
+			assert.NoError(t, NewDraftHandler(validator.New(), dbmock).DiscardDraft(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+)
+
+// Drain tracks batch jobs like csv uploads during shutdown, new jobs are rejected once it starts
+// and the server waits for jobs in flight before it stops
+type Drain struct {
+	mu       sync.Mutex
+	draining bool
+	jobs     sync.WaitGroup
+}
+
+func NewDrain() *Drain {
+	return &Drain{}
+}
+
+// Track rejects requests after Start, other requests are waited for by Wait
+func (d *Drain) Track() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !d.add() {
+				return respondError(c, http.StatusServiceUnavailable, errcode.ShuttingDown)
+			}
+			defer d.jobs.Done()
+
+			return next(c)
+		}
+	}
+}
+
+// add counts a job unless draining started, under lock so it can't race with Wait
+func (d *Drain) add() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.draining {
+		return false
+	}
+
+	d.jobs.Add(1)
+
+	return true
+}
+
+// Start rejects new jobs
+func (d *Drain) Start() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.draining = true
+}
+
+// Wait returns when jobs in flight finish, or error of ctx when it's done first
+func (d *Drain) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+
+	go func() {
+		d.jobs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrain(t *testing.T) {
+	d := NewDrain()
+	e := echo.New()
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+
+	go func() {
+		c := e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), httptest.NewRecorder())
+
+		_ = d.Track()(func(c echo.Context) error {
+			close(started)
+			<-finish
+			return c.NoContent(http.StatusOK)
+		})(c)
+	}()
+
+	<-started
+	d.Start()
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec)
+
+	err := d.Track()(func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var got ResponseMsg
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, errcode.ShuttingDown, got.ErrorCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, d.Wait(ctx), context.DeadlineExceeded, "job in flight is waited for")
+
+	close(finish)
+
+	assert.NoError(t, d.Wait(context.Background()))
+}
@@ -0,0 +1,505 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+)
+
+// error messages use english unless caller prefers thai, existing clients rely on english messages
+const defaultErrorLanguage = "en"
+
+var errorMessages = map[errcode.Code]map[string]string{
+	errcode.InvalidRequest: {
+		"en": "Bad request",
+		"th": "คำขอไม่ถูกต้อง",
+	},
+	errcode.Internal: {
+		"en": "Internal server error",
+		"th": "เกิดข้อผิดพลาดภายในระบบ",
+	},
+	errcode.Unauthorized: {
+		"en": "Unauthorized",
+		"th": "ไม่ได้รับอนุญาต กรุณายืนยันตัวตน",
+	},
+	errcode.Forbidden: {
+		"en": "Forbidden",
+		"th": "ไม่มีสิทธิ์เข้าถึง",
+	},
+	errcode.NotFound: {
+		"en": "Not found",
+		"th": "ไม่พบข้อมูลที่ร้องขอ",
+	},
+	errcode.MethodNotAllowed: {
+		"en": "Method not allowed",
+		"th": "ไม่รองรับเมธอดนี้",
+	},
+	errcode.PayloadTooLarge: {
+		"en": "Request body too large",
+		"th": "ข้อมูลที่ส่งมีขนาดใหญ่เกินไป",
+	},
+	errcode.UnsupportedMediaType: {
+		"en": "Unsupported media type",
+		"th": "ไม่รองรับรูปแบบข้อมูลนี้",
+	},
+	errcode.TooManyRequests: {
+		"en": "Too many requests",
+		"th": "มีคำขอมากเกินไป กรุณาลองใหม่ภายหลัง",
+	},
+	errcode.ServiceUnavailable: {
+		"en": "Service unavailable",
+		"th": "ระบบไม่พร้อมให้บริการชั่วคราว",
+	},
+	errcode.RequestTimeout: {
+		"en": "Request took too long, please try again",
+		"th": "คำขอใช้เวลานานเกินไป กรุณาลองใหม่อีกครั้ง",
+	},
+	errcode.MaintenanceMode: {
+		"en": "Service is under maintenance, changes are not accepted at the moment",
+		"th": "ระบบอยู่ระหว่างการบำรุงรักษา ยังไม่สามารถแก้ไขข้อมูลได้ในขณะนี้",
+	},
+	errcode.TaxYearUnsupported: {
+		"en": "Unsupported tax year",
+		"th": "ไม่รองรับปีภาษีนี้",
+	},
+	errcode.WhtExceedsIncome: {
+		"en": "Invalid wht",
+		"th": "ภาษีหัก ณ ที่จ่ายต้องไม่มากกว่ารายได้",
+	},
+	errcode.InvalidDate: {
+		"en": "Invalid date, require YYYY-MM-DD",
+		"th": "วันที่ไม่ถูกต้อง ต้องอยู่ในรูปแบบ YYYY-MM-DD",
+	},
+	errcode.CSVContentType: {
+		"en": "Unaceptable content, require CSV content",
+		"th": "รองรับเฉพาะไฟล์ CSV เท่านั้น",
+	},
+	errcode.CSVMalformed: {
+		"en": "Bad request, might not be csv format",
+		"th": "รูปแบบไฟล์ CSV ไม่ถูกต้อง",
+	},
+	errcode.CSVEmpty: {
+		"en": "Wrong csv content, no content",
+		"th": "ไฟล์ CSV ไม่มีข้อมูล",
+	},
+	errcode.CSVNoDataRows: {
+		"en": "Wrong csv content, should have more than 1 row due to it is header",
+		"th": "ไฟล์ CSV ต้องมีข้อมูลอย่างน้อย 1 แถวนอกจากหัวตาราง",
+	},
+	errcode.CSVBadColumnCount: {
+		"en": "Wrong csv column length",
+		"th": "จำนวนคอลัมน์ในไฟล์ CSV ไม่ถูกต้อง",
+	},
+	errcode.CSVBadHeader: {
+		"en": "Wrong csv header",
+		"th": "หัวตารางในไฟล์ CSV ไม่ถูกต้อง",
+	},
+	errcode.CSVInvalidIncome: {
+		"en": "Invalid income amount",
+		"th": "จำนวนรายได้ไม่ถูกต้อง",
+	},
+	errcode.CSVInvalidWht: {
+		"en": "Invalid wht amount",
+		"th": "จำนวนภาษีหัก ณ ที่จ่ายไม่ถูกต้อง",
+	},
+	errcode.CSVInvalidDonation: {
+		"en": "Invalid donation amount",
+		"th": "จำนวนเงินบริจาคไม่ถูกต้อง",
+	},
+	errcode.CSVWhtExceedsIncome: {
+		"en": "Income amount should be more than wht amount",
+		"th": "รายได้ต้องมากกว่าภาษีหัก ณ ที่จ่าย",
+	},
+	errcode.UploadRejected: {
+		"en": "Uploaded file was rejected by security scan",
+		"th": "ไฟล์ที่อัปโหลดไม่ผ่านการตรวจสอบความปลอดภัย",
+	},
+	errcode.AmountOutOfRange: {
+		"en": "Invalid amount",
+		"th": "จำนวนเงินไม่อยู่ในช่วงที่กำหนด",
+	},
+	errcode.PersonalUpdateFailed: {
+		"en": "Failed to update personal amount",
+		"th": "ไม่สามารถแก้ไขค่าลดหย่อนส่วนตัวได้",
+	},
+	errcode.KReceiptUpdateFailed: {
+		"en": "Failed to update k-receipt amount",
+		"th": "ไม่สามารถแก้ไขค่าลดหย่อน k-receipt ได้",
+	},
+	errcode.DonationUpdateFailed: {
+		"en": "Failed to update donation amount",
+		"th": "ไม่สามารถแก้ไขค่าลดหย่อนเงินบริจาคได้",
+	},
+	errcode.EffectiveAllowanceUpdateFailed: {
+		"en": "Failed to publish effective allowance",
+		"th": "ไม่สามารถกำหนดค่าลดหย่อนตามวันที่มีผลได้",
+	},
+	errcode.ActivationInPast: {
+		"en": "Activation time must be in the future",
+		"th": "เวลาที่มีผลต้องเป็นเวลาในอนาคต",
+	},
+	errcode.ScheduledChangeCreateFailed: {
+		"en": "Failed to schedule change",
+		"th": "ไม่สามารถตั้งเวลาการเปลี่ยนแปลงได้",
+	},
+	errcode.ScheduledChangeInvalidID: {
+		"en": "Invalid scheduled change id",
+		"th": "รหัสการเปลี่ยนแปลงที่ตั้งเวลาไว้ไม่ถูกต้อง",
+	},
+	errcode.ScheduledChangeNotFound: {
+		"en": "Pending scheduled change not found",
+		"th": "ไม่พบการเปลี่ยนแปลงที่รอดำเนินการ",
+	},
+	errcode.DraftCreateFailed: {
+		"en": "Failed to create draft",
+		"th": "ไม่สามารถสร้างฉบับร่างได้",
+	},
+	errcode.DraftInvalidID: {
+		"en": "Invalid draft id",
+		"th": "รหัสฉบับร่างไม่ถูกต้อง",
+	},
+	errcode.DraftNotFound: {
+		"en": "Pending draft not found",
+		"th": "ไม่พบฉบับร่างที่รอเผยแพร่",
+	},
+	errcode.DraftSelfPublish: {
+		"en": "Draft must be published by another admin",
+		"th": "ฉบับร่างต้องเผยแพร่โดยผู้ดูแลระบบคนอื่น",
+	},
+	errcode.DraftPublishFailed: {
+		"en": "Failed to publish draft",
+		"th": "ไม่สามารถเผยแพร่ฉบับร่างได้",
+	},
+	errcode.SettingVersionInvalid: {
+		"en": "Invalid settings version",
+		"th": "เวอร์ชันการตั้งค่าไม่ถูกต้อง",
+	},
+	errcode.SettingVersionNotFound: {
+		"en": "Settings version not found",
+		"th": "ไม่พบเวอร์ชันการตั้งค่า",
+	},
+	errcode.SettingsRollbackFailed: {
+		"en": "Failed to rollback settings",
+		"th": "ไม่สามารถย้อนกลับการตั้งค่าได้",
+	},
+	errcode.SettingsImportInvalid: {
+		"en": "Invalid settings file",
+		"th": "ไฟล์การตั้งค่าไม่ถูกต้อง",
+	},
+	errcode.SettingsImportFailed: {
+		"en": "Failed to import settings",
+		"th": "ไม่สามารถนำเข้าการตั้งค่าได้",
+	},
+	errcode.SettingsVersionConflict: {
+		"en": "Settings were changed by someone else, reload them and try again",
+		"th": "การตั้งค่าถูกแก้ไขโดยผู้อื่น กรุณาโหลดใหม่แล้วลองอีกครั้ง",
+	},
+	errcode.AllowanceNotFound: {
+		"en": "Allowance type not found",
+		"th": "ไม่พบประเภทค่าลดหย่อน",
+	},
+	errcode.AllowanceDisabled: {
+		"en": "Allowance type is no longer available",
+		"th": "ประเภทค่าลดหย่อนนี้ไม่สามารถใช้ได้แล้ว",
+	},
+	errcode.AllowanceDisableFailed: {
+		"en": "Failed to disable allowance type",
+		"th": "ไม่สามารถปิดการใช้งานประเภทค่าลดหย่อนได้",
+	},
+	errcode.HistoryDeleteFailed: {
+		"en": "Failed to delete calculation history",
+		"th": "ไม่สามารถลบประวัติการคำนวณได้",
+	},
+	errcode.ShuttingDown: {
+		"en": "Server is shutting down, please retry",
+		"th": "เซิร์ฟเวอร์กำลังปิดตัว กรุณาลองใหม่อีกครั้ง",
+	},
+	errcode.ConfigInvalid: {
+		"en": "Invalid configuration, previous settings are kept",
+		"th": "การตั้งค่าไม่ถูกต้อง ยังคงใช้การตั้งค่าเดิม",
+	},
+	errcode.SchemaViolation: {
+		"en": "Request doesn't match the API schema",
+		"th": "คำขอไม่ตรงตามรูปแบบของ API",
+	},
+	errcode.TenantExists: {
+		"en": "Tenant already exists",
+		"th": "มีบริษัทในเครือชื่อนี้อยู่แล้ว",
+	},
+	errcode.TenantInvalidID: {
+		"en": "Invalid tenant id",
+		"th": "รหัสบริษัทในเครือไม่ถูกต้อง",
+	},
+	errcode.TenantNotFound: {
+		"en": "Tenant not found",
+		"th": "ไม่พบบริษัทในเครือ",
+	},
+	errcode.TenantInUse: {
+		"en": "Tenant still has api keys",
+		"th": "บริษัทในเครือยังมี api key อยู่",
+	},
+	errcode.AccountExists: {
+		"en": "An account with this email already exists",
+		"th": "มีบัญชีที่ใช้อีเมลนี้อยู่แล้ว",
+	},
+	errcode.ProfileNotFound: {
+		"en": "Profile not found",
+		"th": "ไม่พบโปรไฟล์",
+	},
+	errcode.CalculationDraftInvalidID: {
+		"en": "Invalid calculation draft id",
+		"th": "รหัสฉบับร่างการคำนวณไม่ถูกต้อง",
+	},
+	errcode.CalculationDraftNotFound: {
+		"en": "Pending calculation draft not found",
+		"th": "ไม่พบฉบับร่างการคำนวณที่รอดำเนินการ",
+	},
+	errcode.CalculationDraftIncomplete: {
+		"en": "Calculation draft has no total income yet",
+		"th": "ฉบับร่างการคำนวณยังไม่มีรายได้ทั้งหมด",
+	},
+	errcode.CalculationDraftChanged: {
+		"en": "Calculation draft was changed, please finalize it again",
+		"th": "ฉบับร่างการคำนวณถูกเปลี่ยนแปลง กรุณายืนยันอีกครั้ง",
+	},
+	errcode.AllowanceAliasInvalid: {
+		"en": "Alias must be another spelling of an allowance type",
+		"th": "ชื่อแทนต้องเป็นการสะกดอื่นของประเภทค่าลดหย่อน",
+	},
+	errcode.AllowanceAliasNotFound: {
+		"en": "Allowance alias not found",
+		"th": "ไม่พบชื่อแทนของค่าลดหย่อน",
+	},
+	errcode.AllowanceDuplicated: {
+		"en": "Allowance type is claimed more than once",
+		"th": "ระบุประเภทค่าลดหย่อนซ้ำกัน",
+	},
+	errcode.AllowanceOutsideWindow: {
+		"en": "Allowance is dated outside the period it can be claimed for",
+		"th": "วันที่ของค่าลดหย่อนอยู่นอกช่วงที่สามารถใช้สิทธิ์ได้",
+	},
+	errcode.BatchJobNotFound: {
+		"en": "Batch job not found",
+		"th": "ไม่พบงานคำนวณแบบกลุ่ม",
+	},
+	errcode.InvalidTaxYear: {
+		"en": "Invalid tax year",
+		"th": "ปีภาษีไม่ถูกต้อง",
+	},
+	errcode.InvalidWindow: {
+		"en": "Invalid allowance window",
+		"th": "ช่วงวันที่ของค่าลดหย่อนไม่ถูกต้อง",
+	},
+	errcode.CalendarUpdateFailed: {
+		"en": "Failed to update tax calendar",
+		"th": "ไม่สามารถแก้ไขปฏิทินภาษีได้",
+	},
+	errcode.CalendarNotFound: {
+		"en": "Tax calendar not found",
+		"th": "ไม่พบปฏิทินภาษี",
+	},
+	errcode.CalendarDeleteFailed: {
+		"en": "Failed to delete tax calendar",
+		"th": "ไม่สามารถลบปฏิทินภาษีได้",
+	},
+	errcode.WebhookCreateFailed: {
+		"en": "Failed to create webhook",
+		"th": "ไม่สามารถสร้าง webhook ได้",
+	},
+	errcode.WebhookInvalidID: {
+		"en": "Invalid webhook id",
+		"th": "รหัส webhook ไม่ถูกต้อง",
+	},
+	errcode.WebhookNotFound: {
+		"en": "Webhook not found",
+		"th": "ไม่พบ webhook",
+	},
+	errcode.WebhookDeleteFailed: {
+		"en": "Failed to delete webhook",
+		"th": "ไม่สามารถลบ webhook ได้",
+	},
+	errcode.APIKeyInvalid: {
+		"en": "Missing or invalid api key",
+		"th": "ไม่พบ api key หรือ api key ไม่ถูกต้อง",
+	},
+	errcode.APIKeyScopeDenied: {
+		"en": "Api key is not allowed to use this endpoint",
+		"th": "api key นี้ไม่มีสิทธิ์ใช้งาน endpoint นี้",
+	},
+	errcode.APIKeyCreateFailed: {
+		"en": "Failed to create api key",
+		"th": "ไม่สามารถสร้าง api key ได้",
+	},
+	errcode.APIKeyInvalidID: {
+		"en": "Invalid api key id",
+		"th": "รหัส api key ไม่ถูกต้อง",
+	},
+	errcode.APIKeyNotFound: {
+		"en": "Api key not found",
+		"th": "ไม่พบ api key",
+	},
+	errcode.QuotaExceeded: {
+		"en": "Monthly quota of api key exceeded",
+		"th": "api key ใช้งานเกินโควตารายเดือนแล้ว",
+	},
+	errcode.InvalidMonth: {
+		"en": "Invalid month, require YYYY-MM",
+		"th": "เดือนไม่ถูกต้อง ต้องอยู่ในรูปแบบ YYYY-MM",
+	},
+	errcode.SignatureInvalid: {
+		"en": "Missing or invalid request signature",
+		"th": "ไม่พบลายเซ็นของคำขอ หรือลายเซ็นไม่ถูกต้อง",
+	},
+	errcode.SignatureExpired: {
+		"en": "Request signature expired",
+		"th": "ลายเซ็นของคำขอหมดอายุแล้ว",
+	},
+	errcode.InvalidCredentials: {
+		"en": "Invalid username or password",
+		"th": "ชื่อผู้ใช้หรือรหัสผ่านไม่ถูกต้อง",
+	},
+	errcode.AdminUserExists: {
+		"en": "Admin user already exists",
+		"th": "มีผู้ดูแลระบบชื่อนี้อยู่แล้ว",
+	},
+	errcode.AdminUserInvalidID: {
+		"en": "Invalid admin user id",
+		"th": "รหัสผู้ดูแลระบบไม่ถูกต้อง",
+	},
+	errcode.AdminUserNotFound: {
+		"en": "Admin user not found",
+		"th": "ไม่พบผู้ดูแลระบบ",
+	},
+	errcode.TOTPRequired: {
+		"en": "Two-factor authentication code is required",
+		"th": "กรุณาระบุรหัสยืนยันตัวตนสองขั้นตอน",
+	},
+	errcode.TOTPInvalid: {
+		"en": "Invalid two-factor authentication code",
+		"th": "รหัสยืนยันตัวตนสองขั้นตอนไม่ถูกต้อง",
+	},
+	errcode.TOTPAlreadyEnabled: {
+		"en": "Two-factor authentication is already enabled",
+		"th": "เปิดใช้งานการยืนยันตัวตนสองขั้นตอนแล้ว",
+	},
+	errcode.TOTPNotEnrolled: {
+		"en": "Two-factor authentication enrollment not found",
+		"th": "ไม่พบการลงทะเบียนการยืนยันตัวตนสองขั้นตอน",
+	},
+	errcode.SessionNotFound: {
+		"en": "Session not found",
+		"th": "ไม่พบเซสชัน",
+	},
+	errcode.IPNotAllowed: {
+		"en": "Access from this network is not allowed",
+		"th": "ไม่อนุญาตให้เข้าถึงจากเครือข่ายนี้",
+	},
+}
+
+// ErrorMessage returns English message of code, e.g. for errors of other transports than HTTP
+func ErrorMessage(code errcode.Code) string {
+	return errorMessage(code, defaultErrorLanguage)
+}
+
+// errorMessage returns message of code in lang, fallback to english
+func errorMessage(code errcode.Code, lang string) string {
+	messages, ok := errorMessages[code]
+	if !ok {
+		return string(code)
+	}
+
+	if msg, ok := messages[lang]; ok {
+		return msg
+	}
+
+	return messages[defaultErrorLanguage]
+}
+
+func respondError(c echo.Context, status int, code errcode.Code) error {
+	lang := preferredLanguage(c.Request().Header.Get("Accept-Language"), defaultErrorLanguage)
+
+	return c.JSON(status, ResponseMsg{
+		Message:   errorMessage(code, lang),
+		ErrorCode: code,
+	})
+}
+
+// RespondSchemaViolation responds 400 with every field of the request which doesn't match the API document
+func RespondSchemaViolation(c echo.Context, details []FieldError) error {
+	lang := preferredLanguage(c.Request().Header.Get("Accept-Language"), defaultErrorLanguage)
+
+	return c.JSON(http.StatusBadRequest, ResponseMsg{
+		Message:   errorMessage(errcode.SchemaViolation, lang),
+		ErrorCode: errcode.SchemaViolation,
+		Details:   details,
+	})
+}
+
+// respondQueryError responds failed query, a query cancelled by request timeout is not an internal error
+func respondQueryError(c echo.Context) error {
+	if c.Request().Context().Err() != nil {
+		return respondError(c, http.StatusServiceUnavailable, errcode.RequestTimeout)
+	}
+
+	return respondError(c, http.StatusInternalServerError, errcode.Internal)
+}
+
+var statusErrorCodes = map[int]errcode.Code{
+	http.StatusBadRequest:            errcode.InvalidRequest,
+	http.StatusUnauthorized:          errcode.Unauthorized,
+	http.StatusForbidden:             errcode.Forbidden,
+	http.StatusNotFound:              errcode.NotFound,
+	http.StatusMethodNotAllowed:      errcode.MethodNotAllowed,
+	http.StatusRequestEntityTooLarge: errcode.PayloadTooLarge,
+	http.StatusUnsupportedMediaType:  errcode.UnsupportedMediaType,
+	http.StatusTooManyRequests:       errcode.TooManyRequests,
+	http.StatusServiceUnavailable:    errcode.ServiceUnavailable,
+}
+
+// HTTPErrorHandler responds errors returned from middlewares and router, e.g. basic auth, with error code
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	status := http.StatusInternalServerError
+
+	var he *echo.HTTPError
+	if errors.As(err, &he) {
+		status = he.Code
+	} else {
+		slog.ErrorContext(c.Request().Context(), "unhandled error", "error", err)
+	}
+
+	code, ok := statusErrorCodes[status]
+	if !ok {
+		status = http.StatusInternalServerError
+		code = errcode.Internal
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(status)
+	} else {
+		err = respondError(c, status, code)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to respond error", "error", err)
+	}
+}
+
+// ErrorCodes returns every code which has messages, sorted, e.g. for the error code enum of the API document
+func ErrorCodes() []errcode.Code {
+	codes := make([]errcode.Code, 0, len(errorMessages))
+	for code := range errorMessages {
+		codes = append(codes, code)
+	}
+
+	slices.Sort(codes)
+
+	return codes
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRespondError(t *testing.T) {
+	type TC struct {
+		acceptLanguage string
+		code           errcode.Code
+		want           ResponseMsg
+	}
+
+	tcs := []TC{
+		{
+			acceptLanguage: "",
+			code:           errcode.WhtExceedsIncome,
+			want:           ResponseMsg{Message: "Invalid wht", ErrorCode: errcode.WhtExceedsIncome},
+		},
+		{
+			acceptLanguage: "th-TH,th;q=0.9,en;q=0.8",
+			code:           errcode.WhtExceedsIncome,
+			want:           ResponseMsg{Message: "ภาษีหัก ณ ที่จ่ายต้องไม่มากกว่ารายได้", ErrorCode: errcode.WhtExceedsIncome},
+		},
+		{
+			acceptLanguage: "ja",
+			code:           errcode.CSVBadHeader,
+			want:           ResponseMsg{Message: "Wrong csv header", ErrorCode: errcode.CSVBadHeader},
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			req.Header.Set("Accept-Language", tc.acceptLanguage)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, respondError(e.NewContext(req, rec), http.StatusBadRequest, tc.code))
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+			var got ResponseMsg
+
+			err := json.Unmarshal([]byte(rec.Body.String()), &got)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestErrorMessagesAreTranslated(t *testing.T) {
+	for code, messages := range errorMessages {
+		for _, lang := range []string{"en", "th"} {
+			assert.NotEmpty(t, messages[lang], "missing %s message of %s", lang, code)
+		}
+	}
+}
+
+// every code declared in package errcode has messages, codes are read from its source so new ones can't be missed
+func TestEveryErrorCodeHasMessages(t *testing.T) {
+	f, err := parser.ParseFile(token.NewFileSet(), "../pkg/errcode/errcode.go", nil, 0)
+	assert.NoError(t, err)
+
+	var codes []errcode.Code
+
+	ast.Inspect(f, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok || len(spec.Values) != len(spec.Names) {
+			return true
+		}
+
+		if ident, ok := spec.Type.(*ast.Ident); !ok || ident.Name != "Code" {
+			return true
+		}
+
+		for _, v := range spec.Values {
+			lit, ok := v.(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				continue
+			}
+
+			code, err := strconv.Unquote(lit.Value)
+			assert.NoError(t, err)
+			codes = append(codes, errcode.Code(code))
+		}
+
+		return true
+	})
+
+	assert.NotEmpty(t, codes)
+
+	for _, code := range codes {
+		for _, lang := range []string{"en", "th"} {
+			assert.NotEmpty(t, errorMessages[code][lang], "missing %s message of %s", lang, code)
+		}
+	}
+}
+
+func TestHTTPErrorHandler(t *testing.T) {
+	type TC struct {
+		err        error
+		wantStatus int
+		want       ResponseMsg
+	}
+
+	tcs := []TC{
+		{
+			err:        echo.ErrUnauthorized,
+			wantStatus: http.StatusUnauthorized,
+			want:       ResponseMsg{Message: "Unauthorized", ErrorCode: errcode.Unauthorized},
+		},
+		{
+			err:        echo.ErrNotFound,
+			wantStatus: http.StatusNotFound,
+			want:       ResponseMsg{Message: "Not found", ErrorCode: errcode.NotFound},
+		},
+		{
+			err:        echo.NewHTTPError(http.StatusTeapot),
+			wantStatus: http.StatusInternalServerError,
+			want:       ResponseMsg{Message: "Internal server error", ErrorCode: errcode.Internal},
+		},
+		{
+			err:        errors.New("an error"),
+			wantStatus: http.StatusInternalServerError,
+			want:       ResponseMsg{Message: "Internal server error", ErrorCode: errcode.Internal},
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			HTTPErrorHandler(tc.err, e.NewContext(req, rec))
+
+			assert.Equal(t, tc.wantStatus, rec.Code)
+
+			var got ResponseMsg
+
+			err := json.Unmarshal([]byte(rec.Body.String()), &got)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
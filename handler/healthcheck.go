@@ -3,11 +3,20 @@ package handler
 import (
 	"net/http"
 
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
 	"github.com/labstack/echo/v4"
 )
 
 type ResponseMsg struct {
-	Message string `json:"message"`
+	Message   string       `json:"message"`
+	ErrorCode errcode.Code `json:"errorCode,omitempty"`
+	Details   []FieldError `json:"details,omitempty"`
+}
+
+// FieldError tells why a field of the request is invalid, Field is its JSON pointer, e.g. /allowances/0/amount
+type FieldError struct {
+	Field  string `json:"field"`
+	Reason string `json:"reason"`
 }
 
 func Healthcheck(c echo.Context) error {
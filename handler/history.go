@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+)
+
+type DeletionReceiptResponse struct {
+	ReceiptID   string    `json:"receiptId"`
+	APIKeyID    *int      `json:"apiKeyId,omitempty"`
+	UserID      *int      `json:"userId,omitempty"`
+	DeletedRows int64     `json:"deletedRows"`
+	DeletedAt   time.Time `json:"deletedAt"`
+}
+
+type CalculationResponse struct {
+	ID           int                `json:"id"`
+	TotalIncome  float64            `json:"totalIncome"`
+	Wht          float64            `json:"wht"`
+	Allowances   map[string]float64 `json:"allowances"`
+	Tax          float64            `json:"tax"`
+	TaxRefund    float64            `json:"taxRefund"`
+	CalculatedAt time.Time          `json:"calculatedAt"`
+}
+
+type HistoryIDB interface {
+	DeleteCalculations(ctx context.Context, apiKeyID int) (database.DeletionReceipt, error)
+	DeleteUserCalculations(ctx context.Context, userID int) (database.DeletionReceipt, error)
+	FindUserCalculations(ctx context.Context, userID int) ([]database.Calculation, error)
+}
+
+type HistoryHandler struct {
+	db HistoryIDB
+}
+
+func NewHistoryHandler(db HistoryIDB) *HistoryHandler {
+	return &HistoryHandler{db}
+}
+
+// GetHistory returns the latest calculations of the signed in taxpayer, anonymous calculations have no owner to list them
+func (h *HistoryHandler) GetHistory(c echo.Context) error {
+	userID := currentUserID(c)
+	if userID == nil {
+		return respondError(c, http.StatusUnauthorized, errcode.Unauthorized)
+	}
+
+	calcs, err := h.db.FindUserCalculations(c.Request().Context(), *userID)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find calculation history", "user_id", *userID, "error", err)
+		return respondQueryError(c)
+	}
+
+	results := []CalculationResponse{}
+
+	for _, calc := range calcs {
+		results = append(results, CalculationResponse{
+			ID:           calc.ID,
+			TotalIncome:  calc.TotalIncome,
+			Wht:          calc.Wht,
+			Allowances:   calc.Allowances,
+			Tax:          calc.Tax,
+			TaxRefund:    calc.TaxRefund,
+			CalculatedAt: calc.CalculatedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// DeleteHistory erases stored calculations and batch jobs to honor data-erasure requests under PDPA, ones of the signed in
+// taxpayer are erased when there is one, otherwise ones of the authenticated api key.
+// Calculations are written in batches, so ones made within the last flush interval may be stored afterwards.
+func (h *HistoryHandler) DeleteHistory(c echo.Context) error {
+	var (
+		receipt database.DeletionReceipt
+		err     error
+	)
+
+	if userID := currentUserID(c); userID != nil {
+		receipt, err = h.db.DeleteUserCalculations(c.Request().Context(), *userID)
+		if err != nil {
+			slog.ErrorContext(c.Request().Context(), "failed to delete calculation history", "user_id", *userID, "error", err)
+			return respondError(c, http.StatusInternalServerError, errcode.HistoryDeleteFailed)
+		}
+
+		slog.InfoContext(c.Request().Context(), "deleted calculation history",
+			"user_id", *userID, "receipt_id", receipt.ID, "rows", receipt.DeletedRows)
+	} else {
+		k, ok := CurrentAPIKey(c)
+		if !ok {
+			return respondError(c, http.StatusUnauthorized, errcode.APIKeyInvalid)
+		}
+
+		receipt, err = h.db.DeleteCalculations(c.Request().Context(), k.ID)
+		if err != nil {
+			slog.ErrorContext(c.Request().Context(), "failed to delete calculation history", "api_key_id", k.ID, "error", err)
+			return respondError(c, http.StatusInternalServerError, errcode.HistoryDeleteFailed)
+		}
+
+		slog.InfoContext(c.Request().Context(), "deleted calculation history",
+			"api_key_id", k.ID, "receipt_id", receipt.ID, "rows", receipt.DeletedRows)
+	}
+
+	return c.JSON(http.StatusOK, DeletionReceiptResponse{
+		ReceiptID:   receipt.ID,
+		APIKeyID:    receipt.APIKeyID,
+		UserID:      receipt.UserID,
+		DeletedRows: receipt.DeletedRows,
+		DeletedAt:   receipt.DeletedAt,
+	})
+}
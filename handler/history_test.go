@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type HistoryDBMock struct {
+	mock.Mock
+}
+
+func (o *HistoryDBMock) DeleteCalculations(ctx context.Context, apiKeyID int) (database.DeletionReceipt, error) {
+	args := o.Called(ctx, apiKeyID)
+	return args.Get(0).(database.DeletionReceipt), args.Error(1)
+}
+
+func (o *HistoryDBMock) DeleteUserCalculations(ctx context.Context, userID int) (database.DeletionReceipt, error) {
+	args := o.Called(ctx, userID)
+	return args.Get(0).(database.DeletionReceipt), args.Error(1)
+}
+
+func (o *HistoryDBMock) FindUserCalculations(ctx context.Context, userID int) ([]database.Calculation, error) {
+	args := o.Called(ctx, userID)
+	return args.Get(0).([]database.Calculation), args.Error(1)
+}
+
+func TestUserDeleteHistory(t *testing.T) {
+	deletedAt := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	keyID, userID := 7, 3
+
+	type TC struct {
+		key        *database.APIKey
+		userID     *int
+		mockDelete *MockSetting
+		mockUser   *MockSetting
+		wantCode   int
+		want       *DeletionReceiptResponse
+		wantErr    errcode.Code
+	}
+
+	tcs := []TC{
+		{
+			key: &database.APIKey{ID: 7},
+			mockDelete: &MockSetting{
+				Args:    []interface{}{mock.Anything, 7},
+				Returns: []interface{}{database.DeletionReceipt{ID: "r1", APIKeyID: &keyID, DeletedRows: 3, DeletedAt: deletedAt}, nil},
+			},
+			wantCode: http.StatusOK,
+			want:     &DeletionReceiptResponse{ReceiptID: "r1", APIKeyID: &keyID, DeletedRows: 3, DeletedAt: deletedAt},
+		},
+		{
+			key:    &database.APIKey{ID: 7},
+			userID: &userID,
+			mockUser: &MockSetting{
+				Args:    []interface{}{mock.Anything, 3},
+				Returns: []interface{}{database.DeletionReceipt{ID: "r2", UserID: &userID, DeletedRows: 2, DeletedAt: deletedAt}, nil},
+			},
+			wantCode: http.StatusOK,
+			want:     &DeletionReceiptResponse{ReceiptID: "r2", UserID: &userID, DeletedRows: 2, DeletedAt: deletedAt},
+		},
+		{
+			wantCode: http.StatusUnauthorized,
+			wantErr:  errcode.APIKeyInvalid,
+		},
+		{
+			key: &database.APIKey{ID: 7},
+			mockDelete: &MockSetting{
+				Args:    []interface{}{mock.Anything, 7},
+				Returns: []interface{}{database.DeletionReceipt{}, errors.New("an error")},
+			},
+			wantCode: http.StatusInternalServerError,
+			wantErr:  errcode.HistoryDeleteFailed,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(HistoryDBMock)
+
+			if tc.mockDelete != nil {
+				dbmock.On("DeleteCalculations", tc.mockDelete.Args...).Return(tc.mockDelete.Returns...)
+			}
+
+			if tc.mockUser != nil {
+				dbmock.On("DeleteUserCalculations", tc.mockUser.Args...).Return(tc.mockUser.Returns...)
+			}
+
+			req := httptest.NewRequest(http.MethodDelete, "/tax/calculations/history", nil)
+			rec := httptest.NewRecorder()
+
+			c := echo.New().NewContext(req, rec)
+			if tc.key != nil {
+				c.Set(apiKeyContextKey, *tc.key)
+			}
+
+			if tc.userID != nil {
+				c.Set(accountClaimsContextKey, *tc.userID)
+			}
+
+			assert.NoError(t, NewHistoryHandler(dbmock).DeleteHistory(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+			dbmock.AssertExpectations(t)
+
+			if tc.want == nil {
+				var got ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, tc.wantErr, got.ErrorCode)
+
+				return
+			}
+
+			var got DeletionReceiptResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, *tc.want, got)
+		})
+	}
+}
+
+func TestUserGetHistory(t *testing.T) {
+	calculatedAt := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	type TC struct {
+		userID   *int
+		wantCode int
+		want     []CalculationResponse
+	}
+
+	userID := 3
+
+	tcs := []TC{
+		{
+			userID:   &userID,
+			wantCode: http.StatusOK,
+			want: []CalculationResponse{
+				{ID: 1, TotalIncome: 500_000, Allowances: map[string]float64{"donation": 0}, Tax: 29_000, CalculatedAt: calculatedAt},
+			},
+		},
+		{wantCode: http.StatusUnauthorized},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(HistoryDBMock)
+			dbmock.On("FindUserCalculations", mock.Anything, userID).Return([]database.Calculation{
+				{ID: 1, TotalIncome: 500_000, Allowances: map[string]float64{"donation": 0}, Tax: 29_000, CalculatedAt: calculatedAt, UserID: &userID},
+			}, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/tax/calculations/history", nil)
+			rec := httptest.NewRecorder()
+
+			c := echo.New().NewContext(req, rec)
+			if tc.userID != nil {
+				c.Set(accountClaimsContextKey, *tc.userID)
+			}
+
+			assert.NoError(t, NewHistoryHandler(dbmock).GetHistory(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.want == nil {
+				return
+			}
+
+			var got []CalculationResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const defaultLanguage = "th"
+
+var supportedLanguages = map[string]bool{
+	"th": true,
+	"en": true,
+}
+
+// PreferredLanguage picks language like Accept-Language header does, th when none of it is supported
+func PreferredLanguage(header string) string {
+	return preferredLanguage(header, defaultLanguage)
+}
+
+// preferredLanguage picks the supported language with highest q value in Accept-Language header
+func preferredLanguage(header string, fallback string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+
+	var candidates []candidate
+
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+
+		// only primary subtag matters, e.g. en-US is en
+		lang := strings.ToLower(strings.SplitN(strings.TrimSpace(fields[0]), "-", 2)[0])
+		if !supportedLanguages[lang] {
+			continue
+		}
+
+		q := 1.0
+
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+
+			if v, ok := strings.CutPrefix(param, "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		if q > 0 {
+			candidates = append(candidates, candidate{lang, q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return fallback
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].q > candidates[j].q
+	})
+
+	return candidates[0].lang
+}
@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreferredLanguage(t *testing.T) {
+	type TC struct {
+		header string
+		want   string
+	}
+
+	tcs := []TC{
+		{header: "", want: "th"},
+		{header: "en", want: "en"},
+		{header: "en-US,en;q=0.9", want: "en"},
+		{header: "fr-FR,en;q=0.5,th;q=0.8", want: "th"},
+		{header: "fr,de", want: "th"},
+		{header: "th;q=0,en;q=0.1", want: "en"},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.header, func(t *testing.T) {
+			assert.Equal(t, tc.want, preferredLanguage(tc.header, "th"))
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+)
+
+type MaintenanceRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+type MaintenanceResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// Maintenance is read-only mode used during database migrations, admin writes are rejected
+// and calculations fall back to the last loaded allowances when database can't be read
+type Maintenance struct {
+	enabled  atomic.Bool
+	onChange func(ctx context.Context, enabled bool)
+
+	mu         sync.RWMutex
+	allowances *allowanceSettings
+}
+
+func NewMaintenance(enabled bool) *Maintenance {
+	m := &Maintenance{}
+	m.enabled.Store(enabled)
+
+	return m
+}
+
+// SetOnChange sets what's called when admin turns maintenance mode on or off, e.g. telling other replicas
+func (m *Maintenance) SetOnChange(onChange func(ctx context.Context, enabled bool)) *Maintenance {
+	m.onChange = onChange
+	return m
+}
+
+// Set turns maintenance mode on or off without calling onChange, e.g. when another replica did
+func (m *Maintenance) Set(enabled bool) {
+	if m.enabled.Swap(enabled) != enabled {
+		slog.Info("maintenance mode changed", "enabled", enabled)
+	}
+}
+
+func (m *Maintenance) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// remember keeps allowances loaded by a calculation
+func (m *Maintenance) remember(allowances allowanceSettings) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	clone := allowances.clone()
+	m.allowances = &clone
+}
+
+// lastAllowances returns allowances remembered before database became unavailable
+func (m *Maintenance) lastAllowances() (allowanceSettings, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.allowances == nil {
+		return allowanceSettings{}, false
+	}
+
+	return m.allowances.clone(), true
+}
+
+// ReadOnly rejects requests which may change data while maintenance is enabled,
+// paths in skip are still writable, e.g. the endpoint turning maintenance off
+func (m *Maintenance) ReadOnly(skip ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			switch c.Request().Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				return next(c)
+			}
+
+			if !m.Enabled() {
+				return next(c)
+			}
+
+			for _, path := range skip {
+				if c.Path() == path {
+					return next(c)
+				}
+			}
+
+			return respondError(c, http.StatusServiceUnavailable, errcode.MaintenanceMode)
+		}
+	}
+}
+
+func (m *Maintenance) GetMaintenance(c echo.Context) error {
+	return c.JSON(http.StatusOK, MaintenanceResponse{Enabled: m.Enabled()})
+}
+
+func (m *Maintenance) SetMaintenance(c echo.Context) error {
+	var req MaintenanceRequest
+
+	if err := c.Bind(&req); err != nil || req.Enabled == nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	m.enabled.Store(*req.Enabled)
+
+	slog.InfoContext(c.Request().Context(), "maintenance mode changed", "enabled", *req.Enabled)
+
+	if m.onChange != nil {
+		m.onChange(c.Request().Context(), *req.Enabled)
+	}
+
+	return c.JSON(http.StatusOK, MaintenanceResponse{Enabled: *req.Enabled})
+}
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMaintenanceReadOnly(t *testing.T) {
+	type TC struct {
+		enabled  bool
+		method   string
+		path     string
+		wantCode int
+	}
+
+	tcs := []TC{
+		{enabled: false, method: http.MethodPost, path: "/admin/deductions/personal", wantCode: http.StatusOK},
+		{enabled: true, method: http.MethodPost, path: "/admin/deductions/personal", wantCode: http.StatusServiceUnavailable},
+		{enabled: true, method: http.MethodDelete, path: "/admin/webhooks/:id", wantCode: http.StatusServiceUnavailable},
+		{enabled: true, method: http.MethodGet, path: "/admin/deductions", wantCode: http.StatusOK},
+		{enabled: true, method: http.MethodPut, path: "/admin/maintenance", wantCode: http.StatusOK},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			m := NewMaintenance(tc.enabled)
+
+			req := httptest.NewRequest(tc.method, "/", nil)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetPath(tc.path)
+
+			err := m.ReadOnly("/admin/maintenance")(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})(c)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode == http.StatusServiceUnavailable {
+				var got ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, errcode.MaintenanceMode, got.ErrorCode)
+			}
+		})
+	}
+}
+
+func TestSetMaintenance(t *testing.T) {
+	var changes []bool
+
+	m := NewMaintenance(false).SetOnChange(func(ctx context.Context, enabled bool) {
+		changes = append(changes, enabled)
+	})
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/maintenance", strings.NewReader(`{"enabled":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	assert.NoError(t, m.SetMaintenance(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, m.Enabled())
+
+	req = httptest.NewRequest(http.MethodPut, "/admin/maintenance", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+
+	assert.NoError(t, m.SetMaintenance(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.True(t, m.Enabled())
+	assert.Equal(t, []bool{true}, changes, "changes are told to other replicas")
+
+	m.Set(false)
+	assert.False(t, m.Enabled())
+	assert.Equal(t, []bool{true}, changes, "changes of other replicas aren't told again")
+}
+
+func TestUserCalculateTaxDuringMaintenance(t *testing.T) {
+	m := NewMaintenance(false)
+
+	calculate := func(mockObj *UserDBMock) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tax/calculations", strings.NewReader(`{"totalIncome":500000,"wht":0,"allowances":[]}`))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+
+		e := echo.New()
+
+		assert.NoError(t, NewTaxHandler(validator.New(), mockObj).SetMaintenance(m).CalculateTax(e.NewContext(req, rec)))
+
+		return rec
+	}
+
+	available := new(UserDBMock)
+	available.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+		{AllowanceType: "personal", Amount: 60_000},
+	}, nil)
+	available.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{}, nil)
+
+	unavailable := new(UserDBMock)
+	unavailable.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{}, errors.New("an error"))
+
+	assert.Equal(t, http.StatusOK, calculate(available).Code)
+
+	// database errors are not hidden outside maintenance
+	assert.Equal(t, http.StatusInternalServerError, calculate(unavailable).Code)
+
+	m.enabled.Store(true)
+
+	rec := calculate(unavailable)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var got TaxResponse
+
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, float64(29_000), got.Tax)
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+)
+
+// NotFound replaces echo.NotFoundHandler for unknown routes
+func NotFound(c echo.Context) error {
+	return respondError(c, http.StatusNotFound, errcode.NotFound)
+}
+
+// MethodNotAllowed replaces echo.MethodNotAllowedHandler, Allow header lists methods of the matched route
+func MethodNotAllowed(c echo.Context) error {
+	if allow, ok := c.Get(echo.ContextKeyHeaderAllow).(string); ok && allow != "" {
+		c.Response().Header().Set(echo.HeaderAllow, allow)
+	}
+
+	return respondError(c, http.StatusMethodNotAllowed, errcode.MethodNotAllowed)
+}
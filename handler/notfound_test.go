@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotFoundAndMethodNotAllowed(t *testing.T) {
+	notFound, methodNotAllowed := echo.NotFoundHandler, echo.MethodNotAllowedHandler
+	defer func() {
+		echo.NotFoundHandler, echo.MethodNotAllowedHandler = notFound, methodNotAllowed
+	}()
+
+	echo.NotFoundHandler = NotFound
+	echo.MethodNotAllowedHandler = MethodNotAllowed
+
+	e := echo.New()
+	e.POST("/tax/calculations", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	type TC struct {
+		method     string
+		path       string
+		wantStatus int
+		wantAllow  string
+		want       ResponseMsg
+	}
+
+	tcs := []TC{
+		{
+			method:     http.MethodGet,
+			path:       "/unknown",
+			wantStatus: http.StatusNotFound,
+			wantAllow:  "",
+			want:       ResponseMsg{Message: "Not found", ErrorCode: errcode.NotFound},
+		},
+		{
+			method:     http.MethodGet,
+			path:       "/tax/calculations",
+			wantStatus: http.StatusMethodNotAllowed,
+			wantAllow:  "OPTIONS, POST",
+			want:       ResponseMsg{Message: "Method not allowed", ErrorCode: errcode.MethodNotAllowed},
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantStatus, rec.Code)
+			assert.Equal(t, tc.wantAllow, rec.Header().Get(echo.HeaderAllow))
+			assert.Contains(t, rec.Header().Get(echo.HeaderContentType), echo.MIMEApplicationJSON)
+
+			var got ResponseMsg
+
+			err := json.Unmarshal([]byte(rec.Body.String()), &got)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
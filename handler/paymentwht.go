@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/AnnaCarter465/assessment-tax/tax/withholding"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// PaymentWhtRequest is payments to one payee on one date, amounts are excluding VAT
+type PaymentWhtRequest struct {
+	Payer    WhtParty            `json:"payer"`
+	Payee    WhtPayee            `json:"payee"`
+	PaidOn   string              `json:"paidOn" validate:"required,datetime=2006-01-02"`
+	Payments []WhtPaymentRequest `json:"payments" validate:"required,min=1,max=100,dive"`
+}
+
+type WhtParty struct {
+	Name    string `json:"name" validate:"required,max=200"`
+	TaxID   string `json:"taxId" validate:"required,len=13,numeric"`
+	Address string `json:"address" validate:"max=500"`
+}
+
+type WhtPayee struct {
+	Name    string `json:"name" validate:"required,max=200"`
+	TaxID   string `json:"taxId" validate:"required,len=13,numeric"`
+	Address string `json:"address" validate:"max=500"`
+	Type    string `json:"type" validate:"required,oneof=individual juristic"`
+}
+
+type WhtPaymentRequest struct {
+	PaymentType string  `json:"paymentType" validate:"required,oneof=service professional contract rent advertising transport prize"`
+	Description string  `json:"description" validate:"max=200"`
+	Amount      float64 `json:"amount" validate:"number,gte=0"`
+}
+
+// WhtCertificateResponse is the data of the wht certificate the payer issues to the payee, Form is the return
+// the wht is filed with
+type WhtCertificateResponse struct {
+	Form        string                       `json:"form"`
+	Payer       WhtParty                     `json:"payer"`
+	Payee       WhtParty                     `json:"payee"`
+	PaidOn      string                       `json:"paidOn"`
+	Items       []WhtCertificateItemResponse `json:"items"`
+	TotalAmount float64                      `json:"totalAmount"`
+	TotalWht    float64                      `json:"totalWht"`
+}
+
+// WhtCertificateItemResponse is wht of a payment, payments below 1,000 baht have 0 wht
+type WhtCertificateItemResponse struct {
+	PaymentType string  `json:"paymentType"`
+	Description string  `json:"description,omitempty"`
+	Rate        float64 `json:"rate"`
+	Amount      float64 `json:"amount"`
+	Wht         float64 `json:"wht"`
+}
+
+// PaymentWhtHandler calculates wht of payments to payees by statutory rates, which are compiled in
+type PaymentWhtHandler struct {
+	vl *validator.Validate
+}
+
+func NewPaymentWhtHandler(vl *validator.Validate) *PaymentWhtHandler {
+	return &PaymentWhtHandler{vl}
+}
+
+func (h *PaymentWhtHandler) CalculatePaymentWht(c echo.Context) error {
+	var req PaymentWhtRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	resp := WhtCertificateResponse{
+		Form:   withholding.FormOf(req.Payee.Type),
+		Payer:  req.Payer,
+		Payee:  WhtParty{Name: req.Payee.Name, TaxID: req.Payee.TaxID, Address: req.Payee.Address},
+		PaidOn: req.PaidOn,
+		Items:  make([]WhtCertificateItemResponse, 0, len(req.Payments)),
+	}
+
+	for _, p := range req.Payments {
+		rate, ok := withholding.Rate(p.PaymentType)
+		if !ok {
+			return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+		}
+
+		item := WhtCertificateItemResponse{
+			PaymentType: p.PaymentType,
+			Description: p.Description,
+			Rate:        rate,
+			Amount:      p.Amount,
+			Wht:         withholding.Withhold(p.PaymentType, p.Amount),
+		}
+
+		resp.Items = append(resp.Items, item)
+		resp.TotalAmount += item.Amount
+		resp.TotalWht += item.Wht
+	}
+
+	resp.TotalAmount = roundAmount(resp.TotalAmount, PrecisionDecimal)
+	resp.TotalWht = roundAmount(resp.TotalWht, PrecisionDecimal)
+
+	return c.JSON(http.StatusOK, resp)
+}
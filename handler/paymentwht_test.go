@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculatePaymentWht(t *testing.T) {
+	payer := `"payer":{"name":"K-Tax Co., Ltd.","taxId":"0105555000001","address":"Bangkok"}`
+
+	type TC struct {
+		reqbody  string
+		wantCode int
+		want     WhtCertificateResponse
+	}
+
+	tcs := []TC{
+		{
+			reqbody: `{` + payer + `,"payee":{"name":"Somchai","taxId":"1100100000001","type":"individual"},"paidOn":"2024-06-30",
+				"payments":[{"paymentType":"professional","description":"audit fee","amount":20000},{"paymentType":"rent","amount":500}]}`,
+			wantCode: http.StatusOK,
+			want: WhtCertificateResponse{
+				Form:   "PND3",
+				Payer:  WhtParty{Name: "K-Tax Co., Ltd.", TaxID: "0105555000001", Address: "Bangkok"},
+				Payee:  WhtParty{Name: "Somchai", TaxID: "1100100000001"},
+				PaidOn: "2024-06-30",
+				Items: []WhtCertificateItemResponse{
+					{PaymentType: "professional", Description: "audit fee", Rate: 0.03, Amount: 20_000, Wht: 600},
+					{PaymentType: "rent", Rate: 0.05, Amount: 500, Wht: 0},
+				},
+				TotalAmount: 20_500,
+				TotalWht:    600,
+			},
+		},
+		{
+			reqbody: `{` + payer + `,"payee":{"name":"Cleaning Co., Ltd.","taxId":"0105555000002","type":"juristic"},"paidOn":"2024-06-30",
+				"payments":[{"paymentType":"service","amount":10000}]}`,
+			wantCode: http.StatusOK,
+			want: WhtCertificateResponse{
+				Form:        "PND53",
+				Payer:       WhtParty{Name: "K-Tax Co., Ltd.", TaxID: "0105555000001", Address: "Bangkok"},
+				Payee:       WhtParty{Name: "Cleaning Co., Ltd.", TaxID: "0105555000002"},
+				PaidOn:      "2024-06-30",
+				Items:       []WhtCertificateItemResponse{{PaymentType: "service", Rate: 0.03, Amount: 10_000, Wht: 300}},
+				TotalAmount: 10_000,
+				TotalWht:    300,
+			},
+		},
+		// tax id must have 13 digits
+		{
+			reqbody: `{` + payer + `,"payee":{"name":"Somchai","taxId":"12345","type":"individual"},"paidOn":"2024-06-30",
+				"payments":[{"paymentType":"service","amount":10000}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			reqbody: `{` + payer + `,"payee":{"name":"Somchai","taxId":"1100100000001","type":"individual"},"paidOn":"2024-06-30",
+				"payments":[{"paymentType":"salary","amount":10000}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			reqbody: `{"payee":{"name":"Somchai","taxId":"1100100000001","type":"individual"},"paidOn":"2024-06-30",
+				"payments":[{"paymentType":"service","amount":10000}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tax/payment-withholdings", strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			assert.NoError(t, NewPaymentWhtHandler(validator.New()).CalculatePaymentWht(echo.New().NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			var got WhtCertificateResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
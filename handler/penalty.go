@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/AnnaCarter465/assessment-tax/tax/penalty"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// PenaltyRequest is tax of query param `taxYear` which is paid on PaidOn
+type PenaltyRequest struct {
+	TaxDue float64 `json:"taxDue" validate:"number,gt=0"`
+	PaidOn string  `json:"paidOn" validate:"required,datetime=2006-01-02"`
+}
+
+type PenaltyResponse struct {
+	TaxYear        int     `json:"taxYear"`
+	FilingDeadline string  `json:"filingDeadline"`
+	PaidOn         string  `json:"paidOn"`
+	MonthsLate     int     `json:"monthsLate"`
+	SurchargeRate  float64 `json:"surchargeRate"`
+	Surcharge      float64 `json:"surcharge"`
+	Total          float64 `json:"total"`
+}
+
+// TaxCalendarFinder finds calendar of a tax year managed by admin
+type TaxCalendarFinder interface {
+	FindTaxCalendar(ctx context.Context, taxYear int) (database.TaxCalendar, error)
+}
+
+// PenaltyHandler calculates surcharges of tax paid after the filing deadline in the calendar of its tax year
+type PenaltyHandler struct {
+	vl       *validator.Validate
+	calendar TaxCalendarFinder
+}
+
+func NewPenaltyHandler(vl *validator.Validate, calendar TaxCalendarFinder) *PenaltyHandler {
+	return &PenaltyHandler{vl, calendar}
+}
+
+func (h *PenaltyHandler) CalculatePenalty(c echo.Context) error {
+	taxYear, ok := getTaxYear(c)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.TaxYearUnsupported)
+	}
+
+	var req PenaltyRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	paidOn, err := time.Parse(dateLayout, req.PaidOn)
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidDate)
+	}
+
+	cal, err := h.calendar.FindTaxCalendar(c.Request().Context(), taxYear)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.CalendarNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find tax calendar", "error", err)
+		return respondQueryError(c)
+	}
+
+	surcharge := penalty.Calculate(req.TaxDue, cal.FilingDeadline, paidOn)
+
+	return c.JSON(http.StatusOK, PenaltyResponse{
+		TaxYear:        taxYear,
+		FilingDeadline: cal.FilingDeadline.Format(dateLayout),
+		PaidOn:         req.PaidOn,
+		MonthsLate:     surcharge.MonthsLate,
+		SurchargeRate:  penalty.SurchargeRate,
+		Surcharge:      surcharge.Amount,
+		Total:          req.TaxDue + surcharge.Amount,
+	})
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCalculatePenalty(t *testing.T) {
+	type TC struct {
+		target   string
+		reqbody  string
+		setup    func(calendar *CalendarDBMock)
+		wantCode int
+		want     PenaltyResponse
+		wantErr  errcode.Code
+	}
+
+	deadline := time.Date(2025, time.April, 8, 0, 0, 0, 0, time.UTC)
+
+	found := func(calendar *CalendarDBMock) {
+		calendar.On("FindTaxCalendar", mock.Anything, 2024).Return(database.TaxCalendar{TaxYear: 2024, FilingDeadline: deadline}, nil)
+	}
+
+	tcs := []TC{
+		{
+			target:   "/tax/penalties",
+			reqbody:  `{"taxDue":10000,"paidOn":"2025-05-20"}`,
+			setup:    found,
+			wantCode: http.StatusOK,
+			want: PenaltyResponse{TaxYear: 2024, FilingDeadline: "2025-04-08", PaidOn: "2025-05-20", MonthsLate: 2,
+				SurchargeRate: 0.015, Surcharge: 300, Total: 10_300},
+		},
+		{
+			target:   "/tax/penalties?taxYear=2567",
+			reqbody:  `{"taxDue":10000,"paidOn":"2025-04-01"}`,
+			setup:    found,
+			wantCode: http.StatusOK,
+			want: PenaltyResponse{TaxYear: 2024, FilingDeadline: "2025-04-08", PaidOn: "2025-04-01",
+				SurchargeRate: 0.015, Total: 10_000},
+		},
+		{
+			target:  "/tax/penalties?taxYear=2023",
+			reqbody: `{"taxDue":10000,"paidOn":"2024-05-20"}`,
+			setup: func(calendar *CalendarDBMock) {
+				calendar.On("FindTaxCalendar", mock.Anything, 2023).Return(database.TaxCalendar{}, database.ErrNotFound)
+			},
+			wantCode: http.StatusNotFound,
+			wantErr:  errcode.CalendarNotFound,
+		},
+		{
+			target:  "/tax/penalties",
+			reqbody: `{"taxDue":10000,"paidOn":"2025-05-20"}`,
+			setup: func(calendar *CalendarDBMock) {
+				calendar.On("FindTaxCalendar", mock.Anything, 2024).Return(database.TaxCalendar{}, errors.New("connection refused"))
+			},
+			wantCode: http.StatusInternalServerError,
+			wantErr:  errcode.Internal,
+		},
+		{
+			target:   "/tax/penalties",
+			reqbody:  `{"taxDue":0,"paidOn":"2025-05-20"}`,
+			setup:    func(*CalendarDBMock) {},
+			wantCode: http.StatusBadRequest,
+			wantErr:  errcode.InvalidRequest,
+		},
+		{
+			target:   "/tax/penalties",
+			reqbody:  `{"taxDue":10000,"paidOn":"20/05/2025"}`,
+			setup:    func(*CalendarDBMock) {},
+			wantCode: http.StatusBadRequest,
+			wantErr:  errcode.InvalidRequest,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tc.target, strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			calendar := new(CalendarDBMock)
+			tc.setup(calendar)
+
+			h := NewPenaltyHandler(validator.New(), calendar)
+
+			assert.NoError(t, h.CalculatePenalty(echo.New().NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantErr != "" {
+				var got ResponseMsg
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, tc.wantErr, got.ErrorCode)
+
+				return
+			}
+
+			var got PenaltyResponse
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"math"
+
+	"github.com/labstack/echo/v4"
+)
+
+// precisions of monetary amounts in responses
+const (
+	PrecisionRaw     = "raw"     // amounts as calculated
+	PrecisionBaht    = "baht"    // rounded to whole baht
+	PrecisionDecimal = "decimal" // rounded to two decimals
+	PrecisionSatang  = "satang"  // fractions of a satang are dropped, like the Revenue Department does
+)
+
+// satangEpsilon keeps float error, e.g. 0.29*100 is 28.999999999999996, from dropping a whole satang
+const satangEpsilon = 1e-6
+
+// getPrecision returns precision of query param `precision`, or the configured one when it isn't set
+func getPrecision(c echo.Context, configured func() string) (string, bool) {
+	v := c.QueryParam("precision")
+	if v == "" {
+		if configured == nil {
+			return PrecisionRaw, true
+		}
+
+		v = configured()
+	}
+
+	switch v {
+	case PrecisionRaw, PrecisionBaht, PrecisionDecimal, PrecisionSatang:
+		return v, true
+	default:
+		return "", false
+	}
+}
+
+// roundAmount formats amount in precision, amounts are left as they are in raw precision
+func roundAmount(amount float64, precision string) float64 {
+	switch precision {
+	case PrecisionBaht:
+		return math.Round(amount)
+	case PrecisionDecimal:
+		return math.Round(amount*100) / 100
+	case PrecisionSatang:
+		return math.Trunc(amount*100+math.Copysign(satangEpsilon, amount)) / 100
+	default:
+		return amount
+	}
+}
+
+func roundTaxLevels(levels []TaxLevel, precision string) {
+	for i := range levels {
+		levels[i].Tax = roundAmount(levels[i].Tax, precision)
+	}
+}
+
+// round formats every amount of the response in precision
+func (r *TaxResponse) round(precision string) {
+	r.Tax = roundAmount(r.Tax, precision)
+	r.TaxRefund = roundAmount(r.TaxRefund, precision)
+	roundTaxLevels(r.TaxLevel, precision)
+
+	for i := range r.AllowanceSavings {
+		s := &r.AllowanceSavings[i]
+		s.Tax = roundAmount(s.Tax, precision)
+		s.TaxRefund = roundAmount(s.TaxRefund, precision)
+		s.Saving = roundAmount(s.Saving, precision)
+	}
+}
+
+// round formats every amount of the response in precision
+func (r *TaxCSVResponse) round(precision string) {
+	for i := range r.Taxes {
+		r.Taxes[i].TotalIncome = roundAmount(r.Taxes[i].TotalIncome, precision)
+		r.Taxes[i].Tax = roundAmount(r.Taxes[i].Tax, precision)
+	}
+}
+
+// round formats every amount of the response in precision
+func (r *ProjectionResponse) round(precision string) {
+	r.ProjectedIncome = roundAmount(r.ProjectedIncome, precision)
+	r.ProjectedWht = roundAmount(r.ProjectedWht, precision)
+	r.Tax = roundAmount(r.Tax, precision)
+	r.TaxRefund = roundAmount(r.TaxRefund, precision)
+	r.TaxShortfall = roundAmount(r.TaxShortfall, precision)
+	r.MonthlyWht = roundAmount(r.MonthlyWht, precision)
+	roundTaxLevels(r.TaxLevel, precision)
+}
+
+// round formats every amount of the response in precision
+func (r *WithholdingResponse) round(precision string) {
+	r.AnnualIncome = roundAmount(r.AnnualIncome, precision)
+	r.AnnualTax = roundAmount(r.AnnualTax, precision)
+	r.CumulativeTax = roundAmount(r.CumulativeTax, precision)
+	r.BonusWht = roundAmount(r.BonusWht, precision)
+	r.Wht = roundAmount(r.Wht, precision)
+}
+
+// round formats every amount of the response in precision
+func (r *CorporateTaxResponse) round(precision string) {
+	r.Tax = roundAmount(r.Tax, precision)
+	r.TaxRefund = roundAmount(r.TaxRefund, precision)
+	roundTaxLevels(r.TaxLevel, precision)
+}
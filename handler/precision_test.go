@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRoundAmount(t *testing.T) {
+	type TC struct {
+		amount    float64
+		precision string
+		want      float64
+	}
+
+	tcs := []TC{
+		{amount: 1234.5678, precision: PrecisionRaw, want: 1234.5678},
+		{amount: 1234.5678, precision: PrecisionBaht, want: 1235},
+		{amount: 1234.4999, precision: PrecisionBaht, want: 1234},
+		{amount: 1234.5678, precision: PrecisionDecimal, want: 1234.57},
+		{amount: 1234.5678, precision: PrecisionSatang, want: 1234.56},
+		{amount: 0.29, precision: PrecisionSatang, want: 0.29},
+		{amount: 0, precision: PrecisionSatang, want: 0},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			assert.Equal(t, tc.want, roundAmount(tc.amount, tc.precision))
+		})
+	}
+}
+
+func TestUserCalculateTaxPrecision(t *testing.T) {
+	type TC struct {
+		configured string
+		query      string
+		wantCode   int
+		want       float64
+	}
+
+	tcs := []TC{
+		{configured: "", query: "", wantCode: http.StatusOK, want: 29_000.0555},
+		{configured: PrecisionBaht, query: "", wantCode: http.StatusOK, want: 29_000},
+		{configured: PrecisionBaht, query: "?precision=decimal", wantCode: http.StatusOK, want: 29_000.06},
+		{configured: PrecisionRaw, query: "?precision=satang", wantCode: http.StatusOK, want: 29_000.05},
+		{configured: PrecisionRaw, query: "?precision=cents", wantCode: http.StatusBadRequest},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(UserDBMock)
+			mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{}, nil)
+
+			h := NewTaxHandler(validator.New(), mockObj)
+			if tc.configured != "" {
+				h.SetPrecision(func() string { return tc.configured })
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations"+tc.query, strings.NewReader(
+				`{"totalIncome":500000.555,"wht":0,"allowances":[]}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			assert.NoError(t, h.CalculateTax(echo.New().NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			var got TaxResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.InDelta(t, tc.want, got.Tax, 1e-9)
+
+			var levels float64
+			for _, l := range got.TaxLevel {
+				levels += l.Tax
+			}
+
+			assert.InDelta(t, tc.want, levels, 1e-9)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	MaritalStatusSingle  = "single"
+	MaritalStatusMarried = "married"
+)
+
+// allowances claimed for family of a profile, they take effect only when admins allow "spouse" and "child" types,
+// which also cap them
+const (
+	spouseAllowance = 60_000.0
+	childAllowance  = 30_000.0
+)
+
+type ProfileRequest struct {
+	MaritalStatus string      `json:"maritalStatus" validate:"required,oneof=single married"`
+	Children      int         `json:"children" validate:"gte=0,lte=20"`
+	Allowances    []Allowance `json:"allowances" validate:"dive"`
+}
+
+type ProfileResponse struct {
+	MaritalStatus string      `json:"maritalStatus"`
+	Children      int         `json:"children"`
+	Allowances    []Allowance `json:"allowances"`
+	CreatedAt     time.Time   `json:"createdAt"`
+	UpdatedAt     time.Time   `json:"updatedAt"`
+}
+
+// ProfileReader finds profiles merged into calculations of signed in taxpayers
+type ProfileReader interface {
+	FindUserProfile(ctx context.Context, userID int) (database.UserProfile, error)
+}
+
+type ProfileIDB interface {
+	FindUserProfile(ctx context.Context, userID int) (database.UserProfile, error)
+	UpsertUserProfile(ctx context.Context, p database.UserProfile) (database.UserProfile, error)
+	DeleteUserProfile(ctx context.Context, userID int) error
+}
+
+// ProfileHandler manages profile of the signed in taxpayer, routes must be used after RequireAccount
+type ProfileHandler struct {
+	vl *validator.Validate
+	db ProfileIDB
+}
+
+func NewProfileHandler(vl *validator.Validate, db ProfileIDB) *ProfileHandler {
+	return &ProfileHandler{vl, db}
+}
+
+func toProfileResponse(p database.UserProfile) ProfileResponse {
+	return ProfileResponse{
+		MaritalStatus: p.MaritalStatus,
+		Children:      p.Children,
+		Allowances:    sortedAllowances(p.Allowances),
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+	}
+}
+
+// sortedAllowances orders allowances by type, so responses and calculations are stable
+func sortedAllowances(amounts map[string]float64) []Allowance {
+	allowances := []Allowance{}
+
+	for allowanceType, amount := range amounts {
+		allowances = append(allowances, Allowance{AllowanceType: allowanceType, Amount: amount})
+	}
+
+	sort.Slice(allowances, func(i, j int) bool { return allowances[i].AllowanceType < allowances[j].AllowanceType })
+
+	return allowances
+}
+
+func (h *ProfileHandler) GetProfile(c echo.Context) error {
+	userID := currentUserID(c)
+	if userID == nil {
+		return respondError(c, http.StatusUnauthorized, errcode.Unauthorized)
+	}
+
+	p, err := h.db.FindUserProfile(c.Request().Context(), *userID)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.ProfileNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find profile", "user_id", *userID, "error", err)
+		return respondQueryError(c)
+	}
+
+	return c.JSON(http.StatusOK, toProfileResponse(p))
+}
+
+// PutProfile creates or replaces profile, an allowance type listed twice is claimed with the sum of its amounts
+func (h *ProfileHandler) PutProfile(c echo.Context) error {
+	userID := currentUserID(c)
+	if userID == nil {
+		return respondError(c, http.StatusUnauthorized, errcode.Unauthorized)
+	}
+
+	var req ProfileRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	allowances := map[string]float64{}
+	for _, a := range req.Allowances {
+		allowances[a.AllowanceType] += a.Amount
+	}
+
+	p, err := h.db.UpsertUserProfile(c.Request().Context(), database.UserProfile{
+		UserID:        *userID,
+		MaritalStatus: req.MaritalStatus,
+		Children:      req.Children,
+		Allowances:    allowances,
+	})
+	// the account was deleted after its token was issued
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusUnauthorized, errcode.Unauthorized)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to save profile", "user_id", *userID, "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.JSON(http.StatusOK, toProfileResponse(p))
+}
+
+func (h *ProfileHandler) DeleteProfile(c echo.Context) error {
+	userID := currentUserID(c)
+	if userID == nil {
+		return respondError(c, http.StatusUnauthorized, errcode.Unauthorized)
+	}
+
+	err := h.db.DeleteUserProfile(c.Request().Context(), *userID)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.ProfileNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to delete profile", "user_id", *userID, "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// findProfileAllowances returns allowances of the taxpayer's profile which claimed doesn't claim,
+// allowances of the request take precedence. Nothing is returned when the taxpayer has no profile
+func findProfileAllowances(ctx context.Context, profiles ProfileReader, userID *int, claimed []Allowance) ([]Allowance, error) {
+	if profiles == nil || userID == nil {
+		return nil, nil
+	}
+
+	p, err := profiles.FindUserProfile(ctx, *userID)
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find profile", "user_id", *userID, "error", err)
+		return nil, err
+	}
+
+	// family allowances listed in the profile are claimed with the listed amounts
+	amounts := maps.Clone(p.Allowances)
+	if amounts == nil {
+		amounts = map[string]float64{}
+	}
+
+	if _, ok := amounts["spouse"]; !ok && p.MaritalStatus == MaritalStatusMarried {
+		amounts["spouse"] = spouseAllowance
+	}
+
+	if _, ok := amounts["child"]; !ok && p.Children > 0 {
+		amounts["child"] = childAllowance * float64(p.Children)
+	}
+
+	return slices.DeleteFunc(sortedAllowances(amounts), func(a Allowance) bool {
+		return slices.ContainsFunc(claimed, func(c Allowance) bool { return c.AllowanceType == a.AllowanceType })
+	}), nil
+}
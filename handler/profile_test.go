@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type ProfileDBMock struct {
+	mock.Mock
+}
+
+func (o *ProfileDBMock) FindUserProfile(ctx context.Context, userID int) (database.UserProfile, error) {
+	args := o.Called(ctx, userID)
+	return args.Get(0).(database.UserProfile), args.Error(1)
+}
+
+func (o *ProfileDBMock) UpsertUserProfile(ctx context.Context, p database.UserProfile) (database.UserProfile, error) {
+	args := o.Called(ctx, p)
+	return args.Get(0).(database.UserProfile), args.Error(1)
+}
+
+func (o *ProfileDBMock) DeleteUserProfile(ctx context.Context, userID int) error {
+	args := o.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func TestProfilePutProfile(t *testing.T) {
+	type TC struct {
+		reqbody     string
+		wantProfile database.UserProfile
+		wantCode    int
+		wantError   errcode.Code
+		want        []Allowance
+	}
+
+	tcs := []TC{
+		{
+			reqbody: `{"maritalStatus":"married","children":2,"allowances":[{"allowanceType":"k-receipt","amount":20000},{"allowanceType":"donation","amount":1000},{"allowanceType":"donation","amount":2000}]}`,
+			wantProfile: database.UserProfile{
+				UserID:        3,
+				MaritalStatus: MaritalStatusMarried,
+				Children:      2,
+				Allowances:    map[string]float64{"donation": 3_000, "k-receipt": 20_000},
+			},
+			wantCode: http.StatusOK,
+			want:     []Allowance{{AllowanceType: "donation", Amount: 3_000}, {AllowanceType: "k-receipt", Amount: 20_000}},
+		},
+		{
+			reqbody:   `{"maritalStatus":"divorced"}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: errcode.InvalidRequest,
+		},
+		{
+			reqbody:   `{"maritalStatus":"single","children":-1}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: errcode.InvalidRequest,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(ProfileDBMock)
+			mockObj.On("UpsertUserProfile", mock.Anything, tc.wantProfile).Return(tc.wantProfile, nil)
+
+			req := httptest.NewRequest(http.MethodPut, "/users/me/profile", strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			c := echo.New().NewContext(req, rec)
+			c.Set(accountClaimsContextKey, 3)
+
+			assert.NoError(t, NewProfileHandler(validator.New(), mockObj).PutProfile(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				var got ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, tc.wantError, got.ErrorCode)
+
+				return
+			}
+
+			var got ProfileResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tc.want, got.Allowances)
+		})
+	}
+}
+
+func TestUserCalculateTaxProfile(t *testing.T) {
+	type TC struct {
+		userID  *int
+		reqbody string
+		wantTax float64
+	}
+
+	userID := 3
+
+	tcs := []TC{
+		// anonymous calculations don't claim any profile
+		{reqbody: `{"totalIncome":500000,"wht":0,"allowances":[]}`, wantTax: 29_000},
+		// donation and spouse of the profile are claimed, child isn't allowed
+		{userID: &userID, reqbody: `{"totalIncome":500000,"wht":0,"allowances":[]}`, wantTax: 18_000},
+		// donation of the request takes precedence
+		{userID: &userID, reqbody: `{"totalIncome":500000,"wht":0,"allowances":[{"allowanceType":"donation","amount":0}]}`, wantTax: 23_000},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(UserDBMock)
+			mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+				{AllowanceType: "donation", MaxAmount: 100_000},
+				{AllowanceType: "spouse", MaxAmount: 60_000},
+			}, nil)
+
+			profiles := new(ProfileDBMock)
+			profiles.On("FindUserProfile", mock.Anything, userID).Return(database.UserProfile{
+				UserID:        userID,
+				MaritalStatus: MaritalStatusMarried,
+				Children:      1,
+				Allowances:    map[string]float64{"donation": 50_000},
+			}, nil)
+
+			h := NewTaxHandler(validator.New(), mockObj).SetProfiles(profiles)
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations", strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			c := echo.New().NewContext(req, rec)
+			if tc.userID != nil {
+				c.Set(accountClaimsContextKey, *tc.userID)
+			}
+
+			assert.NoError(t, h.CalculateTax(c))
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var got TaxResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tc.wantTax, got.Tax)
+		})
+	}
+}
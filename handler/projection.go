@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+const monthsInYear = 12
+
+// ProjectionRequest has payroll data of the year so far, income and wht are totals of the months elapsed
+type ProjectionRequest struct {
+	MonthsElapsed int         `json:"monthsElapsed" validate:"required,gte=1,lte=12"`
+	YtdIncome     float64     `json:"ytdIncome" validate:"number,gte=0"`
+	YtdWht        float64     `json:"ytdWht" validate:"number,gte=0"`
+	Allowances    []Allowance `json:"allowances" validate:"dive"`
+}
+
+// ProjectionResponse is the year-end outcome if income and withholding go on at the pace so far.
+// MonthlyWht is the withholding of each remaining month which settles the tax exactly
+type ProjectionResponse struct {
+	ProjectedIncome float64    `json:"projectedIncome"`
+	ProjectedWht    float64    `json:"projectedWht"`
+	Tax             float64    `json:"tax"`
+	TaxRefund       float64    `json:"taxRefund"`
+	TaxShortfall    float64    `json:"taxShortfall"`
+	MonthlyWht      float64    `json:"monthlyWht"`
+	TaxLevel        []TaxLevel `json:"taxLevel,omitempty"`
+}
+
+// ProjectionHandler projects year-end tax from year-to-date payroll data. Projections are estimates, so calculations
+// shouldn't record them to history
+type ProjectionHandler struct {
+	vl           *validator.Validate
+	calculations *TaxHandler
+}
+
+func NewProjectionHandler(vl *validator.Validate, calculations *TaxHandler) *ProjectionHandler {
+	return &ProjectionHandler{vl, calculations}
+}
+
+// ProjectTax annualizes income and wht of the months elapsed, the tax of the projected income is calculated like
+// POST /tax/calculations with query params taxYear, date, detail and precision
+func (h *ProjectionHandler) ProjectTax(c echo.Context) error {
+	taxYear, ok := getTaxYear(c)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.TaxYearUnsupported)
+	}
+
+	effectiveDate, ok := getEffectiveDate(c, h.calculations.now())
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidDate)
+	}
+
+	detail, ok := getDetail(c)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	precision, ok := getPrecision(c, h.calculations.precision)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	var req ProjectionRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if req.YtdIncome < req.YtdWht {
+		return respondError(c, http.StatusBadRequest, errcode.WhtExceedsIncome)
+	}
+
+	allowances := req.Allowances
+	if allowances == nil {
+		allowances = []Allowance{}
+	}
+
+	projectedIncome := req.YtdIncome / float64(req.MonthsElapsed) * monthsInYear
+	projectedWht := req.YtdWht / float64(req.MonthsElapsed) * monthsInYear
+
+	// wht is left out, so the calculated tax is the liability of the whole year
+	resp, degraded, err := h.calculations.Calculate(c.Request().Context(), Calculation{
+		TaxRequest:    TaxRequest{TotalIncome: projectedIncome, Allowances: allowances},
+		TaxYear:       taxYear,
+		EffectiveDate: effectiveDate,
+		Language:      preferredLanguage(c.Request().Header.Get("Accept-Language"), defaultLanguage),
+		APIKeyID:      currentAPIKeyID(c),
+		TenantID:      currentTenantID(c),
+		UserID:        currentUserID(c),
+	})
+
+	if degraded {
+		c.Response().Header().Set(degradedHeader, "true")
+	}
+
+	if err != nil {
+		var calcErr *CalculationError
+		if errors.As(err, &calcErr) {
+			return respondError(c, calcErr.Status, calcErr.Code)
+		}
+
+		return respondQueryError(c)
+	}
+
+	if detail == DetailSummary {
+		resp.TaxLevel = nil
+	}
+
+	p := projectTax(req, projectedIncome, projectedWht, resp)
+	p.round(precision)
+
+	return c.JSON(http.StatusOK, p)
+}
+
+func projectTax(req ProjectionRequest, projectedIncome float64, projectedWht float64, resp *TaxResponse) ProjectionResponse {
+	p := ProjectionResponse{
+		ProjectedIncome: projectedIncome,
+		ProjectedWht:    projectedWht,
+		Tax:             resp.Tax,
+		TaxLevel:        resp.TaxLevel,
+	}
+
+	if projectedWht > resp.Tax {
+		p.TaxRefund = projectedWht - resp.Tax
+	} else {
+		p.TaxShortfall = resp.Tax - projectedWht
+	}
+
+	// nothing is left to withhold after the last month, and wht so far may already cover the tax
+	if remaining := monthsInYear - req.MonthsElapsed; remaining > 0 && resp.Tax > req.YtdWht {
+		p.MonthlyWht = (resp.Tax - req.YtdWht) / float64(remaining)
+	}
+
+	return p
+}
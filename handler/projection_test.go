@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestProjectTax(t *testing.T) {
+	type TC struct {
+		query     string
+		reqbody   string
+		wantCode  int
+		wantError errcode.Code
+		want      ProjectionResponse
+	}
+
+	tcs := []TC{
+		{
+			reqbody:  `{"monthsElapsed":6,"ytdIncome":300000,"ytdWht":15000}`,
+			wantCode: http.StatusOK,
+			want:     ProjectionResponse{ProjectedIncome: 600_000, ProjectedWht: 30_000, Tax: 50_000, TaxShortfall: 20_000, MonthlyWht: 35_000.0 / 6},
+		},
+		{
+			reqbody:  `{"monthsElapsed":6,"ytdIncome":300000,"ytdWht":30000}`,
+			wantCode: http.StatusOK,
+			want:     ProjectionResponse{ProjectedIncome: 600_000, ProjectedWht: 60_000, Tax: 50_000, TaxRefund: 10_000, MonthlyWht: 20_000.0 / 6},
+		},
+		{
+			reqbody:  `{"monthsElapsed":12,"ytdIncome":600000,"ytdWht":60000,"allowances":[{"allowanceType":"donation","amount":0}]}`,
+			wantCode: http.StatusOK,
+			want:     ProjectionResponse{ProjectedIncome: 600_000, ProjectedWht: 60_000, Tax: 50_000, TaxRefund: 10_000},
+		},
+		// summary leaves out tax levels
+		{
+			query:    "?detail=summary",
+			reqbody:  `{"monthsElapsed":6,"ytdIncome":300000,"ytdWht":15000}`,
+			wantCode: http.StatusOK,
+			want:     ProjectionResponse{ProjectedIncome: 600_000, ProjectedWht: 30_000, Tax: 50_000, TaxShortfall: 20_000, MonthlyWht: 35_000.0 / 6},
+		},
+		{
+			query:     "?detail=levels",
+			reqbody:   `{"monthsElapsed":6,"ytdIncome":300000,"ytdWht":15000}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: errcode.InvalidRequest,
+		},
+		{
+			reqbody:   `{"monthsElapsed":13,"ytdIncome":600000,"ytdWht":0}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: errcode.InvalidRequest,
+		},
+		{
+			reqbody:   `{"monthsElapsed":3,"ytdIncome":100000,"ytdWht":100001}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: errcode.WhtExceedsIncome,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			taxes := new(UserDBMock)
+			taxes.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			taxes.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+				{AllowanceType: "donation", MaxAmount: 100_000},
+			}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/projections"+tc.query, strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			c := echo.New().NewContext(req, rec)
+
+			h := NewProjectionHandler(validator.New(), NewTaxHandler(validator.New(), taxes))
+
+			assert.NoError(t, h.ProjectTax(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				var got ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, tc.wantError, got.ErrorCode)
+
+				return
+			}
+
+			var got ProjectionResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+
+			assert.Equal(t, tc.query != "?detail=summary", len(got.TaxLevel) > 0)
+
+			got.TaxLevel = nil
+			assert.InDelta(t, tc.want.MonthlyWht, got.MonthlyWht, 0.001)
+
+			got.MonthlyWht = tc.want.MonthlyWht
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
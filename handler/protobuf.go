@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	taxv1 "github.com/AnnaCarter465/assessment-tax/proto/tax/v1"
+	"github.com/labstack/echo/v4"
+	"google.golang.org/protobuf/proto"
+)
+
+// protobuf bodies are selected by either media type, application/x-protobuf is the one most clients send
+var protobufMIMETypes = []string{"application/x-protobuf", echo.MIMEApplicationProtobuf}
+
+func isProtobuf(mediaType string) bool {
+	for _, t := range protobufMIMETypes {
+		if mediaType == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasProtobufBody reports whether Content-Type of request is protobuf
+func hasProtobufBody(c echo.Context) bool {
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	return isProtobuf(mediaType)
+}
+
+// acceptsProtobuf reports whether Accept header of request prefers protobuf to json,
+// json is responded unless protobuf is listed before it
+func acceptsProtobuf(c echo.Context) bool {
+	for _, part := range strings.Split(c.Request().Header.Get(echo.HeaderAccept), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+
+		if isProtobuf(mediaType) {
+			return true
+		}
+
+		if mediaType == echo.MIMEApplicationJSON {
+			return false
+		}
+	}
+
+	return false
+}
+
+// bindTaxRequest binds json or protobuf body by its Content-Type
+func bindTaxRequest(c echo.Context, req *TaxRequest) error {
+	if !hasProtobufBody(c) {
+		return c.Bind(req)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return err
+	}
+
+	var msg taxv1.TaxRequest
+	if err := proto.Unmarshal(body, &msg); err != nil {
+		return err
+	}
+
+	*req = TaxRequestFromProto(&msg)
+
+	return nil
+}
+
+// bindAdminTaxRequest binds json or protobuf body of admin settings by its Content-Type
+func bindAdminTaxRequest(c echo.Context, req *AdminTaxRequest) error {
+	if !hasProtobufBody(c) {
+		return c.Bind(req)
+	}
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return err
+	}
+
+	var msg taxv1.AdminSettingRequest
+	if err := proto.Unmarshal(body, &msg); err != nil {
+		return err
+	}
+
+	*req = AdminTaxRequest{Amount: msg.GetAmount(), SampleIncomes: msg.GetSampleIncomes()}
+
+	return nil
+}
+
+// respondNegotiated responds protobuf of msg when client accepts it, or v as json
+func respondNegotiated(c echo.Context, v any, msg func() proto.Message) error {
+	if !acceptsProtobuf(c) {
+		return c.JSON(http.StatusOK, v)
+	}
+
+	body, err := proto.Marshal(msg())
+	if err != nil {
+		return err
+	}
+
+	c.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+
+	return c.Blob(http.StatusOK, protobufMIMETypes[0], body)
+}
+
+// TaxRequestFromProto converts request of gRPC or protobuf body, allowances are empty rather than missing
+// since protobuf can't tell them apart
+func TaxRequestFromProto(msg *taxv1.TaxRequest) TaxRequest {
+	req := TaxRequest{
+		TotalIncome: msg.GetTotalIncome(),
+		Wht:         msg.GetWht(),
+		Allowances:  []Allowance{},
+	}
+
+	for _, a := range msg.GetAllowances() {
+		req.Allowances = append(req.Allowances, Allowance{
+			AllowanceType: a.GetAllowanceType(),
+			Amount:        a.GetAmount(),
+		})
+	}
+
+	return req
+}
+
+func (r *TaxResponse) Proto() *taxv1.TaxResponse {
+	msg := &taxv1.TaxResponse{
+		Tax:       r.Tax,
+		TaxRefund: r.TaxRefund,
+		Notices:   r.Notices,
+	}
+
+	for _, l := range r.TaxLevel {
+		msg.TaxLevel = append(msg.TaxLevel, &taxv1.TaxLevel{Level: l.Level, Tax: l.Tax})
+	}
+
+	return msg
+}
+
+func (r *TaxCSVResponse) Proto() *taxv1.TaxCSVResponse {
+	msg := &taxv1.TaxCSVResponse{}
+
+	for _, t := range r.Taxes {
+		msg.Taxes = append(msg.Taxes, &taxv1.TaxCSV{TotalIncome: t.TotalIncome, Tax: t.Tax})
+	}
+
+	return msg
+}
+
+func (r *AdminDeductionsResponse) Proto() *taxv1.AdminDeductionsResponse {
+	msg := &taxv1.AdminDeductionsResponse{PersonalDeduction: r.PersonalDeduction, KReceipt: r.KReceipt}
+
+	for _, a := range r.DefaultAllowances {
+		msg.DefaultAllowances = append(msg.DefaultAllowances, &taxv1.DefaultAllowance{AllowanceType: a.AllowanceType, Amount: a.Amount})
+	}
+
+	for _, a := range r.AllowedAllowances {
+		msg.AllowedAllowances = append(msg.AllowedAllowances, &taxv1.AllowedAllowance{AllowanceType: a.AllowanceType, MaxAmount: a.MaxAmount})
+	}
+
+	return msg
+}
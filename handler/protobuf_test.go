@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	taxv1 "github.com/AnnaCarter465/assessment-tax/proto/tax/v1"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestUserCalculateTaxProtobuf(t *testing.T) {
+	jsonBody := `{"totalIncome":500000,"wht":0,"allowances":[{"allowanceType":"donation","amount":200000}]}`
+
+	protoBody, err := proto.Marshal(&taxv1.TaxRequest{
+		TotalIncome: 500_000,
+		Allowances:  []*taxv1.Allowance{{AllowanceType: "donation", Amount: 200_000}},
+	})
+	assert.NoError(t, err)
+
+	type TC struct {
+		contentType string
+		body        []byte
+		accept      string
+		wantCode    int
+		wantProto   bool
+	}
+
+	tcs := []TC{
+		{contentType: "application/x-protobuf", body: protoBody, accept: "application/x-protobuf", wantCode: http.StatusOK, wantProto: true},
+		{contentType: "application/protobuf", body: protoBody, accept: "", wantCode: http.StatusOK},
+		{contentType: "application/json", body: []byte(jsonBody), accept: "application/x-protobuf, application/json", wantCode: http.StatusOK, wantProto: true},
+		{contentType: "application/json", body: []byte(jsonBody), accept: "application/json, application/x-protobuf", wantCode: http.StatusOK},
+		{contentType: "application/x-protobuf", body: []byte("not protobuf"), accept: "application/x-protobuf", wantCode: http.StatusBadRequest},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(UserDBMock)
+			mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+				{AllowanceType: "donation", MaxAmount: 100_000},
+			}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations", bytes.NewReader(tc.body))
+			req.Header.Set(echo.HeaderContentType, tc.contentType)
+			req.Header.Set(echo.HeaderAccept, tc.accept)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, NewTaxHandler(validator.New(), mockObj).CalculateTax(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			if tc.wantProto {
+				var got taxv1.TaxResponse
+
+				assert.Equal(t, "application/x-protobuf", rec.Header().Get(echo.HeaderContentType))
+				assert.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, 19_000.0, got.GetTax())
+				assert.Len(t, got.GetTaxLevel(), 5)
+
+				return
+			}
+
+			var got TaxResponse
+
+			assert.True(t, strings.HasPrefix(rec.Header().Get(echo.HeaderContentType), echo.MIMEApplicationJSON))
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, 19_000.0, got.Tax)
+		})
+	}
+}
+
+func TestAdminSettingsProtobuf(t *testing.T) {
+	dbmock := new(AdminDBMock)
+	mockSettingBounds(dbmock)
+	dbmock.On("UpdateAmountAllowedAllowances", mock.Anything, "donation", float64(150_000)).
+		Return(database.AllowedAllowance{AllowanceType: "donation", MaxAmount: 150_000}, nil)
+	dbmock.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+		{AllowanceType: "personal", Amount: 60_000, UUID: "a1"},
+	}, nil)
+	dbmock.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+		{AllowanceType: "k-receipt", MaxAmount: 50_000, UUID: "b1"},
+	}, nil)
+
+	h := NewAdminHandler(validator.New(), dbmock)
+	e := echo.New()
+
+	body, err := proto.Marshal(&taxv1.AdminSettingRequest{Amount: 150_000})
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/deductions/donation", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, "application/x-protobuf")
+	req.Header.Set(echo.HeaderAccept, "application/x-protobuf")
+	rec := httptest.NewRecorder()
+
+	assert.NoError(t, h.UpdateDonation(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var setting taxv1.AdminSettingResponse
+
+	assert.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &setting))
+	assert.Equal(t, 150_000.0, setting.GetDonation())
+	assert.Nil(t, setting.PersonalDeduction, "only the changed setting is set")
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/deductions/donation", strings.NewReader("not protobuf"))
+	req.Header.Set(echo.HeaderContentType, "application/x-protobuf")
+	rec = httptest.NewRecorder()
+
+	assert.NoError(t, h.UpdateDonation(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/deductions", nil)
+	req.Header.Set(echo.HeaderAccept, "application/x-protobuf")
+	rec = httptest.NewRecorder()
+
+	assert.NoError(t, h.GetDeductions(e.NewContext(req, rec)))
+
+	var deductions taxv1.AdminDeductionsResponse
+
+	assert.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &deductions))
+	assert.Equal(t, 60_000.0, deductions.GetPersonalDeduction())
+	assert.Equal(t, 50_000.0, deductions.GetKReceipt())
+	assert.Equal(t, "personal", deductions.GetDefaultAllowances()[0].GetAllowanceType())
+	assert.Equal(t, 50_000.0, deductions.GetAllowedAllowances()[0].GetMaxAmount())
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+)
+
+// RateLimit limits requests per api key by store, requests without a key are limited per client ip.
+// It must be used after APIKeyAuth
+func RateLimit(store middleware.RateLimiterStore) echo.MiddlewareFunc {
+	return middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Store:               store,
+		IdentifierExtractor: rateLimitIdentifier,
+		DenyHandler: func(c echo.Context, _ string, _ error) error {
+			return respondError(c, http.StatusTooManyRequests, errcode.TooManyRequests)
+		},
+	})
+}
+
+func rateLimitIdentifier(c echo.Context) (string, error) {
+	if k, ok := CurrentAPIKey(c); ok {
+		return "key:" + strconv.Itoa(k.ID), nil
+	}
+
+	return "ip:" + c.RealIP(), nil
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type rateLimiterStub struct {
+	allowed     bool
+	identifiers []string
+}
+
+func (s *rateLimiterStub) Allow(identifier string) (bool, error) {
+	s.identifiers = append(s.identifiers, identifier)
+	return s.allowed, nil
+}
+
+func TestRateLimit(t *testing.T) {
+	type TC struct {
+		apiKey         *database.APIKey
+		allowed        bool
+		wantCode       int
+		wantIdentifier string
+	}
+
+	tcs := []TC{
+		{apiKey: &database.APIKey{ID: 7}, allowed: true, wantCode: http.StatusOK, wantIdentifier: "key:7"},
+		{apiKey: &database.APIKey{ID: 7}, allowed: false, wantCode: http.StatusTooManyRequests, wantIdentifier: "key:7"},
+		{allowed: true, wantCode: http.StatusOK, wantIdentifier: "ip:192.0.2.1"},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			store := &rateLimiterStub{allowed: tc.allowed}
+
+			e := echo.New()
+			e.GET("/", func(c echo.Context) error { return c.NoContent(http.StatusOK) },
+				func(next echo.HandlerFunc) echo.HandlerFunc {
+					return func(c echo.Context) error {
+						if tc.apiKey != nil {
+							c.Set(apiKeyContextKey, *tc.apiKey)
+						}
+
+						return next(c)
+					}
+				},
+				RateLimit(store))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Equal(t, []string{tc.wantIdentifier}, store.identifiers)
+
+			if tc.wantCode == http.StatusTooManyRequests {
+				var errresp ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errresp))
+				assert.Equal(t, errcode.TooManyRequests, errresp.ErrorCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+)
+
+// Recover responds internal error when handler panics, so client gets error envelope instead of closed connection
+func Recover() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+
+				// let http server abort the response as requested
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+
+				slog.ErrorContext(c.Request().Context(), "recovered from panic",
+					"panic", fmt.Sprint(r),
+					"stack", string(debug.Stack()))
+
+				if c.Response().Committed {
+					err = nil
+					return
+				}
+
+				err = respondError(c, http.StatusInternalServerError, errcode.Internal)
+			}()
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecover(t *testing.T) {
+	e := echo.New()
+	e.Use(Recover())
+	e.GET("/panic", func(c echo.Context) error {
+		var rates map[int]float64
+		rates[0] = 1
+
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/panic", nil)
+	rec := httptest.NewRecorder()
+
+	e.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	var got ResponseMsg
+
+	err := json.Unmarshal([]byte(rec.Body.String()), &got)
+	assert.NoError(t, err)
+	assert.Equal(t, ResponseMsg{Message: "Internal server error", ErrorCode: errcode.Internal}, got)
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+)
+
+// Reloader reads configuration again, e.g. app.App
+type Reloader interface {
+	Reload() error
+}
+
+type ReloadHandler struct {
+	reloader Reloader
+}
+
+func NewReloadHandler(reloader Reloader) *ReloadHandler {
+	return &ReloadHandler{reloader}
+}
+
+// Reload applies settings changed since startup, like SIGHUP does, invalid settings are rejected
+// and the running ones are kept
+func (h *ReloadHandler) Reload(c echo.Context) error {
+	if err := h.reloader.Reload(); err != nil {
+		slog.WarnContext(c.Request().Context(), "cannot reload configuration", "error", err)
+		return respondError(c, http.StatusBadRequest, errcode.ConfigInvalid)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type ReloaderMock struct {
+	mock.Mock
+}
+
+func (o *ReloaderMock) Reload() error {
+	return o.Called().Error(0)
+}
+
+func TestAdminReloadConfig(t *testing.T) {
+	type TC struct {
+		reloadErr     error
+		wantCode      int
+		wantErrorCode errcode.Code
+	}
+
+	tcs := []TC{
+		{reloadErr: nil, wantCode: http.StatusNoContent},
+		{reloadErr: errors.New("invalid setting CSV_UPLOAD_TIMEOUT"), wantCode: http.StatusBadRequest, wantErrorCode: errcode.ConfigInvalid},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			reloader := new(ReloaderMock)
+			reloader.On("Reload").Return(tc.reloadErr)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/config/reload", nil)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, NewReloadHandler(reloader).Reload(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+			reloader.AssertExpectations(t)
+
+			if tc.wantErrorCode != "" {
+				var errresp ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &errresp))
+				assert.Equal(t, tc.wantErrorCode, errresp.ErrorCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type ScheduledChangeRequest struct {
+	AllowanceType string    `json:"allowanceType" validate:"required"`
+	Amount        float64   `json:"amount" validate:"required,number,gt=0"`
+	ActivateAt    time.Time `json:"activateAt" validate:"required"`
+}
+
+type ScheduledChangeResponse struct {
+	ID            int       `json:"id"`
+	AllowanceType string    `json:"allowanceType"`
+	Amount        float64   `json:"amount"`
+	ActivateAt    time.Time `json:"activateAt"`
+	UUID          string    `json:"uuid"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+type ScheduleIDB interface {
+	FindSettingBound(ctx context.Context, setting string) (database.SettingBound, error)
+	CreateScheduledChange(ctx context.Context, kind string, allowanceType string, amount float64, activateAt time.Time) (database.ScheduledChange, error)
+	FindPendingScheduledChanges(ctx context.Context) ([]database.ScheduledChange, error)
+	CancelScheduledChange(ctx context.Context, id int) error
+}
+
+type ScheduleHandler struct {
+	vl *validator.Validate
+	db ScheduleIDB
+}
+
+func NewScheduleHandler(vl *validator.Validate, db ScheduleIDB) *ScheduleHandler {
+	return &ScheduleHandler{vl: vl, db: db}
+}
+
+func toScheduledChangeResponse(s database.ScheduledChange) ScheduledChangeResponse {
+	return ScheduledChangeResponse{
+		ID:            s.ID,
+		AllowanceType: s.AllowanceType,
+		Amount:        s.Amount,
+		ActivateAt:    s.ActivateAt,
+		UUID:          s.UUID,
+		CreatedAt:     s.CreatedAt,
+		UpdatedAt:     s.UpdatedAt,
+	}
+}
+
+// GetScheduledChanges returns changes waiting for their activation time
+func (h *ScheduleHandler) GetScheduledChanges(c echo.Context) error {
+	changes, err := h.db.FindPendingScheduledChanges(c.Request().Context())
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find scheduled changes", "error", err)
+		return respondQueryError(c)
+	}
+
+	results := []ScheduledChangeResponse{}
+
+	for _, s := range changes {
+		results = append(results, toScheduledChangeResponse(s))
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+func (h *ScheduleHandler) CreateScheduledChange(c echo.Context) error {
+	var req ScheduledChangeRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	kind, ok := settingKinds[req.AllowanceType]
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if !req.ActivateAt.After(time.Now()) {
+		return respondError(c, http.StatusBadRequest, errcode.ActivationInPast)
+	}
+
+	if ok, err := withinBound(c, h.db, req.AllowanceType, req.Amount); !ok {
+		return err
+	}
+
+	change, err := h.db.CreateScheduledChange(c.Request().Context(), kind, req.AllowanceType, req.Amount, req.ActivateAt)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to create scheduled change", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.ScheduledChangeCreateFailed)
+	}
+
+	return c.JSON(http.StatusCreated, toScheduledChangeResponse(change))
+}
+
+func (h *ScheduleHandler) CancelScheduledChange(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.ScheduledChangeInvalidID)
+	}
+
+	err = h.db.CancelScheduledChange(c.Request().Context(), id)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.ScheduledChangeNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to cancel scheduled change", "error", err)
+		return respondQueryError(c)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
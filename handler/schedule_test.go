@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type ScheduleDBMock struct {
+	mock.Mock
+}
+
+func (o *ScheduleDBMock) FindSettingBound(ctx context.Context, setting string) (database.SettingBound, error) {
+	args := o.Called(ctx, setting)
+	return args.Get(0).(database.SettingBound), args.Error(1)
+}
+
+func (o *ScheduleDBMock) CreateScheduledChange(ctx context.Context, kind string, allowanceType string, amount float64, activateAt time.Time) (database.ScheduledChange, error) {
+	args := o.Called(ctx, kind, allowanceType, amount, activateAt)
+	return args.Get(0).(database.ScheduledChange), args.Error(1)
+}
+
+func (o *ScheduleDBMock) FindPendingScheduledChanges(ctx context.Context) ([]database.ScheduledChange, error) {
+	args := o.Called(ctx)
+	return args.Get(0).([]database.ScheduledChange), args.Error(1)
+}
+
+func (o *ScheduleDBMock) CancelScheduledChange(ctx context.Context, id int) error {
+	args := o.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestAdminCreateScheduledChange(t *testing.T) {
+	activateAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+
+	type TC struct {
+		reqbody    map[string]interface{}
+		mockCreate *MockSetting
+		wantCode   int
+		wantErr    errcode.Code
+	}
+
+	tcs := []TC{
+		{
+			reqbody: map[string]interface{}{"allowanceType": "k-receipt", "amount": 100_000, "activateAt": activateAt},
+			mockCreate: &MockSetting{
+				Args: []interface{}{mock.Anything, database.AllowanceKindAllowed, "k-receipt", float64(100_000), activateAt},
+				Returns: []interface{}{
+					database.ScheduledChange{ID: 1, AllowanceKind: database.AllowanceKindAllowed, AllowanceType: "k-receipt", Amount: 100_000, ActivateAt: activateAt},
+					nil,
+				},
+			},
+			wantCode: http.StatusCreated,
+		},
+		{
+			reqbody:  map[string]interface{}{"allowanceType": "k-receipt", "amount": 100_000, "activateAt": time.Now().Add(-time.Hour)},
+			wantCode: http.StatusBadRequest,
+			wantErr:  errcode.ActivationInPast,
+		},
+		{
+			reqbody:  map[string]interface{}{"allowanceType": "unknown", "amount": 100_000, "activateAt": activateAt},
+			wantCode: http.StatusBadRequest,
+			wantErr:  errcode.InvalidRequest,
+		},
+		{
+			reqbody:  map[string]interface{}{"allowanceType": "k-receipt", "amount": 100_001, "activateAt": activateAt},
+			wantCode: http.StatusBadRequest,
+			wantErr:  errcode.AmountOutOfRange,
+		},
+		{
+			reqbody: map[string]interface{}{"allowanceType": "k-receipt", "amount": 100_000, "activateAt": activateAt},
+			mockCreate: &MockSetting{
+				Args:    []interface{}{mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything},
+				Returns: []interface{}{database.ScheduledChange{}, errors.New("an error")},
+			},
+			wantCode: http.StatusInternalServerError,
+			wantErr:  errcode.ScheduledChangeCreateFailed,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(ScheduleDBMock)
+			dbmock.On("FindSettingBound", mock.Anything, "k-receipt").
+				Return(database.SettingBound{Setting: "k-receipt", MaxAmount: 100_000}, nil)
+
+			if tc.mockCreate != nil {
+				dbmock.On("CreateScheduledChange", tc.mockCreate.Args...).Return(tc.mockCreate.Returns...)
+			}
+
+			val, _ := json.Marshal(tc.reqbody)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/scheduled-changes", strings.NewReader(string(val)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, NewScheduleHandler(validator.New(), dbmock).CreateScheduledChange(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantErr != "" {
+				var got ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, tc.wantErr, got.ErrorCode)
+
+				return
+			}
+
+			var got ScheduledChangeResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, 1, got.ID)
+			assert.Equal(t, "k-receipt", got.AllowanceType)
+			assert.True(t, activateAt.Equal(got.ActivateAt))
+		})
+	}
+}
+
+func TestAdminGetScheduledChanges(t *testing.T) {
+	activateAt := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	dbmock := new(ScheduleDBMock)
+	dbmock.On("FindPendingScheduledChanges", mock.Anything).Return([]database.ScheduledChange{
+		{ID: 1, AllowanceKind: database.AllowanceKindAllowed, AllowanceType: "k-receipt", Amount: 100_000, ActivateAt: activateAt},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/scheduled-changes", nil)
+	rec := httptest.NewRecorder()
+
+	e := echo.New()
+
+	assert.NoError(t, NewScheduleHandler(validator.New(), dbmock).GetScheduledChanges(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var got []ScheduledChangeResponse
+
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, []ScheduledChangeResponse{
+		{ID: 1, AllowanceType: "k-receipt", Amount: 100_000, ActivateAt: activateAt},
+	}, got)
+}
+
+func TestAdminCancelScheduledChange(t *testing.T) {
+	type TC struct {
+		id         string
+		mockCancel *MockSetting
+		wantCode   int
+	}
+
+	tcs := []TC{
+		{
+			id:         "1",
+			mockCancel: &MockSetting{Args: []interface{}{mock.Anything, 1}, Returns: []interface{}{nil}},
+			wantCode:   http.StatusNoContent,
+		},
+		{
+			id:       "abc",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			id:         "2",
+			mockCancel: &MockSetting{Args: []interface{}{mock.Anything, 2}, Returns: []interface{}{database.ErrNotFound}},
+			wantCode:   http.StatusNotFound,
+		},
+		{
+			id:         "3",
+			mockCancel: &MockSetting{Args: []interface{}{mock.Anything, 3}, Returns: []interface{}{errors.New("an error")}},
+			wantCode:   http.StatusInternalServerError,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(ScheduleDBMock)
+
+			if tc.mockCancel != nil {
+				dbmock.On("CancelScheduledChange", tc.mockCancel.Args...).Return(tc.mockCancel.Returns...)
+			}
+
+			req := httptest.NewRequest(http.MethodDelete, "/admin/scheduled-changes/"+tc.id, nil)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tc.id)
+
+			assert.NoError(t, NewScheduleHandler(validator.New(), dbmock).CancelScheduledChange(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+)
+
+type SessionResponse struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type SessionIDB interface {
+	FindActiveAdminSessions(ctx context.Context) ([]database.AdminSession, error)
+	FindAdminSession(ctx context.Context, id string) (database.AdminSession, error)
+	RevokeAdminSession(ctx context.Context, id string) error
+}
+
+type SessionHandler struct {
+	db SessionIDB
+}
+
+func NewSessionHandler(db SessionIDB) *SessionHandler {
+	return &SessionHandler{db}
+}
+
+func (h *SessionHandler) GetSessions(c echo.Context) error {
+	sessions, err := h.db.FindActiveAdminSessions(c.Request().Context())
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find admin sessions", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	results := []SessionResponse{}
+
+	for _, s := range sessions {
+		results = append(results, SessionResponse{
+			ID:        s.ID,
+			Username:  s.Username,
+			Role:      s.Role,
+			CreatedAt: s.CreatedAt,
+			ExpiresAt: s.ExpiresAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// RevokeSession revokes session of any user for superadmin, other admins can revoke only their own sessions
+func (h *SessionHandler) RevokeSession(c echo.Context) error {
+	id := c.Param("id")
+
+	claims, ok := CurrentAdmin(c)
+	if !ok {
+		return respondError(c, http.StatusForbidden, errcode.Forbidden)
+	}
+
+	session, err := h.db.FindAdminSession(c.Request().Context(), id)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.SessionNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find admin session", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	if claims.Role != RoleSuperadmin && claims.Subject != session.Username {
+		return respondError(c, http.StatusForbidden, errcode.Forbidden)
+	}
+
+	err = h.db.RevokeAdminSession(c.Request().Context(), id)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.SessionNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to revoke admin session", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type SessionDBMock struct {
+	mock.Mock
+}
+
+func (o *SessionDBMock) CreateAdminSession(ctx context.Context, id string, username string, role string, expiresAt time.Time) (database.AdminSession, error) {
+	args := o.Called(ctx, id, username, role, expiresAt)
+	return args.Get(0).(database.AdminSession), args.Error(1)
+}
+
+func (o *SessionDBMock) FindActiveAdminSessions(ctx context.Context) ([]database.AdminSession, error) {
+	args := o.Called(ctx)
+	return args.Get(0).([]database.AdminSession), args.Error(1)
+}
+
+func (o *SessionDBMock) FindAdminSession(ctx context.Context, id string) (database.AdminSession, error) {
+	args := o.Called(ctx, id)
+	return args.Get(0).(database.AdminSession), args.Error(1)
+}
+
+func (o *SessionDBMock) RevokeAdminSession(ctx context.Context, id string) error {
+	args := o.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestAdminAuthWithSessions(t *testing.T) {
+	revokedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	dbmock := new(SessionDBMock)
+	dbmock.On("FindAdminSession", mock.Anything, "active").Return(database.AdminSession{ID: "active"}, nil)
+	dbmock.On("FindAdminSession", mock.Anything, "revoked").Return(database.AdminSession{ID: "revoked", RevokedAt: &revokedAt}, nil)
+	dbmock.On("FindAdminSession", mock.Anything, "").Return(database.AdminSession{}, database.ErrNotFound)
+
+	type TC struct {
+		sessionID string
+		wantCode  int
+	}
+
+	tcs := []TC{
+		{sessionID: "active", wantCode: http.StatusOK},
+		{sessionID: "revoked", wantCode: http.StatusUnauthorized},
+		{sessionID: "", wantCode: http.StatusUnauthorized},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			e := echo.New()
+			e.GET("/admin/calendar", func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			}, AdminAuth(testAuthConfig, dbmock))
+
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, AdminClaims{
+				Role: RoleViewer,
+				RegisteredClaims: jwt.RegisteredClaims{
+					ID:        tc.sessionID,
+					Issuer:    tokenIssuer,
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+				},
+			}).SignedString(testAuthConfig.Secret)
+			assert.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/calendar", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
+
+func TestAdminRevokeSession(t *testing.T) {
+	type TC struct {
+		username   string
+		role       string
+		wantCode   int
+		wantRevoke bool
+	}
+
+	tcs := []TC{
+		{username: "somchai", role: RoleViewer, wantCode: http.StatusNoContent, wantRevoke: true},
+		{username: "somsri", role: RoleEditor, wantCode: http.StatusForbidden, wantRevoke: false},
+		{username: "adminTax", role: RoleSuperadmin, wantCode: http.StatusNoContent, wantRevoke: true},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(SessionDBMock)
+			dbmock.On("FindAdminSession", mock.Anything, "abc").Return(database.AdminSession{ID: "abc", Username: "somchai"}, nil)
+			dbmock.On("RevokeAdminSession", mock.Anything, "abc").Return(nil)
+
+			req := httptest.NewRequest(http.MethodDelete, "/admin/sessions/abc", nil)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("abc")
+			c.Set(adminClaimsContextKey, &AdminClaims{
+				Role:             tc.role,
+				RegisteredClaims: jwt.RegisteredClaims{Subject: tc.username},
+			})
+
+			assert.NoError(t, NewSessionHandler(dbmock).RevokeSession(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantRevoke {
+				dbmock.AssertCalled(t, "RevokeAdminSession", mock.Anything, "abc")
+			} else {
+				dbmock.AssertNotCalled(t, "RevokeAdminSession", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,415 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+)
+
+type SettingVersionResponse struct {
+	Version           int                `json:"version"`
+	DefaultAllowances map[string]float64 `json:"defaultAllowances"`
+	AllowedAllowances map[string]float64 `json:"allowedAllowances"`
+	CreatedAt         time.Time          `json:"createdAt"`
+}
+
+// SettingHistoryResponse is value of a setting valid from ValidFrom until ValidTo, name of a bracket is "<tax year>/<level>"
+type SettingHistoryResponse struct {
+	Kind       string     `json:"kind"`
+	Name       string     `json:"name"`
+	Amount     *float64   `json:"amount"`
+	Percentage *float64   `json:"percentage,omitempty"`
+	ValidFrom  time.Time  `json:"validFrom"`
+	ValidTo    *time.Time `json:"validTo"`
+}
+
+// SettingsImportRequest contains all settings of an environment, CSV imports are converted to it
+type SettingsImportRequest struct {
+	DefaultAllowances []DefaultAllowanceResponse `json:"defaultAllowances"`
+	AllowedAllowances []AllowedAllowanceResponse `json:"allowedAllowances"`
+	Brackets          []BracketsImport           `json:"brackets"`
+}
+
+type BracketsImport struct {
+	TaxYear  int             `json:"taxYear"`
+	Brackets []BracketImport `json:"brackets"`
+}
+
+// BracketImport is a bracket of a tax year, levels are stored as imported but calculations label brackets by their
+// amounts
+type BracketImport struct {
+	Level  string            `json:"level"`
+	Levels map[string]string `json:"levels,omitempty"` // translated levels by language
+	Rate   float64           `json:"rate"`
+	Max    *float64          `json:"max"` // null for the highest bracket
+}
+
+type SettingsIDB interface {
+	FindAllSettingVersions(ctx context.Context) ([]database.SettingVersion, error)
+	FindSettingHistory(ctx context.Context, at time.Time) ([]database.SettingHistory, error)
+	RollbackSettings(ctx context.Context, version int) (database.SettingVersion, error)
+	FindSettingBound(ctx context.Context, setting string) (database.SettingBound, error)
+	ImportSettings(ctx context.Context, imp database.SettingsImport) (database.SettingVersion, error)
+}
+
+type SettingsHandler struct {
+	db       SettingsIDB
+	notifier SettingsNotifier
+}
+
+func NewSettingsHandler(db SettingsIDB) *SettingsHandler {
+	return &SettingsHandler{db: db}
+}
+
+// SetNotifier sets notifier called for every setting and tax year of brackets after a rollback or import
+func (h *SettingsHandler) SetNotifier(notifier SettingsNotifier) *SettingsHandler {
+	h.notifier = notifier
+	return h
+}
+
+func toSettingVersionResponse(v database.SettingVersion) SettingVersionResponse {
+	return SettingVersionResponse{
+		Version:           v.Version,
+		DefaultAllowances: v.DefaultAllowances,
+		AllowedAllowances: v.AllowedAllowances,
+		CreatedAt:         v.CreatedAt,
+	}
+}
+
+// GetVersions returns every version of settings, latest first
+func (h *SettingsHandler) GetVersions(c echo.Context) error {
+	versions, err := h.db.FindAllSettingVersions(c.Request().Context())
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find setting versions", "error", err)
+		return respondQueryError(c)
+	}
+
+	if len(versions) > 0 {
+		c.Response().Header().Set("ETag", strconv.Quote(strconv.Itoa(versions[0].Version)))
+	}
+
+	results := []SettingVersionResponse{}
+
+	for _, v := range versions {
+		results = append(results, toSettingVersionResponse(v))
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// GetHistory returns value of every setting at query param `at` in RFC 3339, it's now by default,
+// so a past calculation can be explained with the settings it used
+func (h *SettingsHandler) GetHistory(c echo.Context) error {
+	at := time.Now()
+
+	if v := c.QueryParam("at"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return respondError(c, http.StatusBadRequest, errcode.InvalidDate)
+		}
+
+		at = t
+	}
+
+	history, err := h.db.FindSettingHistory(c.Request().Context(), at)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find setting history", "error", err)
+		return respondQueryError(c)
+	}
+
+	results := []SettingHistoryResponse{}
+
+	for _, s := range history {
+		results = append(results, SettingHistoryResponse{
+			Kind:       s.Kind,
+			Name:       s.Name,
+			Amount:     s.Amount,
+			Percentage: s.Percentage,
+			ValidFrom:  s.ValidFrom,
+			ValidTo:    s.ValidTo,
+		})
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// ExpectedSettingsVersion makes writes fail with 409 when settings were changed after the version in If-Match,
+// it's the ETag of GetVersions, writes without If-Match are applied unconditionally
+func ExpectedSettingsVersion() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ifMatch := c.Request().Header.Get("If-Match")
+			if ifMatch == "" {
+				return next(c)
+			}
+
+			version, err := strconv.Atoi(strings.Trim(ifMatch, `"`))
+			if err != nil || version < 0 {
+				return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+			}
+
+			ctx := database.WithExpectedVersion(c.Request().Context(), version)
+			c.SetRequest(c.Request().WithContext(ctx))
+
+			return next(c)
+		}
+	}
+}
+
+// Rollback restores settings of a previous version, the restored settings become a new version
+func (h *SettingsHandler) Rollback(c echo.Context) error {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.SettingVersionInvalid)
+	}
+
+	restored, err := h.db.RollbackSettings(c.Request().Context(), version)
+	if errors.Is(err, database.ErrConflict) {
+		return respondError(c, http.StatusConflict, errcode.SettingsVersionConflict)
+	}
+
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.SettingVersionNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to rollback settings", "version", version, "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.SettingsRollbackFailed)
+	}
+
+	if h.notifier != nil {
+		for setting, value := range restored.DefaultAllowances {
+			h.notifier.SettingsChanged(setting, value)
+		}
+
+		for setting, value := range restored.AllowedAllowances {
+			h.notifier.SettingsChanged(setting, value)
+		}
+
+		for taxYear, brackets := range restored.Brackets {
+			h.notifier.BracketsChanged(taxYear, brackets)
+		}
+	}
+
+	return c.JSON(http.StatusOK, toSettingVersionResponse(restored))
+}
+
+// ParseImport converts body in the format of Import, JSON or CSV when isCSV, it's validated the same way
+func (h *SettingsHandler) ParseImport(ctx context.Context, body []byte, isCSV bool) (database.SettingsImport, error) {
+	var req SettingsImportRequest
+
+	if isCSV {
+		parsed, err := parseSettingsCSV(string(body))
+		if err != nil {
+			return database.SettingsImport{}, err
+		}
+
+		req = parsed
+	} else if err := json.Unmarshal(body, &req); err != nil {
+		return database.SettingsImport{}, err
+	}
+
+	return toSettingsImport(ctx, h.db, req)
+}
+
+// parseSettingsCSV reads rows of `section,name,value,max,label`, section is default, allowed or bracket.
+// Name of a bracket row is its tax year, value is its rate and an empty max means the highest bracket.
+func parseSettingsCSV(body string) (SettingsImportRequest, error) {
+	var req SettingsImportRequest
+
+	rows, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	if err != nil {
+		return req, err
+	}
+
+	if len(rows) == 0 || strings.Join(rows[0], ",") != "section,name,value,max,label" {
+		return req, errors.New("wrong csv header")
+	}
+
+	bracketsByYear := map[int]int{}
+
+	for i, row := range rows[1:] {
+		value, err := strconv.ParseFloat(row[2], 64)
+		if err != nil {
+			return req, fmt.Errorf("row %d: invalid value", i+2)
+		}
+
+		switch row[0] {
+		case "default":
+			req.DefaultAllowances = append(req.DefaultAllowances, DefaultAllowanceResponse{AllowanceType: row[1], Amount: value})
+		case "allowed":
+			req.AllowedAllowances = append(req.AllowedAllowances, AllowedAllowanceResponse{AllowanceType: row[1], MaxAmount: value})
+		case "bracket":
+			taxYear, err := strconv.Atoi(row[1])
+			if err != nil {
+				return req, fmt.Errorf("row %d: invalid tax year", i+2)
+			}
+
+			taxYear = NormalizeTaxYear(taxYear)
+
+			b := BracketImport{Level: row[4], Rate: value}
+
+			if row[3] != "" {
+				max, err := strconv.ParseFloat(row[3], 64)
+				if err != nil {
+					return req, fmt.Errorf("row %d: invalid max", i+2)
+				}
+
+				b.Max = &max
+			}
+
+			idx, ok := bracketsByYear[taxYear]
+			if !ok {
+				idx = len(req.Brackets)
+				bracketsByYear[taxYear] = idx
+				req.Brackets = append(req.Brackets, BracketsImport{TaxYear: taxYear})
+			}
+
+			req.Brackets[idx].Brackets = append(req.Brackets[idx].Brackets, b)
+		default:
+			return req, fmt.Errorf("row %d: unknown section %q", i+2, row[0])
+		}
+	}
+
+	return req, nil
+}
+
+// toSettingsImport validates req and converts it, allowances must be within stored bounds when there is one
+func toSettingsImport(ctx context.Context, bounds BoundReader, req SettingsImportRequest) (database.SettingsImport, error) {
+	imp := database.SettingsImport{Brackets: map[int][]database.TaxBracket{}}
+	amounts := map[string]float64{}
+
+	for _, a := range req.DefaultAllowances {
+		imp.DefaultAllowances = append(imp.DefaultAllowances, database.DefaultAllowance{AllowanceType: a.AllowanceType, Amount: a.Amount})
+		amounts[a.AllowanceType] = a.Amount
+	}
+
+	for _, a := range req.AllowedAllowances {
+		imp.AllowedAllowances = append(imp.AllowedAllowances, database.AllowedAllowance{AllowanceType: a.AllowanceType, MaxAmount: a.MaxAmount})
+		amounts[a.AllowanceType] = a.MaxAmount
+	}
+
+	if len(amounts) != len(req.DefaultAllowances)+len(req.AllowedAllowances) {
+		return imp, errors.New("an allowance is imported twice")
+	}
+
+	for allowanceType, amount := range amounts {
+		if allowanceType == "" || strings.ToLower(allowanceType) != allowanceType || amount < 0 {
+			return imp, fmt.Errorf("invalid allowance %q", allowanceType)
+		}
+
+		bound, err := bounds.FindSettingBound(ctx, allowanceType)
+		if errors.Is(err, database.ErrNotFound) {
+			continue
+		}
+
+		if err != nil {
+			return imp, err
+		}
+
+		if amount < bound.MinAmount || amount > bound.MaxAmount {
+			return imp, fmt.Errorf("allowance %q is out of bound", allowanceType)
+		}
+	}
+
+	for _, y := range req.Brackets {
+		y.TaxYear = NormalizeTaxYear(y.TaxYear)
+
+		if y.TaxYear < 2000 || y.TaxYear > 2999 || len(y.Brackets) == 0 {
+			return imp, fmt.Errorf("invalid brackets of tax year %d", y.TaxYear)
+		}
+
+		if _, ok := imp.Brackets[y.TaxYear]; ok {
+			return imp, fmt.Errorf("brackets of tax year %d are imported twice", y.TaxYear)
+		}
+
+		for i, b := range y.Brackets {
+			highest := i == len(y.Brackets)-1
+
+			if b.Rate < 0 || b.Rate > 1 || (b.Max == nil) != highest || (b.Max != nil && *b.Max <= 0) {
+				return imp, fmt.Errorf("invalid bracket %d of tax year %d", i+1, y.TaxYear)
+			}
+
+			imp.Brackets[y.TaxYear] = append(imp.Brackets[y.TaxYear], database.TaxBracket{
+				TaxYear:    y.TaxYear,
+				Level:      i + 1,
+				Percentage: b.Rate,
+				MaxAmount:  b.Max,
+				Label:      b.Level,
+				Labels:     b.Levels,
+			})
+		}
+	}
+
+	return imp, nil
+}
+
+// Import applies all settings of a JSON or CSV file in one transaction
+func (h *SettingsHandler) Import(c echo.Context) error {
+	var req SettingsImportRequest
+
+	if strings.HasPrefix(c.Request().Header.Get(echo.HeaderContentType), "text/csv") {
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+		}
+
+		parsed, err := parseSettingsCSV(string(body))
+		if err != nil {
+			slog.InfoContext(c.Request().Context(), "rejected settings import", "error", err)
+			return respondError(c, http.StatusBadRequest, errcode.SettingsImportInvalid)
+		}
+
+		req = parsed
+	} else if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	imp, err := toSettingsImport(c.Request().Context(), h.db, req)
+	if err != nil {
+		if c.Request().Context().Err() != nil {
+			return respondQueryError(c)
+		}
+
+		slog.InfoContext(c.Request().Context(), "rejected settings import", "error", err)
+		return respondError(c, http.StatusBadRequest, errcode.SettingsImportInvalid)
+	}
+
+	version, err := h.db.ImportSettings(c.Request().Context(), imp)
+	if errors.Is(err, database.ErrConflict) {
+		return respondError(c, http.StatusConflict, errcode.SettingsVersionConflict)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to import settings", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.SettingsImportFailed)
+	}
+
+	if h.notifier != nil {
+		for _, a := range imp.DefaultAllowances {
+			h.notifier.SettingsChanged(a.AllowanceType, a.Amount)
+		}
+
+		for _, a := range imp.AllowedAllowances {
+			h.notifier.SettingsChanged(a.AllowanceType, a.MaxAmount)
+		}
+
+		for taxYear, brackets := range imp.Brackets {
+			h.notifier.BracketsChanged(taxYear, brackets)
+		}
+	}
+
+	return c.JSON(http.StatusOK, toSettingVersionResponse(version))
+}
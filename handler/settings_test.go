@@ -0,0 +1,370 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type SettingsDBMock struct {
+	mock.Mock
+}
+
+func (o *SettingsDBMock) FindAllSettingVersions(ctx context.Context) ([]database.SettingVersion, error) {
+	args := o.Called(ctx)
+	return args.Get(0).([]database.SettingVersion), args.Error(1)
+}
+
+func (o *SettingsDBMock) FindSettingHistory(ctx context.Context, at time.Time) ([]database.SettingHistory, error) {
+	args := o.Called(ctx, at)
+	return args.Get(0).([]database.SettingHistory), args.Error(1)
+}
+
+func (o *SettingsDBMock) RollbackSettings(ctx context.Context, version int) (database.SettingVersion, error) {
+	args := o.Called(ctx, version)
+	return args.Get(0).(database.SettingVersion), args.Error(1)
+}
+
+func (o *SettingsDBMock) FindSettingBound(ctx context.Context, setting string) (database.SettingBound, error) {
+	args := o.Called(ctx, setting)
+	return args.Get(0).(database.SettingBound), args.Error(1)
+}
+
+func (o *SettingsDBMock) ImportSettings(ctx context.Context, imp database.SettingsImport) (database.SettingVersion, error) {
+	args := o.Called(ctx, imp)
+	return args.Get(0).(database.SettingVersion), args.Error(1)
+}
+
+func TestAdminGetSettingVersions(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	dbmock := new(SettingsDBMock)
+	dbmock.On("FindAllSettingVersions", mock.Anything).Return([]database.SettingVersion{
+		{
+			Version:           2,
+			DefaultAllowances: map[string]float64{"personal": 70_000},
+			AllowedAllowances: map[string]float64{"donation": 100_000, "k-receipt": 50_000},
+			CreatedAt:         createdAt,
+		},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/settings/versions", nil)
+	rec := httptest.NewRecorder()
+
+	e := echo.New()
+
+	assert.NoError(t, NewSettingsHandler(dbmock).GetVersions(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, `"2"`, rec.Header().Get("ETag"))
+
+	var got []SettingVersionResponse
+
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, []SettingVersionResponse{
+		{
+			Version:           2,
+			DefaultAllowances: map[string]float64{"personal": 70_000},
+			AllowedAllowances: map[string]float64{"donation": 100_000, "k-receipt": 50_000},
+			CreatedAt:         createdAt,
+		},
+	}, got)
+}
+
+func TestAdminGetSettingHistory(t *testing.T) {
+	at := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	validFrom := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	amount := 60_000.0
+
+	dbmock := new(SettingsDBMock)
+	dbmock.On("FindSettingHistory", mock.Anything, at).Return([]database.SettingHistory{
+		{Kind: database.AllowanceKindDefault, Name: "personal", Amount: &amount, ValidFrom: validFrom},
+	}, nil)
+
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/settings/history?at=2024-03-01T00:00:00Z", nil)
+	rec := httptest.NewRecorder()
+
+	assert.NoError(t, NewSettingsHandler(dbmock).GetHistory(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var got []SettingHistoryResponse
+
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, []SettingHistoryResponse{
+		{Kind: "default", Name: "personal", Amount: &amount, ValidFrom: validFrom},
+	}, got)
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/settings/history?at=yesterday", nil)
+	rec = httptest.NewRecorder()
+
+	assert.NoError(t, NewSettingsHandler(dbmock).GetHistory(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestAdminRollbackSettings(t *testing.T) {
+	type TC struct {
+		version      string
+		mockRollback *MockSetting
+		wantCode     int
+		wantErr      errcode.Code
+	}
+
+	restored := database.SettingVersion{
+		Version:           3,
+		DefaultAllowances: map[string]float64{"personal": 60_000},
+		AllowedAllowances: map[string]float64{"k-receipt": 50_000},
+		Brackets:          map[int][]database.TaxBracket{2024: {{TaxYear: 2024, Level: 1, Percentage: 0.1, Label: "0 ขึ้นไป"}}},
+	}
+
+	tcs := []TC{
+		{
+			version:      "1",
+			mockRollback: &MockSetting{Args: []interface{}{mock.Anything, 1}, Returns: []interface{}{restored, nil}},
+			wantCode:     http.StatusOK,
+		},
+		{
+			version:  "abc",
+			wantCode: http.StatusBadRequest,
+			wantErr:  errcode.SettingVersionInvalid,
+		},
+		{
+			version:      "9",
+			mockRollback: &MockSetting{Args: []interface{}{mock.Anything, 9}, Returns: []interface{}{database.SettingVersion{}, database.ErrNotFound}},
+			wantCode:     http.StatusNotFound,
+			wantErr:      errcode.SettingVersionNotFound,
+		},
+		{
+			version:      "1",
+			mockRollback: &MockSetting{Args: []interface{}{mock.Anything, 1}, Returns: []interface{}{database.SettingVersion{}, database.ErrConflict}},
+			wantCode:     http.StatusConflict,
+			wantErr:      errcode.SettingsVersionConflict,
+		},
+		{
+			version:      "1",
+			mockRollback: &MockSetting{Args: []interface{}{mock.Anything, 1}, Returns: []interface{}{database.SettingVersion{}, errors.New("an error")}},
+			wantCode:     http.StatusInternalServerError,
+			wantErr:      errcode.SettingsRollbackFailed,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(SettingsDBMock)
+
+			if tc.mockRollback != nil {
+				dbmock.On("RollbackSettings", tc.mockRollback.Args...).Return(tc.mockRollback.Returns...)
+			}
+
+			notifier := new(NotifierMock)
+			notifier.On("SettingsChanged", "personal", float64(60_000)).Return()
+			notifier.On("SettingsChanged", "k-receipt", float64(50_000)).Return()
+			notifier.On("BracketsChanged", 2024, restored.Brackets[2024]).Return()
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/settings/rollback/"+tc.version, nil)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("version")
+			c.SetParamValues(tc.version)
+
+			assert.NoError(t, NewSettingsHandler(dbmock).SetNotifier(notifier).Rollback(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantErr != "" {
+				var got ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, tc.wantErr, got.ErrorCode)
+				notifier.AssertNotCalled(t, "SettingsChanged", mock.Anything, mock.Anything)
+				notifier.AssertNotCalled(t, "BracketsChanged", mock.Anything, mock.Anything)
+
+				return
+			}
+
+			notifier.AssertExpectations(t)
+		})
+	}
+}
+
+func TestExpectedSettingsVersion(t *testing.T) {
+	type TC struct {
+		ifMatch     string
+		wantCode    int
+		wantVersion int
+		wantOK      bool
+	}
+
+	tcs := []TC{
+		{ifMatch: "", wantCode: http.StatusOK},
+		{ifMatch: `"4"`, wantCode: http.StatusOK, wantVersion: 4, wantOK: true},
+		{ifMatch: "4", wantCode: http.StatusOK, wantVersion: 4, wantOK: true},
+		{ifMatch: `"abc"`, wantCode: http.StatusBadRequest},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/admin/settings/rollback/1", nil)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+
+			var gotVersion int
+			var gotOK bool
+
+			next := func(c echo.Context) error {
+				dbmock := new(SettingsDBMock)
+				dbmock.On("RollbackSettings", mock.Anything, 1).Return(database.SettingVersion{}, nil).Run(func(args mock.Arguments) {
+					gotVersion, gotOK = database.ExpectedVersion(args.Get(0).(context.Context))
+				})
+
+				return NewSettingsHandler(dbmock).Rollback(c)
+			}
+
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("version")
+			c.SetParamValues("1")
+
+			assert.NoError(t, ExpectedSettingsVersion()(next)(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Equal(t, tc.wantOK, gotOK)
+			assert.Equal(t, tc.wantVersion, gotVersion)
+		})
+	}
+}
+
+func TestAdminImportSettings(t *testing.T) {
+	max := float64(150_000)
+
+	want := database.SettingsImport{
+		DefaultAllowances: []database.DefaultAllowance{{AllowanceType: "personal", Amount: 60_000}},
+		AllowedAllowances: []database.AllowedAllowance{{AllowanceType: "donation", MaxAmount: 100_000}},
+		Brackets: map[int][]database.TaxBracket{
+			2025: {
+				{TaxYear: 2025, Level: 1, Percentage: 0, MaxAmount: &max, Label: "0-150,000"},
+				{TaxYear: 2025, Level: 2, Percentage: 0.1, Label: "150,001 ขึ้นไป"},
+			},
+		},
+	}
+
+	type TC struct {
+		contentType string
+		reqbody     string
+		mockImport  *MockSetting
+		wantCode    int
+		wantErr     errcode.Code
+	}
+
+	tcs := []TC{
+		{
+			contentType: "application/json",
+			reqbody: `{
+				"defaultAllowances":[{"allowanceType":"personal","amount":60000}],
+				"allowedAllowances":[{"allowanceType":"donation","maxAmount":100000}],
+				"brackets":[{"taxYear":2025,"brackets":[
+					{"level":"0-150,000","rate":0,"max":150000},
+					{"level":"150,001 ขึ้นไป","rate":0.1,"max":null}
+				]}]
+			}`,
+			mockImport: &MockSetting{Args: []interface{}{mock.Anything, want}, Returns: []interface{}{database.SettingVersion{Version: 2}, nil}},
+			wantCode:   http.StatusOK,
+		},
+		{
+			contentType: "text/csv",
+			reqbody: "section,name,value,max,label\n" +
+				"default,personal,60000,,\n" +
+				"allowed,donation,100000,,\n" +
+				"bracket,2025,0,150000,\"0-150,000\"\n" +
+				"bracket,2025,0.1,,\"150,001 ขึ้นไป\"\n",
+			mockImport: &MockSetting{Args: []interface{}{mock.Anything, want}, Returns: []interface{}{database.SettingVersion{Version: 2}, nil}},
+			wantCode:   http.StatusOK,
+		},
+		{
+			contentType: "text/csv",
+			reqbody:     "section,name,value,max,label\nunknown,personal,60000,,\n",
+			wantCode:    http.StatusBadRequest,
+			wantErr:     errcode.SettingsImportInvalid,
+		},
+		{
+			contentType: "application/json",
+			reqbody:     `{"brackets":[{"taxYear":2025,"brackets":[{"level":"0-150,000","rate":0,"max":150000}]}]}`,
+			wantCode:    http.StatusBadRequest,
+			wantErr:     errcode.SettingsImportInvalid,
+		},
+		{
+			contentType: "application/json",
+			reqbody:     `{"defaultAllowances":[{"allowanceType":"personal","amount":5000}]}`,
+			wantCode:    http.StatusBadRequest,
+			wantErr:     errcode.SettingsImportInvalid,
+		},
+		{
+			contentType: "application/json",
+			reqbody:     `{"defaultAllowances":[{"allowanceType":"personal","amount":60000}],"allowedAllowances":[{"allowanceType":"personal","maxAmount":60000}]}`,
+			wantCode:    http.StatusBadRequest,
+			wantErr:     errcode.SettingsImportInvalid,
+		},
+		{
+			contentType: "application/json",
+			reqbody:     `{"defaultAllowances":[{"allowanceType":"personal","amount":60000}]}`,
+			mockImport:  &MockSetting{Args: []interface{}{mock.Anything, mock.Anything}, Returns: []interface{}{database.SettingVersion{}, errors.New("an error")}},
+			wantCode:    http.StatusInternalServerError,
+			wantErr:     errcode.SettingsImportFailed,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(SettingsDBMock)
+			dbmock.On("FindSettingBound", mock.Anything, "personal").
+				Return(database.SettingBound{Setting: "personal", MinAmount: 10_000, MaxAmount: 100_000}, nil)
+			dbmock.On("FindSettingBound", mock.Anything, mock.Anything).Return(database.SettingBound{}, database.ErrNotFound)
+
+			if tc.mockImport != nil {
+				dbmock.On("ImportSettings", tc.mockImport.Args...).Return(tc.mockImport.Returns...)
+			}
+
+			notifier := new(NotifierMock)
+			notifier.On("SettingsChanged", mock.Anything, mock.Anything).Return()
+			notifier.On("BracketsChanged", mock.Anything, mock.Anything).Return()
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/settings/import", strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", tc.contentType)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, NewSettingsHandler(dbmock).SetNotifier(notifier).Import(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantErr != "" {
+				var got ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, tc.wantErr, got.ErrorCode)
+				dbmock.AssertNotCalled(t, "ImportSettings", mock.Anything, want)
+				notifier.AssertNotCalled(t, "BracketsChanged", mock.Anything, mock.Anything)
+
+				return
+			}
+
+			dbmock.AssertExpectations(t)
+			notifier.AssertCalled(t, "SettingsChanged", "personal", float64(60_000))
+			notifier.AssertCalled(t, "BracketsChanged", 2025, want.Brackets[2025])
+		})
+	}
+}
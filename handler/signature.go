@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	signatureHeader          = "X-Signature"
+	signatureTimestampHeader = "X-Signature-Timestamp"
+	signaturePrefix          = "sha256="
+)
+
+// SignRequest returns hex HMAC-SHA256 of method, path with query, unix timestamp and uncompressed body
+func SignRequest(secret string, method string, path string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(method + "\n" + path + "\n" + timestamp + "\n"))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ParseSignature returns hex HMAC of signature header, sha256=<hex>, when it's signed within maxAge of now.
+// Otherwise code tells why the request is rejected
+func ParseSignature(header string, timestamp string, maxAge time.Duration) (string, errcode.Code, bool) {
+	signature, found := strings.CutPrefix(header, signaturePrefix)
+	if timestamp == "" || !found {
+		return "", errcode.SignatureInvalid, false
+	}
+
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "", errcode.SignatureInvalid, false
+	}
+
+	age := time.Since(time.Unix(sec, 0))
+	if age > maxAge || age < -maxAge {
+		return "", errcode.SignatureExpired, false
+	}
+
+	return signature, "", true
+}
+
+// VerifySignature rejects tampered requests or requests older than maxAge from keys with signing secret,
+// it must be used after APIKeyAuth
+func VerifySignature(maxAge time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			k, ok := CurrentAPIKey(c)
+			if !ok || k.SigningSecret == nil {
+				return next(c)
+			}
+
+			req := c.Request()
+
+			timestamp := req.Header.Get(signatureTimestampHeader)
+
+			signature, code, ok := ParseSignature(req.Header.Get(signatureHeader), timestamp, maxAge)
+			if !ok {
+				return respondError(c, http.StatusUnauthorized, code)
+			}
+
+			body, err := io.ReadAll(req.Body)
+			if errors.Is(err, echo.ErrStatusRequestEntityTooLarge) {
+				return respondError(c, http.StatusRequestEntityTooLarge, errcode.PayloadTooLarge)
+			}
+
+			if err != nil {
+				return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+			}
+
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			// the uri sent by client, /v1 is stripped from the url before routing
+			uri := req.RequestURI
+			if uri == "" {
+				uri = req.URL.RequestURI()
+			}
+
+			want := SignRequest(*k.SigningSecret, req.Method, uri, timestamp, body)
+			if !hmac.Equal([]byte(signature), []byte(want)) {
+				return respondError(c, http.StatusUnauthorized, errcode.SignatureInvalid)
+			}
+
+			return next(c)
+		}
+	}
+}
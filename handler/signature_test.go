@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifySignature(t *testing.T) {
+	secret := "partner-secret"
+	body := `{"totalIncome":500000}`
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+
+	type TC struct {
+		key       database.APIKey
+		timestamp string
+		signature string
+		wantCode  int
+	}
+
+	tcs := []TC{
+		{
+			key:       database.APIKey{ID: 1, SigningSecret: &secret},
+			timestamp: now,
+			signature: "sha256=" + SignRequest(secret, http.MethodPost, "/tax/calculations?taxYear=2024", now, []byte(body)),
+			wantCode:  http.StatusOK,
+		},
+		{
+			key:       database.APIKey{ID: 1, SigningSecret: &secret},
+			timestamp: now,
+			signature: "sha256=" + SignRequest(secret, http.MethodPost, "/tax/calculations?taxYear=2024", now, []byte(`{"totalIncome":1}`)),
+			wantCode:  http.StatusUnauthorized,
+		},
+		{
+			key:       database.APIKey{ID: 1, SigningSecret: &secret},
+			timestamp: stale,
+			signature: "sha256=" + SignRequest(secret, http.MethodPost, "/tax/calculations?taxYear=2024", stale, []byte(body)),
+			wantCode:  http.StatusUnauthorized,
+		},
+		{
+			key:      database.APIKey{ID: 1, SigningSecret: &secret},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			key:      database.APIKey{ID: 2},
+			wantCode: http.StatusOK,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			e := echo.New()
+			e.POST("/tax/calculations", func(c echo.Context) error {
+				// body is still readable by handler
+				b, _ := io.ReadAll(c.Request().Body)
+				assert.Equal(t, body, string(b))
+
+				return c.NoContent(http.StatusOK)
+			}, func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					c.Set(apiKeyContextKey, tc.key)
+					return next(c)
+				}
+			}, VerifySignature(5*time.Minute))
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations?taxYear=2024", strings.NewReader(body))
+			req.Header.Set("X-Signature-Timestamp", tc.timestamp)
+			req.Header.Set("X-Signature", tc.signature)
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type TenantRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// APIKeyTenantRequest assigns a key to a tenant, null tenantId makes the key use the global settings
+type APIKeyTenantRequest struct {
+	TenantID *int `json:"tenantId" validate:"omitempty,gt=0"`
+}
+
+type TenantResponse struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	UUID      string    `json:"uuid"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// TenantReader finds overrides of a tenant, they take precedence over the global settings for its keys
+type TenantReader interface {
+	FindTenantSettings(ctx context.Context, tenantID int) (database.SettingsImport, error)
+}
+
+type TenantIDB interface {
+	FindAllTenants(ctx context.Context) ([]database.Tenant, error)
+	FindTenant(ctx context.Context, id int) (database.Tenant, error)
+	CreateTenant(ctx context.Context, name string) (database.Tenant, error)
+	DeleteTenant(ctx context.Context, id int) error
+	FindTenantSettings(ctx context.Context, tenantID int) (database.SettingsImport, error)
+	ReplaceTenantSettings(ctx context.Context, tenantID int, s database.SettingsImport) error
+	FindSettingBound(ctx context.Context, setting string) (database.SettingBound, error)
+	UpdateAPIKeyTenant(ctx context.Context, id int, tenantID *int) (database.APIKey, error)
+}
+
+type TenantHandler struct {
+	vl *validator.Validate
+	db TenantIDB
+}
+
+func NewTenantHandler(vl *validator.Validate, db TenantIDB) *TenantHandler {
+	return &TenantHandler{vl, db}
+}
+
+func toTenantResponse(t database.Tenant) TenantResponse {
+	return TenantResponse{
+		ID:        t.ID,
+		Name:      t.Name,
+		UUID:      t.UUID,
+		CreatedAt: t.CreatedAt,
+		UpdatedAt: t.UpdatedAt,
+	}
+}
+
+// toSettingsImportRequest converts overrides to the format they're replaced in, brackets are ordered by tax year
+func toSettingsImportRequest(s database.SettingsImport) SettingsImportRequest {
+	req := SettingsImportRequest{
+		DefaultAllowances: []DefaultAllowanceResponse{},
+		AllowedAllowances: []AllowedAllowanceResponse{},
+		Brackets:          []BracketsImport{},
+	}
+
+	for _, a := range s.DefaultAllowances {
+		req.DefaultAllowances = append(req.DefaultAllowances, DefaultAllowanceResponse{AllowanceType: a.AllowanceType, Amount: a.Amount})
+	}
+
+	for _, a := range s.AllowedAllowances {
+		req.AllowedAllowances = append(req.AllowedAllowances, AllowedAllowanceResponse{AllowanceType: a.AllowanceType, MaxAmount: a.MaxAmount})
+	}
+
+	for taxYear, brackets := range s.Brackets {
+		y := BracketsImport{TaxYear: taxYear, Brackets: []BracketImport{}}
+
+		for _, b := range brackets {
+			y.Brackets = append(y.Brackets, BracketImport{Level: b.Label, Levels: b.Labels, Rate: b.Percentage, Max: b.MaxAmount})
+		}
+
+		req.Brackets = append(req.Brackets, y)
+	}
+
+	sort.Slice(req.Brackets, func(i, j int) bool { return req.Brackets[i].TaxYear < req.Brackets[j].TaxYear })
+
+	return req
+}
+
+func (h *TenantHandler) GetTenants(c echo.Context) error {
+	tenants, err := h.db.FindAllTenants(c.Request().Context())
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find tenants", "error", err)
+		return respondQueryError(c)
+	}
+
+	results := []TenantResponse{}
+
+	for _, t := range tenants {
+		results = append(results, toTenantResponse(t))
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+func (h *TenantHandler) CreateTenant(c echo.Context) error {
+	var req TenantRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	created, err := h.db.CreateTenant(c.Request().Context(), req.Name)
+	if errors.Is(err, database.ErrAlreadyExists) {
+		return respondError(c, http.StatusConflict, errcode.TenantExists)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to create tenant", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.JSON(http.StatusCreated, toTenantResponse(created))
+}
+
+// DeleteTenant deletes tenant with its overrides, keys must be moved to another tenant first
+func (h *TenantHandler) DeleteTenant(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.TenantInvalidID)
+	}
+
+	err = h.db.DeleteTenant(c.Request().Context(), id)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.TenantNotFound)
+	}
+
+	if errors.Is(err, database.ErrInUse) {
+		return respondError(c, http.StatusConflict, errcode.TenantInUse)
+	}
+
+	if errors.Is(err, database.ErrConflict) {
+		return respondError(c, http.StatusConflict, errcode.SettingsVersionConflict)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to delete tenant", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetTenantSettings returns overrides of tenant, settings which aren't overridden are left out
+func (h *TenantHandler) GetTenantSettings(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.TenantInvalidID)
+	}
+
+	_, err = h.db.FindTenant(c.Request().Context(), id)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.TenantNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find tenant", "error", err)
+		return respondQueryError(c)
+	}
+
+	s, err := h.db.FindTenantSettings(c.Request().Context(), id)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find tenant settings", "tenant", id, "error", err)
+		return respondQueryError(c)
+	}
+
+	return c.JSON(http.StatusOK, toSettingsImportRequest(s))
+}
+
+// ReplaceTenantSettings replaces every override of tenant, it's validated like a settings import
+// and an empty body removes every override
+func (h *TenantHandler) ReplaceTenantSettings(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.TenantInvalidID)
+	}
+
+	var req SettingsImportRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	s, err := toSettingsImport(c.Request().Context(), h.db, req)
+	if err != nil {
+		if c.Request().Context().Err() != nil {
+			return respondQueryError(c)
+		}
+
+		slog.InfoContext(c.Request().Context(), "rejected tenant settings", "tenant", id, "error", err)
+		return respondError(c, http.StatusBadRequest, errcode.SettingsImportInvalid)
+	}
+
+	err = h.db.ReplaceTenantSettings(c.Request().Context(), id, s)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.TenantNotFound)
+	}
+
+	if errors.Is(err, database.ErrConflict) {
+		return respondError(c, http.StatusConflict, errcode.SettingsVersionConflict)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to replace tenant settings", "tenant", id, "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.SettingsImportFailed)
+	}
+
+	return c.JSON(http.StatusOK, toSettingsImportRequest(s))
+}
+
+// AssignAPIKey assigns key to a tenant, its next requests are calculated with overrides of the tenant
+func (h *TenantHandler) AssignAPIKey(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.APIKeyInvalidID)
+	}
+
+	var req APIKeyTenantRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if req.TenantID != nil {
+		_, err := h.db.FindTenant(c.Request().Context(), *req.TenantID)
+		if errors.Is(err, database.ErrNotFound) {
+			return respondError(c, http.StatusNotFound, errcode.TenantNotFound)
+		}
+
+		if err != nil {
+			slog.ErrorContext(c.Request().Context(), "failed to find tenant", "error", err)
+			return respondQueryError(c)
+		}
+	}
+
+	// the tenant may be deleted after it's found, the key is reported missing then
+	updated, err := h.db.UpdateAPIKeyTenant(c.Request().Context(), id, req.TenantID)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.APIKeyNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to assign api key to tenant", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.JSON(http.StatusOK, toAPIKeyResponse(updated))
+}
+
+// findTenantSettings returns overrides of tenantID, nothing is overridden for keys without a tenant
+func findTenantSettings(ctx context.Context, tenants TenantReader, tenantID *int) (database.SettingsImport, error) {
+	if tenants == nil || tenantID == nil {
+		return database.SettingsImport{}, nil
+	}
+
+	s, err := tenants.FindTenantSettings(ctx, *tenantID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find tenant settings", "tenant", *tenantID, "error", err)
+		return database.SettingsImport{}, err
+	}
+
+	return s, nil
+}
+
+// overrideAllowances applies allowances of a tenant to settings, types disabled globally stay disabled.
+// settings are cloned since maintenance mode keeps the ones it has loaded
+func overrideAllowances(settings allowanceSettings, overrides database.SettingsImport) allowanceSettings {
+	if len(overrides.DefaultAllowances) == 0 && len(overrides.AllowedAllowances) == 0 {
+		return settings
+	}
+
+	settings = settings.clone()
+
+	for _, a := range overrides.DefaultAllowances {
+		if !settings.disabled[a.AllowanceType] {
+			settings.defaultAllowances[a.AllowanceType] = a.Amount
+		}
+	}
+
+	for _, a := range overrides.AllowedAllowances {
+		if !settings.disabled[a.AllowanceType] {
+			settings.allowedAllowances[a.AllowanceType] = a.MaxAmount
+		}
+	}
+
+	return settings
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type TenantDBMock struct {
+	mock.Mock
+}
+
+func (o *TenantDBMock) FindAllTenants(ctx context.Context) ([]database.Tenant, error) {
+	args := o.Called(ctx)
+	return args.Get(0).([]database.Tenant), args.Error(1)
+}
+
+func (o *TenantDBMock) FindTenant(ctx context.Context, id int) (database.Tenant, error) {
+	args := o.Called(ctx, id)
+	return args.Get(0).(database.Tenant), args.Error(1)
+}
+
+func (o *TenantDBMock) CreateTenant(ctx context.Context, name string) (database.Tenant, error) {
+	args := o.Called(ctx, name)
+	return args.Get(0).(database.Tenant), args.Error(1)
+}
+
+func (o *TenantDBMock) DeleteTenant(ctx context.Context, id int) error {
+	args := o.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (o *TenantDBMock) FindTenantSettings(ctx context.Context, tenantID int) (database.SettingsImport, error) {
+	args := o.Called(ctx, tenantID)
+	return args.Get(0).(database.SettingsImport), args.Error(1)
+}
+
+func (o *TenantDBMock) ReplaceTenantSettings(ctx context.Context, tenantID int, s database.SettingsImport) error {
+	args := o.Called(ctx, tenantID, s)
+	return args.Error(0)
+}
+
+func (o *TenantDBMock) FindSettingBound(ctx context.Context, setting string) (database.SettingBound, error) {
+	args := o.Called(ctx, setting)
+	return args.Get(0).(database.SettingBound), args.Error(1)
+}
+
+func (o *TenantDBMock) UpdateAPIKeyTenant(ctx context.Context, id int, tenantID *int) (database.APIKey, error) {
+	args := o.Called(ctx, id, tenantID)
+	return args.Get(0).(database.APIKey), args.Error(1)
+}
+
+func TestTenantReplaceTenantSettings(t *testing.T) {
+	type TC struct {
+		id         string
+		reqbody    string
+		replaceErr error
+		wantCode   int
+		wantError  errcode.Code
+	}
+
+	tcs := []TC{
+		{
+			id:       "1",
+			reqbody:  `{"allowedAllowances":[{"allowanceType":"donation","maxAmount":150000}],"brackets":[{"taxYear":2024,"brackets":[{"level":"0-300,000","rate":0,"max":300000},{"level":"300,001 ขึ้นไป","rate":0.1,"max":null}]}]}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			id:        "1",
+			reqbody:   `{"allowedAllowances":[{"allowanceType":"donation","maxAmount":300000}]}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: errcode.SettingsImportInvalid,
+		},
+		{
+			id:         "2",
+			reqbody:    `{}`,
+			replaceErr: database.ErrNotFound,
+			wantCode:   http.StatusNotFound,
+			wantError:  errcode.TenantNotFound,
+		},
+		{
+			id:        "abc",
+			reqbody:   `{}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: errcode.TenantInvalidID,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(TenantDBMock)
+			mockObj.On("FindSettingBound", mock.Anything, "donation").Return(database.SettingBound{Setting: "donation", MinAmount: 0, MaxAmount: 200_000}, nil)
+			mockObj.On("ReplaceTenantSettings", mock.Anything, mock.Anything, mock.Anything).Return(tc.replaceErr)
+
+			req := httptest.NewRequest(http.MethodPut, "/admin/tenants/"+tc.id+"/settings", strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tc.id)
+
+			assert.NoError(t, NewTenantHandler(validator.New(), mockObj).ReplaceTenantSettings(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				var got ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, tc.wantError, got.ErrorCode)
+
+				return
+			}
+
+			var got SettingsImportRequest
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, []AllowedAllowanceResponse{{AllowanceType: "donation", MaxAmount: 150_000}}, got.AllowedAllowances)
+			assert.Len(t, got.Brackets, 1)
+			assert.Len(t, got.Brackets[0].Brackets, 2)
+		})
+	}
+}
+
+func TestTenantDeleteTenant(t *testing.T) {
+	type TC struct {
+		deleteErr error
+		wantCode  int
+	}
+
+	tcs := []TC{
+		{wantCode: http.StatusNoContent},
+		{deleteErr: database.ErrNotFound, wantCode: http.StatusNotFound},
+		{deleteErr: database.ErrInUse, wantCode: http.StatusConflict},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(TenantDBMock)
+			mockObj.On("DeleteTenant", mock.Anything, 1).Return(tc.deleteErr)
+
+			req := httptest.NewRequest(http.MethodDelete, "/admin/tenants/1", nil)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+
+			assert.NoError(t, NewTenantHandler(validator.New(), mockObj).DeleteTenant(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
+
+func TestUserCalculateTaxTenant(t *testing.T) {
+	type TC struct {
+		tenantID *int
+		wantTax  float64
+	}
+
+	allowances, brackets := 1, 2
+
+	tcs := []TC{
+		{tenantID: nil, wantTax: 19_000},
+		{tenantID: &allowances, wantTax: 14_000},
+		{tenantID: &brackets, wantTax: 4_000},
+	}
+
+	max := 300_000.0
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(UserDBMock)
+			mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+				{AllowanceType: "donation", MaxAmount: 100_000},
+			}, nil)
+
+			tenants := new(TenantDBMock)
+			tenants.On("FindTenantSettings", mock.Anything, allowances).Return(database.SettingsImport{
+				AllowedAllowances: []database.AllowedAllowance{{AllowanceType: "donation", MaxAmount: 150_000}},
+			}, nil)
+			tenants.On("FindTenantSettings", mock.Anything, brackets).Return(database.SettingsImport{
+				Brackets: map[int][]database.TaxBracket{
+					2024: {{Percentage: 0, MaxAmount: &max, Label: "0-300,000"}, {Percentage: 0.1, Label: "300,001 ขึ้นไป"}},
+				},
+			}, nil)
+
+			h := NewTaxHandler(validator.New(), mockObj).SetTenants(tenants)
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations", strings.NewReader(
+				`{"totalIncome":500000,"wht":0,"allowances":[{"allowanceType":"donation","amount":200000}]}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+			c := e.NewContext(req, rec)
+			c.Set(apiKeyContextKey, database.APIKey{ID: 1, TenantID: tc.tenantID})
+
+			assert.NoError(t, h.CalculateTax(c))
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var got TaxResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tc.wantTax, got.Tax)
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/AnnaCarter465/assessment-tax/pkg/secretbox"
+	"github.com/AnnaCarter465/assessment-tax/pkg/totp"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"rsc.io/qr"
+)
+
+const totpIssuer = "Assessment Tax"
+
+type TOTPEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type TOTPVerifyRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type TOTPIDB interface {
+	FindAdminUserByUsername(ctx context.Context, username string) (database.AdminUser, error)
+	UpdateAdminUserTOTP(ctx context.Context, username string, secret []byte, enabled bool) error
+}
+
+// TOTPHandler enrolls two-factor authentication of the signed in admin user
+type TOTPHandler struct {
+	vl  *validator.Validate
+	db  TOTPIDB
+	box *secretbox.Box
+}
+
+func NewTOTPHandler(vl *validator.Validate, db TOTPIDB, box *secretbox.Box) *TOTPHandler {
+	return &TOTPHandler{vl, db, box}
+}
+
+// currentUser returns admin user signed in, admin from env variables has no user
+func (h *TOTPHandler) currentUser(c echo.Context) (database.AdminUser, error) {
+	claims, ok := CurrentAdmin(c)
+	if !ok {
+		return database.AdminUser{}, database.ErrNotFound
+	}
+
+	return h.db.FindAdminUserByUsername(c.Request().Context(), claims.Subject)
+}
+
+func (h *TOTPHandler) respondUserError(c echo.Context, err error) error {
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.AdminUserNotFound)
+	}
+
+	slog.ErrorContext(c.Request().Context(), "failed to find admin user", "error", err)
+
+	return respondQueryError(c)
+}
+
+// pendingSecret returns secret enrolled but not verified yet
+func (h *TOTPHandler) pendingSecret(u database.AdminUser) (string, bool) {
+	if u.TOTPEnabled || len(u.TOTPSecret) == 0 {
+		return "", false
+	}
+
+	secret, err := h.box.Open(u.TOTPSecret)
+	if err != nil {
+		return "", false
+	}
+
+	return string(secret), true
+}
+
+// Enroll generates new secret, it's enabled after the first code is verified
+func (h *TOTPHandler) Enroll(c echo.Context) error {
+	u, err := h.currentUser(c)
+	if err != nil {
+		return h.respondUserError(c, err)
+	}
+
+	if u.TOTPEnabled {
+		return respondError(c, http.StatusConflict, errcode.TOTPAlreadyEnabled)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to generate totp secret", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	sealed, err := h.box.Seal([]byte(secret))
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to encrypt totp secret", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	if err := h.db.UpdateAdminUserTOTP(c.Request().Context(), u.Username, sealed, false); err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to update totp secret", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.JSON(http.StatusCreated, TOTPEnrollResponse{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, u.Username, secret),
+	})
+}
+
+// QRCode returns png qr code of pending enrollment for authenticator apps
+func (h *TOTPHandler) QRCode(c echo.Context) error {
+	u, err := h.currentUser(c)
+	if err != nil {
+		return h.respondUserError(c, err)
+	}
+
+	secret, ok := h.pendingSecret(u)
+	if !ok {
+		return respondError(c, http.StatusNotFound, errcode.TOTPNotEnrolled)
+	}
+
+	code, err := qr.Encode(totp.URI(totpIssuer, u.Username, secret), qr.M)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to encode qr code", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+
+	return c.Blob(http.StatusOK, "image/png", code.PNG())
+}
+
+// Verify enables two-factor authentication of pending enrollment
+func (h *TOTPHandler) Verify(c echo.Context) error {
+	var req TOTPVerifyRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	u, err := h.currentUser(c)
+	if err != nil {
+		return h.respondUserError(c, err)
+	}
+
+	secret, ok := h.pendingSecret(u)
+	if !ok {
+		return respondError(c, http.StatusNotFound, errcode.TOTPNotEnrolled)
+	}
+
+	if !totp.Validate(secret, req.Code, time.Now()) {
+		return respondError(c, http.StatusBadRequest, errcode.TOTPInvalid)
+	}
+
+	if err := h.db.UpdateAdminUserTOTP(c.Request().Context(), u.Username, u.TOTPSecret, true); err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to enable totp", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
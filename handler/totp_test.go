@@ -0,0 +1,156 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/AnnaCarter465/assessment-tax/pkg/secretbox"
+	"github.com/AnnaCarter465/assessment-tax/pkg/totp"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newTestBox(t *testing.T) *secretbox.Box {
+	box, err := secretbox.New([]byte("0123456789abcdef0123456789abcdef"))
+	assert.NoError(t, err)
+
+	return box
+}
+
+func newAdminContext(e *echo.Echo, req *http.Request, rec *httptest.ResponseRecorder, username string) echo.Context {
+	c := e.NewContext(req, rec)
+	c.Set(adminClaimsContextKey, &AdminClaims{
+		Role:             RoleViewer,
+		RegisteredClaims: jwt.RegisteredClaims{Subject: username},
+	})
+
+	return c
+}
+
+func TestTOTPEnrollAndVerify(t *testing.T) {
+	box := newTestBox(t)
+
+	dbmock := new(AdminUserDBMock)
+	dbmock.On("FindAdminUserByUsername", mock.Anything, "somchai").
+		Return(database.AdminUser{ID: 1, Username: "somchai", Role: RoleViewer}, nil).Once()
+	dbmock.On("UpdateAdminUserTOTP", mock.Anything, "somchai", mock.Anything, false).Return(nil)
+
+	h := NewTOTPHandler(validator.New(), dbmock, box)
+	e := echo.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/totp", nil)
+	rec := httptest.NewRecorder()
+
+	assert.NoError(t, h.Enroll(newAdminContext(e, req, rec, "somchai")))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+
+	var enrolled TOTPEnrollResponse
+
+	err := json.Unmarshal([]byte(rec.Body.String()), &enrolled)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(enrolled.URI, "otpauth://totp/"))
+
+	// secret is stored encrypted
+	sealed := dbmock.Calls[1].Arguments.Get(2).([]byte)
+	assert.NotContains(t, string(sealed), enrolled.Secret)
+
+	dbmock.On("FindAdminUserByUsername", mock.Anything, "somchai").
+		Return(database.AdminUser{ID: 1, Username: "somchai", Role: RoleViewer, TOTPSecret: sealed}, nil)
+	dbmock.On("UpdateAdminUserTOTP", mock.Anything, "somchai", sealed, true).Return(nil)
+
+	type TC struct {
+		code     string
+		wantCode int
+	}
+
+	valid, _ := totp.Code(enrolled.Secret, time.Now())
+
+	tcs := []TC{
+		{code: "000000", wantCode: http.StatusBadRequest},
+		{code: "abc", wantCode: http.StatusBadRequest},
+		{code: valid, wantCode: http.StatusNoContent},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			val, _ := json.Marshal(map[string]interface{}{"code": tc.code})
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/totp/verify", strings.NewReader(string(val)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			assert.NoError(t, h.Verify(newAdminContext(e, req, rec, "somchai")))
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+
+	dbmock.AssertCalled(t, "UpdateAdminUserTOTP", mock.Anything, "somchai", sealed, true)
+}
+
+func TestAdminLoginWithTOTP(t *testing.T) {
+	box := newTestBox(t)
+
+	secret, _ := totp.GenerateSecret()
+	sealed, _ := box.Seal([]byte(secret))
+	hash, _ := bcrypt.GenerateFromPassword([]byte("correct horse battery"), bcrypt.MinCost)
+
+	dbmock := new(AdminUserDBMock)
+	dbmock.On("FindAdminUserByUsername", mock.Anything, "somchai").Return(database.AdminUser{
+		ID:           1,
+		Username:     "somchai",
+		PasswordHash: string(hash),
+		Role:         RoleEditor,
+		TOTPSecret:   sealed,
+		TOTPEnabled:  true,
+	}, nil)
+
+	valid, _ := totp.Code(secret, time.Now())
+
+	type TC struct {
+		code          string
+		wantCode      int
+		wantErrorCode errcode.Code
+	}
+
+	tcs := []TC{
+		{code: "", wantCode: http.StatusUnauthorized, wantErrorCode: errcode.TOTPRequired},
+		{code: "000000", wantCode: http.StatusUnauthorized, wantErrorCode: errcode.TOTPInvalid},
+		{code: valid, wantCode: http.StatusOK},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			h := NewAuthHandler(validator.New(), testAuthConfig).SetUsers(dbmock).SetTOTP(box)
+
+			val, _ := json.Marshal(map[string]interface{}{"username": "somchai", "password": "correct horse battery", "code": tc.code})
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/login", strings.NewReader(string(val)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, h.Login(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantErrorCode != "" {
+				var errresp ResponseMsg
+
+				err := json.Unmarshal([]byte(rec.Body.String()), &errresp)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wantErrorCode, errresp.ErrorCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	csvRowsContextKey = "csvRows"
+	monthLayout       = "2006-01"
+)
+
+type UsageResponse struct {
+	APIKeyID     int    `json:"apiKeyId"`
+	Name         string `json:"name"`
+	Month        string `json:"month"`
+	Requests     int64  `json:"requests"`
+	CSVRows      int64  `json:"csvRows"`
+	MonthlyQuota *int   `json:"monthlyQuota"`
+}
+
+type UsageIDB interface {
+	FindAllAPIKeyUsage(ctx context.Context, period time.Time) ([]database.APIKeyUsage, error)
+}
+
+type UsageMeterIDB interface {
+	FindAPIKeyUsage(ctx context.Context, keyID int, period time.Time) (database.APIKeyUsage, error)
+	RecordAPIKeyUsage(ctx context.Context, keyID int, period time.Time, requests int64, csvRows int64) error
+}
+
+type UsageHandler struct {
+	db UsageIDB
+}
+
+func NewUsageHandler(db UsageIDB) *UsageHandler {
+	return &UsageHandler{db}
+}
+
+// UsagePeriod returns first day of the month, usage is counted per calendar month in UTC by every transport
+func UsagePeriod(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// GetUsage reports usage of every key in month from query param `month`, default is current month
+func (h *UsageHandler) GetUsage(c echo.Context) error {
+	period := UsagePeriod(time.Now())
+
+	if v := c.QueryParam("month"); v != "" {
+		month, err := time.Parse(monthLayout, v)
+		if err != nil {
+			return respondError(c, http.StatusBadRequest, errcode.InvalidMonth)
+		}
+
+		period = month
+	}
+
+	usage, err := h.db.FindAllAPIKeyUsage(c.Request().Context(), period)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find api key usage", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	results := []UsageResponse{}
+
+	for _, u := range usage {
+		results = append(results, UsageResponse{
+			APIKeyID:     u.APIKeyID,
+			Name:         u.Name,
+			Month:        period.Format(monthLayout),
+			Requests:     u.Requests,
+			CSVRows:      u.CSVRows,
+			MonthlyQuota: u.MonthlyQuota,
+		})
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+// recordCSVRows reports number of processed csv rows to UsageMeter
+func recordCSVRows(c echo.Context, n int) {
+	c.Set(csvRowsContextKey, n)
+}
+
+// UsageMeter counts requests and csv rows of authenticated api key and rejects keys over monthly quota,
+// it must be used after APIKeyAuth
+func UsageMeter(db UsageMeterIDB) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			k, ok := CurrentAPIKey(c)
+			if !ok {
+				return next(c)
+			}
+
+			period := UsagePeriod(time.Now())
+
+			if k.MonthlyQuota != nil {
+				usage, err := db.FindAPIKeyUsage(c.Request().Context(), k.ID, period)
+				if err != nil {
+					slog.ErrorContext(c.Request().Context(), "failed to find api key usage", "error", err)
+					return respondQueryError(c)
+				}
+
+				if usage.Requests >= int64(*k.MonthlyQuota) {
+					return respondError(c, http.StatusTooManyRequests, errcode.QuotaExceeded)
+				}
+			}
+
+			err := next(c)
+
+			rows, _ := c.Get(csvRowsContextKey).(int)
+
+			// usage is recorded even when request was timed out
+			ctx := context.WithoutCancel(c.Request().Context())
+
+			if err := db.RecordAPIKeyUsage(ctx, k.ID, period, 1, int64(rows)); err != nil {
+				slog.ErrorContext(ctx, "failed to record api key usage", "error", err)
+			}
+
+			return err
+		}
+	}
+}
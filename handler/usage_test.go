@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type UsageDBMock struct {
+	mock.Mock
+}
+
+func (o *UsageDBMock) FindAllAPIKeyUsage(ctx context.Context, period time.Time) ([]database.APIKeyUsage, error) {
+	args := o.Called(ctx, period)
+	return args.Get(0).([]database.APIKeyUsage), args.Error(1)
+}
+
+func (o *UsageDBMock) FindAPIKeyUsage(ctx context.Context, keyID int, period time.Time) (database.APIKeyUsage, error) {
+	args := o.Called(ctx, keyID, period)
+	return args.Get(0).(database.APIKeyUsage), args.Error(1)
+}
+
+func (o *UsageDBMock) RecordAPIKeyUsage(ctx context.Context, keyID int, period time.Time, requests int64, csvRows int64) error {
+	args := o.Called(ctx, keyID, period, requests, csvRows)
+	return args.Error(0)
+}
+
+func TestAdminGetUsage(t *testing.T) {
+	type TC struct {
+		month       string
+		mockFind    *MockSetting
+		wantCode    int
+		wantMessage string
+	}
+
+	tcs := []TC{
+		{
+			month: "2024-05",
+			mockFind: &MockSetting{
+				Args: []interface{}{mock.Anything, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+				Returns: []interface{}{
+					[]database.APIKeyUsage{{APIKeyID: 1, Name: "payroll", Requests: 10, CSVRows: 200}},
+					nil,
+				},
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			month:       "05-2024",
+			mockFind:    nil,
+			wantCode:    http.StatusBadRequest,
+			wantMessage: "Invalid month, require YYYY-MM",
+		},
+		{
+			month: "2024-05",
+			mockFind: &MockSetting{
+				Args:    []interface{}{mock.Anything, time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)},
+				Returns: []interface{}{[]database.APIKeyUsage(nil), errors.New("an error")},
+			},
+			wantCode:    http.StatusInternalServerError,
+			wantMessage: "Internal server error",
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(UsageDBMock)
+
+			if tc.mockFind != nil {
+				dbmock.On("FindAllAPIKeyUsage", tc.mockFind.Args...).Return(tc.mockFind.Returns...)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/usage?month="+tc.month, nil)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, NewUsageHandler(dbmock).GetUsage(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantMessage != "" {
+				var errresp ResponseMsg
+
+				err := json.Unmarshal([]byte(rec.Body.String()), &errresp)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wantMessage, errresp.Message)
+
+				return
+			}
+
+			var got []UsageResponse
+
+			err := json.Unmarshal([]byte(rec.Body.String()), &got)
+			assert.NoError(t, err)
+			assert.Equal(t, []UsageResponse{{APIKeyID: 1, Name: "payroll", Month: "2024-05", Requests: 10, CSVRows: 200}}, got)
+		})
+	}
+}
+
+func TestUsageMeter(t *testing.T) {
+	quota := 10
+
+	type TC struct {
+		key        database.APIKey
+		used       int64
+		wantCode   int
+		wantRecord bool
+	}
+
+	tcs := []TC{
+		{
+			key:        database.APIKey{ID: 1},
+			wantCode:   http.StatusOK,
+			wantRecord: true,
+		},
+		{
+			key:        database.APIKey{ID: 1, MonthlyQuota: &quota},
+			used:       9,
+			wantCode:   http.StatusOK,
+			wantRecord: true,
+		},
+		{
+			key:        database.APIKey{ID: 1, MonthlyQuota: &quota},
+			used:       10,
+			wantCode:   http.StatusTooManyRequests,
+			wantRecord: false,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(UsageDBMock)
+			dbmock.On("FindAPIKeyUsage", mock.Anything, tc.key.ID, mock.Anything).
+				Return(database.APIKeyUsage{Requests: tc.used}, nil)
+			dbmock.On("RecordAPIKeyUsage", mock.Anything, tc.key.ID, mock.Anything, int64(1), int64(3)).Return(nil)
+
+			e := echo.New()
+			e.POST("/tax/calculations/upload-csv", func(c echo.Context) error {
+				recordCSVRows(c, 3)
+				return c.NoContent(http.StatusOK)
+			}, func(next echo.HandlerFunc) echo.HandlerFunc {
+				return func(c echo.Context) error {
+					c.Set(apiKeyContextKey, tc.key)
+					return next(c)
+				}
+			}, UsageMeter(dbmock))
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations/upload-csv", nil)
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantRecord {
+				dbmock.AssertCalled(t, "RecordAPIKeyUsage", mock.Anything, tc.key.ID, mock.Anything, int64(1), int64(3))
+			} else {
+				dbmock.AssertNotCalled(t, "RecordAPIKeyUsage", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
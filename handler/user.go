@@ -1,16 +1,29 @@
 package handler
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
 	"encoding/csv"
-	"log"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
 	"net/http"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/AnnaCarter465/assessment-tax/pkg/tracing"
+	"github.com/AnnaCarter465/assessment-tax/pkg/uploadscan"
 	"github.com/AnnaCarter465/assessment-tax/tax"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel/attribute"
+	"google.golang.org/protobuf/proto"
 )
 
 type TaxRequest struct {
@@ -22,12 +35,24 @@ type TaxRequest struct {
 type Allowance struct {
 	AllowanceType string  `json:"allowanceType" validate:"required,lowercase"`
 	Amount        float64 `json:"amount" validate:"number,gte=0"`
+	// Date of the purchase or donation, it must be in the window of the type when the tax calendar has one
+	Date string `json:"date,omitempty" validate:"omitempty,datetime=2006-01-02"`
 }
 
 type TaxResponse struct {
-	Tax       float64    `json:"tax"`
-	TaxRefund float64    `json:"taxRefund"`
-	TaxLevel  []TaxLevel `json:"taxLevel"`
+	Tax              float64           `json:"tax"`
+	TaxRefund        float64           `json:"taxRefund"`
+	TaxLevel         []TaxLevel        `json:"taxLevel,omitempty"`
+	Notices          []string          `json:"notices,omitempty"`
+	AllowanceSavings []AllowanceSaving `json:"allowanceSavings,omitempty"`
+}
+
+// AllowanceSaving is the tax if an allowance were omitted, Saving is how much less the taxpayer pays with it
+type AllowanceSaving struct {
+	AllowanceType string  `json:"allowanceType"`
+	Tax           float64 `json:"tax"`
+	TaxRefund     float64 `json:"taxRefund"`
+	Saving        float64 `json:"saving"`
 }
 
 type TaxLevel struct {
@@ -44,12 +69,128 @@ type TaxCSVResponse struct {
 	Taxes []TaxCSV `json:"taxes"`
 }
 
-var rates = []tax.Rate{
-	{Percentage: 0, Max: 150_000, Label: "0-150,000"},
-	{Percentage: 0.1, Max: 500_000, Label: "150,001-500,000"},
-	{Percentage: 0.15, Max: 1_000_000, Label: "500,001-1,000,000"},
-	{Percentage: 0.2, Max: 2_000_000, Label: "1,000,001-2,000,000"},
-	{Percentage: 0.35, Max: -1, Label: "2,000,001 ขึ้นไป"},
+var rates = tax.LabelRates([]tax.Rate{
+	{Percentage: 0, Max: 150_000},
+	{Percentage: 0.1, Max: 500_000},
+	{Percentage: 0.15, Max: 1_000_000},
+	{Percentage: 0.2, Max: 2_000_000},
+	{Percentage: 0.35, Max: -1},
+})
+
+// tax year used when request doesn't specify one
+const defaultTaxYear = 2024
+
+var ratesByTaxYear = map[int][]tax.Rate{
+	2024: rates,
+}
+
+// years from 2500 on are Buddhist era, which is 543 years ahead. 2500 BE is 1957, so no supported Gregorian year
+// reaches it
+const (
+	buddhistEraStart  = 2500
+	buddhistEraOffset = 543
+)
+
+// NormalizeTaxYear returns the Gregorian year of a tax year given in either Gregorian (2024) or Buddhist era (2567)
+func NormalizeTaxYear(year int) int {
+	if year >= buddhistEraStart {
+		return year - buddhistEraOffset
+	}
+
+	return year
+}
+
+// BuddhistEraYear returns the Buddhist era year of a Gregorian tax year
+func BuddhistEraYear(year int) int {
+	return year + buddhistEraOffset
+}
+
+// getTaxYear returns Gregorian tax year from query param `taxYear`, which is in either era
+func getTaxYear(c echo.Context) (int, bool) {
+	if v := c.QueryParam("taxYear"); v != "" {
+		year, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, false
+		}
+
+		return NormalizeTaxYear(year), true
+	}
+
+	return defaultTaxYear, true
+}
+
+const (
+	DetailFull    = "full"
+	DetailSummary = "summary"
+)
+
+// getDetail returns detail of response from query param `detail`, summary leaves out tax levels
+func getDetail(c echo.Context) (string, bool) {
+	switch v := c.QueryParam("detail"); v {
+	case "":
+		return DetailFull, true
+	case DetailFull, DetailSummary:
+		return v, true
+	default:
+		return "", false
+	}
+}
+
+// BracketReader finds brackets imported by admin
+type BracketReader interface {
+	FindTaxBrackets(ctx context.Context, taxYear int) ([]database.TaxBracket, error)
+}
+
+// findRates returns brackets imported for tax year of request, or rates compiled into the service
+func findRates(c echo.Context, brackets BracketReader) ([]tax.Rate, bool, error) {
+	taxYear, ok := getTaxYear(c)
+	if !ok {
+		return nil, false, nil
+	}
+
+	return findRatesOfYear(c.Request().Context(), brackets, taxYear)
+}
+
+// findRatesOfYear returns brackets imported for taxYear, or rates compiled into the service
+func findRatesOfYear(ctx context.Context, brackets BracketReader, taxYear int) ([]tax.Rate, bool, error) {
+	if brackets == nil {
+		r, ok := ratesByTaxYear[taxYear]
+		return r, ok, nil
+	}
+
+	imported, err := brackets.FindTaxBrackets(ctx, taxYear)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find tax brackets", "error", err)
+		return nil, false, err
+	}
+
+	if len(imported) == 0 {
+		r, ok := ratesByTaxYear[taxYear]
+		return r, ok, nil
+	}
+
+	return toRates(imported), true, nil
+}
+
+// toRates converts brackets ordered from the lowest to rates of calculations, labels are generated from the amounts
+// of brackets, so they're correct whenever admin edits them
+func toRates(brackets []database.TaxBracket) []tax.Rate {
+	var r []tax.Rate
+
+	for _, b := range brackets {
+		rate := tax.Rate{
+			Percentage: b.Percentage,
+			Max:        -1,
+		}
+
+		if b.MaxAmount != nil {
+			rate.Max = *b.MaxAmount
+		}
+
+		r = append(r, rate)
+	}
+
+	return tax.LabelRates(r)
 }
 
 type IDB interface {
@@ -57,144 +198,939 @@ type IDB interface {
 	FindAllAllowedAllowances(ctx context.Context) ([]database.AllowedAllowance, error)
 }
 
+// EffectiveAllowanceReader finds allowances published with an effective date
+type EffectiveAllowanceReader interface {
+	FindEffectiveAllowances(ctx context.Context, at time.Time) ([]database.EffectiveAllowance, error)
+}
+
+type CalendarReader interface {
+	TaxCalendarFinder
+	FindUpcomingTaxCalendar(ctx context.Context, from time.Time) (database.TaxCalendar, error)
+}
+
+// HistoryRecorder records calculations, it must not block since it's called on every calculation
+type HistoryRecorder interface {
+	Record(calc database.Calculation)
+}
+
+// deadlines within this period are noticed in calculation responses
+const deadlineNoticePeriod = 30 * 24 * time.Hour
+
 type TaxHandler struct {
-	vl *validator.Validate
-	db IDB
+	vl          *validator.Validate
+	db          IDB
+	scanner     uploadscan.Scanner
+	calendar    CalendarReader
+	effective   EffectiveAllowanceReader
+	brackets    BracketReader
+	maintenance *Maintenance
+	history     HistoryRecorder
+	tenants     TenantReader
+	profiles    ProfileReader
+	aliases     AliasReader
+	now         func() time.Time
+	fallback    func() bool
+	duplicates  func() string
+	precision   func() string
 }
 
 func NewTaxHandler(vl *validator.Validate, db IDB) *TaxHandler {
-	return &TaxHandler{vl, db}
+	return &TaxHandler{vl: vl, db: db, now: time.Now}
+}
+
+// SetClock sets clock of calculations, it's today's date of effective allowances and time of history
+func (t *TaxHandler) SetClock(now func() time.Time) *TaxHandler {
+	t.now = now
+	return t
+}
+
+// SetScanner sets a scanner that checks uploaded files before parsing
+func (t *TaxHandler) SetScanner(scanner uploadscan.Scanner) *TaxHandler {
+	t.scanner = scanner
+	return t
+}
+
+// SetCalendar sets calendar used to notice approaching filing deadlines, and to check dates of allowances
+// against windows of their types
+func (t *TaxHandler) SetCalendar(calendar CalendarReader) *TaxHandler {
+	t.calendar = calendar
+	return t
+}
+
+// SetBrackets sets reader of imported brackets, they take precedence over rates compiled into the service
+func (t *TaxHandler) SetBrackets(brackets BracketReader) *TaxHandler {
+	t.brackets = brackets
+	return t
+}
+
+// SetMaintenance sets maintenance mode, allowances of the last calculation are used when database can't be read during maintenance
+func (t *TaxHandler) SetMaintenance(maintenance *Maintenance) *TaxHandler {
+	t.maintenance = maintenance
+	return t
+}
+
+// SetEffectiveAllowances sets reader of effective-dated allowances, they override current allowances on the calculation date
+func (t *TaxHandler) SetEffectiveAllowances(effective EffectiveAllowanceReader) *TaxHandler {
+	t.effective = effective
+	return t
 }
 
-func (t *TaxHandler) getDefaultAllowancesMap(ctx context.Context) (tax.Allowances, error) {
+// SetFallback sets function reporting whether degraded mode is enabled, calculations use compiled-in allowances
+// and rates instead of failing when database can't be read, responses are flagged by X-Degraded-Mode header
+func (t *TaxHandler) SetFallback(enabled func() bool) *TaxHandler {
+	t.fallback = enabled
+	return t
+}
+
+// SetHistory sets recorder of every calculated tax
+func (t *TaxHandler) SetHistory(history HistoryRecorder) *TaxHandler {
+	t.history = history
+	return t
+}
+
+// SetTenants sets reader of tenant overrides, they take precedence over the global settings for keys of a tenant
+func (t *TaxHandler) SetTenants(tenants TenantReader) *TaxHandler {
+	t.tenants = tenants
+	return t
+}
+
+// SetProfiles merges profile of the signed in taxpayer into calculations, allowances of a request take precedence
+func (t *TaxHandler) SetProfiles(profiles ProfileReader) *TaxHandler {
+	t.profiles = profiles
+	return t
+}
+
+// SetAliases sets reader of allowance aliases, claimed allowances are normalized and their aliases are resolved
+// before they're checked
+func (t *TaxHandler) SetAliases(aliases AliasReader) *TaxHandler {
+	t.aliases = aliases
+	return t
+}
+
+// SetDuplicateAllowances sets function reporting policy of an allowance type claimed twice, it's one of
+// DuplicateAllowances constants. Amounts are summed when it isn't set
+func (t *TaxHandler) SetDuplicateAllowances(policy func() string) *TaxHandler {
+	t.duplicates = policy
+	return t
+}
+
+// SetPrecision sets function reporting precision of amounts in responses, it's one of Precision constants and
+// query param `precision` overrides it. Amounts are responded as calculated when it isn't set
+func (t *TaxHandler) SetPrecision(precision func() string) *TaxHandler {
+	t.precision = precision
+	return t
+}
+
+// getEffectiveDate returns date of configuration used by calculation, it is query param `date`,
+// the last day of query param `taxYear` or today
+func getEffectiveDate(c echo.Context, now time.Time) (time.Time, bool) {
+	if v := c.QueryParam("date"); v != "" {
+		date, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		return date, true
+	}
+
+	if v := c.QueryParam("taxYear"); v != "" {
+		year, err := strconv.Atoi(v)
+		if err != nil {
+			return time.Time{}, false
+		}
+
+		return time.Date(NormalizeTaxYear(year), time.December, 31, 0, 0, 0, 0, time.UTC), true
+	}
+
+	return now.UTC().Truncate(24 * time.Hour), true
+}
+
+func (t *TaxHandler) getNotices(ctx context.Context) []string {
+	if t.calendar == nil {
+		return nil
+	}
+
+	now := t.now()
+
+	cal, err := t.calendar.FindUpcomingTaxCalendar(ctx, now.Truncate(24*time.Hour))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			slog.ErrorContext(ctx, "failed to find upcoming tax calendar", "error", err)
+		}
+		return nil
+	}
+
+	if cal.FilingDeadline.Sub(now) > deadlineNoticePeriod {
+		return nil
+	}
+
+	return []string{
+		fmt.Sprintf("Filing deadline for tax year %d is %s", cal.TaxYear, cal.FilingDeadline.Format("2006-01-02")),
+	}
+}
+
+// getDefaultAllowancesMap returns amounts of enabled default allowances and types which are disabled
+func (t *TaxHandler) getDefaultAllowancesMap(ctx context.Context) (tax.Allowances, []string, error) {
 	defaultAllowances, err := t.db.FindAllDefaultAllowances(ctx)
 	if err != nil {
-		log.Println("Failed to find all default allowaces:", err)
-		return nil, err
+		slog.ErrorContext(ctx, "failed to find default allowances", "error", err)
+		return nil, nil, err
 	}
 
 	defaultAllowancesMap := make(tax.Allowances)
 
+	var disabled []string
+
 	for _, defaultAllowance := range defaultAllowances {
+		if defaultAllowance.DisabledAt != nil {
+			disabled = append(disabled, defaultAllowance.AllowanceType)
+			continue
+		}
+
 		defaultAllowancesMap[defaultAllowance.AllowanceType] = defaultAllowance.Amount
 	}
 
-	return defaultAllowancesMap, nil
+	return defaultAllowancesMap, disabled, nil
 }
 
-func (t *TaxHandler) getAllowedAllowancesMap(ctx context.Context) (tax.Allowances, error) {
+// getAllowedAllowancesMap returns max amounts of enabled allowed allowances and types which are disabled
+func (t *TaxHandler) getAllowedAllowancesMap(ctx context.Context) (tax.Allowances, []string, error) {
 	allowedAllowances, err := t.db.FindAllAllowedAllowances(ctx)
 	if err != nil {
-		log.Println("Failed to find all allowed allowaces:", err)
-		return nil, err
+		slog.ErrorContext(ctx, "failed to find allowed allowances", "error", err)
+		return nil, nil, err
 	}
 
 	allowedAllowancesMap := make(tax.Allowances)
 
+	var disabled []string
+
 	for _, allowedAllowance := range allowedAllowances {
+		if allowedAllowance.DisabledAt != nil {
+			disabled = append(disabled, allowedAllowance.AllowanceType)
+			continue
+		}
+
 		allowedAllowancesMap[allowedAllowance.AllowanceType] = allowedAllowance.MaxAmount
 	}
 
-	return allowedAllowancesMap, nil
+	return allowedAllowancesMap, disabled, nil
+}
+
+// allowanceSettings are allowances used by calculations, types in disabled are rejected by them
+type allowanceSettings struct {
+	defaultAllowances tax.Allowances
+	allowedAllowances tax.Allowances
+	disabled          map[string]bool
+	degraded          bool // compiled-in fallback is used since database can't be read
+}
+
+func (s allowanceSettings) clone() allowanceSettings {
+	return allowanceSettings{
+		defaultAllowances: maps.Clone(s.defaultAllowances),
+		allowedAllowances: maps.Clone(s.allowedAllowances),
+		disabled:          maps.Clone(s.disabled),
+		degraded:          s.degraded,
+	}
+}
+
+// fallbackAllowances are the reference allowances of initialdata/init.sql, used in degraded mode
+var fallbackAllowances = allowanceSettings{
+	defaultAllowances: tax.Allowances{"personal": 60_000},
+	allowedAllowances: tax.Allowances{"donation": 100_000, "k-receipt": 50_000},
+	disabled:          map[string]bool{},
+	degraded:          true,
+}
+
+// degradedHeader flags responses calculated with compiled-in settings during a database outage
+const degradedHeader = "X-Degraded-Mode"
+
+// getAllowancesMaps returns allowances effective on at
+func (t *TaxHandler) getAllowancesMaps(ctx context.Context, at time.Time) (allowanceSettings, error) {
+	settings, err := t.findAllowancesMaps(ctx, at)
+	if err == nil {
+		if t.maintenance != nil {
+			t.maintenance.remember(settings)
+		}
+
+		return settings, nil
+	}
+
+	// client is gone or out of time, the database isn't to blame
+	if ctx.Err() != nil {
+		return allowanceSettings{}, err
+	}
+
+	if t.maintenance != nil && t.maintenance.Enabled() {
+		if settings, ok := t.maintenance.lastAllowances(); ok {
+			slog.WarnContext(ctx, "using last loaded allowances during maintenance")
+			return settings, nil
+		}
+	}
+
+	if t.fallback != nil && t.fallback() {
+		slog.WarnContext(ctx, "using compiled-in allowances since database can't be read", "error", err)
+		return fallbackAllowances.clone(), nil
+	}
+
+	return allowanceSettings{}, err
+}
+
+// findRatesOfYear returns rates like findRatesOfYear, compiled-in rates are returned as degraded when brackets can't be read in degraded mode
+func (t *TaxHandler) findRatesOfYear(ctx context.Context, taxYear int) (rates []tax.Rate, ok bool, degraded bool, err error) {
+	rates, ok, err = findRatesOfYear(ctx, t.brackets, taxYear)
+	if err != nil && t.fallback != nil && t.fallback() && ctx.Err() == nil {
+		rates, ok = ratesByTaxYear[taxYear]
+		return rates, ok, true, nil
+	}
+
+	return rates, ok, false, err
+}
+
+// findTenantSettings returns overrides like findTenantSettings, nothing is overridden when they can't be read in degraded mode
+func (t *TaxHandler) findTenantSettings(ctx context.Context, tenantID *int) (overrides database.SettingsImport, degraded bool, err error) {
+	overrides, err = findTenantSettings(ctx, t.tenants, tenantID)
+	if err != nil && t.fallback != nil && t.fallback() && ctx.Err() == nil {
+		return database.SettingsImport{}, true, nil
+	}
+
+	return overrides, false, err
+}
+
+// findAllowanceAliases returns aliases like findAllowanceAliases, nothing is aliased when they can't be read in degraded mode
+func (t *TaxHandler) findAllowanceAliases(ctx context.Context) (aliases map[string]string, degraded bool, err error) {
+	aliases, err = findAllowanceAliases(ctx, t.aliases)
+	if err != nil && t.fallback != nil && t.fallback() && ctx.Err() == nil {
+		return nil, true, nil
+	}
+
+	return aliases, false, err
+}
+
+// findTenantRates returns rates like findRatesOfYear, brackets of the tenant replace them when it has some for taxYear
+func (t *TaxHandler) findTenantRates(ctx context.Context, taxYear int, overrides database.SettingsImport) (rates []tax.Rate, ok bool, degraded bool, err error) {
+	if brackets := overrides.Brackets[taxYear]; len(brackets) > 0 {
+		return toRates(brackets), true, false, nil
+	}
+
+	return t.findRatesOfYear(ctx, taxYear)
+}
+
+func (t *TaxHandler) findAllowancesMaps(ctx context.Context, at time.Time) (allowanceSettings, error) {
+	defaultAllowancesMap, disabledDefaults, err := t.getDefaultAllowancesMap(ctx)
+	if err != nil {
+		return allowanceSettings{}, err
+	}
+
+	allowedAllowancesMap, disabledAllowed, err := t.getAllowedAllowancesMap(ctx)
+	if err != nil {
+		return allowanceSettings{}, err
+	}
+
+	settings := allowanceSettings{
+		defaultAllowances: defaultAllowancesMap,
+		allowedAllowances: allowedAllowancesMap,
+		disabled:          map[string]bool{},
+	}
+
+	for _, allowanceType := range append(disabledDefaults, disabledAllowed...) {
+		settings.disabled[allowanceType] = true
+	}
+
+	if t.effective == nil {
+		return settings, nil
+	}
+
+	effectiveAllowances, err := t.effective.FindEffectiveAllowances(ctx, at)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find effective allowances", "error", err)
+		return allowanceSettings{}, err
+	}
+
+	for _, a := range effectiveAllowances {
+		// values published before the type was disabled must not enable it again
+		if settings.disabled[a.AllowanceType] {
+			continue
+		}
+
+		switch a.AllowanceKind {
+		case database.AllowanceKindDefault:
+			defaultAllowancesMap[a.AllowanceType] = a.Amount
+		case database.AllowanceKindAllowed:
+			allowedAllowancesMap[a.AllowanceType] = a.Amount
+		}
+	}
+
+	return settings, nil
 }
 
 func (t *TaxHandler) CalculateTax(c echo.Context) error {
+	taxYear, ok := getTaxYear(c)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.TaxYearUnsupported)
+	}
+
+	effectiveDate, ok := getEffectiveDate(c, t.now())
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidDate)
+	}
+
+	detail, ok := getDetail(c)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	precision, ok := getPrecision(c, t.precision)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
 	var req TaxRequest
 
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, ResponseMsg{
-			Message: "Bad request",
-		})
+	if err := bindTaxRequest(c, &req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
 	}
 
-	if err := t.vl.Struct(req); err != nil {
-		return c.JSON(http.StatusBadRequest, ResponseMsg{
-			Message: "Bad request",
-		})
+	resp, degraded, err := t.Calculate(c.Request().Context(), Calculation{
+		TaxRequest:    req,
+		TaxYear:       taxYear,
+		EffectiveDate: effectiveDate,
+		Language:      preferredLanguage(c.Request().Header.Get("Accept-Language"), defaultLanguage),
+		APIKeyID:      currentAPIKeyID(c),
+		TenantID:      currentTenantID(c),
+		UserID:        currentUserID(c),
+		// each claimed allowance is recalculated, so it's only done on request
+		AllowanceSavings: c.QueryParam("allowanceSavings") == "true",
+	})
+
+	if degraded {
+		c.Response().Header().Set(degradedHeader, "true")
 	}
 
-	if req.TotalIncome < req.Wht {
-		return c.JSON(http.StatusBadRequest, ResponseMsg{
-			Message: "Invalid wht",
-		})
+	if err != nil {
+		return respondCalculationError(c, err)
+	}
+
+	if detail == DetailSummary {
+		resp.TaxLevel = nil
+	}
+
+	resp.round(precision)
+
+	return respondNegotiated(c, resp, func() proto.Message { return resp.Proto() })
+}
+
+// Calculation is a calculation of one taxpayer requested over HTTP or gRPC
+type Calculation struct {
+	TaxRequest
+	TaxYear       int
+	EffectiveDate time.Time // date of allowances used by the calculation
+	Language      string    // language of tax levels
+	APIKeyID      *int      // nil when api key auth is disabled
+	TenantID      *int      // tenant of the key, nil when it uses the global settings
+	UserID        *int      // signed in taxpayer, nil for anonymous calculations
+	// AllowanceSavings reports tax of the calculation without each claimed allowance
+	AllowanceSavings bool
+}
+
+// CalculationError rejects a calculation, Status is the HTTP status it's responded with
+type CalculationError struct {
+	Status int
+	Code   errcode.Code
+}
+
+func (e *CalculationError) Error() string {
+	return errorMessage(e.Code, defaultErrorLanguage)
+}
+
+// Calculate calculates tax of one taxpayer, degraded is true when compiled-in settings are used during a database outage.
+// Rejected calculations return *CalculationError, other errors are failed queries
+func (t *TaxHandler) Calculate(ctx context.Context, calc Calculation) (resp *TaxResponse, degraded bool, err error) {
+	overrides, degradedTenant, err := t.findTenantSettings(ctx, calc.TenantID)
+	if err != nil {
+		return nil, false, err
 	}
 
-	defaultAllowancesMap, err := t.getDefaultAllowancesMap(c.Request().Context())
+	rates, ok, degradedRates, err := t.findTenantRates(ctx, calc.TaxYear, overrides)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ResponseMsg{
-			Message: "Internal server error",
-		})
+		return nil, degradedTenant, err
+	}
+
+	degradedRates = degradedRates || degradedTenant
+
+	if !ok {
+		return nil, false, &CalculationError{Status: http.StatusBadRequest, Code: errcode.TaxYearUnsupported}
 	}
 
-	allowedAllowancesMap, err := t.getAllowedAllowancesMap(c.Request().Context())
+	aliases, degradedAliases, err := t.findAllowanceAliases(ctx)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ResponseMsg{
-			Message: "Internal server error",
-		})
+		return nil, degradedRates, err
+	}
+
+	degradedRates = degradedRates || degradedAliases
+	calc.Allowances = resolveAllowances(calc.Allowances, aliases)
+
+	if err := t.vl.Struct(calc.TaxRequest); err != nil {
+		return nil, degradedRates, &CalculationError{Status: http.StatusBadRequest, Code: errcode.InvalidRequest}
+	}
+
+	if calc.TotalIncome < calc.Wht {
+		return nil, degradedRates, &CalculationError{Status: http.StatusBadRequest, Code: errcode.WhtExceedsIncome}
 	}
 
+	degradedWindows, err := t.checkAllowanceWindows(ctx, calc.TaxYear, calc.Allowances)
+	degradedRates = degradedRates || degradedWindows
+
+	if err != nil {
+		return nil, degradedRates, err
+	}
+
+	allowances, err := t.getAllowancesMaps(ctx, calc.EffectiveDate)
+	if err != nil {
+		return nil, degradedRates, err
+	}
+
+	allowances = overrideAllowances(allowances, overrides)
+	degraded = degradedRates || allowances.degraded
+
+	claimedOnce, ok := t.combineAllowances(calc.Allowances)
+	if !ok {
+		return nil, degraded, &CalculationError{Status: http.StatusBadRequest, Code: errcode.AllowanceDuplicated}
+	}
+
+	calc.Allowances = claimedOnce
+
+	for _, a := range calc.Allowances {
+		if allowances.disabled[a.AllowanceType] {
+			return nil, degraded, &CalculationError{Status: http.StatusBadRequest, Code: errcode.AllowanceDisabled}
+		}
+	}
+
+	fromProfile, err := findProfileAllowances(ctx, t.profiles, calc.UserID, calc.Allowances)
+	if err != nil {
+		return nil, degraded, err
+	}
+
+	// allowances of the profile which were disabled later are left out rather than rejected
+	claimed := slices.Clip(calc.Allowances)
+	for _, a := range fromProfile {
+		if !allowances.disabled[a.AllowanceType] {
+			claimed = append(claimed, a)
+		}
+	}
+
+	_, span := tracing.Start(ctx, "tax.compute")
+
 	tx := tax.NewTax(tax.TaxConfig{
 		Rates:             rates,
-		DefaultAllowances: defaultAllowancesMap,
-		AllowedAllowances: allowedAllowancesMap,
-	}).SetIncome(req.TotalIncome).SetWht(req.Wht)
+		DefaultAllowances: allowances.defaultAllowances,
+		AllowedAllowances: allowances.allowedAllowances,
+	}).SetIncome(calc.TotalIncome).SetWht(calc.Wht)
 
-	for _, a := range req.Allowances {
+	for _, a := range claimed {
 		tx.AddAllowance(a.AllowanceType, a.Amount)
 	}
 
 	summary := tx.CalculateTaxSummary()
 
+	var savings []AllowanceSaving
+	if calc.AllowanceSavings {
+		savings = allowanceSavings(claimed, summary, tx.CalculateTaxSummariesWithout())
+	}
+
+	span.End()
+
+	if t.history != nil {
+		allowances := map[string]float64{}
+		for _, a := range claimed {
+			allowances[a.AllowanceType] += a.Amount
+		}
+
+		t.history.Record(database.Calculation{
+			TotalIncome:  calc.TotalIncome,
+			Wht:          calc.Wht,
+			Allowances:   allowances,
+			Tax:          summary.Tax,
+			TaxRefund:    summary.Refund,
+			CalculatedAt: t.now(),
+			APIKeyID:     calc.APIKeyID,
+			UserID:       calc.UserID,
+		})
+	}
+
+	resp = newTaxResponseIn(calc.Language, summary)
+	resp.Notices = t.getNotices(ctx)
+	resp.AllowanceSavings = savings
+
+	return resp, degraded, nil
+}
+
+// policies of an allowance type claimed twice by a calculation
+const (
+	DuplicateAllowancesSum    = "sum"    // amounts are summed
+	DuplicateAllowancesReject = "reject" // the calculation is rejected
+	DuplicateAllowancesLast   = "last"   // the last amount is claimed
+)
+
+// combineAllowances returns allowances claiming each type once in order they're first claimed,
+// false is returned when a type is claimed twice and the policy rejects it
+// checkAllowanceWindows rejects allowances dated outside the window of their type in the calendar of taxYear,
+// allowances without a date and types without a window aren't checked. Nothing is checked when the calendar
+// can't be read in degraded mode
+func (t *TaxHandler) checkAllowanceWindows(ctx context.Context, taxYear int, allowances []Allowance) (degraded bool, err error) {
+	if t.calendar == nil || !slices.ContainsFunc(allowances, func(a Allowance) bool { return a.Date != "" }) {
+		return false, nil
+	}
+
+	cal, err := t.calendar.FindTaxCalendar(ctx, taxYear)
+	if errors.Is(err, database.ErrNotFound) {
+		return false, nil
+	}
+
+	if err != nil {
+		if t.fallback != nil && t.fallback() && ctx.Err() == nil {
+			return true, nil
+		}
+
+		slog.ErrorContext(ctx, "failed to find tax calendar", "error", err)
+		return false, err
+	}
+
+	for _, a := range allowances {
+		if a.Date == "" {
+			continue
+		}
+
+		date, err := time.Parse(dateLayout, a.Date)
+		if err != nil {
+			return false, &CalculationError{Status: http.StatusBadRequest, Code: errcode.InvalidRequest}
+		}
+
+		for _, w := range cal.AllowanceWindows {
+			if w.AllowanceType == a.AllowanceType && (date.Before(w.StartsOn) || date.After(w.EndsOn)) {
+				return false, &CalculationError{Status: http.StatusBadRequest, Code: errcode.AllowanceOutsideWindow}
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func (t *TaxHandler) combineAllowances(allowances []Allowance) ([]Allowance, bool) {
+	policy := DuplicateAllowancesSum
+	if t.duplicates != nil {
+		policy = t.duplicates()
+	}
+
+	var combined []Allowance
+
+	for _, a := range allowances {
+		i := slices.IndexFunc(combined, func(c Allowance) bool { return c.AllowanceType == a.AllowanceType })
+		if i == -1 {
+			combined = append(combined, a)
+			continue
+		}
+
+		switch policy {
+		case DuplicateAllowancesReject:
+			return nil, false
+		case DuplicateAllowancesLast:
+			combined[i].Amount = a.Amount
+		default:
+			combined[i].Amount += a.Amount
+		}
+	}
+
+	return combined, true
+}
+
+// allowanceSavings lists savings in order allowances were claimed, a type claimed twice is listed once
+func allowanceSavings(claimed []Allowance, summary tax.TaxSummary, without map[string]tax.TaxSummary) []AllowanceSaving {
+	savings := []AllowanceSaving{}
+
+	for _, a := range claimed {
+		if slices.ContainsFunc(savings, func(s AllowanceSaving) bool { return s.AllowanceType == a.AllowanceType }) {
+			continue
+		}
+
+		w := without[a.AllowanceType]
+
+		savings = append(savings, AllowanceSaving{
+			AllowanceType: a.AllowanceType,
+			Tax:           w.Tax,
+			TaxRefund:     w.Refund,
+			Saving:        (w.Tax - w.Refund) - (summary.Tax - summary.Refund),
+		})
+	}
+
+	return savings
+}
+
+// newTaxResponse responds levels in preferred language of request
+func newTaxResponse(c echo.Context, summary tax.TaxSummary) *TaxResponse {
+	return newTaxResponseIn(preferredLanguage(c.Request().Header.Get("Accept-Language"), defaultLanguage), summary)
+}
+
+func newTaxResponseIn(lang string, summary tax.TaxSummary) *TaxResponse {
 	var levels []TaxLevel
 
 	for _, l := range summary.TaxStatements {
 		levels = append(levels, TaxLevel{
-			Level: l.Rate.Label,
+			Level: l.Rate.LabelFor(lang),
 			Tax:   l.Tax,
 		})
 	}
 
-	return c.JSON(http.StatusOK, &TaxResponse{
+	return &TaxResponse{
 		Tax:       summary.Tax,
 		TaxRefund: summary.Refund,
 		TaxLevel:  levels,
-	})
+	}
 }
 
 func (t *TaxHandler) CalculateTaxWithCSV(c echo.Context) error {
+	precision, ok := getPrecision(c, t.precision)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	upload, err := t.readCSVUpload(c)
+	if err != nil {
+		return respondCalculationError(c, err)
+	}
+
+	taxes, degraded, err := t.calculateCSV(c.Request().Context(), upload)
+	if err != nil {
+		return respondCalculationError(c, err)
+	}
+
+	if degraded {
+		c.Response().Header().Set(degradedHeader, "true")
+	}
+
+	recordCSVRows(c, len(taxes))
+
+	resp := &TaxCSVResponse{
+		Taxes: taxes,
+	}
+
+	resp.round(precision)
+
+	return respondNegotiated(c, resp, func() proto.Message { return resp.Proto() })
+}
+
+// csvUpload is a checked csv upload, it's calculated in the request or later by a batch worker
+type csvUpload struct {
+	taxYear       int
+	effectiveDate time.Time
+	tenantID      *int
+	apiKeyID      *int
+	userID        *int
+	body          []byte
+	datasets      []TaxCSVRow
+}
+
+func respondCalculationError(c echo.Context, err error) error {
+	var calcErr *CalculationError
+	if errors.As(err, &calcErr) {
+		return respondError(c, calcErr.Status, calcErr.Code)
+	}
+
+	return respondQueryError(c)
+}
+
+// readCSVUpload reads csv of request and checks everything which doesn't need settings, the error is
+// *CalculationError
+func (t *TaxHandler) readCSVUpload(c echo.Context) (csvUpload, error) {
+	taxYear, ok := getTaxYear(c)
+	if !ok {
+		return csvUpload{}, &CalculationError{Status: http.StatusBadRequest, Code: errcode.TaxYearUnsupported}
+	}
+
+	effectiveDate, ok := getEffectiveDate(c, t.now())
+	if !ok {
+		return csvUpload{}, &CalculationError{Status: http.StatusBadRequest, Code: errcode.InvalidDate}
+	}
+
 	if c.Request().Header.Get("Content-Type") != "text/csv" {
-		return c.JSON(http.StatusBadRequest, ResponseMsg{
-			Message: "Unaceptable content, require CSV content",
-		})
+		return csvUpload{}, &CalculationError{Status: http.StatusBadRequest, Code: errcode.CSVContentType}
+	}
+
+	_, span := tracing.Start(c.Request().Context(), "csv.parse")
+	defer span.End()
+
+	body, err := io.ReadAll(c.Request().Body)
+	if errors.Is(err, echo.ErrStatusRequestEntityTooLarge) {
+		return csvUpload{}, &CalculationError{Status: http.StatusRequestEntityTooLarge, Code: errcode.PayloadTooLarge}
 	}
 
-	rows, err := csv.NewReader(c.Request().Body).ReadAll()
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, ResponseMsg{
-			Message: "Bad request, might not be csv format",
+		return csvUpload{}, &CalculationError{Status: http.StatusBadRequest, Code: errcode.InvalidRequest}
+	}
+
+	if t.scanner != nil {
+		if err := t.scanner.Scan(c.Request().Context(), bytes.NewReader(body)); err != nil {
+			slog.ErrorContext(c.Request().Context(), "failed to scan uploaded file", "error", err)
+
+			if errors.Is(err, uploadscan.ErrInfected) {
+				return csvUpload{}, &CalculationError{Status: http.StatusUnprocessableEntity, Code: errcode.UploadRejected}
+			}
+
+			return csvUpload{}, &CalculationError{Status: http.StatusInternalServerError, Code: errcode.Internal}
+		}
+	}
+
+	datasets, err := parseCSVUpload(body)
+	if err != nil {
+		return csvUpload{}, err
+	}
+
+	span.SetAttributes(attribute.Int("csv.rows", len(datasets)))
+
+	return csvUpload{
+		taxYear:       taxYear,
+		effectiveDate: effectiveDate,
+		tenantID:      currentTenantID(c),
+		apiKeyID:      currentAPIKeyID(c),
+		userID:        currentUserID(c),
+		body:          body,
+		datasets:      datasets,
+	}, nil
+}
+
+// parseCSVUpload parses csv like ParseTaxCSV, the error is *CalculationError
+func parseCSVUpload(body []byte) ([]TaxCSVRow, error) {
+	datasets, err := ParseTaxCSV(bytes.NewReader(body))
+	if err != nil {
+		var csvErr *CSVError
+		if errors.As(err, &csvErr) {
+			return nil, &CalculationError{Status: http.StatusBadRequest, Code: csvErr.Code}
+		}
+
+		return nil, &CalculationError{Status: http.StatusBadRequest, Code: errcode.CSVMalformed}
+	}
+
+	return datasets, nil
+}
+
+// calculateCSV returns taxes of rows of upload, errors which aren't *CalculationError are query errors.
+// degraded is true when settings which couldn't be read were replaced by the last known ones.
+func (t *TaxHandler) calculateCSV(ctx context.Context, upload csvUpload) (taxes []TaxCSV, degraded bool, err error) {
+	overrides, degradedTenant, err := t.findTenantSettings(ctx, upload.tenantID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	rates, ok, degradedRates, err := t.findTenantRates(ctx, upload.taxYear, overrides)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !ok {
+		return nil, false, &CalculationError{Status: http.StatusBadRequest, Code: errcode.TaxYearUnsupported}
+	}
+
+	allowances, err := t.getAllowancesMaps(ctx, upload.effectiveDate)
+	if err != nil {
+		return nil, false, err
+	}
+
+	allowances = overrideAllowances(allowances, overrides)
+
+	if allowances.disabled["donation"] {
+		for _, d := range upload.datasets {
+			if d.Donation != 0 {
+				return nil, false, &CalculationError{Status: http.StatusBadRequest, Code: errcode.AllowanceDisabled}
+			}
+		}
+	}
+
+	_, span := tracing.Start(ctx, "tax.compute", attribute.Int("csv.rows", len(upload.datasets)))
+	defer span.End()
+
+	for _, d := range upload.datasets {
+		// stop computing when client deadline is exceeded
+		if ctx.Err() != nil {
+			return nil, false, &CalculationError{Status: http.StatusServiceUnavailable, Code: errcode.RequestTimeout}
+		}
+
+		tx := tax.NewTax(tax.TaxConfig{
+			Rates:             rates,
+			DefaultAllowances: allowances.defaultAllowances,
+			AllowedAllowances: allowances.allowedAllowances,
 		})
+
+		summary := tx.
+			SetIncome(d.TotalIncome).
+			SetWht(d.Wht).
+			AddAllowance("donation", d.Donation).
+			CalculateTaxSummary()
+
+		if t.history != nil {
+			t.history.Record(database.Calculation{
+				TotalIncome:  d.TotalIncome,
+				Wht:          d.Wht,
+				Allowances:   map[string]float64{"donation": d.Donation},
+				Tax:          summary.Tax,
+				TaxRefund:    summary.Refund,
+				CalculatedAt: t.now(),
+				APIKeyID:     upload.apiKeyID,
+				UserID:       upload.userID,
+			})
+		}
+
+		taxes = append(taxes, TaxCSV{
+			TotalIncome: d.TotalIncome,
+			Tax:         summary.Tax,
+		})
+	}
+
+	return taxes, degradedTenant || degradedRates || allowances.degraded, nil
+}
+
+// TaxCSVRow is a data row of csv with header totalIncome,wht,donation
+type TaxCSVRow struct {
+	TotalIncome float64
+	Wht         float64
+	Donation    float64
+}
+
+// CSVError rejects csv, Line is 0 when it isn't caused by one row
+type CSVError struct {
+	Line int
+	Code errcode.Code
+}
+
+func (e *CSVError) Error() string {
+	if e.Line == 0 {
+		return errorMessage(e.Code, defaultErrorLanguage)
+	}
+
+	return fmt.Sprintf("line %d: %s", e.Line, errorMessage(e.Code, defaultErrorLanguage))
+}
+
+// ParseTaxCSV validates every row of csv before any is returned, the error is *CSVError
+func ParseTaxCSV(r io.Reader) ([]TaxCSVRow, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, &CSVError{Code: errcode.CSVMalformed}
 	}
 
 	if len(rows) == 0 {
-		return c.JSON(http.StatusBadRequest, ResponseMsg{
-			Message: "Wrong csv content, no content",
-		})
+		return nil, &CSVError{Code: errcode.CSVEmpty}
 	}
 
 	if len(rows) == 1 {
-		return c.JSON(http.StatusBadRequest, ResponseMsg{
-			Message: "Wrong csv content, should have more than 1 row due to it is header",
-		})
+		return nil, &CSVError{Code: errcode.CSVNoDataRows}
 	}
 
-	var datasets [][]float64
+	var datasets []TaxCSVRow
 
-	// vaildation
 	for i, row := range rows {
+		line := i + 1
+
 		if len(row) != 3 {
-			return c.JSON(http.StatusBadRequest, ResponseMsg{
-				Message: "Wrong csv column length",
-			})
+			return nil, &CSVError{Line: line, Code: errcode.CSVBadColumnCount}
 		}
 
 		if i == 0 {
@@ -203,9 +1139,7 @@ func (t *TaxHandler) CalculateTaxWithCSV(c echo.Context) error {
 				row[2] != "donation"
 
 			if badcsvformat {
-				return c.JSON(http.StatusBadRequest, ResponseMsg{
-					Message: "Wrong csv header",
-				})
+				return nil, &CSVError{Line: line, Code: errcode.CSVBadHeader}
 			}
 
 			continue
@@ -213,88 +1147,37 @@ func (t *TaxHandler) CalculateTaxWithCSV(c echo.Context) error {
 
 		income, err := strconv.ParseFloat(row[0], 64)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, ResponseMsg{
-				Message: "Invalid income amount",
-			})
+			return nil, &CSVError{Line: line, Code: errcode.CSVInvalidIncome}
 		}
 
 		wht, err := strconv.ParseFloat(row[1], 64)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, ResponseMsg{
-				Message: "Invalid wht amount",
-			})
+			return nil, &CSVError{Line: line, Code: errcode.CSVInvalidWht}
 		}
 
 		donation, err := strconv.ParseFloat(row[2], 64)
 		if err != nil {
-			return c.JSON(http.StatusBadRequest, ResponseMsg{
-				Message: "Invalid donation amount",
-			})
+			return nil, &CSVError{Line: line, Code: errcode.CSVInvalidDonation}
 		}
 
 		if income < 0 {
-			return c.JSON(http.StatusBadRequest, ResponseMsg{
-				Message: "Invalid income amount",
-			})
+			return nil, &CSVError{Line: line, Code: errcode.CSVInvalidIncome}
 		}
 
 		if wht < 0 {
-			return c.JSON(http.StatusBadRequest, ResponseMsg{
-				Message: "Invalid wht amount",
-			})
+			return nil, &CSVError{Line: line, Code: errcode.CSVInvalidWht}
 		}
 
 		if donation < 0 {
-			return c.JSON(http.StatusBadRequest, ResponseMsg{
-				Message: "Invalid donation amount",
-			})
+			return nil, &CSVError{Line: line, Code: errcode.CSVInvalidDonation}
 		}
 
 		if income < wht {
-			return c.JSON(http.StatusBadRequest, ResponseMsg{
-				Message: "Income amount should be more than wht amount",
-			})
+			return nil, &CSVError{Line: line, Code: errcode.CSVWhtExceedsIncome}
 		}
 
-		datasets = append(datasets, []float64{income, wht, donation})
-	}
-
-	defaultAllowancesMap, err := t.getDefaultAllowancesMap(c.Request().Context())
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ResponseMsg{
-			Message: "Internal server error",
-		})
-	}
-
-	allowedAllowancesMap, err := t.getAllowedAllowancesMap(c.Request().Context())
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, ResponseMsg{
-			Message: "Internal server error",
-		})
-	}
-
-	var taxes []TaxCSV
-
-	for _, d := range datasets {
-		tx := tax.NewTax(tax.TaxConfig{
-			Rates:             rates,
-			DefaultAllowances: defaultAllowancesMap,
-			AllowedAllowances: allowedAllowancesMap,
-		})
-
-		summary := tx.
-			SetIncome(d[0]).
-			SetWht(d[1]).
-			AddAllowance("donation", d[2]).
-			CalculateTaxSummary()
-
-		taxes = append(taxes, TaxCSV{
-			TotalIncome: d[0],
-			Tax:         summary.Tax,
-		})
+		datasets = append(datasets, TaxCSVRow{TotalIncome: income, Wht: wht, Donation: donation})
 	}
 
-	return c.JSON(http.StatusOK, &TaxCSVResponse{
-		Taxes: taxes,
-	})
+	return datasets, nil
 }
@@ -1,20 +1,28 @@
 package handler
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/AnnaCarter465/assessment-tax/pkg/uploadscan"
 	"github.com/go-playground/validator/v10"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
@@ -33,6 +41,24 @@ func (o *UserDBMock) FindAllAllowedAllowances(ctx context.Context) ([]database.A
 	return args.Get(0).([]database.AllowedAllowance), args.Error(1)
 }
 
+type EffectiveAllowanceMock struct {
+	mock.Mock
+}
+
+func (o *EffectiveAllowanceMock) FindEffectiveAllowances(ctx context.Context, at time.Time) ([]database.EffectiveAllowance, error) {
+	args := o.Called(ctx, at)
+	return args.Get(0).([]database.EffectiveAllowance), args.Error(1)
+}
+
+type ScannerMock struct {
+	mock.Mock
+}
+
+func (o *ScannerMock) Scan(ctx context.Context, r io.Reader) error {
+	args := o.Called(ctx, r)
+	return args.Error(0)
+}
+
 func TestUserCalculateTax(t *testing.T) {
 	type TC struct {
 		reqbody                      map[string]interface{}
@@ -110,7 +136,8 @@ func TestUserCalculateTax(t *testing.T) {
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Bad request",
+				Message:   "Bad request",
+				ErrorCode: errcode.InvalidRequest,
 			},
 		},
 		{
@@ -123,7 +150,8 @@ func TestUserCalculateTax(t *testing.T) {
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Bad request",
+				Message:   "Bad request",
+				ErrorCode: errcode.InvalidRequest,
 			},
 		},
 		{
@@ -138,7 +166,8 @@ func TestUserCalculateTax(t *testing.T) {
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Invalid wht",
+				Message:   "Invalid wht",
+				ErrorCode: errcode.WhtExceedsIncome,
 			},
 		},
 		{
@@ -161,7 +190,8 @@ func TestUserCalculateTax(t *testing.T) {
 			},
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Internal server error",
+				Message:   "Internal server error",
+				ErrorCode: errcode.Internal,
 			},
 		},
 		{
@@ -194,7 +224,8 @@ func TestUserCalculateTax(t *testing.T) {
 				},
 			},
 			errresp: &ResponseMsg{
-				Message: "Internal server error",
+				Message:   "Internal server error",
+				ErrorCode: errcode.Internal,
 			},
 		},
 		{
@@ -324,11 +355,557 @@ func TestUserCalculateTax(t *testing.T) {
 	}
 }
 
+func TestUserCalculateTaxDeadlineNotice(t *testing.T) {
+	type TC struct {
+		deadline time.Time
+		want     []string
+	}
+
+	soon := time.Now().AddDate(0, 0, 5)
+	later := time.Now().AddDate(0, 3, 0)
+
+	tcs := []TC{
+		{
+			deadline: soon,
+			want:     []string{"Filing deadline for tax year 2024 is " + soon.Format("2006-01-02")},
+		},
+		{
+			deadline: later,
+			want:     nil,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(UserDBMock)
+			mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+				{AllowanceType: "donation", MaxAmount: 100_000},
+			}, nil)
+
+			calendar := new(CalendarDBMock)
+			calendar.On("FindUpcomingTaxCalendar", mock.Anything, mock.Anything).Return(database.TaxCalendar{
+				TaxYear:        2024,
+				FilingDeadline: tc.deadline,
+			}, nil)
+
+			h := NewTaxHandler(validator.New(), mockObj).SetCalendar(calendar)
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations", strings.NewReader(`{"totalIncome":500000,"wht":0,"allowances":[]}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, h.CalculateTax(e.NewContext(req, rec)))
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var got TaxResponse
+
+			err := json.Unmarshal([]byte(rec.Body.String()), &got)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.want, got.Notices)
+		})
+	}
+}
+
+func TestUserCalculateTaxAllowanceWindows(t *testing.T) {
+	type TC struct {
+		reqbody  string
+		calendar error
+		wantCode int
+		wantTax  float64
+		wantErr  errcode.Code
+	}
+
+	tcs := []TC{
+		{
+			reqbody:  `{"totalIncome":500000,"wht":0,"allowances":[{"allowanceType":"k-receipt","amount":50000,"date":"2024-02-01"}]}`,
+			wantCode: http.StatusOK,
+			wantTax:  24_000,
+		},
+		{
+			reqbody:  `{"totalIncome":500000,"wht":0,"allowances":[{"allowanceType":"k-receipt","amount":50000,"date":"2024-03-01"}]}`,
+			wantCode: http.StatusBadRequest,
+			wantErr:  errcode.AllowanceOutsideWindow,
+		},
+		{
+			reqbody:  `{"totalIncome":500000,"wht":0,"allowances":[{"allowanceType":"k-receipt","amount":50000}]}`,
+			wantCode: http.StatusOK,
+			wantTax:  24_000,
+		},
+		{
+			reqbody:  `{"totalIncome":500000,"wht":0,"allowances":[{"allowanceType":"donation","amount":50000,"date":"2024-12-01"}]}`,
+			wantCode: http.StatusOK,
+			wantTax:  24_000,
+		},
+		{
+			reqbody:  `{"totalIncome":500000,"wht":0,"allowances":[{"allowanceType":"k-receipt","amount":50000,"date":"2024-03-01"}]}`,
+			calendar: database.ErrNotFound,
+			wantCode: http.StatusOK,
+			wantTax:  24_000,
+		},
+		{
+			reqbody:  `{"totalIncome":500000,"wht":0,"allowances":[{"allowanceType":"k-receipt","amount":50000,"date":"2024-03-01"}]}`,
+			calendar: errors.New("connection refused"),
+			wantCode: http.StatusInternalServerError,
+			wantErr:  errcode.Internal,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(UserDBMock)
+			mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+				{AllowanceType: "donation", MaxAmount: 100_000},
+				{AllowanceType: "k-receipt", MaxAmount: 50_000},
+			}, nil)
+
+			calendar := new(CalendarDBMock)
+			calendar.On("FindUpcomingTaxCalendar", mock.Anything, mock.Anything).Return(database.TaxCalendar{}, sql.ErrNoRows)
+			calendar.On("FindTaxCalendar", mock.Anything, 2024).Return(database.TaxCalendar{
+				TaxYear:        2024,
+				FilingDeadline: time.Date(2025, time.April, 8, 0, 0, 0, 0, time.UTC),
+				AllowanceWindows: []database.AllowanceWindow{{
+					AllowanceType: "k-receipt",
+					StartsOn:      time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+					EndsOn:        time.Date(2024, time.February, 15, 0, 0, 0, 0, time.UTC),
+				}},
+			}, tc.calendar)
+
+			h := NewTaxHandler(validator.New(), mockObj).SetCalendar(calendar)
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations", strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			assert.NoError(t, h.CalculateTax(echo.New().NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantErr != "" {
+				var got ResponseMsg
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, tc.wantErr, got.ErrorCode)
+
+				return
+			}
+
+			var got TaxResponse
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tc.wantTax, got.Tax)
+		})
+	}
+}
+
+func TestUserCalculateTaxWithTaxYear(t *testing.T) {
+	type TC struct {
+		taxYear  string
+		wantCode int
+	}
+
+	tcs := []TC{
+		{taxYear: "2024", wantCode: http.StatusOK},
+		{taxYear: "2567", wantCode: http.StatusOK},
+		{taxYear: "2020", wantCode: http.StatusBadRequest},
+		{taxYear: "2563", wantCode: http.StatusBadRequest},
+		{taxYear: "abc", wantCode: http.StatusBadRequest},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(UserDBMock)
+			mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+				{AllowanceType: "donation", MaxAmount: 100_000},
+			}, nil)
+
+			h := NewTaxHandler(validator.New(), mockObj)
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations?taxYear="+tc.taxYear, strings.NewReader(`{"totalIncome":500000,"wht":0,"allowances":[]}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, h.CalculateTax(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
+
+type HistoryMock struct {
+	mock.Mock
+}
+
+func (o *HistoryMock) Record(calc database.Calculation) {
+	o.Called(calc)
+}
+
+func TestUserCalculateTaxHistory(t *testing.T) {
+	mockObj := new(UserDBMock)
+	mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+		{AllowanceType: "personal", Amount: 60_000},
+	}, nil)
+	mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+		{AllowanceType: "donation", MaxAmount: 100_000},
+	}, nil)
+
+	history := new(HistoryMock)
+	history.On("Record", mock.MatchedBy(func(calc database.Calculation) bool {
+		return calc.TotalIncome == 500_000 && calc.Wht == 0 && calc.Tax == 19_000 &&
+			calc.Allowances["donation"] == 200_000 && !calc.CalculatedAt.IsZero()
+	})).Return()
+
+	h := NewTaxHandler(validator.New(), mockObj).SetHistory(history)
+
+	req := httptest.NewRequest(http.MethodPost, "/tax/calculations", strings.NewReader(
+		`{"totalIncome":500000,"wht":0,"allowances":[{"allowanceType":"donation","amount":200000}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	e := echo.New()
+
+	assert.NoError(t, h.CalculateTax(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	history.AssertExpectations(t)
+}
+
+func TestUserCalculateTaxAllowanceSavings(t *testing.T) {
+	type TC struct {
+		query string
+		want  []AllowanceSaving
+	}
+
+	tcs := []TC{
+		{query: ""},
+		{
+			query: "?allowanceSavings=true",
+			want: []AllowanceSaving{
+				{AllowanceType: "donation", Tax: 30_000, Saving: 10_000},
+				{AllowanceType: "k-receipt", Tax: 25_000, Saving: 5_000},
+			},
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(UserDBMock)
+			mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+				{AllowanceType: "donation", MaxAmount: 100_000},
+				{AllowanceType: "k-receipt", MaxAmount: 50_000},
+			}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations"+tc.query, strings.NewReader(
+				`{"totalIncome":560000,"wht":0,"allowances":[{"allowanceType":"donation","amount":200000},{"allowanceType":"k-receipt","amount":50000}]}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			assert.NoError(t, NewTaxHandler(validator.New(), mockObj).CalculateTax(echo.New().NewContext(req, rec)))
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var got TaxResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, 20_000.0, got.Tax)
+			assert.Equal(t, tc.want, got.AllowanceSavings)
+		})
+	}
+}
+
+func TestUserCalculateTaxDetail(t *testing.T) {
+	type TC struct {
+		query      string
+		wantCode   int
+		wantLevels bool
+	}
+
+	tcs := []TC{
+		{query: "", wantCode: http.StatusOK, wantLevels: true},
+		{query: "?detail=full", wantCode: http.StatusOK, wantLevels: true},
+		{query: "?detail=summary", wantCode: http.StatusOK, wantLevels: false},
+		{query: "?detail=levels", wantCode: http.StatusBadRequest},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(UserDBMock)
+			mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations"+tc.query, strings.NewReader(
+				`{"totalIncome":500000,"wht":0,"allowances":[]}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			assert.NoError(t, NewTaxHandler(validator.New(), mockObj).CalculateTax(echo.New().NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			var got map[string]interface{}
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, 29_000.0, got["tax"])
+
+			_, ok := got["taxLevel"]
+			assert.Equal(t, tc.wantLevels, ok)
+		})
+	}
+}
+
+func TestUserCalculateTaxDisabledAllowance(t *testing.T) {
+	type TC struct {
+		reqbody  string
+		wantCode int
+		wantTax  float64
+	}
+
+	tcs := []TC{
+		{
+			reqbody:  `{"totalIncome":500000,"wht":0,"allowances":[{"allowanceType":"donation","amount":200000}]}`,
+			wantCode: http.StatusOK,
+			wantTax:  19_000,
+		},
+		{
+			reqbody:  `{"totalIncome":500000,"wht":0,"allowances":[{"allowanceType":"k-receipt","amount":50000}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	disabledAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(UserDBMock)
+			mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+				{AllowanceType: "donation", MaxAmount: 100_000},
+				{AllowanceType: "k-receipt", MaxAmount: 50_000, DisabledAt: &disabledAt},
+			}, nil)
+
+			// an effective value published before k-receipt was disabled doesn't enable it again
+			effective := new(EffectiveAllowanceMock)
+			effective.On("FindEffectiveAllowances", mock.Anything, mock.Anything).Return([]database.EffectiveAllowance{
+				{AllowanceKind: database.AllowanceKindAllowed, AllowanceType: "k-receipt", Amount: 50_000},
+			}, nil)
+
+			h := NewTaxHandler(validator.New(), mockObj).SetEffectiveAllowances(effective)
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations", strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, h.CalculateTax(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				var got ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, errcode.AllowanceDisabled, got.ErrorCode)
+
+				return
+			}
+
+			var got TaxResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tc.wantTax, got.Tax)
+		})
+	}
+}
+
+func TestUserCalculateTaxDegradedMode(t *testing.T) {
+	type TC struct {
+		fallback     bool
+		wantCode     int
+		wantDegraded string
+	}
+
+	tcs := []TC{
+		{fallback: true, wantCode: http.StatusOK, wantDegraded: "true"},
+		{fallback: false, wantCode: http.StatusInternalServerError},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(UserDBMock)
+			mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{}, errors.New("connection refused"))
+
+			brackets := new(BracketReaderMock)
+			brackets.On("FindTaxBrackets", mock.Anything, 2024).Return([]database.TaxBracket{}, errors.New("connection refused"))
+
+			h := NewTaxHandler(validator.New(), mockObj).SetBrackets(brackets).SetFallback(func() bool { return tc.fallback })
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations", strings.NewReader(
+				`{"totalIncome":500000,"wht":0,"allowances":[{"allowanceType":"donation","amount":200000}]}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, h.CalculateTax(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+			assert.Equal(t, tc.wantDegraded, rec.Header().Get("X-Degraded-Mode"))
+
+			if tc.wantCode == http.StatusOK {
+				var got TaxResponse
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, 19_000.0, got.Tax)
+			}
+		})
+	}
+}
+
+func TestUserCalculateTaxEffectiveAllowances(t *testing.T) {
+	type TC struct {
+		query    string
+		wantCode int
+		wantTax  float64
+	}
+
+	tcs := []TC{
+		{query: "?date=2024-06-01", wantCode: http.StatusOK, wantTax: 29_000},
+		{query: "?date=2025-01-01", wantCode: http.StatusOK, wantTax: 25_000},
+		{query: "?taxYear=2024", wantCode: http.StatusOK, wantTax: 29_000},
+		{query: "?date=01-01-2025", wantCode: http.StatusBadRequest},
+	}
+
+	published := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(UserDBMock)
+			mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{}, nil)
+
+			effective := new(EffectiveAllowanceMock)
+			effective.On("FindEffectiveAllowances", mock.Anything, mock.MatchedBy(func(at time.Time) bool {
+				return !at.Before(published)
+			})).Return([]database.EffectiveAllowance{
+				{AllowanceKind: database.AllowanceKindDefault, AllowanceType: "personal", Amount: 100_000, EffectiveFrom: published},
+			}, nil)
+			effective.On("FindEffectiveAllowances", mock.Anything, mock.Anything).Return([]database.EffectiveAllowance{}, nil)
+
+			h := NewTaxHandler(validator.New(), mockObj).SetEffectiveAllowances(effective)
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations"+tc.query, strings.NewReader(`{"totalIncome":500000,"wht":0,"allowances":[]}`))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, h.CalculateTax(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			var got TaxResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tc.wantTax, got.Tax)
+		})
+	}
+}
+
+func TestUserCalculateTaxLocalizedLevel(t *testing.T) {
+	type TC struct {
+		acceptLanguage string
+		want           string
+	}
+
+	tcs := []TC{
+		{acceptLanguage: "", want: "2,000,001 ขึ้นไป"},
+		{acceptLanguage: "th-TH", want: "2,000,001 ขึ้นไป"},
+		{acceptLanguage: "en-US,en;q=0.9", want: "2,000,001 and above"},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(UserDBMock)
+			mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{}, nil)
+
+			h := NewTaxHandler(validator.New(), mockObj)
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations", strings.NewReader(`{"totalIncome":500000,"wht":0,"allowances":[]}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", tc.acceptLanguage)
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, h.CalculateTax(e.NewContext(req, rec)))
+			assert.Equal(t, http.StatusOK, rec.Code)
+
+			var got TaxResponse
+
+			err := json.Unmarshal([]byte(rec.Body.String()), &got)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tc.want, got.TaxLevel[4].Level)
+		})
+	}
+}
+
+func TestUserCalculateTaxTimeout(t *testing.T) {
+	mockObj := new(UserDBMock)
+	mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{}, context.DeadlineExceeded)
+
+	h := NewTaxHandler(validator.New(), mockObj)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	defer cancel()
+
+	req := httptest.NewRequest(http.MethodPost, "/tax/calculations", strings.NewReader(`{"totalIncome":500000,"wht":0,"allowances":[]}`))
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	e := echo.New()
+
+	assert.NoError(t, h.CalculateTax(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var got ResponseMsg
+
+	err := json.Unmarshal([]byte(rec.Body.String()), &got)
+	assert.NoError(t, err)
+	assert.Equal(t, errcode.RequestTimeout, got.ErrorCode)
+}
+
 func TestUserCalculateTaxWithCSV(t *testing.T) {
 	type TC struct {
 		reqbody                      string
 		contentType                  string
 		want                         *TaxCSVResponse
+		mockScan                     *MockSetting
 		mockFindAllDefaultAllowances *MockSetting
 		mockFindAllAllowedAllowances *MockSetting
 		errresp                      *ResponseMsg
@@ -396,7 +973,8 @@ totalIncome,wht,donation
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Unaceptable content, require CSV content",
+				Message:   "Unaceptable content, require CSV content",
+				ErrorCode: errcode.CSVContentType,
 			},
 		},
 		{
@@ -406,7 +984,8 @@ totalIncome,wht,donation
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Wrong csv content, no content",
+				Message:   "Wrong csv content, no content",
+				ErrorCode: errcode.CSVEmpty,
 			},
 		},
 		{
@@ -416,7 +995,8 @@ totalIncome,wht,donation
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Wrong csv content, should have more than 1 row due to it is header",
+				Message:   "Wrong csv content, should have more than 1 row due to it is header",
+				ErrorCode: errcode.CSVNoDataRows,
 			},
 		},
 		{
@@ -430,7 +1010,8 @@ totalIncome,wht,donation,k-receipt
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Bad request, might not be csv format",
+				Message:   "Bad request, might not be csv format",
+				ErrorCode: errcode.CSVMalformed,
 			},
 		},
 		{
@@ -444,7 +1025,8 @@ totalIncome,wht,donation
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Bad request, might not be csv format",
+				Message:   "Bad request, might not be csv format",
+				ErrorCode: errcode.CSVMalformed,
 			},
 		},
 		{
@@ -458,7 +1040,8 @@ totalIncome,wht,donation,k-receipt
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Wrong csv column length",
+				Message:   "Wrong csv column length",
+				ErrorCode: errcode.CSVBadColumnCount,
 			},
 		},
 		{
@@ -472,7 +1055,8 @@ totalIncome,wht,k-receipt
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Wrong csv header",
+				Message:   "Wrong csv header",
+				ErrorCode: errcode.CSVBadHeader,
 			},
 		},
 		{
@@ -486,7 +1070,8 @@ aaaa,0,0
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Invalid income amount",
+				Message:   "Invalid income amount",
+				ErrorCode: errcode.CSVInvalidIncome,
 			},
 		},
 		{
@@ -500,7 +1085,8 @@ totalIncome,wht,donation
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Invalid wht amount",
+				Message:   "Invalid wht amount",
+				ErrorCode: errcode.CSVInvalidWht,
 			},
 		},
 		{
@@ -514,7 +1100,8 @@ totalIncome,wht,donation
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Invalid donation amount",
+				Message:   "Invalid donation amount",
+				ErrorCode: errcode.CSVInvalidDonation,
 			},
 		},
 		{
@@ -528,7 +1115,8 @@ totalIncome,wht,donation
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Invalid income amount",
+				Message:   "Invalid income amount",
+				ErrorCode: errcode.CSVInvalidIncome,
 			},
 		},
 		{
@@ -542,7 +1130,8 @@ totalIncome,wht,donation
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Invalid wht amount",
+				Message:   "Invalid wht amount",
+				ErrorCode: errcode.CSVInvalidWht,
 			},
 		},
 		{
@@ -556,7 +1145,8 @@ totalIncome,wht,donation
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Invalid donation amount",
+				Message:   "Invalid donation amount",
+				ErrorCode: errcode.CSVInvalidDonation,
 			},
 		},
 		{
@@ -570,7 +1160,8 @@ totalIncome,wht,donation
 			mockFindAllDefaultAllowances: nil,
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Income amount should be more than wht amount",
+				Message:   "Income amount should be more than wht amount",
+				ErrorCode: errcode.CSVWhtExceedsIncome,
 			},
 		},
 		{
@@ -592,7 +1183,8 @@ totalIncome,wht,donation
 			},
 			mockFindAllAllowedAllowances: nil,
 			errresp: &ResponseMsg{
-				Message: "Internal server error",
+				Message:   "Internal server error",
+				ErrorCode: errcode.Internal,
 			},
 		},
 		{
@@ -624,7 +1216,52 @@ totalIncome,wht,donation
 				},
 			},
 			errresp: &ResponseMsg{
-				Message: "Internal server error",
+				Message:   "Internal server error",
+				ErrorCode: errcode.Internal,
+			},
+		},
+		{
+			reqbody: `
+totalIncome,wht,donation
+500000,0,0`,
+			contentType: "text/csv",
+			want:        nil,
+			mockScan: &MockSetting{
+				Args: []interface{}{
+					mock.Anything,
+					mock.Anything,
+				},
+				Returns: []interface{}{
+					uploadscan.ErrInfected,
+				},
+			},
+			mockFindAllDefaultAllowances: nil,
+			mockFindAllAllowedAllowances: nil,
+			errresp: &ResponseMsg{
+				Message:   "Uploaded file was rejected by security scan",
+				ErrorCode: errcode.UploadRejected,
+			},
+		},
+		{
+			reqbody: `
+totalIncome,wht,donation
+500000,0,0`,
+			contentType: "text/csv",
+			want:        nil,
+			mockScan: &MockSetting{
+				Args: []interface{}{
+					mock.Anything,
+					mock.Anything,
+				},
+				Returns: []interface{}{
+					errors.New("an error"),
+				},
+			},
+			mockFindAllDefaultAllowances: nil,
+			mockFindAllAllowedAllowances: nil,
+			errresp: &ResponseMsg{
+				Message:   "Internal server error",
+				ErrorCode: errcode.Internal,
 			},
 		},
 	}
@@ -649,6 +1286,13 @@ totalIncome,wht,donation
 
 			h := NewTaxHandler(validator.New(), mockObj)
 
+			if tc.mockScan != nil {
+				scanner := new(ScannerMock)
+				scanner.On("Scan", tc.mockScan.Args...).Return(tc.mockScan.Returns...)
+
+				h.SetScanner(scanner)
+			}
+
 			req := httptest.NewRequest(http.MethodPost, "/tax/calculations/upload-csv", strings.NewReader(tc.reqbody))
 			req.Header.Set("Content-Type", tc.contentType)
 			rec := httptest.NewRecorder()
@@ -691,3 +1335,109 @@ totalIncome,wht,donation
 		})
 	}
 }
+
+func TestUserCalculateTaxWithGzipCSV(t *testing.T) {
+	mockObj := new(UserDBMock)
+	mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+		{AllowanceType: "personal", Amount: 60_000},
+	}, nil)
+	mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+		{AllowanceType: "donation", MaxAmount: 100_000},
+	}, nil)
+
+	h := NewTaxHandler(validator.New(), mockObj)
+
+	e := echo.New()
+	e.Use(middleware.Decompress())
+	e.POST("/tax/calculations/upload-csv", h.CalculateTaxWithCSV, middleware.BodyLimit("1K"))
+
+	type TC struct {
+		csv      string
+		wantCode int
+	}
+
+	tcs := []TC{
+		{
+			csv:      "totalIncome,wht,donation\n500000,0,0\n",
+			wantCode: http.StatusOK,
+		},
+		{
+			csv:      "totalIncome,wht,donation\n" + strings.Repeat("500000,0,0\n", 1000),
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var buf bytes.Buffer
+
+			gz := gzip.NewWriter(&buf)
+			gz.Write([]byte(tc.csv))
+			gz.Close()
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations/upload-csv", &buf)
+			req.Header.Set("Content-Type", "text/csv")
+			req.Header.Set("Content-Encoding", "gzip")
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+		})
+	}
+}
+
+func TestCalculateDuplicateAllowances(t *testing.T) {
+	type TC struct {
+		policy   func() string
+		wantTax  float64
+		wantCode errcode.Code
+	}
+
+	tcs := []TC{
+		// 500,000 - 60,000 personal - 70,000 donation
+		{wantTax: 22_000},
+		{policy: func() string { return DuplicateAllowancesSum }, wantTax: 22_000},
+		// 500,000 - 60,000 personal - 30,000 donation
+		{policy: func() string { return DuplicateAllowancesLast }, wantTax: 26_000},
+		{policy: func() string { return DuplicateAllowancesReject }, wantCode: errcode.AllowanceDuplicated},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			mockObj := new(UserDBMock)
+			mockObj.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			mockObj.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{
+				{AllowanceType: "donation", MaxAmount: 100_000},
+			}, nil)
+
+			h := NewTaxHandler(validator.New(), mockObj)
+			if tc.policy != nil {
+				h.SetDuplicateAllowances(tc.policy)
+			}
+
+			resp, _, err := h.Calculate(context.Background(), Calculation{
+				TaxRequest: TaxRequest{TotalIncome: 500_000, Allowances: []Allowance{
+					{AllowanceType: "donation", Amount: 40_000},
+					{AllowanceType: "donation", Amount: 30_000},
+				}},
+				TaxYear: defaultTaxYear,
+			})
+
+			if tc.wantCode != "" {
+				var calcErr *CalculationError
+
+				assert.ErrorAs(t, err, &calcErr)
+				assert.Equal(t, http.StatusBadRequest, calcErr.Status)
+				assert.Equal(t, tc.wantCode, calcErr.Code)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantTax, resp.Tax)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/AnnaCarter465/assessment-tax/tax/vat"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// VATRequest is items of an invoice, prices are exclusive of VAT and VAT is rounded per invoice unless they're set
+type VATRequest struct {
+	Pricing  string           `json:"pricing" validate:"omitempty,oneof=exclusive inclusive"`
+	Rounding string           `json:"rounding" validate:"omitempty,oneof=invoice item"`
+	Items    []VATItemRequest `json:"items" validate:"required,min=1,max=1000,dive"`
+}
+
+type VATItemRequest struct {
+	Description string  `json:"description" validate:"max=200"`
+	Quantity    float64 `json:"quantity" validate:"number,gt=0"`
+	UnitPrice   float64 `json:"unitPrice" validate:"number,gte=0"`
+}
+
+type VATLineResponse struct {
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	UnitPrice   float64 `json:"unitPrice"`
+	Net         float64 `json:"net"`
+	VAT         float64 `json:"vat"`
+	Gross       float64 `json:"gross"`
+}
+
+type VATResponse struct {
+	Rate     float64           `json:"rate"`
+	Pricing  string            `json:"pricing"`
+	Rounding string            `json:"rounding"`
+	Lines    []VATLineResponse `json:"lines"`
+	Net      float64           `json:"net"`
+	VAT      float64           `json:"vat"`
+	Gross    float64           `json:"gross"`
+}
+
+// VATHandler calculates VAT of invoices at the rate reported by rate, which is read on every request so a reload
+// applies to the next one
+type VATHandler struct {
+	vl   *validator.Validate
+	rate func() float64
+}
+
+func NewVATHandler(vl *validator.Validate, rate func() float64) *VATHandler {
+	return &VATHandler{vl, rate}
+}
+
+func (h *VATHandler) CalculateVAT(c echo.Context) error {
+	var req VATRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if req.Pricing == "" {
+		req.Pricing = vat.PricingExclusive
+	}
+
+	if req.Rounding == "" {
+		req.Rounding = vat.RoundingInvoice
+	}
+
+	items := make([]vat.Item, 0, len(req.Items))
+	for _, i := range req.Items {
+		items = append(items, vat.Item{Description: i.Description, Quantity: i.Quantity, UnitPrice: i.UnitPrice})
+	}
+
+	rate := h.rate()
+	inv := vat.Calculate(items, rate, req.Pricing, req.Rounding)
+
+	resp := VATResponse{
+		Rate:     rate,
+		Pricing:  req.Pricing,
+		Rounding: req.Rounding,
+		Lines:    make([]VATLineResponse, 0, len(inv.Lines)),
+		Net:      inv.Net,
+		VAT:      inv.VAT,
+		Gross:    inv.Gross,
+	}
+
+	for _, l := range inv.Lines {
+		resp.Lines = append(resp.Lines, VATLineResponse{
+			Description: l.Description,
+			Quantity:    l.Quantity,
+			UnitPrice:   l.UnitPrice,
+			Net:         l.Net,
+			VAT:         l.VAT,
+			Gross:       l.Gross,
+		})
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateVAT(t *testing.T) {
+	type TC struct {
+		reqbody  string
+		wantCode int
+		want     VATResponse
+	}
+
+	tcs := []TC{
+		{
+			reqbody:  `{"items":[{"description":"book","quantity":2,"unitPrice":50}]}`,
+			wantCode: http.StatusOK,
+			want: VATResponse{Rate: 0.07, Pricing: "exclusive", Rounding: "invoice", Net: 100, VAT: 7, Gross: 107, Lines: []VATLineResponse{
+				{Description: "book", Quantity: 2, UnitPrice: 50, Net: 100, VAT: 7, Gross: 107},
+			}},
+		},
+		{
+			reqbody:  `{"pricing":"inclusive","rounding":"item","items":[{"quantity":1,"unitPrice":107}]}`,
+			wantCode: http.StatusOK,
+			want: VATResponse{Rate: 0.07, Pricing: "inclusive", Rounding: "item", Net: 100, VAT: 7, Gross: 107, Lines: []VATLineResponse{
+				{Quantity: 1, UnitPrice: 107, Net: 100, VAT: 7, Gross: 107},
+			}},
+		},
+		{
+			reqbody:  `{"items":[]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			reqbody:  `{"items":[{"quantity":0,"unitPrice":10}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			reqbody:  `{"pricing":"gross","items":[{"quantity":1,"unitPrice":10}]}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/vat/calculations", strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			h := NewVATHandler(validator.New(), func() float64 { return 0.07 })
+
+			assert.NoError(t, h.CalculateVAT(echo.New().NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+
+			var got VATResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
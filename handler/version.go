@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+	"runtime"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/buildinfo"
+	"github.com/labstack/echo/v4"
+)
+
+type VersionResponse struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"buildTime"`
+	GoVersion string `json:"goVersion"`
+	Platform  string `json:"platform"`
+}
+
+func Version(c echo.Context) error {
+	return c.JSON(http.StatusOK, VersionResponse{
+		Version:   buildinfo.Version,
+		Commit:    buildinfo.GetCommit(),
+		BuildTime: buildinfo.GetBuildTime(),
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	})
+}
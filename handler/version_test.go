@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/buildinfo"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersion(t *testing.T) {
+	buildinfo.Version = "v1.2.0"
+	buildinfo.Commit = "0123abc"
+	buildinfo.BuildTime = "2024-05-01T00:00:00Z"
+
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, "/version", nil)
+	rec := httptest.NewRecorder()
+
+	assert.NoError(t, Version(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var got VersionResponse
+
+	err := json.Unmarshal([]byte(rec.Body.String()), &got)
+	assert.NoError(t, err)
+	assert.Equal(t, VersionResponse{
+		Version:   "v1.2.0",
+		Commit:    "0123abc",
+		BuildTime: "2024-05-01T00:00:00Z",
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}, got)
+}
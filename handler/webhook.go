@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+type WebhookRequest struct {
+	URL    string `json:"url" validate:"required,url"`
+	Secret string `json:"secret" validate:"omitempty,min=16"`
+}
+
+type WebhookResponse struct {
+	ID        int       `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	UUID      string    `json:"uuid"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type WebhookIDB interface {
+	FindAllWebhooks(ctx context.Context) ([]database.Webhook, error)
+	CreateWebhook(ctx context.Context, url string, secret string) (database.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int) error
+}
+
+type WebhookHandler struct {
+	vl *validator.Validate
+	db WebhookIDB
+}
+
+func NewWebhookHandler(vl *validator.Validate, db WebhookIDB) *WebhookHandler {
+	return &WebhookHandler{vl, db}
+}
+
+func (h *WebhookHandler) GetWebhooks(c echo.Context) error {
+	hooks, err := h.db.FindAllWebhooks(c.Request().Context())
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to find webhooks", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.Internal)
+	}
+
+	results := []WebhookResponse{}
+
+	// secrets are shown only once when created
+	for _, hook := range hooks {
+		results = append(results, WebhookResponse{
+			ID:        hook.ID,
+			URL:       hook.URL,
+			UUID:      hook.UUID,
+			CreatedAt: hook.CreatedAt,
+			UpdatedAt: hook.UpdatedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, results)
+}
+
+func (h *WebhookHandler) CreateWebhook(c echo.Context) error {
+	var req WebhookRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	secret := req.Secret
+
+	if secret == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			slog.ErrorContext(c.Request().Context(), "failed to generate webhook secret", "error", err)
+			return respondError(c, http.StatusInternalServerError, errcode.Internal)
+		}
+
+		secret = hex.EncodeToString(b)
+	}
+
+	hook, err := h.db.CreateWebhook(c.Request().Context(), req.URL, secret)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to create webhook", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.WebhookCreateFailed)
+	}
+
+	return c.JSON(http.StatusCreated, WebhookResponse{
+		ID:        hook.ID,
+		URL:       hook.URL,
+		Secret:    hook.Secret,
+		UUID:      hook.UUID,
+		CreatedAt: hook.CreatedAt,
+		UpdatedAt: hook.UpdatedAt,
+	})
+}
+
+func (h *WebhookHandler) DeleteWebhook(c echo.Context) error {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.WebhookInvalidID)
+	}
+
+	err = h.db.DeleteWebhook(c.Request().Context(), id)
+	if errors.Is(err, database.ErrNotFound) {
+		return respondError(c, http.StatusNotFound, errcode.WebhookNotFound)
+	}
+
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to delete webhook", "error", err)
+		return respondError(c, http.StatusInternalServerError, errcode.WebhookDeleteFailed)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type WebhookDBMock struct {
+	mock.Mock
+}
+
+func (o *WebhookDBMock) FindAllWebhooks(ctx context.Context) ([]database.Webhook, error) {
+	args := o.Called(ctx)
+	return args.Get(0).([]database.Webhook), args.Error(1)
+}
+
+func (o *WebhookDBMock) CreateWebhook(ctx context.Context, url string, secret string) (database.Webhook, error) {
+	args := o.Called(ctx, url, secret)
+	return args.Get(0).(database.Webhook), args.Error(1)
+}
+
+func (o *WebhookDBMock) DeleteWebhook(ctx context.Context, id int) error {
+	args := o.Called(ctx, id)
+	return args.Error(0)
+}
+
+func TestAdminCreateWebhook(t *testing.T) {
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	type TC struct {
+		reqbody          map[string]interface{}
+		mockCreate       *MockSetting
+		wantCode         int
+		wantMessage      string
+		wantSecretLength int
+	}
+
+	tcs := []TC{
+		{
+			reqbody: map[string]interface{}{
+				"url":    "https://payroll.example.com/hooks",
+				"secret": "0123456789abcdef",
+			},
+			mockCreate: &MockSetting{
+				Args: []interface{}{mock.Anything, "https://payroll.example.com/hooks", "0123456789abcdef"},
+				Returns: []interface{}{
+					database.Webhook{ID: 1, URL: "https://payroll.example.com/hooks", Secret: "0123456789abcdef", CreatedAt: createdAt},
+					nil,
+				},
+			},
+			wantCode:         http.StatusCreated,
+			wantSecretLength: 16,
+		},
+		{
+			reqbody: map[string]interface{}{
+				"url": "https://payroll.example.com/hooks",
+			},
+			mockCreate: &MockSetting{
+				Args: []interface{}{mock.Anything, "https://payroll.example.com/hooks", mock.Anything},
+				Returns: []interface{}{
+					database.Webhook{ID: 1, URL: "https://payroll.example.com/hooks", Secret: strings.Repeat("a", 64), CreatedAt: createdAt},
+					nil,
+				},
+			},
+			wantCode:         http.StatusCreated,
+			wantSecretLength: 64,
+		},
+		{
+			reqbody: map[string]interface{}{
+				"url": "not a url",
+			},
+			mockCreate:  nil,
+			wantCode:    http.StatusBadRequest,
+			wantMessage: "Bad request",
+		},
+		{
+			reqbody: map[string]interface{}{
+				"url": "https://payroll.example.com/hooks",
+			},
+			mockCreate: &MockSetting{
+				Args:    []interface{}{mock.Anything, "https://payroll.example.com/hooks", mock.Anything},
+				Returns: []interface{}{database.Webhook{}, errors.New("an error")},
+			},
+			wantCode:    http.StatusInternalServerError,
+			wantMessage: "Failed to create webhook",
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dbmock := new(WebhookDBMock)
+
+			if tc.mockCreate != nil {
+				dbmock.On("CreateWebhook", tc.mockCreate.Args...).Return(tc.mockCreate.Returns...)
+			}
+
+			h := NewWebhookHandler(validator.New(), dbmock)
+
+			val, _ := json.Marshal(tc.reqbody)
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/webhooks", strings.NewReader(string(val)))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			e := echo.New()
+
+			assert.NoError(t, h.CreateWebhook(e.NewContext(req, rec)))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantMessage != "" {
+				var errresp ResponseMsg
+
+				err := json.Unmarshal([]byte(rec.Body.String()), &errresp)
+				assert.NoError(t, err)
+				assert.Equal(t, tc.wantMessage, errresp.Message)
+
+				return
+			}
+
+			var got WebhookResponse
+
+			err := json.Unmarshal([]byte(rec.Body.String()), &got)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantSecretLength, len(got.Secret))
+		})
+	}
+}
+
+func TestAdminGetWebhooksHidesSecret(t *testing.T) {
+	dbmock := new(WebhookDBMock)
+	dbmock.On("FindAllWebhooks", mock.Anything).Return([]database.Webhook{
+		{ID: 1, URL: "https://payroll.example.com/hooks", Secret: "0123456789abcdef"},
+	}, nil)
+
+	h := NewWebhookHandler(validator.New(), dbmock)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/webhooks", nil)
+	rec := httptest.NewRecorder()
+
+	e := echo.New()
+
+	assert.NoError(t, h.GetWebhooks(e.NewContext(req, rec)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "0123456789abcdef")
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+)
+
+// WithholdingRequest has payroll of an employee so far, salaries are ones of each month of the year and the last one
+// is the salary of this month. PriorWht is what was withheld in the previous months.
+// Bonus is irregular income paid this month, it isn't part of salaries
+type WithholdingRequest struct {
+	Salaries   []float64   `json:"salaries" validate:"required,min=1,max=12,dive,gte=0"`
+	PriorWht   float64     `json:"priorWht" validate:"number,gte=0"`
+	Bonus      float64     `json:"bonus" validate:"number,gte=0"`
+	Allowances []Allowance `json:"allowances" validate:"dive"`
+}
+
+// WithholdingResponse is the wht to deduct from salary of this month. CumulativeTax is the part of the annual tax
+// due by the end of this month, which is covered by prior wht and wht of this month.
+// BonusWht is the part of Wht withheld for the bonus, AnnualIncome and AnnualTax don't include the bonus
+type WithholdingResponse struct {
+	Month         int     `json:"month"`
+	AnnualIncome  float64 `json:"annualIncome"`
+	AnnualTax     float64 `json:"annualTax"`
+	CumulativeTax float64 `json:"cumulativeTax"`
+	BonusWht      float64 `json:"bonusWht,omitempty"`
+	Wht           float64 `json:"wht"`
+}
+
+// WithholdingHandler calculates monthly wht of payroll by the cumulative method. Annual tax is calculated like
+// POST /tax/calculations, but it's an estimate, so calculations shouldn't record it to history
+type WithholdingHandler struct {
+	vl           *validator.Validate
+	calculations *TaxHandler
+}
+
+func NewWithholdingHandler(vl *validator.Validate, calculations *TaxHandler) *WithholdingHandler {
+	return &WithholdingHandler{vl, calculations}
+}
+
+// CalculateWithholding projects annual income as salaries so far plus salary of this month for the remaining months,
+// the tax due so far is the share of the annual tax of months elapsed, less prior wht.
+// Overpaid prior wht isn't returned by payroll, so wht is never negative.
+// A bonus is taxed in full in the month it's paid, with the tax it adds on top of the annual income
+func (h *WithholdingHandler) CalculateWithholding(c echo.Context) error {
+	taxYear, ok := getTaxYear(c)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.TaxYearUnsupported)
+	}
+
+	effectiveDate, ok := getEffectiveDate(c, h.calculations.now())
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidDate)
+	}
+
+	precision, ok := getPrecision(c, h.calculations.precision)
+	if !ok {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	var req WithholdingRequest
+
+	if err := c.Bind(&req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	if err := h.vl.Struct(req); err != nil {
+		return respondError(c, http.StatusBadRequest, errcode.InvalidRequest)
+	}
+
+	month := len(req.Salaries)
+	salary := req.Salaries[month-1]
+
+	var income float64
+	for _, s := range req.Salaries {
+		income += s
+	}
+
+	if income+req.Bonus < req.PriorWht {
+		return respondError(c, http.StatusBadRequest, errcode.WhtExceedsIncome)
+	}
+
+	annualIncome := income + salary*float64(monthsInYear-month)
+
+	annualTax, err := h.calculateAnnualTax(c, taxYear, effectiveDate, annualIncome, req.Allowances)
+	if err != nil {
+		var calcErr *CalculationError
+		if errors.As(err, &calcErr) {
+			return respondError(c, calcErr.Status, calcErr.Code)
+		}
+
+		return respondQueryError(c)
+	}
+
+	var bonusWht float64
+
+	if req.Bonus > 0 {
+		taxWithBonus, err := h.calculateAnnualTax(c, taxYear, effectiveDate, annualIncome+req.Bonus, req.Allowances)
+		if err != nil {
+			var calcErr *CalculationError
+			if errors.As(err, &calcErr) {
+				return respondError(c, calcErr.Status, calcErr.Code)
+			}
+
+			return respondQueryError(c)
+		}
+
+		bonusWht = taxWithBonus - annualTax
+	}
+
+	cumulativeTax := annualTax * float64(month) / monthsInYear
+
+	resp := WithholdingResponse{
+		Month:         month,
+		AnnualIncome:  annualIncome,
+		AnnualTax:     annualTax,
+		CumulativeTax: cumulativeTax,
+		BonusWht:      bonusWht,
+		Wht:           max(cumulativeTax-req.PriorWht, 0) + bonusWht,
+	}
+
+	resp.round(precision)
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// calculateAnnualTax returns tax of the annual income without wht, degraded header is set when compiled-in settings
+// are used
+func (h *WithholdingHandler) calculateAnnualTax(c echo.Context, taxYear int, effectiveDate time.Time, income float64, allowances []Allowance) (float64, error) {
+	if allowances == nil {
+		allowances = []Allowance{}
+	}
+
+	resp, degraded, err := h.calculations.Calculate(c.Request().Context(), Calculation{
+		TaxRequest:    TaxRequest{TotalIncome: income, Allowances: allowances},
+		TaxYear:       taxYear,
+		EffectiveDate: effectiveDate,
+		Language:      preferredLanguage(c.Request().Header.Get("Accept-Language"), defaultLanguage),
+		APIKeyID:      currentAPIKeyID(c),
+		TenantID:      currentTenantID(c),
+		UserID:        currentUserID(c),
+	})
+
+	if degraded {
+		c.Response().Header().Set(degradedHeader, "true")
+	}
+
+	if err != nil {
+		return 0, err
+	}
+
+	return resp.Tax, nil
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCalculateWithholding(t *testing.T) {
+	type TC struct {
+		reqbody   string
+		wantCode  int
+		wantError errcode.Code
+		want      WithholdingResponse
+	}
+
+	tcs := []TC{
+		{
+			reqbody:  `{"salaries":[40000,40000,40000,40000,40000,40000],"priorWht":10000}`,
+			wantCode: http.StatusOK,
+			want:     WithholdingResponse{Month: 6, AnnualIncome: 480_000, AnnualTax: 27_000, CumulativeTax: 13_500, Wht: 3_500},
+		},
+		// a raise is spread over the remaining months
+		{
+			reqbody:  `{"salaries":[30000,30000,30000,40000],"priorWht":0}`,
+			wantCode: http.StatusOK,
+			want:     WithholdingResponse{Month: 4, AnnualIncome: 450_000, AnnualTax: 24_000, CumulativeTax: 8_000, Wht: 8_000},
+		},
+		// overpaid wht isn't returned
+		{
+			reqbody:  `{"salaries":[30000,30000,30000,40000],"priorWht":9000}`,
+			wantCode: http.StatusOK,
+			want:     WithholdingResponse{Month: 4, AnnualIncome: 450_000, AnnualTax: 24_000, CumulativeTax: 8_000, Wht: 0},
+		},
+		// bonus of 30,000 adds 3,000 to the annual tax of 450,000
+		{
+			reqbody:  `{"salaries":[30000,30000,30000,40000],"priorWht":9000,"bonus":30000}`,
+			wantCode: http.StatusOK,
+			want:     WithholdingResponse{Month: 4, AnnualIncome: 450_000, AnnualTax: 24_000, CumulativeTax: 8_000, BonusWht: 3_000, Wht: 3_000},
+		},
+		{
+			reqbody:  `{"salaries":[40000,40000,40000,40000,40000,40000],"priorWht":10000,"bonus":10000}`,
+			wantCode: http.StatusOK,
+			want:     WithholdingResponse{Month: 6, AnnualIncome: 480_000, AnnualTax: 27_000, CumulativeTax: 13_500, BonusWht: 1_000, Wht: 4_500},
+		},
+		{
+			reqbody:   `{"salaries":[40000],"priorWht":0,"bonus":-1}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: errcode.InvalidRequest,
+		},
+		{
+			reqbody:   `{"salaries":[],"priorWht":0}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: errcode.InvalidRequest,
+		},
+		{
+			reqbody:   `{"salaries":[1,1,1,1,1,1,1,1,1,1,1,1,1],"priorWht":0}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: errcode.InvalidRequest,
+		},
+		{
+			reqbody:   `{"salaries":[10000],"priorWht":20000}`,
+			wantCode:  http.StatusBadRequest,
+			wantError: errcode.WhtExceedsIncome,
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			taxes := new(UserDBMock)
+			taxes.On("FindAllDefaultAllowances", mock.Anything).Return([]database.DefaultAllowance{
+				{AllowanceType: "personal", Amount: 60_000},
+			}, nil)
+			taxes.On("FindAllAllowedAllowances", mock.Anything).Return([]database.AllowedAllowance{}, nil)
+
+			req := httptest.NewRequest(http.MethodPost, "/tax/withholdings", strings.NewReader(tc.reqbody))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			c := echo.New().NewContext(req, rec)
+
+			h := NewWithholdingHandler(validator.New(), NewTaxHandler(validator.New(), taxes))
+
+			assert.NoError(t, h.CalculateWithholding(c))
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode != http.StatusOK {
+				var got ResponseMsg
+
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+				assert.Equal(t, tc.wantError, got.ErrorCode)
+
+				return
+			}
+
+			var got WithholdingResponse
+
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
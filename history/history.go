@@ -0,0 +1,99 @@
+package history
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+)
+
+// flushTimeout bounds the last flush when Run stops, its ctx is already done by then
+const flushTimeout = 10 * time.Second
+
+type IDB interface {
+	InsertCalculations(ctx context.Context, calcs []database.Calculation) error
+}
+
+// Recorder buffers calculations in memory and writes them in batches from Run,
+// so recording history doesn't wait for the database on the calculation path
+type Recorder struct {
+	db        IDB
+	records   chan database.Calculation
+	batchSize int
+}
+
+// NewRecorder buffers up to bufferSize calculations, Record drops calculations when the buffer is full
+func NewRecorder(db IDB, bufferSize int, batchSize int) *Recorder {
+	return &Recorder{
+		db:        db,
+		records:   make(chan database.Calculation, bufferSize),
+		batchSize: batchSize,
+	}
+}
+
+// Record queues calc for the next batch without blocking
+func (r *Recorder) Record(calc database.Calculation) {
+	select {
+	case r.records <- calc:
+	default:
+		slog.Warn("calculation history buffer is full, dropping calculation")
+	}
+}
+
+// Run writes a batch whenever batchSize calculations are buffered or interval passes,
+// calculations buffered when ctx is done are written before it returns
+func (r *Recorder) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	batch := make([]database.Calculation, 0, r.batchSize)
+
+	for {
+		select {
+		case <-ctx.Done():
+			r.drain(batch)
+			return
+		case calc := <-r.records:
+			batch = append(batch, calc)
+			if len(batch) < r.batchSize {
+				continue
+			}
+		case <-ticker.C:
+		}
+
+		r.flush(ctx, batch)
+		batch = batch[:0]
+	}
+}
+
+func (r *Recorder) drain(batch []database.Calculation) {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case calc := <-r.records:
+			batch = append(batch, calc)
+			if len(batch) < r.batchSize {
+				continue
+			}
+
+			r.flush(ctx, batch)
+			batch = batch[:0]
+		default:
+			r.flush(ctx, batch)
+			return
+		}
+	}
+}
+
+func (r *Recorder) flush(ctx context.Context, batch []database.Calculation) {
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := r.db.InsertCalculations(ctx, batch); err != nil {
+		slog.ErrorContext(ctx, "failed to write calculation history", "calculations", len(batch), "error", err)
+	}
+}
@@ -0,0 +1,72 @@
+package history
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/stretchr/testify/assert"
+)
+
+type dbStub struct {
+	mu      sync.Mutex
+	batches [][]database.Calculation
+}
+
+func (d *dbStub) InsertCalculations(ctx context.Context, calcs []database.Calculation) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.batches = append(d.batches, append([]database.Calculation(nil), calcs...))
+
+	return nil
+}
+
+func TestRecorderBatches(t *testing.T) {
+	db := &dbStub{}
+	r := NewRecorder(db, 10, 2)
+
+	for i := 0; i < 5; i++ {
+		r.Record(database.Calculation{TotalIncome: float64(i)})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		r.Run(ctx, time.Hour)
+	}()
+
+	assert.Eventually(t, func() bool {
+		db.mu.Lock()
+		defer db.mu.Unlock()
+
+		return len(db.batches) == 2
+	}, time.Second, time.Millisecond)
+
+	cancel()
+	<-done
+
+	assert.Equal(t, [][]database.Calculation{
+		{{TotalIncome: 0}, {TotalIncome: 1}},
+		{{TotalIncome: 2}, {TotalIncome: 3}},
+		{{TotalIncome: 4}},
+	}, db.batches)
+}
+
+func TestRecorderDropsWhenFull(t *testing.T) {
+	db := &dbStub{}
+	r := NewRecorder(db, 1, 10)
+
+	r.Record(database.Calculation{TotalIncome: 1})
+	r.Record(database.Calculation{TotalIncome: 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	r.Run(ctx, time.Hour)
+
+	assert.Equal(t, [][]database.Calculation{{{TotalIncome: 1}}}, db.batches)
+}
@@ -1,8 +1,3 @@
-SELECT 'CREATE DATABASE ktaxes'
-WHERE NOT EXISTS (SELECT FROM pg_database WHERE datname = 'ktaxes')\gexec
-
-\c "ktaxes";
-
 CREATE TABLE IF NOT EXISTS default_allowances (
     allowance_type varchar(100) NOT NULL,
     amount float8 DEFAULT 0 NOT NULL,
@@ -25,4 +20,425 @@ INSERT INTO allowed_allowances (allowance_type,max_amount)
 VALUES 
     ('donation',100000.0),
     ('k-receipt',50000.0)
-ON CONFLICT (allowance_type) DO NOTHING;
\ No newline at end of file
+ON CONFLICT (allowance_type) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS tax_calendars (
+    tax_year int NOT NULL,
+    filing_deadline date NOT NULL,
+    CONSTRAINT tax_calendars_pk PRIMARY KEY (tax_year)
+);
+
+CREATE TABLE IF NOT EXISTS allowance_windows (
+    tax_year int NOT NULL REFERENCES tax_calendars (tax_year) ON DELETE CASCADE,
+    allowance_type varchar(100) NOT NULL,
+    starts_on date NOT NULL,
+    ends_on date NOT NULL,
+    CONSTRAINT allowance_windows_pk PRIMARY KEY (tax_year, allowance_type)
+);
+
+CREATE TABLE IF NOT EXISTS webhooks (
+    id serial NOT NULL,
+    url text NOT NULL,
+    secret varchar(100) NOT NULL,
+    created_at timestamptz DEFAULT now() NOT NULL,
+    CONSTRAINT webhooks_pk PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id serial NOT NULL,
+    name varchar(100) NOT NULL,
+    key_prefix varchar(20) NOT NULL,
+    key_hash char(64) NOT NULL,
+    scopes text[] DEFAULT '{}' NOT NULL,
+    created_at timestamptz DEFAULT now() NOT NULL,
+    revoked_at timestamptz,
+    CONSTRAINT api_keys_pk PRIMARY KEY (id),
+    CONSTRAINT api_keys_key_hash_uq UNIQUE (key_hash)
+);
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS monthly_quota int;
+
+CREATE TABLE IF NOT EXISTS api_key_usage (
+    api_key_id int NOT NULL REFERENCES api_keys (id) ON DELETE CASCADE,
+    period date NOT NULL,
+    requests bigint DEFAULT 0 NOT NULL,
+    csv_rows bigint DEFAULT 0 NOT NULL,
+    CONSTRAINT api_key_usage_pk PRIMARY KEY (api_key_id, period)
+);
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS signing_secret varchar(100);
+
+CREATE TABLE IF NOT EXISTS admin_users (
+    id serial NOT NULL,
+    username varchar(100) NOT NULL,
+    password_hash text NOT NULL,
+    role varchar(20) NOT NULL CHECK (role IN ('viewer', 'editor', 'superadmin')),
+    created_at timestamptz DEFAULT now() NOT NULL,
+    CONSTRAINT admin_users_pk PRIMARY KEY (id),
+    CONSTRAINT admin_users_username_uq UNIQUE (username)
+);
+
+ALTER TABLE admin_users ADD COLUMN IF NOT EXISTS totp_secret bytea;
+ALTER TABLE admin_users ADD COLUMN IF NOT EXISTS totp_enabled boolean DEFAULT false NOT NULL;
+
+CREATE TABLE IF NOT EXISTS admin_sessions (
+    id char(32) NOT NULL,
+    username varchar(100) NOT NULL,
+    role varchar(20) NOT NULL,
+    created_at timestamptz DEFAULT now() NOT NULL,
+    expires_at timestamptz NOT NULL,
+    revoked_at timestamptz,
+    CONSTRAINT admin_sessions_pk PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS setting_bounds (
+    setting varchar(100) NOT NULL,
+    min_amount float8 DEFAULT 0 NOT NULL,
+    max_amount float8 NOT NULL,
+    CONSTRAINT setting_bounds_pk PRIMARY KEY (setting),
+    CONSTRAINT setting_bounds_range_ck CHECK (min_amount <= max_amount)
+);
+
+INSERT INTO setting_bounds (setting,min_amount,max_amount)
+VALUES
+    ('personal',10000.0,100000.0),
+    ('k-receipt',0.0,100000.0),
+    ('donation',0.0,200000.0)
+ON CONFLICT (setting) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS effective_allowances (
+    allowance_kind varchar(20) NOT NULL CHECK (allowance_kind IN ('default', 'allowed')),
+    allowance_type varchar(100) NOT NULL,
+    amount float8 NOT NULL,
+    effective_from date NOT NULL,
+    CONSTRAINT effective_allowances_pk PRIMARY KEY (allowance_kind, allowance_type, effective_from)
+);
+
+CREATE TABLE IF NOT EXISTS scheduled_changes (
+    id serial NOT NULL,
+    allowance_kind varchar(20) NOT NULL CHECK (allowance_kind IN ('default', 'allowed')),
+    allowance_type varchar(100) NOT NULL,
+    amount float8 NOT NULL,
+    activate_at timestamptz NOT NULL,
+    created_at timestamptz DEFAULT now() NOT NULL,
+    applied_at timestamptz,
+    cancelled_at timestamptz,
+    CONSTRAINT scheduled_changes_pk PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS setting_drafts (
+    id serial NOT NULL,
+    allowance_kind varchar(20) NOT NULL CHECK (allowance_kind IN ('default', 'allowed')),
+    allowance_type varchar(100) NOT NULL,
+    amount float8 NOT NULL,
+    created_by varchar(100) NOT NULL,
+    created_at timestamptz DEFAULT now() NOT NULL,
+    published_by varchar(100),
+    published_at timestamptz,
+    discarded_at timestamptz,
+    CONSTRAINT setting_drafts_pk PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS setting_versions (
+    version serial NOT NULL,
+    default_allowances jsonb NOT NULL,
+    allowed_allowances jsonb NOT NULL,
+    created_at timestamptz DEFAULT now() NOT NULL,
+    CONSTRAINT setting_versions_pk PRIMARY KEY (version)
+);
+
+INSERT INTO setting_versions (default_allowances, allowed_allowances)
+SELECT
+    (SELECT COALESCE(jsonb_object_agg(allowance_type, amount), '{}') FROM default_allowances),
+    (SELECT COALESCE(jsonb_object_agg(allowance_type, max_amount), '{}') FROM allowed_allowances)
+WHERE NOT EXISTS (SELECT FROM setting_versions);
+
+CREATE TABLE IF NOT EXISTS tax_brackets (
+    tax_year int NOT NULL,
+    level int NOT NULL,
+    percentage float8 NOT NULL CHECK (percentage >= 0 AND percentage <= 1),
+    max_amount float8,
+    label text NOT NULL,
+    labels jsonb DEFAULT '{}' NOT NULL,
+    CONSTRAINT tax_brackets_pk PRIMARY KEY (tax_year, level)
+);
+
+CREATE TABLE IF NOT EXISTS calculation_history (
+    id bigserial NOT NULL,
+    total_income float8 NOT NULL,
+    wht float8 NOT NULL,
+    allowances jsonb DEFAULT '{}' NOT NULL,
+    tax float8 NOT NULL,
+    tax_refund float8 NOT NULL,
+    calculated_at timestamptz NOT NULL,
+    CONSTRAINT calculation_history_pk PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS calculation_history_calculated_at_idx ON calculation_history (calculated_at);
+
+CREATE TABLE IF NOT EXISTS setting_history (
+    id bigserial NOT NULL,
+    kind varchar(20) NOT NULL CHECK (kind IN ('default', 'allowed', 'bracket')),
+    name varchar(100) NOT NULL,
+    amount float8,
+    percentage float8,
+    valid_from timestamptz NOT NULL,
+    valid_to timestamptz,
+    CONSTRAINT setting_history_pk PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS setting_history_valid_idx ON setting_history (valid_from, valid_to);
+
+INSERT INTO setting_history (kind, name, amount, percentage, valid_from)
+SELECT kind, name, amount, percentage, now() FROM (
+    SELECT 'default' AS kind, allowance_type AS name, amount, NULL::float8 AS percentage FROM default_allowances
+    UNION ALL
+    SELECT 'allowed', allowance_type, max_amount, NULL::float8 FROM allowed_allowances
+    UNION ALL
+    SELECT 'bracket', tax_year || '/' || level, max_amount, percentage FROM tax_brackets
+) current
+WHERE NOT EXISTS (SELECT FROM setting_history);
+
+-- surrogate keys referenced by audit logs and webhooks, updated_at is set by the repository layer on every update
+ALTER TABLE default_allowances ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE default_allowances ADD COLUMN IF NOT EXISTS created_at timestamptz DEFAULT now() NOT NULL;
+ALTER TABLE default_allowances ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS default_allowances_uuid_uq ON default_allowances (uuid);
+
+ALTER TABLE allowed_allowances ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE allowed_allowances ADD COLUMN IF NOT EXISTS created_at timestamptz DEFAULT now() NOT NULL;
+ALTER TABLE allowed_allowances ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS allowed_allowances_uuid_uq ON allowed_allowances (uuid);
+
+ALTER TABLE tax_calendars ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE tax_calendars ADD COLUMN IF NOT EXISTS created_at timestamptz DEFAULT now() NOT NULL;
+ALTER TABLE tax_calendars ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS tax_calendars_uuid_uq ON tax_calendars (uuid);
+
+ALTER TABLE allowance_windows ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE allowance_windows ADD COLUMN IF NOT EXISTS created_at timestamptz DEFAULT now() NOT NULL;
+ALTER TABLE allowance_windows ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS allowance_windows_uuid_uq ON allowance_windows (uuid);
+
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE webhooks ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS webhooks_uuid_uq ON webhooks (uuid);
+
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS api_keys_uuid_uq ON api_keys (uuid);
+
+ALTER TABLE api_key_usage ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE api_key_usage ADD COLUMN IF NOT EXISTS created_at timestamptz DEFAULT now() NOT NULL;
+ALTER TABLE api_key_usage ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS api_key_usage_uuid_uq ON api_key_usage (uuid);
+
+ALTER TABLE admin_users ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE admin_users ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS admin_users_uuid_uq ON admin_users (uuid);
+
+ALTER TABLE admin_sessions ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE admin_sessions ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS admin_sessions_uuid_uq ON admin_sessions (uuid);
+
+ALTER TABLE setting_bounds ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE setting_bounds ADD COLUMN IF NOT EXISTS created_at timestamptz DEFAULT now() NOT NULL;
+ALTER TABLE setting_bounds ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS setting_bounds_uuid_uq ON setting_bounds (uuid);
+
+ALTER TABLE effective_allowances ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE effective_allowances ADD COLUMN IF NOT EXISTS created_at timestamptz DEFAULT now() NOT NULL;
+ALTER TABLE effective_allowances ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS effective_allowances_uuid_uq ON effective_allowances (uuid);
+
+ALTER TABLE scheduled_changes ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE scheduled_changes ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS scheduled_changes_uuid_uq ON scheduled_changes (uuid);
+
+ALTER TABLE setting_drafts ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE setting_drafts ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS setting_drafts_uuid_uq ON setting_drafts (uuid);
+
+ALTER TABLE setting_versions ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE setting_versions ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS setting_versions_uuid_uq ON setting_versions (uuid);
+
+-- brackets of every tax year, versions recorded before brackets were snapshotted leave brackets alone on rollback
+ALTER TABLE setting_versions ADD COLUMN IF NOT EXISTS brackets jsonb;
+
+-- disabled allowance types by kind, versions recorded before they were snapshotted leave disabled types alone on rollback
+ALTER TABLE setting_versions ADD COLUMN IF NOT EXISTS disabled_allowances jsonb;
+
+ALTER TABLE tax_brackets ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE tax_brackets ADD COLUMN IF NOT EXISTS created_at timestamptz DEFAULT now() NOT NULL;
+ALTER TABLE tax_brackets ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS tax_brackets_uuid_uq ON tax_brackets (uuid);
+
+ALTER TABLE calculation_history ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE calculation_history ADD COLUMN IF NOT EXISTS created_at timestamptz DEFAULT now() NOT NULL;
+ALTER TABLE calculation_history ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS calculation_history_uuid_uq ON calculation_history (uuid);
+
+ALTER TABLE setting_history ADD COLUMN IF NOT EXISTS uuid uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE setting_history ADD COLUMN IF NOT EXISTS created_at timestamptz DEFAULT now() NOT NULL;
+ALTER TABLE setting_history ADD COLUMN IF NOT EXISTS updated_at timestamptz DEFAULT now() NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS setting_history_uuid_uq ON setting_history (uuid);
+
+-- allowance types referenced by old calculations are disabled instead of deleted
+ALTER TABLE default_allowances ADD COLUMN IF NOT EXISTS disabled_at timestamptz;
+ALTER TABLE allowed_allowances ADD COLUMN IF NOT EXISTS disabled_at timestamptz;
+
+-- subject of calculations, data-erasure requests delete every calculation of the key
+ALTER TABLE calculation_history ADD COLUMN IF NOT EXISTS api_key_id int;
+CREATE INDEX IF NOT EXISTS calculation_history_api_key_id_idx ON calculation_history (api_key_id);
+
+CREATE TABLE IF NOT EXISTS calculation_deletions (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    api_key_id int NOT NULL,
+    deleted_rows bigint NOT NULL,
+    deleted_at timestamptz DEFAULT now() NOT NULL,
+    CONSTRAINT calculation_deletions_pk PRIMARY KEY (id)
+);
+
+-- amounts of calculations are sealed by the application when encryption is configured,
+-- plain columns are left NULL for those rows
+ALTER TABLE calculation_history ADD COLUMN IF NOT EXISTS sealed_amounts bytea;
+ALTER TABLE calculation_history ADD COLUMN IF NOT EXISTS wrapped_key bytea;
+ALTER TABLE calculation_history ADD COLUMN IF NOT EXISTS key_id varchar(100);
+ALTER TABLE calculation_history ALTER COLUMN total_income DROP NOT NULL;
+ALTER TABLE calculation_history ALTER COLUMN wht DROP NOT NULL;
+ALTER TABLE calculation_history ALTER COLUMN allowances DROP NOT NULL;
+ALTER TABLE calculation_history ALTER COLUMN tax DROP NOT NULL;
+ALTER TABLE calculation_history ALTER COLUMN tax_refund DROP NOT NULL;
+
+-- subsidiaries served by one deployment, keys of a tenant calculate with its overrides of the global settings
+CREATE TABLE IF NOT EXISTS tenants (
+    id serial NOT NULL,
+    name varchar(100) NOT NULL,
+    uuid uuid DEFAULT gen_random_uuid() NOT NULL,
+    created_at timestamptz DEFAULT now() NOT NULL,
+    updated_at timestamptz DEFAULT now() NOT NULL,
+    CONSTRAINT tenants_pk PRIMARY KEY (id),
+    CONSTRAINT tenants_name_uq UNIQUE (name)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS tenants_uuid_uq ON tenants (uuid);
+
+-- a tenant with keys can't be deleted, so its keys never fall back to the global settings silently
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS tenant_id int REFERENCES tenants (id);
+
+CREATE TABLE IF NOT EXISTS tenant_allowances (
+    tenant_id int NOT NULL REFERENCES tenants (id) ON DELETE CASCADE,
+    allowance_kind varchar(20) NOT NULL CHECK (allowance_kind IN ('default', 'allowed')),
+    allowance_type varchar(100) NOT NULL,
+    amount float8 NOT NULL,
+    uuid uuid DEFAULT gen_random_uuid() NOT NULL,
+    created_at timestamptz DEFAULT now() NOT NULL,
+    updated_at timestamptz DEFAULT now() NOT NULL,
+    CONSTRAINT tenant_allowances_pk PRIMARY KEY (tenant_id, allowance_type)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS tenant_allowances_uuid_uq ON tenant_allowances (uuid);
+
+CREATE TABLE IF NOT EXISTS tenant_tax_brackets (
+    tenant_id int NOT NULL REFERENCES tenants (id) ON DELETE CASCADE,
+    tax_year int NOT NULL,
+    level int NOT NULL,
+    percentage float8 NOT NULL CHECK (percentage >= 0 AND percentage <= 1),
+    max_amount float8,
+    label text NOT NULL,
+    labels jsonb DEFAULT '{}' NOT NULL,
+    uuid uuid DEFAULT gen_random_uuid() NOT NULL,
+    created_at timestamptz DEFAULT now() NOT NULL,
+    updated_at timestamptz DEFAULT now() NOT NULL,
+    CONSTRAINT tenant_tax_brackets_pk PRIMARY KEY (tenant_id, tax_year, level)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS tenant_tax_brackets_uuid_uq ON tenant_tax_brackets (uuid);
+
+-- taxpayer accounts, calculations of a signed in taxpayer are kept as their history
+CREATE TABLE IF NOT EXISTS users (
+    id serial NOT NULL,
+    email varchar(254) NOT NULL,
+    password_hash text NOT NULL,
+    uuid uuid DEFAULT gen_random_uuid() NOT NULL,
+    created_at timestamptz DEFAULT now() NOT NULL,
+    updated_at timestamptz DEFAULT now() NOT NULL,
+    CONSTRAINT users_pk PRIMARY KEY (id),
+    CONSTRAINT users_email_uq UNIQUE (email)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_uuid_uq ON users (uuid);
+
+ALTER TABLE calculation_history ADD COLUMN IF NOT EXISTS user_id int;
+CREATE INDEX IF NOT EXISTS calculation_history_user_id_idx ON calculation_history (user_id);
+
+-- erasures are requested either by an api key or by a taxpayer
+ALTER TABLE calculation_deletions ALTER COLUMN api_key_id DROP NOT NULL;
+ALTER TABLE calculation_deletions ADD COLUMN IF NOT EXISTS user_id int;
+
+-- profile of a taxpayer, its allowances are claimed by calculations which don't claim them
+CREATE TABLE IF NOT EXISTS user_profiles (
+    user_id int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    marital_status varchar(20) NOT NULL,
+    children int DEFAULT 0 NOT NULL,
+    allowances jsonb DEFAULT '{}' NOT NULL,
+    created_at timestamptz DEFAULT now() NOT NULL,
+    updated_at timestamptz DEFAULT now() NOT NULL,
+    CONSTRAINT user_profiles_pk PRIMARY KEY (user_id)
+);
+
+-- inputs a taxpayer builds up over time, a finalized draft keeps the tax it was calculated with
+CREATE TABLE IF NOT EXISTS calculation_drafts (
+    id serial NOT NULL,
+    user_id int NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name varchar(100) DEFAULT '' NOT NULL,
+    total_income float8,
+    wht float8,
+    allowances jsonb DEFAULT '{}' NOT NULL,
+    tax float8,
+    tax_refund float8,
+    uuid uuid DEFAULT gen_random_uuid() NOT NULL,
+    created_at timestamptz DEFAULT now() NOT NULL,
+    updated_at timestamptz DEFAULT now() NOT NULL,
+    finalized_at timestamptz,
+    CONSTRAINT calculation_drafts_pk PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS calculation_drafts_user_id_idx ON calculation_drafts (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS calculation_drafts_uuid_uq ON calculation_drafts (uuid);
+
+-- other spellings of allowance types, calculations claim the allowance type of an alias
+CREATE TABLE IF NOT EXISTS allowance_aliases (
+    alias varchar(100) NOT NULL,
+    allowance_type varchar(100) NOT NULL,
+    created_at timestamptz DEFAULT now() NOT NULL,
+    updated_at timestamptz DEFAULT now() NOT NULL,
+    CONSTRAINT allowance_aliases_pk PRIMARY KEY (alias)
+);
+
+-- csv uploads calculated by batch workers, payload is the csv and result is the taxes of a done job
+CREATE TABLE IF NOT EXISTS batch_jobs (
+    id uuid DEFAULT gen_random_uuid() NOT NULL,
+    status varchar(20) NOT NULL,
+    tax_year int NOT NULL,
+    effective_date date NOT NULL,
+    api_key_id int,
+    tenant_id int,
+    user_id int,
+    payload bytea NOT NULL,
+    result jsonb,
+    error_code varchar(100) DEFAULT '' NOT NULL,
+    created_at timestamptz DEFAULT now() NOT NULL,
+    updated_at timestamptz DEFAULT now() NOT NULL,
+    CONSTRAINT batch_jobs_pk PRIMARY KEY (id)
+);
+
+-- csv and result of jobs are sealed by the application when encryption is configured,
+-- plain columns are left NULL for those rows
+ALTER TABLE batch_jobs ADD COLUMN IF NOT EXISTS sealed_payload bytea;
+ALTER TABLE batch_jobs ADD COLUMN IF NOT EXISTS payload_key bytea;
+ALTER TABLE batch_jobs ADD COLUMN IF NOT EXISTS sealed_result bytea;
+ALTER TABLE batch_jobs ADD COLUMN IF NOT EXISTS result_key bytea;
+ALTER TABLE batch_jobs ADD COLUMN IF NOT EXISTS key_id varchar(100);
+ALTER TABLE batch_jobs ALTER COLUMN payload DROP NOT NULL;
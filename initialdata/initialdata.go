@@ -0,0 +1,8 @@
+package initialdata
+
+import _ "embed"
+
+// Schema creates tables and reference data, every statement can run again on an existing database
+//
+//go:embed init.sql
+var Schema string
@@ -2,18 +2,21 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log"
-	"net/http"
+	"log/slog"
 	"os"
 	"os/signal"
-	"strings"
-	"time"
-
-	"github.com/AnnaCarter465/assessment-tax/database"
-	"github.com/AnnaCarter465/assessment-tax/handler"
-	"github.com/go-playground/validator/v10"
-	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
+	"syscall"
+
+	"github.com/AnnaCarter465/assessment-tax/app"
+	"github.com/AnnaCarter465/assessment-tax/calc"
+	"github.com/AnnaCarter465/assessment-tax/config"
+	"github.com/AnnaCarter465/assessment-tax/migrate"
+	"github.com/AnnaCarter465/assessment-tax/pkg/logging"
+	"github.com/AnnaCarter465/assessment-tax/pkg/tracing"
+	"github.com/AnnaCarter465/assessment-tax/seed"
+	"github.com/AnnaCarter465/assessment-tax/smoketest"
 )
 
 type TaxRequest struct {
@@ -38,57 +41,101 @@ type TaxLevel struct {
 	Tax   float64 `json:"tax"`
 }
 
+const usage = `usage: assessment-tax [command] [flags]
+
+commands:
+  serve      run the HTTP server (default)
+  worker     calculate batch jobs of a shared queue
+  migrate    create or update database tables
+  seed       insert reference data
+  calc       calculate tax of one taxpayer or a csv file offline
+  smoketest  check critical paths of a running deployment
+
+run a command with -h to see its flags
+`
+
 func main() {
-	dbURL := os.Getenv("DATABASE_URL")
-	port := os.Getenv("PORT")
+	command, args := "serve", []string{}
+	if len(os.Args) > 1 {
+		command, args = os.Args[1], os.Args[2:]
+	}
 
-	if len(strings.TrimSpace(dbURL)) == 0 {
-		log.Fatal("Missing an env variable `DATABASE_URL`")
+	switch command {
+	case "serve":
+		serve(func(ctx context.Context, a *app.App) error { return a.Run(ctx) })
+	case "worker":
+		serve(func(ctx context.Context, a *app.App) error { return a.RunWorker(ctx) })
+	case "migrate":
+		os.Exit(migrate.Run(args))
+	case "seed":
+		os.Exit(seed.Run(args))
+	case "calc":
+		os.Exit(calc.Run(args))
+	case "smoketest":
+		os.Exit(smoketest.Run(args))
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Printf("unknown command %q\n\n%s", command, usage)
+		os.Exit(2)
 	}
+}
 
-	db, err := database.NewDB(dbURL)
+// serve runs the service with run until interrupted, settings are read by config.Load
+func serve(run func(ctx context.Context, a *app.App) error) {
+	cfg, err := config.Load()
 	if err != nil {
-		log.Fatal("Cannot connection to database", err)
+		log.Fatal("Invalid configuration\n", err)
 	}
 
-	vl := validator.New()
+	logger, err := logging.New(os.Stdout, cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		log.Fatal("Cannot create logger", err)
+	}
 
-	e := echo.New()
+	slog.SetDefault(logger)
 
-	e.GET("/", handler.Healthcheck)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.ServiceName)
+	if err != nil {
+		fatal("cannot set up tracing", "error", err)
+	}
 
-	// user ------------------------------------------------------------------------------
-	u := e.Group("/tax")
-	u.POST("/calculations", handler.NewTaxHandler(vl, db).CalculateTax)
-	u.POST("/calculations/upload-csv", handler.NewTaxHandler(vl, db).CalculateTaxWithCSV)
+	a, err := app.New(app.WithConfig(cfg), app.WithLogger(logger))
+	if err != nil {
+		fatal("cannot create service", "error", err)
+	}
 
-	// admin -----------------------------------------------------------------------------
-	am := e.Group("/admin")
-	am.Use(middleware.BasicAuth(func(username, password string, c echo.Context) (bool, error) {
-		if username == os.Getenv("ADMIN_USERNAME") && password == os.Getenv("ADMIN_PASSWORD") {
-			return true, nil
-		}
-		return false, nil
-	}))
+	// kubernetes sends SIGTERM before killing the pod after its grace period
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	am.POST("/deductions/personal", handler.NewAdminHandler(vl, db).UpdatePesonal)
-	am.POST("/deductions/k-receipt", handler.NewAdminHandler(vl, db).UpdateKReceipt)
+	// limits, timeouts and feature flags are read again on SIGHUP, other settings need a restart
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
 
 	go func() {
-		if err := e.Start(":" + port); err != nil && err != http.ErrServerClosed {
-			e.Logger.Fatal(err)
+		for range hup {
+			if err := a.Reload(); err != nil {
+				slog.Error("cannot reload configuration, previous settings are kept", "error", err)
+			}
 		}
 	}()
-	shutdown := make(chan os.Signal, 1)
-	signal.Notify(shutdown, os.Interrupt)
-	<-shutdown
 
-	log.Println("shutting down the server")
+	if err := run(ctx, a); err != nil {
+		fatal("server stopped with error", "error", err)
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 	defer cancel()
 
-	if err := e.Shutdown(ctx); err != nil {
-		e.Logger.Fatal(err)
+	// flush spans buffered by batcher
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("cannot shut down tracing", "error", err)
 	}
 }
+
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,46 @@
+package migrate
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+)
+
+// Run creates or updates tables of database of `DATABASE_URL` and returns process exit code
+func Run(args []string) int {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+
+	dbURL := fs.String("database-url", os.Getenv("DATABASE_URL"), "database to migrate")
+	timeout := fs.Duration("timeout", 5*time.Minute, "timeout of migration")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *dbURL == "" {
+		fmt.Println("missing database url, set `DATABASE_URL` or -database-url")
+		return 2
+	}
+
+	db, err := database.NewDB(*dbURL, database.Config{ConnectTimeout: *timeout})
+	if err != nil {
+		fmt.Printf("cannot connect to database: %v\n", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	if err := db.Migrate(ctx); err != nil {
+		fmt.Printf("cannot migrate database: %v\n", err)
+		return 1
+	}
+
+	fmt.Println("database is up to date")
+
+	return 0
+}
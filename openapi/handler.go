@@ -0,0 +1,52 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/labstack/echo/v4"
+)
+
+// SpecPath is where the document is served, Swagger UI loads it from there
+const SpecPath = "/swagger/openapi.json"
+
+// swagger-ui-dist is pinned, so the page doesn't change without a change of this file
+const swaggerUIHTML = `<!doctype html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>K-Tax API</title>
+  <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5.17.14/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5.17.14/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "` + SpecPath + `", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+type Handler struct {
+	spec []byte
+}
+
+// NewHandler serves doc, it is encoded once since the document doesn't change while running
+func NewHandler(doc *openapi3.T) (*Handler, error) {
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Handler{spec: spec}, nil
+}
+
+func (h *Handler) Spec(c echo.Context) error {
+	return c.JSONBlob(http.StatusOK, h.spec)
+}
+
+func (h *Handler) UI(c echo.Context) error {
+	return c.HTML(http.StatusOK, swaggerUIHTML)
+}
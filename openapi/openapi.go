@@ -0,0 +1,245 @@
+// Package openapi documents the HTTP API as an OpenAPI 3 document, schemas are generated from types of handler
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/AnnaCarter465/assessment-tax/handler"
+	"github.com/AnnaCarter465/assessment-tax/pkg/buildinfo"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// names of security schemes
+const (
+	apiKeyScheme = "apiKey"
+	basicScheme  = "basicAuth"
+	bearerScheme = "bearerAuth"
+	userScheme   = "userBearerAuth"
+)
+
+// errorSchemaRef refers to body of every error response
+const errorSchemaRef = "#/components/schemas/ResponseMsg"
+
+// protobufMIMEType is media type of protobuf bodies, they are messages of proto/tax/v1
+const protobufMIMEType = "application/x-protobuf"
+
+// integerPathParams are path params parsed as integers by handlers, others are strings
+var integerPathParams = map[string]bool{"id": true, "taxYear": true, "version": true}
+
+// Document builds the document of all routes and validates it
+func Document() (*openapi3.T, error) {
+	errorSchema, err := newErrorSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &openapi3.T{
+		OpenAPI: "3.0.3",
+		Info: &openapi3.Info{
+			Title:       "K-Tax API",
+			Description: "Personal income tax calculation with allowances, and administration of its settings.\n\nEvery route is served under /v1 as well, JSON responses of /v1 routes are wrapped in data, error and meta, where data is the response documented here, error is the error response and meta has requestId, timestamp and version.",
+			Version:     buildinfo.Version,
+		},
+		Paths: openapi3.NewPaths(),
+		Components: &openapi3.Components{
+			Schemas: openapi3.Schemas{"ResponseMsg": openapi3.NewSchemaRef("", errorSchema)},
+			SecuritySchemes: openapi3.SecuritySchemes{
+				apiKeyScheme: {Value: openapi3.NewSecurityScheme().WithType("apiKey").WithIn("header").WithName("X-Api-Key").
+					WithDescription("Required by /tax routes when API_KEY_REQUIRED is set")},
+				basicScheme:  {Value: openapi3.NewSecurityScheme().WithType("http").WithScheme("basic")},
+				bearerScheme: {Value: openapi3.NewJWTSecurityScheme().WithDescription("Token of POST /admin/login")},
+				userScheme: {Value: openapi3.NewJWTSecurityScheme().
+					WithDescription("Token of POST /users/login, optional for /tax routes which then record calculations to the taxpayer")},
+			},
+		},
+	}
+
+	for _, op := range operations {
+		o, err := newOperation(op, errorSchema)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", op.method, op.path, err)
+		}
+
+		path := Path(op.path)
+
+		item := doc.Paths.Value(path)
+		if item == nil {
+			item = &openapi3.PathItem{}
+			doc.Paths.Set(path, item)
+		}
+
+		item.SetOperation(op.method, o)
+	}
+
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// Path converts echo path to OpenAPI path, e.g. /admin/drafts/:id to /admin/drafts/{id}
+func Path(echoPath string) string {
+	segments := strings.Split(echoPath, "/")
+
+	for i, s := range segments {
+		if name, ok := strings.CutPrefix(s, ":"); ok {
+			segments[i] = "{" + name + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+// newErrorSchema returns schema of error responses, errorCode lists every code with its English message
+func newErrorSchema() (*openapi3.Schema, error) {
+	schema, err := newSchema(handler.ResponseMsg{})
+	if err != nil {
+		return nil, err
+	}
+
+	errorCode := openapi3.NewStringSchema()
+
+	var lines []string
+
+	for _, code := range handler.ErrorCodes() {
+		errorCode.Enum = append(errorCode.Enum, string(code))
+		lines = append(lines, fmt.Sprintf("- `%s`: %s", code, handler.ErrorMessage(code)))
+	}
+
+	errorCode.Description = "Stable code of the error, message is translated by Accept-Language\n\n" + strings.Join(lines, "\n")
+	schema.Properties["errorCode"] = openapi3.NewSchemaRef("", errorCode)
+
+	return schema, nil
+}
+
+func newOperation(op operation, errorSchema *openapi3.Schema) (*openapi3.Operation, error) {
+	o := openapi3.NewOperation()
+	o.OperationID = operationID(op)
+	o.Summary = op.summary
+	o.Tags = []string{op.tag}
+
+	if len(op.security) > 0 {
+		security := openapi3.NewSecurityRequirements()
+		for _, scheme := range op.security {
+			security.With(openapi3.NewSecurityRequirement().Authenticate(scheme))
+		}
+
+		o.Security = security
+	}
+
+	for _, p := range op.params {
+		o.AddParameter(p)
+	}
+
+	for _, s := range strings.Split(op.path, "/") {
+		name, ok := strings.CutPrefix(s, ":")
+		if !ok || o.Parameters.GetByInAndName(openapi3.ParameterInPath, name) != nil {
+			continue
+		}
+
+		schema := openapi3.NewStringSchema()
+		if integerPathParams[name] {
+			schema = openapi3.NewIntegerSchema()
+		}
+
+		o.AddParameter(openapi3.NewPathParameter(name).WithSchema(schema))
+	}
+
+	if err := setRequestBody(o, op); err != nil {
+		return nil, err
+	}
+
+	resp := openapi3.NewResponse().WithDescription(http.StatusText(op.status))
+
+	switch {
+	case op.mediaType != "":
+		resp.WithContent(openapi3.NewContentWithSchema(binarySchema(), []string{op.mediaType}))
+	case op.response != nil:
+		schema, err := responseSchema(op.response)
+		if err != nil {
+			return nil, err
+		}
+
+		resp.WithJSONSchema(schema)
+
+		if op.protobuf {
+			resp.Content[protobufMIMEType] = openapi3.NewMediaType().WithSchema(binarySchema())
+		}
+	}
+
+	o.Responses = openapi3.NewResponses()
+	o.AddResponse(op.status, resp)
+	o.Responses.Set("default", &openapi3.ResponseRef{Value: openapi3.NewResponse().
+		WithDescription("Error, errorCode tells which one").
+		WithJSONSchemaRef(openapi3.NewSchemaRef(errorSchemaRef, errorSchema))})
+
+	return o, nil
+}
+
+func setRequestBody(o *openapi3.Operation, op operation) error {
+	if op.request == nil && !op.csvBody {
+		return nil
+	}
+
+	content := openapi3.NewContent()
+
+	if op.request != nil {
+		schema, err := newSchema(op.request)
+		if err != nil {
+			return err
+		}
+
+		content["application/json"] = openapi3.NewMediaType().WithSchema(schema)
+	}
+
+	if op.csvBody {
+		content["text/csv"] = openapi3.NewMediaType().WithSchema(openapi3.NewStringSchema())
+	}
+
+	if op.protobuf && op.request != nil {
+		content[protobufMIMEType] = openapi3.NewMediaType().WithSchema(binarySchema())
+	}
+
+	o.RequestBody = &openapi3.RequestBodyRef{Value: openapi3.NewRequestBody().WithRequired(true).WithContent(content)}
+
+	return nil
+}
+
+func responseSchema(v any) (*openapi3.Schema, error) {
+	alternatives, ok := v.(oneOf)
+	if !ok {
+		return newSchema(v)
+	}
+
+	var schemas []*openapi3.Schema
+
+	for _, a := range alternatives {
+		schema, err := newSchema(a)
+		if err != nil {
+			return nil, err
+		}
+
+		schemas = append(schemas, schema)
+	}
+
+	return openapi3.NewOneOfSchema(schemas...), nil
+}
+
+func binarySchema() *openapi3.Schema {
+	return openapi3.NewStringSchema().WithFormat("binary")
+}
+
+// operationID returns unique id of operation from method and path, e.g. postAdminDraftsIdPreview
+func operationID(op operation) string {
+	id := strings.ToLower(op.method)
+
+	for _, s := range strings.FieldsFunc(op.path, func(r rune) bool { return r == '/' || r == ':' || r == '-' }) {
+		id += strings.ToUpper(s[:1]) + s[1:]
+	}
+
+	return id
+}
@@ -0,0 +1,90 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strconv"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/handler"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocument(t *testing.T) {
+	doc, err := Document()
+	assert.NoError(t, err)
+
+	op := doc.Paths.Value("/admin/drafts/{id}/preview").Post
+	assert.NotNil(t, op)
+	assert.Equal(t, "postAdminDraftsIdPreview", op.OperationID)
+	assert.NotNil(t, op.Parameters.GetByInAndName("path", "id"))
+
+	errorCode := doc.Components.Schemas["ResponseMsg"].Value.Properties["errorCode"].Value
+	assert.Contains(t, errorCode.Enum, string(errcode.WhtExceedsIncome))
+	assert.Len(t, errorCode.Enum, len(handler.ErrorCodes()))
+}
+
+func TestNewSchema(t *testing.T) {
+	type TC struct {
+		v        any
+		property string
+		check    func(t *testing.T, required []string, s map[string]any)
+	}
+
+	tcs := []TC{
+		{
+			v:        handler.TaxRequest{},
+			property: "totalIncome",
+			check: func(t *testing.T, required []string, s map[string]any) {
+				assert.ElementsMatch(t, []string{"totalIncome", "allowances"}, required)
+				assert.Equal(t, 0.0, s["minimum"])
+			},
+		},
+		{
+			v:        handler.AdminTaxRequest{},
+			property: "amount",
+			check: func(t *testing.T, _ []string, s map[string]any) {
+				assert.Equal(t, true, s["exclusiveMinimum"])
+			},
+		},
+		{
+			v:        handler.APIKeyRequest{},
+			property: "scopes",
+			check: func(t *testing.T, _ []string, s map[string]any) {
+				assert.Equal(t, 1.0, s["minItems"])
+				assert.Equal(t, []any{"calculate", "upload-csv", "config:read"}, s["items"].(map[string]any)["enum"])
+			},
+		},
+		{
+			v:        handler.TOTPVerifyRequest{},
+			property: "code",
+			check: func(t *testing.T, _ []string, s map[string]any) {
+				assert.Equal(t, 6.0, s["minLength"])
+				assert.Equal(t, 6.0, s["maxLength"])
+				assert.Equal(t, "^[0-9]+$", s["pattern"])
+			},
+		},
+		{
+			v:        handler.CalendarRequest{},
+			property: "filingDeadline",
+			check: func(t *testing.T, _ []string, s map[string]any) {
+				assert.Equal(t, "date", s["format"])
+			},
+		},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			schema, err := newSchema(tc.v)
+			assert.NoError(t, err)
+
+			property, err := schema.Properties[tc.property].Value.MarshalJSON()
+			assert.NoError(t, err)
+
+			var got map[string]any
+			assert.NoError(t, json.Unmarshal(property, &got))
+
+			tc.check(t, schema.Required, got)
+		})
+	}
+}
@@ -0,0 +1,289 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/AnnaCarter465/assessment-tax/handler"
+	taxv1 "github.com/AnnaCarter465/assessment-tax/proto/tax/v1"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// security requirements of operations, public calculations use api keys when API_KEY_REQUIRED is set
+var (
+	publicSecurity = []string{apiKeyScheme}
+	adminSecurity  = []string{basicScheme, bearerScheme}
+	userSecurity   = []string{userScheme}
+)
+
+// operation documents one route, path uses echo syntax so it can be compared with routes of the server
+type operation struct {
+	method   string
+	path     string
+	tag      string
+	summary  string
+	security []string
+	params   []*openapi3.Parameter
+
+	// request is json body, csv bodies are documented by csvBody
+	request any
+	csvBody bool
+	// protobuf is set when request and response can be messages of proto/tax/v1 instead of json
+	protobuf bool
+
+	status int
+	// response is json body, nil when there is no body; oneOf lists alternatives
+	response  any
+	mediaType string
+}
+
+// oneOf is a response which has one of several shapes, e.g. results of a dry run
+type oneOf []any
+
+var (
+	taxYearParam = queryParam("taxYear", "Tax year of brackets in Gregorian (2024) or Buddhist era (2567), default is 2024", openapi3.NewIntegerSchema())
+	dateParam    = queryParam("date", "Date of allowances in YYYY-MM-DD, default is the last day of taxYear or today",
+		openapi3.NewStringSchema().WithFormat("date"))
+	dryRunParam           = queryParam("dryRun", "Projects taxes of the change without applying it", openapi3.NewBoolSchema())
+	allowanceSavingsParam = queryParam("allowanceSavings",
+		"Also reports tax without each claimed allowance, JSON responses only", openapi3.NewBoolSchema())
+	detailParam = queryParam("detail", "summary leaves out taxLevel, default is full",
+		openapi3.NewStringSchema().WithEnum(handler.DetailSummary, handler.DetailFull))
+	precisionParam = queryParam("precision", "Rounding of amounts, satang drops fractions of a satang, default is OUTPUT_PRECISION",
+		openapi3.NewStringSchema().WithEnum(handler.PrecisionRaw, handler.PrecisionBaht, handler.PrecisionDecimal, handler.PrecisionSatang))
+
+	languageHeader  = headerParam("Accept-Language", "Language of messages, th or en")
+	signatureHeader = headerParam("X-Signature",
+		"sha256=<hex> HMAC of timestamp and body, required when the api key has a signing secret")
+	signatureTimestampHeader = headerParam("X-Signature-Timestamp", "Unix time the request was signed")
+	ifMatchHeader            = headerParam("If-Match", "Settings version of ETag, the write fails with 409 when settings changed after it")
+
+	// sessions are identified by random strings rather than sequential ids
+	sessionIDParam = openapi3.NewPathParameter("id").WithSchema(openapi3.NewStringSchema())
+)
+
+func queryParam(name, description string, schema *openapi3.Schema) *openapi3.Parameter {
+	return openapi3.NewQueryParameter(name).WithDescription(description).WithSchema(schema)
+}
+
+func headerParam(name, description string) *openapi3.Parameter {
+	return openapi3.NewHeaderParameter(name).WithDescription(description).WithSchema(openapi3.NewStringSchema())
+}
+
+// operations lists every route of routes.go, except /metrics and the document itself
+var operations = []operation{
+	{method: http.MethodGet, path: "/", tag: "health", summary: "Healthcheck",
+		status: http.StatusOK, response: handler.ResponseMsg{}},
+	{method: http.MethodGet, path: "/version", tag: "health", summary: "Version of the running build",
+		status: http.StatusOK, response: handler.VersionResponse{}},
+
+	{method: http.MethodGet, path: "/tax/deductions", tag: "tax", summary: "Allowances which can be claimed",
+		security: publicSecurity, params: []*openapi3.Parameter{languageHeader},
+		status: http.StatusOK, response: handler.DeductionsResponse{}},
+	{method: http.MethodGet, path: "/tax/brackets", tag: "tax", summary: "Tax brackets of a tax year",
+		security: publicSecurity, params: []*openapi3.Parameter{taxYearParam, languageHeader},
+		status: http.StatusOK, response: handler.BracketsResponse{}},
+	{method: http.MethodPost, path: "/tax/calculations", tag: "tax", summary: "Calculate tax",
+		security: publicSecurity,
+		params: []*openapi3.Parameter{taxYearParam, dateParam, allowanceSavingsParam, detailParam, precisionParam, languageHeader,
+			signatureHeader, signatureTimestampHeader},
+		request: handler.TaxRequest{}, protobuf: true, status: http.StatusOK, response: handler.TaxResponse{}},
+	{method: http.MethodPost, path: "/tax/calculations/upload-csv", tag: "tax", summary: "Calculate tax of every row of a CSV file",
+		security: publicSecurity,
+		params:   []*openapi3.Parameter{taxYearParam, dateParam, precisionParam, signatureHeader, signatureTimestampHeader},
+		csvBody:  true, protobuf: true, status: http.StatusOK, response: handler.TaxCSVResponse{}},
+	{method: http.MethodPost, path: "/tax/calculations/jobs", tag: "tax", summary: "Queue a CSV file to be calculated by batch workers",
+		security: publicSecurity, params: []*openapi3.Parameter{taxYearParam, dateParam, signatureHeader, signatureTimestampHeader},
+		csvBody: true, status: http.StatusAccepted, response: handler.BatchJobResponse{}},
+	{method: http.MethodGet, path: "/tax/calculations/jobs/:id", tag: "tax", summary: "Status of a batch job, with its taxes once it's done",
+		security: publicSecurity, params: []*openapi3.Parameter{precisionParam},
+		status: http.StatusOK, response: handler.BatchJobResponse{}},
+	{method: http.MethodPost, path: "/tax/gateway/calculations", tag: "gateway", summary: "Calculate tax like CalculateTax of the gRPC API",
+		security: publicSecurity, params: []*openapi3.Parameter{signatureHeader, signatureTimestampHeader},
+		request: taxv1.CalculateTaxRequest{}, status: http.StatusOK, response: taxv1.CalculateTaxResponse{}},
+	{method: http.MethodGet, path: "/tax/gateway/deductions", tag: "gateway", summary: "Deductions like GetDeductions of the gRPC API",
+		security: publicSecurity, status: http.StatusOK, response: taxv1.GetDeductionsResponse{}},
+	{method: http.MethodPost, path: "/tax/projections", tag: "tax", summary: "Project year-end tax from year-to-date payroll data",
+		security: publicSecurity, params: []*openapi3.Parameter{taxYearParam, dateParam, detailParam, precisionParam, languageHeader},
+		request: handler.ProjectionRequest{}, status: http.StatusOK, response: handler.ProjectionResponse{}},
+	{method: http.MethodPost, path: "/tax/withholdings", tag: "tax", summary: "Wht of this month's salary by the cumulative method",
+		security: publicSecurity, params: []*openapi3.Parameter{taxYearParam, dateParam, precisionParam},
+		request: handler.WithholdingRequest{}, status: http.StatusOK, response: handler.WithholdingResponse{}},
+	{method: http.MethodPost, path: "/tax/corporate/calculations", tag: "tax", summary: "Calculate corporate income tax of a company's net profit",
+		security: publicSecurity, params: []*openapi3.Parameter{detailParam, precisionParam, languageHeader},
+		request: handler.CorporateTaxRequest{}, status: http.StatusOK, response: handler.CorporateTaxResponse{}},
+	{method: http.MethodPost, path: "/tax/payment-withholdings", tag: "tax", summary: "Wht of service, rent and fee payments with its certificate, filed with PND 3 or PND 53",
+		security: publicSecurity, request: handler.PaymentWhtRequest{}, status: http.StatusOK, response: handler.WhtCertificateResponse{}},
+	{method: http.MethodPost, path: "/tax/penalties", tag: "tax", summary: "Surcharge of tax paid after the filing deadline of the tax calendar",
+		security: publicSecurity, params: []*openapi3.Parameter{taxYearParam},
+		request: handler.PenaltyRequest{}, status: http.StatusOK, response: handler.PenaltyResponse{}},
+	{method: http.MethodPost, path: "/vat/calculations", tag: "vat", summary: "Calculate VAT of an invoice at VAT_RATE",
+		security: publicSecurity, request: handler.VATRequest{}, status: http.StatusOK, response: handler.VATResponse{}},
+	{method: http.MethodGet, path: "/tax/calculations/history", tag: "tax", summary: "Latest calculations of the taxpayer",
+		security: userSecurity, status: http.StatusOK, response: []handler.CalculationResponse{}},
+	{method: http.MethodGet, path: "/tax/drafts", tag: "tax", summary: "Calculation drafts of the taxpayer",
+		security: userSecurity, status: http.StatusOK, response: []handler.CalculationDraftResponse{}},
+	{method: http.MethodPost, path: "/tax/drafts", tag: "tax", summary: "Save inputs known so far as a calculation draft",
+		security: userSecurity, request: handler.CalculationDraftRequest{},
+		status: http.StatusCreated, response: handler.CalculationDraftResponse{}},
+	{method: http.MethodPut, path: "/tax/drafts/:id", tag: "tax", summary: "Replace inputs of a pending calculation draft",
+		security: userSecurity, request: handler.CalculationDraftRequest{},
+		status: http.StatusOK, response: handler.CalculationDraftResponse{}},
+	{method: http.MethodPost, path: "/tax/drafts/:id/finalize", tag: "tax", summary: "Calculate tax of a complete calculation draft",
+		security: userSecurity, params: []*openapi3.Parameter{taxYearParam, dateParam, languageHeader},
+		status: http.StatusOK, response: handler.CalculationDraftResponse{}},
+	{method: http.MethodDelete, path: "/tax/calculations/history", tag: "tax", summary: "Erase calculation history and batch jobs of the taxpayer or the api key",
+		security: []string{userScheme, apiKeyScheme}, status: http.StatusOK, response: handler.DeletionReceiptResponse{}},
+
+	{method: http.MethodPost, path: "/users", tag: "users", summary: "Register a taxpayer account",
+		request: handler.AccountRequest{}, status: http.StatusCreated, response: handler.AccountResponse{}},
+	{method: http.MethodPost, path: "/users/login", tag: "users", summary: "Log in as a taxpayer and get a bearer token",
+		request: handler.AccountLoginRequest{}, status: http.StatusOK, response: handler.LoginResponse{}},
+	{method: http.MethodGet, path: "/users/me", tag: "users", summary: "Account of the taxpayer",
+		security: userSecurity, status: http.StatusOK, response: handler.AccountResponse{}},
+	{method: http.MethodGet, path: "/users/me/profile", tag: "users", summary: "Profile merged into calculations of the taxpayer",
+		security: userSecurity, status: http.StatusOK, response: handler.ProfileResponse{}},
+	{method: http.MethodPut, path: "/users/me/profile", tag: "users", summary: "Create or replace profile of the taxpayer",
+		security: userSecurity, request: handler.ProfileRequest{}, status: http.StatusOK, response: handler.ProfileResponse{}},
+	{method: http.MethodDelete, path: "/users/me/profile", tag: "users", summary: "Delete profile of the taxpayer",
+		security: userSecurity, status: http.StatusNoContent},
+
+	{method: http.MethodPost, path: "/admin/login", tag: "auth", summary: "Log in and get a bearer token",
+		request: handler.LoginRequest{}, status: http.StatusOK, response: handler.LoginResponse{}},
+
+	{method: http.MethodGet, path: "/admin/maintenance", tag: "maintenance", summary: "Whether maintenance mode is on",
+		security: adminSecurity, status: http.StatusOK, response: handler.MaintenanceResponse{}},
+	{method: http.MethodPut, path: "/admin/maintenance", tag: "maintenance", summary: "Turn maintenance mode on or off",
+		security: adminSecurity, request: handler.MaintenanceRequest{},
+		status: http.StatusOK, response: handler.MaintenanceResponse{}},
+	{method: http.MethodPost, path: "/admin/config/reload", tag: "maintenance", summary: "Reload settings from the environment, on every replica with SETTINGS_PUBSUB",
+		security: adminSecurity, status: http.StatusNoContent},
+
+	{method: http.MethodGet, path: "/admin/deductions", tag: "deductions", summary: "Current deductions",
+		security: adminSecurity, protobuf: true, status: http.StatusOK, response: handler.AdminDeductionsResponse{}},
+	{method: http.MethodPost, path: "/admin/deductions/personal", tag: "deductions", summary: "Set personal deduction",
+		security: adminSecurity, params: []*openapi3.Parameter{dryRunParam, ifMatchHeader}, request: handler.AdminTaxRequest{}, protobuf: true,
+		status: http.StatusOK, response: oneOf{map[string]float64{}, handler.DryRunResponse{}}},
+	{method: http.MethodPost, path: "/admin/deductions/k-receipt", tag: "deductions", summary: "Set maximum k-receipt",
+		security: adminSecurity, params: []*openapi3.Parameter{dryRunParam, ifMatchHeader}, request: handler.AdminTaxRequest{}, protobuf: true,
+		status: http.StatusOK, response: oneOf{map[string]float64{}, handler.DryRunResponse{}}},
+	{method: http.MethodPost, path: "/admin/deductions/donation", tag: "deductions", summary: "Set maximum donation",
+		security: adminSecurity, params: []*openapi3.Parameter{dryRunParam, ifMatchHeader}, request: handler.AdminTaxRequest{}, protobuf: true,
+		status: http.StatusOK, response: oneOf{map[string]float64{}, handler.DryRunResponse{}}},
+	{method: http.MethodDelete, path: "/admin/deductions/:allowanceType", tag: "deductions", summary: "Disable an allowance type",
+		security: adminSecurity, params: []*openapi3.Parameter{ifMatchHeader}, status: http.StatusNoContent},
+	{method: http.MethodGet, path: "/admin/deductions/effective", tag: "deductions", summary: "Effective-dated settings",
+		security: adminSecurity, status: http.StatusOK, response: []handler.EffectiveAllowanceResponse{}},
+	{method: http.MethodPost, path: "/admin/deductions/effective", tag: "deductions", summary: "Publish an effective-dated setting",
+		security: adminSecurity, params: []*openapi3.Parameter{ifMatchHeader}, request: handler.AdminEffectiveAllowanceRequest{},
+		status: http.StatusOK, response: handler.EffectiveAllowanceResponse{}},
+
+	{method: http.MethodGet, path: "/admin/drafts", tag: "drafts", summary: "Pending drafts",
+		security: adminSecurity, status: http.StatusOK, response: []handler.DraftResponse{}},
+	{method: http.MethodPost, path: "/admin/drafts", tag: "drafts", summary: "Create a draft of a setting",
+		security: adminSecurity, request: handler.DraftRequest{}, status: http.StatusCreated, response: handler.DraftResponse{}},
+	{method: http.MethodPost, path: "/admin/drafts/:id/preview", tag: "drafts", summary: "Calculate tax as if the draft was published",
+		security: adminSecurity, params: []*openapi3.Parameter{taxYearParam, languageHeader}, request: handler.TaxRequest{},
+		status: http.StatusOK, response: handler.TaxResponse{}},
+	{method: http.MethodPost, path: "/admin/drafts/:id/publish", tag: "drafts", summary: "Publish a draft",
+		security: adminSecurity, params: []*openapi3.Parameter{ifMatchHeader}, status: http.StatusOK, response: handler.DraftResponse{}},
+	{method: http.MethodDelete, path: "/admin/drafts/:id", tag: "drafts", summary: "Discard a draft",
+		security: adminSecurity, status: http.StatusNoContent},
+
+	{method: http.MethodGet, path: "/admin/settings/versions", tag: "settings", summary: "Versions of settings",
+		security: adminSecurity, status: http.StatusOK, response: []handler.SettingVersionResponse{}},
+	{method: http.MethodGet, path: "/admin/settings/history", tag: "settings", summary: "Value of every setting at a time",
+		security: adminSecurity,
+		params:   []*openapi3.Parameter{queryParam("at", "Time in RFC 3339, default is now", openapi3.NewDateTimeSchema())},
+		status:   http.StatusOK, response: []handler.SettingHistoryResponse{}},
+	{method: http.MethodPost, path: "/admin/settings/rollback/:version", tag: "settings", summary: "Restore settings of a version",
+		security: adminSecurity, params: []*openapi3.Parameter{ifMatchHeader},
+		status: http.StatusOK, response: handler.SettingVersionResponse{}},
+	{method: http.MethodPost, path: "/admin/settings/import", tag: "settings", summary: "Import all settings of a JSON or CSV file",
+		security: adminSecurity, params: []*openapi3.Parameter{ifMatchHeader}, request: handler.SettingsImportRequest{}, csvBody: true,
+		status: http.StatusOK, response: handler.SettingVersionResponse{}},
+
+	{method: http.MethodGet, path: "/admin/scheduled-changes", tag: "scheduled-changes", summary: "Pending scheduled changes",
+		security: adminSecurity, status: http.StatusOK, response: []handler.ScheduledChangeResponse{}},
+	{method: http.MethodPost, path: "/admin/scheduled-changes", tag: "scheduled-changes", summary: "Schedule a change of a setting",
+		security: adminSecurity, params: []*openapi3.Parameter{ifMatchHeader}, request: handler.ScheduledChangeRequest{},
+		status: http.StatusCreated, response: handler.ScheduledChangeResponse{}},
+	{method: http.MethodDelete, path: "/admin/scheduled-changes/:id", tag: "scheduled-changes", summary: "Cancel a scheduled change",
+		security: adminSecurity, params: []*openapi3.Parameter{ifMatchHeader}, status: http.StatusNoContent},
+
+	{method: http.MethodGet, path: "/admin/calendar", tag: "calendar", summary: "Tax calendars",
+		security: adminSecurity, status: http.StatusOK, response: []handler.CalendarResponse{}},
+	{method: http.MethodPut, path: "/admin/calendar/:taxYear", tag: "calendar", summary: "Create or replace calendar of a tax year",
+		security: adminSecurity, params: []*openapi3.Parameter{ifMatchHeader}, request: handler.CalendarRequest{},
+		status: http.StatusOK, response: handler.CalendarResponse{}},
+	{method: http.MethodDelete, path: "/admin/calendar/:taxYear", tag: "calendar", summary: "Delete calendar of a tax year",
+		security: adminSecurity, params: []*openapi3.Parameter{ifMatchHeader}, status: http.StatusNoContent},
+
+	{method: http.MethodGet, path: "/admin/allowance-aliases", tag: "deductions", summary: "Aliases of allowance types",
+		security: adminSecurity, status: http.StatusOK, response: []handler.AllowanceAliasResponse{}},
+	{method: http.MethodPut, path: "/admin/allowance-aliases/:alias", tag: "deductions", summary: "Create or replace an alias of an allowance type",
+		security: adminSecurity, request: handler.AllowanceAliasRequest{}, status: http.StatusOK, response: handler.AllowanceAliasResponse{}},
+	{method: http.MethodDelete, path: "/admin/allowance-aliases/:alias", tag: "deductions", summary: "Delete an alias of an allowance type",
+		security: adminSecurity, status: http.StatusNoContent},
+
+	{method: http.MethodGet, path: "/admin/webhooks", tag: "webhooks", summary: "Registered webhooks",
+		security: adminSecurity, status: http.StatusOK, response: []handler.WebhookResponse{}},
+	{method: http.MethodPost, path: "/admin/webhooks", tag: "webhooks", summary: "Register a webhook for settings changes",
+		security: adminSecurity, request: handler.WebhookRequest{}, status: http.StatusCreated, response: handler.WebhookResponse{}},
+	{method: http.MethodDelete, path: "/admin/webhooks/:id", tag: "webhooks", summary: "Delete a webhook",
+		security: adminSecurity, status: http.StatusNoContent},
+
+	{method: http.MethodGet, path: "/admin/api-keys", tag: "api-keys", summary: "API keys",
+		security: adminSecurity, status: http.StatusOK, response: []handler.APIKeyResponse{}},
+	{method: http.MethodPost, path: "/admin/api-keys", tag: "api-keys", summary: "Create an api key, the key is shown only once",
+		security: adminSecurity, request: handler.APIKeyRequest{}, status: http.StatusCreated, response: handler.APIKeyResponse{}},
+	{method: http.MethodPut, path: "/admin/api-keys/:id/scopes", tag: "api-keys", summary: "Replace scopes of an api key",
+		security: adminSecurity, request: handler.APIKeyScopesRequest{}, status: http.StatusOK, response: handler.APIKeyResponse{}},
+	{method: http.MethodPut, path: "/admin/api-keys/:id/quota", tag: "api-keys", summary: "Set monthly quota of an api key",
+		security: adminSecurity, request: handler.APIKeyQuotaRequest{}, status: http.StatusOK, response: handler.APIKeyResponse{}},
+	{method: http.MethodPut, path: "/admin/api-keys/:id/signing-secret", tag: "api-keys",
+		summary:  "Require signed requests, the secret is shown only once",
+		security: adminSecurity, status: http.StatusOK, response: handler.APIKeyResponse{}},
+	{method: http.MethodDelete, path: "/admin/api-keys/:id/signing-secret", tag: "api-keys", summary: "Stop requiring signed requests",
+		security: adminSecurity, status: http.StatusOK, response: handler.APIKeyResponse{}},
+	{method: http.MethodDelete, path: "/admin/api-keys/:id", tag: "api-keys", summary: "Revoke an api key",
+		security: adminSecurity, status: http.StatusNoContent},
+	{method: http.MethodPut, path: "/admin/api-keys/:id/tenant", tag: "api-keys", summary: "Assign an api key to a tenant",
+		security: adminSecurity, request: handler.APIKeyTenantRequest{}, status: http.StatusOK, response: handler.APIKeyResponse{}},
+
+	{method: http.MethodGet, path: "/admin/tenants", tag: "tenants", summary: "Tenants",
+		security: adminSecurity, status: http.StatusOK, response: []handler.TenantResponse{}},
+	{method: http.MethodPost, path: "/admin/tenants", tag: "tenants", summary: "Create a tenant",
+		security: adminSecurity, request: handler.TenantRequest{}, status: http.StatusCreated, response: handler.TenantResponse{}},
+	{method: http.MethodDelete, path: "/admin/tenants/:id", tag: "tenants", summary: "Delete a tenant without api keys",
+		security: adminSecurity, status: http.StatusNoContent},
+	{method: http.MethodGet, path: "/admin/tenants/:id/settings", tag: "tenants", summary: "Overrides of the global settings for a tenant",
+		security: adminSecurity, status: http.StatusOK, response: handler.SettingsImportRequest{}},
+	{method: http.MethodPut, path: "/admin/tenants/:id/settings", tag: "tenants", summary: "Replace every override of a tenant",
+		security: adminSecurity, request: handler.SettingsImportRequest{}, status: http.StatusOK, response: handler.SettingsImportRequest{}},
+
+	{method: http.MethodGet, path: "/admin/usage", tag: "api-keys", summary: "Usage of every api key in a month",
+		security: adminSecurity,
+		params:   []*openapi3.Parameter{queryParam("month", "Month in YYYY-MM, default is the current month", openapi3.NewStringSchema())},
+		status:   http.StatusOK, response: []handler.UsageResponse{}},
+
+	{method: http.MethodPost, path: "/admin/totp", tag: "auth", summary: "Start two-factor enrollment",
+		security: adminSecurity, status: http.StatusCreated, response: handler.TOTPEnrollResponse{}},
+	{method: http.MethodGet, path: "/admin/totp/qr", tag: "auth", summary: "QR code of the pending enrollment",
+		security: adminSecurity, status: http.StatusOK, mediaType: "image/png"},
+	{method: http.MethodPost, path: "/admin/totp/verify", tag: "auth", summary: "Enable two-factor authentication",
+		security: adminSecurity, request: handler.TOTPVerifyRequest{}, status: http.StatusNoContent},
+
+	{method: http.MethodGet, path: "/admin/sessions", tag: "auth", summary: "Active sessions",
+		security: adminSecurity, status: http.StatusOK, response: []handler.SessionResponse{}},
+	{method: http.MethodDelete, path: "/admin/sessions/:id", tag: "auth", summary: "Revoke a session, admins can revoke their own",
+		security: adminSecurity, params: []*openapi3.Parameter{sessionIDParam}, status: http.StatusNoContent},
+
+	{method: http.MethodGet, path: "/admin/users", tag: "users", summary: "Admin users",
+		security: adminSecurity, status: http.StatusOK, response: []handler.AdminUserResponse{}},
+	{method: http.MethodPost, path: "/admin/users", tag: "users", summary: "Create an admin user",
+		security: adminSecurity, request: handler.AdminUserRequest{}, status: http.StatusCreated, response: handler.AdminUserResponse{}},
+	{method: http.MethodPut, path: "/admin/users/:id/role", tag: "users", summary: "Change role of an admin user",
+		security: adminSecurity, request: handler.AdminUserRoleRequest{}, status: http.StatusOK, response: handler.AdminUserResponse{}},
+	{method: http.MethodDelete, path: "/admin/users/:id", tag: "users", summary: "Delete an admin user",
+		security: adminSecurity, status: http.StatusNoContent},
+}
@@ -0,0 +1,148 @@
+package openapi
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3gen"
+)
+
+// newSchema generates schema of v from its json tags, constraints are taken from validate tags
+// so the document can't drift from what handlers accept
+func newSchema(v any) (*openapi3.Schema, error) {
+	ref, err := openapi3gen.NewSchemaRefForValue(v, nil, openapi3gen.SchemaCustomizer(applyValidateTag))
+	if err != nil {
+		return nil, err
+	}
+
+	return ref.Value, nil
+}
+
+// applyValidateTag is called for every type of the generated schema, tag is the tag of the field holding it,
+// elements of a slice are called with the tag of the slice field
+func applyValidateTag(_ string, t reflect.Type, tag reflect.StructTag, schema *openapi3.Schema) error {
+	if t.Kind() == reflect.Struct {
+		schema.Required = requiredFields(t)
+	}
+
+	for _, rule := range validateRules(t, tag) {
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "gt", "gte":
+			if n, err := strconv.ParseFloat(param, 64); err == nil {
+				schema.Min = &n
+				schema.ExclusiveMin = name == "gt"
+			}
+		case "lt", "lte":
+			if n, err := strconv.ParseFloat(param, 64); err == nil {
+				schema.Max = &n
+				schema.ExclusiveMax = name == "lt"
+			}
+		case "min", "max", "len":
+			applyLength(t, schema, name, param)
+		case "oneof":
+			for _, v := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, v)
+			}
+		case "url":
+			schema.Format = "uri"
+		case "numeric":
+			schema.Pattern = "^[0-9]+$"
+		case "datetime":
+			if param == "2006-01-02" {
+				schema.Format = "date"
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateRules returns rules of tag which apply to t, rules before dive apply to the slice
+// and rules after it to its elements
+func validateRules(t reflect.Type, tag reflect.StructTag) []string {
+	v, ok := tag.Lookup("validate")
+	if !ok || v == "" {
+		return nil
+	}
+
+	rules := strings.Split(v, ",")
+
+	dive := slices.Index(rules, "dive")
+	if dive < 0 {
+		return rules
+	}
+
+	if t.Kind() == reflect.Slice {
+		return rules[:dive]
+	}
+
+	return rules[dive+1:]
+}
+
+// requiredFields returns json names of fields of struct t which are validated as required
+func requiredFields(t reflect.Type) []string {
+	var required []string
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		rules := strings.Split(f.Tag.Get("validate"), ",")
+		if dive := slices.Index(rules, "dive"); dive >= 0 {
+			rules = rules[:dive]
+		}
+
+		if slices.Contains(rules, "required") {
+			required = append(required, name)
+		}
+	}
+
+	return required
+}
+
+// applyLength applies min, max or len of validator, they limit length of strings and slices,
+// or value of numbers
+func applyLength(t reflect.Type, schema *openapi3.Schema, name, param string) {
+	n, err := strconv.ParseUint(param, 10, 64)
+	if err != nil {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		if name != "max" {
+			schema.MinLength = n
+		}
+
+		if name != "min" {
+			schema.MaxLength = &n
+		}
+	case reflect.Slice:
+		if name != "max" {
+			schema.MinItems = n
+		}
+
+		if name != "min" {
+			schema.MaxItems = &n
+		}
+	default:
+		f := float64(n)
+
+		if name != "max" {
+			schema.Min = &f
+		}
+
+		if name != "min" {
+			schema.Max = &f
+		}
+	}
+}
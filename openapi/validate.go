@@ -0,0 +1,80 @@
+package openapi
+
+import (
+	"errors"
+	"mime"
+	"strings"
+
+	"github.com/AnnaCarter465/assessment-tax/handler"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/labstack/echo/v4"
+)
+
+// ValidateRequest rejects json bodies which don't match the operation of doc with 400 listing every invalid field,
+// so validation can't drift from the document. Other media types, e.g. csv and protobuf, are checked by handlers
+func ValidateRequest(doc *openapi3.T) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			op := findOperation(doc, c)
+			if op == nil || op.RequestBody == nil || !hasJSONBody(c) {
+				return next(c)
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request: c.Request(),
+				Options: &openapi3filter.Options{MultiError: true},
+			}
+
+			// the body is read and put back, so handlers can bind it again
+			if err := openapi3filter.ValidateRequestBody(c.Request().Context(), input, op.RequestBody.Value); err != nil {
+				return handler.RespondSchemaViolation(c, fieldErrors(err))
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// findOperation returns operation of the route matched by echo, nil when it isn't documented
+func findOperation(doc *openapi3.T, c echo.Context) *openapi3.Operation {
+	item := doc.Paths.Value(Path(c.Path()))
+	if item == nil {
+		return nil
+	}
+
+	return item.GetOperation(c.Request().Method)
+}
+
+func hasJSONBody(c echo.Context) bool {
+	mediaType, _, _ := mime.ParseMediaType(c.Request().Header.Get(echo.HeaderContentType))
+	return mediaType == echo.MIMEApplicationJSON
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// fieldErrors flattens errors of validation, errors which aren't about a field, e.g. malformed json,
+// are reported for the whole body whose JSON pointer is empty
+func fieldErrors(err error) []handler.FieldError {
+	var multi openapi3.MultiError
+	if errors.As(err, &multi) {
+		var details []handler.FieldError
+		for _, e := range multi {
+			details = append(details, fieldErrors(e)...)
+		}
+
+		return details
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(err, &schemaErr) {
+		var pointer string
+		for _, p := range schemaErr.JSONPointer() {
+			pointer += "/" + pointerEscaper.Replace(p)
+		}
+
+		return []handler.FieldError{{Field: pointer, Reason: schemaErr.Reason}}
+	}
+
+	return []handler.FieldError{{Field: "", Reason: err.Error()}}
+}
@@ -0,0 +1,104 @@
+package openapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/handler"
+	"github.com/AnnaCarter465/assessment-tax/pkg/errcode"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRequest(t *testing.T) {
+	type TC struct {
+		contentType string
+		body        string
+		wantCode    int
+		wantFields  []string
+	}
+
+	tcs := []TC{
+		{
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"totalIncome":500000,"wht":0,"allowances":[{"allowanceType":"donation","amount":0}]}`,
+			wantCode:    http.StatusOK,
+		},
+		{
+			contentType: echo.MIMEApplicationJSONCharsetUTF8,
+			body:        `{"totalIncome":500000,"allowances":[],"extra":true}`,
+			wantCode:    http.StatusOK,
+		},
+		{
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"wht":-1,"allowances":[{"allowanceType":"donation","amount":-1}]}`,
+			wantCode:    http.StatusBadRequest,
+			wantFields:  []string{"/totalIncome", "/wht", "/allowances/0/amount"},
+		},
+		{
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"totalIncome":"500000","allowances":[]}`,
+			wantCode:    http.StatusBadRequest,
+			wantFields:  []string{"/totalIncome"},
+		},
+		{
+			contentType: echo.MIMEApplicationJSON,
+			body:        `{"totalIncome":`,
+			wantCode:    http.StatusBadRequest,
+			wantFields:  []string{""},
+		},
+		{
+			contentType: "text/csv",
+			body:        "totalIncome,wht,donation\n500000,0,0\n",
+			wantCode:    http.StatusOK,
+		},
+	}
+
+	doc, err := Document()
+	assert.NoError(t, err)
+
+	e := echo.New()
+	e.POST("/tax/calculations", func(c echo.Context) error {
+		// handlers must still be able to read the body
+		body, err := io.ReadAll(c.Request().Body)
+		if err != nil {
+			return err
+		}
+
+		return c.String(http.StatusOK, string(body))
+	}, ValidateRequest(doc))
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/tax/calculations", strings.NewReader(tc.body))
+			req.Header.Set(echo.HeaderContentType, tc.contentType)
+			rec := httptest.NewRecorder()
+
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.wantCode, rec.Code)
+
+			if tc.wantCode == http.StatusOK {
+				assert.Equal(t, tc.body, rec.Body.String())
+				return
+			}
+
+			var got handler.ResponseMsg
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			assert.Equal(t, errcode.SchemaViolation, got.ErrorCode)
+
+			var fields []string
+			for _, d := range got.Details {
+				fields = append(fields, d.Field)
+				assert.NotEmpty(t, d.Reason)
+			}
+
+			assert.ElementsMatch(t, tc.wantFields, fields)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+)
+
+// set at build time, e.g.
+// go build -ldflags "-X github.com/AnnaCarter465/assessment-tax/pkg/buildinfo.Version=v1.2.0"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// GetCommit returns commit from ldflags, fallback to vcs revision recorded by go build
+func GetCommit() string {
+	if Commit != "" {
+		return Commit
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.revision" {
+				return s.Value
+			}
+		}
+	}
+
+	return "unknown"
+}
+
+// GetBuildTime returns build time from ldflags, fallback to vcs commit time recorded by go build
+func GetBuildTime() string {
+	if BuildTime != "" {
+		return BuildTime
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "vcs.time" {
+				return s.Value
+			}
+		}
+	}
+
+	return "unknown"
+}
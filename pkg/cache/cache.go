@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Cache stores encoded values by key until they expire
+type Cache interface {
+	// Get returns false when key doesn't exist or is expired
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// New returns cache by kind, empty kind means an in-process cache which isn't shared by replicas.
+func New(kind string, url string) (Cache, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", "local":
+		return NewLocal(), nil
+	case "redis":
+		return NewRedis(url)
+	default:
+		return nil, fmt.Errorf("unknown cache %q", kind)
+	}
+}
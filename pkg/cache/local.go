@@ -0,0 +1,60 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Local keeps values in process, expired values are dropped when they are read
+type Local struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	now     func() time.Time
+}
+
+type entry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+func NewLocal() *Local {
+	return &Local{entries: map[string]entry{}, now: time.Now}
+}
+
+func (l *Local) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	if !l.now().Before(e.expiresAt) {
+		delete(l.entries, key)
+		return nil, false, nil
+	}
+
+	return e.value, true, nil
+}
+
+func (l *Local) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[key] = entry{value: value, expiresAt: l.now().Add(ttl)}
+
+	return nil
+}
+
+func (l *Local) Delete(ctx context.Context, keys ...string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		delete(l.entries, key)
+	}
+
+	return nil
+}
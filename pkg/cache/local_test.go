@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	l := NewLocal()
+	l.now = func() time.Time { return now }
+
+	assert.NoError(t, l.Set(ctx, "a", []byte("1"), 10*time.Second))
+	assert.NoError(t, l.Set(ctx, "b", []byte("2"), 10*time.Second))
+
+	v, ok, err := l.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), v)
+
+	assert.NoError(t, l.Delete(ctx, "a"))
+
+	_, ok, _ = l.Get(ctx, "a")
+	assert.False(t, ok, "deleted")
+
+	now = now.Add(10 * time.Second)
+
+	_, ok, _ = l.Get(ctx, "b")
+	assert.False(t, ok, "expired")
+}
@@ -0,0 +1,81 @@
+package envelope
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/secretbox"
+)
+
+// KeyWrapper encrypts data keys with the master key, *secretbox.Box wraps with a key from config
+// and a KMS client can implement it so the master key never leaves the KMS
+type KeyWrapper interface {
+	Seal(plaintext []byte) ([]byte, error)
+	Open(sealed []byte) ([]byte, error)
+}
+
+// Envelope encrypts every value with a fresh data key which is stored wrapped by the master key,
+// so rotating the master key only rewraps data keys instead of reencrypting values
+type Envelope struct {
+	keyID   string
+	wrapper KeyWrapper
+}
+
+// New returns Envelope wrapping data keys with wrapper, keyID identifies the master key in stored values
+func New(keyID string, wrapper KeyWrapper) (*Envelope, error) {
+	if keyID == "" {
+		return nil, errors.New("envelope key id must not be empty")
+	}
+
+	return &Envelope{keyID: keyID, wrapper: wrapper}, nil
+}
+
+// Sealed is ciphertext with its wrapped data key and id of the master key wrapping it
+type Sealed struct {
+	Ciphertext []byte
+	WrappedKey []byte
+	KeyID      string
+}
+
+func (e *Envelope) Seal(plaintext []byte) (Sealed, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return Sealed{}, err
+	}
+
+	box, err := secretbox.New(dataKey)
+	if err != nil {
+		return Sealed{}, err
+	}
+
+	ciphertext, err := box.Seal(plaintext)
+	if err != nil {
+		return Sealed{}, err
+	}
+
+	wrappedKey, err := e.wrapper.Seal(dataKey)
+	if err != nil {
+		return Sealed{}, err
+	}
+
+	return Sealed{Ciphertext: ciphertext, WrappedKey: wrappedKey, KeyID: e.keyID}, nil
+}
+
+func (e *Envelope) Open(s Sealed) ([]byte, error) {
+	if s.KeyID != e.keyID {
+		return nil, fmt.Errorf("value is sealed by unknown master key %q", s.KeyID)
+	}
+
+	dataKey, err := e.wrapper.Open(s.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	box, err := secretbox.New(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return box.Open(s.Ciphertext)
+}
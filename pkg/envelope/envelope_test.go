@@ -0,0 +1,43 @@
+package envelope
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/secretbox"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestEnvelope(t *testing.T, keyID string, key byte) *Envelope {
+	box, err := secretbox.New(bytes.Repeat([]byte{key}, 32))
+	assert.NoError(t, err)
+
+	e, err := New(keyID, box)
+	assert.NoError(t, err)
+
+	return e
+}
+
+func TestEnvelope(t *testing.T) {
+	e := newTestEnvelope(t, "k1", 1)
+	plaintext := []byte(`{"totalIncome":500000}`)
+
+	sealed, err := e.Seal(plaintext)
+	assert.NoError(t, err)
+	assert.Equal(t, "k1", sealed.KeyID)
+	assert.False(t, bytes.Contains(sealed.Ciphertext, []byte("500000")))
+
+	opened, err := e.Open(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, plaintext, opened)
+
+	other, err := e.Seal(plaintext)
+	assert.NoError(t, err)
+	assert.NotEqual(t, sealed.WrappedKey, other.WrappedKey, "every value has its own data key")
+
+	_, err = newTestEnvelope(t, "k2", 1).Open(sealed)
+	assert.Error(t, err)
+
+	_, err = newTestEnvelope(t, "k1", 2).Open(sealed)
+	assert.Error(t, err)
+}
@@ -0,0 +1,104 @@
+package errcode
+
+// Code is a stable machine-readable error code returned as `errorCode`,
+// clients should branch on it instead of the human readable message.
+type Code string
+
+const (
+	InvalidRequest                 Code = "INVALID_REQUEST"
+	Internal                       Code = "INTERNAL_ERROR"
+	Unauthorized                   Code = "UNAUTHORIZED"
+	Forbidden                      Code = "FORBIDDEN"
+	NotFound                       Code = "NOT_FOUND"
+	MethodNotAllowed               Code = "METHOD_NOT_ALLOWED"
+	PayloadTooLarge                Code = "PAYLOAD_TOO_LARGE"
+	UnsupportedMediaType           Code = "UNSUPPORTED_MEDIA_TYPE"
+	TooManyRequests                Code = "TOO_MANY_REQUESTS"
+	ServiceUnavailable             Code = "SERVICE_UNAVAILABLE"
+	RequestTimeout                 Code = "REQUEST_TIMEOUT"
+	MaintenanceMode                Code = "MAINTENANCE_MODE"
+	TaxYearUnsupported             Code = "TAX_YEAR_UNSUPPORTED"
+	WhtExceedsIncome               Code = "TAX_WHT_EXCEEDS_INCOME"
+	InvalidDate                    Code = "TAX_INVALID_DATE"
+	CSVContentType                 Code = "CSV_UNSUPPORTED_CONTENT_TYPE"
+	CSVMalformed                   Code = "CSV_MALFORMED"
+	CSVEmpty                       Code = "CSV_EMPTY"
+	CSVNoDataRows                  Code = "CSV_NO_DATA_ROWS"
+	CSVBadColumnCount              Code = "CSV_BAD_COLUMN_COUNT"
+	CSVBadHeader                   Code = "CSV_BAD_HEADER"
+	CSVInvalidIncome               Code = "CSV_INVALID_INCOME"
+	CSVInvalidWht                  Code = "CSV_INVALID_WHT"
+	CSVInvalidDonation             Code = "CSV_INVALID_DONATION"
+	CSVWhtExceedsIncome            Code = "CSV_WHT_EXCEEDS_INCOME"
+	UploadRejected                 Code = "UPLOAD_REJECTED"
+	AmountOutOfRange               Code = "ADMIN_AMOUNT_OUT_OF_RANGE"
+	PersonalUpdateFailed           Code = "ADMIN_PERSONAL_UPDATE_FAILED"
+	KReceiptUpdateFailed           Code = "ADMIN_K_RECEIPT_UPDATE_FAILED"
+	DonationUpdateFailed           Code = "ADMIN_DONATION_UPDATE_FAILED"
+	EffectiveAllowanceUpdateFailed Code = "ADMIN_EFFECTIVE_ALLOWANCE_UPDATE_FAILED"
+	ActivationInPast               Code = "SCHEDULE_ACTIVATION_IN_PAST"
+	ScheduledChangeCreateFailed    Code = "SCHEDULE_CREATE_FAILED"
+	ScheduledChangeInvalidID       Code = "SCHEDULE_INVALID_ID"
+	ScheduledChangeNotFound        Code = "SCHEDULE_NOT_FOUND"
+	DraftCreateFailed              Code = "DRAFT_CREATE_FAILED"
+	DraftInvalidID                 Code = "DRAFT_INVALID_ID"
+	DraftNotFound                  Code = "DRAFT_NOT_FOUND"
+	DraftSelfPublish               Code = "DRAFT_SELF_PUBLISH"
+	DraftPublishFailed             Code = "DRAFT_PUBLISH_FAILED"
+	SettingVersionInvalid          Code = "SETTINGS_INVALID_VERSION"
+	SettingVersionNotFound         Code = "SETTINGS_VERSION_NOT_FOUND"
+	SettingsRollbackFailed         Code = "SETTINGS_ROLLBACK_FAILED"
+	SettingsImportInvalid          Code = "SETTINGS_IMPORT_INVALID"
+	SettingsImportFailed           Code = "SETTINGS_IMPORT_FAILED"
+	SettingsVersionConflict        Code = "SETTINGS_VERSION_CONFLICT"
+	InvalidTaxYear                 Code = "CALENDAR_INVALID_TAX_YEAR"
+	InvalidWindow                  Code = "CALENDAR_INVALID_WINDOW"
+	CalendarUpdateFailed           Code = "CALENDAR_UPDATE_FAILED"
+	CalendarNotFound               Code = "CALENDAR_NOT_FOUND"
+	CalendarDeleteFailed           Code = "CALENDAR_DELETE_FAILED"
+	WebhookCreateFailed            Code = "WEBHOOK_CREATE_FAILED"
+	WebhookInvalidID               Code = "WEBHOOK_INVALID_ID"
+	WebhookNotFound                Code = "WEBHOOK_NOT_FOUND"
+	WebhookDeleteFailed            Code = "WEBHOOK_DELETE_FAILED"
+	APIKeyInvalid                  Code = "API_KEY_INVALID"
+	APIKeyScopeDenied              Code = "API_KEY_SCOPE_DENIED"
+	APIKeyCreateFailed             Code = "API_KEY_CREATE_FAILED"
+	APIKeyInvalidID                Code = "API_KEY_INVALID_ID"
+	APIKeyNotFound                 Code = "API_KEY_NOT_FOUND"
+	QuotaExceeded                  Code = "API_KEY_QUOTA_EXCEEDED"
+	InvalidMonth                   Code = "INVALID_MONTH"
+	SignatureInvalid               Code = "SIGNATURE_INVALID"
+	SignatureExpired               Code = "SIGNATURE_EXPIRED"
+	InvalidCredentials             Code = "INVALID_CREDENTIALS"
+	AdminUserExists                Code = "ADMIN_USER_EXISTS"
+	AdminUserInvalidID             Code = "ADMIN_USER_INVALID_ID"
+	AdminUserNotFound              Code = "ADMIN_USER_NOT_FOUND"
+	TOTPRequired                   Code = "TOTP_REQUIRED"
+	TOTPInvalid                    Code = "TOTP_INVALID"
+	TOTPAlreadyEnabled             Code = "TOTP_ALREADY_ENABLED"
+	TOTPNotEnrolled                Code = "TOTP_NOT_ENROLLED"
+	SessionNotFound                Code = "SESSION_NOT_FOUND"
+	IPNotAllowed                   Code = "IP_NOT_ALLOWED"
+	AllowanceNotFound              Code = "ALLOWANCE_NOT_FOUND"
+	AllowanceDisabled              Code = "ALLOWANCE_DISABLED"
+	AllowanceDisableFailed         Code = "ALLOWANCE_DISABLE_FAILED"
+	HistoryDeleteFailed            Code = "HISTORY_DELETE_FAILED"
+	ShuttingDown                   Code = "SHUTTING_DOWN"
+	ConfigInvalid                  Code = "CONFIG_INVALID"
+	SchemaViolation                Code = "SCHEMA_VIOLATION"
+	TenantExists                   Code = "TENANT_EXISTS"
+	TenantInvalidID                Code = "TENANT_INVALID_ID"
+	TenantNotFound                 Code = "TENANT_NOT_FOUND"
+	TenantInUse                    Code = "TENANT_IN_USE"
+	AccountExists                  Code = "ACCOUNT_EXISTS"
+	ProfileNotFound                Code = "PROFILE_NOT_FOUND"
+	CalculationDraftInvalidID      Code = "CALCULATION_DRAFT_INVALID_ID"
+	CalculationDraftNotFound       Code = "CALCULATION_DRAFT_NOT_FOUND"
+	CalculationDraftIncomplete     Code = "CALCULATION_DRAFT_INCOMPLETE"
+	CalculationDraftChanged        Code = "CALCULATION_DRAFT_CHANGED"
+	AllowanceAliasInvalid          Code = "ALLOWANCE_ALIAS_INVALID"
+	AllowanceAliasNotFound         Code = "ALLOWANCE_ALIAS_NOT_FOUND"
+	AllowanceDuplicated            Code = "ALLOWANCE_DUPLICATED"
+	AllowanceOutsideWindow         Code = "ALLOWANCE_OUTSIDE_WINDOW"
+	BatchJobNotFound               Code = "BATCH_JOB_NOT_FOUND"
+)
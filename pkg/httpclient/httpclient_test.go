@@ -0,0 +1,126 @@
+package httpclient
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	client, err := New(Config{Timeout: 50 * time.Millisecond})
+	assert.NoError(t, err)
+
+	_, err = client.Get(srv.URL)
+	assert.ErrorContains(t, err, "Client.Timeout")
+}
+
+func TestRetry(t *testing.T) {
+	var calls atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client, err := New(Config{Retries: 2})
+	assert.NoError(t, err)
+	client.Transport.(*retryTransport).backoff = time.Millisecond
+
+	resp, err := client.Get(srv.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), calls.Load())
+
+	calls.Store(0)
+
+	resp, err = client.Post(srv.URL, "application/json", strings.NewReader("{}"))
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, int32(1), calls.Load(), "posts aren't retried")
+
+	var downCalls atomic.Int32
+
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downCalls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	resp, err = client.Get(down.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "the last response is returned once retries run out")
+	assert.Equal(t, int32(3), downCalls.Load(), "a request is sent once and retried twice")
+}
+
+func TestProxy(t *testing.T) {
+	var proxied atomic.Value
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Store(r.URL.String())
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	client, err := New(Config{ProxyURL: proxy.URL})
+	assert.NoError(t, err)
+
+	resp, err := client.Get("http://integration.invalid/hooks")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "http://integration.invalid/hooks", proxied.Load(), "requests are sent through the proxy")
+
+	_, err = New(Config{ProxyURL: "://"})
+	assert.Error(t, err)
+}
+
+func TestCAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	client, err := New(Config{})
+	assert.NoError(t, err)
+
+	_, err = client.Get(srv.URL)
+	assert.Error(t, err, "certificate of the server isn't trusted by default")
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600))
+
+	client, err = New(Config{CAFile: caFile})
+	assert.NoError(t, err)
+
+	resp, err := client.Get(srv.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	_, err = New(Config{CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,224 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// bodies longer than this are cut before redaction, audit log is for debugging not archiving
+const maxAuditBody = 64 * 1024
+
+// loggedFields are json fields logged as they are, they describe a request without identifying anybody.
+// Every other field is redacted, numbers are logged as buckets and strings aren't logged, so income figures,
+// credentials, personal data and fields added later are never logged by mistake
+var loggedFields = map[string]bool{
+	"allowanceType":  true,
+	"activateAt":     true,
+	"apiKeyId":       true,
+	"calculatedAt":   true,
+	"createdAt":      true,
+	"csvRows":        true,
+	"disabledAt":     true,
+	"effectiveFrom":  true,
+	"enabled":        true,
+	"endsOn":         true,
+	"errorCode":      true,
+	"expiresAt":      true,
+	"expiresIn":      true,
+	"field":          true,
+	"filingDeadline": true,
+	"form":           true,
+	"id":             true,
+	"kind":           true,
+	"level":          true,
+	"message":        true,
+	"month":          true,
+	"monthsElapsed":  true,
+	"paidOn":         true,
+	"paymentType":    true,
+	"percentage":     true,
+	"prefix":         true,
+	"rate":           true,
+	"requestId":      true,
+	"revokedAt":      true,
+	"role":           true,
+	"scheme":         true,
+	"scopes":         true,
+	"setting":        true,
+	"startsOn":       true,
+	"status":         true,
+	"taxYear":        true,
+	"taxYearBE":      true,
+	"tenantId":       true,
+	"timestamp":      true,
+	"tokenType":      true,
+	"type":           true,
+	"updatedAt":      true,
+	"uuid":           true,
+	"version":        true,
+}
+
+var incomeBuckets = []float64{150_000, 500_000, 1_000_000, 2_000_000}
+
+type AuditConfig struct {
+	// Bodies logs request and response bodies redacted by Redact
+	Bodies bool
+}
+
+// Audit logs method, path, status and latency of every request
+func Audit(l *slog.Logger, conf AuditConfig) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			req := c.Request()
+
+			var reqBody []byte
+			var resBody *limitedBuffer
+
+			if conf.Bodies {
+				reqBody, _ = io.ReadAll(io.LimitReader(req.Body, maxAuditBody))
+				req.Body = readCloser{io.MultiReader(bytes.NewReader(reqBody), req.Body), req.Body}
+
+				resBody = &limitedBuffer{}
+				c.Response().Writer = &teeResponseWriter{c.Response().Writer, resBody}
+			}
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+				slog.Int("status", c.Response().Status),
+				slog.Duration("latency", time.Since(start)),
+			}
+
+			if conf.Bodies {
+				attrs = append(attrs,
+					slog.String("request_body", Redact(req.Header.Get(echo.HeaderContentType), reqBody)),
+					slog.String("response_body", Redact(c.Response().Header().Get(echo.HeaderContentType), resBody.Bytes())),
+				)
+			}
+
+			l.LogAttrs(req.Context(), slog.LevelInfo, "audit", attrs...)
+
+			return nil
+		}
+	}
+}
+
+// Redact returns json body with fields other than loggedFields redacted, bodies which can't be inspected are omitted
+func Redact(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	switch {
+	case strings.HasPrefix(contentType, echo.MIMEApplicationJSON):
+		var v any
+		if err := json.Unmarshal(body, &v); err != nil {
+			return "[redacted]"
+		}
+
+		b, err := json.Marshal(redactValue("", v))
+		if err != nil {
+			return "[redacted]"
+		}
+
+		return string(b)
+	case strings.HasPrefix(contentType, "text/csv"):
+		return fmt.Sprintf("[redacted csv, %d lines]", bytes.Count(bytes.TrimSpace(body), []byte("\n"))+1)
+	default:
+		return "[redacted]"
+	}
+}
+
+func redactValue(key string, v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			v[k] = redactValue(k, val)
+		}
+		return v
+	case []any:
+		for i, val := range v {
+			v[i] = redactValue(key, val)
+		}
+		return v
+	case float64:
+		if loggedFields[key] {
+			return v
+		}
+		return bucket(v)
+	case string:
+		if loggedFields[key] {
+			return v
+		}
+		return "[redacted]"
+	default:
+		return v
+	}
+}
+
+// bucket returns range of amount following tax brackets
+func bucket(v float64) string {
+	lower := 0.0
+
+	for _, upper := range incomeBuckets {
+		if v <= upper {
+			return fmt.Sprintf("%.0f-%.0f", lower, upper)
+		}
+
+		lower = upper
+	}
+
+	return fmt.Sprintf("%.0f+", lower)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxAuditBody - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+
+	return len(p), nil
+}
+
+type teeResponseWriter struct {
+	http.ResponseWriter
+	buf io.Writer
+}
+
+func (w *teeResponseWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *teeResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *teeResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
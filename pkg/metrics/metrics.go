@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type Labels map[string]string
+
+type Metrics interface {
+	IncCounter(name string, value float64, labels Labels)
+	ObserveDuration(name string, d time.Duration, labels Labels)
+}
+
+// New returns metrics backend by kind, empty kind means metrics are discarded.
+// statsdAddr is used by statsd and datadog backends only.
+func New(kind string, prefix string, statsdAddr string) (Metrics, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "":
+		return Noop{}, nil
+	case "prometheus":
+		return NewPrometheus(prefix), nil
+	case "statsd", "datadog":
+		return NewStatsD(statsdAddr, prefix)
+	default:
+		return nil, fmt.Errorf("unknown metrics backend %q", kind)
+	}
+}
+
+type Noop struct{}
+
+func (Noop) IncCounter(string, float64, Labels) {}
+
+func (Noop) ObserveDuration(string, time.Duration, Labels) {}
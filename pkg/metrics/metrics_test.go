@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	m, err := New("", "", "")
+	assert.NoError(t, err)
+	assert.Equal(t, Noop{}, m)
+
+	m, err = New(" Prometheus ", "tax_", "")
+	assert.NoError(t, err)
+	assert.IsType(t, &Prometheus{}, m)
+
+	_, err = New("graphite", "", "")
+	assert.ErrorContains(t, err, "graphite")
+}
+
+func TestPrometheus(t *testing.T) {
+	p := NewPrometheus("tax_")
+
+	p.IncCounter("http_requests", 1, Labels{"route": "/tax/calculations", "method": "POST"})
+	p.IncCounter("http_requests", 2, Labels{"route": "/tax/calculations", "method": "POST"})
+	p.IncCounter("csv_rows", 3, nil)
+	p.ObserveDuration("http_request_duration", 20*time.Millisecond, Labels{"route": `/say "hi"`})
+
+	rec := httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+
+	assert.Contains(t, body, "# TYPE tax_http_requests_total counter\n")
+	assert.Contains(t, body, `tax_http_requests_total{method="POST",route="/tax/calculations"} 3`+"\n", "labels are sorted")
+	assert.Contains(t, body, "tax_csv_rows_total 3\n")
+	assert.Contains(t, body, "# TYPE tax_http_request_duration_seconds histogram\n")
+	assert.Contains(t, body, `tax_http_request_duration_seconds_bucket{route="/say \"hi\"",le="0.01"} 0`+"\n", "label values are escaped")
+	assert.Contains(t, body, `tax_http_request_duration_seconds_bucket{route="/say \"hi\"",le="0.025"} 1`+"\n")
+	assert.Contains(t, body, `tax_http_request_duration_seconds_bucket{route="/say \"hi\"",le="+Inf"} 1`+"\n")
+	assert.Contains(t, body, `tax_http_request_duration_seconds_count{route="/say \"hi\""} 1`+"\n")
+	assert.Contains(t, body, "go_goroutines ", "runtime metrics are exposed too")
+
+	p.IncCounter("http_requests", 1, Labels{"route": "/tax/calculations"})
+
+	rec = httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Contains(t, rec.Body.String(), `tax_http_requests_total{method="POST",route="/tax/calculations"} 3`+"\n",
+		"observations with other label names are dropped")
+}
+
+func TestStatsD(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	s, err := NewStatsD(conn.LocalAddr().String(), "tax.")
+	assert.NoError(t, err)
+
+	read := func() string {
+		buf := make([]byte, 512)
+
+		assert.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+		n, _, err := conn.ReadFrom(buf)
+		assert.NoError(t, err)
+
+		return string(buf[:n])
+	}
+
+	s.IncCounter("http_requests", 1, Labels{"status": "200", "method": "GET"})
+	assert.Equal(t, "tax.http_requests:1|c|#method:GET,status:200", read())
+
+	s.ObserveDuration("http_request_duration", 1500*time.Millisecond, nil)
+	assert.Equal(t, "tax.http_request_duration:1500|ms", read())
+}
+
+func TestMiddleware(t *testing.T) {
+	p := NewPrometheus("")
+
+	var returned []error
+
+	e := echo.New()
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			err := next(c)
+			returned = append(returned, err)
+
+			return err
+		}
+	})
+
+	errFailed := errors.New("query failed")
+
+	e.Use(Middleware(p))
+	e.GET("/tax/brackets/:year", func(c echo.Context) error {
+		return echo.ErrNotFound
+	})
+	e.GET("/tax/calendars/:year", func(c echo.Context) error {
+		return errFailed
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tax/brackets/2024", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/tax/calendars/2024", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, []error{echo.ErrNotFound, errFailed}, returned, "errors reach outer middlewares unchanged")
+
+	rec = httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Contains(t, rec.Body.String(), `http_requests_total{method="GET",route="/tax/brackets/:year",status="404"} 1`,
+		"requests are counted by route and the status of the handled error")
+	assert.Contains(t, rec.Body.String(), `http_requests_total{method="GET",route="/tax/calendars/:year",status="500"} 1`)
+}
@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware counts requests by method, route and status. An error returned by next is left to the error handler
+// of echo, its status is recorded before the error handler writes it
+func Middleware(m Metrics) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			err := next(c)
+
+			labels := Labels{
+				"method": c.Request().Method,
+				"route":  c.Path(),
+				"status": strconv.Itoa(responseStatus(c, err)),
+			}
+
+			m.IncCounter("http_requests", 1, labels)
+			m.ObserveDuration("http_request_duration", time.Since(start), labels)
+
+			return err
+		}
+	}
+}
+
+// responseStatus is the status of the response of c, or the status err will be responded with when nothing is
+// written yet
+func responseStatus(c echo.Context, err error) int {
+	if err == nil || c.Response().Committed {
+		return c.Response().Status
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+
+	return http.StatusInternalServerError
+}
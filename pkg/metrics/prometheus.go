@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus registers a vector by name and the label names of its first observation, its registry is exposed
+// by Handler with Go runtime and process metrics. Observations with other label names are dropped.
+type Prometheus struct {
+	prefix     string
+	registry   *prometheus.Registry
+	mu         sync.Mutex
+	counters   map[string]*prometheus.CounterVec
+	histograms map[string]*prometheus.HistogramVec
+}
+
+func NewPrometheus(prefix string) *Prometheus {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	return &Prometheus{
+		prefix:     prefix,
+		registry:   registry,
+		counters:   make(map[string]*prometheus.CounterVec),
+		histograms: make(map[string]*prometheus.HistogramVec),
+	}
+}
+
+func (p *Prometheus) IncCounter(name string, value float64, labels Labels) {
+	name = p.prefix + name + "_total"
+
+	p.mu.Lock()
+	vec, ok := p.counters[name]
+	if !ok {
+		vec = prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: name}, labelNames(labels))
+		p.register(name, vec)
+		p.counters[name] = vec
+	}
+	p.mu.Unlock()
+
+	counter, err := vec.GetMetricWith(prometheus.Labels(labels))
+	if err != nil {
+		slog.Warn("dropped metric", "name", name, "error", err)
+		return
+	}
+
+	counter.Add(value)
+}
+
+func (p *Prometheus) ObserveDuration(name string, d time.Duration, labels Labels) {
+	name = p.prefix + name + "_seconds"
+
+	p.mu.Lock()
+	vec, ok := p.histograms[name]
+	if !ok {
+		vec = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: name, Help: name, Buckets: prometheus.DefBuckets}, labelNames(labels))
+		p.register(name, vec)
+		p.histograms[name] = vec
+	}
+	p.mu.Unlock()
+
+	histogram, err := vec.GetMetricWith(prometheus.Labels(labels))
+	if err != nil {
+		slog.Warn("dropped metric", "name", name, "error", err)
+		return
+	}
+
+	histogram.Observe(d.Seconds())
+}
+
+// register registers c, a vector which can't be registered is still observed but isn't exposed
+func (p *Prometheus) register(name string, c prometheus.Collector) {
+	if err := p.registry.Register(c); err != nil {
+		slog.Warn("cannot register metric", "name", name, "error", err)
+	}
+}
+
+func (p *Prometheus) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+func labelNames(labels Labels) []string {
+	return sortedKeys(labels)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// StatsD sends metrics over UDP with Datadog style tags.
+type StatsD struct {
+	prefix string
+	conn   net.Conn
+}
+
+func NewStatsD(addr string, prefix string) (*StatsD, error) {
+	if strings.TrimSpace(addr) == "" {
+		addr = "127.0.0.1:8125"
+	}
+
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &StatsD{prefix: prefix, conn: conn}, nil
+}
+
+func (s *StatsD) IncCounter(name string, value float64, labels Labels) {
+	s.send(fmt.Sprintf("%s%s:%v|c%s", s.prefix, name, value, formatTags(labels)))
+}
+
+func (s *StatsD) ObserveDuration(name string, d time.Duration, labels Labels) {
+	s.send(fmt.Sprintf("%s%s:%d|ms%s", s.prefix, name, d.Milliseconds(), formatTags(labels)))
+}
+
+func (s *StatsD) send(line string) {
+	// metrics are best effort, a lost packet should never fail a request
+	_, _ = s.conn.Write([]byte(line))
+}
+
+func formatTags(labels Labels) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	tags := make([]string, 0, len(labels))
+
+	for _, k := range sortedKeys(labels) {
+		tags = append(tags, k+":"+labels[k])
+	}
+
+	return "|#" + strings.Join(tags, ",")
+}
@@ -0,0 +1,49 @@
+package pubsub
+
+import (
+	"context"
+	"sync"
+)
+
+// Local broadcasts to subscribers in process, e.g. to replicas embedded in tests
+type Local struct {
+	mu          sync.Mutex
+	subscribers map[string]map[*func(msg []byte)]struct{}
+}
+
+func NewLocal() *Local {
+	return &Local{subscribers: map[string]map[*func(msg []byte)]struct{}{}}
+}
+
+// Publish calls handlers of channel before it returns
+func (l *Local) Publish(ctx context.Context, channel string, msg []byte) error {
+	l.mu.Lock()
+	handlers := make([]func(msg []byte), 0, len(l.subscribers[channel]))
+	for h := range l.subscribers[channel] {
+		handlers = append(handlers, *h)
+	}
+	l.mu.Unlock()
+
+	for _, h := range handlers {
+		h(msg)
+	}
+
+	return nil
+}
+
+func (l *Local) Subscribe(ctx context.Context, channel string, handle func(msg []byte)) error {
+	l.mu.Lock()
+	if l.subscribers[channel] == nil {
+		l.subscribers[channel] = map[*func(msg []byte)]struct{}{}
+	}
+	l.subscribers[channel][&handle] = struct{}{}
+	l.mu.Unlock()
+
+	<-ctx.Done()
+
+	l.mu.Lock()
+	delete(l.subscribers[channel], &handle)
+	l.mu.Unlock()
+
+	return nil
+}
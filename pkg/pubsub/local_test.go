@@ -0,0 +1,46 @@
+package pubsub
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocal(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	l := NewLocal()
+
+	var received [2]atomic.Int32
+
+	done := make(chan struct{})
+
+	for i := range received {
+		go func() {
+			l.Subscribe(ctx, "settings", func(msg []byte) { received[i].Add(1) })
+			done <- struct{}{}
+		}()
+	}
+
+	assert.Eventually(t, func() bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		return len(l.subscribers["settings"]) == 2
+	}, time.Second, time.Millisecond)
+
+	assert.NoError(t, l.Publish(ctx, "settings", []byte("changed")))
+	assert.NoError(t, l.Publish(ctx, "other", []byte("changed")))
+
+	assert.Equal(t, int32(1), received[0].Load(), "every subscriber gets the message")
+	assert.Equal(t, int32(1), received[1].Load())
+
+	cancel()
+	<-done
+	<-done
+
+	assert.Empty(t, l.subscribers["settings"], "subscribers are removed once ctx is done")
+}
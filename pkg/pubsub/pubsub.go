@@ -0,0 +1,11 @@
+// Package pubsub broadcasts messages to every subscribed process, unlike a queue each subscriber gets every message
+package pubsub
+
+import "context"
+
+type PubSub interface {
+	Publish(ctx context.Context, channel string, msg []byte) error
+	// Subscribe calls handle with messages of channel until ctx is done, messages published while a subscriber
+	// is disconnected are lost
+	Subscribe(ctx context.Context, channel string, handle func(msg []byte)) error
+}
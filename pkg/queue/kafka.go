@@ -0,0 +1,95 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// Kafka publishes messages to a topic consumed by one consumer group, brokers is like host1:9092,host2:9092.
+// The topic and group are name with colons replaced by dots. An offset is committed after its message is handled,
+// a failed message is published again before its offset is committed so it doesn't block its partition.
+type Kafka struct {
+	brokers    []string
+	topic      string
+	writer     *kafka.Writer
+	retryDelay time.Duration
+}
+
+func NewKafka(brokers string, name string) (*Kafka, error) {
+	var addrs []string
+
+	for _, b := range strings.Split(brokers, ",") {
+		if b = strings.TrimSpace(b); b != "" {
+			addrs = append(addrs, b)
+		}
+	}
+
+	if len(addrs) == 0 {
+		return nil, errors.New("kafka brokers are required")
+	}
+
+	topic := strings.ReplaceAll(name, ":", ".")
+
+	return &Kafka{
+		brokers: addrs,
+		topic:   topic,
+		writer: &kafka.Writer{
+			Addr:         kafka.TCP(addrs...),
+			Topic:        topic,
+			Balancer:     &kafka.LeastBytes{},
+			RequiredAcks: kafka.RequireAll,
+			// a message is published by a request, waiting for more of them only delays it
+			BatchTimeout: 10 * time.Millisecond,
+		},
+		retryDelay: retryDelay,
+	}, nil
+}
+
+func (k *Kafka) Publish(ctx context.Context, msg []byte) error {
+	return k.writer.WriteMessages(ctx, kafka.Message{Value: msg})
+}
+
+func (k *Kafka) Consume(ctx context.Context, handle func(ctx context.Context, msg []byte) error) error {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: k.brokers,
+		GroupID: k.topic,
+		Topic:   k.topic,
+	})
+	defer reader.Close()
+
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// the offset is committed without ctx, so a handled message isn't delivered again on shutdown
+		ackCtx := context.WithoutCancel(ctx)
+
+		if err := handle(ackCtx, m.Value); err != nil {
+			slog.ErrorContext(ctx, "failed to handle message, it's queued again", "queue", k.topic, "error", err)
+
+			if err := k.Publish(ackCtx, m.Value); err != nil {
+				return err
+			}
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(k.retryDelay):
+			}
+		}
+
+		if err := reader.CommitMessages(ackCtx, m); err != nil {
+			return err
+		}
+	}
+}
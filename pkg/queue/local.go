@@ -0,0 +1,53 @@
+package queue
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// localSize is the number of messages published before Publish blocks
+const localSize = 1024
+
+// Local passes messages through a channel, they are lost when the process exits
+type Local struct {
+	messages   chan []byte
+	retryDelay time.Duration
+}
+
+func NewLocal() *Local {
+	return &Local{messages: make(chan []byte, localSize), retryDelay: retryDelay}
+}
+
+func (l *Local) Publish(ctx context.Context, msg []byte) error {
+	select {
+	case l.messages <- msg:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *Local) Consume(ctx context.Context, handle func(ctx context.Context, msg []byte) error) error {
+	for {
+		select {
+		case msg := <-l.messages:
+			if err := handle(context.WithoutCancel(ctx), msg); err != nil {
+				slog.ErrorContext(ctx, "failed to handle message, it's queued again", "error", err)
+
+				// the message is lost like the other queued ones when ctx is done meanwhile
+				if l.Publish(ctx, msg) != nil {
+					return nil
+				}
+
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(l.retryDelay):
+				}
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
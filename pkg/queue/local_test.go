@@ -0,0 +1,60 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocal(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	l := NewLocal()
+
+	assert.NoError(t, l.Publish(ctx, []byte("1")))
+	assert.NoError(t, l.Publish(ctx, []byte("2")))
+
+	var got []string
+
+	err := l.Consume(ctx, func(ctx context.Context, msg []byte) error {
+		got = append(got, string(msg))
+		if len(got) == 2 {
+			cancel()
+		}
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, got)
+}
+
+func TestLocalRedeliversFailedMessages(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	l := NewLocal()
+	l.retryDelay = time.Millisecond
+
+	assert.NoError(t, l.Publish(ctx, []byte("1")))
+
+	var attempts int
+
+	err := l.Consume(ctx, func(ctx context.Context, msg []byte) error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("database is down")
+		}
+
+		cancel()
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts, "a failed message is handled again")
+}
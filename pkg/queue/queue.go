@@ -0,0 +1,35 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Queue delivers messages published by one process to consumers in any process sharing the queue
+type Queue interface {
+	Publish(ctx context.Context, msg []byte) error
+	// Consume calls handle with messages until ctx is done, a message taken is handled to the end even when ctx is
+	// done meanwhile. A message is acknowledged once handle returns nil, it's delivered again when handle fails or
+	// its consumer stops before acknowledging it, so handle must be idempotent
+	Consume(ctx context.Context, handle func(ctx context.Context, msg []byte) error) error
+}
+
+// New returns queue by kind, empty kind means an in-process queue which is consumed by the process publishing to it.
+// url is the address of the broker, the queue url of sqs. name is the key, topic or queue of the broker.
+func New(kind string, url string, name string) (Queue, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", "local":
+		return NewLocal(), nil
+	case "redis":
+		return NewRedis(url, name)
+	case "kafka":
+		return NewKafka(url, name)
+	case "sqs":
+		return NewSQS(context.Background(), url)
+	case "rabbitmq":
+		return NewRabbitMQ(url, name)
+	default:
+		return nil, fmt.Errorf("unknown queue %q", kind)
+	}
+}
@@ -0,0 +1,85 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRedisTest(t *testing.T) (*Redis, *miniredis.Miniredis) {
+	s := miniredis.RunT(t)
+
+	r, err := NewRedis("redis://"+s.Addr(), "jobs")
+	assert.NoError(t, err)
+
+	r.retryDelay = time.Millisecond
+
+	return r, s
+}
+
+func TestRedis(t *testing.T) {
+	r, s := newRedisTest(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	assert.NoError(t, r.Publish(ctx, []byte("1")))
+	assert.NoError(t, r.Publish(ctx, []byte("2")))
+
+	var got []string
+
+	err := r.Consume(ctx, func(ctx context.Context, msg []byte) error {
+		got = append(got, string(msg))
+
+		// the failed message is taken again after the other one
+		if len(got) == 1 {
+			return errors.New("database is down")
+		}
+
+		if len(got) == 3 {
+			cancel()
+		}
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2", "1"}, got)
+	assert.Empty(t, s.Keys(), "handled messages are removed from the processing list")
+}
+
+func TestRedisRecoversMessagesOfStoppedConsumers(t *testing.T) {
+	r, s := newRedisTest(t)
+
+	// a consumer stopped while handling the message and its lease expired
+	_, err := s.Lpush(r.processingKey("stopped"), "1")
+	assert.NoError(t, err)
+
+	// a running consumer handles its message
+	_, err = s.Lpush(r.processingKey("running"), "2")
+	assert.NoError(t, err)
+	assert.NoError(t, s.Set(r.leaseKey("running"), "1"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var got []string
+
+	err = r.Consume(ctx, func(ctx context.Context, msg []byte) error {
+		got = append(got, string(msg))
+		cancel()
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1"}, got)
+
+	running, err := s.List(r.processingKey("running"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"2"}, running, "messages of running consumers are left to them")
+}
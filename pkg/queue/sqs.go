@@ -0,0 +1,92 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+// SQS sends messages to an Amazon SQS queue by its url, credentials and region are read like the AWS CLI does.
+// A message is deleted after it's handled, a failed message becomes visible again after retryDelay. The visibility
+// timeout of the queue must be longer than handling a message takes, or it's delivered to another consumer meanwhile.
+type SQS struct {
+	client     *sqs.Client
+	url        string
+	retryDelay time.Duration
+}
+
+func NewSQS(ctx context.Context, queueURL string) (*SQS, error) {
+	if strings.TrimSpace(queueURL) == "" {
+		return nil, errors.New("sqs queue url is required")
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SQS{client: sqs.NewFromConfig(cfg), url: queueURL, retryDelay: retryDelay}, nil
+}
+
+// Publish sends msg as the body of a message, it must be UTF-8 text
+func (s *SQS) Publish(ctx context.Context, msg []byte) error {
+	_, err := s.client.SendMessage(ctx, &sqs.SendMessageInput{
+		QueueUrl:    aws.String(s.url),
+		MessageBody: aws.String(string(msg)),
+	})
+
+	return err
+}
+
+func (s *SQS) Consume(ctx context.Context, handle func(ctx context.Context, msg []byte) error) error {
+	for ctx.Err() == nil {
+		out, err := s.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(s.url),
+			MaxNumberOfMessages: 1,
+			WaitTimeSeconds:     int32(pollTimeout / time.Second),
+		})
+		if ctx.Err() != nil {
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		// the message is deleted without ctx, so a handled message isn't delivered again on shutdown
+		ackCtx := context.WithoutCancel(ctx)
+
+		for _, m := range out.Messages {
+			if err := handle(ackCtx, []byte(aws.ToString(m.Body))); err != nil {
+				slog.ErrorContext(ctx, "failed to handle message, it's queued again", "queue", s.url, "error", err)
+
+				_, err := s.client.ChangeMessageVisibility(ackCtx, &sqs.ChangeMessageVisibilityInput{
+					QueueUrl:          aws.String(s.url),
+					ReceiptHandle:     m.ReceiptHandle,
+					VisibilityTimeout: int32(s.retryDelay / time.Second),
+				})
+				if err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			_, err := s.client.DeleteMessage(ackCtx, &sqs.DeleteMessageInput{
+				QueueUrl:      aws.String(s.url),
+				ReceiptHandle: m.ReceiptHandle,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
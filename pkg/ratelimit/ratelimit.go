@@ -0,0 +1,31 @@
+// Package ratelimit has stores of echo's rate limiter middleware, buckets of a local store are per process while
+// a redis store shares them between replicas
+package ratelimit
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+// idle buckets of a local store are dropped after this
+const localExpiry = 3 * time.Minute
+
+// New returns store by kind allowing perSecond requests per second in bursts of burst, empty kind means a local store
+func New(kind string, url string, perSecond int, burst int) (middleware.RateLimiterStore, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "", "local":
+		return middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Limit(perSecond),
+			Burst:     burst,
+			ExpiresIn: localExpiry,
+		}), nil
+	case "redis":
+		return NewRedis(url, perSecond, burst)
+	default:
+		return nil, fmt.Errorf("unknown rate limiter %q", kind)
+	}
+}
@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLocal(t *testing.T) {
+	store, err := New("", "", 1, 2)
+	assert.NoError(t, err)
+
+	var allowed []bool
+
+	for range 3 {
+		ok, err := store.Allow("key:1")
+		assert.NoError(t, err)
+
+		allowed = append(allowed, ok)
+	}
+
+	assert.Equal(t, []bool{true, true, false}, allowed, "a burst is allowed, then the rate")
+
+	ok, err := store.Allow("key:2")
+	assert.NoError(t, err)
+	assert.True(t, ok, "every identifier has its own bucket")
+}
+
+func TestNewUnknown(t *testing.T) {
+	_, err := New("memcached", "", 1, 1)
+	assert.ErrorContains(t, err, "memcached")
+
+	_, err = New("redis", "not a url", 1, 1)
+	assert.Error(t, err)
+}
+
+func TestRedisUnreachable(t *testing.T) {
+	store, err := New("redis", "redis://127.0.0.1:1/0", 1, 1)
+	assert.NoError(t, err)
+
+	ok, err := store.Allow("key:1")
+	assert.Error(t, err)
+	assert.True(t, ok, "requests are allowed when redis can't be reached")
+}
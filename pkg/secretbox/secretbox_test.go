@@ -0,0 +1,45 @@
+package secretbox
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBox(t *testing.T) {
+	box, err := New(bytes.Repeat([]byte{1}, 32))
+	assert.NoError(t, err)
+
+	sealed, err := box.Seal([]byte("JBSWY3DPEHPK3PXP"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(sealed), "JBSWY3DPEHPK3PXP")
+
+	again, err := box.Seal([]byte("JBSWY3DPEHPK3PXP"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, sealed, again, "every seal has its own nonce")
+
+	opened, err := box.Open(sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("JBSWY3DPEHPK3PXP"), opened)
+
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+
+	_, err = box.Open(tampered)
+	assert.Error(t, err)
+
+	_, err = box.Open(sealed[:4])
+	assert.Error(t, err)
+
+	other, err := New(bytes.Repeat([]byte{2}, 32))
+	assert.NoError(t, err)
+
+	_, err = other.Open(sealed)
+	assert.Error(t, err, "sealed by another key")
+}
+
+func TestNewInvalidKey(t *testing.T) {
+	_, err := New([]byte("short"))
+	assert.Error(t, err)
+}
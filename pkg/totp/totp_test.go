@@ -0,0 +1,49 @@
+package totp
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// test vectors of RFC 6238 appendix B, SHA1 with 6 digits
+func TestCode(t *testing.T) {
+	secret := encoding.EncodeToString([]byte("12345678901234567890"))
+
+	type TC struct {
+		unix int64
+		want string
+	}
+
+	tcs := []TC{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for i, tc := range tcs {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			got, err := Code(secret, time.Unix(tc.unix, 0))
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	assert.NoError(t, err)
+
+	now := time.Now()
+
+	previous, _ := Code(secret, now.Add(-30*time.Second))
+	stale, _ := Code(secret, now.Add(-90*time.Second))
+
+	assert.True(t, Validate(secret, previous, now))
+	assert.False(t, Validate(secret, stale, now))
+	assert.False(t, Validate(secret, "12345", now))
+}
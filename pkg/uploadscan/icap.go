@@ -0,0 +1,115 @@
+package uploadscan
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+// ICAP sends files to an ICAP service with RESPMOD, e.g. icap://host:1344/avscan
+type ICAP struct {
+	url *url.URL
+}
+
+func NewICAP(rawURL string) (*ICAP, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "icap" {
+		return nil, fmt.Errorf("invalid icap url %q", rawURL)
+	}
+
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), "1344")
+	}
+
+	return &ICAP{url: u}, nil
+}
+
+func (s *ICAP) Scan(ctx context.Context, r io.Reader) error {
+	var d net.Dialer
+
+	conn, err := d.DialContext(ctx, "tcp", s.url.Host)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	resHdr := "HTTP/1.1 200 OK\r\nContent-Type: text/csv\r\n\r\n"
+
+	w := bufio.NewWriter(conn)
+
+	fmt.Fprintf(w, "RESPMOD %s ICAP/1.0\r\n", s.url.String())
+	fmt.Fprintf(w, "Host: %s\r\n", s.url.Hostname())
+	fmt.Fprintf(w, "Allow: 204\r\n")
+	fmt.Fprintf(w, "Encapsulated: res-hdr=0, res-body=%d\r\n\r\n", len(resHdr))
+	w.WriteString(resHdr)
+
+	buf := make([]byte, 32*1024)
+
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			fmt.Fprintf(w, "%x\r\n", n)
+			w.Write(buf[:n])
+			w.WriteString("\r\n")
+		}
+
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	w.WriteString("0\r\n\r\n")
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	tp := textproto.NewReader(bufio.NewReader(conn))
+
+	status, err := tp.ReadLine()
+	if err != nil {
+		return err
+	}
+
+	header, err := tp.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	parts := strings.SplitN(status, " ", 3)
+	if len(parts) < 2 {
+		return fmt.Errorf("unexpected icap reply: %s", status)
+	}
+
+	switch parts[1] {
+	case "204":
+		return nil
+	case "200":
+		// the service modified or blocked the content
+		reason := header.Get("X-Infection-Found")
+		if reason == "" {
+			reason = header.Get("X-Violations-Found")
+		}
+
+		return fmt.Errorf("%w: %s", ErrInfected, reason)
+	default:
+		return fmt.Errorf("unexpected icap reply: %s", status)
+	}
+}
@@ -0,0 +1,34 @@
+package uploadscan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var ErrInfected = errors.New("uploaded file was rejected by scanner")
+
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) error
+}
+
+// New returns a scanner by kind, empty kind means no scanning.
+func New(kind string, addr string) (Scanner, error) {
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "":
+		return nil, nil
+	case "clamav":
+		return NewClamAV(addr), nil
+	case "icap":
+		s, err := NewICAP(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unknown upload scanner %q", kind)
+	}
+}
@@ -0,0 +1,4 @@
+// Package proto holds protobuf definitions of the gRPC API, code generated from them is committed
+package proto
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative --grpc-gateway_out=. --grpc-gateway_opt=paths=source_relative,grpc_api_configuration=tax/v1/tax_gateway.yaml tax/v1/tax.proto
@@ -0,0 +1,1284 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        v5.27.1
+// source: tax/v1/tax.proto
+
+package taxv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Allowance struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	AllowanceType string  `protobuf:"bytes,1,opt,name=allowance_type,json=allowanceType,proto3" json:"allowance_type,omitempty"`
+	Amount        float64 `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
+}
+
+func (x *Allowance) Reset() {
+	*x = Allowance{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tax_v1_tax_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Allowance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Allowance) ProtoMessage() {}
+
+func (x *Allowance) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Allowance.ProtoReflect.Descriptor instead.
+func (*Allowance) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Allowance) GetAllowanceType() string {
+	if x != nil {
+		return x.AllowanceType
+	}
+	return ""
+}
+
+func (x *Allowance) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+// TaxRequest is also body of POST /tax/calculations encoded as protobuf
+type TaxRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TotalIncome float64      `protobuf:"fixed64,1,opt,name=total_income,json=totalIncome,proto3" json:"total_income,omitempty"`
+	Wht         float64      `protobuf:"fixed64,2,opt,name=wht,proto3" json:"wht,omitempty"`
+	Allowances  []*Allowance `protobuf:"bytes,3,rep,name=allowances,proto3" json:"allowances,omitempty"`
+}
+
+func (x *TaxRequest) Reset() {
+	*x = TaxRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tax_v1_tax_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TaxRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaxRequest) ProtoMessage() {}
+
+func (x *TaxRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaxRequest.ProtoReflect.Descriptor instead.
+func (*TaxRequest) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TaxRequest) GetTotalIncome() float64 {
+	if x != nil {
+		return x.TotalIncome
+	}
+	return 0
+}
+
+func (x *TaxRequest) GetWht() float64 {
+	if x != nil {
+		return x.Wht
+	}
+	return 0
+}
+
+func (x *TaxRequest) GetAllowances() []*Allowance {
+	if x != nil {
+		return x.Allowances
+	}
+	return nil
+}
+
+type CalculateTaxRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Request *TaxRequest `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	// defaults to 2024
+	TaxYear int32 `protobuf:"varint,2,opt,name=tax_year,json=taxYear,proto3" json:"tax_year,omitempty"`
+	// date of allowances as YYYY-MM-DD, defaults to the last day of tax_year when it's set, or today
+	Date string `protobuf:"bytes,3,opt,name=date,proto3" json:"date,omitempty"`
+	// en or th, or a list like Accept-Language header, defaults to th
+	Language string `protobuf:"bytes,4,opt,name=language,proto3" json:"language,omitempty"`
+}
+
+func (x *CalculateTaxRequest) Reset() {
+	*x = CalculateTaxRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tax_v1_tax_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CalculateTaxRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CalculateTaxRequest) ProtoMessage() {}
+
+func (x *CalculateTaxRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CalculateTaxRequest.ProtoReflect.Descriptor instead.
+func (*CalculateTaxRequest) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CalculateTaxRequest) GetRequest() *TaxRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *CalculateTaxRequest) GetTaxYear() int32 {
+	if x != nil {
+		return x.TaxYear
+	}
+	return 0
+}
+
+func (x *CalculateTaxRequest) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *CalculateTaxRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+type TaxLevel struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Level string  `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`
+	Tax   float64 `protobuf:"fixed64,2,opt,name=tax,proto3" json:"tax,omitempty"`
+}
+
+func (x *TaxLevel) Reset() {
+	*x = TaxLevel{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tax_v1_tax_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TaxLevel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaxLevel) ProtoMessage() {}
+
+func (x *TaxLevel) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaxLevel.ProtoReflect.Descriptor instead.
+func (*TaxLevel) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TaxLevel) GetLevel() string {
+	if x != nil {
+		return x.Level
+	}
+	return ""
+}
+
+func (x *TaxLevel) GetTax() float64 {
+	if x != nil {
+		return x.Tax
+	}
+	return 0
+}
+
+// TaxResponse is also response of POST /tax/calculations encoded as protobuf
+type TaxResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Tax       float64     `protobuf:"fixed64,1,opt,name=tax,proto3" json:"tax,omitempty"`
+	TaxRefund float64     `protobuf:"fixed64,2,opt,name=tax_refund,json=taxRefund,proto3" json:"tax_refund,omitempty"`
+	TaxLevel  []*TaxLevel `protobuf:"bytes,3,rep,name=tax_level,json=taxLevel,proto3" json:"tax_level,omitempty"`
+	Notices   []string    `protobuf:"bytes,4,rep,name=notices,proto3" json:"notices,omitempty"`
+}
+
+func (x *TaxResponse) Reset() {
+	*x = TaxResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tax_v1_tax_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TaxResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaxResponse) ProtoMessage() {}
+
+func (x *TaxResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaxResponse.ProtoReflect.Descriptor instead.
+func (*TaxResponse) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *TaxResponse) GetTax() float64 {
+	if x != nil {
+		return x.Tax
+	}
+	return 0
+}
+
+func (x *TaxResponse) GetTaxRefund() float64 {
+	if x != nil {
+		return x.TaxRefund
+	}
+	return 0
+}
+
+func (x *TaxResponse) GetTaxLevel() []*TaxLevel {
+	if x != nil {
+		return x.TaxLevel
+	}
+	return nil
+}
+
+func (x *TaxResponse) GetNotices() []string {
+	if x != nil {
+		return x.Notices
+	}
+	return nil
+}
+
+type CalculateTaxResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Response *TaxResponse `protobuf:"bytes,1,opt,name=response,proto3" json:"response,omitempty"`
+	// calculated with compiled-in settings since database can't be read
+	Degraded bool `protobuf:"varint,2,opt,name=degraded,proto3" json:"degraded,omitempty"`
+}
+
+func (x *CalculateTaxResponse) Reset() {
+	*x = CalculateTaxResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tax_v1_tax_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CalculateTaxResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CalculateTaxResponse) ProtoMessage() {}
+
+func (x *CalculateTaxResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CalculateTaxResponse.ProtoReflect.Descriptor instead.
+func (*CalculateTaxResponse) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *CalculateTaxResponse) GetResponse() *TaxResponse {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *CalculateTaxResponse) GetDegraded() bool {
+	if x != nil {
+		return x.Degraded
+	}
+	return false
+}
+
+// TaxCSVResponse is response of POST /tax/calculations/upload-csv encoded as protobuf
+type TaxCSVResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Taxes []*TaxCSV `protobuf:"bytes,1,rep,name=taxes,proto3" json:"taxes,omitempty"`
+}
+
+func (x *TaxCSVResponse) Reset() {
+	*x = TaxCSVResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tax_v1_tax_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TaxCSVResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaxCSVResponse) ProtoMessage() {}
+
+func (x *TaxCSVResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaxCSVResponse.ProtoReflect.Descriptor instead.
+func (*TaxCSVResponse) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *TaxCSVResponse) GetTaxes() []*TaxCSV {
+	if x != nil {
+		return x.Taxes
+	}
+	return nil
+}
+
+type TaxCSV struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	TotalIncome float64 `protobuf:"fixed64,1,opt,name=total_income,json=totalIncome,proto3" json:"total_income,omitempty"`
+	Tax         float64 `protobuf:"fixed64,2,opt,name=tax,proto3" json:"tax,omitempty"`
+}
+
+func (x *TaxCSV) Reset() {
+	*x = TaxCSV{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tax_v1_tax_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *TaxCSV) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaxCSV) ProtoMessage() {}
+
+func (x *TaxCSV) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaxCSV.ProtoReflect.Descriptor instead.
+func (*TaxCSV) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TaxCSV) GetTotalIncome() float64 {
+	if x != nil {
+		return x.TotalIncome
+	}
+	return 0
+}
+
+func (x *TaxCSV) GetTax() float64 {
+	if x != nil {
+		return x.Tax
+	}
+	return 0
+}
+
+type GetDeductionsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *GetDeductionsRequest) Reset() {
+	*x = GetDeductionsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tax_v1_tax_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetDeductionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeductionsRequest) ProtoMessage() {}
+
+func (x *GetDeductionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeductionsRequest.ProtoReflect.Descriptor instead.
+func (*GetDeductionsRequest) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{8}
+}
+
+// DefaultAllowance is deducted from income of every taxpayer
+type DefaultAllowance struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	AllowanceType string  `protobuf:"bytes,1,opt,name=allowance_type,json=allowanceType,proto3" json:"allowance_type,omitempty"`
+	Amount        float64 `protobuf:"fixed64,2,opt,name=amount,proto3" json:"amount,omitempty"`
+}
+
+func (x *DefaultAllowance) Reset() {
+	*x = DefaultAllowance{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tax_v1_tax_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DefaultAllowance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DefaultAllowance) ProtoMessage() {}
+
+func (x *DefaultAllowance) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DefaultAllowance.ProtoReflect.Descriptor instead.
+func (*DefaultAllowance) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DefaultAllowance) GetAllowanceType() string {
+	if x != nil {
+		return x.AllowanceType
+	}
+	return ""
+}
+
+func (x *DefaultAllowance) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+// AllowedAllowance can be claimed up to max_amount
+type AllowedAllowance struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	AllowanceType string  `protobuf:"bytes,1,opt,name=allowance_type,json=allowanceType,proto3" json:"allowance_type,omitempty"`
+	MaxAmount     float64 `protobuf:"fixed64,2,opt,name=max_amount,json=maxAmount,proto3" json:"max_amount,omitempty"`
+}
+
+func (x *AllowedAllowance) Reset() {
+	*x = AllowedAllowance{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tax_v1_tax_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AllowedAllowance) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AllowedAllowance) ProtoMessage() {}
+
+func (x *AllowedAllowance) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AllowedAllowance.ProtoReflect.Descriptor instead.
+func (*AllowedAllowance) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *AllowedAllowance) GetAllowanceType() string {
+	if x != nil {
+		return x.AllowanceType
+	}
+	return ""
+}
+
+func (x *AllowedAllowance) GetMaxAmount() float64 {
+	if x != nil {
+		return x.MaxAmount
+	}
+	return 0
+}
+
+type GetDeductionsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DefaultAllowances []*DefaultAllowance `protobuf:"bytes,1,rep,name=default_allowances,json=defaultAllowances,proto3" json:"default_allowances,omitempty"`
+	AllowedAllowances []*AllowedAllowance `protobuf:"bytes,2,rep,name=allowed_allowances,json=allowedAllowances,proto3" json:"allowed_allowances,omitempty"`
+}
+
+func (x *GetDeductionsResponse) Reset() {
+	*x = GetDeductionsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tax_v1_tax_proto_msgTypes[11]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetDeductionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeductionsResponse) ProtoMessage() {}
+
+func (x *GetDeductionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[11]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeductionsResponse.ProtoReflect.Descriptor instead.
+func (*GetDeductionsResponse) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetDeductionsResponse) GetDefaultAllowances() []*DefaultAllowance {
+	if x != nil {
+		return x.DefaultAllowances
+	}
+	return nil
+}
+
+func (x *GetDeductionsResponse) GetAllowedAllowances() []*AllowedAllowance {
+	if x != nil {
+		return x.AllowedAllowances
+	}
+	return nil
+}
+
+// AdminSettingRequest is body of POST /admin/deductions/{personal,k-receipt,donation} encoded as protobuf
+type AdminSettingRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Amount float64 `protobuf:"fixed64,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	// projected by dry runs instead of the default sample incomes
+	SampleIncomes []float64 `protobuf:"fixed64,2,rep,packed,name=sample_incomes,json=sampleIncomes,proto3" json:"sample_incomes,omitempty"`
+}
+
+func (x *AdminSettingRequest) Reset() {
+	*x = AdminSettingRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tax_v1_tax_proto_msgTypes[12]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AdminSettingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdminSettingRequest) ProtoMessage() {}
+
+func (x *AdminSettingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[12]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdminSettingRequest.ProtoReflect.Descriptor instead.
+func (*AdminSettingRequest) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *AdminSettingRequest) GetAmount() float64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *AdminSettingRequest) GetSampleIncomes() []float64 {
+	if x != nil {
+		return x.SampleIncomes
+	}
+	return nil
+}
+
+// AdminSettingResponse is their response encoded as protobuf, only the changed setting is set.
+// Dry runs are responded as JSON
+type AdminSettingResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PersonalDeduction *float64 `protobuf:"fixed64,1,opt,name=personal_deduction,json=personalDeduction,proto3,oneof" json:"personal_deduction,omitempty"`
+	KReceipt          *float64 `protobuf:"fixed64,2,opt,name=k_receipt,json=kReceipt,proto3,oneof" json:"k_receipt,omitempty"`
+	Donation          *float64 `protobuf:"fixed64,3,opt,name=donation,proto3,oneof" json:"donation,omitempty"`
+}
+
+func (x *AdminSettingResponse) Reset() {
+	*x = AdminSettingResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tax_v1_tax_proto_msgTypes[13]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AdminSettingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdminSettingResponse) ProtoMessage() {}
+
+func (x *AdminSettingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[13]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdminSettingResponse.ProtoReflect.Descriptor instead.
+func (*AdminSettingResponse) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *AdminSettingResponse) GetPersonalDeduction() float64 {
+	if x != nil && x.PersonalDeduction != nil {
+		return *x.PersonalDeduction
+	}
+	return 0
+}
+
+func (x *AdminSettingResponse) GetKReceipt() float64 {
+	if x != nil && x.KReceipt != nil {
+		return *x.KReceipt
+	}
+	return 0
+}
+
+func (x *AdminSettingResponse) GetDonation() float64 {
+	if x != nil && x.Donation != nil {
+		return *x.Donation
+	}
+	return 0
+}
+
+// AdminDeductionsResponse is response of GET /admin/deductions encoded as protobuf,
+// uuids and timestamps of allowances are responded as JSON only
+type AdminDeductionsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	PersonalDeduction float64             `protobuf:"fixed64,1,opt,name=personal_deduction,json=personalDeduction,proto3" json:"personal_deduction,omitempty"`
+	KReceipt          float64             `protobuf:"fixed64,2,opt,name=k_receipt,json=kReceipt,proto3" json:"k_receipt,omitempty"`
+	DefaultAllowances []*DefaultAllowance `protobuf:"bytes,3,rep,name=default_allowances,json=defaultAllowances,proto3" json:"default_allowances,omitempty"`
+	AllowedAllowances []*AllowedAllowance `protobuf:"bytes,4,rep,name=allowed_allowances,json=allowedAllowances,proto3" json:"allowed_allowances,omitempty"`
+}
+
+func (x *AdminDeductionsResponse) Reset() {
+	*x = AdminDeductionsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tax_v1_tax_proto_msgTypes[14]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AdminDeductionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdminDeductionsResponse) ProtoMessage() {}
+
+func (x *AdminDeductionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tax_v1_tax_proto_msgTypes[14]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdminDeductionsResponse.ProtoReflect.Descriptor instead.
+func (*AdminDeductionsResponse) Descriptor() ([]byte, []int) {
+	return file_tax_v1_tax_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *AdminDeductionsResponse) GetPersonalDeduction() float64 {
+	if x != nil {
+		return x.PersonalDeduction
+	}
+	return 0
+}
+
+func (x *AdminDeductionsResponse) GetKReceipt() float64 {
+	if x != nil {
+		return x.KReceipt
+	}
+	return 0
+}
+
+func (x *AdminDeductionsResponse) GetDefaultAllowances() []*DefaultAllowance {
+	if x != nil {
+		return x.DefaultAllowances
+	}
+	return nil
+}
+
+func (x *AdminDeductionsResponse) GetAllowedAllowances() []*AllowedAllowance {
+	if x != nil {
+		return x.AllowedAllowances
+	}
+	return nil
+}
+
+var File_tax_v1_tax_proto protoreflect.FileDescriptor
+
+var file_tax_v1_tax_proto_rawDesc = []byte{
+	0x0a, 0x10, 0x74, 0x61, 0x78, 0x2f, 0x76, 0x31, 0x2f, 0x74, 0x61, 0x78, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x06, 0x74, 0x61, 0x78, 0x2e, 0x76, 0x31, 0x22, 0x4a, 0x0a, 0x09, 0x41, 0x6c,
+	0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x61, 0x6c, 0x6c, 0x6f, 0x77,
+	0x61, 0x6e, 0x63, 0x65, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x0d, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x16,
+	0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06,
+	0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0x74, 0x0a, 0x0a, 0x54, 0x61, 0x78, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x21, 0x0a, 0x0c, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x69, 0x6e,
+	0x63, 0x6f, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x74, 0x6f, 0x74, 0x61,
+	0x6c, 0x49, 0x6e, 0x63, 0x6f, 0x6d, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x77, 0x68, 0x74, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x77, 0x68, 0x74, 0x12, 0x31, 0x0a, 0x0a, 0x61, 0x6c, 0x6c,
+	0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x11, 0x2e,
+	0x74, 0x61, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65,
+	0x52, 0x0a, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x22, 0x8e, 0x01, 0x0a,
+	0x13, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x54, 0x61, 0x78, 0x52, 0x65, 0x71,
+	0x75, 0x65, 0x73, 0x74, 0x12, 0x2c, 0x0a, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x12, 0x2e, 0x74, 0x61, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x54,
+	0x61, 0x78, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x52, 0x07, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x12, 0x19, 0x0a, 0x08, 0x74, 0x61, 0x78, 0x5f, 0x79, 0x65, 0x61, 0x72, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x05, 0x52, 0x07, 0x74, 0x61, 0x78, 0x59, 0x65, 0x61, 0x72, 0x12, 0x12, 0x0a,
+	0x04, 0x64, 0x61, 0x74, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x64, 0x61, 0x74,
+	0x65, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x08, 0x6c, 0x61, 0x6e, 0x67, 0x75, 0x61, 0x67, 0x65, 0x22, 0x32, 0x0a,
+	0x08, 0x54, 0x61, 0x78, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x14, 0x0a, 0x05, 0x6c, 0x65, 0x76,
+	0x65, 0x6c, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x12,
+	0x10, 0x0a, 0x03, 0x74, 0x61, 0x78, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x03, 0x74, 0x61,
+	0x78, 0x22, 0x87, 0x01, 0x0a, 0x0b, 0x54, 0x61, 0x78, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x61, 0x78, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x03,
+	0x74, 0x61, 0x78, 0x12, 0x1d, 0x0a, 0x0a, 0x74, 0x61, 0x78, 0x5f, 0x72, 0x65, 0x66, 0x75, 0x6e,
+	0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x74, 0x61, 0x78, 0x52, 0x65, 0x66, 0x75,
+	0x6e, 0x64, 0x12, 0x2d, 0x0a, 0x09, 0x74, 0x61, 0x78, 0x5f, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x18,
+	0x03, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x10, 0x2e, 0x74, 0x61, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x54,
+	0x61, 0x78, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x52, 0x08, 0x74, 0x61, 0x78, 0x4c, 0x65, 0x76, 0x65,
+	0x6c, 0x12, 0x18, 0x0a, 0x07, 0x6e, 0x6f, 0x74, 0x69, 0x63, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03,
+	0x28, 0x09, 0x52, 0x07, 0x6e, 0x6f, 0x74, 0x69, 0x63, 0x65, 0x73, 0x22, 0x63, 0x0a, 0x14, 0x43,
+	0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x54, 0x61, 0x78, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x2f, 0x0a, 0x08, 0x72, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x13, 0x2e, 0x74, 0x61, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x54,
+	0x61, 0x78, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x52, 0x08, 0x72, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x64, 0x65, 0x67, 0x72, 0x61, 0x64, 0x65, 0x64,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x08, 0x52, 0x08, 0x64, 0x65, 0x67, 0x72, 0x61, 0x64, 0x65, 0x64,
+	0x22, 0x36, 0x0a, 0x0e, 0x54, 0x61, 0x78, 0x43, 0x53, 0x56, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x24, 0x0a, 0x05, 0x74, 0x61, 0x78, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28,
+	0x0b, 0x32, 0x0e, 0x2e, 0x74, 0x61, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x61, 0x78, 0x43, 0x53,
+	0x56, 0x52, 0x05, 0x74, 0x61, 0x78, 0x65, 0x73, 0x22, 0x3d, 0x0a, 0x06, 0x54, 0x61, 0x78, 0x43,
+	0x53, 0x56, 0x12, 0x21, 0x0a, 0x0c, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x5f, 0x69, 0x6e, 0x63, 0x6f,
+	0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x49,
+	0x6e, 0x63, 0x6f, 0x6d, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x74, 0x61, 0x78, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x03, 0x74, 0x61, 0x78, 0x22, 0x16, 0x0a, 0x14, 0x47, 0x65, 0x74, 0x44, 0x65,
+	0x64, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22,
+	0x51, 0x0a, 0x10, 0x44, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x61,
+	0x6e, 0x63, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65,
+	0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x61, 0x6c, 0x6c,
+	0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x61, 0x6d,
+	0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x06, 0x61, 0x6d, 0x6f, 0x75,
+	0x6e, 0x74, 0x22, 0x58, 0x0a, 0x10, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x41, 0x6c, 0x6c,
+	0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x12, 0x25, 0x0a, 0x0e, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x61,
+	0x6e, 0x63, 0x65, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d,
+	0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1d, 0x0a,
+	0x0a, 0x6d, 0x61, 0x78, 0x5f, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x01, 0x52, 0x09, 0x6d, 0x61, 0x78, 0x41, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x22, 0xa9, 0x01, 0x0a,
+	0x15, 0x47, 0x65, 0x74, 0x44, 0x65, 0x64, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x47, 0x0a, 0x12, 0x64, 0x65, 0x66, 0x61, 0x75, 0x6c,
+	0x74, 0x5f, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x18, 0x2e, 0x74, 0x61, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x66, 0x61,
+	0x75, 0x6c, 0x74, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x11, 0x64, 0x65,
+	0x66, 0x61, 0x75, 0x6c, 0x74, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x12,
+	0x47, 0x0a, 0x12, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x5f, 0x61, 0x6c, 0x6c, 0x6f, 0x77,
+	0x61, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x74, 0x61,
+	0x78, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x41, 0x6c, 0x6c, 0x6f,
+	0x77, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x11, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x41, 0x6c,
+	0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x22, 0x54, 0x0a, 0x13, 0x41, 0x64, 0x6d, 0x69,
+	0x6e, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x16, 0x0a, 0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52,
+	0x06, 0x61, 0x6d, 0x6f, 0x75, 0x6e, 0x74, 0x12, 0x25, 0x0a, 0x0e, 0x73, 0x61, 0x6d, 0x70, 0x6c,
+	0x65, 0x5f, 0x69, 0x6e, 0x63, 0x6f, 0x6d, 0x65, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x01, 0x52,
+	0x0d, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x49, 0x6e, 0x63, 0x6f, 0x6d, 0x65, 0x73, 0x22, 0xbf,
+	0x01, 0x0a, 0x14, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x53, 0x65, 0x74, 0x74, 0x69, 0x6e, 0x67, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x32, 0x0a, 0x12, 0x70, 0x65, 0x72, 0x73, 0x6f,
+	0x6e, 0x61, 0x6c, 0x5f, 0x64, 0x65, 0x64, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x01, 0x48, 0x00, 0x52, 0x11, 0x70, 0x65, 0x72, 0x73, 0x6f, 0x6e, 0x61, 0x6c, 0x44,
+	0x65, 0x64, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x12, 0x20, 0x0a, 0x09, 0x6b,
+	0x5f, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x48, 0x01,
+	0x52, 0x08, 0x6b, 0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a,
+	0x08, 0x64, 0x6f, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x48,
+	0x02, 0x52, 0x08, 0x64, 0x6f, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x88, 0x01, 0x01, 0x42, 0x15,
+	0x0a, 0x13, 0x5f, 0x70, 0x65, 0x72, 0x73, 0x6f, 0x6e, 0x61, 0x6c, 0x5f, 0x64, 0x65, 0x64, 0x75,
+	0x63, 0x74, 0x69, 0x6f, 0x6e, 0x42, 0x0c, 0x0a, 0x0a, 0x5f, 0x6b, 0x5f, 0x72, 0x65, 0x63, 0x65,
+	0x69, 0x70, 0x74, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x64, 0x6f, 0x6e, 0x61, 0x74, 0x69, 0x6f, 0x6e,
+	0x22, 0xf7, 0x01, 0x0a, 0x17, 0x41, 0x64, 0x6d, 0x69, 0x6e, 0x44, 0x65, 0x64, 0x75, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2d, 0x0a, 0x12,
+	0x70, 0x65, 0x72, 0x73, 0x6f, 0x6e, 0x61, 0x6c, 0x5f, 0x64, 0x65, 0x64, 0x75, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x11, 0x70, 0x65, 0x72, 0x73, 0x6f, 0x6e,
+	0x61, 0x6c, 0x44, 0x65, 0x64, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1b, 0x0a, 0x09, 0x6b,
+	0x5f, 0x72, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08,
+	0x6b, 0x52, 0x65, 0x63, 0x65, 0x69, 0x70, 0x74, 0x12, 0x47, 0x0a, 0x12, 0x64, 0x65, 0x66, 0x61,
+	0x75, 0x6c, 0x74, 0x5f, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x03,
+	0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x74, 0x61, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65,
+	0x66, 0x61, 0x75, 0x6c, 0x74, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x11,
+	0x64, 0x65, 0x66, 0x61, 0x75, 0x6c, 0x74, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65,
+	0x73, 0x12, 0x47, 0x0a, 0x12, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x5f, 0x61, 0x6c, 0x6c,
+	0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e,
+	0x74, 0x61, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64, 0x41, 0x6c,
+	0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x52, 0x11, 0x61, 0x6c, 0x6c, 0x6f, 0x77, 0x65, 0x64,
+	0x41, 0x6c, 0x6c, 0x6f, 0x77, 0x61, 0x6e, 0x63, 0x65, 0x73, 0x32, 0xa5, 0x01, 0x0a, 0x0a, 0x54,
+	0x61, 0x78, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x49, 0x0a, 0x0c, 0x43, 0x61, 0x6c,
+	0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x54, 0x61, 0x78, 0x12, 0x1b, 0x2e, 0x74, 0x61, 0x78, 0x2e,
+	0x76, 0x31, 0x2e, 0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x54, 0x61, 0x78, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x74, 0x61, 0x78, 0x2e, 0x76, 0x31, 0x2e,
+	0x43, 0x61, 0x6c, 0x63, 0x75, 0x6c, 0x61, 0x74, 0x65, 0x54, 0x61, 0x78, 0x52, 0x65, 0x73, 0x70,
+	0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4c, 0x0a, 0x0d, 0x47, 0x65, 0x74, 0x44, 0x65, 0x64, 0x75, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1c, 0x2e, 0x74, 0x61, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x47,
+	0x65, 0x74, 0x44, 0x65, 0x64, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x74, 0x61, 0x78, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74,
+	0x44, 0x65, 0x64, 0x75, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x42, 0x3c, 0x5a, 0x3a, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x41, 0x6e, 0x6e, 0x61, 0x43, 0x61, 0x72, 0x74, 0x65, 0x72, 0x34, 0x36, 0x35, 0x2f, 0x61,
+	0x73, 0x73, 0x65, 0x73, 0x73, 0x6d, 0x65, 0x6e, 0x74, 0x2d, 0x74, 0x61, 0x78, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x2f, 0x74, 0x61, 0x78, 0x2f, 0x76, 0x31, 0x3b, 0x74, 0x61, 0x78, 0x76, 0x31,
+	0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_tax_v1_tax_proto_rawDescOnce sync.Once
+	file_tax_v1_tax_proto_rawDescData = file_tax_v1_tax_proto_rawDesc
+)
+
+func file_tax_v1_tax_proto_rawDescGZIP() []byte {
+	file_tax_v1_tax_proto_rawDescOnce.Do(func() {
+		file_tax_v1_tax_proto_rawDescData = protoimpl.X.CompressGZIP(file_tax_v1_tax_proto_rawDescData)
+	})
+	return file_tax_v1_tax_proto_rawDescData
+}
+
+var file_tax_v1_tax_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_tax_v1_tax_proto_goTypes = []any{
+	(*Allowance)(nil),               // 0: tax.v1.Allowance
+	(*TaxRequest)(nil),              // 1: tax.v1.TaxRequest
+	(*CalculateTaxRequest)(nil),     // 2: tax.v1.CalculateTaxRequest
+	(*TaxLevel)(nil),                // 3: tax.v1.TaxLevel
+	(*TaxResponse)(nil),             // 4: tax.v1.TaxResponse
+	(*CalculateTaxResponse)(nil),    // 5: tax.v1.CalculateTaxResponse
+	(*TaxCSVResponse)(nil),          // 6: tax.v1.TaxCSVResponse
+	(*TaxCSV)(nil),                  // 7: tax.v1.TaxCSV
+	(*GetDeductionsRequest)(nil),    // 8: tax.v1.GetDeductionsRequest
+	(*DefaultAllowance)(nil),        // 9: tax.v1.DefaultAllowance
+	(*AllowedAllowance)(nil),        // 10: tax.v1.AllowedAllowance
+	(*GetDeductionsResponse)(nil),   // 11: tax.v1.GetDeductionsResponse
+	(*AdminSettingRequest)(nil),     // 12: tax.v1.AdminSettingRequest
+	(*AdminSettingResponse)(nil),    // 13: tax.v1.AdminSettingResponse
+	(*AdminDeductionsResponse)(nil), // 14: tax.v1.AdminDeductionsResponse
+}
+var file_tax_v1_tax_proto_depIdxs = []int32{
+	0,  // 0: tax.v1.TaxRequest.allowances:type_name -> tax.v1.Allowance
+	1,  // 1: tax.v1.CalculateTaxRequest.request:type_name -> tax.v1.TaxRequest
+	3,  // 2: tax.v1.TaxResponse.tax_level:type_name -> tax.v1.TaxLevel
+	4,  // 3: tax.v1.CalculateTaxResponse.response:type_name -> tax.v1.TaxResponse
+	7,  // 4: tax.v1.TaxCSVResponse.taxes:type_name -> tax.v1.TaxCSV
+	9,  // 5: tax.v1.GetDeductionsResponse.default_allowances:type_name -> tax.v1.DefaultAllowance
+	10, // 6: tax.v1.GetDeductionsResponse.allowed_allowances:type_name -> tax.v1.AllowedAllowance
+	9,  // 7: tax.v1.AdminDeductionsResponse.default_allowances:type_name -> tax.v1.DefaultAllowance
+	10, // 8: tax.v1.AdminDeductionsResponse.allowed_allowances:type_name -> tax.v1.AllowedAllowance
+	2,  // 9: tax.v1.TaxService.CalculateTax:input_type -> tax.v1.CalculateTaxRequest
+	8,  // 10: tax.v1.TaxService.GetDeductions:input_type -> tax.v1.GetDeductionsRequest
+	5,  // 11: tax.v1.TaxService.CalculateTax:output_type -> tax.v1.CalculateTaxResponse
+	11, // 12: tax.v1.TaxService.GetDeductions:output_type -> tax.v1.GetDeductionsResponse
+	11, // [11:13] is the sub-list for method output_type
+	9,  // [9:11] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
+}
+
+func init() { file_tax_v1_tax_proto_init() }
+func file_tax_v1_tax_proto_init() {
+	if File_tax_v1_tax_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_tax_v1_tax_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Allowance); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tax_v1_tax_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*TaxRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tax_v1_tax_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*CalculateTaxRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tax_v1_tax_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*TaxLevel); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tax_v1_tax_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*TaxResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tax_v1_tax_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*CalculateTaxResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tax_v1_tax_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*TaxCSVResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tax_v1_tax_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*TaxCSV); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tax_v1_tax_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*GetDeductionsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tax_v1_tax_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*DefaultAllowance); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tax_v1_tax_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*AllowedAllowance); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tax_v1_tax_proto_msgTypes[11].Exporter = func(v any, i int) any {
+			switch v := v.(*GetDeductionsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tax_v1_tax_proto_msgTypes[12].Exporter = func(v any, i int) any {
+			switch v := v.(*AdminSettingRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tax_v1_tax_proto_msgTypes[13].Exporter = func(v any, i int) any {
+			switch v := v.(*AdminSettingResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tax_v1_tax_proto_msgTypes[14].Exporter = func(v any, i int) any {
+			switch v := v.(*AdminDeductionsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_tax_v1_tax_proto_msgTypes[13].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_tax_v1_tax_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_tax_v1_tax_proto_goTypes,
+		DependencyIndexes: file_tax_v1_tax_proto_depIdxs,
+		MessageInfos:      file_tax_v1_tax_proto_msgTypes,
+	}.Build()
+	File_tax_v1_tax_proto = out.File
+	file_tax_v1_tax_proto_rawDesc = nil
+	file_tax_v1_tax_proto_goTypes = nil
+	file_tax_v1_tax_proto_depIdxs = nil
+}
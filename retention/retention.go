@@ -0,0 +1,77 @@
+package retention
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/metrics"
+)
+
+type IDB interface {
+	PurgeBefore(ctx context.Context, before time.Time) (map[string]int64, error)
+}
+
+// Cleaner purges rows older than the retention period, so tables written on every request don't grow unbounded
+type Cleaner struct {
+	db      IDB
+	period  time.Duration
+	metrics metrics.Metrics
+	paused  func() bool
+	now     func() time.Time
+}
+
+func NewCleaner(db IDB, period time.Duration) *Cleaner {
+	return &Cleaner{db: db, period: period, metrics: metrics.Noop{}, now: time.Now}
+}
+
+// SetMetrics sets backend counting rows deleted by table
+func (c *Cleaner) SetMetrics(m metrics.Metrics) *Cleaner {
+	c.metrics = m
+	return c
+}
+
+// SetPaused sets function reporting whether rows must not be deleted, e.g. during maintenance
+func (c *Cleaner) SetPaused(paused func() bool) *Cleaner {
+	c.paused = paused
+	return c
+}
+
+// Run purges expired rows every interval until ctx is done
+func (c *Cleaner) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		c.purge(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (c *Cleaner) purge(ctx context.Context) {
+	if c.paused != nil && c.paused() {
+		return
+	}
+
+	before := c.now().Add(-c.period)
+
+	deleted, err := c.db.PurgeBefore(ctx, before)
+
+	// tables purged before a failure are still counted
+	for table, n := range deleted {
+		c.metrics.IncCounter("retention_deleted_rows", float64(n), metrics.Labels{"table": table})
+
+		if n > 0 {
+			slog.InfoContext(ctx, "purged expired rows", "table", table, "rows", n, "before", before)
+		}
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to purge expired rows", "error", err)
+	}
+}
@@ -0,0 +1,79 @@
+package retention
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+)
+
+type purgerStub struct {
+	calls   []time.Time
+	deleted map[string]int64
+	err     error
+}
+
+func (p *purgerStub) PurgeBefore(ctx context.Context, before time.Time) (map[string]int64, error) {
+	p.calls = append(p.calls, before)
+	return p.deleted, p.err
+}
+
+func TestCleaner(t *testing.T) {
+	now := time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	type TC struct {
+		paused    bool
+		deleted   map[string]int64
+		err       error
+		wantCalls int
+		wantRows  string
+	}
+
+	tcs := []TC{
+		{
+			deleted:   map[string]int64{"calculation_history": 3, "api_key_usage": 0},
+			wantCalls: 1,
+			wantRows:  `retention_deleted_rows_total{table="calculation_history"} 3`,
+		},
+		{
+			deleted:   map[string]int64{"calculation_history": 2},
+			err:       errors.New("an error"),
+			wantCalls: 1,
+			wantRows:  `retention_deleted_rows_total{table="calculation_history"} 2`,
+		},
+		{
+			paused:    true,
+			wantCalls: 0,
+		},
+	}
+
+	for _, tc := range tcs {
+		db := &purgerStub{deleted: tc.deleted, err: tc.err}
+		p := metrics.NewPrometheus("")
+
+		c := NewCleaner(db, 30*24*time.Hour).
+			SetMetrics(p).
+			SetPaused(func() bool { return tc.paused })
+		c.now = func() time.Time { return now }
+
+		c.purge(context.Background())
+
+		assert.Len(t, db.calls, tc.wantCalls)
+
+		if tc.wantCalls == 0 {
+			continue
+		}
+
+		assert.Equal(t, now.AddDate(0, 0, -30), db.calls[0], "rows older than the period are purged")
+
+		rec := httptest.NewRecorder()
+		p.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+		assert.Contains(t, rec.Body.String(), tc.wantRows, "tables purged before a failure are still counted")
+	}
+}
@@ -0,0 +1,77 @@
+package schedule
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+)
+
+type IDB interface {
+	ApplyDueScheduledChanges(ctx context.Context, now time.Time) ([]database.ScheduledChange, error)
+}
+
+type Notifier interface {
+	SettingsChanged(setting string, value float64)
+}
+
+// Applier applies scheduled configuration changes when their activation time is reached
+type Applier struct {
+	db       IDB
+	notifier Notifier
+	paused   func() bool
+	now      func() time.Time
+}
+
+func NewApplier(db IDB, notifier Notifier) *Applier {
+	return &Applier{db: db, notifier: notifier, now: time.Now}
+}
+
+// SetClock sets clock which activation times are compared with
+func (a *Applier) SetClock(now func() time.Time) *Applier {
+	a.now = now
+	return a
+}
+
+// SetPaused sets function reporting whether changes must not be applied, e.g. during maintenance
+func (a *Applier) SetPaused(paused func() bool) *Applier {
+	a.paused = paused
+	return a
+}
+
+// Run applies due changes every interval until ctx is done
+func (a *Applier) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		a.apply(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (a *Applier) apply(ctx context.Context) {
+	if a.paused != nil && a.paused() {
+		return
+	}
+
+	changes, err := a.db.ApplyDueScheduledChanges(ctx, a.now())
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to apply scheduled changes", "error", err)
+		return
+	}
+
+	for _, c := range changes {
+		slog.InfoContext(ctx, "applied scheduled change", "scheduled_change_id", c.ID, "setting", c.AllowanceType, "value", c.Amount)
+
+		if a.notifier != nil {
+			a.notifier.SettingsChanged(c.AllowanceType, c.Amount)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package schedule
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/stretchr/testify/assert"
+)
+
+type notifierStub struct {
+	changed map[string]float64
+}
+
+func (n *notifierStub) SettingsChanged(setting string, value float64) {
+	n.changed[setting] = value
+}
+
+func TestApplier(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	store := database.NewMemory()
+
+	_, err := store.CreateScheduledChange(ctx, database.AllowanceKindAllowed, "k-receipt", 100_000, now)
+	assert.NoError(t, err)
+	_, err = store.CreateScheduledChange(ctx, database.AllowanceKindAllowed, "donation", 150_000, now.Add(time.Hour))
+	assert.NoError(t, err)
+
+	notifier := &notifierStub{changed: map[string]float64{}}
+	paused := true
+
+	a := NewApplier(store, notifier).SetClock(func() time.Time { return now }).SetPaused(func() bool { return paused })
+
+	a.apply(ctx)
+	assert.Empty(t, notifier.changed, "nothing is applied while paused")
+
+	paused = false
+
+	a.apply(ctx)
+	assert.Equal(t, map[string]float64{"k-receipt": 100_000}, notifier.changed, "changes which aren't due are left")
+
+	a.apply(ctx)
+	assert.Len(t, notifier.changed, 1, "applied changes aren't applied again")
+}
+
+func TestApplierRun(t *testing.T) {
+	store := database.NewMemory()
+	now := time.Now()
+
+	_, err := store.CreateScheduledChange(context.Background(), database.AllowanceKindDefault, "personal", 70_000, now)
+	assert.NoError(t, err)
+
+	notifier := &notifierStub{changed: map[string]float64{}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// changes are applied when Run starts, before the first tick
+	NewApplier(store, notifier).SetClock(func() time.Time { return now }).Run(ctx, time.Hour)
+
+	assert.Equal(t, map[string]float64{"personal": 70_000}, notifier.changed)
+}
@@ -0,0 +1,82 @@
+package seed
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+)
+
+// Seeder inserts reference data
+type Seeder interface {
+	SeedSettings(ctx context.Context, s database.SettingsImport) (int, error)
+}
+
+func ptr(v float64) *float64 {
+	return &v
+}
+
+// Settings is the standard reference data of a new environment
+var Settings = database.SettingsImport{
+	DefaultAllowances: []database.DefaultAllowance{
+		{AllowanceType: "personal", Amount: 60_000},
+	},
+	AllowedAllowances: []database.AllowedAllowance{
+		{AllowanceType: "donation", MaxAmount: 100_000},
+		{AllowanceType: "k-receipt", MaxAmount: 50_000},
+	},
+	Brackets: map[int][]database.TaxBracket{
+		2024: {
+			{Percentage: 0, MaxAmount: ptr(150_000), Label: "0-150,000", Labels: map[string]string{"en": "0-150,000"}},
+			{Percentage: 0.1, MaxAmount: ptr(500_000), Label: "150,001-500,000", Labels: map[string]string{"en": "150,001-500,000"}},
+			{Percentage: 0.15, MaxAmount: ptr(1_000_000), Label: "500,001-1,000,000", Labels: map[string]string{"en": "500,001-1,000,000"}},
+			{Percentage: 0.2, MaxAmount: ptr(2_000_000), Label: "1,000,001-2,000,000", Labels: map[string]string{"en": "1,000,001-2,000,000"}},
+			{Percentage: 0.35, Label: "2,000,001 ขึ้นไป", Labels: map[string]string{"en": "2,000,001 and above"}},
+		},
+	},
+}
+
+// Run inserts reference data into database of `DATABASE_URL` and returns process exit code,
+// tables must already exist (see initialdata/init.sql)
+func Run(args []string) int {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+
+	dbURL := fs.String("database-url", os.Getenv("DATABASE_URL"), "database to seed")
+	timeout := fs.Duration("timeout", 30*time.Second, "timeout of seeding")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *dbURL == "" {
+		fmt.Println("missing database url, set `DATABASE_URL` or -database-url")
+		return 2
+	}
+
+	db, err := database.NewDB(*dbURL, database.Config{ConnectTimeout: *timeout})
+	if err != nil {
+		fmt.Printf("cannot connect to database: %v\n", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	n, err := Seed(ctx, db)
+	if err != nil {
+		fmt.Printf("cannot seed database: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("seeded %d rows\n", n)
+
+	return 0
+}
+
+// Seed inserts Settings which don't exist yet
+func Seed(ctx context.Context, s Seeder) (int, error) {
+	return s.SeedSettings(ctx, Settings)
+}
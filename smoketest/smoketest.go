@@ -0,0 +1,198 @@
+package smoketest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+type check struct {
+	name string
+	run  func(c *client) error
+}
+
+type client struct {
+	baseURL  string
+	username string
+	password string
+	apiKey   string
+	http     *http.Client
+}
+
+// Run exercises critical paths of a running deployment and returns process exit code
+func Run(args []string) int {
+	fs := flag.NewFlagSet("smoketest", flag.ContinueOnError)
+
+	baseURL := fs.String("base-url", "http://localhost:8080", "base url of the deployment")
+	username := fs.String("admin-username", os.Getenv("ADMIN_USERNAME"), "admin username")
+	password := fs.String("admin-password", os.Getenv("ADMIN_PASSWORD"), "admin password")
+	apiKey := fs.String("api-key", os.Getenv("SMOKETEST_API_KEY"), "api key for tax endpoints")
+	timeout := fs.Duration("timeout", 10*time.Second, "timeout per request")
+
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	c := &client{
+		baseURL:  strings.TrimRight(*baseURL, "/"),
+		username: *username,
+		password: *password,
+		apiKey:   *apiKey,
+		http:     &http.Client{Timeout: *timeout},
+	}
+
+	checks := []check{
+		{"healthcheck", checkHealthcheck},
+		{"calculation", checkCalculation},
+		{"csv upload", checkCSVUpload},
+		{"admin validation", checkAdminValidation},
+	}
+
+	failed := 0
+
+	for _, ch := range checks {
+		if err := ch.run(c); err != nil {
+			failed++
+			fmt.Printf("FAIL %s: %v\n", ch.name, err)
+			continue
+		}
+
+		fmt.Printf("ok   %s\n", ch.name)
+	}
+
+	if failed > 0 {
+		fmt.Printf("%d of %d checks failed\n", failed, len(checks))
+		return 1
+	}
+
+	return 0
+}
+
+func (c *client) do(method, path, contentType string, body []byte, admin bool) (int, []byte, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if admin {
+		req.SetBasicAuth(c.username, c.password)
+	} else if c.apiKey != "" {
+		req.Header.Set("X-Api-Key", c.apiKey)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return res.StatusCode, b, nil
+}
+
+func expectStatus(got, want int, body []byte) error {
+	if got != want {
+		return fmt.Errorf("expected status %d, got %d: %s", want, got, body)
+	}
+
+	return nil
+}
+
+func checkHealthcheck(c *client) error {
+	code, body, err := c.do(http.MethodGet, "/", "", nil, false)
+	if err != nil {
+		return err
+	}
+
+	return expectStatus(code, http.StatusOK, body)
+}
+
+// income under the lowest bracket after any allowed personal deduction, so result does not depend on settings
+func checkCalculation(c *client) error {
+	code, body, err := c.do(http.MethodPost, "/tax/calculations", "application/json",
+		[]byte(`{"totalIncome":100000,"wht":5000,"allowances":[{"allowanceType":"donation","amount":0}]}`), false)
+	if err != nil {
+		return err
+	}
+
+	if err := expectStatus(code, http.StatusOK, body); err != nil {
+		return err
+	}
+
+	var got struct {
+		Tax       float64 `json:"tax"`
+		TaxRefund float64 `json:"taxRefund"`
+		TaxLevel  []struct {
+			Level string  `json:"level"`
+			Tax   float64 `json:"tax"`
+		} `json:"taxLevel"`
+	}
+
+	if err := json.Unmarshal(body, &got); err != nil {
+		return err
+	}
+
+	if got.Tax != 0 || got.TaxRefund != 5000 || len(got.TaxLevel) != 5 {
+		return fmt.Errorf("unexpected calculation result: %s", body)
+	}
+
+	return nil
+}
+
+func checkCSVUpload(c *client) error {
+	code, body, err := c.do(http.MethodPost, "/tax/calculations/upload-csv", "text/csv",
+		[]byte("totalIncome,wht,donation\n100000,2000,0\n"), false)
+	if err != nil {
+		return err
+	}
+
+	if err := expectStatus(code, http.StatusOK, body); err != nil {
+		return err
+	}
+
+	var got struct {
+		Taxes []struct {
+			TotalIncome float64 `json:"totalIncome"`
+			Tax         float64 `json:"tax"`
+		} `json:"taxes"`
+	}
+
+	if err := json.Unmarshal(body, &got); err != nil {
+		return err
+	}
+
+	if len(got.Taxes) != 1 || got.Taxes[0].TotalIncome != 100000 || got.Taxes[0].Tax != 0 {
+		return fmt.Errorf("unexpected csv result: %s", body)
+	}
+
+	return nil
+}
+
+// an out of range amount is rejected after authentication, so settings are never changed
+func checkAdminValidation(c *client) error {
+	if c.username == "" {
+		return fmt.Errorf("admin credentials are required")
+	}
+
+	code, body, err := c.do(http.MethodPost, "/admin/deductions/personal", "application/json",
+		[]byte(`{"amount":1}`), true)
+	if err != nil {
+		return err
+	}
+
+	return expectStatus(code, http.StatusBadRequest, body)
+}
@@ -0,0 +1,47 @@
+// Package corporate calculates corporate income tax of a company's net profit with the bracket walk of package tax
+package corporate
+
+import "github.com/AnnaCarter465/assessment-tax/tax"
+
+// schemes of corporate income tax
+const (
+	SchemeSME      = "sme"      // progressive rates of small and medium enterprises
+	SchemeStandard = "standard" // flat rate of every other company
+)
+
+// a company is an SME when both its paid-up capital and revenue are within these
+const (
+	smeMaxPaidUpCapital = 5_000_000
+	smeMaxRevenue       = 30_000_000
+)
+
+var rates = map[string][]tax.Rate{
+	SchemeSME: tax.LabelRates([]tax.Rate{
+		{Percentage: 0, Max: 300_000},
+		{Percentage: 0.15, Max: 3_000_000},
+		{Percentage: 0.2, Max: -1},
+	}),
+	SchemeStandard: tax.LabelRates([]tax.Rate{
+		{Percentage: 0.2, Max: -1},
+	}),
+}
+
+// SchemeOf returns the scheme a company is taxed by
+func SchemeOf(paidUpCapital float64, revenue float64) string {
+	if paidUpCapital <= smeMaxPaidUpCapital && revenue <= smeMaxRevenue {
+		return SchemeSME
+	}
+
+	return SchemeStandard
+}
+
+// NewTax returns tax of scheme, companies have no allowances so only net profit and wht are set.
+// ok is false when the scheme is unknown
+func NewTax(scheme string) (t *tax.Tax, ok bool) {
+	r, ok := rates[scheme]
+	if !ok {
+		return nil, false
+	}
+
+	return tax.NewTax(tax.TaxConfig{Rates: r}), true
+}
@@ -0,0 +1,63 @@
+package corporate
+
+import "testing"
+
+func TestSchemeOf(t *testing.T) {
+	type TC struct {
+		name          string
+		paidUpCapital float64
+		revenue       float64
+		expected      string
+	}
+
+	tcs := []TC{
+		{name: "within both limits", paidUpCapital: 5_000_000, revenue: 30_000_000, expected: SchemeSME},
+		{name: "paid-up capital over limit", paidUpCapital: 5_000_001, revenue: 1_000_000, expected: SchemeStandard},
+		{name: "revenue over limit", paidUpCapital: 1_000_000, revenue: 30_000_001, expected: SchemeStandard},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SchemeOf(tc.paidUpCapital, tc.revenue); got != tc.expected {
+				t.Errorf("expected %s, but got %s", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestNewTax(t *testing.T) {
+	type TC struct {
+		name           string
+		scheme         string
+		netProfit      float64
+		wht            float64
+		expectedTax    float64
+		expectedRefund float64
+	}
+
+	tcs := []TC{
+		{name: "sme exempt bracket", scheme: SchemeSME, netProfit: 200_000, expectedTax: 0},
+		{name: "sme second bracket", scheme: SchemeSME, netProfit: 1_000_000, expectedTax: 105_000},
+		{name: "sme with wht over tax", scheme: SchemeSME, netProfit: 1_000_000, wht: 150_000, expectedRefund: 45_000},
+		{name: "standard rate", scheme: SchemeStandard, netProfit: 1_000_000, expectedTax: 200_000},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			tx, ok := NewTax(tc.scheme)
+			if !ok {
+				t.Fatalf("expected scheme %s", tc.scheme)
+			}
+
+			summary := tx.SetIncome(tc.netProfit).SetWht(tc.wht).CalculateTaxSummary()
+
+			if summary.Tax != tc.expectedTax || summary.Refund != tc.expectedRefund {
+				t.Errorf("expected tax %v and refund %v, but got %v and %v", tc.expectedTax, tc.expectedRefund, summary.Tax, summary.Refund)
+			}
+		})
+	}
+
+	if _, ok := NewTax("cooperative"); ok {
+		t.Error("expected unknown scheme")
+	}
+}
@@ -0,0 +1,66 @@
+package tax
+
+import (
+	"strconv"
+	"strings"
+)
+
+// labels of the highest rate, the default label is Thai
+const (
+	aboveLabel   = "ขึ้นไป"
+	aboveLabelEn = "and above"
+)
+
+// LabelRates returns rates ordered from the lowest labelled by their range in Thai and English, e.g. "150,001-500,000"
+// and "2,000,001 ขึ้นไป" or "2,000,001 and above" for the highest one. A rate starts 1 baht above max of the rate
+// before it, labels of rates are replaced so they always match the brackets
+func LabelRates(rates []Rate) []Rate {
+	labelled := make([]Rate, 0, len(rates))
+
+	var from float64
+
+	for _, r := range rates {
+		if r.Max == -1 {
+			r.Label = formatAmount(from) + " " + aboveLabel
+			r.Labels = map[string]string{"en": formatAmount(from) + " " + aboveLabelEn}
+		} else {
+			label := formatAmount(from) + "-" + formatAmount(r.Max)
+
+			r.Label = label
+			r.Labels = map[string]string{"en": label}
+			from = r.Max + 1
+		}
+
+		labelled = append(labelled, r)
+	}
+
+	return labelled
+}
+
+// formatAmount formats amount with thousand separators, which are the same in Thai and English
+func formatAmount(amount float64) string {
+	s := strconv.FormatFloat(amount, 'f', -1, 64)
+
+	integer, fraction, hasFraction := strings.Cut(s, ".")
+
+	sign := ""
+	if strings.HasPrefix(integer, "-") {
+		sign, integer = "-", integer[1:]
+	}
+
+	var b strings.Builder
+
+	for i, d := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+
+		b.WriteRune(d)
+	}
+
+	if hasFraction {
+		return sign + b.String() + "." + fraction
+	}
+
+	return sign + b.String()
+}
@@ -0,0 +1,62 @@
+package tax
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLabelRates(t *testing.T) {
+	type TC struct {
+		name     string
+		rates    []Rate
+		expected []Rate
+	}
+
+	tcs := []TC{
+		{
+			name: "brackets of 2024",
+			rates: []Rate{
+				{Percentage: 0, Max: 150_000},
+				{Percentage: 0.1, Max: 500_000},
+				{Percentage: 0.35, Max: -1},
+			},
+			expected: []Rate{
+				{Percentage: 0, Max: 150_000, Label: "0-150,000", Labels: map[string]string{"en": "0-150,000"}},
+				{Percentage: 0.1, Max: 500_000, Label: "150,001-500,000", Labels: map[string]string{"en": "150,001-500,000"}},
+				{Percentage: 0.35, Max: -1, Label: "500,001 ขึ้นไป", Labels: map[string]string{"en": "500,001 and above"}},
+			},
+		},
+		{
+			name: "stale labels are replaced",
+			rates: []Rate{
+				{Percentage: 0, Max: 200_000, Label: "0-150,000", Labels: map[string]string{"en": "0-150,000"}},
+				{Percentage: 0.1, Max: -1, Label: "150,001 ขึ้นไป"},
+			},
+			expected: []Rate{
+				{Percentage: 0, Max: 200_000, Label: "0-200,000", Labels: map[string]string{"en": "0-200,000"}},
+				{Percentage: 0.1, Max: -1, Label: "200,001 ขึ้นไป", Labels: map[string]string{"en": "200,001 and above"}},
+			},
+		},
+		{
+			name: "amounts with fractions and above a million",
+			rates: []Rate{
+				{Percentage: 0, Max: 999.5},
+				{Percentage: 0.1, Max: 12_345_678},
+			},
+			expected: []Rate{
+				{Percentage: 0, Max: 999.5, Label: "0-999.5", Labels: map[string]string{"en": "0-999.5"}},
+				{Percentage: 0.1, Max: 12_345_678, Label: "1,000.5-12,345,678", Labels: map[string]string{"en": "1,000.5-12,345,678"}},
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got := LabelRates(tc.rates)
+
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Errorf("expected %v, but got %v", tc.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+// Package penalty calculates surcharges of tax paid after the filing deadline, amounts are rounded to satang
+package penalty
+
+import (
+	"math"
+	"time"
+)
+
+// SurchargeRate is the surcharge of Revenue Code section 27, per month or part of a month after the deadline
+const SurchargeRate = 0.015
+
+type Surcharge struct {
+	MonthsLate int
+	Amount     float64
+}
+
+// Calculate returns surcharge of taxDue paid on paidOn, the day after deadline starts the first month.
+// The surcharge doesn't exceed taxDue
+func Calculate(taxDue float64, deadline time.Time, paidOn time.Time) Surcharge {
+	var months int
+
+	for deadline.AddDate(0, months, 0).Before(paidOn) {
+		months++
+	}
+
+	amount := math.Min(taxDue, roundSatang(taxDue*SurchargeRate*float64(months)))
+
+	return Surcharge{MonthsLate: months, Amount: amount}
+}
+
+// roundSatang rounds amount to satang, half a satang is rounded up
+func roundSatang(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,64 @@
+package penalty
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculate(t *testing.T) {
+	type TC struct {
+		name     string
+		taxDue   float64
+		paidOn   time.Time
+		expected Surcharge
+	}
+
+	deadline := time.Date(2025, time.April, 8, 0, 0, 0, 0, time.UTC)
+
+	tcs := []TC{
+		{
+			name:     "paid on the deadline",
+			taxDue:   10_000,
+			paidOn:   deadline,
+			expected: Surcharge{},
+		},
+		{
+			name:     "a day late is a month",
+			taxDue:   10_000,
+			paidOn:   deadline.AddDate(0, 0, 1),
+			expected: Surcharge{MonthsLate: 1, Amount: 150},
+		},
+		{
+			name:     "a whole month",
+			taxDue:   10_000,
+			paidOn:   deadline.AddDate(0, 1, 0),
+			expected: Surcharge{MonthsLate: 1, Amount: 150},
+		},
+		{
+			name:     "part of the next month",
+			taxDue:   10_000,
+			paidOn:   deadline.AddDate(0, 1, 1),
+			expected: Surcharge{MonthsLate: 2, Amount: 300},
+		},
+		{
+			name:     "rounded to satang",
+			taxDue:   333.33,
+			paidOn:   deadline.AddDate(0, 0, 1),
+			expected: Surcharge{MonthsLate: 1, Amount: 5},
+		},
+		{
+			name:     "capped at the tax",
+			taxDue:   10_000,
+			paidOn:   deadline.AddDate(6, 0, 0),
+			expected: Surcharge{MonthsLate: 72, Amount: 10_000},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Calculate(tc.taxDue, deadline, tc.paidOn))
+		})
+	}
+}
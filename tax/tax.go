@@ -4,7 +4,18 @@ type Rate struct {
 	Percentage float64
 	Max        float64
 	Label      string
+	Labels     map[string]string // translated labels by language
 }
+
+// LabelFor returns label in lang, fallback to default label when there is no translation
+func (r Rate) LabelFor(lang string) string {
+	if label, ok := r.Labels[lang]; ok {
+		return label
+	}
+
+	return r.Label
+}
+
 type Allowances map[string]float64
 
 type TaxConfig struct {
@@ -37,6 +48,7 @@ func (t *Tax) SetWht(wht float64) *Tax {
 	return t
 }
 
+// AddAllowance claims amount of an allowance type, it replaces the amount claimed before for the type
 func (t *Tax) AddAllowance(allowanceType string, amount float64) *Tax {
 	t.allowances[allowanceType] = amount
 	return t
@@ -50,30 +62,30 @@ func (t *Tax) calculateTotalAllowance() float64 {
 	}
 
 	for allowanceType, allowanceAmount := range t.allowances {
-		// check if allowances input is duplicated with default allowance, we should ignore it.
-		_, ok := t.taxConf.DefaultAllowances[allowanceType]
-
-		if ok {
-			continue
-		}
-
-		// check if provided allowances are allowed and they shouldn't go over max amount
-		maxAmount, ok := t.taxConf.AllowedAllowances[allowanceType]
+		totalAllowance += t.deductedAmount(allowanceType, allowanceAmount)
+	}
 
-		if !ok {
-			continue
-		}
+	return totalAllowance
+}
 
-		amount := allowanceAmount
+// deductedAmount returns how much of a provided allowance is deducted from income
+func (t *Tax) deductedAmount(allowanceType string, amount float64) float64 {
+	// check if allowances input is duplicated with default allowance, we should ignore it.
+	if _, ok := t.taxConf.DefaultAllowances[allowanceType]; ok {
+		return 0
+	}
 
-		if amount > maxAmount {
-			amount = maxAmount
-		}
+	// check if provided allowances are allowed and they shouldn't go over max amount
+	maxAmount, ok := t.taxConf.AllowedAllowances[allowanceType]
+	if !ok {
+		return 0
+	}
 
-		totalAllowance += amount
+	if amount > maxAmount {
+		return maxAmount
 	}
 
-	return totalAllowance
+	return amount
 }
 
 type TaxStatement struct {
@@ -134,8 +146,24 @@ type TaxSummary struct {
 }
 
 func (t *Tax) CalculateTaxSummary() TaxSummary {
+	return t.summarize(t.income - t.calculateTotalAllowance())
+}
+
+// CalculateTaxSummariesWithout returns summary of the tax as if each provided allowance were omitted, by allowance type.
+// Allowances are totalled once, so each summary only adds back what one allowance deducts
+func (t *Tax) CalculateTaxSummariesWithout() map[string]TaxSummary {
 	netIncome := t.income - t.calculateTotalAllowance()
 
+	summaries := make(map[string]TaxSummary, len(t.allowances))
+
+	for allowanceType, allowanceAmount := range t.allowances {
+		summaries[allowanceType] = t.summarize(netIncome + t.deductedAmount(allowanceType, allowanceAmount))
+	}
+
+	return summaries
+}
+
+func (t *Tax) summarize(netIncome float64) TaxSummary {
 	statements := t.calculateTaxStatement(netIncome)
 
 	if netIncome <= 0 {
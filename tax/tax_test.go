@@ -1,7 +1,11 @@
 package tax
 
 import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -309,3 +313,67 @@ func TestCalculateTax(t *testing.T) {
 		})
 	}
 }
+
+func TestCalculateTaxSummariesWithout(t *testing.T) {
+	taxer := NewTax(TaxConfig{
+		Rates: []Rate{
+			{Percentage: 0, Max: 150_000},
+			{Percentage: 0.1, Max: 500_000},
+			{Percentage: 0.15, Max: 1_000_000},
+			{Percentage: 0.2, Max: 2_000_000},
+			{Percentage: 0.35, Max: -1},
+		},
+		DefaultAllowances: Allowances{"personal": 60_000},
+		AllowedAllowances: Allowances{"donation": 100_000, "k-receipt": 50_000},
+	}).SetIncome(500_000).SetWht(20_000)
+
+	taxer.AddAllowance("donation", 100_000)
+	taxer.AddAllowance("k-receipt", 80_000)
+	taxer.AddAllowance("something", 1_000)
+
+	// net income is 290,000 with every allowance, tax is 14,000 before wht
+	want := map[string]TaxSummary{
+		"donation":  {Tax: 4_000},
+		"k-receipt": {Tax: 0, Refund: 1_000},
+		"something": {Tax: 0, Refund: 6_000},
+	}
+
+	got := taxer.CalculateTaxSummariesWithout()
+
+	if len(got) != len(want) {
+		t.Fatalf("Wrong summaries expected %v, but got %v", want, got)
+	}
+
+	for allowanceType, w := range want {
+		if got[allowanceType].Tax != w.Tax || got[allowanceType].Refund != w.Refund {
+			t.Errorf("Wrong summary without %s expected tax %v refund %v, but got tax %v refund %v",
+				allowanceType, w.Tax, w.Refund, got[allowanceType].Tax, got[allowanceType].Refund)
+		}
+	}
+}
+
+// tax is built to WebAssembly for front-end previews, so it must not import packages of the server
+func TestImportsOnlyStandardLibrary(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+
+		f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ImportsOnly)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, imp := range f.Imports {
+			path := strings.Trim(imp.Path.Value, `"`)
+			if strings.Contains(strings.Split(path, "/")[0], ".") {
+				t.Errorf("%s imports %s, only standard library can be imported", file, path)
+			}
+		}
+	}
+}
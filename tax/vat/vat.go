@@ -0,0 +1,94 @@
+// Package vat calculates value added tax of invoices, amounts are rounded to satang
+package vat
+
+import "math"
+
+// pricings of items, whether their prices include VAT
+const (
+	PricingExclusive = "exclusive"
+	PricingInclusive = "inclusive"
+)
+
+// roundings of VAT, either of every line or of the invoice total
+const (
+	RoundingInvoice = "invoice"
+	RoundingItem    = "item"
+)
+
+type Item struct {
+	Description string
+	Quantity    float64
+	UnitPrice   float64
+}
+
+// Line is VAT of an item rounded to satang, lines don't add up to VAT of the invoice when it's rounded per invoice
+type Line struct {
+	Item
+	Net   float64
+	VAT   float64
+	Gross float64
+}
+
+type Invoice struct {
+	Lines []Line
+	Net   float64
+	VAT   float64
+	Gross float64
+}
+
+// Calculate returns VAT of items at rate, e.g. 0.07. Prices of items are net of VAT when pricing is exclusive and
+// gross when it's inclusive. VAT rounded per item is the sum of VAT of lines, per invoice it's the sum of VAT of
+// items before rounding, so they may differ by a few satang
+func Calculate(items []Item, rate float64, pricing string, rounding string) Invoice {
+	var inv Invoice
+
+	var amount, vat float64
+
+	for _, item := range items {
+		lineAmount := roundSatang(item.Quantity * item.UnitPrice)
+		lineVAT := vatOf(lineAmount, rate, pricing)
+
+		if rounding == RoundingItem {
+			lineVAT = roundSatang(lineVAT)
+		}
+
+		inv.Lines = append(inv.Lines, newLine(item, lineAmount, roundSatang(lineVAT), pricing))
+
+		amount += lineAmount
+		vat += lineVAT
+	}
+
+	inv.VAT = roundSatang(vat)
+
+	if pricing == PricingInclusive {
+		inv.Gross = roundSatang(amount)
+		inv.Net = roundSatang(inv.Gross - inv.VAT)
+	} else {
+		inv.Net = roundSatang(amount)
+		inv.Gross = roundSatang(inv.Net + inv.VAT)
+	}
+
+	return inv
+}
+
+// vatOf returns VAT of amount, which is part of amount when pricing is inclusive
+func vatOf(amount float64, rate float64, pricing string) float64 {
+	if pricing == PricingInclusive {
+		return amount * rate / (1 + rate)
+	}
+
+	return amount * rate
+}
+
+func newLine(item Item, amount float64, vat float64, pricing string) Line {
+	if pricing == PricingInclusive {
+		return Line{Item: item, Net: roundSatang(amount - vat), VAT: vat, Gross: amount}
+	}
+
+	return Line{Item: item, Net: amount, VAT: vat, Gross: roundSatang(amount + vat)}
+}
+
+// roundSatang rounds amount to satang, half a satang is rounded up
+func roundSatang(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,92 @@
+package vat
+
+import "testing"
+
+func TestCalculate(t *testing.T) {
+	type TC struct {
+		name          string
+		items         []Item
+		pricing       string
+		rounding      string
+		expectedNet   float64
+		expectedVAT   float64
+		expectedGross float64
+		expectedLines []float64 // VAT of each line
+	}
+
+	cents := []Item{{Quantity: 1, UnitPrice: 0.1}, {Quantity: 1, UnitPrice: 0.1}, {Quantity: 1, UnitPrice: 0.1}}
+
+	tcs := []TC{
+		{
+			name:          "exclusive price",
+			items:         []Item{{Description: "pen", Quantity: 3, UnitPrice: 33.33}},
+			pricing:       PricingExclusive,
+			rounding:      RoundingInvoice,
+			expectedNet:   99.99,
+			expectedVAT:   7,
+			expectedGross: 106.99,
+			expectedLines: []float64{7},
+		},
+		{
+			name:          "inclusive price",
+			items:         []Item{{Description: "book", Quantity: 2, UnitPrice: 53.5}},
+			pricing:       PricingInclusive,
+			rounding:      RoundingInvoice,
+			expectedNet:   100,
+			expectedVAT:   7,
+			expectedGross: 107,
+			expectedLines: []float64{7},
+		},
+		{
+			name:          "rounded per invoice",
+			items:         cents,
+			pricing:       PricingExclusive,
+			rounding:      RoundingInvoice,
+			expectedNet:   0.3,
+			expectedVAT:   0.02,
+			expectedGross: 0.32,
+			expectedLines: []float64{0.01, 0.01, 0.01},
+		},
+		{
+			name:          "rounded per item",
+			items:         cents,
+			pricing:       PricingExclusive,
+			rounding:      RoundingItem,
+			expectedNet:   0.3,
+			expectedVAT:   0.03,
+			expectedGross: 0.33,
+			expectedLines: []float64{0.01, 0.01, 0.01},
+		},
+		{
+			name:          "inclusive price rounded per item",
+			items:         []Item{{Quantity: 1, UnitPrice: 10}, {Quantity: 1, UnitPrice: 10}},
+			pricing:       PricingInclusive,
+			rounding:      RoundingItem,
+			expectedNet:   18.7,
+			expectedVAT:   1.3,
+			expectedGross: 20,
+			expectedLines: []float64{0.65, 0.65},
+		},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			inv := Calculate(tc.items, 0.07, tc.pricing, tc.rounding)
+
+			if inv.Net != tc.expectedNet || inv.VAT != tc.expectedVAT || inv.Gross != tc.expectedGross {
+				t.Errorf("expected net %v, vat %v and gross %v, but got %v, %v and %v",
+					tc.expectedNet, tc.expectedVAT, tc.expectedGross, inv.Net, inv.VAT, inv.Gross)
+			}
+
+			if len(inv.Lines) != len(tc.expectedLines) {
+				t.Fatalf("expected %d lines, but got %d", len(tc.expectedLines), len(inv.Lines))
+			}
+
+			for i, l := range inv.Lines {
+				if l.VAT != tc.expectedLines[i] {
+					t.Errorf("expected vat %v of line %d, but got %v", tc.expectedLines[i], i, l.VAT)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Package withholding calculates tax withheld from payments to individuals and companies, which is filed with
+// PND 3 and PND 53, at statutory rates of the payment types
+package withholding
+
+import "math"
+
+// types of payments, by the income type of the Revenue Code they're paid as
+const (
+	PaymentService      = "service"
+	PaymentProfessional = "professional"
+	PaymentContract     = "contract"
+	PaymentRent         = "rent"
+	PaymentAdvertising  = "advertising"
+	PaymentTransport    = "transport"
+	PaymentPrize        = "prize"
+)
+
+// types of payees, they decide which form wht is filed with
+const (
+	PayeeIndividual = "individual"
+	PayeeJuristic   = "juristic"
+)
+
+// forms wht is filed with
+const (
+	FormPND3  = "PND3"
+	FormPND53 = "PND53"
+)
+
+// rates are the same for individuals and companies
+var rates = map[string]float64{
+	PaymentService:      0.03,
+	PaymentProfessional: 0.03,
+	PaymentContract:     0.03,
+	PaymentRent:         0.05,
+	PaymentAdvertising:  0.02,
+	PaymentTransport:    0.01,
+	PaymentPrize:        0.05,
+}
+
+// payments of one transaction below this aren't withheld
+const minPayment = 1_000
+
+// Rate returns statutory rate of payment type, ok is false when the type is unknown
+func Rate(paymentType string) (rate float64, ok bool) {
+	rate, ok = rates[paymentType]
+	return rate, ok
+}
+
+// FormOf returns the form wht from payee is filed with
+func FormOf(payeeType string) string {
+	if payeeType == PayeeJuristic {
+		return FormPND53
+	}
+
+	return FormPND3
+}
+
+// Withhold returns wht of amount paid excluding VAT, rounded to satang. It's 0 for unknown payment types and
+// payments below 1,000 baht
+func Withhold(paymentType string, amount float64) float64 {
+	rate, ok := rates[paymentType]
+	if !ok || amount < minPayment {
+		return 0
+	}
+
+	return math.Round(amount*rate*100) / 100
+}
@@ -0,0 +1,39 @@
+package withholding
+
+import "testing"
+
+func TestWithhold(t *testing.T) {
+	type TC struct {
+		name        string
+		paymentType string
+		amount      float64
+		expected    float64
+	}
+
+	tcs := []TC{
+		{name: "service", paymentType: PaymentService, amount: 10_000, expected: 300},
+		{name: "rent", paymentType: PaymentRent, amount: 25_000, expected: 1_250},
+		{name: "advertising", paymentType: PaymentAdvertising, amount: 1_234.56, expected: 24.69},
+		{name: "transport", paymentType: PaymentTransport, amount: 1_000, expected: 10},
+		{name: "below minimum payment", paymentType: PaymentProfessional, amount: 999.99, expected: 0},
+		{name: "unknown payment type", paymentType: "salary", amount: 50_000, expected: 0},
+	}
+
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Withhold(tc.paymentType, tc.amount); got != tc.expected {
+				t.Errorf("expected %v, but got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestFormOf(t *testing.T) {
+	if got := FormOf(PayeeIndividual); got != FormPND3 {
+		t.Errorf("expected %s, but got %s", FormPND3, got)
+	}
+
+	if got := FormOf(PayeeJuristic); got != FormPND53 {
+		t.Errorf("expected %s, but got %s", FormPND53, got)
+	}
+}
@@ -0,0 +1,144 @@
+//go:build js && wasm
+
+// Command wasm runs calculations of package tax in browsers, so previews of the web front-end match the server.
+// Build it with
+//
+//	GOOS=js GOARCH=wasm go build -o ktax.wasm ./wasm
+//
+// and load it by wasm_exec.js of the same Go version. It sets global function
+// ktaxCalculate(request, deductions, brackets) taking JSON of the body of POST /tax/calculations and responses of
+// GET /tax/deductions and GET /tax/brackets, it returns JSON like response of POST /tax/calculations,
+// or {"error": message} for an invalid argument.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"syscall/js"
+
+	"github.com/AnnaCarter465/assessment-tax/tax"
+)
+
+type taxRequest struct {
+	TotalIncome float64 `json:"totalIncome"`
+	Wht         float64 `json:"wht"`
+	Allowances  []struct {
+		AllowanceType string  `json:"allowanceType"`
+		Amount        float64 `json:"amount"`
+	} `json:"allowances"`
+}
+
+type deductions struct {
+	DefaultAllowances []struct {
+		AllowanceType string  `json:"allowanceType"`
+		Amount        float64 `json:"amount"`
+	} `json:"defaultAllowances"`
+	AllowedAllowances []struct {
+		AllowanceType string  `json:"allowanceType"`
+		MaxAmount     float64 `json:"maxAmount"`
+	} `json:"allowedAllowances"`
+}
+
+type brackets struct {
+	Brackets []struct {
+		Level string   `json:"level"`
+		Rate  float64  `json:"rate"`
+		Max   *float64 `json:"max"`
+	} `json:"brackets"`
+}
+
+type taxLevel struct {
+	Level string  `json:"level"`
+	Tax   float64 `json:"tax"`
+}
+
+type taxResponse struct {
+	Tax       float64    `json:"tax"`
+	TaxRefund float64    `json:"taxRefund"`
+	TaxLevel  []taxLevel `json:"taxLevel"`
+}
+
+func main() {
+	js.Global().Set("ktaxCalculate", js.FuncOf(func(_ js.Value, args []js.Value) any {
+		if len(args) != 3 {
+			return respondError(errors.New("ktaxCalculate(request, deductions, brackets) takes 3 arguments"))
+		}
+
+		resp, err := calculate(args[0].String(), args[1].String(), args[2].String())
+		if err != nil {
+			return respondError(err)
+		}
+
+		return resp
+	}))
+
+	// calculations are served until the page is closed
+	select {}
+}
+
+func calculate(requestJSON string, deductionsJSON string, bracketsJSON string) (string, error) {
+	var req taxRequest
+	if err := json.Unmarshal([]byte(requestJSON), &req); err != nil {
+		return "", errors.New("invalid request: " + err.Error())
+	}
+
+	var d deductions
+	if err := json.Unmarshal([]byte(deductionsJSON), &d); err != nil {
+		return "", errors.New("invalid deductions: " + err.Error())
+	}
+
+	var b brackets
+	if err := json.Unmarshal([]byte(bracketsJSON), &b); err != nil {
+		return "", errors.New("invalid brackets: " + err.Error())
+	}
+
+	if req.TotalIncome < req.Wht {
+		return "", errors.New("wht must not exceed total income")
+	}
+
+	conf := tax.TaxConfig{
+		DefaultAllowances: tax.Allowances{},
+		AllowedAllowances: tax.Allowances{},
+	}
+
+	for _, a := range d.DefaultAllowances {
+		conf.DefaultAllowances[a.AllowanceType] = a.Amount
+	}
+
+	for _, a := range d.AllowedAllowances {
+		conf.AllowedAllowances[a.AllowanceType] = a.MaxAmount
+	}
+
+	// levels of brackets are already in language of the front-end
+	for _, r := range b.Brackets {
+		rate := tax.Rate{Percentage: r.Rate, Max: -1, Label: r.Level}
+		if r.Max != nil {
+			rate.Max = *r.Max
+		}
+
+		conf.Rates = append(conf.Rates, rate)
+	}
+
+	tx := tax.NewTax(conf).SetIncome(req.TotalIncome).SetWht(req.Wht)
+
+	for _, a := range req.Allowances {
+		tx.AddAllowance(a.AllowanceType, a.Amount)
+	}
+
+	summary := tx.CalculateTaxSummary()
+
+	resp := taxResponse{Tax: summary.Tax, TaxRefund: summary.Refund, TaxLevel: []taxLevel{}}
+
+	for _, s := range summary.TaxStatements {
+		resp.TaxLevel = append(resp.TaxLevel, taxLevel{Level: s.Rate.Label, Tax: s.Tax})
+	}
+
+	body, err := json.Marshal(resp)
+
+	return string(body), err
+}
+
+func respondError(err error) string {
+	body, _ := json.Marshal(map[string]string{"error": err.Error()})
+	return string(body)
+}
@@ -0,0 +1,162 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+)
+
+const maxAttempts = 3
+
+type IDB interface {
+	FindAllWebhooks(ctx context.Context) ([]database.Webhook, error)
+}
+
+type Event struct {
+	Event     string    `json:"event"`
+	Setting   string    `json:"setting"`
+	Value     float64   `json:"value"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// BracketsEvent is delivered when brackets of a tax year are imported or rolled back
+type BracketsEvent struct {
+	Event     string    `json:"event"`
+	TaxYear   int       `json:"taxYear"`
+	Brackets  []Bracket `json:"brackets"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// Bracket is a bracket of BracketsEvent ordered from the lowest, MaxAmount is nil for the highest one
+type Bracket struct {
+	Percentage float64  `json:"percentage"`
+	MaxAmount  *float64 `json:"maxAmount"`
+	Label      string   `json:"label"`
+}
+
+type Dispatcher struct {
+	db     IDB
+	client *http.Client
+}
+
+func NewDispatcher(db IDB, client *http.Client) *Dispatcher {
+	return &Dispatcher{db: db, client: client}
+}
+
+// Sign returns hex encoded HMAC-SHA256 of "timestamp.body", receivers recompute it with their secret
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SettingsChanged delivers event to every registered webhook in background
+func (d *Dispatcher) SettingsChanged(setting string, value float64) {
+	event := Event{
+		Event:     "settings.changed",
+		Setting:   setting,
+		Value:     value,
+		ChangedAt: time.Now().UTC(),
+	}
+
+	go d.dispatch(event)
+}
+
+// BracketsChanged delivers brackets of tax year to every registered webhook in background
+func (d *Dispatcher) BracketsChanged(taxYear int, brackets []database.TaxBracket) {
+	event := BracketsEvent{
+		Event:     "brackets.changed",
+		TaxYear:   taxYear,
+		Brackets:  make([]Bracket, 0, len(brackets)),
+		ChangedAt: time.Now().UTC(),
+	}
+
+	for _, b := range brackets {
+		event.Brackets = append(event.Brackets, Bracket{Percentage: b.Percentage, MaxAmount: b.MaxAmount, Label: b.Label})
+	}
+
+	go d.dispatch(event)
+}
+
+func (d *Dispatcher) dispatch(event any) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	hooks, err := d.db.FindAllWebhooks(ctx)
+	if err != nil {
+		slog.Error("failed to find webhooks", "error", err)
+		return
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("failed to marshal webhook event", "error", err)
+		return
+	}
+
+	for _, hook := range hooks {
+		go d.deliver(context.WithoutCancel(ctx), hook, body)
+	}
+}
+
+// deliver posts body to hook with retries, ctx must outlive dispatch which returns before deliveries are done
+func (d *Dispatcher) deliver(ctx context.Context, hook database.Webhook, body []byte) {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	for attempt := 1; ; attempt++ {
+		err := d.post(ctx, hook, body)
+		if err == nil {
+			return
+		}
+
+		if attempt == maxAttempts {
+			slog.Error("failed to deliver webhook", "webhook_id", hook.ID, "attempts", attempt, "error", err)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.Error("failed to deliver webhook", "webhook_id", hook.ID, "attempts", attempt, "error", ctx.Err())
+			return
+		case <-time.After(time.Duration(attempt) * time.Second):
+		}
+	}
+}
+
+func (d *Dispatcher) post(ctx context.Context, hook database.Webhook, body []byte) error {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+Sign(hook.Secret, timestamp, body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/AnnaCarter465/assessment-tax/database"
+	"github.com/stretchr/testify/assert"
+)
+
+type webhooksStub []database.Webhook
+
+func (s webhooksStub) FindAllWebhooks(ctx context.Context) ([]database.Webhook, error) {
+	return s, nil
+}
+
+type delivery struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver records deliveries, it responds with statuses in order and 200 once they run out
+func receiver(t *testing.T, statuses ...int) (*httptest.Server, <-chan delivery) {
+	deliveries := make(chan delivery, 10)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		deliveries <- delivery{header: r.Header.Clone(), body: body}
+
+		status := http.StatusOK
+		if len(statuses) > 0 {
+			status, statuses = statuses[0], statuses[1:]
+		}
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, deliveries
+}
+
+func receive(t *testing.T, deliveries <-chan delivery) delivery {
+	t.Helper()
+
+	select {
+	case d := <-deliveries:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook wasn't delivered")
+		return delivery{}
+	}
+}
+
+func TestSettingsChanged(t *testing.T) {
+	srv, deliveries := receiver(t)
+	d := NewDispatcher(webhooksStub{{ID: 1, URL: srv.URL, Secret: "s3cret"}}, srv.Client())
+
+	d.SettingsChanged("personal", 70_000)
+
+	got := receive(t, deliveries)
+
+	assert.Equal(t, "application/json", got.header.Get("Content-Type"))
+	assert.Equal(t, "sha256="+Sign("s3cret", got.header.Get("X-Webhook-Timestamp"), got.body), got.header.Get("X-Webhook-Signature"))
+	assert.NotEqual(t, "sha256="+Sign("other", got.header.Get("X-Webhook-Timestamp"), got.body), got.header.Get("X-Webhook-Signature"))
+
+	var event Event
+
+	assert.NoError(t, json.Unmarshal(got.body, &event))
+	assert.Equal(t, "settings.changed", event.Event)
+	assert.Equal(t, "personal", event.Setting)
+	assert.Equal(t, 70_000.0, event.Value)
+}
+
+func TestBracketsChanged(t *testing.T) {
+	srv, deliveries := receiver(t)
+	d := NewDispatcher(webhooksStub{{ID: 1, URL: srv.URL, Secret: "s3cret"}}, srv.Client())
+
+	top := 150_000.0
+	d.BracketsChanged(2024, []database.TaxBracket{
+		{TaxYear: 2024, Level: 1, Percentage: 0, MaxAmount: &top, Label: "0-150,000"},
+		{TaxYear: 2024, Level: 2, Percentage: 0.1, Label: "150,001 ขึ้นไป"},
+	})
+
+	got := receive(t, deliveries)
+
+	var event BracketsEvent
+
+	assert.NoError(t, json.Unmarshal(got.body, &event))
+	assert.Equal(t, "brackets.changed", event.Event)
+	assert.Equal(t, 2024, event.TaxYear)
+	assert.Equal(t, []Bracket{
+		{Percentage: 0, MaxAmount: &top, Label: "0-150,000"},
+		{Percentage: 0.1, Label: "150,001 ขึ้นไป"},
+	}, event.Brackets)
+}
+
+func TestDeliverRetries(t *testing.T) {
+	srv, deliveries := receiver(t, http.StatusInternalServerError)
+	d := NewDispatcher(webhooksStub{{ID: 1, URL: srv.URL, Secret: "s3cret"}}, srv.Client())
+
+	d.SettingsChanged("k-receipt", 50_000)
+
+	first := receive(t, deliveries)
+	second := receive(t, deliveries)
+
+	assert.Equal(t, first.body, second.body, "the same event is delivered again after a failure")
+}